@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -50,6 +51,14 @@ type ConnectionArgs struct {
 	// TransportWrapper wraps the *http.Transport set by Incus
 	TransportWrapper func(*http.Transport) HTTPTransporter
 
+	// Custom dialer establishing the connections to the server in place of the unix socket or TCP ones
+	// (for HTTPS connections, TLS is still negotiated over the returned connections)
+	DialContext func(ctx context.Context, network string, addr string) (net.Conn, error)
+
+	// Delay before also trying the next address of a server resolving to several of them
+	// while the connection to the previous ones is still pending (defaults to 250ms)
+	DialStagger time.Duration
+
 	// Controls whether a client verifies the server's certificate chain and host name.
 	InsecureSkipVerify bool
 
@@ -65,6 +74,18 @@ type ConnectionArgs struct {
 	// Caching support for image servers
 	CachePath   string
 	CacheExpiry time.Duration
+
+	// Retry policy for requests failing for transient reasons (no retries if unset)
+	RetryPolicy *RetryPolicy
+
+	// Interval between keepalive pings on the events connection (no keepalive if unset)
+	EventsKeepaliveInterval time.Duration
+
+	// How long to wait for a keepalive answer before dropping the events connection (defaults to the interval)
+	EventsKeepaliveTimeout time.Duration
+
+	// Re-establish the events connection when lost rather than disconnecting the event listeners
+	EventsReconnect bool
 }
 
 // ConnectIncus lets you connect to a remote Incus daemon over HTTPs.
@@ -117,14 +138,18 @@ func ConnectIncusHTTPWithContext(ctx context.Context, args *ConnectionArgs, clie
 
 	// Initialize the client struct
 	server := ProtocolIncus{
-		ctx:                ctx,
-		httpBaseURL:        *httpBaseURL,
-		httpProtocol:       "custom",
-		httpUserAgent:      args.UserAgent,
-		ctxConnected:       ctxConnected,
-		ctxConnectedCancel: ctxConnectedCancel,
-		eventConns:         make(map[string]*websocket.Conn),
-		eventListeners:     make(map[string][]*EventListener),
+		ctx:                     ctx,
+		httpBaseURL:             *httpBaseURL,
+		httpProtocol:            "custom",
+		httpUserAgent:           args.UserAgent,
+		ctxConnected:            ctxConnected,
+		ctxConnectedCancel:      ctxConnectedCancel,
+		eventConns:              make(map[string]*websocket.Conn),
+		eventListeners:          make(map[string][]*EventListener),
+		retryPolicy:             args.RetryPolicy,
+		eventsKeepaliveInterval: args.EventsKeepaliveInterval,
+		eventsKeepaliveTimeout:  args.EventsKeepaliveTimeout,
+		eventsReconnect:         args.EventsReconnect,
 	}
 
 	// Setup the HTTP client
@@ -149,6 +174,9 @@ func ConnectIncusHTTPWithContext(ctx context.Context, args *ConnectionArgs, clie
 // If the path argument is empty, then $INCUS_SOCKET will be used, if
 // unset $INCUS_DIR/unix.socket will be used and if that one isn't set
 // either, then the path will default to /var/lib/incus/unix.socket or /run/incus/unix.socket.
+//
+// When a custom dialer is provided (DialContext), it gets called with the path (possibly empty)
+// instead of connecting to the socket, for example to use an in-memory listener.
 func ConnectIncusUnix(path string, args *ConnectionArgs) (InstanceServer, error) {
 	return ConnectIncusUnixWithContext(context.Background(), path, args)
 }
@@ -175,7 +203,7 @@ func ConnectIncusUnixWithContext(ctx context.Context, path string, args *Connect
 
 	// Determine the socket path
 	var projectName string
-	if path == "" {
+	if path == "" && args.DialContext == nil {
 		path = os.Getenv("INCUS_SOCKET")
 		if path == "" {
 			incusDir := os.Getenv("INCUS_DIR")
@@ -203,16 +231,20 @@ func ConnectIncusUnixWithContext(ctx context.Context, path string, args *Connect
 
 	// Initialize the client struct
 	server := ProtocolIncus{
-		ctx:                ctx,
-		httpBaseURL:        *httpBaseURL,
-		httpUnixPath:       path,
-		httpProtocol:       "unix",
-		httpUserAgent:      args.UserAgent,
-		ctxConnected:       ctxConnected,
-		ctxConnectedCancel: ctxConnectedCancel,
-		eventConns:         make(map[string]*websocket.Conn),
-		eventListeners:     make(map[string][]*EventListener),
-		project:            projectName,
+		ctx:                     ctx,
+		httpBaseURL:             *httpBaseURL,
+		httpUnixPath:            path,
+		httpProtocol:            "unix",
+		httpUserAgent:           args.UserAgent,
+		ctxConnected:            ctxConnected,
+		ctxConnectedCancel:      ctxConnectedCancel,
+		eventConns:              make(map[string]*websocket.Conn),
+		eventListeners:          make(map[string][]*EventListener),
+		retryPolicy:             args.RetryPolicy,
+		eventsKeepaliveInterval: args.EventsKeepaliveInterval,
+		eventsKeepaliveTimeout:  args.EventsKeepaliveTimeout,
+		eventsReconnect:         args.EventsReconnect,
+		project:                 projectName,
 	}
 
 	// Setup the HTTP client
@@ -278,7 +310,7 @@ func ConnectSimpleStreams(url string, args *ConnectionArgs) (ImageServer, error)
 	}
 
 	// Setup the HTTP client
-	httpClient, err := tlsHTTPClient(args.HTTPClient, args.TLSClientCert, args.TLSClientKey, args.TLSCA, args.TLSServerCert, args.InsecureSkipVerify, args.Proxy, args.TransportWrapper)
+	httpClient, err := tlsHTTPClient(args.HTTPClient, args.TLSClientCert, args.TLSClientKey, args.TLSCA, args.TLSServerCert, args.InsecureSkipVerify, args.Proxy, args.TransportWrapper, args.DialContext, args.DialStagger)
 	if err != nil {
 		return nil, err
 	}
@@ -332,15 +364,19 @@ func httpsIncus(ctx context.Context, requestURL string, args *ConnectionArgs) (I
 
 	// Initialize the client struct
 	server := ProtocolIncus{
-		ctx:                ctx,
-		httpCertificate:    args.TLSServerCert,
-		httpBaseURL:        *httpBaseURL,
-		httpProtocol:       "https",
-		httpUserAgent:      args.UserAgent,
-		ctxConnected:       ctxConnected,
-		ctxConnectedCancel: ctxConnectedCancel,
-		eventConns:         make(map[string]*websocket.Conn),
-		eventListeners:     make(map[string][]*EventListener),
+		ctx:                     ctx,
+		httpCertificate:         args.TLSServerCert,
+		httpBaseURL:             *httpBaseURL,
+		httpProtocol:            "https",
+		httpUserAgent:           args.UserAgent,
+		ctxConnected:            ctxConnected,
+		ctxConnectedCancel:      ctxConnectedCancel,
+		eventConns:              make(map[string]*websocket.Conn),
+		eventListeners:          make(map[string][]*EventListener),
+		retryPolicy:             args.RetryPolicy,
+		eventsKeepaliveInterval: args.EventsKeepaliveInterval,
+		eventsKeepaliveTimeout:  args.EventsKeepaliveTimeout,
+		eventsReconnect:         args.EventsReconnect,
 	}
 
 	if slices.Contains([]string{api.AuthenticationMethodOIDC}, args.AuthType) {
@@ -348,7 +384,7 @@ func httpsIncus(ctx context.Context, requestURL string, args *ConnectionArgs) (I
 	}
 
 	// Setup the HTTP client
-	httpClient, err := tlsHTTPClient(args.HTTPClient, args.TLSClientCert, args.TLSClientKey, args.TLSCA, args.TLSServerCert, args.InsecureSkipVerify, args.Proxy, args.TransportWrapper)
+	httpClient, err := tlsHTTPClient(args.HTTPClient, args.TLSClientCert, args.TLSClientKey, args.TLSCA, args.TLSServerCert, args.InsecureSkipVerify, args.Proxy, args.TransportWrapper, args.DialContext, args.DialStagger)
 	if err != nil {
 		return nil, err
 	}
@@ -358,6 +394,12 @@ func httpsIncus(ctx context.Context, requestURL string, args *ConnectionArgs) (I
 	}
 
 	server.http = httpClient
+
+	err = server.setupClientCertificate()
+	if err != nil {
+		return nil, err
+	}
+
 	if args.AuthType == api.AuthenticationMethodOIDC {
 		server.setupOIDCClient(args.OIDCTokens)
 	}
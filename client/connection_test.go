@@ -0,0 +1,178 @@
+package incus
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+	localtls "github.com/lxc/incus/v6/shared/tls"
+)
+
+// pipeListener is an in-memory net.Listener fed with net.Pipe connections.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	close(l.done)
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "unix"}
+}
+
+func (l *pipeListener) dial(ctx context.Context, network string, addr string) (net.Conn, error) {
+	client, server := net.Pipe()
+
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// countingTransport counts the requests going through the wrapped transport.
+type countingTransport struct {
+	transport *http.Transport
+	requests  atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return t.transport.RoundTrip(req)
+}
+
+func (t *countingTransport) Transport() *http.Transport {
+	return t.transport
+}
+
+func TestConnectIncusUnixDialContext(t *testing.T) {
+	listener := &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+
+	var paths []string
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		server := api.Server{Environment: api.ServerEnvironment{Server: "incus"}}
+		_ = json.NewEncoder(w).Encode(api.ResponseRaw{Type: api.SyncResponse, Status: "Success", StatusCode: http.StatusOK, Metadata: server})
+	})}
+
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	var dialed atomic.Value
+	counter := &countingTransport{}
+	args := &ConnectionArgs{
+		DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			dialed.Store(network + ":" + addr)
+			return listener.dial(ctx, network, addr)
+		},
+		TransportWrapper: func(transport *http.Transport) HTTPTransporter {
+			counter.transport = transport
+			return counter
+		},
+	}
+
+	c, err := ConnectIncusUnix("", args)
+	require.NoError(t, err)
+
+	info, err := c.GetConnectionInfo()
+	require.NoError(t, err)
+	assert.Empty(t, info.SocketPath)
+
+	serverStatus, _, err := c.GetServer()
+	require.NoError(t, err)
+	assert.Equal(t, "incus", serverStatus.Environment.Server)
+
+	// The requests went through the custom dialer and the instrumentation transport.
+	assert.Equal(t, "unix:", dialed.Load())
+	assert.Equal(t, []string{"/1.0", "/1.0"}, paths)
+	assert.Equal(t, int32(2), counter.requests.Load())
+
+	httpClient, err := c.GetHTTPClient()
+	require.NoError(t, err)
+	assert.Equal(t, counter, httpClient.Transport)
+}
+
+func TestUpdateClientCertificate(t *testing.T) {
+	var lock sync.Mutex
+	var presented []string
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		if len(r.TLS.PeerCertificates) > 0 {
+			presented = append(presented, localtls.CertFingerprint(r.TLS.PeerCertificates[0]))
+		}
+
+		lock.Unlock()
+
+		server := api.Server{Environment: api.ServerEnvironment{Server: "incus"}}
+		_ = json.NewEncoder(w).Encode(api.ResponseRaw{Type: api.SyncResponse, Status: "Success", StatusCode: http.StatusOK, Metadata: server})
+	}))
+
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	newCert := func() (string, string, string) {
+		certPEM, keyPEM, err := localtls.GenerateMemCert(true, false)
+		require.NoError(t, err)
+
+		fingerprint, err := localtls.CertFingerprintStr(string(certPEM))
+		require.NoError(t, err)
+
+		return string(certPEM), string(keyPEM), fingerprint
+	}
+
+	cert1, key1, fingerprint1 := newCert()
+	cert2, key2, fingerprint2 := newCert()
+
+	c, err := ConnectIncus(server.URL, &ConnectionArgs{TLSClientCert: cert1, TLSClientKey: key1, InsecureSkipVerify: true})
+	require.NoError(t, err)
+
+	err = c.UpdateClientCertificate(cert2, key2)
+	require.NoError(t, err)
+
+	_, _, err = c.GetServer()
+	require.NoError(t, err)
+
+	// Clients derived from the connection share the certificate.
+	_, _, err = c.UseProject("foo").GetServer()
+	require.NoError(t, err)
+
+	lock.Lock()
+	assert.Equal(t, []string{fingerprint1, fingerprint2, fingerprint2}, presented)
+	lock.Unlock()
+
+	// Invalid certificates are rejected, keeping the current one.
+	err = c.UpdateClientCertificate(cert1, key2)
+	assert.Error(t, err)
+
+	_, _, err = c.GetServer()
+	require.NoError(t, err)
+	lock.Lock()
+	assert.Equal(t, fingerprint2, presented[len(presented)-1])
+	lock.Unlock()
+}
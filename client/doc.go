@@ -6,6 +6,19 @@
 // servers over a Unix socket or HTTPs. You can then interact with those
 // remote servers, creating instances, images, moving them around, ...
 //
+// # Errors
+//
+// Errors returned by the server, including those of the requests returning raw
+// content like files or logs, wrap an api.StatusError holding the HTTP status code.
+// They can be matched using api.StatusErrorCheck rather than their message:
+//
+//	_, _, err := c.GetInstance("c1")
+//	if api.StatusErrorCheck(err, http.StatusNotFound) {
+//	  // The instance doesn't exist
+//	}
+//
+// Failures of background operations are reported by Operation.Wait without a status code.
+//
 // # Example - instance creation
 //
 // This creates a container on a local Incus daemon and then starts it.
@@ -143,4 +156,78 @@
 //	if err != nil {
 //	  return err
 //	}
+//
+// # Example - instance device update
+//
+// This changes a single device of an instance, leaving the rest of the instance alone
+//
+//	// Connect to Incus over the Unix socket
+//	c, err := incus.ConnectIncusUnix("", nil)
+//	if err != nil {
+//	  return err
+//	}
+//
+//	// Add a new disk device (background operation)
+//	op, err := c.CreateInstanceDevice("c1", "data", map[string]string{
+//	  "type": "disk",
+//	  "source": "/srv/data",
+//	  "path": "/data",
+//	})
+//	if err != nil {
+//	  return err
+//	}
+//
+//	err = op.Wait()
+//	if err != nil {
+//	  return err
+//	}
+//
+//	// Make it read-only, only sending the modified key (background operation)
+//	op, err = c.UpdateInstanceDevice("c1", "data", map[string]string{"readonly": "true"}, false)
+//	if err != nil {
+//	  return err
+//	}
+//
+//	err = op.Wait()
+//	if err != nil {
+//	  return err
+//	}
+//
+//	// Retrieve its configuration
+//	device, _, err := c.GetInstanceDevice("c1", "data")
+//	if err != nil {
+//	  return err
+//	}
+//
+//	// And remove it again (background operation)
+//	op, err = c.DeleteInstanceDevice("c1", "data", false)
+//	if err != nil {
+//	  return err
+//	}
+//
+//	err = op.Wait()
+//	if err != nil {
+//	  return err
+//	}
+//
+// Profile devices are handled the same way through CreateProfileDevice, UpdateProfileDevice,
+// GetProfileDevice and DeleteProfileDevice, which don't return an operation.
+//
+// # Example - operation progress
+//
+// This waits for up to ten minutes on an instance memory dump, printing its progress and
+// canceling it if it takes any longer
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+//	defer cancel()
+//
+//	err = op.WaitProgress(ctx, func(op api.Operation) {
+//	  progress, _ := op.ToOperationProgress()
+//	  if progress != nil {
+//	    fmt.Printf("%d/%d bytes\n", progress.Processed, progress.Total)
+//	  }
+//	})
+//	if err != nil {
+//	  return err
+//	}
 package incus
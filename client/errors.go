@@ -0,0 +1,26 @@
+package incus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotSupported is matched by the errors returned when the server lacks an API extension
+// required for the request.
+var ErrNotSupported = errors.New("Not supported by the server")
+
+// MissingExtensionError is returned when the server is missing a required API extension.
+// It matches ErrNotSupported.
+type MissingExtensionError struct {
+	Extension string
+}
+
+// Error returns the error message.
+func (e *MissingExtensionError) Error() string {
+	return fmt.Sprintf("The server is missing the required %q API extension", e.Extension)
+}
+
+// Is returns whether target is ErrNotSupported.
+func (e *MissingExtensionError) Is(target error) bool {
+	return target == ErrNotSupported
+}
@@ -19,6 +19,9 @@ type EventListener struct {
 	projectName string
 	targets     []*EventTarget
 	targetsLock sync.Mutex
+
+	// reconnectHandler is called after the events connection got re-established.
+	reconnectHandler func()
 }
 
 // The EventTarget struct is returned to the caller of AddHandler and used in RemoveHandler.
@@ -72,6 +75,16 @@ func (e *EventListener) RemoveHandler(target *EventTarget) error {
 	return fmt.Errorf("Couldn't find this function and event types combination")
 }
 
+// SetReconnectHandler sets a function to be called whenever the events connection got re-established
+// after being lost, events having possibly been missed in between.
+// This requires EventsReconnect to be set in the ConnectionArgs.
+func (e *EventListener) SetReconnectHandler(function func()) {
+	e.targetsLock.Lock()
+	defer e.targetsLock.Unlock()
+
+	e.reconnectHandler = function
+}
+
 // Disconnect must be used once done listening for events.
 func (e *EventListener) Disconnect() {
 	// Handle locking
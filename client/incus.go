@@ -3,6 +3,7 @@ package incus
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -24,6 +25,8 @@ import (
 type ProtocolIncus struct {
 	ctx                context.Context
 	server             *api.Server
+	serverLock         sync.RWMutex
+	serverRefresh      sync.Once
 	ctxConnected       context.Context
 	ctxConnectedCancel context.CancelFunc
 
@@ -50,6 +53,15 @@ type ProtocolIncus struct {
 	project       string
 
 	oidcClient *oidcClient
+
+	// clientCert holds the TLS client certificate, nil when not connected over HTTPS.
+	clientCert *clientCertificate
+
+	retryPolicy *RetryPolicy
+
+	eventsKeepaliveInterval time.Duration
+	eventsKeepaliveTimeout  time.Duration
+	eventsReconnect         bool
 }
 
 // Disconnect gets rid of any background goroutines.
@@ -72,9 +84,11 @@ func (r *ProtocolIncus) GetConnectionInfo() (*ConnectionInfo, error) {
 		info.Project = api.ProjectDefaultName
 	}
 
+	server := r.cachedServer()
+
 	info.Target = r.clusterTarget
-	if info.Target == "" && r.server != nil {
-		info.Target = r.server.Environment.ServerName
+	if info.Target == "" && server != nil {
+		info.Target = server.Environment.ServerName
 	}
 
 	urls := []string{}
@@ -82,8 +96,8 @@ func (r *ProtocolIncus) GetConnectionInfo() (*ConnectionInfo, error) {
 		urls = append(urls, r.httpBaseURL.String())
 	}
 
-	if r.server != nil && len(r.server.Environment.Addresses) > 0 {
-		for _, addr := range r.server.Environment.Addresses {
+	if server != nil && len(server.Environment.Addresses) > 0 {
+		for _, addr := range server.Environment.Addresses {
 			if strings.HasPrefix(addr, ":") {
 				continue
 			}
@@ -114,7 +128,7 @@ func (r *ProtocolIncus) isSameServer(server Server) bool {
 	}
 
 	// When dealing with uninitialized servers, we can't safely compare.
-	if r.server == nil {
+	if r.cachedServer() == nil {
 		return false
 	}
 
@@ -135,6 +149,9 @@ func (r *ProtocolIncus) isSameServer(server Server) bool {
 }
 
 // GetHTTPClient returns the http client used for the connection. This can be used to set custom http options.
+//
+// The client is shared with the connection. Replacing or mutating its transport once connected is unsupported,
+// instrumentation transports should be set up through ConnectionArgs.TransportWrapper instead.
 func (r *ProtocolIncus) GetHTTPClient() (*http.Client, error) {
 	if r.http == nil {
 		return nil, fmt.Errorf("HTTP client isn't set, bad connection")
@@ -144,9 +161,19 @@ func (r *ProtocolIncus) GetHTTPClient() (*http.Client, error) {
 }
 
 // DoHTTP performs a Request, using OIDC authentication if set.
+// Transient failures are retried according to the retry policy, if any.
 func (r *ProtocolIncus) DoHTTP(req *http.Request) (*http.Response, error) {
 	r.addClientHeaders(req)
 
+	if r.retryPolicy != nil {
+		return r.retryPolicy.do(req, r.doHTTP)
+	}
+
+	return r.doHTTP(req)
+}
+
+// doHTTP sends a single Request, using OIDC authentication if set.
+func (r *ProtocolIncus) doHTTP(req *http.Request) (*http.Response, error) {
 	if r.oidcClient != nil {
 		return r.oidcClient.do(req)
 	}
@@ -184,6 +211,7 @@ func (r *ProtocolIncus) addClientHeaders(req *http.Request) {
 }
 
 // RequireAuthenticated sets whether we expect to be authenticated with the server.
+// It's meant to be called while setting up the client, before it's used.
 func (r *ProtocolIncus) RequireAuthenticated(authenticated bool) {
 	r.requireAuthenticated = authenticated
 }
@@ -212,6 +240,9 @@ func (r *ProtocolIncus) RawOperation(method string, path string, data any, ETag
 }
 
 // Internal functions.
+
+// incusParseResponse decodes the API response, returning an api.StatusError
+// holding the HTTP status code for unsuccessful requests.
 func incusParseResponse(resp *http.Response) (*api.Response, string, error) {
 	// Get the ETag
 	etag := resp.Header.Get("ETag")
@@ -224,23 +255,38 @@ func incusParseResponse(resp *http.Response) (*api.Response, string, error) {
 	if err != nil {
 		// Check the return value for a cleaner error
 		if resp.StatusCode != http.StatusOK {
-			return nil, "", fmt.Errorf("Failed to fetch %s: %s", resp.Request.URL.String(), resp.Status)
+			return nil, "", api.StatusErrorf(resp.StatusCode, "Failed to fetch %s: %s", resp.Request.URL.String(), resp.Status)
 		}
 
 		return nil, "", err
 	}
 
 	// Handle errors
-	if response.Type == api.ErrorResponse {
+	if response.Type == api.ErrorResponse || resp.StatusCode >= http.StatusBadRequest {
 		return &response, "", api.StatusErrorf(resp.StatusCode, response.Error)
 	}
 
 	return &response, etag, nil
 }
 
+// incusParseError returns the error of an unsuccessful raw HTTP response as an api.StatusError.
+func incusParseError(resp *http.Response) error {
+	_, _, err := incusParseResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	return api.StatusErrorf(resp.StatusCode, "Unexpected response from %s: %s", resp.Request.URL.String(), resp.Status)
+}
+
 // rawQuery is a method that sends an HTTP request to the Incus server with the provided method, URL, data, and ETag.
 // It processes the request based on the data's type and handles the HTTP response, returning parsed results or an error if it occurs.
 func (r *ProtocolIncus) rawQuery(method string, url string, data any, ETag string) (*api.Response, string, error) {
+	return r.rawQueryContext(r.ctx, method, url, data, ETag)
+}
+
+// rawQueryContext is rawQuery with the HTTP request bound to ctx rather than the client context.
+func (r *ProtocolIncus) rawQueryContext(ctx context.Context, method string, url string, data any, ETag string) (*api.Response, string, error) {
 	var req *http.Request
 	var err error
 
@@ -256,12 +302,28 @@ func (r *ProtocolIncus) rawQuery(method string, url string, data any, ETag strin
 		switch data := data.(type) {
 		case io.Reader:
 			// Some data to be sent along with the request
-			req, err = http.NewRequestWithContext(r.ctx, method, url, io.NopCloser(data))
+			req, err = http.NewRequestWithContext(ctx, method, url, io.NopCloser(data))
 			if err != nil {
 				return nil, "", err
 			}
 
-			req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(data), nil }
+			// Only seekable data can be sent again.
+			seeker, ok := data.(io.Seeker)
+			if ok {
+				offset, err := seeker.Seek(0, io.SeekCurrent)
+				if err != nil {
+					return nil, "", err
+				}
+
+				req.GetBody = func() (io.ReadCloser, error) {
+					_, err := seeker.Seek(offset, io.SeekStart)
+					if err != nil {
+						return nil, err
+					}
+
+					return io.NopCloser(data), nil
+				}
+			}
 
 			// Set the encoding accordingly
 			req.Header.Set("Content-Type", "application/octet-stream")
@@ -275,7 +337,7 @@ func (r *ProtocolIncus) rawQuery(method string, url string, data any, ETag strin
 
 			// Some data to be sent along with the request
 			// Use a reader since the request body needs to be seekable
-			req, err = http.NewRequestWithContext(r.ctx, method, url, bytes.NewReader(buf.Bytes()))
+			req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewReader(buf.Bytes()))
 			if err != nil {
 				return nil, "", err
 			}
@@ -290,7 +352,7 @@ func (r *ProtocolIncus) rawQuery(method string, url string, data any, ETag strin
 		}
 	} else {
 		// No data to be sent along with the request
-		req, err = http.NewRequestWithContext(r.ctx, method, url, nil)
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
 		if err != nil {
 			return nil, "", err
 		}
@@ -344,6 +406,11 @@ func (r *ProtocolIncus) setQueryAttributes(uri string) (string, error) {
 }
 
 func (r *ProtocolIncus) query(method string, path string, data any, ETag string) (*api.Response, string, error) {
+	return r.queryContext(r.ctx, method, path, data, ETag)
+}
+
+// queryContext is query with the HTTP request bound to ctx rather than the client context.
+func (r *ProtocolIncus) queryContext(ctx context.Context, method string, path string, data any, ETag string) (*api.Response, string, error) {
 	// Generate the URL
 	url := fmt.Sprintf("%s/1.0%s", r.httpBaseURL.String(), path)
 
@@ -354,7 +421,7 @@ func (r *ProtocolIncus) query(method string, path string, data any, ETag string)
 	}
 
 	// Run the actual query
-	return r.rawQuery(method, url, data, ETag)
+	return r.rawQueryContext(ctx, method, url, data, ETag)
 }
 
 // queryStruct sends a query to the Incus server, then converts the response metadata into the specified target struct.
@@ -380,6 +447,11 @@ func (r *ProtocolIncus) queryStruct(method string, path string, data any, ETag s
 // queryOperation sends a query to the Incus server and then converts the response metadata into an Operation object.
 // It sets up an early event listener, performs the query, processes the response, and manages the lifecycle of the event listener.
 func (r *ProtocolIncus) queryOperation(method string, path string, data any, ETag string) (Operation, string, error) {
+	return r.queryOperationContext(r.ctx, method, path, data, ETag)
+}
+
+// queryOperationContext is queryOperation with the HTTP request bound to ctx rather than the client context.
+func (r *ProtocolIncus) queryOperationContext(ctx context.Context, method string, path string, data any, ETag string) (Operation, string, error) {
 	// Attempt to setup an early event listener
 	skipListener := false
 	listener, err := r.GetEvents()
@@ -392,7 +464,7 @@ func (r *ProtocolIncus) queryOperation(method string, path string, data any, ETa
 	}
 
 	// Send the query
-	resp, etag, err := r.query(method, path, data, ETag)
+	resp, etag, err := r.queryContext(ctx, method, path, data, ETag)
 	if err != nil {
 		if listener != nil {
 			listener.Disconnect()
@@ -448,11 +520,26 @@ func (r *ProtocolIncus) rawWebsocket(url string) (*websocket.Conn, error) {
 	// for the websocket request.
 	req := &http.Request{URL: &r.httpBaseURL, Header: http.Header{}}
 
+	// Connect the same way as the HTTP requests (racing the server addresses), unless going through a proxy.
+	if httpTransport.DialTLSContext != nil {
+		var proxyURL *neturl.URL
+		if httpTransport.Proxy != nil {
+			proxyURL, err = httpTransport.Proxy(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if proxyURL == nil {
+			dialer.NetDialTLSContext = httpTransport.DialTLSContext
+		}
+	}
+
 	// Establish the connection
 	conn, resp, err := r.DoWebsocket(dialer, url, req)
 	if err != nil {
 		if resp != nil {
-			_, _, err = incusParseResponse(resp)
+			err = incusParseError(resp)
 		}
 
 		return nil, err
@@ -489,11 +576,101 @@ func (r *ProtocolIncus) websocket(path string) (*websocket.Conn, error) {
 
 // WithContext returns a client that will add context.Context.
 func (r *ProtocolIncus) WithContext(ctx context.Context) InstanceServer {
-	rr := r
+	rr := r.clone()
 	rr.ctx = ctx
 	return rr
 }
 
+// clone returns a shallow copy of the client for UseProject, UseTarget and WithContext to adjust.
+// The copy shares the HTTP client and connection state but gets its own event listeners and
+// server information cache, leaving the original client untouched.
+func (r *ProtocolIncus) clone() *ProtocolIncus {
+	return &ProtocolIncus{
+		ctx:                     r.ctx,
+		ctxConnected:            r.ctxConnected,
+		ctxConnectedCancel:      r.ctxConnectedCancel,
+		server:                  r.cachedServer(),
+		http:                    r.http,
+		httpCertificate:         r.httpCertificate,
+		httpBaseURL:             r.httpBaseURL,
+		httpProtocol:            r.httpProtocol,
+		httpUserAgent:           r.httpUserAgent,
+		httpUnixPath:            r.httpUnixPath,
+		requireAuthenticated:    r.requireAuthenticated,
+		clusterTarget:           r.clusterTarget,
+		project:                 r.project,
+		eventConns:              make(map[string]*websocket.Conn),
+		eventListeners:          make(map[string][]*EventListener),
+		oidcClient:              r.oidcClient,
+		clientCert:              r.clientCert,
+		retryPolicy:             r.retryPolicy,
+		eventsKeepaliveInterval: r.eventsKeepaliveInterval,
+		eventsKeepaliveTimeout:  r.eventsKeepaliveTimeout,
+		eventsReconnect:         r.eventsReconnect,
+	}
+}
+
+// cachedServer returns the cached server information, nil if not retrieved yet.
+// The returned struct must not be modified.
+func (r *ProtocolIncus) cachedServer() *api.Server {
+	r.serverLock.RLock()
+	defer r.serverLock.RUnlock()
+
+	return r.server
+}
+
+// UpdateClientCertificate replaces the TLS client certificate and key used to authenticate with the server.
+// The client keeps its state, including the cached server information and the event listeners.
+//
+// New connections, such as those of reconnecting event listeners, present the new certificate and idle
+// keepalive connections are closed so that they don't get reused. Established connections, like those of
+// active event listeners or operation websockets, keep going with the certificate they were set up with.
+func (r *ProtocolIncus) UpdateClientCertificate(certPEM string, keyPEM string) error {
+	if r.clientCert == nil {
+		return fmt.Errorf("The connection isn't using TLS client certificates")
+	}
+
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return fmt.Errorf("Failed to parse the client certificate: %w", err)
+	}
+
+	r.clientCert.cert.Store(&cert)
+
+	httpTransport, err := r.getUnderlyingHTTPTransport()
+	if err != nil {
+		return err
+	}
+
+	httpTransport.CloseIdleConnections()
+
+	return nil
+}
+
+// setupClientCertificate serves the TLS client certificate of the underlying transport from
+// r.clientCert so that it can be replaced later on.
+func (r *ProtocolIncus) setupClientCertificate() error {
+	httpTransport, err := r.getUnderlyingHTTPTransport()
+	if err != nil {
+		return err
+	}
+
+	tlsConfig := httpTransport.TLSClientConfig
+	if tlsConfig == nil || tlsConfig.GetClientCertificate != nil {
+		return nil
+	}
+
+	r.clientCert = &clientCertificate{}
+	if len(tlsConfig.Certificates) > 0 {
+		r.clientCert.cert.Store(&tlsConfig.Certificates[0])
+		tlsConfig.Certificates = nil
+	}
+
+	tlsConfig.GetClientCertificate = r.clientCert.get
+
+	return nil
+}
+
 // getUnderlyingHTTPTransport returns the *http.Transport used by the http client. If the http
 // client was initialized with a HTTPTransporter, it returns the wrapped *http.Transport.
 func (r *ProtocolIncus) getUnderlyingHTTPTransport() (*http.Transport, error) {
@@ -0,0 +1,137 @@
+package incus
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/lxc/incus/v6/shared/ws"
+)
+
+// GetDebugProfile returns a goroutine or heap profile of the server, in the pprof format.
+//
+// This is only available over the local unix socket.
+func (r *ProtocolIncus) GetDebugProfile(kind string) (io.ReadCloser, error) {
+	err := r.CheckExtension("debug_pprof")
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare the HTTP request
+	url := fmt.Sprintf("%s/1.0/debug/pprof?kind=%s", r.httpBaseURL.String(), url.QueryEscape(kind))
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	resp, err := r.DoHTTP(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		return nil, incusParseError(resp)
+	}
+
+	return resp.Body, nil
+}
+
+// GetDebugAppArmorProfile returns the AppArmor profile the server would generate for the given kind of profile.
+func (r *ProtocolIncus) GetDebugAppArmorProfile(kind string, args *DebugAppArmorProfileArgs) (string, error) {
+	err := r.CheckExtension("debug_apparmor_profile")
+	if err != nil {
+		return "", err
+	}
+
+	values := url.Values{}
+	values.Set("kind", kind)
+
+	if args != nil {
+		if args.Instance != "" {
+			values.Set("instance", args.Instance)
+		}
+
+		for _, source := range args.Sources {
+			values.Add("source", source)
+		}
+
+		for _, destination := range args.Destinations {
+			values.Add("destination", destination)
+		}
+
+		if args.Access != "" {
+			values.Set("access", args.Access)
+		}
+
+		if args.Cluster != "" {
+			values.Set("cluster", args.Cluster)
+		}
+
+		if args.Path != "" {
+			values.Set("path", args.Path)
+		}
+	}
+
+	// Fetch the raw value
+	var profile string
+	_, err = r.queryStruct("GET", "/debug/apparmor?"+values.Encode(), nil, "", &profile)
+	if err != nil {
+		return "", err
+	}
+
+	return profile, nil
+}
+
+// GetDebugCPUProfile starts sampling the server CPU usage and streams the resulting profile into args.Writer.
+//
+// This is only available over the local unix socket.
+func (r *ProtocolIncus) GetDebugCPUProfile(args *DebugCPUProfileArgs) (Operation, error) {
+	err := r.CheckExtension("debug_pprof")
+	if err != nil {
+		return nil, err
+	}
+
+	if args == nil || args.Writer == nil {
+		return nil, fmt.Errorf("A writer is required")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("GET", "/debug/pprof?kind=cpu", nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the websocket secret
+	opAPI := op.Get()
+	fds, ok := opAPI.Metadata["fds"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("The server didn't provide a websocket for the profile")
+	}
+
+	secret, ok := fds["0"].(string)
+	if !ok {
+		return nil, fmt.Errorf("The server didn't provide a websocket for the profile")
+	}
+
+	// Connect to the websocket
+	conn, err := r.GetOperationWebsocket(opAPI.ID, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Receive the profile
+	go func() {
+		<-ws.MirrorWrite(conn, args.Writer)
+		_ = conn.Close()
+
+		if args.DataDone != nil {
+			close(args.DataDone)
+		}
+	}()
+
+	return op, nil
+}
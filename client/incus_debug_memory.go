@@ -0,0 +1,167 @@
+package incus
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/ioprogress"
+	"github.com/lxc/incus/v6/shared/units"
+	"github.com/lxc/incus/v6/shared/ws"
+)
+
+// GetInstanceDebugMemory starts a background dump of the memory of a running instance.
+//
+// The dump is written into args.Path on the server, into the args.Volume custom volume or,
+// when neither is set, streamed into args.Writer.
+func (r *ProtocolIncus) GetInstanceDebugMemory(name string, args *InstanceDebugMemoryArgs) (Operation, error) {
+	return r.GetInstanceDebugMemoryWithContext(r.ctx, name, args)
+}
+
+// GetInstanceDebugMemoryWithContext is GetInstanceDebugMemory with the request bound to ctx.
+//
+// Cancelling ctx once the dump has started cancels the background operation and stops streaming
+// into args.Writer.
+func (r *ProtocolIncus) GetInstanceDebugMemoryWithContext(ctx context.Context, name string, args *InstanceDebugMemoryArgs) (Operation, error) {
+	err := r.CheckExtension("instance_debug_memory")
+	if err != nil {
+		return nil, err
+	}
+
+	if args == nil || (args.Path == "" && args.Volume == "" && args.Writer == nil) {
+		return nil, fmt.Errorf("Either a dump path, a storage volume or a writer is required")
+	}
+
+	// Containers can be dumped too, so don't restrict the instance type.
+	path, v, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Path != "" {
+		v.Set("path", args.Path)
+	}
+
+	if args.Overwrite {
+		v.Set("overwrite", "true")
+	}
+
+	if args.Volume != "" {
+		v.Set("pool", args.Pool)
+		v.Set("volume", args.Volume)
+	}
+
+	if args.Format != "" {
+		v.Set("format", args.Format)
+	}
+
+	if args.PID > 0 {
+		v.Set("pid", strconv.Itoa(args.PID))
+	}
+
+	if args.Compress != "" {
+		v.Set("compress", args.Compress)
+	}
+
+	if args.Force {
+		v.Set("force", "true")
+	}
+
+	if args.MaxSize > 0 {
+		v.Set("max-size", strconv.FormatInt(args.MaxSize, 10))
+	}
+
+	// Send the request, the project and target getting added to the query like for any other call.
+	op, _, err := r.queryOperationContext(ctx, "GET", fmt.Sprintf("%s/%s/debug/memory?%s", path, url.PathEscape(name), v.Encode()), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Path != "" || args.Volume != "" {
+		if args.DataDone != nil {
+			close(args.DataDone)
+		}
+
+		go r.cancelDebugMemoryOnDone(ctx, op, nil)
+
+		return op, nil
+	}
+
+	// Retrieve the websocket secret
+	opAPI := op.Get()
+	fds, ok := opAPI.Metadata["fds"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("The server didn't provide a websocket for the dump")
+	}
+
+	secret, ok := fds["0"].(string)
+	if !ok {
+		return nil, fmt.Errorf("The server didn't provide a websocket for the dump")
+	}
+
+	// Connect to the websocket
+	conn, err := r.GetOperationWebsocket(opAPI.ID, secret)
+	if err != nil {
+		_ = op.Cancel()
+		return nil, err
+	}
+
+	go r.cancelDebugMemoryOnDone(ctx, op, conn)
+
+	var target io.Writer = args.Writer
+	if args.ProgressHandler != nil {
+		target = &ioprogress.ProgressWriter{
+			WriteCloser: nopWriteCloser{args.Writer},
+			Tracker: &ioprogress.ProgressTracker{
+				Handler: func(received int64, speed int64) {
+					args.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(received, 2), units.GetByteSizeString(speed, 2))})
+				},
+			},
+		}
+	}
+
+	// Receive the dump
+	go func() {
+		<-ws.MirrorWrite(conn, target)
+		_ = conn.Close()
+
+		if args.DataDone != nil {
+			close(args.DataDone)
+		}
+	}()
+
+	return op, nil
+}
+
+// cancelDebugMemoryOnDone cancels the dump operation and closes its websocket, if any,
+// when ctx is done before the operation completes.
+func (r *ProtocolIncus) cancelDebugMemoryOnDone(ctx context.Context, op Operation, conn *websocket.Conn) {
+	if ctx == nil || ctx.Done() == nil {
+		return
+	}
+
+	err := op.WaitContext(ctx)
+	if err == nil || ctx.Err() == nil {
+		return
+	}
+
+	_ = op.Cancel()
+
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// nopWriteCloser adds a no-op Close method to an io.Writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package incus
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInstanceDebugMemoryQuery(t *testing.T) {
+	var query url.Values
+	var userAgent string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1.0/instances/c1/debug/memory" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type": "error", "error": "Not found", "error_code": 404}`)
+			return
+		}
+
+		query = r.URL.Query()
+		userAgent = r.Header.Get("User-Agent")
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, `{"type": "async", "status": "Operation created", "status_code": 100, "operation": "/1.0/operations/1234", "metadata": {"id": "1234", "class": "task", "status": "Running", "status_code": 103}}`)
+	}))
+
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	r := &ProtocolIncus{
+		ctx:            context.Background(),
+		http:           server.Client(),
+		httpBaseURL:    *baseURL,
+		httpUserAgent:  "test-agent",
+		eventConns:     map[string]*websocket.Conn{},
+		eventListeners: map[string][]*EventListener{},
+	}
+
+	client, ok := r.UseProject("foo").UseTarget("member1").(*ProtocolIncus)
+	require.True(t, ok)
+
+	op, err := client.GetInstanceDebugMemory("c1", &InstanceDebugMemoryArgs{Path: "/srv/c1.elf", Format: "elf"})
+	require.NoError(t, err)
+	assert.Equal(t, "1234", op.Get().ID)
+
+	assert.Equal(t, "foo", query.Get("project"))
+	assert.Equal(t, "member1", query.Get("target"))
+	assert.Equal(t, "/srv/c1.elf", query.Get("path"))
+	assert.Equal(t, "elf", query.Get("format"))
+	assert.Empty(t, query.Get("instance-type"))
+	assert.Equal(t, "test-agent", userAgent)
+}
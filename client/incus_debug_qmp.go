@@ -0,0 +1,33 @@
+package incus
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// GetInstanceDebugQMP runs a read-only QMP command against a running virtual machine and returns its raw result.
+func (r *ProtocolIncus) GetInstanceDebugQMP(name string, command string) (json.RawMessage, error) {
+	err := r.CheckExtension("instance_debug_qmp")
+	if err != nil {
+		return nil, err
+	}
+
+	path, v, err := r.instanceTypeToPath(api.InstanceTypeVM)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Set("command", command)
+
+	// Fetch the raw value
+	var result json.RawMessage
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s/%s/debug/qmp?%s", path, url.PathEscape(name), v.Encode()), nil, "", &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
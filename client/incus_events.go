@@ -3,7 +3,9 @@ package incus
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"slices"
 	"time"
 
@@ -58,7 +60,7 @@ func (r *ProtocolIncus) getEvents(allProjects bool) (*EventListener, error) {
 	}
 
 	// Connect websocket and save.
-	wsConn, err := r.websocket(url)
+	wsConn, err := r.eventsWebsocket(url)
 	if err != nil {
 		return nil, err
 	}
@@ -103,61 +105,170 @@ func (r *ProtocolIncus) getEvents(allProjects bool) (*EventListener, error) {
 	}()
 
 	// Spawn the listener
-	go func() {
-		for {
-			_, data, err := wsConn.ReadMessage()
-			if err != nil {
-				// Prevent anything else from interacting with the listeners
-				r.eventListenersLock.Lock()
-				defer r.eventListenersLock.Unlock()
-
-				// Tell all the current listeners about the failure
-				for _, listener := range r.eventListeners[listener.projectName] {
-					listener.err = err
-					listener.ctxCancel()
-				}
+	go r.handleEvents(listener.projectName, url, wsConn, stopCh)
 
-				// And remove them all from the list so that when watcher routine runs it will
-				// close the websocket connection.
-				r.eventListeners[listener.projectName] = nil
+	return &listener, nil
+}
 
-				close(stopCh) // Instruct watcher go routine to cleanup.
+// handleEvents dispatches the events received on wsConn to the listeners of the project.
+// When the connection is lost, it gets re-established if enabled or the listeners are disconnected.
+func (r *ProtocolIncus) handleEvents(projectName string, url string, wsConn *websocket.Conn, stopCh chan struct{}) {
+	for {
+		_, data, err := wsConn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				err = fmt.Errorf("No answer to keepalive on the events connection: %w", err)
+			}
 
-				return
+			// Try to get a new connection before giving up.
+			newConn := r.reconnectEvents(projectName, url)
+			if newConn != nil {
+				wsConn = newConn
+				continue
+			}
+
+			// Prevent anything else from interacting with the listeners
+			r.eventListenersLock.Lock()
+			defer r.eventListenersLock.Unlock()
+
+			// Tell all the current listeners about the failure
+			for _, listener := range r.eventListeners[projectName] {
+				listener.err = err
+				listener.ctxCancel()
 			}
 
-			// Attempt to unpack the message
-			event := api.Event{}
-			err = json.Unmarshal(data, &event)
+			// And remove them all from the list so that when watcher routine runs it will
+			// close the websocket connection.
+			r.eventListeners[projectName] = nil
+
+			close(stopCh) // Instruct watcher go routine to cleanup.
+
+			return
+		}
+
+		// Attempt to unpack the message
+		event := api.Event{}
+		err = json.Unmarshal(data, &event)
+		if err != nil {
+			continue
+		}
+
+		// Extract the message type
+		if event.Type == "" {
+			continue
+		}
+
+		// Send the message to all handlers
+		r.eventListenersLock.Lock()
+		for _, listener := range r.eventListeners[projectName] {
+			listener.targetsLock.Lock()
+			for _, target := range listener.targets {
+				if target.types != nil && !slices.Contains(target.types, event.Type) {
+					continue
+				}
+
+				go target.function(event)
+			}
+
+			listener.targetsLock.Unlock()
+		}
+
+		r.eventListenersLock.Unlock()
+	}
+}
+
+// eventsWebsocket connects to the events websocket at url.
+// With keepalive enabled, the connection gets pinged regularly and reads fail once the pongs stop.
+func (r *ProtocolIncus) eventsWebsocket(url string) (*websocket.Conn, error) {
+	wsConn, err := r.websocket(url)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := r.eventsKeepaliveInterval
+	if interval <= 0 {
+		return wsConn, nil
+	}
+
+	timeout := r.eventsKeepaliveTimeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+
+	_ = wsConn.SetReadDeadline(time.Now().Add(interval + timeout))
+	wsConn.SetPongHandler(func(string) error {
+		return wsConn.SetReadDeadline(time.Now().Add(interval + timeout))
+	})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			// Stop once the connection is closed.
+			err := wsConn.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout))
 			if err != nil {
-				continue
+				return
 			}
+		}
+	}()
 
-			// Extract the message type
-			if event.Type == "" {
-				continue
+	return wsConn, nil
+}
+
+// reconnectEvents re-establishes the lost events connection of the project when enabled,
+// retrying until it succeeds, the client gets disconnected or no listener is left.
+// The listeners are told about the new connection so that they can catch up on missed events.
+func (r *ProtocolIncus) reconnectEvents(projectName string, url string) *websocket.Conn {
+	if !r.eventsReconnect {
+		return nil
+	}
+
+	delay := time.Second
+	for {
+		r.eventListenersLock.Lock()
+		active := len(r.eventListeners[projectName]) > 0
+		r.eventListenersLock.Unlock()
+
+		if !active {
+			return nil
+		}
+
+		wsConn, err := r.eventsWebsocket(url)
+		if err == nil {
+			r.eventConnsLock.Lock()
+			oldConn, ok := r.eventConns[projectName]
+			if ok {
+				_ = oldConn.Close()
 			}
 
-			// Send the message to all handlers
+			r.eventConns[projectName] = wsConn
+			r.eventConnsLock.Unlock()
+
 			r.eventListenersLock.Lock()
-			for _, listener := range r.eventListeners[listener.projectName] {
+			for _, listener := range r.eventListeners[projectName] {
 				listener.targetsLock.Lock()
-				for _, target := range listener.targets {
-					if target.types != nil && !slices.Contains(target.types, event.Type) {
-						continue
-					}
-
-					go target.function(event)
+				if listener.reconnectHandler != nil {
+					go listener.reconnectHandler()
 				}
 
 				listener.targetsLock.Unlock()
 			}
 
 			r.eventListenersLock.Unlock()
+
+			return wsConn
 		}
-	}()
 
-	return &listener, nil
+		select {
+		case <-r.ctxConnected.Done():
+			return nil
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, time.Minute)
+	}
 }
 
 // GetEvents gets the events for the project defined on the client.
@@ -0,0 +1,153 @@
+package incus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// eventsServer returns a client connected to a server running handler on every events connection,
+// along with the number of connections made so far.
+func eventsServer(t *testing.T, args *ConnectionArgs, handler func(conn *websocket.Conn, count int32)) (*ProtocolIncus, *atomic.Int32) {
+	var connections atomic.Int32
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		go func() {
+			<-done
+			_ = conn.Close()
+		}()
+
+		handler(conn, connections.Add(1))
+	}))
+
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(done) })
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	ctxConnected, ctxConnectedCancel := context.WithCancel(context.Background())
+	t.Cleanup(ctxConnectedCancel)
+
+	r := &ProtocolIncus{
+		ctx:                     context.Background(),
+		http:                    server.Client(),
+		httpBaseURL:             *baseURL,
+		ctxConnected:            ctxConnected,
+		ctxConnectedCancel:      ctxConnectedCancel,
+		eventConns:              map[string]*websocket.Conn{},
+		eventListeners:          map[string][]*EventListener{},
+		eventsKeepaliveInterval: args.EventsKeepaliveInterval,
+		eventsKeepaliveTimeout:  args.EventsKeepaliveTimeout,
+		eventsReconnect:         args.EventsReconnect,
+	}
+
+	return r, &connections
+}
+
+func TestEventsKeepalive(t *testing.T) {
+	// The server never reads from the connection so never answers the pings.
+	r, _ := eventsServer(t, &ConnectionArgs{EventsKeepaliveInterval: 50 * time.Millisecond}, func(conn *websocket.Conn, count int32) {})
+
+	listener, err := r.GetEvents()
+	require.NoError(t, err)
+
+	errCh := make(chan error)
+	go func() { errCh <- listener.Wait() }()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorContains(t, err, "keepalive")
+		assert.False(t, listener.IsActive())
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listener not disconnected after the keepalive timeout")
+	}
+}
+
+func TestEventsKeepaliveAnswered(t *testing.T) {
+	// Reading from the connection answers the pings.
+	r, _ := eventsServer(t, &ConnectionArgs{EventsKeepaliveInterval: 20 * time.Millisecond}, func(conn *websocket.Conn, count int32) {
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+		}
+	})
+
+	listener, err := r.GetEvents()
+	require.NoError(t, err)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.True(t, listener.IsActive())
+
+	listener.Disconnect()
+}
+
+func TestEventsReconnect(t *testing.T) {
+	ready := make(chan struct{})
+	r, connections := eventsServer(t, &ConnectionArgs{EventsReconnect: true}, func(conn *websocket.Conn, count int32) {
+		// Drop the first connection once the handlers are in place.
+		<-ready
+		if count == 1 {
+			_ = conn.Close()
+			return
+		}
+
+		_ = conn.WriteJSON(api.Event{Type: "lifecycle"})
+
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+		}
+	})
+
+	listener, err := r.GetEvents()
+	require.NoError(t, err)
+
+	reconnected := make(chan struct{}, 1)
+	listener.SetReconnectHandler(func() { reconnected <- struct{}{} })
+
+	events := make(chan api.Event, 1)
+	_, err = listener.AddHandler([]string{"lifecycle"}, func(event api.Event) { events <- event })
+	require.NoError(t, err)
+
+	close(ready)
+
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Events connection not re-established")
+	}
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "lifecycle", event.Type)
+	case <-time.After(5 * time.Second):
+		t.Fatal("No event received after reconnecting")
+	}
+
+	assert.True(t, listener.IsActive())
+	assert.Equal(t, int32(2), connections.Load())
+
+	listener.Disconnect()
+}
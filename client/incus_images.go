@@ -16,6 +16,7 @@ import (
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/cancel"
 	"github.com/lxc/incus/v6/shared/ioprogress"
+	"github.com/lxc/incus/v6/shared/logger"
 	localtls "github.com/lxc/incus/v6/shared/tls"
 	"github.com/lxc/incus/v6/shared/units"
 	"github.com/lxc/incus/v6/shared/util"
@@ -58,7 +59,12 @@ func (r *ProtocolIncus) GetImagesAllProjects() ([]api.Image, error) {
 // GetImagesWithFilter returns a filtered list of available images as Image structs.
 func (r *ProtocolIncus) GetImagesWithFilter(filters []string) ([]api.Image, error) {
 	if !r.HasExtension("api_filtering") {
-		return nil, fmt.Errorf("The server is missing the required \"api_filtering\" API extension")
+		images, err := r.GetImages()
+		if err != nil {
+			return nil, err
+		}
+
+		return filterList(images, filters), nil
 	}
 
 	images := []api.Image{}
@@ -201,6 +207,19 @@ func incusDownloadImage(fingerprint string, uri string, userAgent string, do fun
 		request.Header.Set("User-Agent", userAgent)
 	}
 
+	// Resume from the data already in the metadata file.
+	var offset int64
+	if req.Resume {
+		offset, err = imageDownloadResumeOffset(req.MetaFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if offset > 0 {
+			request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+	}
+
 	// Start the request
 	response, doneCh, err := cancel.CancelableDownload(req.Canceler, do, request)
 	if err != nil {
@@ -210,11 +229,43 @@ func incusDownloadImage(fingerprint string, uri string, userAgent string, do fun
 	defer func() { _ = response.Body.Close() }()
 	defer close(doneCh)
 
-	if response.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(response)
+	// Restart from scratch when the existing data doesn't fit the image.
+	if offset > 0 && response.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		_ = response.Body.Close()
+
+		req.Resume = false
+		return incusDownloadImage(fingerprint, uri, userAgent, do, req)
+	}
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusPartialContent {
+		return nil, incusParseError(response)
+	}
+
+	// Hash the data already downloaded, leaving the file ready for the rest of it.
+	sha256 := sha256.New()
+
+	if response.StatusCode == http.StatusPartialContent {
+		start, err := imageDownloadRangeStart(response.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, err
+		}
+
+		if start != offset {
+			return nil, fmt.Errorf("Unexpected range in image download, got offset %d instead of %d", start, offset)
+		}
+
+		_, err = req.MetaFile.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = io.CopyN(sha256, req.MetaFile.(io.Reader), offset)
 		if err != nil {
 			return nil, err
 		}
+	} else {
+		// The whole image is being sent.
+		offset = 0
 	}
 
 	ctype, ctypeParams, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
@@ -233,21 +284,34 @@ func incusDownloadImage(fingerprint string, uri string, userAgent string, do fun
 		}
 
 		if response.ContentLength > 0 {
-			reader.Tracker.Handler = func(percent int64, speed int64) {
-				req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+			// Account for the data downloaded before resuming.
+			total := offset + response.ContentLength
+
+			reader.Tracker.Handler = func(_ int64, speed int64) {
+				transferred := offset + reader.Tracker.Processed()
+				percent := min(transferred*100/total, 100)
+
+				req.ProgressHandler(ioprogress.ProgressData{
+					Text:             fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)),
+					Percentage:       int(percent),
+					TransferredBytes: transferred,
+					TotalBytes:       total,
+					Speed:            speed,
+				})
 			}
 		} else {
 			reader.Tracker.Handler = func(received int64, speed int64) {
-				req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(received, 2), units.GetByteSizeString(speed, 2))})
+				req.ProgressHandler(ioprogress.ProgressData{
+					Text:             fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(offset+received, 2), units.GetByteSizeString(speed, 2)),
+					TransferredBytes: offset + received,
+					Speed:            speed,
+				})
 			}
 		}
 
 		body = reader
 	}
 
-	// Hashing
-	sha256 := sha256.New()
-
 	// Deal with split images
 	if ctype == "multipart/form-data" {
 		if req.MetaFile == nil || req.RootfsFile == nil {
@@ -318,18 +382,65 @@ func incusDownloadImage(fingerprint string, uri string, userAgent string, do fun
 		return nil, err
 	}
 
-	resp.MetaSize = size
+	resp.MetaSize = offset + size
 	resp.MetaName = filename
 
 	// Check the hash
 	hash := fmt.Sprintf("%x", sha256.Sum(nil))
 	if !strings.HasPrefix(hash, fingerprint) {
+		// The data downloaded before resuming may not have been part of the image.
+		if offset > 0 {
+			logger.Warn("Resumed image download doesn't match its fingerprint, downloading it again", logger.Ctx{"fingerprint": fingerprint})
+
+			_, err = req.MetaFile.Seek(0, io.SeekStart)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Resume = false
+			return incusDownloadImage(fingerprint, uri, userAgent, do, req)
+		}
+
 		return nil, fmt.Errorf("Image fingerprint doesn't match. Got %s expected %s", hash, fingerprint)
 	}
 
 	return &resp, nil
 }
 
+// imageDownloadResumeOffset returns the amount of data already in file, 0 if it can't be read back
+// to be checked against the image fingerprint. The file is left at its start.
+func imageDownloadResumeOffset(file io.WriteSeeker) (int64, error) {
+	_, ok := file.(io.Reader)
+	if !ok {
+		return 0, nil
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// imageDownloadRangeStart returns the start offset of a "bytes <start>-<end>/<size>" Content-Range header.
+func imageDownloadRangeStart(contentRange string) (int64, error) {
+	var start, end int64
+	var size string
+
+	_, err := fmt.Sscanf(contentRange, "bytes %d-%d/%s", &start, &end, &size)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid Content-Range header %q: %w", contentRange, err)
+	}
+
+	return start, nil
+}
+
 // GetImageAliases returns the list of available aliases as ImageAliasesEntry structs.
 func (r *ProtocolIncus) GetImageAliases() ([]api.ImageAliasesEntry, error) {
 	aliases := []api.ImageAliasesEntry{}
@@ -0,0 +1,100 @@
+package incus
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/ioprogress"
+)
+
+func TestIncusDownloadImageResume(t *testing.T) {
+	image := bytes.Repeat([]byte("incus-image-data"), 1024)
+	fingerprint := fmt.Sprintf("%x", sha256.Sum256(image))
+
+	tests := []struct {
+		name    string
+		partial []byte
+		ranged  bool
+	}{
+		{
+			name:    "Resume from matching data",
+			partial: image[:1000],
+			ranged:  true,
+		},
+		{
+			name:    "Restart on mismatching data",
+			partial: bytes.Repeat([]byte("x"), 1000),
+			ranged:  true,
+		},
+		{
+			name:    "Restart when past the end",
+			partial: append(append([]byte{}, image...), image[:10]...),
+		},
+		{
+			name: "Empty file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ranges = append(ranges, r.Header.Get("Range"))
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Header().Set("Content-Disposition", "inline;filename="+fingerprint)
+				http.ServeContent(w, r, fingerprint, time.Time{}, bytes.NewReader(image))
+			}))
+
+			defer server.Close()
+
+			path := filepath.Join(t.TempDir(), fingerprint)
+			err := os.WriteFile(path, tt.partial, 0o600)
+			require.NoError(t, err)
+
+			f, err := os.OpenFile(path, os.O_RDWR, 0)
+			require.NoError(t, err)
+
+			defer func() { _ = f.Close() }()
+
+			var last ioprogress.ProgressData
+			req := ImageFileRequest{
+				MetaFile:        f,
+				ProgressHandler: func(data ioprogress.ProgressData) { last = data },
+				Resume:          true,
+			}
+
+			resp, err := incusDownloadImage(fingerprint, server.URL, "", server.Client().Do, req)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(image)), resp.MetaSize)
+
+			require.NoError(t, f.Truncate(resp.MetaSize))
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, image, content)
+
+			if tt.ranged {
+				assert.Equal(t, fmt.Sprintf("bytes=%d-", len(tt.partial)), ranges[0])
+			}
+
+			// Restarting always asks for the whole image.
+			if len(ranges) > 1 {
+				assert.Empty(t, ranges[len(ranges)-1])
+			}
+
+			if last.TotalBytes > 0 {
+				assert.Equal(t, int64(len(image)), last.TotalBytes)
+			}
+		})
+	}
+}
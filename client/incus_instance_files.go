@@ -0,0 +1,374 @@
+package incus
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lxc/incus/v6/shared/ioprogress"
+	"github.com/lxc/incus/v6/shared/units"
+)
+
+// instanceFileTreeEntry is a local file part of a directory tree transfer.
+type instanceFileTreeEntry struct {
+	path string
+	name string
+	info fs.FileInfo
+}
+
+// PushInstanceFileTree copies the local file or directory at sourcePath and everything below it
+// into the existing directory at targetPath in the instance, keeping ownership and permissions.
+//
+// Directories, symlinks and small files are sent together as a single tarball,
+// larger files are then sent individually, several of them in parallel.
+func (r *ProtocolIncus) PushInstanceFileTree(instanceName string, sourcePath string, targetPath string, args *InstanceFileTreeArgs) error {
+	err := r.CheckExtension("instance_files_tar")
+	if err != nil {
+		return err
+	}
+
+	if args == nil {
+		args = &InstanceFileTreeArgs{}
+	}
+
+	batchMaxSize := args.BatchMaxSize
+	if batchMaxSize <= 0 {
+		batchMaxSize = 1024 * 1024
+	}
+
+	// List the files to send.
+	sourcePath = filepath.Clean(sourcePath)
+	parent := filepath.Dir(sourcePath)
+
+	progress := &instanceFileTreeProgress{handler: args.ProgressHandler}
+	batched := []instanceFileTreeEntry{}
+	individual := []instanceFileTreeEntry{}
+
+	err = filepath.Walk(sourcePath, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("Failed to walk path for %q: %w", p, err)
+		}
+
+		if !info.Mode().IsRegular() && !info.IsDir() && info.Mode()&os.ModeSymlink != os.ModeSymlink {
+			return fmt.Errorf("%q isn't a supported file type", p)
+		}
+
+		name, err := filepath.Rel(parent, p)
+		if err != nil {
+			return err
+		}
+
+		entry := instanceFileTreeEntry{path: p, name: filepath.ToSlash(name), info: info}
+
+		if info.Mode().IsRegular() {
+			progress.total += info.Size()
+
+			if info.Size() > batchMaxSize {
+				individual = append(individual, entry)
+				return nil
+			}
+		}
+
+		batched = append(batched, entry)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Send the directories, symlinks and small files as a tarball.
+	if len(batched) > 0 {
+		reader, writer := io.Pipe()
+
+		go func() {
+			_ = writer.CloseWithError(instanceFileTreeWriteTar(writer, batched, progress))
+		}()
+
+		err = r.CreateInstanceFileTar(instanceName, targetPath, reader)
+		_ = reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Send the larger files individually.
+	concurrency := args.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	g := errgroup.Group{}
+	g.SetLimit(concurrency)
+
+	for _, entry := range individual {
+		entry := entry
+
+		g.Go(func() error {
+			return r.pushInstanceFileTreeEntry(instanceName, path.Join(targetPath, entry.name), entry, progress)
+		})
+	}
+
+	return g.Wait()
+}
+
+// pushInstanceFileTreeEntry sends a single regular file from a directory tree transfer.
+func (r *ProtocolIncus) pushInstanceFileTreeEntry(instanceName string, targetPath string, entry instanceFileTreeEntry, progress *instanceFileTreeProgress) error {
+	file, err := os.Open(entry.path)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	// Get the ownership the same way as for the tarball.
+	hdr, err := tar.FileInfoHeader(entry.info, "")
+	if err != nil {
+		return err
+	}
+
+	return r.CreateInstanceFile(instanceName, targetPath, InstanceFileArgs{
+		Content: struct {
+			io.Reader
+			io.Seeker
+		}{&instanceFileTreeProgressReader{Reader: file, progress: progress}, file},
+		UID:       int64(hdr.Uid),
+		GID:       int64(hdr.Gid),
+		Mode:      int(entry.info.Mode().Perm()),
+		Type:      "file",
+		WriteMode: "overwrite",
+	})
+}
+
+// instanceFileTreeWriteTar writes the given entries as a tarball.
+func instanceFileTreeWriteTar(w io.Writer, entries []instanceFileTreeEntry, progress *instanceFileTreeProgress) error {
+	tw := tar.NewWriter(w)
+
+	for _, entry := range entries {
+		var link string
+		if entry.info.Mode()&os.ModeSymlink == os.ModeSymlink {
+			var err error
+
+			link, err = os.Readlink(entry.path)
+			if err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(entry.info, link)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = entry.name
+		if entry.info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		if entry.info.Mode().IsRegular() {
+			file, err := os.Open(entry.path)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(tw, &instanceFileTreeProgressReader{Reader: file, progress: progress})
+			_ = file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Close()
+}
+
+// PullInstanceFileTree copies the directory at sourcePath in the instance and everything below it
+// into the local directory at targetPath, keeping permissions.
+//
+// The whole tree is retrieved as a single tarball.
+func (r *ProtocolIncus) PullInstanceFileTree(instanceName string, sourcePath string, targetPath string, args *InstanceFileTreeArgs) error {
+	if args == nil {
+		args = &InstanceFileTreeArgs{}
+	}
+
+	content, err := r.GetInstanceFileTar(instanceName, sourcePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = content.Close() }()
+
+	progress := &instanceFileTreeProgress{handler: args.ProgressHandler}
+	tr := tar.NewReader(&instanceFileTreeProgressReader{Reader: content, progress: progress})
+
+	// Directory permissions are applied last, children first, so that read-only directories can still be filled.
+	type dirMode struct {
+		path string
+		mode fs.FileMode
+	}
+
+	dirModes := []dirMode{}
+
+	// Symlinks created so far, entries can't be extracted through them.
+	symlinks := map[string]bool{}
+
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+
+			break
+		}
+
+		// Keep the entry within the target directory.
+		name := path.Clean("/" + hdr.Name)
+		target := filepath.Join(targetPath, filepath.FromSlash(name))
+		mode := fs.FileMode(hdr.Mode).Perm()
+		if name == "/" {
+			continue
+		}
+
+		for dir := path.Dir(name); dir != "/"; dir = path.Dir(dir) {
+			if symlinks[dir] {
+				return fmt.Errorf("Tarball entry %q is below a symlink", hdr.Name)
+			}
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.Mkdir(target, 0700)
+			if err != nil && !errors.Is(err, fs.ErrExist) {
+				return err
+			}
+
+			dirModes = append(dirModes, dirMode{path: target, mode: mode})
+
+		case tar.TypeReg:
+			file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(file, tr)
+			if err != nil {
+				_ = file.Close()
+				return err
+			}
+
+			err = file.Close()
+			if err != nil {
+				return err
+			}
+
+			err = os.Chmod(target, mode)
+			if err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			err = os.Symlink(hdr.Linkname, target)
+			if err != nil {
+				return err
+			}
+
+			symlinks[name] = true
+
+		default:
+			return fmt.Errorf("Unsupported type for tarball entry %q", hdr.Name)
+		}
+	}
+
+	for i := len(dirModes) - 1; i >= 0; i-- {
+		err = os.Chmod(dirModes[i].path, dirModes[i].mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// instanceFileTreeProgress tracks the overall progress of a directory tree transfer.
+type instanceFileTreeProgress struct {
+	handler func(progress ioprogress.ProgressData)
+
+	// Total number of bytes to transfer (0 if unknown).
+	total int64
+
+	mu        sync.Mutex
+	processed int64
+	start     time.Time
+	last      time.Time
+}
+
+// add records that n more bytes got transferred, reporting the progress at most every 100ms.
+func (p *instanceFileTreeProgress) add(n int) {
+	if p.handler == nil || n <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.start.IsZero() {
+		p.start = now
+	}
+
+	p.processed += int64(n)
+	if now.Sub(p.last) < 100*time.Millisecond && (p.total == 0 || p.processed < p.total) {
+		return
+	}
+
+	p.last = now
+
+	var speed int64
+	duration := now.Sub(p.start).Seconds()
+	if duration > 0 {
+		speed = int64(float64(p.processed) / duration)
+	}
+
+	data := ioprogress.ProgressData{
+		TransferredBytes: p.processed,
+		TotalBytes:       p.total,
+		Speed:            speed,
+	}
+
+	if p.total > 0 {
+		data.Percentage = int(min(p.processed*100/p.total, 100))
+		data.Text = fmt.Sprintf("%d%% (%s/s)", data.Percentage, units.GetByteSizeString(speed, 2))
+	} else {
+		data.Text = fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(p.processed, 2), units.GetByteSizeString(speed, 2))
+	}
+
+	p.handler(data)
+}
+
+// instanceFileTreeProgressReader records the data read through it in a directory tree transfer progress.
+type instanceFileTreeProgressReader struct {
+	io.Reader
+
+	progress *instanceFileTreeProgress
+}
+
+// Read reads from the underlying reader and records the progress.
+func (r *instanceFileTreeProgressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.progress.add(n)
+	return n, err
+}
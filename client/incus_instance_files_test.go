@@ -0,0 +1,158 @@
+package incus
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/ioprogress"
+)
+
+func TestPushInstanceFileTree(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "tree")
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "small"), []byte("small"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "sub", "large1"), bytes.Repeat([]byte("a"), 100), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "sub", "large2"), bytes.Repeat([]byte("b"), 200), 0600))
+	require.NoError(t, os.Symlink("small", filepath.Join(source, "link")))
+
+	var lock sync.Mutex
+	var tarTarget string
+	tarEntries := map[string]string{}
+	files := map[string]int{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if r.Method != http.MethodPost || r.URL.Path != "/1.0/instances/c1/files" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type": "error", "error": "Not found", "error_code": 404}`)
+			return
+		}
+
+		if r.Header.Get("X-Incus-type") == "tar" {
+			tarTarget = r.URL.Query().Get("path")
+
+			tr := tar.NewReader(r.Body)
+			for {
+				hdr, err := tr.Next()
+				if err != nil {
+					break
+				}
+
+				content, _ := io.ReadAll(tr)
+				tarEntries[hdr.Name] = string(hdr.Typeflag) + ":" + hdr.Linkname + string(content)
+			}
+		} else {
+			content, _ := io.ReadAll(r.Body)
+			files[r.URL.Query().Get("path")] = len(content)
+			assert.Equal(t, "0600", r.Header.Get("X-Incus-mode"))
+		}
+
+		_, _ = io.WriteString(w, `{"type": "sync", "status": "Success", "status_code": 200}`)
+	}))
+
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	r := &ProtocolIncus{ctx: context.Background(), http: server.Client(), httpBaseURL: *baseURL}
+
+	progressed := false
+	err = r.PushInstanceFileTree("c1", source, "/root", &InstanceFileTreeArgs{BatchMaxSize: 50, Concurrency: 2, ProgressHandler: func(progress ioprogress.ProgressData) { progressed = true }})
+	require.NoError(t, err)
+
+	names := []string{}
+	for name := range tarEntries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	assert.Equal(t, "/root", tarTarget)
+	assert.Equal(t, []string{"tree/", "tree/link", "tree/small", "tree/sub/"}, names)
+	assert.Equal(t, "0:small", tarEntries["tree/small"])
+	assert.Equal(t, "2:small", tarEntries["tree/link"])
+	assert.Equal(t, map[string]int{"/root/tree/sub/large1": 100, "/root/tree/sub/large2": 200}, files)
+	assert.True(t, progressed)
+}
+
+func TestPullInstanceFileTree(t *testing.T) {
+	var body bytes.Buffer
+	tw := tar.NewWriter(&body)
+
+	add := func(hdr *tar.Header, content string) {
+		hdr.Size = int64(len(content))
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := io.WriteString(tw, content)
+		require.NoError(t, err)
+	}
+
+	add(&tar.Header{Name: "tree/", Typeflag: tar.TypeDir, Mode: 0555}, "")
+	add(&tar.Header{Name: "tree/file", Typeflag: tar.TypeReg, Mode: 0640}, "hello")
+	add(&tar.Header{Name: "tree/link", Typeflag: tar.TypeSymlink, Linkname: "file"}, "")
+	add(&tar.Header{Name: "../../escape", Typeflag: tar.TypeReg, Mode: 0644}, "contained")
+	require.NoError(t, tw.Close())
+
+	var query url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+
+		w.Header().Set("Content-Type", "application/x-tar")
+		_, _ = w.Write(body.Bytes())
+	}))
+
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	r := &ProtocolIncus{ctx: context.Background(), http: server.Client(), httpBaseURL: *baseURL}
+
+	target := filepath.Join(t.TempDir(), "target")
+	require.NoError(t, os.Mkdir(target, 0755))
+
+	err = r.PullInstanceFileTree("c1", "/root/tree", target, nil)
+	require.NoError(t, err)
+
+	// Allow the temporary directory to be cleaned up.
+	t.Cleanup(func() { _ = os.Chmod(filepath.Join(target, "tree"), 0755) })
+
+	assert.Equal(t, "/root/tree", query.Get("path"))
+	assert.Equal(t, "tar", query.Get("format"))
+
+	content, err := os.ReadFile(filepath.Join(target, "tree", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	info, err := os.Stat(filepath.Join(target, "tree", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	info, err = os.Stat(filepath.Join(target, "tree"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0555), info.Mode().Perm())
+
+	link, err := os.Readlink(filepath.Join(target, "tree", "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "file", link)
+
+	content, err = os.ReadFile(filepath.Join(target, "escape"))
+	require.NoError(t, err)
+	assert.Equal(t, "contained", string(content))
+}
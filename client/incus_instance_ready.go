@@ -0,0 +1,112 @@
+package incus
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// WaitInstanceReady waits until predicate returns true for the state of the instance and returns that state.
+//
+// The state is checked every pollInterval (defaults to a second) and, when the event stream is available,
+// as soon as a lifecycle event is received for the instance. The context error is returned should ctx be
+// done first.
+func (r *ProtocolIncus) WaitInstanceReady(ctx context.Context, name string, predicate func(state *api.InstanceState) bool, pollInterval time.Duration) (*api.InstanceState, error) {
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	// Check again as soon as something happens to the instance.
+	wake := make(chan struct{}, 1)
+
+	listener, err := r.GetEvents()
+	if err == nil {
+		defer listener.Disconnect()
+
+		_, err = listener.AddHandler([]string{api.EventTypeLifecycle}, func(event api.Event) {
+			lifecycle := api.EventLifecycle{}
+			err := json.Unmarshal(event.Metadata, &lifecycle)
+			if err != nil || !strings.HasPrefix(lifecycle.Action, "instance-") {
+				return
+			}
+
+			source, err := url.Parse(lifecycle.Source)
+			if err != nil || source.Path != "/1.0/instances/"+name {
+				return
+			}
+
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	timer := time.NewTimer(pollInterval)
+	defer timer.Stop()
+
+	for {
+		state, _, err := r.GetInstanceState(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if predicate(state) {
+			return state, nil
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+
+		timer.Reset(pollInterval)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// HasIPv4OnInterface returns a predicate for WaitInstanceReady checking that the instance has a global
+// IPv4 address on the named network interface.
+func HasIPv4OnInterface(iface string) func(state *api.InstanceState) bool {
+	return func(state *api.InstanceState) bool {
+		network, ok := state.Network[iface]
+		if !ok {
+			return false
+		}
+
+		for _, address := range network.Addresses {
+			if address.Family == "inet" && address.Scope == "global" {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// ProcessesRunning is a predicate for WaitInstanceReady checking that processes are running in the instance.
+// The processes of virtual machines are only known once their agent responds.
+func ProcessesRunning(state *api.InstanceState) bool {
+	return state.Processes > 0
+}
+
+// AgentResponding is a predicate for WaitInstanceReady checking that the instance is running and, for virtual
+// machines, that their agent responds.
+func AgentResponding(state *api.InstanceState) bool {
+	return state.StatusCode == api.Running && state.Processes >= 0
+}
@@ -0,0 +1,90 @@
+package incus
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func TestWaitInstanceReady(t *testing.T) {
+	var polls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1.0/instances/c1/state" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type": "error", "error": "Not found", "error_code": 404}`)
+			return
+		}
+
+		// Processes show up on the third poll.
+		state := api.InstanceState{Status: "Running", StatusCode: api.Running, Processes: -1}
+		if polls.Add(1) >= 3 {
+			state.Processes = 3
+		}
+
+		_ = json.NewEncoder(w).Encode(api.ResponseRaw{Type: api.SyncResponse, Status: "Success", StatusCode: http.StatusOK, Metadata: state})
+	}))
+
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	r := &ProtocolIncus{ctx: context.Background(), http: server.Client(), httpBaseURL: *baseURL}
+
+	// Without an event stream, the state gets polled.
+	state, err := r.WaitInstanceReady(context.Background(), "c1", ProcessesRunning, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), state.Processes)
+	assert.Equal(t, int32(3), polls.Load())
+
+	// Context errors are returned.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = r.WaitInstanceReady(ctx, "c1", HasIPv4OnInterface("eth0"), 10*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestInstanceReadyPredicates(t *testing.T) {
+	network := map[string]api.InstanceStateNetwork{
+		"eth0": {Addresses: []api.InstanceStateNetworkAddress{
+			{Family: "inet6", Address: "fe80::1", Scope: "link"},
+			{Family: "inet", Address: "10.0.0.2", Scope: "global"},
+		}},
+		"eth1": {Addresses: []api.InstanceStateNetworkAddress{
+			{Family: "inet6", Address: "fd42::2", Scope: "global"},
+		}},
+	}
+
+	state := &api.InstanceState{StatusCode: api.Running, Processes: -1, Network: network}
+
+	assert.True(t, HasIPv4OnInterface("eth0")(state))
+	assert.False(t, HasIPv4OnInterface("eth1")(state))
+	assert.False(t, HasIPv4OnInterface("eth2")(state))
+
+	// Virtual machine without a responding agent.
+	assert.False(t, ProcessesRunning(state))
+	assert.False(t, AgentResponding(state))
+
+	state.Processes = 0
+	assert.False(t, ProcessesRunning(state))
+	assert.True(t, AgentResponding(state))
+
+	state.Processes = 5
+	assert.True(t, ProcessesRunning(state))
+
+	state.StatusCode = api.Stopped
+	assert.False(t, AgentResponding(state))
+}
@@ -108,7 +108,12 @@ func (r *ProtocolIncus) GetInstances(instanceType api.InstanceType) ([]api.Insta
 // GetInstancesWithFilter returns a filtered list of instances.
 func (r *ProtocolIncus) GetInstancesWithFilter(instanceType api.InstanceType, filters []string) ([]api.Instance, error) {
 	if !r.HasExtension("api_filtering") {
-		return nil, fmt.Errorf("The server is missing the required \"api_filtering\" API extension")
+		instances, err := r.GetInstances(instanceType)
+		if err != nil {
+			return nil, err
+		}
+
+		return filterList(instances, filters), nil
 	}
 
 	instances := []api.Instance{}
@@ -142,8 +147,9 @@ func (r *ProtocolIncus) GetInstancesAllProjects(instanceType api.InstanceType) (
 	v.Set("recursion", "1")
 	v.Set("all-projects", "true")
 
-	if !r.HasExtension("instance_all_projects") {
-		return nil, fmt.Errorf("The server is missing the required \"instance_all_projects\" API extension")
+	err = r.CheckExtension("instance_all_projects")
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch the raw value
@@ -158,7 +164,12 @@ func (r *ProtocolIncus) GetInstancesAllProjects(instanceType api.InstanceType) (
 // GetInstancesAllProjectsWithFilter returns a filtered list of instances from all projects.
 func (r *ProtocolIncus) GetInstancesAllProjectsWithFilter(instanceType api.InstanceType, filters []string) ([]api.Instance, error) {
 	if !r.HasExtension("api_filtering") {
-		return nil, fmt.Errorf("The server is missing the required \"api_filtering\" API extension")
+		instances, err := r.GetInstancesAllProjects(instanceType)
+		if err != nil {
+			return nil, err
+		}
+
+		return filterList(instances, filters), nil
 	}
 
 	instances := []api.Instance{}
@@ -172,8 +183,9 @@ func (r *ProtocolIncus) GetInstancesAllProjectsWithFilter(instanceType api.Insta
 	v.Set("all-projects", "true")
 	v.Set("filter", parseFilters(filters))
 
-	if !r.HasExtension("instance_all_projects") {
-		return nil, fmt.Errorf("The server is missing the required \"instance_all_projects\" API extension")
+	err = r.CheckExtension("instance_all_projects")
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch the raw value
@@ -354,7 +366,12 @@ func (r *ProtocolIncus) GetInstancesFull(instanceType api.InstanceType) ([]api.I
 // GetInstancesFullWithFilter returns a filtered list of instances including snapshots, backups and state.
 func (r *ProtocolIncus) GetInstancesFullWithFilter(instanceType api.InstanceType, filters []string) ([]api.InstanceFull, error) {
 	if !r.HasExtension("api_filtering") {
-		return nil, fmt.Errorf("The server is missing the required \"api_filtering\" API extension")
+		instances, err := r.GetInstancesFull(instanceType)
+		if err != nil {
+			return nil, err
+		}
+
+		return filterList(instances, filters), nil
 	}
 
 	instances := []api.InstanceFull{}
@@ -396,8 +413,9 @@ func (r *ProtocolIncus) GetInstancesFullAllProjects(instanceType api.InstanceTyp
 		return nil, fmt.Errorf("The server is missing the required \"container_full\" API extension")
 	}
 
-	if !r.HasExtension("instance_all_projects") {
-		return nil, fmt.Errorf("The server is missing the required \"instance_all_projects\" API extension")
+	err = r.CheckExtension("instance_all_projects")
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch the raw value
@@ -412,7 +430,12 @@ func (r *ProtocolIncus) GetInstancesFullAllProjects(instanceType api.InstanceTyp
 // GetInstancesFullAllProjectsWithFilter returns a filtered list of instances including snapshots, backups and state from all projects.
 func (r *ProtocolIncus) GetInstancesFullAllProjectsWithFilter(instanceType api.InstanceType, filters []string) ([]api.InstanceFull, error) {
 	if !r.HasExtension("api_filtering") {
-		return nil, fmt.Errorf("The server is missing the required \"api_filtering\" API extension")
+		instances, err := r.GetInstancesFullAllProjects(instanceType)
+		if err != nil {
+			return nil, err
+		}
+
+		return filterList(instances, filters), nil
 	}
 
 	instances := []api.InstanceFull{}
@@ -430,8 +453,9 @@ func (r *ProtocolIncus) GetInstancesFullAllProjectsWithFilter(instanceType api.I
 		return nil, fmt.Errorf("The server is missing the required \"container_full\" API extension")
 	}
 
-	if !r.HasExtension("instance_all_projects") {
-		return nil, fmt.Errorf("The server is missing the required \"instance_all_projects\" API extension")
+	err = r.CheckExtension("instance_all_projects")
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch the raw value
@@ -951,6 +975,136 @@ func (r *ProtocolIncus) UpdateInstance(name string, instance api.InstancePut, ET
 	return op, nil
 }
 
+// GetInstanceDevice returns the configuration of a device of the instance, along with the instance ETag.
+// Devices inherited from profiles aren't included.
+func (r *ProtocolIncus) GetInstanceDevice(instanceName string, deviceName string) (map[string]string, string, error) {
+	instance, ETag, err := r.GetInstance(instanceName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	device, ok := instance.Devices[deviceName]
+	if !ok {
+		return nil, "", api.StatusErrorf(http.StatusNotFound, "Device %q not found", deviceName)
+	}
+
+	return device, ETag, nil
+}
+
+// CreateInstanceDevice adds a new device to the instance.
+func (r *ProtocolIncus) CreateInstanceDevice(instanceName string, deviceName string, device map[string]string) (Operation, error) {
+	return r.updateInstanceDevices(instanceName, func(devices map[string]map[string]string) error {
+		_, ok := devices[deviceName]
+		if ok {
+			return api.StatusErrorf(http.StatusConflict, "Device %q already exists", deviceName)
+		}
+
+		devices[deviceName] = device
+
+		return nil
+	})
+}
+
+// UpdateInstanceDevice updates a subset of the configuration of an instance device.
+// Keys set to an empty value are removed from the device.
+// Changing the device holding the root filesystem requires force.
+// Servers lacking the "device_patch" API extension get the whole instance updated instead.
+func (r *ProtocolIncus) UpdateInstanceDevice(instanceName string, deviceName string, device map[string]string, force bool) (Operation, error) {
+	if !r.HasExtension("device_patch") {
+		return r.updateInstanceDevices(instanceName, func(devices map[string]map[string]string) error {
+			_, ok := devices[deviceName]
+			if !ok {
+				return api.StatusErrorf(http.StatusNotFound, "Device %q not found", deviceName)
+			}
+
+			for k, v := range device {
+				if v == "" {
+					delete(devices[deviceName], k)
+					continue
+				}
+
+				devices[deviceName][k] = v
+			}
+
+			return nil
+		})
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	params := ""
+	if force {
+		params += "?force=1"
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("PATCH", fmt.Sprintf("%s/%s/devices/%s%s", path, url.PathEscape(instanceName), url.PathEscape(deviceName), params), device, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// DeleteInstanceDevice removes a device from an instance.
+// Removing the device holding the root filesystem requires force.
+// Servers lacking the "device_patch" API extension get the whole instance updated instead.
+func (r *ProtocolIncus) DeleteInstanceDevice(instanceName string, deviceName string, force bool) (Operation, error) {
+	if !r.HasExtension("device_patch") {
+		return r.updateInstanceDevices(instanceName, func(devices map[string]map[string]string) error {
+			_, ok := devices[deviceName]
+			if !ok {
+				return api.StatusErrorf(http.StatusNotFound, "Device %q not found", deviceName)
+			}
+
+			delete(devices, deviceName)
+
+			return nil
+		})
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	params := ""
+	if force {
+		params += "?force=1"
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("DELETE", fmt.Sprintf("%s/%s/devices/%s%s", path, url.PathEscape(instanceName), url.PathEscape(deviceName), params), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// updateInstanceDevices applies update to the devices of the instance and sends the whole instance back,
+// its ETag guarding against concurrent changes.
+func (r *ProtocolIncus) updateInstanceDevices(instanceName string, update func(devices map[string]map[string]string) error) (Operation, error) {
+	instance, ETag, err := r.GetInstance(instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance.Devices == nil {
+		instance.Devices = map[string]map[string]string{}
+	}
+
+	err = update(instance.Devices)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UpdateInstance(instanceName, instance.Writable(), ETag)
+}
+
 // RenameInstance requests that Incus renames the instance.
 func (r *ProtocolIncus) RenameInstance(name string, instance api.InstancePost) (Operation, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -1388,10 +1542,7 @@ func (r *ProtocolIncus) GetInstanceFile(instanceName string, filePath string) (i
 
 	// Check the return value for a cleaner error
 	if resp.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(resp)
-		if err != nil {
-			return nil, nil, err
-		}
+		return nil, nil, incusParseError(resp)
 	}
 
 	// Parse the headers
@@ -1508,6 +1659,86 @@ func (r *ProtocolIncus) CreateInstanceFile(instanceName string, filePath string,
 	return nil
 }
 
+// GetInstanceFileTar retrieves the directory at filePath in the instance and everything below it as a tarball.
+func (r *ProtocolIncus) GetInstanceFileTar(instanceName string, filePath string) (io.ReadCloser, error) {
+	err := r.CheckExtension("instance_files_tar")
+	if err != nil {
+		return nil, err
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare the HTTP request
+	requestURL := fmt.Sprintf("%s/1.0%s/%s/files?path=%s&format=tar", r.httpBaseURL.String(), path, url.PathEscape(instanceName), url.QueryEscape(filePath))
+	requestURL, err = r.setQueryAttributes(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	resp, err := r.DoHTTP(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		return nil, incusParseError(resp)
+	}
+
+	return resp.Body, nil
+}
+
+// CreateInstanceFileTar extracts the tarball read from content into the existing directory at filePath in the instance.
+func (r *ProtocolIncus) CreateInstanceFileTar(instanceName string, filePath string, content io.Reader) error {
+	err := r.CheckExtension("instance_files_tar")
+	if err != nil {
+		return err
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	// Prepare the HTTP request
+	requestURL := fmt.Sprintf("%s/1.0%s/%s/files?path=%s", r.httpBaseURL.String(), path, url.PathEscape(instanceName), url.QueryEscape(filePath))
+	requestURL, err = r.setQueryAttributes(requestURL)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", requestURL, content)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-tar")
+	req.Header.Set("X-Incus-type", "tar")
+
+	// Send the request
+	resp, err := r.DoHTTP(req)
+	if err != nil {
+		return err
+	}
+
+	// Check the return value for a cleaner error
+	_, _, err = incusParseResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // DeleteInstanceFile deletes a file in the instance.
 func (r *ProtocolIncus) DeleteInstanceFile(instanceName string, filePath string) error {
 	if !r.HasExtension("file_delete") {
@@ -1597,10 +1828,7 @@ func (r *ProtocolIncus) rawSFTPConn(apiURL *url.URL) (net.Conn, error) {
 	}
 
 	if resp.StatusCode != http.StatusSwitchingProtocols {
-		_, _, err := incusParseResponse(resp)
-		if err != nil {
-			return nil, err
-		}
+		return nil, incusParseError(resp)
 	}
 
 	if resp.Header.Get("Upgrade") != "sftp" {
@@ -2186,10 +2414,7 @@ func (r *ProtocolIncus) GetInstanceLogfile(name string, filename string) (io.Rea
 
 	// Check the return value for a cleaner error
 	if resp.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(resp)
-		if err != nil {
-			return nil, err
-		}
+		return nil, incusParseError(resp)
 	}
 
 	return resp.Body, err
@@ -2246,10 +2471,7 @@ func (r *ProtocolIncus) getInstanceExecOutputLogFile(name string, filename strin
 
 	// Check the return value for a cleaner error
 	if resp.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(resp)
-		if err != nil {
-			return nil, err
-		}
+		return nil, incusParseError(resp)
 	}
 
 	return resp.Body, nil
@@ -2371,10 +2593,7 @@ func (r *ProtocolIncus) GetInstanceTemplateFile(instanceName string, templateNam
 
 	// Check the return value for a cleaner error
 	if resp.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(resp)
-		if err != nil {
-			return nil, err
-		}
+		return nil, incusParseError(resp)
 	}
 
 	return resp.Body, err
@@ -2407,14 +2626,18 @@ func (r *ProtocolIncus) CreateInstanceTemplateFile(instanceName string, template
 
 	// Send the request
 	resp, err := r.DoHTTP(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
 	// Check the return value for a cleaner error
 	if resp.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(resp)
-		if err != nil {
-			return err
-		}
+		return incusParseError(resp)
 	}
-	return err
+
+	return nil
 }
 
 // DeleteInstanceTemplateFile deletes a template file for a instance.
@@ -2607,6 +2830,9 @@ func (r *ProtocolIncus) ConsoleInstanceDynamic(instanceName string, console api.
 
 // GetInstanceConsoleLog requests that Incus attaches to the console device of a instance.
 //
+// When following the console log, the returned ReadCloser yields new data as the instance writes it,
+// until closed or the instance stops.
+//
 // Note that it's the caller's responsibility to close the returned ReadCloser.
 func (r *ProtocolIncus) GetInstanceConsoleLog(instanceName string, args *InstanceConsoleLogArgs) (io.ReadCloser, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -2618,8 +2844,19 @@ func (r *ProtocolIncus) GetInstanceConsoleLog(instanceName string, args *Instanc
 		return nil, fmt.Errorf("The server is missing the required \"console\" API extension")
 	}
 
+	follow := args != nil && args.Follow
+	if follow {
+		err = r.CheckExtension("console_log_follow")
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Prepare the HTTP request
 	url := fmt.Sprintf("%s/1.0%s/%s/console", r.httpBaseURL.String(), path, url.PathEscape(instanceName))
+	if follow {
+		url += "?follow=true"
+	}
 
 	url, err = r.setQueryAttributes(url)
 	if err != nil {
@@ -2639,10 +2876,7 @@ func (r *ProtocolIncus) GetInstanceConsoleLog(instanceName string, args *Instanc
 
 	// Check the return value for a cleaner error
 	if resp.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(resp)
-		if err != nil {
-			return nil, err
-		}
+		return nil, incusParseError(resp)
 	}
 
 	return resp.Body, err
@@ -2831,10 +3065,7 @@ func (r *ProtocolIncus) GetInstanceBackupFile(instanceName string, name string,
 	defer close(doneCh)
 
 	if response.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(response)
-		if err != nil {
-			return nil, err
-		}
+		return nil, incusParseError(response)
 	}
 
 	// Handle the data
@@ -21,3 +21,20 @@ func (r *ProtocolIncus) GetMetadataConfiguration() (*api.MetadataConfiguration,
 
 	return &metadataConfiguration, nil
 }
+
+// GetDeviceTypes returns the configuration keys of each device type, indexed by device type.
+func (r *ProtocolIncus) GetDeviceTypes() (map[string][]api.MetadataDeviceTypeKey, error) {
+	err := r.CheckExtension("metadata_device_types")
+	if err != nil {
+		return nil, err
+	}
+
+	deviceTypes := map[string][]api.MetadataDeviceTypeKey{}
+
+	_, err = r.queryStruct("GET", "/metadata/device-types", nil, "", &deviceTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return deviceTypes, nil
+}
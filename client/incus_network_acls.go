@@ -104,10 +104,7 @@ func (r *ProtocolIncus) GetNetworkACLLogfile(name string) (io.ReadCloser, error)
 
 	// Check the return value for a cleaner error
 	if resp.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(resp)
-		if err != nil {
-			return nil, err
-		}
+		return nil, incusParseError(resp)
 	}
 
 	return resp.Body, err
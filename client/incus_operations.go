@@ -2,11 +2,15 @@ package incus
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 
 	"github.com/gorilla/websocket"
 
 	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/ioprogress"
+	"github.com/lxc/incus/v6/shared/units"
 )
 
 // GetOperationUUIDs returns a list of operation uuids.
@@ -139,3 +143,58 @@ func (r *ProtocolIncus) DeleteOperation(uuid string) error {
 
 	return nil
 }
+
+// GetOperationFile downloads a file attached to an operation, once the operation produced it.
+// Each file can only be downloaded once.
+//
+// Note that it's the caller's responsibility to close the returned ReadCloser.
+func (r *ProtocolIncus) GetOperationFile(uuid string, name string, progressHandler func(progress ioprogress.ProgressData)) (io.ReadCloser, *OperationFileResponse, error) {
+	err := r.CheckExtension("operation_files")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Prepare the HTTP request
+	requestURL, err := r.setQueryAttributes(fmt.Sprintf("%s/1.0/operations/%s/files/%s", r.httpBaseURL.String(), url.PathEscape(uuid), url.PathEscape(name)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Send the request
+	resp, err := r.DoHTTP(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+
+		return nil, nil, incusParseError(resp)
+	}
+
+	fileResp := OperationFileResponse{
+		Size:   resp.ContentLength,
+		SHA256: resp.Header.Get("X-Incus-sha256"),
+	}
+
+	body := resp.Body
+	if progressHandler != nil {
+		body = &ioprogress.ProgressReader{
+			ReadCloser: resp.Body,
+			Tracker: &ioprogress.ProgressTracker{
+				Length: resp.ContentLength,
+				Handler: func(percent int64, speed int64) {
+					progressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+				},
+			},
+		}
+	}
+
+	return body, &fileResp, nil
+}
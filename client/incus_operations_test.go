@@ -0,0 +1,138 @@
+package incus
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/ioprogress"
+)
+
+func TestGetOperationFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1.0/operations/1234/files/dump.elf" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type": "error", "error": "Operation file not found", "error_code": 404}`)
+			return
+		}
+
+		w.Header().Set("X-Incus-sha256", "abcd")
+		_, _ = io.WriteString(w, "memory")
+	}))
+
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	r := &ProtocolIncus{ctx: context.Background(), http: server.Client(), httpBaseURL: *baseURL}
+
+	progressed := false
+	content, resp, err := r.GetOperationFile("1234", "dump.elf", func(progress ioprogress.ProgressData) { progressed = true })
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	_ = content.Close()
+
+	assert.Equal(t, "memory", string(data))
+	assert.Equal(t, int64(6), resp.Size)
+	assert.Equal(t, "abcd", resp.SHA256)
+	assert.True(t, progressed)
+
+	_, _, err = r.GetOperationFile("1234", "missing", nil)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusNotFound))
+}
+
+func TestOperationWaitProgress(t *testing.T) {
+	running := api.Operation{ID: "1234", Status: "Running", StatusCode: api.Running, MayCancel: true}
+	progress := running
+	progress.Metadata = map[string]any{"progress": "50%"}
+
+	var canceled atomic.Bool
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/1.0/events":
+			upgrader := websocket.Upgrader{}
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+
+			defer func() { _ = conn.Close() }()
+
+			metadata, err := json.Marshal(progress)
+			if err != nil {
+				return
+			}
+
+			// Keep reporting progress as the handler may not be set up yet.
+			for {
+				err = conn.WriteJSON(api.Event{Type: "operation", Metadata: metadata})
+				if err != nil {
+					return
+				}
+
+				select {
+				case <-done:
+					return
+				case <-time.After(20 * time.Millisecond):
+				}
+			}
+
+		case r.Method == http.MethodDelete:
+			canceled.Store(true)
+			_ = json.NewEncoder(w).Encode(api.ResponseRaw{Type: api.SyncResponse, Status: "Success", StatusCode: http.StatusOK})
+
+		default:
+			_ = json.NewEncoder(w).Encode(api.ResponseRaw{Type: api.SyncResponse, Status: "Success", StatusCode: http.StatusOK, Metadata: running})
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(done) })
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	ctxConnected, ctxConnectedCancel := context.WithCancel(context.Background())
+	t.Cleanup(ctxConnectedCancel)
+
+	r := &ProtocolIncus{
+		ctx:                context.Background(),
+		http:               server.Client(),
+		httpBaseURL:        *baseURL,
+		ctxConnected:       ctxConnected,
+		ctxConnectedCancel: ctxConnectedCancel,
+		eventConns:         map[string]*websocket.Conn{},
+		eventListeners:     map[string][]*EventListener{},
+	}
+
+	op := &operation{Operation: running, r: r, chActive: make(chan bool)}
+
+	// Give up on the operation as soon as it reports progress.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var reported atomic.Value
+	err = op.WaitProgress(ctx, func(newOp api.Operation) {
+		reported.Store(newOp.Metadata["progress"])
+		cancel()
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "50%", reported.Load())
+	assert.True(t, canceled.Load())
+}
@@ -2,6 +2,7 @@ package incus
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 
 	"github.com/lxc/incus/v6/shared/api"
@@ -87,6 +88,125 @@ func (r *ProtocolIncus) UpdateProfile(name string, profile api.ProfilePut, ETag
 	return nil
 }
 
+// GetProfileDevice returns the configuration of a device of the profile, along with the profile ETag.
+func (r *ProtocolIncus) GetProfileDevice(profileName string, deviceName string) (map[string]string, string, error) {
+	profile, ETag, err := r.GetProfile(profileName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	device, ok := profile.Devices[deviceName]
+	if !ok {
+		return nil, "", api.StatusErrorf(http.StatusNotFound, "Device %q not found", deviceName)
+	}
+
+	return device, ETag, nil
+}
+
+// CreateProfileDevice adds a new device to the profile.
+func (r *ProtocolIncus) CreateProfileDevice(profileName string, deviceName string, device map[string]string) error {
+	return r.updateProfileDevices(profileName, func(devices map[string]map[string]string) error {
+		_, ok := devices[deviceName]
+		if ok {
+			return api.StatusErrorf(http.StatusConflict, "Device %q already exists", deviceName)
+		}
+
+		devices[deviceName] = device
+
+		return nil
+	})
+}
+
+// UpdateProfileDevice updates a subset of the configuration of a profile device.
+// Keys set to an empty value are removed from the device.
+// Changing the device holding the root filesystem requires force.
+// Servers lacking the "device_patch" API extension get the whole profile updated instead.
+func (r *ProtocolIncus) UpdateProfileDevice(profileName string, deviceName string, device map[string]string, force bool) error {
+	if !r.HasExtension("device_patch") {
+		return r.updateProfileDevices(profileName, func(devices map[string]map[string]string) error {
+			_, ok := devices[deviceName]
+			if !ok {
+				return api.StatusErrorf(http.StatusNotFound, "Device %q not found", deviceName)
+			}
+
+			for k, v := range device {
+				if v == "" {
+					delete(devices[deviceName], k)
+					continue
+				}
+
+				devices[deviceName][k] = v
+			}
+
+			return nil
+		})
+	}
+
+	params := ""
+	if force {
+		params += "?force=1"
+	}
+
+	// Send the request
+	_, _, err := r.query("PATCH", fmt.Sprintf("/profiles/%s/devices/%s%s", url.PathEscape(profileName), url.PathEscape(deviceName), params), device, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteProfileDevice removes a device from a profile.
+// Removing the device holding the root filesystem requires force.
+// Servers lacking the "device_patch" API extension get the whole profile updated instead.
+func (r *ProtocolIncus) DeleteProfileDevice(profileName string, deviceName string, force bool) error {
+	if !r.HasExtension("device_patch") {
+		return r.updateProfileDevices(profileName, func(devices map[string]map[string]string) error {
+			_, ok := devices[deviceName]
+			if !ok {
+				return api.StatusErrorf(http.StatusNotFound, "Device %q not found", deviceName)
+			}
+
+			delete(devices, deviceName)
+
+			return nil
+		})
+	}
+
+	params := ""
+	if force {
+		params += "?force=1"
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/profiles/%s/devices/%s%s", url.PathEscape(profileName), url.PathEscape(deviceName), params), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// updateProfileDevices applies update to the devices of the profile and sends the whole profile back,
+// its ETag guarding against concurrent changes.
+func (r *ProtocolIncus) updateProfileDevices(profileName string, update func(devices map[string]map[string]string) error) error {
+	profile, ETag, err := r.GetProfile(profileName)
+	if err != nil {
+		return err
+	}
+
+	if profile.Devices == nil {
+		profile.Devices = map[string]map[string]string{}
+	}
+
+	err = update(profile.Devices)
+	if err != nil {
+		return err
+	}
+
+	return r.UpdateProfile(profileName, profile.Writable(), ETag)
+}
+
 // RenameProfile renames an existing profile entry.
 func (r *ProtocolIncus) RenameProfile(name string, profile api.ProfilePost) error {
 	// Send the request
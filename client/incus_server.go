@@ -6,9 +6,8 @@ import (
 	"net/http"
 	"slices"
 
-	"github.com/gorilla/websocket"
-
 	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
 	localtls "github.com/lxc/incus/v6/shared/tls"
 )
 
@@ -39,7 +38,9 @@ func (r *ProtocolIncus) GetServer() (*api.Server, string, error) {
 	}
 
 	// Add the value to the cache
+	r.serverLock.Lock()
 	r.server = &server
+	r.serverLock.Unlock()
 
 	return &server, etag, nil
 }
@@ -60,17 +61,31 @@ func (r *ProtocolIncus) UpdateServer(server api.ServerPut, ETag string) error {
 func (r *ProtocolIncus) HasExtension(extension string) bool {
 	// If no cached API information, just assume we're good
 	// This is needed for those rare cases where we must avoid a GetServer call
-	if r.server == nil {
+	server := r.cachedServer()
+	if server == nil {
 		return true
 	}
 
-	return slices.Contains(r.server.APIExtensions, extension)
+	return slices.Contains(server.APIExtensions, extension)
 }
 
 // CheckExtension checks if the server has the specified extension.
+// The cached server information is refreshed once should the extension be missing, in case the server
+// got upgraded since connecting. The returned error is a *MissingExtensionError matching ErrNotSupported.
 func (r *ProtocolIncus) CheckExtension(extensionName string) error {
+	if r.HasExtension(extensionName) {
+		return nil
+	}
+
+	r.serverRefresh.Do(func() {
+		_, _, err := r.GetServer()
+		if err != nil {
+			logger.Debug("Failed to refresh the server information", logger.Ctx{"err": err})
+		}
+	})
+
 	if !r.HasExtension(extensionName) {
-		return fmt.Errorf("The server is missing the required %q API extension", extensionName)
+		return &MissingExtensionError{Extension: extensionName}
 	}
 
 	return nil
@@ -78,7 +93,9 @@ func (r *ProtocolIncus) CheckExtension(extensionName string) error {
 
 // IsClustered returns true if the server is part of an Incus cluster.
 func (r *ProtocolIncus) IsClustered() bool {
-	return r.server.Environment.ServerClustered
+	server := r.cachedServer()
+
+	return server != nil && server.Environment.ServerClustered
 }
 
 // GetServerResources returns the resources available to a given Incus server.
@@ -99,54 +116,30 @@ func (r *ProtocolIncus) GetServerResources() (*api.Resources, error) {
 }
 
 // UseProject returns a client that will use a specific project.
+// The original client is left untouched.
 func (r *ProtocolIncus) UseProject(name string) InstanceServer {
-	return &ProtocolIncus{
-		ctx:                  r.ctx,
-		ctxConnected:         r.ctxConnected,
-		ctxConnectedCancel:   r.ctxConnectedCancel,
-		server:               r.server,
-		http:                 r.http,
-		httpCertificate:      r.httpCertificate,
-		httpBaseURL:          r.httpBaseURL,
-		httpProtocol:         r.httpProtocol,
-		httpUserAgent:        r.httpUserAgent,
-		httpUnixPath:         r.httpUnixPath,
-		requireAuthenticated: r.requireAuthenticated,
-		clusterTarget:        r.clusterTarget,
-		project:              name,
-		eventConns:           make(map[string]*websocket.Conn),  // New project specific listener conns.
-		eventListeners:       make(map[string][]*EventListener), // New project specific listeners.
-		oidcClient:           r.oidcClient,
-	}
+	client := r.clone()
+	client.project = name
+
+	return client
 }
 
 // UseTarget returns a client that will target a specific cluster member.
 // Use this member-specific operations such as specific container
 // placement, preparing a new storage pool or network, ...
+// The original client is left untouched.
 func (r *ProtocolIncus) UseTarget(name string) InstanceServer {
-	return &ProtocolIncus{
-		ctx:                  r.ctx,
-		ctxConnected:         r.ctxConnected,
-		ctxConnectedCancel:   r.ctxConnectedCancel,
-		server:               r.server,
-		http:                 r.http,
-		httpCertificate:      r.httpCertificate,
-		httpBaseURL:          r.httpBaseURL,
-		httpProtocol:         r.httpProtocol,
-		httpUserAgent:        r.httpUserAgent,
-		httpUnixPath:         r.httpUnixPath,
-		requireAuthenticated: r.requireAuthenticated,
-		project:              r.project,
-		eventConns:           make(map[string]*websocket.Conn),  // New target specific listener conns.
-		eventListeners:       make(map[string][]*EventListener), // New target specific listeners.
-		oidcClient:           r.oidcClient,
-		clusterTarget:        name,
-	}
+	client := r.clone()
+	client.clusterTarget = name
+
+	return client
 }
 
 // IsAgent returns true if the server is an Incus agent.
 func (r *ProtocolIncus) IsAgent() bool {
-	return r.server != nil && r.server.Environment.Server == "incus-agent"
+	server := r.cachedServer()
+
+	return server != nil && server.Environment.Server == "incus-agent"
 }
 
 // GetMetrics returns the text OpenMetrics data.
@@ -176,7 +169,7 @@ func (r *ProtocolIncus) GetMetrics() (string, error) {
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Bad HTTP status: %d", resp.StatusCode)
+		return "", incusParseError(resp)
 	}
 
 	// Get the content.
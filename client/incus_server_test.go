@@ -0,0 +1,120 @@
+package incus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func TestCheckExtension(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1.0" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type": "error", "error": "Not found", "error_code": 404}`)
+			return
+		}
+
+		requests++
+		_, _ = io.WriteString(w, `{"type": "sync", "status": "Success", "status_code": 200, "metadata": {"api_extensions": ["old", "upgraded"]}}`)
+	}))
+
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	r := &ProtocolIncus{
+		ctx:         context.Background(),
+		http:        server.Client(),
+		httpBaseURL: *baseURL,
+		server:      &api.Server{ServerUntrusted: api.ServerUntrusted{APIExtensions: []string{"old"}}},
+	}
+
+	// Supported from the cached server information.
+	assert.NoError(t, r.CheckExtension("old"))
+	assert.Equal(t, 0, requests)
+
+	// Supported once the server information is refreshed.
+	assert.NoError(t, r.CheckExtension("upgraded"))
+	assert.Equal(t, 1, requests)
+
+	// Unsupported, without refreshing again.
+	err = r.CheckExtension("missing")
+	assert.ErrorIs(t, err, ErrNotSupported)
+	assert.EqualError(t, err, `The server is missing the required "missing" API extension`)
+	assert.Equal(t, 1, requests)
+
+	var extErr *MissingExtensionError
+	require.True(t, errors.As(err, &extErr))
+	assert.Equal(t, "missing", extErr.Extension)
+
+	// Methods relying on an extension report it as unsupported.
+	_, err = r.GetInstanceDebugMemory("c1", &InstanceDebugMemoryArgs{Path: "/srv/c1.elf"})
+	assert.ErrorIs(t, err, ErrNotSupported)
+
+	_, err = r.GetInstancesAllProjects(api.InstanceTypeAny)
+	assert.ErrorIs(t, err, ErrNotSupported)
+	assert.Equal(t, 1, requests)
+}
+
+func TestUseProjectConcurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"type": "sync", "status": "Success", "status_code": 200, "metadata": {"api_extensions": ["projects"], "environment": {"project": "`+r.URL.Query().Get("project")+`"}}}`)
+	}))
+
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	r := &ProtocolIncus{ctx: context.Background(), http: server.Client(), httpBaseURL: *baseURL}
+
+	type key struct{}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			project := fmt.Sprintf("p%d", i)
+
+			// Derived clients don't affect the shared one.
+			c := r.WithContext(context.WithValue(context.Background(), key{}, i)).UseProject(project).UseTarget("member1")
+			info, err := c.GetConnectionInfo()
+			assert.NoError(t, err)
+			assert.Equal(t, project, info.Project)
+			assert.Equal(t, "member1", info.Target)
+
+			serverInfo, _, err := c.GetServer()
+			assert.NoError(t, err)
+			assert.Equal(t, project, serverInfo.Environment.Project)
+			assert.True(t, c.HasExtension("projects"))
+
+			// Requests and server information updates on the shared client.
+			_, _, err = r.GetServer()
+			assert.NoError(t, err)
+			assert.True(t, r.HasExtension("projects"))
+		}(i)
+	}
+
+	wg.Wait()
+
+	info, err := r.GetConnectionInfo()
+	require.NoError(t, err)
+	assert.Equal(t, api.ProjectDefaultName, info.Project)
+	assert.Equal(t, context.Background(), r.ctx)
+}
@@ -324,10 +324,7 @@ func (r *ProtocolIncus) GetStoragePoolBucketBackupFile(pool string, bucketName s
 	defer close(doneCh)
 
 	if response.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(response)
-		if err != nil {
-			return nil, err
-		}
+		return nil, incusParseError(response)
 	}
 
 	// Handle the data
@@ -127,6 +127,15 @@ func (r *ProtocolIncus) GetStoragePoolVolumesWithFilter(pool string, filters []s
 		return nil, fmt.Errorf("The server is missing the required \"storage\" API extension")
 	}
 
+	if !r.HasExtension("storage_volume_api_filtering") {
+		volumes, err := r.GetStoragePoolVolumes(pool)
+		if err != nil {
+			return nil, err
+		}
+
+		return filterList(volumes, filters), nil
+	}
+
 	volumes := []api.StorageVolume{}
 
 	v := url.Values{}
@@ -153,6 +162,15 @@ func (r *ProtocolIncus) GetStoragePoolVolumesWithFilterAllProjects(pool string,
 		return nil, err
 	}
 
+	if !r.HasExtension("storage_volume_api_filtering") {
+		volumes, err := r.GetStoragePoolVolumesAllProjects(pool)
+		if err != nil {
+			return nil, err
+		}
+
+		return filterList(volumes, filters), nil
+	}
+
 	volumes := []api.StorageVolume{}
 
 	url := api.NewURL().Path("storage-pools", pool, "volumes").
@@ -945,10 +963,7 @@ func (r *ProtocolIncus) GetStoragePoolVolumeBackupFile(pool string, volName stri
 	defer close(doneCh)
 
 	if response.StatusCode != http.StatusOK {
-		_, _, err := incusParseResponse(response)
-		if err != nil {
-			return nil, err
-		}
+		return nil, incusParseError(response)
 	}
 
 	// Handle the data
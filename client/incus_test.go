@@ -0,0 +1,84 @@
+package incus
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func TestStatusErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{
+			name:   "Not found",
+			status: http.StatusNotFound,
+			body:   `{"type": "error", "error": "Instance not found", "error_code": 404}`,
+		},
+		{
+			name:   "Forbidden",
+			status: http.StatusForbidden,
+			body:   `{"type": "error", "error": "not authorized", "error_code": 403}`,
+		},
+		{
+			name:   "Conflict",
+			status: http.StatusConflict,
+			body:   `{"type": "error", "error": "Instance already exists", "error_code": 409}`,
+		},
+		{
+			name:   "Not an API response",
+			status: http.StatusBadGateway,
+			body:   "<html>Bad gateway</html>",
+		},
+		{
+			name:   "Unexpected response type",
+			status: http.StatusForbidden,
+			body:   `{"type": "sync", "metadata": {}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+
+			defer server.Close()
+
+			baseURL, err := url.Parse(server.URL)
+			require.NoError(t, err)
+
+			r := &ProtocolIncus{ctx: context.Background(), http: server.Client(), httpBaseURL: *baseURL}
+
+			check := func(err error) {
+				var statusErr api.StatusError
+				require.True(t, errors.As(err, &statusErr), "Unexpected error %v", err)
+				assert.Equal(t, tt.status, statusErr.Status())
+				assert.True(t, api.StatusErrorCheck(err, tt.status))
+			}
+
+			// Parsed API responses.
+			_, _, err = r.GetInstance("c1")
+			check(err)
+
+			// Raw content.
+			_, err = r.GetInstanceLogfile("c1", "lxc.log")
+			check(err)
+
+			_, err = r.GetMetrics()
+			check(err)
+		})
+	}
+}
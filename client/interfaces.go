@@ -2,9 +2,11 @@ package incus
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/pkg/sftp"
@@ -24,6 +26,7 @@ type Operation interface {
 	Refresh() (err error)
 	Wait() (err error)
 	WaitContext(ctx context.Context) error
+	WaitProgress(ctx context.Context, handler func(api.Operation)) (err error)
 }
 
 // The RemoteOperation type represents an Operation that may be using multiple servers.
@@ -77,10 +80,14 @@ type InstanceServer interface {
 	GetMetrics() (metrics string, err error)
 	GetServer() (server *api.Server, ETag string, err error)
 	GetServerResources() (resources *api.Resources, err error)
+	GetDebugProfile(kind string) (content io.ReadCloser, err error)
+	GetDebugCPUProfile(args *DebugCPUProfileArgs) (op Operation, err error)
+	GetDebugAppArmorProfile(kind string, args *DebugAppArmorProfileArgs) (profile string, err error)
 	UpdateServer(server api.ServerPut, ETag string) (err error)
 	ApplyServerPreseed(config api.InitPreseed) error
 	HasExtension(extension string) (exists bool)
 	RequireAuthenticated(authenticated bool)
+	UpdateClientCertificate(certPEM string, keyPEM string) (err error)
 	IsClustered() (clustered bool)
 	UseTarget(name string) (client InstanceServer)
 	UseProject(name string) (client InstanceServer)
@@ -111,6 +118,10 @@ type InstanceServer interface {
 	CreateInstanceFromImage(source ImageServer, image api.Image, req api.InstancesPost) (op RemoteOperation, err error)
 	CopyInstance(source InstanceServer, instance api.Instance, args *InstanceCopyArgs) (op RemoteOperation, err error)
 	UpdateInstance(name string, instance api.InstancePut, ETag string) (op Operation, err error)
+	GetInstanceDevice(instanceName string, deviceName string) (device map[string]string, ETag string, err error)
+	CreateInstanceDevice(instanceName string, deviceName string, device map[string]string) (op Operation, err error)
+	UpdateInstanceDevice(instanceName string, deviceName string, device map[string]string, force bool) (op Operation, err error)
+	DeleteInstanceDevice(instanceName string, deviceName string, force bool) (op Operation, err error)
 	RenameInstance(name string, instance api.InstancePost) (op Operation, err error)
 	MigrateInstance(name string, instance api.InstancePost) (op Operation, err error)
 	DeleteInstance(name string) (op Operation, err error)
@@ -128,6 +139,10 @@ type InstanceServer interface {
 	GetInstanceFile(instanceName string, path string) (content io.ReadCloser, resp *InstanceFileResponse, err error)
 	CreateInstanceFile(instanceName string, path string, args InstanceFileArgs) (err error)
 	DeleteInstanceFile(instanceName string, path string) (err error)
+	GetInstanceFileTar(instanceName string, path string) (content io.ReadCloser, err error)
+	CreateInstanceFileTar(instanceName string, path string, content io.Reader) (err error)
+	PushInstanceFileTree(instanceName string, sourcePath string, targetPath string, args *InstanceFileTreeArgs) (err error)
+	PullInstanceFileTree(instanceName string, sourcePath string, targetPath string, args *InstanceFileTreeArgs) (err error)
 
 	GetInstanceFileSFTPConn(instanceName string) (net.Conn, error)
 	GetInstanceFileSFTP(instanceName string) (*sftp.Client, error)
@@ -153,9 +168,14 @@ type InstanceServer interface {
 
 	GetInstanceState(name string) (state *api.InstanceState, ETag string, err error)
 	UpdateInstanceState(name string, state api.InstanceStatePut, ETag string) (op Operation, err error)
+	WaitInstanceReady(ctx context.Context, name string, predicate func(state *api.InstanceState) bool, pollInterval time.Duration) (state *api.InstanceState, err error)
 
 	GetInstanceAccess(name string) (access api.Access, err error)
 
+	GetInstanceDebugMemory(name string, args *InstanceDebugMemoryArgs) (op Operation, err error)
+	GetInstanceDebugMemoryWithContext(ctx context.Context, name string, args *InstanceDebugMemoryArgs) (op Operation, err error)
+	GetInstanceDebugQMP(name string, command string) (result json.RawMessage, err error)
+
 	GetInstanceLogfiles(name string) (logfiles []string, err error)
 	GetInstanceLogfile(name string, filename string) (content io.ReadCloser, err error)
 	DeleteInstanceLogfile(name string, filename string) (err error)
@@ -187,6 +207,7 @@ type InstanceServer interface {
 
 	// Configuration metadata functions
 	GetMetadataConfiguration() (meta *api.MetadataConfiguration, err error)
+	GetDeviceTypes() (deviceTypes map[string][]api.MetadataDeviceTypeKey, err error)
 
 	// Network functions ("network" API extension)
 	GetNetworkNames() (names []string, err error)
@@ -272,6 +293,7 @@ type InstanceServer interface {
 	GetOperationWait(uuid string, timeout int) (op *api.Operation, ETag string, err error)
 	GetOperationWaitSecret(uuid string, secret string, timeout int) (op *api.Operation, ETag string, err error)
 	GetOperationWebsocket(uuid string, secret string) (conn *websocket.Conn, err error)
+	GetOperationFile(uuid string, name string, progressHandler func(progress ioprogress.ProgressData)) (content io.ReadCloser, resp *OperationFileResponse, err error)
 	DeleteOperation(uuid string) (err error)
 
 	// Profile functions
@@ -281,6 +303,10 @@ type InstanceServer interface {
 	GetProfile(name string) (profile *api.Profile, ETag string, err error)
 	CreateProfile(profile api.ProfilesPost) (err error)
 	UpdateProfile(name string, profile api.ProfilePut, ETag string) (err error)
+	GetProfileDevice(profileName string, deviceName string) (device map[string]string, ETag string, err error)
+	CreateProfileDevice(profileName string, deviceName string, device map[string]string) (err error)
+	UpdateProfileDevice(profileName string, deviceName string, device map[string]string, force bool) (err error)
+	DeleteProfileDevice(profileName string, deviceName string, force bool) (err error)
 	RenameProfile(name string, profile api.ProfilePost) (err error)
 	DeleteProfile(name string) (err error)
 
@@ -466,6 +492,11 @@ type ImageFileRequest struct {
 	// Path retriever for image delta downloads
 	// If set, it must return the path to the image file or an empty string if not available
 	DeltaSourceRetriever func(fingerprint string, file string) string
+
+	// Resume an interrupted download from the data already in MetaFile (Incus servers only)
+	// MetaFile must also be an io.Reader so that the existing data can be checked against the fingerprint,
+	// the image being downloaded again should it not match
+	Resume bool
 }
 
 // The ImageFileResponse struct is used as the response for image downloads.
@@ -605,6 +636,76 @@ type InstanceConsoleArgs struct {
 // The InstanceConsoleLogArgs struct is used to pass additional options during a
 // instance console log request.
 type InstanceConsoleLogArgs struct {
+	// Keep receiving what the instance writes to its console until the returned reader is closed
+	Follow bool
+}
+
+// The InstanceDebugMemoryArgs struct is used to pass additional options during an
+// instance memory dump.
+type InstanceDebugMemoryArgs struct {
+	// Path on the server to write the dump to (the dump is streamed to Writer when empty)
+	Path string
+
+	// Whether to replace an existing file at Path
+	Overwrite bool
+
+	// Storage pool and custom volume to write the dump into, instead of Path
+	Pool   string
+	Volume string
+
+	// Writer receiving the dump when no server-side path is set
+	Writer io.Writer
+
+	// Progress handler (called with the amount of data received)
+	ProgressHandler func(progress ioprogress.ProgressData)
+
+	// Channel that will be closed when the dump has been fully received
+	DataDone chan bool
+
+	// Dump format (elf, win-dmp, kdump-zlib, kdump-lzo or kdump-snappy for virtual machines, criu or core for containers)
+	Format string
+
+	// Process to dump with the core format on containers (0 for the init process)
+	PID int
+
+	// Compression applied to elf dumps (none, gzip or zstd)
+	Compress string
+
+	// Maximum size of the dump file in bytes (0 for no limit)
+	MaxSize int64
+
+	// Skip the server checks that the guest supports the requested format
+	Force bool
+}
+
+// The DebugCPUProfileArgs struct is used to pass additional options when profiling the server CPU usage.
+type DebugCPUProfileArgs struct {
+	// Writer receiving the profile
+	Writer io.Writer
+
+	// Channel that will be closed when the profile has been fully received
+	DataDone chan bool
+}
+
+// The DebugAppArmorProfileArgs struct is used to select the AppArmor profile to render.
+type DebugAppArmorProfileArgs struct {
+	// Instance name (instance profiles)
+	Instance string
+
+	// Paths read from (rsync and qemu-img profiles)
+	Sources []string
+
+	// Paths written to (rsync and qemu-img profiles)
+	Destinations []string
+
+	// Access given to the destination paths (rsync profiles, create-only or full)
+	Access string
+
+	// Ceph cluster name (ceph profiles)
+	Cluster string
+
+	// Path a volume is exported to or imported from (ceph profiles)
+	Path string
 }
 
 // The InstanceExecArgs struct is used to pass additional options during instance exec.
@@ -646,6 +747,18 @@ type InstanceFileArgs struct {
 	WriteMode string
 }
 
+// The InstanceFileTreeArgs struct is used to pass the various options for a directory tree transfer.
+type InstanceFileTreeArgs struct {
+	// Files up to this size get batched in a single tarball, larger ones are sent individually (defaults to 1MiB)
+	BatchMaxSize int64
+
+	// Number of files sent individually in parallel (defaults to 4)
+	Concurrency int
+
+	// Progress handler (called with the overall progress)
+	ProgressHandler func(progress ioprogress.ProgressData)
+}
+
 // The InstanceFileResponse struct is used as part of the response for a instance file download.
 type InstanceFileResponse struct {
 	// User id that owns the file
@@ -664,6 +777,15 @@ type InstanceFileResponse struct {
 	Entries []string
 }
 
+// The OperationFileResponse struct is used as part of the response for an operation file download.
+type OperationFileResponse struct {
+	// Size of the file
+	Size int64
+
+	// SHA256 checksum of the file
+	SHA256 string
+}
+
 // The StoragePoolBucketBackupArgs struct is used when creating a storage volume from a backup.
 // API extension: storage_bucket_backup.
 type StoragePoolBucketBackupArgs struct {
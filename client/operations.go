@@ -175,6 +175,42 @@ func (op *operation) WaitContext(ctx context.Context) error {
 	return nil
 }
 
+// WaitProgress waits until the operation reaches a final state, calling handler with every update of the
+// operation along the way, progress metadata included. If ctx is done first, the operation gets canceled
+// on the server when it allows it.
+func (op *operation) WaitProgress(ctx context.Context, handler func(api.Operation)) error {
+	if handler != nil && !op.skipListener {
+		target, err := op.AddHandler(handler)
+		if err != nil {
+			return err
+		}
+
+		if target != nil {
+			defer func() { _ = op.RemoveHandler(target) }()
+		}
+	}
+
+	err := op.WaitContext(ctx)
+	if err == nil || ctx.Err() == nil || !errors.Is(err, ctx.Err()) {
+		return err
+	}
+
+	op.handlerLock.Lock()
+	mayCancel := op.MayCancel
+	op.handlerLock.Unlock()
+
+	if !mayCancel {
+		return fmt.Errorf("%w (the operation can't be canceled and keeps running)", err)
+	}
+
+	cancelErr := op.Cancel()
+	if cancelErr != nil {
+		return fmt.Errorf("%w (failed to cancel the operation: %v)", err, cancelErr)
+	}
+
+	return err
+}
+
 // setupListener initiates an event listener for an operation and manages updates to the operation's state.
 // It adds handlers to process events, monitors the listener for completion or errors,
 // and triggers a manual refresh of the operation's state to prevent race conditions.
@@ -0,0 +1,142 @@
+package incus
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how requests failing for transient reasons get retried.
+//
+// Only GET and HEAD requests are retried, along with the requests SafeRequest accepts.
+// Requests changing anything on the server are never retried otherwise.
+type RetryPolicy struct {
+	// Maximum number of attempts, including the first one.
+	MaxAttempts int
+
+	// Delay before the first retry, doubled after each retry (defaults to 500ms).
+	Backoff time.Duration
+
+	// Upper bound of the delay between retries (no bound if unset).
+	MaxBackoff time.Duration
+
+	// HTTP status codes worth retrying (defaults to 502, 503 and 504).
+	StatusCodes []int
+
+	// Retryable reports whether a transport error is worth retrying
+	// (defaults to unexpected EOFs, reset connections and refused connections).
+	Retryable func(err error) bool
+
+	// SafeRequest reports whether a request other than GET or HEAD can be retried,
+	// typically because it doesn't change anything on the server.
+	SafeRequest func(req *http.Request) bool
+
+	// OnRetry gets called before retrying req with the number of the failed attempt
+	// and either the response or the error it got.
+	OnRetry func(req *http.Request, attempt int, resp *http.Response, err error)
+}
+
+// retryStatusCodes are the HTTP status codes retried by default.
+var retryStatusCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// allows returns whether req may be retried.
+func (p *RetryPolicy) allows(req *http.Request) bool {
+	if p.MaxAttempts <= 1 {
+		return false
+	}
+
+	// The body must be sent again on every attempt.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+
+	return p.SafeRequest != nil && p.SafeRequest(req)
+}
+
+// retryable returns whether the attempt resulting in resp or err is worth retrying.
+func (p *RetryPolicy) retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		if p.Retryable != nil {
+			return p.Retryable(err)
+		}
+
+		return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+	}
+
+	statusCodes := p.StatusCodes
+	if statusCodes == nil {
+		statusCodes = retryStatusCodes
+	}
+
+	return slices.Contains(statusCodes, resp.StatusCode)
+}
+
+// delay returns how long to wait after the given failed attempt.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.Backoff
+	if delay <= 0 {
+		delay = 500 * time.Millisecond
+	}
+
+	// Stop doubling past an hour so that the delay can't overflow.
+	for i := 1; i < attempt && delay < time.Hour; i++ {
+		delay *= 2
+	}
+
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	return delay
+}
+
+// do sends req through send, retrying transient failures as allowed by the policy.
+// The last response or error is returned once the attempts are exhausted.
+func (p *RetryPolicy) do(req *http.Request, send func(req *http.Request) (*http.Response, error)) (*http.Response, error) {
+	if !p.allows(req) {
+		return send(req)
+	}
+
+	ctx := req.Context()
+	attemptReq := req
+
+	for attempt := 1; ; attempt++ {
+		resp, err := send(attemptReq)
+		if attempt >= p.MaxAttempts || ctx.Err() != nil || !p.retryable(resp, err) {
+			return resp, err
+		}
+
+		if p.OnRetry != nil {
+			p.OnRetry(req, attempt, resp, err)
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(p.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		// Get a fresh copy of the request and its body.
+		attemptReq = req.Clone(ctx)
+		if req.GetBody != nil {
+			attemptReq.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package incus
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingServer returns a server failing the first failures requests with a 502 error
+// and echoing the request body back otherwise, along with its request counter.
+func failingServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		_, _ = io.Copy(w, r.Body)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		failures int32
+		policy   *RetryPolicy
+		status   int
+		requests int32
+	}{
+		{
+			name:     "No policy",
+			method:   http.MethodGet,
+			failures: 2,
+			status:   http.StatusBadGateway,
+			requests: 1,
+		},
+		{
+			name:     "Recovering GET",
+			method:   http.MethodGet,
+			failures: 2,
+			policy:   &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+			status:   http.StatusOK,
+			requests: 3,
+		},
+		{
+			name:     "Exhausted attempts",
+			method:   http.MethodGet,
+			failures: 5,
+			policy:   &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+			status:   http.StatusBadGateway,
+			requests: 3,
+		},
+		{
+			name:     "Mutation",
+			method:   http.MethodPost,
+			failures: 2,
+			policy:   &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+			status:   http.StatusBadGateway,
+			requests: 1,
+		},
+		{
+			name:     "Safe POST",
+			method:   http.MethodPost,
+			failures: 2,
+			policy:   &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, SafeRequest: func(req *http.Request) bool { return true }},
+			status:   http.StatusOK,
+			requests: 3,
+		},
+		{
+			name:     "Unlisted status code",
+			method:   http.MethodGet,
+			failures: 2,
+			policy:   &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, StatusCodes: []int{http.StatusServiceUnavailable}},
+			status:   http.StatusBadGateway,
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := failingServer(t, tt.failures)
+			r := &ProtocolIncus{http: server.Client(), retryPolicy: tt.policy}
+
+			body := []byte(`{"name": "c1"}`)
+			req, err := http.NewRequest(tt.method, server.URL, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			resp, err := r.DoHTTP(req)
+			require.NoError(t, err)
+
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.requests, requests.Load())
+
+			// The body must be sent in full with every attempt.
+			if resp.StatusCode == http.StatusOK {
+				data, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, body, data)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyOnRetry(t *testing.T) {
+	server, _ := failingServer(t, 2)
+
+	attempts := []int{}
+	r := &ProtocolIncus{http: server.Client(), retryPolicy: &RetryPolicy{
+		MaxAttempts: 5,
+		Backoff:     time.Millisecond,
+		OnRetry: func(req *http.Request, attempt int, resp *http.Response, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+			attempts = append(attempts, attempt)
+		},
+	}}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := r.DoHTTP(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, []int{1, 2}, attempts)
+}
+
+func TestRetryPolicyContextCancel(t *testing.T) {
+	server, requests := failingServer(t, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &ProtocolIncus{http: server.Client(), retryPolicy: &RetryPolicy{
+		MaxAttempts: 5,
+		Backoff:     time.Hour,
+		OnRetry: func(req *http.Request, attempt int, resp *http.Response, err error) {
+			cancel()
+		},
+	}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = r.DoHTTP(req)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := &RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	assert.Equal(t, time.Second, p.delay(1))
+	assert.Equal(t, 2*time.Second, p.delay(2))
+	assert.Equal(t, 4*time.Second, p.delay(3))
+	assert.Equal(t, 5*time.Second, p.delay(4))
+	assert.Equal(t, 5*time.Second, p.delay(100))
+}
@@ -47,8 +47,14 @@ func (r *ProtocolSimpleStreams) GetImageFingerprints() ([]string, error) {
 }
 
 // GetImagesWithFilter returns a filtered list of available images as Image structs.
+// Simplestreams servers don't filter anything so this is done at client side.
 func (r *ProtocolSimpleStreams) GetImagesWithFilter(filters []string) ([]api.Image, error) {
-	return nil, fmt.Errorf("GetImagesWithFilter is not supported by the simplestreams protocol")
+	images, err := r.GetImages()
+	if err != nil {
+		return nil, err
+	}
+
+	return filterList(images, filters), nil
 }
 
 // GetImage returns an Image struct for the provided fingerprint.
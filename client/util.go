@@ -3,14 +3,21 @@ package incus
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"reflect"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/lxc/incus/v6/shared/proxy"
@@ -19,9 +26,10 @@ import (
 
 // tlsHTTPClient creates an HTTP client with a specified Transport Layer Security (TLS) configuration.
 // It takes in parameters for client certificates, keys, Certificate Authority, server certificates,
-// a boolean for skipping verification, a proxy function, and a transport wrapper function.
+// a boolean for skipping verification, a proxy function, a transport wrapper function, a custom dialer
+// and the delay between connection attempts to the different addresses of the server.
 // It returns the HTTP client with the provided configurations and handles any errors that might occur during the setup process.
-func tlsHTTPClient(client *http.Client, tlsClientCert string, tlsClientKey string, tlsCA string, tlsServerCert string, insecureSkipVerify bool, proxyFunc func(req *http.Request) (*url.URL, error), transportWrapper func(t *http.Transport) HTTPTransporter) (*http.Client, error) {
+func tlsHTTPClient(client *http.Client, tlsClientCert string, tlsClientKey string, tlsCA string, tlsServerCert string, insecureSkipVerify bool, proxyFunc func(req *http.Request) (*url.URL, error), transportWrapper func(t *http.Transport) HTTPTransporter, dialContext func(ctx context.Context, network string, addr string) (net.Conn, error), dialStagger time.Duration) (*http.Client, error) {
 	// Get the TLS configuration
 	tlsConfig, err := localtls.GetTLSConfigMem(tlsClientCert, tlsClientKey, tlsCA, tlsServerCert, insecureSkipVerify)
 	if err != nil {
@@ -43,54 +51,87 @@ func tlsHTTPClient(client *http.Client, tlsClientCert string, tlsClientKey strin
 		transport.Proxy = proxyFunc
 	}
 
+	// Allow overriding how connections are established
+	dial := localtls.RFC3493Dialer
+	if dialContext != nil {
+		dial = dialContext
+		transport.DialContext = dialContext
+	}
+
+	if dialStagger <= 0 {
+		dialStagger = defaultDialStagger
+	}
+
 	// Special TLS handling
-	transport.DialTLSContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
-		tlsDial := func(network string, addr string, config *tls.Config, resetName bool) (net.Conn, error) {
-			conn, err := localtls.RFC3493Dialer(ctx, network, addr)
+	tlsDial := func(ctx context.Context, network string, addr string, serverAddr string, config *tls.Config, resetName bool) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		// Setup TLS
+		if resetName {
+			hostName, _, err := net.SplitHostPort(serverAddr)
 			if err != nil {
-				return nil, err
+				hostName = serverAddr
 			}
 
-			// Setup TLS
-			if resetName {
-				hostName, _, err := net.SplitHostPort(addr)
-				if err != nil {
-					hostName = addr
-				}
+			config = config.Clone()
+			config.ServerName = hostName
+		}
 
-				config = config.Clone()
-				config.ServerName = hostName
-			}
+		tlsConn := tls.Client(conn, config)
 
-			tlsConn := tls.Client(conn, config)
+		// Validate the connection
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
 
-			// Validate the connection
-			err = tlsConn.Handshake()
+		if !config.InsecureSkipVerify {
+			err := tlsConn.VerifyHostname(config.ServerName)
 			if err != nil {
 				_ = conn.Close()
 				return nil, err
 			}
-
-			if !config.InsecureSkipVerify {
-				err := tlsConn.VerifyHostname(config.ServerName)
-				if err != nil {
-					_ = conn.Close()
-					return nil, err
-				}
-			}
-
-			return tlsConn, nil
 		}
 
-		conn, err := tlsDial(network, addr, transport.TLSClientConfig, false)
+		return tlsConn, nil
+	}
+
+	dialServer := func(ctx context.Context, network string, addr string, serverAddr string) (net.Conn, error) {
+		conn, err := tlsDial(ctx, network, addr, serverAddr, transport.TLSClientConfig, false)
 		if err != nil {
 			// We may have gotten redirected to a non-Incus machine
-			return tlsDial(network, addr, transport.TLSClientConfig, true)
+			return tlsDial(ctx, network, addr, serverAddr, transport.TLSClientConfig, true)
 		}
 
 		return conn, nil
 	}
 
+	transport.DialTLSContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		// Custom dialers get the address as-is.
+		if dialContext != nil {
+			return dialServer(ctx, network, addr, addr)
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil || len(addrs) < 2 {
+			return dialServer(ctx, network, addr, addr)
+		}
+
+		// Race the addresses of the server, so an unreachable one doesn't hold up the connection.
+		return raceDial(ctx, addrs, dialStagger, func(ctx context.Context, ip string) (net.Conn, error) {
+			return dialServer(ctx, network, net.JoinHostPort(ip, port), addr)
+		})
+	}
+
 	// Define the http client
 	if client == nil {
 		client = &http.Client{}
@@ -113,11 +154,110 @@ func tlsHTTPClient(client *http.Client, tlsClientCert string, tlsClientKey strin
 	return client, nil
 }
 
+// defaultDialStagger is the delay between connection attempts to the different addresses of a server.
+const defaultDialStagger = 250 * time.Millisecond
+
+// raceDial connects to addrs, starting a new attempt every stagger or as soon as the previous one failed,
+// and returns the first connection established. The other attempts are canceled and their connections closed.
+func raceDial(ctx context.Context, addrs []string, stagger time.Duration, dial func(ctx context.Context, addr string) (net.Conn, error)) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result, len(addrs))
+	timer := time.NewTimer(stagger)
+	defer timer.Stop()
+
+	next := 0
+	pending := 0
+	startNext := func() {
+		if next >= len(addrs) {
+			return
+		}
+
+		addr := addrs[next]
+		go func() {
+			conn, err := dial(ctx, addr)
+			results <- result{conn: conn, err: err}
+		}()
+
+		next++
+		pending++
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+
+		timer.Reset(stagger)
+	}
+
+	startNext()
+
+	var errs []error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			startNext()
+
+		case res := <-results:
+			pending--
+
+			if res.err == nil {
+				// Close the connections of the attempts completing after this one.
+				go func(pending int) {
+					for i := 0; i < pending; i++ {
+						late := <-results
+						if late.conn != nil {
+							_ = late.conn.Close()
+						}
+					}
+				}(pending)
+
+				return res.conn, nil
+			}
+
+			errs = append(errs, res.err)
+
+			// Move on to the next address right away.
+			startNext()
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// clientCertificate holds a TLS client certificate which can be replaced while in use.
+type clientCertificate struct {
+	cert atomic.Pointer[tls.Certificate]
+}
+
+// get returns the current certificate, for use as tls.Config.GetClientCertificate.
+func (c *clientCertificate) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cert := c.cert.Load()
+	if cert == nil {
+		// No certificate to present.
+		return &tls.Certificate{}, nil
+	}
+
+	return cert, nil
+}
+
 // unixHTTPClient creates an HTTP client that communicates over a Unix socket.
 // It takes in the connection arguments and the Unix socket path as parameters.
 // The function sets up a Unix socket dialer, configures the HTTP transport, and returns the HTTP client with the specified configurations.
 // Any errors encountered during the setup process are also handled by the function.
 func unixHTTPClient(args *ConnectionArgs, path string) (*http.Client, error) {
+	if args == nil {
+		args = &ConnectionArgs{}
+	}
+
 	// Setup a Unix socket dialer
 	unixDial := func(_ context.Context, network, addr string) (net.Conn, error) {
 		raddr, err := net.ResolveUnixAddr("unix", path)
@@ -128,8 +268,10 @@ func unixHTTPClient(args *ConnectionArgs, path string) (*http.Client, error) {
 		return net.DialUnix("unix", nil, raddr)
 	}
 
-	if args == nil {
-		args = &ConnectionArgs{}
+	if args.DialContext != nil {
+		unixDial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return args.DialContext(ctx, "unix", path)
+		}
 	}
 
 	// Define the http transport
@@ -148,7 +290,11 @@ func unixHTTPClient(args *ConnectionArgs, path string) (*http.Client, error) {
 		client = &http.Client{}
 	}
 
-	client.Transport = transport
+	if args.TransportWrapper != nil {
+		client.Transport = args.TransportWrapper(transport)
+	} else {
+		client.Transport = transport
+	}
 
 	// Setup redirect policy
 	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
@@ -245,6 +391,109 @@ func parseFilters(filters []string) string {
 	return strings.Join(result, " and ")
 }
 
+// filterList returns the entries of list matching all the filters, for servers unable to filter collections.
+// Like on the server, filters apply to the fields named after their YAML tags.
+func filterList[T any](list []T, filters []string) []T {
+	filtered := []T{}
+	for _, entry := range list {
+		if matchFilters(reflect.ValueOf(entry), filters) {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	return filtered
+}
+
+// matchFilters returns whether the struct value matches all the filters passed at client side.
+func matchFilters(value reflect.Value, filters []string) bool {
+	for _, filter := range filters {
+		key, expected, ok := strings.Cut(filter, "=")
+		if !ok {
+			continue
+		}
+
+		field, ok := filterField(value, key)
+		if !ok || !matchFilterValue(field, expected) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterField returns the field of the struct value with the given YAML name, looking into inlined structs.
+func filterField(value reflect.Value, name string) (reflect.Value, bool) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return reflect.Value{}, false
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		tag := value.Type().Field(i).Tag.Get("yaml")
+		if tag == ",inline" {
+			field, ok := filterField(value.Field(i), name)
+			if ok {
+				return field, true
+			}
+
+			continue
+		}
+
+		key, _, _ := strings.Cut(tag, ",")
+		if key == name {
+			return value.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// matchFilterValue compares a field to the expected value the way the server does.
+// Strings are matched case insensitively, as regular expressions when valid ones.
+func matchFilterValue(field reflect.Value, expected string) bool {
+	switch field.Kind() {
+	case reflect.String:
+		pattern := expected
+		if !strings.Contains(pattern, "^") && !strings.Contains(pattern, "$") {
+			pattern = "^" + pattern + "$"
+		}
+
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return strings.EqualFold(field.String(), expected)
+		}
+
+		return re.MatchString(field.String())
+	case reflect.Bool:
+		value, err := strconv.ParseBool(expected)
+		return err == nil && field.Bool() == value
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(expected, 10, 64)
+		return err == nil && field.Int() == value
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(expected, 10, 64)
+		return err == nil && field.Uint() == value
+	case reflect.Slice:
+		values, ok := field.Interface().([]string)
+		if !ok {
+			return false
+		}
+
+		var expectedValues []string
+		err := json.Unmarshal([]byte(expected), &expectedValues)
+		return err == nil && slices.Equal(values, expectedValues)
+	}
+
+	return false
+}
+
 // HTTPTransporter represents a wrapper around *http.Transport.
 // It is used to add some pre and postprocessing logic to http requests / responses.
 type HTTPTransporter interface {
@@ -0,0 +1,105 @@
+package incus
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func TestFilterList(t *testing.T) {
+	instances := []api.InstanceFull{
+		{Instance: api.Instance{Name: "web1", Status: "Running", InstancePut: api.InstancePut{Ephemeral: true, Profiles: []string{"default"}}}},
+		{Instance: api.Instance{Name: "web2", Status: "Stopped", InstancePut: api.InstancePut{Profiles: []string{"default", "web"}}}},
+		{Instance: api.Instance{Name: "db1", Status: "Running", StatusCode: api.Running}},
+	}
+
+	names := func(instances []api.InstanceFull) []string {
+		result := []string{}
+		for _, inst := range instances {
+			result = append(result, inst.Name)
+		}
+
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		filters []string
+		want    []string
+	}{
+		{name: "No filters", filters: nil, want: []string{"web1", "web2", "db1"}},
+		{name: "Regular expression", filters: []string{"name=web.*"}, want: []string{"web1", "web2"}},
+		{name: "Case insensitive", filters: []string{"status=running"}, want: []string{"web1", "db1"}},
+		{name: "Several filters", filters: []string{"name=web.*", "status=Running"}, want: []string{"web1"}},
+		{name: "Inline field", filters: []string{"ephemeral=true"}, want: []string{"web1"}},
+		{name: "Integer field", filters: []string{"status_code=103"}, want: []string{"db1"}},
+		{name: "Slice field", filters: []string{`profiles=["default","web"]`}, want: []string{"web2"}},
+		{name: "Unknown field", filters: []string{"foo=bar"}, want: []string{}},
+		{name: "Not a key/value pair", filters: []string{"web1"}, want: []string{"web1", "web2", "db1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, names(filterList(instances, tt.filters)))
+		})
+	}
+}
+
+func TestRaceDial(t *testing.T) {
+	// The first address never answers, the second one does.
+	var mu sync.Mutex
+	var canceled bool
+
+	dial := func(ctx context.Context, addr string) (net.Conn, error) {
+		switch addr {
+		case "unreachable":
+			<-ctx.Done()
+
+			mu.Lock()
+			canceled = true
+			mu.Unlock()
+
+			return nil, ctx.Err()
+		case "refused":
+			return nil, errors.New("Connection refused")
+		}
+
+		client, server := net.Pipe()
+		_ = server.Close()
+
+		return client, nil
+	}
+
+	start := time.Now()
+	conn, err := raceDial(context.Background(), []string{"unreachable", "reachable"}, 10*time.Millisecond, dial)
+	require.NoError(t, err)
+	require.NotNil(t, conn)
+	assert.Less(t, time.Since(start), time.Second)
+	_ = conn.Close()
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return canceled
+	}, time.Second, 10*time.Millisecond)
+
+	// A failed attempt moves on to the next address without waiting.
+	start = time.Now()
+	conn, err = raceDial(context.Background(), []string{"refused", "reachable"}, time.Hour, dial)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	_ = conn.Close()
+
+	// All attempts failing.
+	_, err = raceDial(context.Background(), []string{"refused", "refused"}, 10*time.Millisecond, dial)
+	assert.ErrorContains(t, err, "Connection refused")
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lxc/incus/v6/internal/server/response"
+	agentAPI "github.com/lxc/incus/v6/shared/api/agent"
+	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/subprocess"
+)
+
+// defaultFreezeTimeout is used when the host doesn't specify how long the filesystems may stay frozen.
+const defaultFreezeTimeout = 60 * time.Second
+
+// mountsPath is the list of mounts the freezable filesystems are found in, replaced in tests.
+var mountsPath = "/proc/mounts"
+
+// fsfreeze freezes or thaws a filesystem, replaced in tests.
+var fsfreeze = func(action string, mountpoint string) error {
+	_, err := subprocess.RunCommand("fsfreeze", action, mountpoint)
+	return err
+}
+
+var filesystemsFreezeCmd = APIEndpoint{
+	Path: "filesystems/freeze",
+
+	Post:   APIEndpointAction{Handler: filesystemsFreezePost},
+	Delete: APIEndpointAction{Handler: filesystemsFreezeDelete},
+}
+
+// frozenFilesystems holds the mountpoints currently frozen by the agent, in the order they were frozen.
+var frozenFilesystems []string
+var frozenFilesystemsTimer *time.Timer
+var frozenFilesystemsMu sync.Mutex
+
+func filesystemsFreezePost(d *Daemon, r *http.Request) response.Response {
+	req := agentAPI.FilesystemsFreezePost{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	timeout := defaultFreezeTimeout
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+	}
+
+	frozenFilesystemsMu.Lock()
+	defer frozenFilesystemsMu.Unlock()
+
+	if len(frozenFilesystems) > 0 {
+		return response.Conflict(fmt.Errorf("Filesystems are already frozen"))
+	}
+
+	mountpoints, err := freezableFilesystems()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	for _, mountpoint := range mountpoints {
+		err := fsfreeze("--freeze", mountpoint)
+		if err != nil {
+			_ = thawFilesystems()
+			return response.InternalError(fmt.Errorf("Failed freezing filesystem %q: %w", mountpoint, err))
+		}
+
+		frozenFilesystems = append(frozenFilesystems, mountpoint)
+	}
+
+	// Never leave the guest frozen if the host goes away before thawing it.
+	frozenFilesystemsTimer = time.AfterFunc(timeout, func() {
+		frozenFilesystemsMu.Lock()
+		defer frozenFilesystemsMu.Unlock()
+
+		logger.Warn("Filesystem freeze timeout reached, thawing filesystems")
+
+		err := thawFilesystems()
+		if err != nil {
+			logger.Error("Failed thawing filesystems", logger.Ctx{"err": err})
+		}
+	})
+
+	return response.SyncResponse(true, frozenFilesystems)
+}
+
+func filesystemsFreezeDelete(d *Daemon, r *http.Request) response.Response {
+	frozenFilesystemsMu.Lock()
+	defer frozenFilesystemsMu.Unlock()
+
+	// The timer is only cleared by thawing, so the host learns when the freeze expired before it was done.
+	if frozenFilesystemsTimer == nil {
+		return response.Conflict(fmt.Errorf("Filesystems are not frozen or the freeze expired"))
+	}
+
+	err := thawFilesystems()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// thawFilesystems thaws the frozen filesystems in reverse order.
+// The caller must hold frozenFilesystemsMu.
+func thawFilesystems() error {
+	if frozenFilesystemsTimer != nil {
+		frozenFilesystemsTimer.Stop()
+		frozenFilesystemsTimer = nil
+	}
+
+	var errs []error
+	for i := len(frozenFilesystems) - 1; i >= 0; i-- {
+		err := fsfreeze("--unfreeze", frozenFilesystems[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Failed thawing filesystem %q: %w", frozenFilesystems[i], err))
+		}
+	}
+
+	frozenFilesystems = nil
+
+	return errors.Join(errs...)
+}
+
+// freezableFilesystems returns the writable block-backed mountpoints, deepest mounts first.
+func freezableFilesystems() ([]string, error) {
+	mounts, err := os.ReadFile(mountsPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s: %w", mountsPath, err)
+	}
+
+	mountpoints := []string{}
+	sources := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(mounts))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("Invalid %s content: %q", mountsPath, line)
+		}
+
+		// Only block-backed filesystems can be frozen.
+		if !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+
+		if slices.Contains(defFSTypesExcluded, fields[2]) || defMountPointsExcluded.MatchString(fields[1]) {
+			continue
+		}
+
+		if slices.Contains(strings.Split(fields[3], ","), "ro") {
+			continue
+		}
+
+		// Bind mounts share their source with the original mount and must only be frozen once.
+		if sources[fields[0]] {
+			continue
+		}
+
+		sources[fields[0]] = true
+		mountpoints = append(mountpoints, fields[1])
+	}
+
+	slices.Reverse(mountpoints)
+
+	return mountpoints, nil
+}
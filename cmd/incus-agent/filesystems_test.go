@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFsfreeze records the fsfreeze calls and fails those listed in failures.
+type fakeFsfreeze struct {
+	mu       sync.Mutex
+	calls    []string
+	failures map[string]bool
+}
+
+func (f *fakeFsfreeze) run(action string, mountpoint string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	call := action + " " + mountpoint
+	f.calls = append(f.calls, call)
+	if f.failures[call] {
+		return errors.New("fsfreeze failed")
+	}
+
+	return nil
+}
+
+func (f *fakeFsfreeze) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string{}, f.calls...)
+}
+
+// setupFilesystemsFreeze points the agent at a fake mount table and fsfreeze.
+func setupFilesystemsFreeze(t *testing.T, mounts string) *fakeFsfreeze {
+	path := filepath.Join(t.TempDir(), "mounts")
+	require.NoError(t, os.WriteFile(path, []byte(mounts), 0o600))
+
+	fake := &fakeFsfreeze{failures: map[string]bool{}}
+
+	oldMountsPath := mountsPath
+	oldFsfreeze := fsfreeze
+	mountsPath = path
+	fsfreeze = fake.run
+
+	t.Cleanup(func() {
+		frozenFilesystemsMu.Lock()
+		_ = thawFilesystems()
+		frozenFilesystemsMu.Unlock()
+
+		mountsPath = oldMountsPath
+		fsfreeze = oldFsfreeze
+	})
+
+	return fake
+}
+
+func freezeRequest(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/1.0/filesystems/freeze", strings.NewReader(body))
+}
+
+func thawRequest() *http.Request {
+	return httptest.NewRequest(http.MethodDelete, "/1.0/filesystems/freeze", nil)
+}
+
+const testMounts = `/dev/sda1 / ext4 rw,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+tmpfs /run tmpfs rw,nosuid,nodev 0 0
+/dev/sda2 /boot ext4 rw,relatime 0 0
+/dev/sda1 /srv/bind ext4 rw,relatime 0 0
+/dev/sdb1 /mnt/readonly ext4 ro,relatime 0 0
+/dev/loop0 /snap/core/1 squashfs ro,nodev 0 0
+/dev/sdc1 /var/lib/docker/volumes ext4 rw,relatime 0 0
+/dev/sdd1 /data xfs rw,relatime 0 0
+`
+
+// Only writable block-backed filesystems are frozen, once per source and deepest mounts first.
+func TestFreezableFilesystems(t *testing.T) {
+	setupFilesystemsFreeze(t, testMounts)
+
+	mountpoints, err := freezableFilesystems()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/data", "/boot", "/"}, mountpoints)
+}
+
+func TestFreezableFilesystemsInvalid(t *testing.T) {
+	setupFilesystemsFreeze(t, "/dev/sda1 /\n")
+
+	_, err := freezableFilesystems()
+	assert.ErrorContains(t, err, "Invalid")
+}
+
+func TestFilesystemsFreeze(t *testing.T) {
+	fake := setupFilesystemsFreeze(t, testMounts)
+
+	resp := filesystemsFreezePost(nil, freezeRequest(`{"timeout": 60}`))
+	require.Equal(t, http.StatusOK, resp.Code())
+	assert.Equal(t, []string{"/data", "/boot", "/"}, frozenFilesystems)
+
+	// A second freeze must not stack on top of the first one.
+	resp = filesystemsFreezePost(nil, freezeRequest(`{}`))
+	assert.Equal(t, http.StatusConflict, resp.Code())
+
+	resp = filesystemsFreezeDelete(nil, thawRequest())
+	require.Equal(t, http.StatusOK, resp.Code())
+	assert.Empty(t, frozenFilesystems)
+	assert.Nil(t, frozenFilesystemsTimer)
+
+	assert.Equal(t, []string{
+		"--freeze /data",
+		"--freeze /boot",
+		"--freeze /",
+		"--unfreeze /",
+		"--unfreeze /boot",
+		"--unfreeze /data",
+	}, fake.recorded())
+}
+
+// A failure part-way thaws the filesystems frozen so far.
+func TestFilesystemsFreezeFailure(t *testing.T) {
+	fake := setupFilesystemsFreeze(t, testMounts)
+	fake.failures["--freeze /boot"] = true
+
+	resp := filesystemsFreezePost(nil, freezeRequest(`{}`))
+	assert.Equal(t, http.StatusInternalServerError, resp.Code())
+	assert.Empty(t, frozenFilesystems)
+	assert.Nil(t, frozenFilesystemsTimer)
+
+	assert.Equal(t, []string{
+		"--freeze /data",
+		"--freeze /boot",
+		"--unfreeze /data",
+	}, fake.recorded())
+}
+
+func TestFilesystemsThawNotFrozen(t *testing.T) {
+	fake := setupFilesystemsFreeze(t, testMounts)
+
+	resp := filesystemsFreezeDelete(nil, thawRequest())
+	assert.Equal(t, http.StatusConflict, resp.Code())
+	assert.Empty(t, fake.recorded())
+}
+
+func TestFilesystemsFreezeInvalidRequest(t *testing.T) {
+	fake := setupFilesystemsFreeze(t, testMounts)
+
+	resp := filesystemsFreezePost(nil, freezeRequest(`{"timeout": "soon"}`))
+	assert.Equal(t, http.StatusBadRequest, resp.Code())
+	assert.Empty(t, fake.recorded())
+}
+
+// The guest is thawed on its own when the host never comes back to thaw it.
+func TestFilesystemsFreezeTimeout(t *testing.T) {
+	fake := setupFilesystemsFreeze(t, testMounts)
+
+	resp := filesystemsFreezePost(nil, freezeRequest(`{"timeout": 1}`))
+	require.Equal(t, http.StatusOK, resp.Code())
+
+	assert.Eventually(t, func() bool {
+		frozenFilesystemsMu.Lock()
+		defer frozenFilesystemsMu.Unlock()
+
+		return len(frozenFilesystems) == 0 && frozenFilesystemsTimer == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The host must be told the snapshot it took may have been taken after the thaw.
+	resp = filesystemsFreezeDelete(nil, thawRequest())
+	assert.Equal(t, http.StatusConflict, resp.Code())
+
+	assert.Equal(t, []string{
+		"--freeze /data",
+		"--freeze /boot",
+		"--freeze /",
+		"--unfreeze /",
+		"--unfreeze /boot",
+		"--unfreeze /data",
+	}, fake.recorded())
+}
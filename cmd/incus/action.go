@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/lxc/incus/v6/client"
 	cli "github.com/lxc/incus/v6/internal/cmd"
 	"github.com/lxc/incus/v6/internal/i18n"
 	"github.com/lxc/incus/v6/shared/api"
@@ -150,6 +153,7 @@ type cmdAction struct {
 	flagStateful  bool
 	flagStateless bool
 	flagTimeout   int
+	flagWaitReady bool
 }
 
 // Command is a method of the cmdAction structure which constructs and configures a cobra Command object.
@@ -171,6 +175,10 @@ func (c *cmdAction) Command(action string) *cobra.Command {
 		cmd.Flags().Lookup("console").NoOptDefVal = "console"
 	}
 
+	if slices.Contains([]string{"start", "restart"}, action) {
+		cmd.Flags().BoolVar(&c.flagWaitReady, "wait-ready", false, i18n.G("Wait for the instance to be ready (running and, for virtual machines, with a responding agent)"))
+	}
+
 	if slices.Contains([]string{"restart", "stop"}, action) {
 		cmd.Flags().BoolVarP(&c.flagForce, "force", "f", false, i18n.G("Force the instance to stop"))
 		cmd.Flags().IntVar(&c.flagTimeout, "timeout", -1, i18n.G("Time to wait for the instance to shutdown cleanly")+"``")
@@ -336,6 +344,17 @@ func (c *cmdAction) doAction(action string, conf *config.Config, nameArg string)
 
 	progress.Done("")
 
+	// Wait for the instance to be ready
+	if c.flagWaitReady && action != "unfreeze" {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		_, err = d.WaitInstanceReady(ctx, name, incus.AgentResponding, 0)
+		if err != nil {
+			return fmt.Errorf(i18n.G("Failed waiting for the instance to be ready: %w"), err)
+		}
+	}
+
 	// Handle console attach
 	if c.flagConsole != "" {
 		console := cmdConsole{}
@@ -425,6 +444,10 @@ func (c *cmdAction) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if c.flagWaitReady && c.flagAll {
+		return fmt.Errorf(i18n.G("--wait-ready can't be used with --all"))
+	}
+
 	if c.flagConsole != "" {
 		if c.flagAll {
 			return fmt.Errorf(i18n.G("--console can't be used with --all"))
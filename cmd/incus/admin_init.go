@@ -3,9 +3,11 @@
 package main
 
 import (
+	"context"
 	"encoding/pem"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -20,6 +22,9 @@ import (
 	"github.com/lxc/incus/v6/shared/util"
 )
 
+// clusterMemberProbeTimeout is how long to wait for each cluster member of a join token to be reachable.
+const clusterMemberProbeTimeout = 5 * time.Second
+
 type cmdAdminInit struct {
 	global *cmdGlobal
 
@@ -167,31 +172,10 @@ func (c *cmdAdminInit) Run(cmd *cobra.Command, args []string) error {
 		// Set server name from join token
 		config.Cluster.ServerName = joinToken.ServerName
 
-		// Attempt to find a working cluster member to use for joining by retrieving the
-		// cluster certificate from each address in the join token until we succeed.
-		for _, clusterAddress := range joinToken.Addresses {
-			// Cluster URL
-			config.Cluster.ClusterAddress = internalUtil.CanonicalNetworkAddress(clusterAddress, ports.HTTPSDefaultPort)
-
-			// Cluster certificate
-			cert, err := localtls.GetRemoteCertificate(fmt.Sprintf("https://%s", config.Cluster.ClusterAddress), version.UserAgent)
-			if err != nil {
-				fmt.Printf(i18n.G("Error connecting to existing cluster member %q: %v")+"\n", clusterAddress, err)
-				continue
-			}
-
-			certDigest := localtls.CertFingerprint(cert)
-			if joinToken.Fingerprint != certDigest {
-				return fmt.Errorf(i18n.G("Certificate fingerprint mismatch between join token and cluster member %q"), clusterAddress)
-			}
-
-			config.Cluster.ClusterCertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
-
-			break // We've found a working cluster member.
-		}
-
-		if config.Cluster.ClusterCertificate == "" {
-			return fmt.Errorf(i18n.G("Unable to connect to any of the cluster members specified in join token"))
+		// Find a working cluster member to use for joining.
+		config.Cluster.ClusterAddress, config.Cluster.ClusterCertificate, err = findClusterMember(joinToken)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -226,6 +210,29 @@ func (c *cmdAdminInit) Run(cmd *cobra.Command, args []string) error {
 	return d.ApplyServerPreseed(*config)
 }
 
+// findClusterMember probes the addresses of the join token and retrieves the cluster certificate from them,
+// trying the reachable ones first, closest first, until one succeeds. Returns the address of that member and the certificate.
+func findClusterMember(joinToken *api.ClusterMemberJoinToken) (string, string, error) {
+	results := internalUtil.ProbeAddresses(context.Background(), joinToken.Addresses, clusterMemberProbeTimeout)
+
+	for _, clusterAddress := range internalUtil.SortAddresses(results) {
+		cert, err := localtls.GetRemoteCertificate(fmt.Sprintf("https://%s", internalUtil.NetworkAddressURLHost(clusterAddress, ports.HTTPSDefaultPort)), version.UserAgent)
+		if err != nil {
+			fmt.Printf(i18n.G("Error connecting to existing cluster member %q: %v")+"\n", clusterAddress, err)
+			continue
+		}
+
+		certDigest := localtls.CertFingerprint(cert)
+		if joinToken.Fingerprint != certDigest {
+			return "", "", fmt.Errorf(i18n.G("Certificate fingerprint mismatch between join token and cluster member %q"), clusterAddress)
+		}
+
+		return clusterAddress, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})), nil
+	}
+
+	return "", "", fmt.Errorf(i18n.G("Unable to connect to any of the cluster members specified in join token:")+"\n%s", internalUtil.ProbeReport(results))
+}
+
 func (c *cmdAdminInit) defaultHostname() string {
 	if c.hostname != "" {
 		return c.hostname
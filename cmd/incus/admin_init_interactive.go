@@ -3,7 +3,6 @@
 package main
 
 import (
-	"encoding/pem"
 	"fmt"
 	"net"
 	"net/http"
@@ -190,30 +189,10 @@ func (c *cmdAdminInit) askClustering(config *api.InitPreseed, d incus.InstanceSe
 			// Set server name from join token
 			config.Cluster.ServerName = joinToken.ServerName
 
-			// Attempt to find a working cluster member to use for joining by retrieving the
-			// cluster certificate from each address in the join token until we succeed.
-			for _, clusterAddress := range joinToken.Addresses {
-				config.Cluster.ClusterAddress = internalUtil.CanonicalNetworkAddress(clusterAddress, ports.HTTPSDefaultPort)
-
-				// Cluster certificate
-				cert, err := localtls.GetRemoteCertificate(fmt.Sprintf("https://%s", config.Cluster.ClusterAddress), version.UserAgent)
-				if err != nil {
-					fmt.Printf(i18n.G("Error connecting to existing cluster member %q: %v")+"\n", clusterAddress, err)
-					continue
-				}
-
-				certDigest := localtls.CertFingerprint(cert)
-				if joinToken.Fingerprint != certDigest {
-					return fmt.Errorf(i18n.G("Certificate fingerprint mismatch between join token and cluster member %q"), clusterAddress)
-				}
-
-				config.Cluster.ClusterCertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
-
-				break // We've found a working cluster member.
-			}
-
-			if config.Cluster.ClusterCertificate == "" {
-				return fmt.Errorf(i18n.G("Unable to connect to any of the cluster members specified in join token"))
+			// Find a working cluster member to use for joining.
+			config.Cluster.ClusterAddress, config.Cluster.ClusterCertificate, err = findClusterMember(joinToken)
+			if err != nil {
+				return err
 			}
 
 			// Pass the raw join token.
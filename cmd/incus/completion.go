@@ -172,6 +172,113 @@ func (g *cmdGlobal) cmpClusterMembers(toComplete string) ([]string, cobra.ShellC
 	return results, cmpDirectives
 }
 
+// cmpDeviceConfigKeys returns the configuration keys of the given device type along with those already set on device.
+// Keys are suffixed with "=" when withValue is set.
+func (g *cmdGlobal) cmpDeviceConfigKeys(resource remoteResource, deviceType string, device map[string]string, withValue bool) ([]string, cobra.ShellCompDirective) {
+	keys := map[string]bool{}
+	for k := range device {
+		keys[k] = true
+	}
+
+	meta, err := g.getMetadataConfiguration(resource)
+	if err == nil {
+		metaKeys, err := meta.GetKeys("devices", deviceMetadataGroup(deviceType))
+		if err == nil {
+			for k := range metaKeys {
+				// Skip key patterns like "initial.*".
+				if strings.Contains(k, "*") {
+					continue
+				}
+
+				keys[k] = true
+			}
+		}
+	} else {
+		cobra.CompDebug(fmt.Sprintf("%v", err), true)
+	}
+
+	results := make([]string, 0, len(keys))
+	for k := range keys {
+		if k == "type" {
+			continue
+		}
+
+		if withValue {
+			k += "="
+		}
+
+		results = append(results, k)
+	}
+
+	if withValue {
+		return results, cobra.ShellCompDirectiveNoSpace
+	}
+
+	return results, cobra.ShellCompDirectiveNoFileComp
+}
+
+// cmpDeviceDiskValues completes the values of the pool and source keys of disk devices added to the given object.
+// Volumes are only offered for source when a pool is already given in args, files are completed otherwise.
+// The last return value is false when toComplete isn't one of those keys.
+func (g *cmdGlobal) cmpDeviceDiskValues(objectName string, args []string, toComplete string) ([]string, cobra.ShellCompDirective, bool) {
+	if strings.HasPrefix(toComplete, "pool=") {
+		pools, directive := g.cmpStoragePoolNames(objectName)
+		results := make([]string, 0, len(pools))
+		for _, pool := range pools {
+			results = append(results, "pool="+pool)
+		}
+
+		return results, directive, true
+	}
+
+	if !strings.HasPrefix(toComplete, "source=") {
+		return nil, cobra.ShellCompDirectiveNoFileComp, false
+	}
+
+	pool := ""
+	for _, arg := range args {
+		value, ok := strings.CutPrefix(arg, "pool=")
+		if ok {
+			pool = value
+		}
+	}
+
+	if pool == "" {
+		return nil, cobra.ShellCompDirectiveDefault, true
+	}
+
+	volumes, directive := g.cmpStoragePoolCustomVolumes(objectName, pool)
+	results := make([]string, 0, len(volumes))
+	for _, volume := range volumes {
+		results = append(results, "source="+volume)
+	}
+
+	return results, directive, true
+}
+
+// cmpDeviceTypeConfigs returns the configuration keys of a device type for a device yet to be added to the given object.
+func (g *cmdGlobal) cmpDeviceTypeConfigs(objectName string, deviceType string) ([]string, cobra.ShellCompDirective) {
+	// Parse remote
+	resources, err := g.ParseServers(objectName)
+	if err != nil || len(resources) == 0 {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return g.cmpDeviceConfigKeys(resources[0], deviceType, nil, true)
+}
+
+// cmpDeviceTypes returns the device types, including any documented by the server of the given object.
+func (g *cmdGlobal) cmpDeviceTypes(objectName string) ([]string, cobra.ShellCompDirective) {
+	var meta *api.MetadataConfiguration
+
+	resources, err := g.ParseServers(objectName)
+	if err == nil && len(resources) > 0 {
+		meta, _ = g.getMetadataConfiguration(resources[0])
+	}
+
+	return deviceTypes(meta), cobra.ShellCompDirectiveNoFileComp
+}
+
 func (g *cmdGlobal) cmpImages(toComplete string) ([]string, cobra.ShellCompDirective) {
 	results := []string{}
 	var remote string
@@ -266,6 +373,31 @@ func (g *cmdGlobal) cmpInstanceDeviceNames(instanceName string) ([]string, cobra
 	return results, cobra.ShellCompDirectiveNoFileComp
 }
 
+func (g *cmdGlobal) cmpInstanceDeviceConfigs(instanceName string, deviceName string, withValue bool) ([]string, cobra.ShellCompDirective) {
+	// Parse remote
+	resources, err := g.ParseServers(instanceName)
+	if err != nil || len(resources) == 0 {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	resource := resources[0]
+
+	inst, _, err := resource.server.GetInstance(resource.name)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	device, ok := inst.Devices[deviceName]
+	if !ok {
+		device, ok = inst.ExpandedDevices[deviceName]
+		if !ok {
+			return nil, cobra.ShellCompDirectiveError
+		}
+	}
+
+	return g.cmpDeviceConfigKeys(resource, device["type"], device, withValue)
+}
+
 func (g *cmdGlobal) cmpInstanceSnapshots(instanceName string) ([]string, cobra.ShellCompDirective) {
 	resources, err := g.ParseServers(instanceName)
 	if err != nil || len(resources) == 0 {
@@ -814,6 +946,67 @@ func (g *cmdGlobal) cmpProfileDeviceNames(instanceName string) ([]string, cobra.
 	return results, cobra.ShellCompDirectiveNoFileComp
 }
 
+func (g *cmdGlobal) cmpProfileDeviceConfigs(profileName string, deviceName string, withValue bool) ([]string, cobra.ShellCompDirective) {
+	// Parse remote
+	resources, err := g.ParseServers(profileName)
+	if err != nil || len(resources) == 0 {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	resource := resources[0]
+
+	profile, _, err := resource.server.GetProfile(resource.name)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	device, ok := profile.Devices[deviceName]
+	if !ok {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return g.cmpDeviceConfigKeys(resource, device["type"], device, withValue)
+}
+
+// cmpProfileDevicePaths completes <profile>/<device> references to the devices of the profiles
+// on the remote of the given object.
+func (g *cmdGlobal) cmpProfileDevicePaths(objectName string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Parse remote
+	resources, err := g.ParseServers(objectName)
+	if err != nil || len(resources) == 0 {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	client := resources[0].server
+
+	profileName, _, ok := strings.Cut(toComplete, "/")
+	if !ok {
+		profiles, err := client.GetProfileNames()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		results := make([]string, 0, len(profiles))
+		for _, profile := range profiles {
+			results = append(results, profile+"/")
+		}
+
+		return results, cobra.ShellCompDirectiveNoSpace
+	}
+
+	profile, _, err := client.GetProfile(profileName)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	results := make([]string, 0, len(profile.Devices))
+	for name := range profile.Devices {
+		results = append(results, profileName+"/"+name)
+	}
+
+	return results, cobra.ShellCompDirectiveNoFileComp
+}
+
 func (g *cmdGlobal) cmpProfileNamesFromRemote(toComplete string) ([]string, cobra.ShellCompDirective) {
 	results := []string{}
 
@@ -1035,6 +1228,56 @@ func (g *cmdGlobal) cmpStoragePools(toComplete string) ([]string, cobra.ShellCom
 	return results, cobra.ShellCompDirectiveNoFileComp
 }
 
+// cmpStoragePoolCustomVolumes returns the custom volumes of a storage pool on the remote of the given object.
+// Only volumes of the current project are returned.
+func (g *cmdGlobal) cmpStoragePoolCustomVolumes(objectName string, poolName string) ([]string, cobra.ShellCompDirective) {
+	// Parse remote
+	resources, err := g.ParseServers(objectName)
+	if err != nil || len(resources) == 0 {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	client := resources[0].server
+
+	projectName := api.ProjectDefaultName
+	info, err := client.GetConnectionInfo()
+	if err == nil && info.Project != "" {
+		projectName = info.Project
+	}
+
+	volumes, err := client.GetStoragePoolVolumes(poolName)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	results := []string{}
+	for _, volume := range volumes {
+		if volume.Type != "custom" || (volume.Project != "" && volume.Project != projectName) {
+			continue
+		}
+
+		results = append(results, volume.Name)
+	}
+
+	return results, cobra.ShellCompDirectiveNoFileComp
+}
+
+// cmpStoragePoolNames returns the storage pools on the remote of the given object, without any remote prefix.
+func (g *cmdGlobal) cmpStoragePoolNames(objectName string) ([]string, cobra.ShellCompDirective) {
+	// Parse remote
+	resources, err := g.ParseServers(objectName)
+	if err != nil || len(resources) == 0 {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	pools, err := resources[0].server.GetStoragePoolNames()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return pools, cobra.ShellCompDirectiveNoFileComp
+}
+
 func (g *cmdGlobal) cmpStoragePoolVolumeConfigs(poolName string, volumeName string) ([]string, cobra.ShellCompDirective) {
 	// Parse remote
 	resources, err := g.ParseServers(poolName)
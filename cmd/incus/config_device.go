@@ -1,22 +1,46 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
 	"strings"
+	"sync/atomic"
 
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	incus "github.com/lxc/incus/v6/client"
 	cli "github.com/lxc/incus/v6/internal/cmd"
 	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/termios"
 )
 
 type cmdConfigDevice struct {
 	global  *cmdGlobal
 	config  *cmdConfig
 	profile *cmdProfile
+
+	flagDryRun bool
+	flagStrict bool
+	flagFormat string
+
+	// Whether any of the dry-run changes would have modified something.
+	dryRunChanged atomic.Bool
 }
 
+// configDeviceDryRunNoChange is the exit code of a dry-run that wouldn't change anything.
+const configDeviceDryRunNoChange = 2
+
 func (c *cmdConfigDevice) Command() *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Use = usage("device")
@@ -28,6 +52,14 @@ func (c *cmdConfigDevice) Command() *cobra.Command {
 	configDeviceAddCmd := cmdConfigDeviceAdd{global: c.global, config: c.config, profile: c.profile, configDevice: c}
 	cmd.AddCommand(configDeviceAddCmd.Command())
 
+	// Diff
+	configDeviceDiffCmd := cmdConfigDeviceDiff{global: c.global, config: c.config, profile: c.profile, configDevice: c}
+	cmd.AddCommand(configDeviceDiffCmd.Command())
+
+	// Edit
+	configDeviceEditCmd := cmdConfigDeviceEdit{global: c.global, config: c.config, profile: c.profile, configDevice: c}
+	cmd.AddCommand(configDeviceEditCmd.Command())
+
 	// Get
 	configDeviceGetCmd := cmdConfigDeviceGet{global: c.global, config: c.config, profile: c.profile, configDevice: c}
 	cmd.AddCommand(configDeviceGetCmd.Command())
@@ -37,15 +69,17 @@ func (c *cmdConfigDevice) Command() *cobra.Command {
 	cmd.AddCommand(configDeviceListCmd.Command())
 
 	// Override
-	if c.config != nil {
-		configDeviceOverrideCmd := cmdConfigDeviceOverride{global: c.global, config: c.config, profile: c.profile, configDevice: c}
-		cmd.AddCommand(configDeviceOverrideCmd.Command())
-	}
+	configDeviceOverrideCmd := cmdConfigDeviceOverride{global: c.global, config: c.config, profile: c.profile, configDevice: c}
+	cmd.AddCommand(configDeviceOverrideCmd.Command())
 
 	// Remove
 	configDeviceRemoveCmd := cmdConfigDeviceRemove{global: c.global, config: c.config, profile: c.profile, configDevice: c}
 	cmd.AddCommand(configDeviceRemoveCmd.Command())
 
+	// Rename
+	configDeviceRenameCmd := cmdConfigDeviceRename{global: c.global, config: c.config, profile: c.profile, configDevice: c}
+	cmd.AddCommand(configDeviceRenameCmd.Command())
+
 	// Set
 	configDeviceSetCmd := cmdConfigDeviceSet{global: c.global, config: c.config, profile: c.profile, configDevice: c}
 	cmd.AddCommand(configDeviceSetCmd.Command())
@@ -70,21 +104,36 @@ type cmdConfigDeviceAdd struct {
 	config       *cmdConfig
 	configDevice *cmdConfigDevice
 	profile      *cmdProfile
+
+	flagFromFile string
+	flagTarget   string
 }
 
 func (c *cmdConfigDeviceAdd) Command() *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Short = i18n.G("Add instance devices")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`Add instance devices`))
+		`Add instance devices
+
+With --from-file, the device configuration is read from a YAML or JSON file ("-" for stdin).
+The file either holds the configuration keys of a single device, in which case the type
+argument may be omitted, or a map of device names to their configuration, in which case
+neither the device name nor the type arguments are needed.
+Any key=value arguments are applied on top of the file content.`))
 	if c.config != nil {
-		cmd.Use = usage("add", i18n.G("[<remote>:]<instance> <device> <type> [key=value...]"))
+		cmd.Use = usage("add", i18n.G("[<remote>:]<instance>[,<instance>...] <device> <type> [key=value...]"))
 		cmd.Example = cli.FormatSection("", i18n.G(
 			`incus config device add [<remote>:]instance1 <device-name> disk source=/share/c1 path=/opt
     Will mount the host's /share/c1 onto /opt in the instance.
 
 incus config device add [<remote>:]instance1 <device-name> disk pool=some-pool source=some-volume path=/opt
-    Will mount the some-volume volume on some-pool onto /opt in the instance.`))
+    Will mount the some-volume volume on some-pool onto /opt in the instance.
+
+incus profile device show default | incus config device add [<remote>:]instance1 --from-file -
+    Will add all the devices of the default profile to the instance.
+
+incus config device add [<remote>:]instance1,instance2 <device-name> nic network=incusbr1
+    Will add the same nic to both instances.`))
 	} else if c.profile != nil {
 		cmd.Use = usage("add", i18n.G("[<remote>:]<profile> <device> <type> [key=value...]"))
 		cmd.Example = cli.FormatSection("", i18n.G(
@@ -92,9 +141,20 @@ incus config device add [<remote>:]instance1 <device-name> disk pool=some-pool s
     Will mount the host's /share/c1 onto /opt in the instance.
 
 incus profile device add [<remote>:]profile1 <device-name> disk pool=some-pool source=some-volume path=/opt
-    Will mount the some-volume volume on some-pool onto /opt in the instance.`))
+    Will mount the some-volume volume on some-pool onto /opt in the instance.
+
+incus profile device add [<remote>:]profile1 proxy1 --from-file proxy.yaml
+    Will add a device using the configuration from proxy.yaml.`))
 	}
 
+	cmd.Flags().StringVar(&c.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.Flags().StringVar(&c.flagFromFile, "from-file", "", i18n.G("Read the device configuration from a YAML or JSON file (\"-\" for stdin)")+"``")
+
+	cmd.Flags().BoolVar(&c.configDevice.flagDryRun, "dry-run", false, i18n.G("Show the changes that would be made without applying them"))
+	cmd.Flags().StringVar(&c.configDevice.flagFormat, "format", "", i18n.G("Format of the result (json)")+"``")
+
+	cmd.Flags().BoolVar(&c.configDevice.flagStrict, "strict", false, i18n.G("Fail on undocumented device configuration keys instead of warning"))
+
 	cmd.RunE = c.Run
 
 	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -106,19 +166,160 @@ incus profile device add [<remote>:]profile1 <device-name> disk pool=some-pool s
 			}
 		}
 
+		// With --from-file, the remaining arguments can't be told apart.
+		if c.flagFromFile != "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		if len(args) == 2 {
+			return c.global.cmpDeviceTypes(args[0])
+		}
+
+		if len(args) > 2 {
+			results, directive, ok := c.global.cmpDeviceDiskValues(args[0], args[3:], toComplete)
+			if ok {
+				return results, directive
+			}
+
+			return c.global.cmpDeviceTypeConfigs(args[0], args[2])
+		}
+
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
 	return cmd
 }
 
+// parseDevicesFile parses the content of a --from-file argument.
+// A file holding the configuration of a single device is returned as a device with an empty name.
+func (c *cmdConfigDeviceAdd) parseDevicesFile(content []byte) (map[string]map[string]string, error) {
+	device := map[string]string{}
+	err := yaml.Unmarshal(content, &device)
+	if err == nil {
+		if len(device) == 0 {
+			return nil, fmt.Errorf(i18n.G("No device configuration found in file"))
+		}
+
+		return map[string]map[string]string{"": device}, nil
+	}
+
+	devices := map[string]map[string]string{}
+	err = yaml.Unmarshal(content, &devices)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("Failed parsing device configuration: %w"), err)
+	}
+
+	if len(devices) == 0 {
+		return nil, fmt.Errorf(i18n.G("No device configuration found in file"))
+	}
+
+	for name, device := range devices {
+		if device == nil {
+			devices[name] = map[string]string{}
+		}
+	}
+
+	return devices, nil
+}
+
+// devicesFromFile builds the devices to add from the --from-file content and the remaining arguments.
+func (c *cmdConfigDeviceAdd) devicesFromFile(args []string) (map[string]map[string]string, error) {
+	var content []byte
+	var err error
+	if c.flagFromFile == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(c.flagFromFile)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := c.parseDevicesFile(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the positional arguments from the key=value ones.
+	var positional []string
+	config := map[string]string{}
+	for _, arg := range args {
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok {
+			if len(config) > 0 {
+				return nil, fmt.Errorf(i18n.G("No value found in %q"), arg)
+			}
+
+			positional = append(positional, arg)
+			continue
+		}
+
+		config[k] = v
+	}
+
+	var devType string
+	device, single := devices[""]
+	if single {
+		// A single device needs a name and optionally a type.
+		if len(positional) == 0 || len(positional) > 2 {
+			return nil, fmt.Errorf(i18n.G("A device name and optionally a type must be given when adding a single device from a file"))
+		}
+
+		if len(positional) == 2 {
+			devType = positional[1]
+		}
+
+		devices = map[string]map[string]string{positional[0]: device}
+	} else {
+		// Several devices are named by the file and only optionally share a type.
+		if len(positional) > 1 {
+			return nil, fmt.Errorf(i18n.G("Only a type can be given when adding several devices from a file"))
+		}
+
+		if len(positional) == 1 {
+			devType = positional[0]
+		}
+	}
+
+	for name, device := range devices {
+		if devType != "" {
+			if device["type"] != "" && device["type"] != devType {
+				return nil, fmt.Errorf(i18n.G("Device %q is of type %q in the file but %q was requested"), name, device["type"], devType)
+			}
+
+			device["type"] = devType
+		}
+
+		for k, v := range config {
+			device[k] = v
+		}
+
+		if device["type"] == "" {
+			return nil, fmt.Errorf(i18n.G("No type found for device %q"), name)
+		}
+	}
+
+	return devices, nil
+}
+
 func (c *cmdConfigDeviceAdd) Run(cmd *cobra.Command, args []string) error {
 	// Quick checks.
-	exit, err := c.global.CheckArgs(cmd, args, 3, -1)
+	minArgs := 3
+	if c.flagFromFile != "" {
+		minArgs = 1
+	}
+
+	exit, err := c.global.CheckArgs(cmd, args, minArgs, -1)
 	if exit {
 		return err
 	}
 
+	err = c.configDevice.checkFormat()
+	if err != nil {
+		return err
+	}
+
 	// Parse remote
 	resources, err := c.global.ParseServers(args[0])
 	if err != nil {
@@ -131,20 +332,52 @@ func (c *cmdConfigDeviceAdd) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf(i18n.G("Missing name"))
 	}
 
+	err = c.configDevice.useTarget(&resource, c.flagTarget)
+	if err != nil {
+		return err
+	}
+
 	// Add the device
-	devname := args[1]
-	device := map[string]string{}
-	device["type"] = args[2]
-	if len(args) > 3 {
-		for _, prop := range args[3:] {
-			results := strings.SplitN(prop, "=", 2)
-			if len(results) != 2 {
-				return fmt.Errorf(i18n.G("No value found in %q"), prop)
+	var devices map[string]map[string]string
+	if c.flagFromFile != "" {
+		devices, err = c.devicesFromFile(args[1:])
+		if err != nil {
+			return err
+		}
+	} else {
+		device := map[string]string{}
+		device["type"] = args[2]
+		if len(args) > 3 {
+			for _, prop := range args[3:] {
+				results := strings.SplitN(prop, "=", 2)
+				if len(results) != 2 {
+					return fmt.Errorf(i18n.G("No value found in %q"), prop)
+				}
+
+				k := results[0]
+				v := results[1]
+				device[k] = v
 			}
+		}
 
-			k := results[0]
-			v := results[1]
-			device[k] = v
+		devices = map[string]map[string]string{args[1]: device}
+	}
+
+	meta := c.configDevice.deviceMetadata(resource)
+	for devname, device := range devices {
+		err = instance.ValidDeviceName(devname)
+		if err != nil {
+			return err
+		}
+
+		err = c.configDevice.validateDeviceType(meta, device["type"])
+		if err != nil {
+			return err
+		}
+
+		err = c.configDevice.validateDevice(meta, devname, device["type"], device)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -154,35 +387,58 @@ func (c *cmdConfigDeviceAdd) Run(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
+		before := copyDevices(profile.Devices)
+
 		if profile.Devices == nil {
 			profile.Devices = make(map[string]map[string]string)
 		}
 
-		_, ok := profile.Devices[devname]
-		if ok {
-			return fmt.Errorf(i18n.G("The device already exists"))
+		for devname, device := range devices {
+			_, ok := profile.Devices[devname]
+			if ok {
+				return fmt.Errorf(i18n.G("The device %q already exists"), devname)
+			}
+
+			profile.Devices[devname] = device
 		}
 
-		profile.Devices[devname] = device
+		if c.configDevice.flagDryRun {
+			return c.configDevice.dryRunOnce(resource.name, before, profile.Devices, nil)
+		}
 
 		err = resource.server.UpdateProfile(resource.name, profile.Writable(), etag)
 		if err != nil {
 			return err
 		}
-	} else {
-		inst, etag, err := resource.server.GetInstance(resource.name)
+
+		return c.printAdded(resource.server, resource.name, devices, resource.name)
+	}
+
+	return c.configDevice.runInstances(resource.name, func(name string) error {
+		inst, etag, err := resource.server.GetInstance(name)
 		if err != nil {
 			return err
 		}
 
-		_, ok := inst.Devices[devname]
-		if ok {
-			return fmt.Errorf(i18n.G("The device already exists"))
+		before, origins, err := c.configDevice.dryRunState(resource.server, inst)
+		if err != nil {
+			return err
+		}
+
+		for devname, device := range devices {
+			_, ok := inst.Devices[devname]
+			if ok {
+				return fmt.Errorf(i18n.G("The device %q already exists"), devname)
+			}
+
+			inst.Devices[devname] = device
 		}
 
-		inst.Devices[devname] = device
+		if c.configDevice.flagDryRun {
+			return c.configDevice.dryRun(name, before, inst.Devices, origins)
+		}
 
-		op, err := resource.server.UpdateInstance(resource.name, inst.Writable(), etag)
+		op, err := resource.server.UpdateInstance(name, inst.Writable(), etag)
 		if err != nil {
 			return err
 		}
@@ -191,10 +447,27 @@ func (c *cmdConfigDeviceAdd) Run(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+
+		return c.printAdded(resource.server, name, devices, c.configDevice.targetName(name, c.flagTarget))
+	})
+}
+
+// printAdded reports the devices added to the named object, shown as target.
+func (c *cmdConfigDeviceAdd) printAdded(server incus.InstanceServer, name string, devices map[string]map[string]string, target string) error {
+	names := make([]string, 0, len(devices))
+	for devname := range devices {
+		names = append(names, devname)
 	}
 
-	if !c.global.flagQuiet {
-		fmt.Printf(i18n.G("Device %s added to %s")+"\n", devname, resource.name)
+	sort.Strings(names)
+
+	done, err := c.configDevice.printResult(server, name, "add", names...)
+	if done || c.global.flagQuiet {
+		return err
+	}
+
+	for _, devname := range names {
+		fmt.Printf(i18n.G("Device %s added to %s")+"\n", devname, target)
 	}
 
 	return nil
@@ -206,6 +479,8 @@ type cmdConfigDeviceGet struct {
 	config       *cmdConfig
 	configDevice *cmdConfigDevice
 	profile      *cmdProfile
+
+	flagExpanded bool
 }
 
 func (c *cmdConfigDeviceGet) Command() *cobra.Command {
@@ -220,6 +495,10 @@ func (c *cmdConfigDeviceGet) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Get values for device configuration keys`))
 
+	if c.config != nil {
+		cmd.Flags().BoolVarP(&c.flagExpanded, "expanded", "e", false, i18n.G("Read devices inherited from profiles too"))
+	}
+
 	cmd.RunE = c.Run
 
 	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -239,6 +518,14 @@ func (c *cmdConfigDeviceGet) Command() *cobra.Command {
 			}
 		}
 
+		if len(args) == 2 {
+			if c.config != nil {
+				return c.global.cmpInstanceDeviceConfigs(args[0], args[1], false)
+			} else if c.profile != nil {
+				return c.global.cmpProfileDeviceConfigs(args[0], args[1], false)
+			}
+		}
+
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
@@ -288,12 +575,14 @@ func (c *cmdConfigDeviceGet) Run(cmd *cobra.Command, args []string) error {
 
 		dev, ok := inst.Devices[devname]
 		if !ok {
-			_, ok = inst.ExpandedDevices[devname]
+			dev, ok = inst.ExpandedDevices[devname]
 			if !ok {
 				return fmt.Errorf(i18n.G("Device doesn't exist"))
 			}
 
-			return fmt.Errorf(i18n.G("Device from profile(s) cannot be retrieved for individual instance"))
+			if !c.flagExpanded {
+				return fmt.Errorf(i18n.G("Device from profile(s) cannot be retrieved for individual instance"))
+			}
 		}
 
 		fmt.Println(dev[key])
@@ -302,167 +591,807 @@ func (c *cmdConfigDeviceGet) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// List.
-type cmdConfigDeviceList struct {
-	global       *cmdGlobal
-	config       *cmdConfig
-	configDevice *cmdConfigDevice
-	profile      *cmdProfile
-}
+// deviceMetadata returns the configuration metadata used to validate devices,
+// or nil if the server doesn't provide it.
+func (c *cmdConfigDevice) deviceMetadata(resource remoteResource) *api.MetadataConfiguration {
+	if !resource.server.HasExtension("metadata_configuration") {
+		return nil
+	}
 
-func (c *cmdConfigDeviceList) Command() *cobra.Command {
-	cmd := &cobra.Command{}
-	cmd.Aliases = []string{"ls"}
-	cmd.Short = i18n.G("List instance devices")
-	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`List instance devices`))
-	if c.config != nil {
-		cmd.Use = usage("list", i18n.G("[<remote>:]<instance>"))
-	} else if c.profile != nil {
-		cmd.Use = usage("list", i18n.G("[<remote>:]<profile>"))
+	meta, err := c.global.getMetadataConfiguration(resource)
+	if err != nil {
+		return nil
 	}
 
-	cmd.RunE = c.Run
+	return meta
+}
 
-	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) == 0 {
-			if c.config != nil {
-				return c.global.cmpInstances(toComplete)
-			} else if c.profile != nil {
-				return c.global.cmpProfiles(toComplete, true)
-			}
-		}
+// validateDeviceType checks that a new device is of a known type.
+func (c *cmdConfigDevice) validateDeviceType(meta *api.MetadataConfiguration, deviceType string) error {
+	if meta == nil {
+		return nil
+	}
 
-		return nil, cobra.ShellCompDirectiveNoFileComp
+	types := deviceTypes(meta)
+	if !slices.Contains(types, deviceType) {
+		return fmt.Errorf(i18n.G("Unknown device type %q (valid types are: %s)"), deviceType, strings.Join(types, ", "))
 	}
 
-	return cmd
+	return nil
 }
 
-func (c *cmdConfigDeviceList) Run(cmd *cobra.Command, args []string) error {
-	// Quick checks.
-	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
-	if exit {
-		return err
+// validateDevice checks the given configuration keys against the server configuration metadata of the device type.
+// Undocumented keys only cause a warning unless --strict is set.
+func (c *cmdConfigDevice) validateDevice(meta *api.MetadataConfiguration, devname string, deviceType string, config map[string]string) error {
+	if meta == nil {
+		return nil
 	}
 
-	// Parse remote
-	resources, err := c.global.ParseServers(args[0])
+	// Not all device types are documented.
+	documented, err := meta.GetKeys("devices", deviceMetadataGroup(deviceType))
 	if err != nil {
-		return err
-	}
-
-	resource := resources[0]
-
-	if resource.name == "" {
-		return fmt.Errorf(i18n.G("Missing name"))
+		return nil
 	}
 
-	// List the devices
-	var devices []string
-	if c.profile != nil {
-		profile, _, err := resource.server.GetProfile(resource.name)
-		if err != nil {
-			return err
+	unknown := []string{}
+	for k, v := range config {
+		// Empty values unset keys.
+		if k == "type" || v == "" {
+			continue
 		}
 
-		for k := range profile.Devices {
-			devices = append(devices, k)
+		_, ok := documented[k]
+		if ok {
+			continue
 		}
-	} else {
-		inst, _, err := resource.server.GetInstance(resource.name)
-		if err != nil {
-			return err
+
+		found := false
+		for key := range documented {
+			prefix, ok := strings.CutSuffix(key, "*")
+			if ok && strings.HasPrefix(k, prefix) {
+				found = true
+				break
+			}
 		}
 
-		for k := range inst.Devices {
-			devices = append(devices, k)
+		if !found {
+			unknown = append(unknown, k)
 		}
 	}
 
-	fmt.Printf("%s\n", strings.Join(devices, "\n"))
+	if len(unknown) == 0 {
+		return nil
+	}
 
-	return nil
-}
+	sort.Strings(unknown)
 
-// Override.
-type cmdConfigDeviceOverride struct {
-	global       *cmdGlobal
-	config       *cmdConfig
-	configDevice *cmdConfigDevice
-	profile      *cmdProfile
-}
+	if c.flagStrict {
+		return fmt.Errorf(i18n.G("Unknown configuration keys for %s device %q: %s"), deviceType, devname, strings.Join(unknown, ", "))
+	}
 
-func (c *cmdConfigDeviceOverride) Command() *cobra.Command {
-	cmd := &cobra.Command{}
-	cmd.Use = usage("override", i18n.G("[<remote>:]<instance> <device> [key=value...]"))
-	cmd.Short = i18n.G("Copy profile inherited devices and override configuration keys")
-	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`Copy profile inherited devices and override configuration keys`))
+	fmt.Fprintf(os.Stderr, i18n.G("Warning: Unknown configuration keys for %s device %q: %s")+"\n", deviceType, devname, strings.Join(unknown, ", "))
 
-	cmd.RunE = c.Run
+	return nil
+}
 
-	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) == 0 {
-			return c.global.cmpInstances(toComplete)
+// copyDevices returns a deep copy of devices.
+func copyDevices(devices map[string]map[string]string) map[string]map[string]string {
+	newDevices := make(map[string]map[string]string, len(devices))
+	for name, device := range devices {
+		newDevice := make(map[string]string, len(device))
+		for k, v := range device {
+			newDevice[k] = v
 		}
 
-		return nil, cobra.ShellCompDirectiveNoFileComp
+		newDevices[name] = newDevice
 	}
 
-	return cmd
+	return newDevices
 }
 
-func (c *cmdConfigDeviceOverride) Run(cmd *cobra.Command, args []string) error {
-	// Quick checks.
-	exit, err := c.global.CheckArgs(cmd, args, 2, -1)
-	if exit {
-		return err
+// dryRunState returns what is needed to report the changes about to be made to the instance devices with --dry-run.
+func (c *cmdConfigDevice) dryRunState(server incus.InstanceServer, inst *api.Instance) (map[string]map[string]string, map[string]string, error) {
+	if !c.flagDryRun {
+		return nil, nil, nil
 	}
 
-	// Parse remote
-	resources, err := c.global.ParseServers(args[0])
+	origins, err := c.deviceOrigins(server, inst)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	resource := resources[0]
+	return copyDevices(inst.Devices), origins, nil
+}
 
-	if resource.name == "" {
-		return fmt.Errorf(i18n.G("Missing name"))
+// dryRun prints the changes going from the before to the after devices of target would make,
+// along with the profile devices that would get overridden as a result.
+func (c *cmdConfigDevice) dryRun(target string, before map[string]map[string]string, after map[string]map[string]string, origins map[string]string) error {
+	oldData, err := yaml.Marshal(before)
+	if err != nil {
+		return err
 	}
 
-	// Override the device
-	inst, etag, err := resource.server.GetInstance(resource.name)
+	newData, err := yaml.Marshal(after)
 	if err != nil {
 		return err
 	}
 
-	devname := args[1]
-	_, ok := inst.Devices[devname]
-	if ok {
-		return fmt.Errorf(i18n.G("The device already exists"))
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(oldData)),
+		B:        difflib.SplitLines(string(newData)),
+		FromFile: target,
+		ToFile:   target,
+		Context:  3,
+	})
+	if err != nil {
+		return err
 	}
 
-	device, ok := inst.ExpandedDevices[devname]
-	if !ok {
-		return fmt.Errorf(i18n.G("The profile device doesn't exist"))
+	// Print everything at once as several instances may be handled concurrently.
+	var out strings.Builder
+	if diff == "" {
+		out.WriteString(fmt.Sprintf(i18n.G("No changes to %s")+"\n", target))
+		fmt.Print(out.String())
+
+		return nil
 	}
 
-	if len(args) > 2 {
-		for _, prop := range args[2:] {
-			results := strings.SplitN(prop, "=", 2)
-			if len(results) != 2 {
-				return fmt.Errorf(i18n.G("No value found in %q"), prop)
-			}
+	c.dryRunChanged.Store(true)
+	out.WriteString(diff)
 
-			k := results[0]
-			v := results[1]
-			device[k] = v
+	names := []string{}
+	for name := range after {
+		_, ok := before[name]
+		if !ok && origins[name] != "" {
+			names = append(names, name)
 		}
 	}
 
-	inst.Devices[devname] = device
+	if len(names) > 0 {
+		sort.Strings(names)
+
+		out.WriteString(i18n.G("Devices inherited from profiles that would be overridden:") + "\n")
+		for _, name := range names {
+			out.WriteString(fmt.Sprintf("  - "+i18n.G("%s (profile %s)")+"\n", name, origins[name]))
+		}
+	}
+
+	fmt.Print(out.String())
+
+	return nil
+}
+
+// dryRunOnce is dryRun for commands modifying a single instance or profile.
+func (c *cmdConfigDevice) dryRunOnce(target string, before map[string]map[string]string, after map[string]map[string]string, origins map[string]string) error {
+	err := c.dryRun(target, before, after, origins)
+	if err != nil {
+		return err
+	}
+
+	c.dryRunDone()
+
+	return nil
+}
+
+// dryRunDone sets a distinct exit code when a dry-run wouldn't have changed anything.
+func (c *cmdConfigDevice) dryRunDone() {
+	if c.flagDryRun && !c.dryRunChanged.Load() {
+		c.global.ret = configDeviceDryRunNoChange
+	}
+}
+
+// useTarget points the server of resource to the given cluster member, if any.
+func (c *cmdConfigDevice) useTarget(resource *remoteResource, target string) error {
+	if target == "" {
+		return nil
+	}
+
+	if c.profile != nil {
+		return fmt.Errorf(i18n.G("--target cannot be used with profiles"))
+	}
+
+	if !resource.server.IsClustered() {
+		return fmt.Errorf(i18n.G("To use --target, the destination remote must be a cluster"))
+	}
+
+	resource.server = resource.server.UseTarget(target)
+
+	return nil
+}
+
+// targetName returns how an instance is referred to in messages, including the targeted cluster member if any.
+func (c *cmdConfigDevice) targetName(name string, target string) string {
+	if target == "" {
+		return name
+	}
+
+	return fmt.Sprintf(i18n.G("%s on cluster member %s"), name, target)
+}
+
+// configDeviceBatchLimit is how many instances get updated at once when several are given.
+const configDeviceBatchLimit = 10
+
+// runInstances runs action against each of the comma-separated instances in names.
+// All the instances are attempted, the failures being reported at the end.
+func (c *cmdConfigDevice) runInstances(names string, action func(name string) error) error {
+	instances := strings.Split(names, ",")
+	if len(instances) == 1 {
+		err := action(instances[0])
+		if err != nil {
+			return err
+		}
+
+		c.dryRunDone()
+
+		return nil
+	}
+
+	results := runBatchLimited(instances, configDeviceBatchLimit, action)
+
+	failed := []batchResult{}
+	for _, result := range results {
+		if result.err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	if len(failed) == 0 {
+		c.dryRunDone()
+
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, i18n.G("Failed on %d out of %d instances:")+"\n", len(failed), len(instances))
+	for _, result := range failed {
+		fmt.Fprintf(os.Stderr, "  %s: %v\n", result.name, result.err)
+	}
+
+	return fmt.Errorf(i18n.G("Some instances failed to be updated"))
+}
+
+// deviceOrigins returns the name of the profile each of the instance's profile-inherited devices comes from.
+func (c *cmdConfigDevice) deviceOrigins(server incus.InstanceServer, inst *api.Instance) (map[string]string, error) {
+	profiles := make([]api.Profile, 0, len(inst.Profiles))
+	for _, profileName := range inst.Profiles {
+		profile, _, err := server.GetProfile(profileName)
+		if err != nil {
+			return nil, err
+		}
+
+		profiles = append(profiles, *profile)
+	}
+
+	return profileDeviceOrigins(inst, profiles), nil
+}
+
+// profileDeviceOrigins attributes the instance's profile-inherited devices to the profile they come from.
+// Profiles are walked in the order they are applied so that, like on the server, the last profile
+// defining a device wins. Profiles whose device matches the expanded one are preferred, in case
+// a profile was changed since the instance was retrieved.
+func profileDeviceOrigins(inst *api.Instance, profiles []api.Profile) map[string]string {
+	origins := map[string]string{}
+	matches := map[string]string{}
+
+	for _, profile := range profiles {
+		for name, device := range profile.Devices {
+			_, ok := inst.Devices[name]
+			if ok {
+				continue
+			}
+
+			origins[name] = profile.Name
+			if maps.Equal(device, inst.ExpandedDevices[name]) {
+				matches[name] = profile.Name
+			}
+		}
+	}
+
+	maps.Copy(origins, matches)
+
+	return origins
+}
+
+// configDeviceResult is the machine-readable result of a device change.
+type configDeviceResult struct {
+	Object string            `json:"object"`
+	Device string            `json:"device"`
+	Action string            `json:"action"`
+	Config map[string]string `json:"config,omitempty"`
+}
+
+// checkFormat validates the --format flag of the subcommands changing devices.
+func (c *cmdConfigDevice) checkFormat() error {
+	switch c.flagFormat {
+	case "":
+		return nil
+	case "json":
+		if c.flagDryRun {
+			return fmt.Errorf(i18n.G("--format can't be used with --dry-run"))
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf(i18n.G("Invalid format %q"), c.flagFormat)
+}
+
+// printResult prints the result of the action on the devices of the named object as JSON,
+// with their configuration fetched again from the server. It returns false without --format.
+func (c *cmdConfigDevice) printResult(server incus.InstanceServer, name string, action string, devnames ...string) (bool, error) {
+	if c.flagFormat == "" {
+		return false, nil
+	}
+
+	var devices map[string]map[string]string
+	if c.profile != nil {
+		profile, _, err := server.GetProfile(name)
+		if err != nil {
+			return true, err
+		}
+
+		devices = profile.Devices
+	} else {
+		inst, _, err := server.GetInstance(name)
+		if err != nil {
+			return true, err
+		}
+
+		devices = inst.Devices
+	}
+
+	sort.Strings(devnames)
+
+	for _, devname := range devnames {
+		data, err := json.Marshal(configDeviceResult{
+			Object: name,
+			Device: devname,
+			Action: action,
+			Config: devices[devname],
+		})
+		if err != nil {
+			return true, err
+		}
+
+		fmt.Println(string(data))
+	}
+
+	return true, nil
+}
+
+// checkRootDisk refuses to remove or replace the device holding the root filesystem unless forced,
+// warning about the consequences. A nil newDevice means the device gets removed.
+func (c *cmdConfigDevice) checkRootDisk(devices map[string]map[string]string, devname string, newDevice map[string]string, force bool) error {
+	if force || !instance.HoldsRootDisk(devices, devname) || !instance.AffectsRootDisk(devices[devname], newDevice) {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, i18n.G("WARNING: Device %s holds the root filesystem, instances relying on it will fail to start without another root disk device")+"\n", devname)
+
+	return fmt.Errorf(i18n.G("Use --force to change the root disk device %s"), devname)
+}
+
+// liveKeys returns the configuration keys of the device type that take effect immediately on running instances,
+// as documented by the server.
+func (c *cmdConfigDevice) liveKeys(meta *api.MetadataConfiguration, deviceType string) []string {
+	if meta == nil {
+		return nil
+	}
+
+	documented, err := meta.GetKeys("devices", deviceMetadataGroup(deviceType))
+	if err != nil {
+		return nil
+	}
+
+	keys := []string{}
+	for k, key := range documented {
+		if key.LiveUpdate == "yes" {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// confirmLiveChange warns when changing a device of a running instance from device to newDevice affects keys
+// taking effect immediately and asks for confirmation unless forced. A nil newDevice means the device gets removed.
+func (c *cmdConfigDevice) confirmLiveChange(inst *api.Instance, meta *api.MetadataConfiguration, devname string, device map[string]string, newDevice map[string]string, force bool, batch bool) error {
+	if force || c.flagDryRun || inst.StatusCode != api.Running {
+		return nil
+	}
+
+	changed := []string{}
+	for _, k := range c.liveKeys(meta, device["type"]) {
+		if newDevice == nil || device[k] != newDevice[k] {
+			changed = append(changed, k)
+		}
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, i18n.G("WARNING: Instance %s is running, changing %s of device %s takes effect immediately and may detach and re-attach it")+"\n", inst.Name, strings.Join(changed, ", "), devname)
+
+	if batch || !termios.IsTerminal(getStdinFd()) {
+		return fmt.Errorf(i18n.G("Use --force to change device %s of running instance %s"), devname, inst.Name)
+	}
+
+	ok, err := c.global.asker.AskBool(i18n.G("Apply the change now?")+" (yes/no) [default=no]: ", "no")
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf(i18n.G("Device %s of %s left unchanged"), devname, inst.Name)
+	}
+
+	return nil
+}
+
+// applyDeviceKeys returns a copy of the device with the given keys applied, empty values unsetting them.
+func applyDeviceKeys(device map[string]string, keys map[string]string) map[string]string {
+	result := maps.Clone(device)
+	for k, v := range keys {
+		if v == "" {
+			delete(result, k)
+			continue
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
+
+// deviceSummary returns a short description of the main properties of a device.
+func (c *cmdConfigDevice) deviceSummary(device map[string]string) string {
+	var keys []string
+	switch device["type"] {
+	case "disk":
+		keys = []string{"source", "path"}
+	case "nic":
+		keys = []string{"network", "parent"}
+	}
+
+	summary := []string{}
+	for _, key := range keys {
+		if device[key] != "" {
+			summary = append(summary, fmt.Sprintf("%s=%s", key, device[key]))
+		}
+	}
+
+	return strings.Join(summary, ", ")
+}
+
+// List.
+type cmdConfigDeviceList struct {
+	global       *cmdGlobal
+	config       *cmdConfig
+	configDevice *cmdConfigDevice
+	profile      *cmdProfile
+
+	flagFormat   string
+	flagExpanded bool
+	flagFilter   []string
+}
+
+type configDeviceListEntry struct {
+	Name   string            `json:"name" yaml:"name"`
+	Type   string            `json:"type" yaml:"type"`
+	Origin string            `json:"origin,omitempty" yaml:"origin,omitempty"`
+	Config map[string]string `json:"config" yaml:"config"`
+}
+
+func (c *cmdConfigDeviceList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List instance devices")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List instance devices
+
+Without --format, only the device names are printed.
+With --expanded, devices inherited from profiles are included and shown in a table
+along with their origin, either "local" or the profile they come from.
+
+Filters use the same syntax as "incus list": key=value matches a configuration key
+("name" being the device name) and anything else matches the device name.
+Values can use "*" as a wildcard or be regular expressions, several filters all have to match.`))
+	if c.config != nil {
+		cmd.Use = usage("list", i18n.G("[<remote>:]<instance>"))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus config device list c1 --filter type=proxy --filter connect=*:443*
+    List the proxy devices of c1 connecting to port 443.`))
+		cmd.Flags().BoolVarP(&c.flagExpanded, "expanded", "e", false, i18n.G("Include devices inherited from profiles"))
+	} else if c.profile != nil {
+		cmd.Use = usage("list", i18n.G("[<remote>:]<profile>"))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus profile device list default --filter type=disk
+    List the disk devices of the default profile.`))
+	}
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+	cmd.Flags().StringArrayVar(&c.flagFilter, "filter", nil, i18n.G("Only list devices matching the filter (key=value)")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			if c.config != nil {
+				return c.global.cmpInstances(toComplete)
+			} else if c.profile != nil {
+				return c.global.cmpProfiles(toComplete, true)
+			}
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+func (c *cmdConfigDeviceList) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing name"))
+	}
+
+	// List the devices
+	var devices map[string]map[string]string
+	origins := map[string]string{}
+	if c.profile != nil {
+		profile, _, err := resource.server.GetProfile(resource.name)
+		if err != nil {
+			return err
+		}
+
+		devices = profile.Devices
+	} else {
+		inst, _, err := resource.server.GetInstance(resource.name)
+		if err != nil {
+			return err
+		}
+
+		devices = inst.Devices
+		if c.flagExpanded {
+			devices = inst.ExpandedDevices
+
+			origins, err = c.configDevice.deviceOrigins(resource.server, inst)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, device := range devices {
+		if !instance.DeviceMatchesFilters(name, device, c.flagFilter) {
+			delete(devices, name)
+		}
+	}
+
+	if c.flagFormat == "" && c.flagExpanded {
+		c.flagFormat = "table"
+	}
+
+	if c.flagFormat == "" {
+		names := make([]string, 0, len(devices))
+		for k := range devices {
+			names = append(names, k)
+		}
+
+		fmt.Printf("%s\n", strings.Join(names, "\n"))
+
+		return nil
+	}
+
+	entries := make([]configDeviceListEntry, 0, len(devices))
+	data := [][]string{}
+	for name, device := range devices {
+		entry := configDeviceListEntry{
+			Name:   name,
+			Type:   device["type"],
+			Config: device,
+		}
+
+		details := []string{name, device["type"], c.configDevice.deviceSummary(device)}
+		if c.flagExpanded {
+			entry.Origin = origins[name]
+			if entry.Origin == "" {
+				entry.Origin = "local"
+			}
+
+			details = append(details, entry.Origin)
+		}
+
+		entries = append(entries, entry)
+
+		data = append(data, details)
+	}
+
+	sort.Sort(cli.SortColumnsNaturally(data))
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("TYPE"),
+		i18n.G("SUMMARY"),
+	}
+
+	if c.flagExpanded {
+		header = append(header, i18n.G("ORIGIN"))
+	}
+
+	return cli.RenderTable(c.flagFormat, header, data, entries)
+}
+
+// Override.
+type cmdConfigDeviceOverride struct {
+	global       *cmdGlobal
+	config       *cmdConfig
+	configDevice *cmdConfigDevice
+	profile      *cmdProfile
+
+	flagTarget      string
+	flagFromProfile string
+	flagForce       bool
+}
+
+func (c *cmdConfigDeviceOverride) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	if c.config != nil {
+		cmd.Use = usage("override", i18n.G("[<remote>:]<instance> <device> [key=value...]"))
+		cmd.Short = i18n.G("Copy profile inherited devices and override configuration keys")
+		cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+			`Copy profile inherited devices and override configuration keys`))
+
+		cmd.Flags().StringVar(&c.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+		cmd.Flags().BoolVar(&c.flagForce, "force", false, i18n.G("Don't ask before changing devices of running instances"))
+	} else if c.profile != nil {
+		cmd.Use = usage("override", i18n.G("[<remote>:]<profile> [<source profile>/]<device> [key=value...]"))
+		cmd.Short = i18n.G("Copy devices from another profile and override configuration keys")
+		cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+			`Copy devices from another profile and override configuration keys
+
+The source profile is either given in front of the device name or with --from-profile.`))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus profile device override gpu-large base/gpu pci=0000:01:00.0
+    Copy the gpu device of profile base into profile gpu-large and set its PCI address.`))
+
+		cmd.Flags().StringVar(&c.flagFromProfile, "from-profile", "", i18n.G("Profile to copy the device from")+"``")
+	}
+
+	cmd.Flags().BoolVar(&c.configDevice.flagDryRun, "dry-run", false, i18n.G("Show the changes that would be made without applying them"))
+	cmd.Flags().StringVar(&c.configDevice.flagFormat, "format", "", i18n.G("Format of the result (json)")+"``")
+
+	cmd.Flags().BoolVar(&c.configDevice.flagStrict, "strict", false, i18n.G("Fail on undocumented device configuration keys instead of warning"))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			if c.config != nil {
+				return c.global.cmpInstances(toComplete)
+			} else if c.profile != nil {
+				return c.global.cmpProfiles(toComplete, true)
+			}
+		}
+
+		if len(args) == 1 && c.profile != nil {
+			if c.flagFromProfile != "" {
+				return c.global.cmpProfileDeviceNames(c.flagFromProfile)
+			}
+
+			return c.global.cmpProfileDevicePaths(args[0], toComplete)
+		}
+
+		if len(args) > 1 {
+			results, directive, ok := c.global.cmpDeviceDiskValues(args[0], args[2:], toComplete)
+			if ok {
+				return results, directive
+			}
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+func (c *cmdConfigDeviceOverride) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, -1)
+	if exit {
+		return err
+	}
+
+	err = c.configDevice.checkFormat()
+	if err != nil {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing name"))
+	}
+
+	err = c.configDevice.useTarget(&resource, c.flagTarget)
+	if err != nil {
+		return err
+	}
+
+	keys, err := c.overrideKeys(args[2:])
+	if err != nil {
+		return err
+	}
+
+	if c.profile != nil {
+		return c.runProfile(resource, args[1], keys)
+	}
+
+	// Override the device
+	inst, etag, err := resource.server.GetInstance(resource.name)
+	if err != nil {
+		return err
+	}
+
+	before, origins, err := c.configDevice.dryRunState(resource.server, inst)
+	if err != nil {
+		return err
+	}
+
+	devname := args[1]
+	_, ok := inst.Devices[devname]
+	if ok {
+		return fmt.Errorf(i18n.G("The device already exists"))
+	}
+
+	device, ok := inst.ExpandedDevices[devname]
+	if !ok {
+		return fmt.Errorf(i18n.G("The profile device doesn't exist"))
+	}
+
+	meta := c.configDevice.deviceMetadata(resource)
+
+	err = c.configDevice.validateDevice(meta, devname, device["type"], keys)
+	if err != nil {
+		return err
+	}
+
+	err = c.configDevice.confirmLiveChange(inst, meta, devname, device, applyDeviceKeys(device, keys), c.flagForce, false)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range keys {
+		device[k] = v
+	}
+
+	inst.Devices[devname] = device
+
+	if c.configDevice.flagDryRun {
+		return c.configDevice.dryRunOnce(resource.name, before, inst.Devices, origins)
+	}
 
 	op, err := resource.server.UpdateInstance(resource.name, inst.Writable(), etag)
 	if err != nil {
@@ -474,8 +1403,109 @@ func (c *cmdConfigDeviceOverride) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	done, err := c.configDevice.printResult(resource.server, resource.name, "override", devname)
+	if done {
+		return err
+	}
+
 	if !c.global.flagQuiet {
-		fmt.Printf(i18n.G("Device %s overridden for %s")+"\n", devname, resource.name)
+		fmt.Printf(i18n.G("Device %s overridden for %s")+"\n", devname, c.configDevice.targetName(resource.name, c.flagTarget))
+	}
+
+	return nil
+}
+
+// overrideKeys parses the configuration keys to override.
+func (c *cmdConfigDeviceOverride) overrideKeys(args []string) (map[string]string, error) {
+	keys := map[string]string{}
+	for _, prop := range args {
+		results := strings.SplitN(prop, "=", 2)
+		if len(results) != 2 {
+			return nil, fmt.Errorf(i18n.G("No value found in %q"), prop)
+		}
+
+		k := results[0]
+		v := results[1]
+		keys[k] = v
+	}
+
+	return keys, nil
+}
+
+// runProfile copies a device of another profile into the profile, overriding the given keys.
+func (c *cmdConfigDeviceOverride) runProfile(resource remoteResource, source string, keys map[string]string) error {
+	srcProfile := c.flagFromProfile
+	devname := source
+	if srcProfile == "" {
+		var ok bool
+		srcProfile, devname, ok = strings.Cut(source, "/")
+		if !ok || srcProfile == "" || devname == "" {
+			return fmt.Errorf(i18n.G("A source profile is required, either as <profile>/<device> or with --from-profile"))
+		}
+	}
+
+	if srcProfile == resource.name {
+		return fmt.Errorf(i18n.G("The source and target profiles must be different"))
+	}
+
+	err := instance.ValidDeviceName(devname)
+	if err != nil {
+		return err
+	}
+
+	profile, etag, err := resource.server.GetProfile(resource.name)
+	if err != nil {
+		return err
+	}
+
+	_, ok := profile.Devices[devname]
+	if ok {
+		return fmt.Errorf(i18n.G("The device already exists"))
+	}
+
+	src, _, err := resource.server.GetProfile(srcProfile)
+	if err != nil {
+		return err
+	}
+
+	device, ok := src.Devices[devname]
+	if !ok {
+		return fmt.Errorf(i18n.G("Device %s doesn't exist in profile %s"), devname, srcProfile)
+	}
+
+	err = c.configDevice.validateDevice(c.configDevice.deviceMetadata(resource), devname, device["type"], keys)
+	if err != nil {
+		return err
+	}
+
+	before := copyDevices(profile.Devices)
+
+	for k, v := range keys {
+		device[k] = v
+	}
+
+	if profile.Devices == nil {
+		profile.Devices = map[string]map[string]string{}
+	}
+
+	profile.Devices[devname] = device
+
+	if c.configDevice.flagDryRun {
+		return c.configDevice.dryRunOnce(resource.name, before, profile.Devices, nil)
+	}
+
+	err = resource.server.UpdateProfile(resource.name, profile.Writable(), etag)
+	if err != nil {
+		return err
+	}
+
+	done, err := c.configDevice.printResult(resource.server, resource.name, "override", devname)
+	if done {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Device %s copied from %s to %s")+"\n", devname, srcProfile, resource.name)
 	}
 
 	return nil
@@ -487,20 +1517,45 @@ type cmdConfigDeviceRemove struct {
 	config       *cmdConfig
 	configDevice *cmdConfigDevice
 	profile      *cmdProfile
+
+	flagAll   bool
+	flagType  string
+	flagForce bool
 }
 
 func (c *cmdConfigDeviceRemove) Command() *cobra.Command {
 	cmd := &cobra.Command{}
 	if c.config != nil {
-		cmd.Use = usage("remove", i18n.G("[<remote>:]<instance> <name>..."))
+		cmd.Use = usage("remove", i18n.G("[<remote>:]<instance>[,<instance>...] [<name>...]"))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus config device remove c1 eth1 eth2
+    Remove the eth1 and eth2 devices from instance c1.
+
+incus config device remove c1 --type=disk
+    Remove all local disk devices from instance c1.
+
+incus config device remove c1 --all --force
+    Remove all local devices from instance c1 without asking for confirmation.`))
 	} else if c.profile != nil {
-		cmd.Use = usage("remove", i18n.G("[<remote>:]<profile> <name>..."))
+		cmd.Use = usage("remove", i18n.G("[<remote>:]<profile> [<name>...]"))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus profile device remove p1 --type=nic
+    Remove all nic devices from profile p1.`))
 	}
 
 	cmd.Aliases = []string{"rm"}
 	cmd.Short = i18n.G("Remove instance devices")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`Remove instance devices`))
+		`Remove instance devices
+
+Devices can either be given by name or selected with --all and --type.
+Devices inherited from profiles are never selected when removing from an instance.`))
+
+	cmd.Flags().BoolVar(&c.configDevice.flagDryRun, "dry-run", false, i18n.G("Show the changes that would be made without applying them"))
+	cmd.Flags().StringVar(&c.configDevice.flagFormat, "format", "", i18n.G("Format of the result (json)")+"``")
+	cmd.Flags().BoolVar(&c.flagAll, "all", false, i18n.G("Remove all devices"))
+	cmd.Flags().StringVar(&c.flagType, "type", "", i18n.G("Only remove devices of the given type")+"``")
+	cmd.Flags().BoolVarP(&c.flagForce, "force", "f", false, i18n.G("Don't ask for confirmation and allow removing the root disk device or devices of running instances"))
 
 	cmd.RunE = c.Run
 
@@ -526,12 +1581,28 @@ func (c *cmdConfigDeviceRemove) Command() *cobra.Command {
 }
 
 func (c *cmdConfigDeviceRemove) Run(cmd *cobra.Command, args []string) error {
+	selecting := c.flagAll || c.flagType != ""
+
 	// Quick checks.
-	exit, err := c.global.CheckArgs(cmd, args, 2, -1)
+	minArgs := 2
+	if selecting {
+		minArgs = 1
+	}
+
+	exit, err := c.global.CheckArgs(cmd, args, minArgs, -1)
 	if exit {
 		return err
 	}
 
+	err = c.configDevice.checkFormat()
+	if err != nil {
+		return err
+	}
+
+	if selecting && len(args) > 1 {
+		return fmt.Errorf(i18n.G("Device names can't be given together with --all or --type"))
+	}
+
 	// Parse remote
 	resources, err := c.global.ParseServers(args[0])
 	if err != nil {
@@ -551,26 +1622,91 @@ func (c *cmdConfigDeviceRemove) Run(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		for _, devname := range args[1:] {
+		before := copyDevices(profile.Devices)
+
+		devnames, err := c.selectDevices(profile.Devices, args[1:])
+		if err != nil {
+			return err
+		}
+
+		for _, devname := range devnames {
 			_, ok := profile.Devices[devname]
 			if !ok {
 				return fmt.Errorf(i18n.G("Device doesn't exist"))
 			}
 
+			err = c.configDevice.checkRootDisk(before, devname, nil, c.flagForce)
+			if err != nil {
+				return err
+			}
+
 			delete(profile.Devices, devname)
 		}
 
-		err = resource.server.UpdateProfile(resource.name, profile.Writable(), etag)
+		if c.configDevice.flagDryRun {
+			return c.configDevice.dryRunOnce(resource.name, before, profile.Devices, nil)
+		}
+
+		ok, err := c.confirm(before, devnames, resource.name, false)
+		if err != nil || !ok {
+			return err
+		}
+
+		// Only remove the devices themselves when the server allows it.
+		if resource.server.HasExtension("device_patch") {
+			for _, devname := range devnames {
+				err = resource.server.DeleteProfileDevice(resource.name, devname, c.flagForce)
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			err = resource.server.UpdateProfile(resource.name, profile.Writable(), etag)
+			if err != nil {
+				return err
+			}
+		}
+
+		done, err := c.configDevice.printResult(resource.server, resource.name, "remove", devnames...)
+		if done {
+			return err
+		}
+
+		if !c.global.flagQuiet {
+			fmt.Printf(i18n.G("Device %s removed from %s")+"\n", strings.Join(devnames, ", "), resource.name)
+		}
+
+		return nil
+	}
+
+	// Asking once per instance doesn't work when several of them are processed at the same time.
+	batch := strings.Contains(resource.name, ",")
+	if batch && selecting && !c.flagForce && !c.configDevice.flagDryRun {
+		return fmt.Errorf(i18n.G("--force is required when selecting devices on several instances"))
+	}
+
+	meta := c.configDevice.deviceMetadata(resource)
+
+	return c.configDevice.runInstances(resource.name, func(name string) error {
+		inst, etag, err := resource.server.GetInstance(name)
 		if err != nil {
 			return err
 		}
-	} else {
-		inst, etag, err := resource.server.GetInstance(resource.name)
+
+		before, origins, err := c.configDevice.dryRunState(resource.server, inst)
+		if err != nil {
+			return err
+		}
+
+		// Only local devices are selected, those inherited from profiles can't be removed here.
+		devnames, err := c.selectDevices(inst.Devices, args[1:])
 		if err != nil {
 			return err
 		}
 
-		for _, devname := range args[1:] {
+		local := copyDevices(inst.Devices)
+
+		for _, devname := range devnames {
 			_, ok := inst.Devices[devname]
 			if !ok {
 				_, ok := inst.ExpandedDevices[devname]
@@ -581,25 +1717,113 @@ func (c *cmdConfigDeviceRemove) Run(cmd *cobra.Command, args []string) error {
 				return fmt.Errorf(i18n.G("Device from profile(s) cannot be removed from individual instance. Override device or modify profile instead"))
 			}
 
+			err = c.configDevice.checkRootDisk(inst.ExpandedDevices, devname, nil, c.flagForce)
+			if err != nil {
+				return err
+			}
+
+			err = c.configDevice.confirmLiveChange(inst, meta, devname, inst.Devices[devname], nil, c.flagForce, batch)
+			if err != nil {
+				return err
+			}
+
 			delete(inst.Devices, devname)
 		}
 
-		op, err := resource.server.UpdateInstance(resource.name, inst.Writable(), etag)
-		if err != nil {
+		if c.configDevice.flagDryRun {
+			return c.configDevice.dryRun(name, before, inst.Devices, origins)
+		}
+
+		ok, err := c.confirm(local, devnames, name, batch)
+		if err != nil || !ok {
 			return err
 		}
 
-		err = op.Wait()
-		if err != nil {
+		// Only remove the devices themselves when the server allows it.
+		if resource.server.HasExtension("device_patch") {
+			for _, devname := range devnames {
+				op, err := resource.server.DeleteInstanceDevice(name, devname, c.flagForce)
+				if err != nil {
+					return err
+				}
+
+				err = op.Wait()
+				if err != nil {
+					return err
+				}
+			}
+		} else {
+			op, err := resource.server.UpdateInstance(name, inst.Writable(), etag)
+			if err != nil {
+				return err
+			}
+
+			err = op.Wait()
+			if err != nil {
+				return err
+			}
+		}
+
+		done, err := c.configDevice.printResult(resource.server, name, "remove", devnames...)
+		if done {
 			return err
 		}
+
+		if !c.global.flagQuiet {
+			fmt.Printf(i18n.G("Device %s removed from %s")+"\n", strings.Join(devnames, ", "), name)
+		}
+
+		return nil
+	})
+}
+
+// selectDevices returns the names of the devices to remove, either those given as arguments
+// or those selected by --all and --type.
+func (c *cmdConfigDeviceRemove) selectDevices(devices map[string]map[string]string, names []string) ([]string, error) {
+	if !c.flagAll && c.flagType == "" {
+		return names, nil
+	}
+
+	selected := []string{}
+	for devname, device := range devices {
+		if c.flagType != "" && device["type"] != c.flagType {
+			continue
+		}
+
+		selected = append(selected, devname)
 	}
 
-	if !c.global.flagQuiet {
-		fmt.Printf(i18n.G("Device %s removed from %s")+"\n", strings.Join(args[1:], ", "), resource.name)
+	if len(selected) == 0 {
+		if c.flagType != "" {
+			return nil, fmt.Errorf(i18n.G("No %s devices to remove"), c.flagType)
+		}
+
+		return nil, fmt.Errorf(i18n.G("No devices to remove"))
 	}
 
-	return nil
+	sort.Strings(selected)
+
+	return selected, nil
+}
+
+// confirm lists the devices selected by --all or --type and asks whether to remove them.
+// Devices given by name and --force don't need any confirmation.
+func (c *cmdConfigDeviceRemove) confirm(devices map[string]map[string]string, devnames []string, name string, batch bool) (bool, error) {
+	if (!c.flagAll && c.flagType == "") || c.flagForce || batch {
+		return true, nil
+	}
+
+	// Keep the output machine-readable.
+	if c.configDevice.flagFormat != "" {
+		return false, fmt.Errorf(i18n.G("--force is required with --format when selecting devices"))
+	}
+
+	fmt.Printf(i18n.G("The following devices will be removed from %s:")+"\n", name)
+	for _, devname := range devnames {
+		fmt.Printf("  - %s (%s)\n", devname, devices[devname]["type"])
+	}
+
+	return c.global.asker.AskBool(i18n.G("Remove them?")+" (yes/no) [default=no]: ", "no")
 }
 
 // Set.
@@ -608,27 +1832,52 @@ type cmdConfigDeviceSet struct {
 	config       *cmdConfig
 	configDevice *cmdConfigDevice
 	profile      *cmdProfile
+
+	flagTarget        string
+	flagAllowOverride bool
+	flagForce         bool
 }
 
 func (c *cmdConfigDeviceSet) Command() *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.Short = i18n.G("Set device configuration keys")
 	if c.config != nil {
-		cmd.Use = usage("set", i18n.G("[<remote>:]<instance> <device> <key>=<value>..."))
+		cmd.Use = usage("set", i18n.G("[<remote>:]<instance>[,<instance>...] <device> <key>=<value>..."))
 		cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 			`Set device configuration keys
 
+Arguments of the form <key>==<value> only let the update through when the key
+currently has that value, an empty value requiring the key to be unset.
+
 For backward compatibility, a single configuration key may still be set with:
     incus config device set [<remote>:]<instance> <device> <key> <value>`))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus config device set c1 eth0 mtu==1500 mtu=9000
+    Set the MTU of eth0 to 9000 only if it is still 1500.`))
 	} else if c.profile != nil {
 		cmd.Use = usage("set", i18n.G("[<remote>:]<profile> <device> <key>=<value>..."))
 		cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 			`Set device configuration keys
 
+Arguments of the form <key>==<value> only let the update through when the key
+currently has that value, an empty value requiring the key to be unset.
+
 For backward compatibility, a single configuration key may still be set with:
     incus profile device set [<remote>:]<profile> <device> <key> <value>`))
 	}
 
+	if c.config != nil {
+		cmd.Flags().BoolVar(&c.flagAllowOverride, "allow-override", false, i18n.G("Override devices inherited from profiles on the instance instead of failing"))
+	}
+
+	cmd.Flags().StringVar(&c.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.Flags().BoolVar(&c.flagForce, "force", false, i18n.G("Allow changes to the root disk device and don't ask before changing devices of running instances"))
+
+	cmd.Flags().BoolVar(&c.configDevice.flagDryRun, "dry-run", false, i18n.G("Show the changes that would be made without applying them"))
+	cmd.Flags().StringVar(&c.configDevice.flagFormat, "format", "", i18n.G("Format of the result (json)")+"``")
+
+	cmd.Flags().BoolVar(&c.configDevice.flagStrict, "strict", false, i18n.G("Fail on undocumented device configuration keys instead of warning"))
+
 	cmd.RunE = c.Run
 
 	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -648,6 +1897,19 @@ For backward compatibility, a single configuration key may still be set with:
 			}
 		}
 
+		if len(args) >= 2 {
+			results, directive, ok := c.global.cmpDeviceDiskValues(args[0], args[2:], toComplete)
+			if ok {
+				return results, directive
+			}
+
+			if c.config != nil {
+				return c.global.cmpInstanceDeviceConfigs(args[0], args[1], true)
+			} else if c.profile != nil {
+				return c.global.cmpProfileDeviceConfigs(args[0], args[1], true)
+			}
+		}
+
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
@@ -661,6 +1923,11 @@ func (c *cmdConfigDeviceSet) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	err = c.configDevice.checkFormat()
+	if err != nil {
+		return err
+	}
+
 	// Parse remote
 	resources, err := c.global.ParseServers(args[0])
 	if err != nil {
@@ -673,49 +1940,177 @@ func (c *cmdConfigDeviceSet) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf(i18n.G("Missing name"))
 	}
 
+	err = c.configDevice.useTarget(&resource, c.flagTarget)
+	if err != nil {
+		return err
+	}
+
 	// Set the device config key
 	devname := args[1]
 
-	keys, err := getConfig(args[2:]...)
+	guards, pairs := c.splitGuards(args[2:])
+	if len(pairs) == 0 {
+		return fmt.Errorf(i18n.G("No configuration keys to set"))
+	}
+
+	keys, err := getConfig(pairs...)
 	if err != nil {
 		return err
 	}
 
+	meta := c.configDevice.deviceMetadata(resource)
+
 	if c.profile != nil {
 		profile, etag, err := resource.server.GetProfile(resource.name)
 		if err != nil {
 			return err
 		}
 
+		before := copyDevices(profile.Devices)
+
 		dev, ok := profile.Devices[devname]
 		if !ok {
 			return fmt.Errorf(i18n.G("Device doesn't exist"))
 		}
 
+		err = c.checkGuards(devname, dev, guards)
+		if err != nil {
+			return err
+		}
+
+		err = c.configDevice.validateDevice(meta, devname, dev["type"], keys)
+		if err != nil {
+			return err
+		}
+
+		err = c.configDevice.checkRootDisk(profile.Devices, devname, applyDeviceKeys(dev, keys), c.flagForce)
+		if err != nil {
+			return err
+		}
+
+		// Only send the modified keys when the server allows it.
+		// Guarded updates rely on the ETag to catch concurrent changes.
+		if !c.configDevice.flagDryRun && len(guards) == 0 && resource.server.HasExtension("device_patch") {
+			err = resource.server.UpdateProfileDevice(resource.name, devname, keys, c.flagForce)
+			if err != nil {
+				return err
+			}
+
+			_, err = c.configDevice.printResult(resource.server, resource.name, cmd.Name(), devname)
+
+			return err
+		}
+
 		for k, v := range keys {
 			dev[k] = v
 		}
 
 		profile.Devices[devname] = dev
 
+		if c.configDevice.flagDryRun {
+			return c.configDevice.dryRunOnce(resource.name, before, profile.Devices, nil)
+		}
+
 		err = resource.server.UpdateProfile(resource.name, profile.Writable(), etag)
 		if err != nil {
 			return err
 		}
-	} else {
-		inst, etag, err := resource.server.GetInstance(resource.name)
+
+		_, err = c.configDevice.printResult(resource.server, resource.name, cmd.Name(), devname)
+
+		return err
+	}
+
+	batch := strings.Contains(resource.name, ",")
+
+	// Interrupting cancels the pending instance updates.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return c.configDevice.runInstances(resource.name, func(name string) error {
+		inst, etag, err := resource.server.GetInstance(name)
+		if err != nil {
+			return err
+		}
+
+		before, origins, err := c.configDevice.dryRunState(resource.server, inst)
 		if err != nil {
 			return err
 		}
 
 		dev, ok := inst.Devices[devname]
+		if ok {
+			err = c.checkGuards(devname, dev, guards)
+			if err != nil {
+				return err
+			}
+
+			err = c.configDevice.validateDevice(meta, devname, dev["type"], keys)
+			if err != nil {
+				return err
+			}
+
+			err = c.configDevice.checkRootDisk(inst.ExpandedDevices, devname, applyDeviceKeys(dev, keys), c.flagForce)
+			if err != nil {
+				return err
+			}
+
+			err = c.configDevice.confirmLiveChange(inst, meta, devname, dev, applyDeviceKeys(dev, keys), c.flagForce, batch)
+			if err != nil {
+				return err
+			}
+		}
+
+		if ok && !c.configDevice.flagDryRun && len(guards) == 0 && resource.server.HasExtension("device_patch") {
+			// Only send the modified keys when the server allows it.
+			// Guarded updates rely on the ETag to catch concurrent changes.
+			op, err := resource.server.UpdateInstanceDevice(name, devname, keys, c.flagForce)
+			if err != nil {
+				return err
+			}
+
+			err = op.WaitProgress(ctx, nil)
+			if err != nil {
+				return err
+			}
+
+			return c.printUpdated(resource.server, cmd.Name(), devname, name, batch)
+		}
+
 		if !ok {
-			_, ok = inst.ExpandedDevices[devname]
+			dev, ok = inst.ExpandedDevices[devname]
 			if !ok {
 				return fmt.Errorf(i18n.G("Device doesn't exist"))
 			}
 
-			return fmt.Errorf(i18n.G("Device from profile(s) cannot be modified for individual instance. Override device or modify profile instead"))
+			override, err := c.confirmOverride(devname, name, batch)
+			if err != nil {
+				return err
+			}
+
+			if !override {
+				return fmt.Errorf(i18n.G("Device from profile(s) cannot be modified for individual instance. Override device or modify profile instead"))
+			}
+
+			err = c.checkGuards(devname, dev, guards)
+			if err != nil {
+				return err
+			}
+
+			err = c.configDevice.validateDevice(meta, devname, dev["type"], keys)
+			if err != nil {
+				return err
+			}
+
+			err = c.configDevice.checkRootDisk(inst.ExpandedDevices, devname, applyDeviceKeys(dev, keys), c.flagForce)
+			if err != nil {
+				return err
+			}
+
+			err = c.configDevice.confirmLiveChange(inst, meta, devname, dev, applyDeviceKeys(dev, keys), c.flagForce, batch)
+			if err != nil {
+				return err
+			}
 		}
 
 		for k, v := range keys {
@@ -724,39 +2119,121 @@ func (c *cmdConfigDeviceSet) Run(cmd *cobra.Command, args []string) error {
 
 		inst.Devices[devname] = dev
 
-		op, err := resource.server.UpdateInstance(resource.name, inst.Writable(), etag)
+		if c.configDevice.flagDryRun {
+			return c.configDevice.dryRun(name, before, inst.Devices, origins)
+		}
+
+		op, err := resource.server.UpdateInstance(name, inst.Writable(), etag)
 		if err != nil {
 			return err
 		}
 
-		err = op.Wait()
+		err = op.WaitProgress(ctx, nil)
 		if err != nil {
 			return err
 		}
+
+		return c.printUpdated(resource.server, cmd.Name(), devname, name, batch)
+	})
+}
+
+// splitGuards separates the <key>==<value> guards from the configuration keys to set.
+// The backward compatible <key> <value> form never holds any guard.
+func (c *cmdConfigDeviceSet) splitGuards(args []string) (map[string]string, []string) {
+	if len(args) == 2 && !strings.Contains(args[0], "=") {
+		return nil, args
+	}
+
+	guards := map[string]string{}
+	pairs := []string{}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "==")
+		if !ok || key == "" || strings.Contains(key, "=") {
+			pairs = append(pairs, arg)
+			continue
+		}
+
+		guards[key] = value
+	}
+
+	return guards, pairs
+}
+
+// checkGuards makes sure the device still has the values expected by the guards.
+func (c *cmdConfigDeviceSet) checkGuards(devname string, device map[string]string, guards map[string]string) error {
+	keys := make([]string, 0, len(guards))
+	for key := range guards {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if device[key] != guards[key] {
+			return fmt.Errorf(i18n.G("Device %s has %s=%q instead of the expected %q"), devname, key, device[key], guards[key])
+		}
+	}
+
+	return nil
+}
+
+// printUpdated reports the update of an instance device.
+func (c *cmdConfigDeviceSet) printUpdated(server incus.InstanceServer, action string, devname string, name string, batch bool) error {
+	done, err := c.configDevice.printResult(server, name, action, devname)
+	if done {
+		return err
+	}
+
+	// Only confirm the update when there are several instances to keep track of or a member was targeted.
+	if (batch || c.flagTarget != "") && !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Device %s updated on %s")+"\n", devname, c.configDevice.targetName(name, c.flagTarget))
 	}
 
 	return nil
 }
 
+// confirmOverride checks whether a device inherited from a profile should be overridden on the instance.
+// Without --allow-override, the user is asked when attached to a terminal and not updating several instances.
+func (c *cmdConfigDeviceSet) confirmOverride(devname string, name string, batch bool) (bool, error) {
+	if c.flagAllowOverride {
+		return true, nil
+	}
+
+	if batch || !termios.IsTerminal(getStdinFd()) {
+		return false, nil
+	}
+
+	return c.global.asker.AskBool(fmt.Sprintf(i18n.G("Device %s of %s comes from a profile, override it on the instance?"), devname, name)+" (yes/no) [default=no]: ", "no")
+}
+
 // Show.
 type cmdConfigDeviceShow struct {
 	global       *cmdGlobal
 	config       *cmdConfig
 	configDevice *cmdConfigDevice
 	profile      *cmdProfile
+
+	flagFormat   string
+	flagExpanded bool
 }
 
 func (c *cmdConfigDeviceShow) Command() *cobra.Command {
 	cmd := &cobra.Command{}
 	if c.config != nil {
 		cmd.Use = usage("show", i18n.G("[<remote>:]<instance>"))
+		cmd.Flags().BoolVarP(&c.flagExpanded, "expanded", "e", false, i18n.G("Include devices inherited from profiles"))
 	} else if c.profile != nil {
 		cmd.Use = usage("show", i18n.G("[<remote>:]<profile>"))
 	}
 
 	cmd.Short = i18n.G("Show full device configuration")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`Show full device configuration`))
+		`Show full device configuration
+
+With --expanded, the YAML output marks each device inherited from a profile
+with a comment naming that profile.`))
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
 
 	cmd.RunE = c.Run
 
@@ -794,8 +2271,13 @@ func (c *cmdConfigDeviceShow) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf(i18n.G("Missing name"))
 	}
 
+	if c.flagFormat != "json" && c.flagFormat != "yaml" {
+		return fmt.Errorf(i18n.G("Invalid format %q"), c.flagFormat)
+	}
+
 	// Show the devices
 	var devices map[string]map[string]string
+	origins := map[string]string{}
 	if c.profile != nil {
 		profile, _, err := resource.server.GetProfile(resource.name)
 		if err != nil {
@@ -810,14 +2292,58 @@ func (c *cmdConfigDeviceShow) Run(cmd *cobra.Command, args []string) error {
 		}
 
 		devices = inst.Devices
+		if c.flagExpanded {
+			devices = inst.ExpandedDevices
+
+			origins, err = c.configDevice.deviceOrigins(resource.server, inst)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	data, err := yaml.Marshal(&devices)
-	if err != nil {
-		return err
+	if c.flagFormat == "json" {
+		data, err := json.MarshalIndent(devices, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+
+		return nil
 	}
 
-	fmt.Print(string(data))
+	if len(origins) == 0 {
+		data, err := yaml.Marshal(&devices)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(string(data))
+
+		return nil
+	}
+
+	// Render the devices one at a time so the inherited ones can be annotated.
+	names := make([]string, 0, len(devices))
+	for name := range devices {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		data, err := yaml.Marshal(map[string]map[string]string{name: devices[name]})
+		if err != nil {
+			return err
+		}
+
+		if origins[name] != "" {
+			fmt.Printf("# "+i18n.G("From profile %q")+"\n", origins[name])
+		}
+
+		fmt.Print(string(data))
+	}
 
 	return nil
 }
@@ -834,14 +2360,25 @@ type cmdConfigDeviceUnset struct {
 func (c *cmdConfigDeviceUnset) Command() *cobra.Command {
 	cmd := &cobra.Command{}
 	if c.config != nil {
-		cmd.Use = usage("unset", i18n.G("[<remote>:]<instance> <device> <key>"))
+		cmd.Use = usage("unset", i18n.G("[<remote>:]<instance> <device> <key>..."))
 	} else if c.profile != nil {
-		cmd.Use = usage("unset", i18n.G("[<remote>:]<profile> <device> <key>"))
+		cmd.Use = usage("unset", i18n.G("[<remote>:]<profile> <device> <key>..."))
 	}
 
 	cmd.Short = i18n.G("Unset device configuration keys")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`Unset device configuration keys`))
+		`Unset device configuration keys
+
+All the given keys are unset in a single update.`))
+
+	if c.config != nil {
+		cmd.Flags().BoolVar(&c.configDeviceSet.flagAllowOverride, "allow-override", false, i18n.G("Override devices inherited from profiles on the instance instead of failing"))
+	}
+
+	cmd.Flags().BoolVar(&c.configDeviceSet.flagForce, "force", false, i18n.G("Allow changes to the root disk device and don't ask before changing devices of running instances"))
+
+	cmd.Flags().BoolVar(&c.configDevice.flagDryRun, "dry-run", false, i18n.G("Show the changes that would be made without applying them"))
+	cmd.Flags().StringVar(&c.configDevice.flagFormat, "format", "", i18n.G("Format of the result (json)")+"``")
 
 	cmd.RunE = c.Run
 
@@ -862,6 +2399,14 @@ func (c *cmdConfigDeviceUnset) Command() *cobra.Command {
 			}
 		}
 
+		if len(args) >= 2 {
+			if c.config != nil {
+				return c.global.cmpInstanceDeviceConfigs(args[0], args[1], false)
+			} else if c.profile != nil {
+				return c.global.cmpProfileDeviceConfigs(args[0], args[1], false)
+			}
+		}
+
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
@@ -870,11 +2415,15 @@ func (c *cmdConfigDeviceUnset) Command() *cobra.Command {
 
 func (c *cmdConfigDeviceUnset) Run(cmd *cobra.Command, args []string) error {
 	// Quick checks.
-	exit, err := c.global.CheckArgs(cmd, args, 3, 3)
+	exit, err := c.global.CheckArgs(cmd, args, 3, -1)
 	if exit {
 		return err
 	}
 
-	args = append(args, "")
-	return c.configDeviceSet.Run(cmd, args)
+	setArgs := []string{args[0], args[1]}
+	for _, key := range args[2:] {
+		setArgs = append(setArgs, key+"=")
+	}
+
+	return c.configDeviceSet.Run(cmd, setArgs)
 }
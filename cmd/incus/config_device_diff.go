@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	cli "github.com/lxc/incus/v6/internal/cmd"
+	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/shared/termios"
+)
+
+// Diff.
+type cmdConfigDeviceDiff struct {
+	global       *cmdGlobal
+	config       *cmdConfig
+	configDevice *cmdConfigDevice
+	profile      *cmdProfile
+
+	flagFormat string
+}
+
+// configDeviceDiffKey is a configuration key differing between two devices.
+type configDeviceDiffKey struct {
+	Key string `json:"key"`
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// configDeviceDiffEntry is a device differing between two objects.
+type configDeviceDiffEntry struct {
+	Device string                `json:"device"`
+	Status string                `json:"status"`
+	Keys   []configDeviceDiffKey `json:"keys"`
+}
+
+// configDeviceDiffResult is the machine-readable result of a device diff.
+type configDeviceDiffResult struct {
+	From    string                  `json:"from"`
+	To      string                  `json:"to"`
+	Devices []configDeviceDiffEntry `json:"devices"`
+}
+
+func (c *cmdConfigDeviceDiff) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	if c.config != nil {
+		cmd.Use = usage("diff", i18n.G("[<remote>:]<instance> [<remote>:]<instance>"))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus config device diff inst1 inst2
+    Compare the devices of inst1 with those of inst2, including the ones inherited from profiles
+
+incus config device diff inst1 profile/default
+    Compare the devices of inst1 with those of the default profile`))
+	} else if c.profile != nil {
+		cmd.Use = usage("diff", i18n.G("[<remote>:]<profile> [<remote>:]<profile>"))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus profile device diff default other
+    Compare the devices of the default profile with those of the other profile
+
+incus profile device diff default instance/inst1
+    Compare the devices of the default profile with those of inst1`))
+	}
+
+	cmd.Short = i18n.G("Compare the devices of two instances or profiles")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Compare the devices of two instances or profiles
+
+Instances are compared using their devices inherited from profiles too.
+Prefix the name with "profile/" or "instance/" to compare against the other kind of object.
+
+The command exits with a non-zero status when the devices differ.`))
+
+	cmd.Flags().StringVar(&c.flagFormat, "format", "", i18n.G("Format (json)")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		if c.config != nil {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return c.global.cmpProfiles(toComplete, true)
+	}
+
+	return cmd
+}
+
+// devices returns the devices of the object referred to by resource along with how to name it.
+// Instances are used by default with "incus config" and profiles with "incus profile",
+// unless the name is prefixed with "instance/" or "profile/".
+func (c *cmdConfigDeviceDiff) devices(resource remoteResource) (map[string]map[string]string, error) {
+	name := resource.name
+	isProfile := c.profile != nil
+
+	after, ok := strings.CutPrefix(name, "profile/")
+	if ok {
+		name = after
+		isProfile = true
+	} else {
+		after, ok = strings.CutPrefix(name, "instance/")
+		if ok {
+			name = after
+			isProfile = false
+		}
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf(i18n.G("Missing name"))
+	}
+
+	if isProfile {
+		profile, _, err := resource.server.GetProfile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return profile.Devices, nil
+	}
+
+	inst, _, err := resource.server.GetInstance(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return inst.ExpandedDevices, nil
+}
+
+func (c *cmdConfigDeviceDiff) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	if c.flagFormat != "" && c.flagFormat != "json" {
+		return fmt.Errorf(i18n.G("Invalid format %q"), c.flagFormat)
+	}
+
+	// Parse remotes
+	resources, err := c.global.ParseServers(args...)
+	if err != nil {
+		return err
+	}
+
+	before, err := c.devices(resources[0])
+	if err != nil {
+		return err
+	}
+
+	after, err := c.devices(resources[1])
+	if err != nil {
+		return err
+	}
+
+	entries := diffDevices(before, after)
+	if len(entries) > 0 {
+		c.global.ret = 1
+	}
+
+	if c.flagFormat == "json" {
+		data, err := json.Marshal(configDeviceDiffResult{From: args[0], To: args[1], Devices: entries})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	if len(entries) == 0 {
+		if !c.global.flagQuiet {
+			fmt.Printf(i18n.G("No device differences between %s and %s")+"\n", args[0], args[1])
+		}
+
+		return nil
+	}
+
+	fmt.Print(formatDeviceDiff(args[0], args[1], entries, termios.IsTerminal(getStdoutFd())))
+
+	return nil
+}
+
+// diffDevices returns the devices added, removed or changed going from the before to the after devices,
+// sorted by name along with their differing keys.
+func diffDevices(before map[string]map[string]string, after map[string]map[string]string) []configDeviceDiffEntry {
+	names := []string{}
+	for name := range before {
+		names = append(names, name)
+	}
+
+	for name := range after {
+		_, ok := before[name]
+		if !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	entries := []configDeviceDiffEntry{}
+	for _, name := range names {
+		oldDevice, inBefore := before[name]
+		newDevice, inAfter := after[name]
+
+		status := "changed"
+		if !inBefore {
+			status = "added"
+		} else if !inAfter {
+			status = "removed"
+		}
+
+		keys := []string{}
+		for key := range oldDevice {
+			keys = append(keys, key)
+		}
+
+		for key := range newDevice {
+			_, ok := oldDevice[key]
+			if !ok {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		entry := configDeviceDiffEntry{Device: name, Status: status, Keys: []configDeviceDiffKey{}}
+		for _, key := range keys {
+			oldValue, inOld := oldDevice[key]
+			newValue, inNew := newDevice[key]
+			if inOld && inNew && oldValue == newValue {
+				continue
+			}
+
+			entry.Keys = append(entry.Keys, configDeviceDiffKey{Key: key, Old: oldValue, New: newValue})
+		}
+
+		if len(entry.Keys) > 0 || status != "changed" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// formatDeviceDiff renders the device differences from the from object to the to object as a unified-style diff,
+// colorized when color is set.
+func formatDeviceDiff(from string, to string, entries []configDeviceDiffEntry, color bool) string {
+	paint := func(code string, line string) string {
+		if !color {
+			return line + "\n"
+		}
+
+		return "\033[" + code + "m" + line + "\033[0m\n"
+	}
+
+	var out strings.Builder
+	out.WriteString(paint("1", "--- "+from))
+	out.WriteString(paint("1", "+++ "+to))
+
+	for _, entry := range entries {
+		out.WriteString(paint("36", fmt.Sprintf("@@ %s (%s) @@", entry.Device, entry.Status)))
+
+		for _, key := range entry.Keys {
+			if entry.Status != "added" && key.Old != "" {
+				out.WriteString(paint("31", fmt.Sprintf("-  %s: %s", key.Key, key.Old)))
+			}
+
+			if entry.Status != "removed" && key.New != "" {
+				out.WriteString(paint("32", fmt.Sprintf("+  %s: %s", key.Key, key.New)))
+			}
+		}
+	}
+
+	return out.String()
+}
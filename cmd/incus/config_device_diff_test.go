@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func TestConfigDeviceDiffDevices(t *testing.T) {
+	before := map[string]map[string]string{
+		"eth0": {"type": "nic", "network": "incusbr0"},
+		"root": {"type": "disk", "path": "/", "pool": "default"},
+		"data": {"type": "disk", "path": "/data", "source": "/srv"},
+	}
+
+	after := map[string]map[string]string{
+		"eth0": {"type": "nic", "network": "incusbr1", "mtu": "1400"},
+		"root": {"type": "disk", "path": "/", "pool": "default"},
+		"gpu":  {"type": "gpu"},
+	}
+
+	assert.Empty(t, diffDevices(before, before))
+
+	entries := diffDevices(before, after)
+	assert.Equal(t, []configDeviceDiffEntry{
+		{Device: "data", Status: "removed", Keys: []configDeviceDiffKey{
+			{Key: "path", Old: "/data"},
+			{Key: "source", Old: "/srv"},
+			{Key: "type", Old: "disk"},
+		}},
+		{Device: "eth0", Status: "changed", Keys: []configDeviceDiffKey{
+			{Key: "mtu", New: "1400"},
+			{Key: "network", Old: "incusbr0", New: "incusbr1"},
+		}},
+		{Device: "gpu", Status: "added", Keys: []configDeviceDiffKey{
+			{Key: "type", New: "gpu"},
+		}},
+	}, entries)
+
+	assert.Equal(t, `--- c1
++++ c2
+@@ data (removed) @@
+-  path: /data
+-  source: /srv
+-  type: disk
+@@ eth0 (changed) @@
++  mtu: 1400
+-  network: incusbr0
++  network: incusbr1
+@@ gpu (added) @@
++  type: gpu
+`, formatDeviceDiff("c1", "c2", entries, false))
+}
+
+func TestConfigDeviceDiffObjects(t *testing.T) {
+	server := &fakeDeviceServer{
+		instances: map[string]*api.Instance{
+			"c1": {Name: "c1", ExpandedDevices: map[string]map[string]string{
+				"eth0": {"type": "nic", "network": "incusbr0"},
+			}},
+		},
+		profiles: map[string]*api.Profile{
+			"default": {Name: "default", ProfilePut: api.ProfilePut{Devices: map[string]map[string]string{
+				"root": {"type": "disk", "pool": "default", "path": "/"},
+			}}},
+		},
+	}
+
+	// Instances are compared with their expanded devices.
+	c := &cmdConfigDeviceDiff{config: &cmdConfig{}}
+	devices, err := c.devices(remoteResource{name: "c1", server: server})
+	assert.NoError(t, err)
+	assert.Contains(t, devices, "eth0")
+
+	devices, err = c.devices(remoteResource{name: "profile/default", server: server})
+	assert.NoError(t, err)
+	assert.Contains(t, devices, "root")
+
+	c = &cmdConfigDeviceDiff{profile: &cmdProfile{}}
+	devices, err = c.devices(remoteResource{name: "default", server: server})
+	assert.NoError(t, err)
+	assert.Contains(t, devices, "root")
+
+	devices, err = c.devices(remoteResource{name: "instance/c1", server: server})
+	assert.NoError(t, err)
+	assert.Contains(t, devices, "eth0")
+
+	_, err = c.devices(remoteResource{name: "profile/", server: server})
+	assert.ErrorContains(t, err, "Missing name")
+}
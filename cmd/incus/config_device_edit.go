@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	cli "github.com/lxc/incus/v6/internal/cmd"
+	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/termios"
+)
+
+// Edit.
+type cmdConfigDeviceEdit struct {
+	global       *cmdGlobal
+	config       *cmdConfig
+	configDevice *cmdConfigDevice
+	profile      *cmdProfile
+}
+
+func (c *cmdConfigDeviceEdit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	if c.config != nil {
+		cmd.Use = usage("edit", i18n.G("[<remote>:]<instance> <device>"))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus config device edit <instance> <device> < device.yaml
+    Update an instance device using the content of device.yaml`))
+	} else if c.profile != nil {
+		cmd.Use = usage("edit", i18n.G("[<remote>:]<profile> <device>"))
+		cmd.Example = cli.FormatSection("", i18n.G(
+			`incus profile device edit <profile> <device> < device.yaml
+    Update a profile device using the content of device.yaml`))
+	}
+
+	cmd.Short = i18n.G("Edit device configurations as YAML")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Edit device configurations as YAML`))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			if c.config != nil {
+				return c.global.cmpInstances(toComplete)
+			} else if c.profile != nil {
+				return c.global.cmpProfiles(toComplete, true)
+			}
+		}
+
+		if len(args) == 1 {
+			if c.config != nil {
+				return c.global.cmpInstanceDeviceNames(args[0])
+			} else if c.profile != nil {
+				return c.global.cmpProfileDeviceNames(args[0])
+			}
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+func (c *cmdConfigDeviceEdit) helpTemplate() string {
+	return i18n.G(
+		`### This is a YAML representation of the device.
+### Any line starting with a '# will be ignored.
+###
+### A device consists of a set of configuration keys, one of which must be its type.
+###
+### An example would look like:
+### nictype: bridged
+### parent: mybr0
+### type: nic`)
+}
+
+// parse turns the edited content back into a device configuration.
+func (c *cmdConfigDeviceEdit) parse(content []byte) (map[string]string, error) {
+	device := map[string]string{}
+	err := yaml.Unmarshal(content, &device)
+	if err != nil {
+		return nil, err
+	}
+
+	if device["type"] == "" {
+		return nil, fmt.Errorf(i18n.G("Missing device type"))
+	}
+
+	return device, nil
+}
+
+func (c *cmdConfigDeviceEdit) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing name"))
+	}
+
+	devname := args[1]
+
+	// Extract the current value
+	var device map[string]string
+	var update func(device map[string]string) error
+	if c.profile != nil {
+		profile, etag, err := resource.server.GetProfile(resource.name)
+		if err != nil {
+			return err
+		}
+
+		dev, ok := profile.Devices[devname]
+		if !ok {
+			return fmt.Errorf(i18n.G("Device doesn't exist"))
+		}
+
+		device = dev
+		update = func(device map[string]string) error {
+			profile.Devices[devname] = device
+
+			return resource.server.UpdateProfile(resource.name, profile.Writable(), etag)
+		}
+	} else {
+		inst, etag, err := resource.server.GetInstance(resource.name)
+		if err != nil {
+			return err
+		}
+
+		dev, ok := inst.Devices[devname]
+		if !ok {
+			_, ok = inst.ExpandedDevices[devname]
+			if !ok {
+				return fmt.Errorf(i18n.G("Device doesn't exist"))
+			}
+
+			return fmt.Errorf(i18n.G("Device from profile(s) cannot be modified for individual instance. Override device or modify profile instead"))
+		}
+
+		device = dev
+		update = func(device map[string]string) error {
+			inst.Devices[devname] = device
+
+			op, err := resource.server.UpdateInstance(resource.name, inst.Writable(), etag)
+			if err != nil {
+				return err
+			}
+
+			return op.Wait()
+		}
+	}
+
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata, err := c.parse(contents)
+		if err != nil {
+			return err
+		}
+
+		return update(newdata)
+	}
+
+	data, err := yaml.Marshal(&device)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := textEditor("", []byte(c.helpTemplate()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata, err := c.parse(content)
+		if err == nil {
+			err = update(newdata)
+
+			// Someone else changed the object in the meantime, editing again won't help.
+			if api.StatusErrorCheck(err, http.StatusPreconditionFailed) {
+				return err
+			}
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again or ctrl+c to abort change"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = textEditor("", content)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		break
+	}
+
+	return nil
+}
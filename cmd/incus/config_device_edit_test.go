@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigDeviceEditParse(t *testing.T) {
+	c := &cmdConfigDeviceEdit{}
+
+	// Comments from the header are ignored.
+	device, err := c.parse([]byte("### Device data\ntype: disk\nsource: /srv\npath: /data\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"type": "disk", "source": "/srv", "path": "/data"}, device)
+
+	_, err = c.parse([]byte("source: /srv\npath: /data\n"))
+	assert.ErrorContains(t, err, "Missing device type")
+
+	_, err = c.parse([]byte("type: [disk\n"))
+	assert.Error(t, err)
+}
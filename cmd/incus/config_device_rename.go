@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	cli "github.com/lxc/incus/v6/internal/cmd"
+	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/internal/instance"
+)
+
+// Rename.
+type cmdConfigDeviceRename struct {
+	global       *cmdGlobal
+	config       *cmdConfig
+	configDevice *cmdConfigDevice
+	profile      *cmdProfile
+}
+
+func (c *cmdConfigDeviceRename) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	if c.config != nil {
+		cmd.Use = usage("rename", i18n.G("[<remote>:]<instance> <old-name> <new-name>"))
+	} else if c.profile != nil {
+		cmd.Use = usage("rename", i18n.G("[<remote>:]<profile> <old-name> <new-name>"))
+	}
+
+	cmd.Aliases = []string{"mv"}
+	cmd.Short = i18n.G("Rename instance devices")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Rename instance devices`))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			if c.config != nil {
+				return c.global.cmpInstances(toComplete)
+			} else if c.profile != nil {
+				return c.global.cmpProfiles(toComplete, true)
+			}
+		}
+
+		if len(args) == 1 {
+			if c.config != nil {
+				return c.global.cmpInstanceDeviceNames(args[0])
+			} else if c.profile != nil {
+				return c.global.cmpProfileDeviceNames(args[0])
+			}
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+func (c *cmdConfigDeviceRename) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 3, 3)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return fmt.Errorf(i18n.G("Missing name"))
+	}
+
+	oldName := args[1]
+	newName := args[2]
+
+	err = instance.ValidDeviceName(newName)
+	if err != nil {
+		return err
+	}
+
+	// Rename the device
+	if c.profile != nil {
+		profile, etag, err := resource.server.GetProfile(resource.name)
+		if err != nil {
+			return err
+		}
+
+		err = renameDevice(profile.Devices, profile.Devices, oldName, newName)
+		if err != nil {
+			return err
+		}
+
+		err = resource.server.UpdateProfile(resource.name, profile.Writable(), etag)
+		if err != nil {
+			return err
+		}
+	} else {
+		inst, etag, err := resource.server.GetInstance(resource.name)
+		if err != nil {
+			return err
+		}
+
+		err = renameDevice(inst.Devices, inst.ExpandedDevices, oldName, newName)
+		if err != nil {
+			return err
+		}
+
+		op, err := resource.server.UpdateInstance(resource.name, inst.Writable(), etag)
+		if err != nil {
+			return err
+		}
+
+		err = op.Wait()
+		if err != nil {
+			return err
+		}
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Device %s renamed to %s on %s")+"\n", oldName, newName, resource.name)
+	}
+
+	return nil
+}
+
+// renameDevice renames the oldName device of devices to newName. Names of devices inherited from profiles,
+// included in expandedDevices, are also refused as the renamed device would then override them.
+func renameDevice(devices map[string]map[string]string, expandedDevices map[string]map[string]string, oldName string, newName string) error {
+	device, ok := devices[oldName]
+	if !ok {
+		_, ok := expandedDevices[oldName]
+		if !ok {
+			return fmt.Errorf(i18n.G("Device doesn't exist"))
+		}
+
+		return fmt.Errorf(i18n.G("Device from profile(s) cannot be renamed on individual instance. Override device or modify profile instead"))
+	}
+
+	_, ok = expandedDevices[newName]
+	if ok {
+		return fmt.Errorf(i18n.G("The device already exists"))
+	}
+
+	devices[newName] = device
+	delete(devices, oldName)
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigDeviceRenameDevice(t *testing.T) {
+	devices := map[string]map[string]string{
+		"root": {"type": "disk", "pool": "default", "path": "/"},
+		"data": {"type": "disk", "source": "/srv", "path": "/data"},
+	}
+
+	expanded := copyDevices(devices)
+	expanded["eth0"] = map[string]string{"type": "nic", "network": "incusbr0"}
+
+	assert.ErrorContains(t, renameDevice(devices, expanded, "gone", "new"), "Device doesn't exist")
+	assert.ErrorContains(t, renameDevice(devices, expanded, "eth0", "lan"), "Device from profile(s) cannot be renamed")
+	assert.ErrorContains(t, renameDevice(devices, expanded, "data", "root"), "The device already exists")
+
+	// Renaming a device over a profile device would override it.
+	assert.ErrorContains(t, renameDevice(devices, expanded, "data", "eth0"), "The device already exists")
+
+	assert.NoError(t, renameDevice(devices, expanded, "data", "srv"))
+	assert.Equal(t, map[string]map[string]string{
+		"root": {"type": "disk", "pool": "default", "path": "/"},
+		"srv":  {"type": "disk", "source": "/srv", "path": "/data"},
+	}, devices)
+
+	// Profiles have no inherited devices.
+	assert.NoError(t, renameDevice(devices, devices, "srv", "data"))
+	assert.Contains(t, devices, "data")
+}
@@ -0,0 +1,681 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func TestConfigDeviceAddDevicesFromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		args    []string
+		want    map[string]map[string]string
+		wantErr string
+	}{
+		{
+			name:    "single device",
+			content: "type: disk\nsource: /srv\npath: /srv\n",
+			args:    []string{"srv"},
+			want:    map[string]map[string]string{"srv": {"type": "disk", "source": "/srv", "path": "/srv"}},
+		},
+		{
+			name:    "single device with overrides",
+			content: `{"type": "proxy", "listen": "tcp:0.0.0.0:80", "connect": "tcp:127.0.0.1:80"}`,
+			args:    []string{"web", "proxy", "listen=tcp:0.0.0.0:8080"},
+			want:    map[string]map[string]string{"web": {"type": "proxy", "listen": "tcp:0.0.0.0:8080", "connect": "tcp:127.0.0.1:80"}},
+		},
+		{
+			name:    "single device with type argument",
+			content: "source: /srv\npath: /srv\n",
+			args:    []string{"srv", "disk"},
+			want:    map[string]map[string]string{"srv": {"type": "disk", "source": "/srv", "path": "/srv"}},
+		},
+		{
+			name:    "single device without name",
+			content: "type: disk\n",
+			wantErr: "device name",
+		},
+		{
+			name:    "single device with different type",
+			content: "type: disk\n",
+			args:    []string{"srv", "nic"},
+			wantErr: "is of type",
+		},
+		{
+			name:    "single device without type",
+			content: "path: /srv\n",
+			args:    []string{"srv"},
+			wantErr: "No type found",
+		},
+		{
+			name:    "several devices",
+			content: "eth0:\n  type: nic\n  network: incusbr0\nroot:\n  type: disk\n  pool: default\n  path: /\n",
+			want: map[string]map[string]string{
+				"eth0": {"type": "nic", "network": "incusbr0"},
+				"root": {"type": "disk", "pool": "default", "path": "/"},
+			},
+		},
+		{
+			name:    "several devices with shared type",
+			content: "srv:\n  source: /srv\n  path: /srv\nopt:\n  type: disk\n  source: /opt\n  path: /opt\n",
+			args:    []string{"disk", "readonly=true"},
+			want: map[string]map[string]string{
+				"srv": {"type": "disk", "source": "/srv", "path": "/srv", "readonly": "true"},
+				"opt": {"type": "disk", "source": "/opt", "path": "/opt", "readonly": "true"},
+			},
+		},
+		{
+			name:    "several devices with different type",
+			content: "eth0:\n  type: nic\n",
+			args:    []string{"disk"},
+			wantErr: "is of type",
+		},
+		{
+			name:    "several devices with name",
+			content: "eth0:\n  type: nic\n",
+			args:    []string{"eth1", "nic"},
+			wantErr: "Only a type",
+		},
+		{
+			name:    "empty file",
+			content: "",
+			args:    []string{"srv"},
+			wantErr: "No device configuration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "devices.yaml")
+			err := os.WriteFile(path, []byte(tt.content), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := cmdConfigDeviceAdd{flagFromFile: path}
+			got, err := c.devicesFromFile(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("devicesFromFile() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("devicesFromFile() unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("devicesFromFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigDeviceValidateDevice(t *testing.T) {
+	meta := &api.MetadataConfiguration{
+		Config: api.MetadataConfig{
+			"devices": {
+				"disk": {Keys: []map[string]api.MetadataConfigKey{
+					{"source": {}},
+					{"path": {}},
+					{"initial.*": {}},
+				}},
+				"unix-char-block": {Keys: []map[string]api.MetadataConfigKey{
+					{"major": {}},
+				}},
+			},
+		},
+	}
+
+	c := &cmdConfigDevice{}
+
+	assert.NoError(t, c.validateDeviceType(meta, "disk"))
+	assert.NoError(t, c.validateDeviceType(meta, "unix-char"))
+	assert.ErrorContains(t, c.validateDeviceType(meta, "disc"), "valid types are: disk, gpu")
+	assert.NoError(t, c.validateDeviceType(nil, "disc"))
+
+	// Documented and wildcard keys.
+	assert.NoError(t, c.validateDevice(meta, "data", "disk", map[string]string{"type": "disk", "source": "/data", "initial.uid": "1000"}))
+
+	// Undocumented device types aren't checked.
+	assert.NoError(t, c.validateDevice(meta, "eth0", "nic", map[string]string{"foo": "bar"}))
+
+	// Unset keys aren't checked.
+	assert.NoError(t, c.validateDevice(meta, "data", "disk", map[string]string{"sourec": ""}))
+
+	c.flagStrict = true
+	assert.ErrorContains(t, c.validateDevice(meta, "data", "disk", map[string]string{"sourec": "/data"}), `Unknown configuration keys for disk device "data": sourec`)
+	assert.ErrorContains(t, c.validateDevice(meta, "tty", "unix-char", map[string]string{"minor": "1"}), "minor")
+}
+
+func TestConfigDeviceRemoveSelectDevices(t *testing.T) {
+	devices := map[string]map[string]string{
+		"eth0": {"type": "nic"},
+		"eth1": {"type": "nic"},
+		"data": {"type": "disk"},
+	}
+
+	c := &cmdConfigDeviceRemove{}
+	got, err := c.selectDevices(devices, []string{"eth1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eth1"}, got)
+
+	c.flagAll = true
+	got, err = c.selectDevices(devices, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"data", "eth0", "eth1"}, got)
+
+	c.flagType = "nic"
+	got, err = c.selectDevices(devices, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eth0", "eth1"}, got)
+
+	c.flagAll = false
+	c.flagType = "gpu"
+	_, err = c.selectDevices(devices, nil)
+	assert.ErrorContains(t, err, "No gpu devices to remove")
+}
+
+func TestConfigDeviceProfileDeviceOrigins(t *testing.T) {
+	inst := &api.Instance{
+		InstancePut: api.InstancePut{
+			Devices: map[string]map[string]string{
+				"root": {"type": "disk", "pool": "fast", "path": "/"},
+			},
+		},
+		ExpandedDevices: map[string]map[string]string{
+			"root": {"type": "disk", "pool": "fast", "path": "/"},
+			"eth0": {"type": "nic", "network": "lan"},
+			"eth1": {"type": "nic", "network": "wan"},
+		},
+	}
+
+	profiles := []api.Profile{
+		{Name: "default", ProfilePut: api.ProfilePut{Devices: map[string]map[string]string{
+			"root": {"type": "disk", "pool": "default", "path": "/"},
+			"eth0": {"type": "nic", "network": "incusbr0"},
+		}}},
+		{Name: "lan", ProfilePut: api.ProfilePut{Devices: map[string]map[string]string{
+			"eth0": {"type": "nic", "network": "lan"},
+		}}},
+		{Name: "wan", ProfilePut: api.ProfilePut{Devices: map[string]map[string]string{
+			"eth1": {"type": "nic", "network": "wan"},
+		}}},
+	}
+
+	assert.Equal(t, map[string]string{"eth0": "lan", "eth1": "wan"}, profileDeviceOrigins(inst, profiles))
+}
+
+func TestConfigDeviceSetGuards(t *testing.T) {
+	c := &cmdConfigDeviceSet{}
+
+	guards, pairs := c.splitGuards([]string{"mtu==1500", "mtu=9000", "name=a==b", "hwaddr=="})
+	assert.Equal(t, map[string]string{"mtu": "1500", "hwaddr": ""}, guards)
+	assert.Equal(t, []string{"mtu=9000", "name=a==b"}, pairs)
+
+	// The backward compatible form never holds guards.
+	guards, pairs = c.splitGuards([]string{"name", "a==b"})
+	assert.Empty(t, guards)
+	assert.Equal(t, []string{"name", "a==b"}, pairs)
+
+	device := map[string]string{"type": "nic", "mtu": "1500"}
+	assert.NoError(t, c.checkGuards("eth0", device, map[string]string{"mtu": "1500", "hwaddr": ""}))
+	assert.ErrorContains(t, c.checkGuards("eth0", device, map[string]string{"mtu": "9000"}), `Device eth0 has mtu="1500" instead of the expected "9000"`)
+}
+
+func TestConfigDeviceCheckRootDisk(t *testing.T) {
+	c := &cmdConfigDevice{}
+
+	devices := map[string]map[string]string{
+		"root": {"type": "disk", "pool": "default", "path": "/"},
+		"data": {"type": "disk", "pool": "default", "source": "data", "path": "/data"},
+	}
+
+	assert.ErrorContains(t, c.checkRootDisk(devices, "root", nil, false), "--force")
+	assert.NoError(t, c.checkRootDisk(devices, "root", nil, true))
+	assert.NoError(t, c.checkRootDisk(devices, "data", nil, false))
+
+	// Resizing the root disk is fine, moving it isn't.
+	assert.NoError(t, c.checkRootDisk(devices, "root", applyDeviceKeys(devices["root"], map[string]string{"size": "20GiB"}), false))
+	assert.Error(t, c.checkRootDisk(devices, "root", applyDeviceKeys(devices["root"], map[string]string{"pool": "fast"}), false))
+	assert.Error(t, c.checkRootDisk(devices, "root", applyDeviceKeys(devices["root"], map[string]string{"path": ""}), false))
+
+	// The only disk backed by a storage pool holds the root filesystem even without a path.
+	devices = map[string]map[string]string{
+		"rootfs": {"type": "disk", "pool": "default"},
+	}
+
+	assert.Error(t, c.checkRootDisk(devices, "rootfs", nil, false))
+}
+
+func TestConfigDeviceConfirmLiveChange(t *testing.T) {
+	meta := &api.MetadataConfiguration{
+		Config: api.MetadataConfig{
+			"devices": {
+				"disk": {Keys: []map[string]api.MetadataConfigKey{
+					{"source": {LiveUpdate: "yes"}},
+					{"limits.read": {}},
+				}},
+			},
+		},
+	}
+
+	c := &cmdConfigDevice{}
+	assert.Equal(t, []string{"source"}, c.liveKeys(meta, "disk"))
+
+	device := map[string]string{"type": "disk", "source": "/srv", "path": "/srv"}
+	inst := &api.Instance{Name: "c1", StatusCode: api.Stopped}
+
+	// Stopped instances don't need any confirmation.
+	assert.NoError(t, c.confirmLiveChange(inst, meta, "srv", device, nil, false, true))
+
+	inst.StatusCode = api.Running
+	assert.NoError(t, c.confirmLiveChange(inst, meta, "srv", device, applyDeviceKeys(device, map[string]string{"limits.read": "10MB"}), false, true))
+	assert.ErrorContains(t, c.confirmLiveChange(inst, meta, "srv", device, applyDeviceKeys(device, map[string]string{"source": "/opt"}), false, true), "--force")
+	assert.ErrorContains(t, c.confirmLiveChange(inst, meta, "srv", device, nil, false, true), "--force")
+	assert.NoError(t, c.confirmLiveChange(inst, meta, "srv", device, nil, true, true))
+}
+
+// Changing the parent of a NIC re-plugs it, so the generated metadata must have it confirmed on running instances.
+func TestConfigDeviceConfirmLiveChangeNIC(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("..", "..", "internal", "server", "metadata", "configuration.json"))
+	require.NoError(t, err)
+
+	meta := &api.MetadataConfiguration{}
+	require.NoError(t, json.Unmarshal(content, meta))
+
+	c := &cmdConfigDevice{}
+	live := c.liveKeys(meta, "nic")
+	assert.Contains(t, live, "parent")
+	assert.NotContains(t, live, "limits.ingress")
+
+	device := map[string]string{"type": "nic", "nictype": "macvlan", "parent": "eth0"}
+	inst := &api.Instance{Name: "c1", StatusCode: api.Running}
+
+	assert.ErrorContains(t, c.confirmLiveChange(inst, meta, "eth0", device, applyDeviceKeys(device, map[string]string{"parent": "eth1"}), false, true), "--force")
+	assert.NoError(t, c.confirmLiveChange(inst, meta, "eth0", device, applyDeviceKeys(device, map[string]string{"parent": "eth1"}), true, true))
+	assert.NoError(t, c.confirmLiveChange(inst, meta, "eth0", device, applyDeviceKeys(device, map[string]string{"limits.ingress": "10Mbit"}), false, true))
+}
+
+// fakeDeviceServer is an instance server only serving the instances and profiles it holds.
+type fakeDeviceServer struct {
+	incus.InstanceServer
+
+	clustered bool
+	target    string
+	instances map[string]*api.Instance
+	profiles  map[string]*api.Profile
+}
+
+func (s *fakeDeviceServer) HasExtension(extension string) bool {
+	return false
+}
+
+func (s *fakeDeviceServer) IsClustered() bool {
+	return s.clustered
+}
+
+func (s *fakeDeviceServer) UseTarget(name string) incus.InstanceServer {
+	server := *s
+	server.target = name
+
+	return &server
+}
+
+func (s *fakeDeviceServer) GetInstance(name string) (*api.Instance, string, error) {
+	inst, ok := s.instances[name]
+	if !ok {
+		return nil, "", api.StatusErrorf(http.StatusNotFound, "Instance not found")
+	}
+
+	newInst := *inst
+	newInst.Devices = copyDevices(inst.Devices)
+	newInst.ExpandedDevices = copyDevices(inst.ExpandedDevices)
+
+	return &newInst, "", nil
+}
+
+func (s *fakeDeviceServer) GetProfile(name string) (*api.Profile, string, error) {
+	profile, ok := s.profiles[name]
+	if !ok {
+		return nil, "", api.StatusErrorf(http.StatusNotFound, "Profile not found")
+	}
+
+	newProfile := *profile
+	newProfile.Devices = copyDevices(profile.Devices)
+
+	return &newProfile, "", nil
+}
+
+func (s *fakeDeviceServer) UpdateProfile(name string, profile api.ProfilePut, ETag string) error {
+	s.profiles[name].ProfilePut = profile
+
+	return nil
+}
+
+// captureStdout returns what f prints on the standard output.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+
+	_ = w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(out)
+}
+
+// configDeviceCommands returns the device subcommands of "incus config" or, with profile set, of "incus profile".
+func configDeviceCommands(profile bool) map[string]*cobra.Command {
+	c := &cmdConfigDevice{global: &cmdGlobal{}}
+	if profile {
+		c.profile = &cmdProfile{global: c.global}
+	} else {
+		c.config = &cmdConfig{global: c.global}
+	}
+
+	cmds := map[string]*cobra.Command{}
+	for _, cmd := range c.Command().Commands() {
+		cmds[cmd.Name()] = cmd
+	}
+
+	return cmds
+}
+
+func TestConfigDeviceFlags(t *testing.T) {
+	tests := []struct {
+		command  string
+		flag     string
+		instance bool
+		profile  bool
+		value    string
+	}{
+		{"add", "dry-run", true, true, "false"},
+		{"add", "format", true, true, ""},
+		{"add", "target", true, true, ""},
+		{"get", "expanded", true, false, "false"},
+		{"list", "expanded", true, false, "false"},
+		{"list", "format", true, true, ""},
+		{"override", "dry-run", true, true, "false"},
+		{"override", "format", true, true, ""},
+		{"override", "from-profile", false, true, ""},
+		{"override", "target", true, false, ""},
+		{"remove", "dry-run", true, true, "false"},
+		{"remove", "format", true, true, ""},
+		{"set", "allow-override", true, false, "false"},
+		{"set", "dry-run", true, true, "false"},
+		{"set", "format", true, true, ""},
+		{"set", "target", true, true, ""},
+		{"show", "expanded", true, false, "false"},
+		{"show", "format", true, true, "yaml"},
+		{"unset", "allow-override", true, false, "false"},
+		{"unset", "dry-run", true, true, "false"},
+		{"unset", "format", true, true, ""},
+	}
+
+	instanceCmds := configDeviceCommands(false)
+	profileCmds := configDeviceCommands(true)
+
+	for _, tt := range tests {
+		for cmd, want := range map[*cobra.Command]bool{instanceCmds[tt.command]: tt.instance, profileCmds[tt.command]: tt.profile} {
+			flag := cmd.Flags().Lookup(tt.flag)
+			if !want {
+				assert.Nil(t, flag, "%s --%s", cmd.Use, tt.flag)
+				continue
+			}
+
+			if assert.NotNil(t, flag, "%s --%s", cmd.Use, tt.flag) {
+				assert.Equal(t, tt.value, flag.DefValue, "%s --%s", cmd.Use, tt.flag)
+			}
+		}
+	}
+
+	// The rename, edit and diff subcommands exist for instances and profiles alike.
+	for _, name := range []string{"diff", "edit", "rename"} {
+		assert.Contains(t, instanceCmds, name)
+		assert.Contains(t, profileCmds, name)
+	}
+
+	assert.Equal(t, []string{"mv"}, instanceCmds["rename"].Aliases)
+}
+
+func TestConfigDeviceSummary(t *testing.T) {
+	c := &cmdConfigDevice{}
+
+	assert.Equal(t, "source=/srv, path=/data", c.deviceSummary(map[string]string{"type": "disk", "source": "/srv", "path": "/data", "readonly": "true"}))
+	assert.Equal(t, "path=/", c.deviceSummary(map[string]string{"type": "disk", "pool": "default", "path": "/"}))
+	assert.Equal(t, "network=incusbr0", c.deviceSummary(map[string]string{"type": "nic", "network": "incusbr0", "name": "eth0"}))
+	assert.Equal(t, "parent=eth0", c.deviceSummary(map[string]string{"type": "nic", "nictype": "macvlan", "parent": "eth0"}))
+	assert.Empty(t, c.deviceSummary(map[string]string{"type": "gpu"}))
+}
+
+func TestConfigDeviceTypes(t *testing.T) {
+	assert.Equal(t, deviceTypeList, deviceTypes(nil))
+
+	meta := &api.MetadataConfiguration{
+		Config: api.MetadataConfig{
+			"devices": {
+				"disk":            {},
+				"unix-char-block": {},
+				"watchdog":        {},
+			},
+		},
+	}
+
+	types := deviceTypes(meta)
+	assert.Contains(t, types, "watchdog")
+	assert.NotContains(t, types, "unix-char-block")
+	assert.Len(t, types, len(deviceTypeList)+1)
+	assert.True(t, sort.StringsAreSorted(types))
+
+	assert.Equal(t, "unix-char-block", deviceMetadataGroup("unix-block"))
+	assert.Equal(t, "disk", deviceMetadataGroup("disk"))
+}
+
+func TestConfigDeviceCompletionKeys(t *testing.T) {
+	meta := &api.MetadataConfiguration{
+		Config: api.MetadataConfig{
+			"devices": {
+				"disk": {Keys: []map[string]api.MetadataConfigKey{
+					{"source": {}},
+					{"path": {}},
+					{"initial.*": {}},
+				}},
+			},
+		},
+	}
+
+	// The metadata is only fetched once per remote.
+	g := &cmdGlobal{metadataCache: map[string]*api.MetadataConfiguration{"local": meta}}
+	resource := remoteResource{remote: "local"}
+
+	keys, directive := g.cmpDeviceConfigKeys(resource, "disk", map[string]string{"type": "disk", "readonly": "true"}, false)
+	assert.ElementsMatch(t, []string{"source", "path", "readonly"}, keys)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	keys, directive = g.cmpDeviceConfigKeys(resource, "disk", nil, true)
+	assert.ElementsMatch(t, []string{"source=", "path="}, keys)
+	assert.Equal(t, cobra.ShellCompDirectiveNoSpace, directive)
+
+	// Undocumented device types only offer the keys already set.
+	keys, _ = g.cmpDeviceConfigKeys(resource, "nic", map[string]string{"type": "nic", "network": "incusbr0"}, false)
+	assert.Equal(t, []string{"network"}, keys)
+
+	// Only the pool and source values of disks are completed, falling back to files without a pool.
+	_, _, ok := g.cmpDeviceDiskValues("c1", nil, "path=")
+	assert.False(t, ok)
+
+	values, directive, ok := g.cmpDeviceDiskValues("c1", []string{"c1", "data", "disk"}, "source=")
+	assert.True(t, ok)
+	assert.Empty(t, values)
+	assert.Equal(t, cobra.ShellCompDirectiveDefault, directive)
+}
+
+func TestConfigDeviceUseTarget(t *testing.T) {
+	c := &cmdConfigDevice{config: &cmdConfig{}}
+
+	resource := remoteResource{name: "c1", server: &fakeDeviceServer{}}
+	assert.NoError(t, c.useTarget(&resource, ""))
+	assert.ErrorContains(t, c.useTarget(&resource, "server01"), "must be a cluster")
+
+	resource.server = &fakeDeviceServer{clustered: true}
+	assert.NoError(t, c.useTarget(&resource, "server01"))
+	assert.Equal(t, "server01", resource.server.(*fakeDeviceServer).target)
+
+	assert.Equal(t, "c1", c.targetName("c1", ""))
+	assert.Equal(t, "c1 on cluster member server01", c.targetName("c1", "server01"))
+
+	c = &cmdConfigDevice{profile: &cmdProfile{}}
+	assert.ErrorContains(t, c.useTarget(&resource, "server01"), "cannot be used with profiles")
+}
+
+func TestConfigDeviceSetConfirmOverride(t *testing.T) {
+	c := &cmdConfigDeviceSet{}
+
+	// Several instances are never prompted for.
+	ok, err := c.confirmOverride("eth0", "c1", true)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	c.flagAllowOverride = true
+	ok, err = c.confirmOverride("eth0", "c1", true)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestConfigDeviceDryRun(t *testing.T) {
+	before := map[string]map[string]string{
+		"root": {"type": "disk", "pool": "default", "path": "/"},
+	}
+
+	after := copyDevices(before)
+	after["eth0"] = map[string]string{"type": "nic", "network": "incusbr1"}
+
+	c := &cmdConfigDevice{global: &cmdGlobal{}, flagDryRun: true}
+	out := captureStdout(t, func() {
+		assert.NoError(t, c.dryRunOnce("c1", before, after, map[string]string{"eth0": "default"}))
+	})
+
+	assert.Contains(t, out, "--- c1\n+++ c1\n")
+	assert.Contains(t, out, "+eth0:\n+  network: incusbr1\n")
+	assert.Contains(t, out, "Devices inherited from profiles that would be overridden:\n  - eth0 (profile default)\n")
+	assert.Equal(t, 0, c.global.ret)
+
+	// Commands which wouldn't change anything exit with a distinct code.
+	c = &cmdConfigDevice{global: &cmdGlobal{}, flagDryRun: true}
+	out = captureStdout(t, func() {
+		assert.NoError(t, c.dryRunOnce("c1", before, copyDevices(before), nil))
+	})
+
+	assert.Equal(t, "No changes to c1\n", out)
+	assert.Equal(t, configDeviceDryRunNoChange, c.global.ret)
+}
+
+func TestConfigDeviceResultFormat(t *testing.T) {
+	c := &cmdConfigDevice{}
+	assert.NoError(t, c.checkFormat())
+
+	c.flagFormat = "yaml"
+	assert.ErrorContains(t, c.checkFormat(), `Invalid format "yaml"`)
+
+	c.flagFormat = "json"
+	assert.NoError(t, c.checkFormat())
+
+	c.flagDryRun = true
+	assert.ErrorContains(t, c.checkFormat(), "--dry-run")
+
+	server := &fakeDeviceServer{
+		instances: map[string]*api.Instance{
+			"c1": {Name: "c1", InstancePut: api.InstancePut{Devices: map[string]map[string]string{
+				"eth0": {"type": "nic", "network": "incusbr0"},
+				"data": {"type": "disk", "source": "/srv", "path": "/data"},
+			}}},
+		},
+	}
+
+	// The human readable messages are printed without --format.
+	c = &cmdConfigDevice{}
+	done, err := c.printResult(server, "c1", "add", "eth0")
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	c.flagFormat = "json"
+	out := captureStdout(t, func() {
+		done, err = c.printResult(server, "c1", "remove", "gone", "data")
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, `{"object":"c1","device":"data","action":"remove","config":{"path":"/data","source":"/srv","type":"disk"}}
+{"object":"c1","device":"gone","action":"remove"}
+`, out)
+}
+
+func TestConfigDeviceOverrideProfile(t *testing.T) {
+	server := &fakeDeviceServer{
+		profiles: map[string]*api.Profile{
+			"base": {Name: "base", ProfilePut: api.ProfilePut{Devices: map[string]map[string]string{
+				"gpu": {"type": "gpu", "gputype": "physical"},
+			}}},
+			"gpu-large": {Name: "gpu-large"},
+		},
+	}
+
+	resource := remoteResource{name: "gpu-large", server: server}
+	c := &cmdConfigDeviceOverride{global: &cmdGlobal{flagQuiet: true}, configDevice: &cmdConfigDevice{}, profile: &cmdProfile{}}
+
+	keys, err := c.overrideKeys([]string{"pci=0000:01:00.0", "id="})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"pci": "0000:01:00.0", "id": ""}, keys)
+
+	_, err = c.overrideKeys([]string{"pci"})
+	assert.ErrorContains(t, err, `No value found in "pci"`)
+
+	assert.ErrorContains(t, c.runProfile(resource, "gpu", nil), "source profile is required")
+	assert.ErrorContains(t, c.runProfile(resource, "/gpu", nil), "source profile is required")
+	assert.ErrorContains(t, c.runProfile(resource, "gpu-large/gpu", nil), "must be different")
+	assert.ErrorContains(t, c.runProfile(resource, "base/eth0", nil), "Device eth0 doesn't exist in profile base")
+
+	assert.NoError(t, c.runProfile(resource, "base/gpu", map[string]string{"pci": "0000:01:00.0"}))
+	assert.Equal(t, map[string]string{"type": "gpu", "gputype": "physical", "pci": "0000:01:00.0"}, server.profiles["gpu-large"].Devices["gpu"])
+	assert.Equal(t, map[string]string{"type": "gpu", "gputype": "physical"}, server.profiles["base"].Devices["gpu"])
+
+	// Devices already in the target profile are never replaced.
+	assert.ErrorContains(t, c.runProfile(resource, "base/gpu", nil), "already exists")
+
+	// The source profile can be given with --from-profile instead.
+	delete(server.profiles["gpu-large"].Devices, "gpu")
+	c.flagFromProfile = "base"
+	assert.NoError(t, c.runProfile(resource, "gpu", nil))
+	assert.Equal(t, map[string]string{"type": "gpu", "gputype": "physical"}, server.profiles["gpu-large"].Devices["gpu"])
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
 	"slices"
 	"strconv"
@@ -27,6 +29,7 @@ type cmdConsole struct {
 	global *cmdGlobal
 
 	flagShowLog bool
+	flagFollow  bool
 	flagType    string
 }
 
@@ -42,6 +45,7 @@ as well as retrieve past log entries from it.`))
 
 	cmd.RunE = c.Run
 	cmd.Flags().BoolVar(&c.flagShowLog, "show-log", false, i18n.G("Retrieve the instance's console log"))
+	cmd.Flags().BoolVarP(&c.flagFollow, "follow", "f", false, i18n.G("Keep showing what the instance writes to its console log (with --show-log)"))
 	cmd.Flags().StringVarP(&c.flagType, "type", "t", "console", i18n.G("Type of connection to establish: 'console' for serial console, 'vga' for SPICE graphical output")+"``")
 
 	return cmd
@@ -123,19 +127,46 @@ func (c *cmdConsole) Run(cmd *cobra.Command, args []string) error {
 	return c.console(d, name)
 }
 
+// followLog copies the followed console log to the standard output until interrupted.
+func (c *cmdConsole) followLog(log io.ReadCloser) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Closing the log stops following it on the server.
+	go func() {
+		<-ctx.Done()
+		_ = log.Close()
+	}()
+
+	_, err := io.Copy(os.Stdout, log)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	return nil
+}
+
 func (c *cmdConsole) console(d incus.InstanceServer, name string) error {
+	if c.flagFollow && !c.flagShowLog {
+		return fmt.Errorf(i18n.G("The --follow flag can only be used with --show-log"))
+	}
+
 	// Show the current log if requested.
 	if c.flagShowLog {
 		if c.flagType != "console" {
 			return fmt.Errorf(i18n.G("The --show-log flag is only supported for by 'console' output type"))
 		}
 
-		console := &incus.InstanceConsoleLogArgs{}
+		console := &incus.InstanceConsoleLogArgs{Follow: c.flagFollow}
 		log, err := d.GetInstanceConsoleLog(name, console)
 		if err != nil {
 			return err
 		}
 
+		if c.flagFollow {
+			return c.followLog(log)
+		}
+
 		content, err := io.ReadAll(log)
 		if err != nil {
 			return err
@@ -0,0 +1,704 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	incus "github.com/lxc/incus/v6/client"
+	cli "github.com/lxc/incus/v6/internal/cmd"
+	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/termios"
+	"github.com/lxc/incus/v6/shared/units"
+)
+
+type cmdDebug struct {
+	global *cmdGlobal
+}
+
+func (c *cmdDebug) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("debug")
+	cmd.Short = i18n.G("Debug commands")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Debug commands for instances and the server`))
+
+	// Memory
+	debugMemoryCmd := cmdDebugMemory{global: c.global, debug: c}
+	cmd.AddCommand(debugMemoryCmd.Command())
+
+	// QEMU monitor
+	debugQemuMonitorCmd := cmdDebugQemuMonitor{global: c.global, debug: c}
+	cmd.AddCommand(debugQemuMonitorCmd.Command())
+
+	// Daemon profile
+	debugDaemonProfileCmd := cmdDebugDaemonProfile{global: c.global, debug: c}
+	cmd.AddCommand(debugDaemonProfileCmd.Command())
+
+	// AppArmor profile
+	debugAppArmorProfileCmd := cmdDebugAppArmorProfile{global: c.global, debug: c}
+	cmd.AddCommand(debugAppArmorProfileCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// debugMemoryFormat is a memory dump format along with the file extension usually holding it.
+type debugMemoryFormat struct {
+	name      string
+	extension string
+}
+
+// debugMemoryFormats lists the memory dump formats, it's used both to validate --format and to infer it from the file name.
+var debugMemoryFormats = []debugMemoryFormat{
+	{name: "elf", extension: ".elf"},
+	{name: "win-dmp", extension: ".dmp"},
+	{name: "kdump-zlib", extension: ".dump"},
+	{name: "kdump-lzo"},
+	{name: "kdump-snappy"},
+	{name: "criu", extension: ".tar"},
+	{name: "core", extension: ".core"},
+}
+
+// debugMemoryFormatFromPath returns the memory dump format matching the extension of path,
+// ignoring any compression suffix, or an empty string if the extension isn't known.
+func debugMemoryFormatFromPath(path string) string {
+	path = strings.TrimSuffix(strings.TrimSuffix(path, ".gz"), ".zst")
+	extension := filepath.Ext(path)
+
+	for _, format := range debugMemoryFormats {
+		if format.extension != "" && format.extension == extension {
+			return format.name
+		}
+	}
+
+	return ""
+}
+
+// Memory.
+type cmdDebugMemory struct {
+	global *cmdGlobal
+	debug  *cmdDebug
+
+	flagFormat    string
+	flagCompress  string
+	flagMaxSize   string
+	flagServer    bool
+	flagOverwrite bool
+	flagPool      string
+	flagForce     bool
+	flagVolume    string
+	flagTarget    string
+	flagPID       int
+}
+
+func (c *cmdDebugMemory) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("get-instance-memory", i18n.G("[<remote>:]<instance> [<path>]"))
+	cmd.Short = i18n.G("Export an instance's memory state")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Export an instance's memory state
+
+Virtual machines support the elf, win-dmp, kdump-zlib, kdump-lzo and kdump-snappy formats.
+Containers support the criu format (a tarball of the CRIU memory images) and the
+core format (a core file of the process selected with --pid, init by default).
+
+By default, the format is picked from the file extension (.elf for elf, .dmp for win-dmp,
+.dump for kdump-zlib, .tar for criu and .core for core).
+
+The dump is downloaded into the provided local file.
+With --server, it's instead written by the server to the provided absolute path.
+That path can't be within the server's own state directory and an existing file
+is only replaced when --overwrite is passed.
+With --pool and --volume, the dump is instead written by the server into that
+custom storage volume, in which case no path is provided.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus debug get-instance-memory v1 v1.elf
+    Download the memory of the "v1" instance as an elf file.
+
+incus debug get-instance-memory c1 c1-nginx.core --pid=42
+    Download a core file of process 42 of the "c1" container.
+
+incus debug get-instance-memory v1 /srv/dumps/v1.elf --server --target=server01
+    Have cluster member "server01", which hosts "v1", write its memory into /srv/dumps/v1.elf.
+
+incus debug get-instance-memory v1 /var/tmp/v1.elf.zst --compress=zstd --max-size=20GiB
+    Dump the memory of "v1" compressed with zstd, aborting if the file exceeds 20GiB.
+
+incus debug get-instance-memory v1 --pool=ceph --volume=dumps
+    Write the memory of "v1" into the "dumps" custom volume of the "ceph" storage pool.`))
+
+	cmd.Flags().StringVar(&c.flagFormat, "format", "auto", i18n.G("Dump format (auto picks it from the file extension, falling back to elf for virtual machines and criu for containers)")+"``")
+	cmd.Flags().IntVar(&c.flagPID, "pid", 0, i18n.G("Process to dump with the core format on containers")+"``")
+	cmd.Flags().StringVar(&c.flagCompress, "compress", "none", i18n.G("Compression applied to elf dumps (none, gzip or zstd)")+"``")
+	cmd.Flags().StringVar(&c.flagMaxSize, "max-size", "", i18n.G("Abort the dump and remove the file once it exceeds this size")+"``")
+	cmd.Flags().StringVar(&c.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.Flags().BoolVar(&c.flagServer, "server", false, i18n.G("Write the dump to the path on the server instead of downloading it"))
+	cmd.Flags().BoolVar(&c.flagOverwrite, "overwrite", false, i18n.G("Replace an existing file at the path on the server"))
+	cmd.Flags().BoolVar(&c.flagForce, "force", false, i18n.G("Skip the checks that the guest supports the dump format"))
+	cmd.Flags().StringVar(&c.flagPool, "pool", "", i18n.G("Storage pool of the volume to write the dump into")+"``")
+	cmd.Flags().StringVar(&c.flagVolume, "volume", "", i18n.G("Custom storage volume to write the dump into")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		if c.flagServer || c.flagVolume != "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	return cmd
+}
+
+func (c *cmdDebugMemory) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	if exit {
+		return err
+	}
+
+	dumpPath := ""
+	if len(args) > 1 {
+		dumpPath = args[1]
+	}
+
+	if c.flagVolume != "" {
+		if dumpPath != "" || c.flagServer {
+			return fmt.Errorf(i18n.G("A path can't be used when dumping into a storage volume"))
+		}
+
+		if c.flagPool == "" {
+			return fmt.Errorf(i18n.G("A storage pool must be provided with --volume"))
+		}
+	} else if c.flagPool != "" {
+		return fmt.Errorf(i18n.G("A storage volume must be provided with --pool"))
+	} else if dumpPath == "" {
+		return fmt.Errorf(i18n.G("A path or storage volume to write the dump into is required"))
+	}
+
+	// Whether the dump gets downloaded into a local file.
+	download := !c.flagServer && c.flagVolume == ""
+
+	maxSize, err := units.ParseByteSizeString(c.flagMaxSize)
+	if err != nil {
+		return fmt.Errorf(i18n.G("Invalid maximum size: %w"), err)
+	}
+
+	// Pick the format from the file extension unless explicitly set.
+	format := c.flagFormat
+	pathFormat := debugMemoryFormatFromPath(dumpPath)
+	if format == "auto" {
+		format = pathFormat
+	} else {
+		known := slices.ContainsFunc(debugMemoryFormats, func(f debugMemoryFormat) bool { return f.name == format })
+		if !known {
+			return fmt.Errorf(i18n.G("Unknown dump format %q"), format)
+		}
+
+		if pathFormat != "" && pathFormat != format {
+			fmt.Fprintf(os.Stderr, i18n.G("Warning: The file extension of %q usually holds %s dumps, not %s")+"\n", dumpPath, pathFormat, format)
+		}
+	}
+
+	if c.flagCompress != "none" && format != "" && format != "elf" {
+		return fmt.Errorf(i18n.G("Compression is only supported with the elf format"))
+	}
+
+	// Connect to the daemon.
+	remote, name, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	if c.flagTarget != "" {
+		d = d.UseTarget(c.flagTarget)
+	}
+
+	dumpArgs := incus.InstanceDebugMemoryArgs{
+		Format:   format,
+		PID:      c.flagPID,
+		Compress: c.flagCompress,
+		MaxSize:  maxSize,
+		Force:    c.flagForce,
+		DataDone: make(chan bool),
+	}
+
+	// Hash the downloaded dump to check it against the server's checksum.
+	hasher := sha256.New()
+
+	if c.flagVolume != "" {
+		dumpArgs.Pool = c.flagPool
+		dumpArgs.Volume = c.flagVolume
+	} else if c.flagServer {
+		dumpArgs.Path = dumpPath
+		dumpArgs.Overwrite = c.flagOverwrite
+	} else {
+		dumpFile, err := os.Create(dumpPath)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = dumpFile.Close() }()
+
+		dumpArgs.Writer = io.MultiWriter(dumpFile, hasher)
+	}
+
+	op, err := d.GetInstanceDebugMemory(name, &dumpArgs)
+	if err != nil && api.StatusErrorCheck(err, http.StatusConflict) {
+		// Another dump of the instance is running, offer to wait for it and try again.
+		waitErr := c.waitExistingDump(d, name, err)
+		if waitErr != nil {
+			err = waitErr
+		} else {
+			op, err = d.GetInstanceDebugMemory(name, &dumpArgs)
+		}
+	}
+
+	if err != nil {
+		if download {
+			_ = os.Remove(dumpPath)
+		}
+
+		return err
+	}
+
+	progress := cli.ProgressRenderer{
+		Format: i18n.G("Dumping instance memory: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	// Interrupting cancels the dump.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = op.WaitProgress(ctx, progress.UpdateOp)
+	if err != nil {
+		progress.Done("")
+
+		if download {
+			_ = os.Remove(dumpPath)
+		}
+
+		return err
+	}
+
+	// Wait for the whole dump to be received.
+	<-dumpArgs.DataDone
+
+	progress.Done("")
+
+	checksum, _ := op.Get().Metadata["sha256"].(string)
+	size, _ := op.Get().Metadata["bytes_written"].(float64)
+
+	if download && checksum != "" {
+		received := hex.EncodeToString(hasher.Sum(nil))
+		if received != checksum {
+			_ = os.Remove(dumpPath)
+			return fmt.Errorf(i18n.G("Memory dump checksum mismatch (expected sha256 %s, received %s)"), checksum, received)
+		}
+	}
+
+	if !c.global.flagQuiet {
+		if c.flagVolume != "" {
+			volumeFile, _ := op.Get().Metadata["volume_file"].(string)
+			fmt.Printf(i18n.G("Memory dump written to %s in storage volume %s/%s")+"\n", volumeFile, c.flagPool, c.flagVolume)
+		} else {
+			fmt.Printf(i18n.G("Memory dump written to %s")+"\n", dumpPath)
+		}
+
+		if checksum != "" {
+			fmt.Printf(i18n.G("Size: %s")+"\n", units.GetByteSizeStringIEC(int64(size), 2))
+			fmt.Printf(i18n.G("SHA256: %s")+"\n", checksum)
+		}
+	}
+
+	return nil
+}
+
+// waitExistingDump offers to wait for the memory dump already running against the instance.
+// The conflict error is returned as-is if the user declines or can't be asked.
+func (c *cmdDebugMemory) waitExistingDump(d incus.InstanceServer, name string, conflictErr error) error {
+	if !termios.IsTerminal(getStdinFd()) {
+		return conflictErr
+	}
+
+	ops, err := d.GetOperations()
+	if err != nil {
+		return conflictErr
+	}
+
+	// Memory dump operations refer to the memory of the instance among their resources.
+	memoryURL := api.NewURL().Path(version.APIVersion, "instances", name, "debug", "memory").String()
+
+	var existing *api.Operation
+	for i, op := range ops {
+		if !op.StatusCode.IsFinal() && slices.Contains(op.Resources["instances_debug_memory"], memoryURL) {
+			existing = &ops[i]
+			break
+		}
+	}
+
+	if existing == nil {
+		return conflictErr
+	}
+
+	wait, err := c.global.asker.AskBool(fmt.Sprintf(i18n.G("A memory dump of %s is already running (operation %s), wait for it to finish?"), name, existing.ID)+" (yes/no) [default=no]: ", "no")
+	if err != nil {
+		return err
+	}
+
+	if !wait {
+		return conflictErr
+	}
+
+	_, _, err = d.GetOperationWait(existing.ID, -1)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// QEMU monitor.
+type cmdDebugQemuMonitor struct {
+	global *cmdGlobal
+	debug  *cmdDebug
+}
+
+func (c *cmdDebugQemuMonitor) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("qemu-monitor", i18n.G("[<remote>:]<instance> <command>"))
+	cmd.Short = i18n.G("Run a read-only QEMU monitor command")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Run a read-only QEMU monitor command
+
+Runs a QMP query command (such as query-status or query-blockstats) against
+a running virtual machine and prints its result as JSON.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus debug qemu-monitor v1 query-blockstats
+    Show the block device statistics of the "v1" virtual machine.`))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+func (c *cmdDebugQemuMonitor) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Connect to the daemon.
+	remote, name, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	result, err := d.GetInstanceDebugQMP(name, args[1])
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	err = json.Indent(&out, result, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(out.String())
+
+	return nil
+}
+
+// Daemon profile.
+type cmdDebugDaemonProfile struct {
+	global *cmdGlobal
+	debug  *cmdDebug
+
+	flagKind   string
+	flagOutput string
+}
+
+func (c *cmdDebugDaemonProfile) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("daemon-profile", i18n.G("[<remote>:]"))
+	cmd.Short = i18n.G("Get a profile of the daemon")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Get a profile of the daemon
+
+Retrieves a goroutine, heap or cpu profile of the daemon in the pprof format.
+The cpu profile samples the daemon for 30 seconds.
+
+This is only available when connected to the server over its local unix socket.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus debug daemon-profile --kind=goroutine --output=goroutine.pprof
+    Save the stack traces of all the daemon goroutines into goroutine.pprof.
+
+incus debug daemon-profile --kind=cpu --output=cpu.pprof
+    Sample the daemon CPU usage and save the resulting profile into cpu.pprof.`))
+
+	cmd.Flags().StringVar(&c.flagKind, "kind", "goroutine", i18n.G("Profile kind (goroutine, heap or cpu)")+"``")
+	cmd.Flags().StringVarP(&c.flagOutput, "output", "o", "", i18n.G("File to write the profile to (defaults to <kind>.pprof)")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpRemotes(false)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+func (c *cmdDebugDaemonProfile) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	if !slices.Contains([]string{"goroutine", "heap", "cpu"}, c.flagKind) {
+		return fmt.Errorf(i18n.G("Invalid profile kind %q"), c.flagKind)
+	}
+
+	output := c.flagOutput
+	if output == "" {
+		output = c.flagKind + ".pprof"
+	}
+
+	// Connect to the daemon.
+	remote := conf.DefaultRemote
+	if len(args) > 0 {
+		remote, _, err = conf.ParseRemote(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	profileFile, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = profileFile.Close() }()
+
+	if c.flagKind != "cpu" {
+		content, err := d.GetDebugProfile(c.flagKind)
+		if err != nil {
+			_ = os.Remove(output)
+			return err
+		}
+
+		defer func() { _ = content.Close() }()
+
+		_, err = io.Copy(profileFile, content)
+		if err != nil {
+			_ = os.Remove(output)
+			return err
+		}
+
+		if !c.global.flagQuiet {
+			fmt.Printf(i18n.G("Profile written to %s")+"\n", output)
+		}
+
+		return nil
+	}
+
+	profileArgs := incus.DebugCPUProfileArgs{
+		Writer:   profileFile,
+		DataDone: make(chan bool),
+	}
+
+	op, err := d.GetDebugCPUProfile(&profileArgs)
+	if err != nil {
+		_ = os.Remove(output)
+		return err
+	}
+
+	progress := cli.ProgressRenderer{
+		Format: i18n.G("Profiling daemon CPU usage: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	progress.Update(i18n.G("sampling"))
+
+	err = cli.CancelableWait(op, &progress)
+	if err != nil {
+		progress.Done("")
+		_ = os.Remove(output)
+		return err
+	}
+
+	// Wait for the whole profile to be received.
+	<-profileArgs.DataDone
+
+	progress.Done(fmt.Sprintf(i18n.G("Profile written to %s"), output))
+
+	return nil
+}
+
+// AppArmor profile.
+type cmdDebugAppArmorProfile struct {
+	global *cmdGlobal
+	debug  *cmdDebug
+
+	flagSources      []string
+	flagDestinations []string
+	flagAccess       string
+	flagCluster      string
+	flagPath         string
+}
+
+func (c *cmdDebugAppArmorProfile) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("apparmor-profile", i18n.G("<kind> [<remote>:][<instance>]"))
+	cmd.Short = i18n.G("Show a generated AppArmor profile")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show a generated AppArmor profile
+
+Prints the AppArmor profile the server would generate, without loading it.
+The kind is one of instance, rsync, ceph or qemu-img.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus debug apparmor-profile instance v1
+    Show the profile the instance v1 would be started with.
+
+incus debug apparmor-profile rsync --source=/var/lib/incus/containers/c1 --destination=/var/lib/incus/containers/c2
+    Show the profile confining rsync between the two paths.
+
+incus debug apparmor-profile ceph --cluster=ceph --path=/var/lib/incus/backups/volume.img
+    Show the profile confining the Ceph tools exporting a volume to the given path.`))
+
+	cmd.Flags().StringArrayVar(&c.flagSources, "source", nil, i18n.G("Path read from (rsync and qemu-img)")+"``")
+	cmd.Flags().StringArrayVar(&c.flagDestinations, "destination", nil, i18n.G("Path written to (rsync and qemu-img)")+"``")
+	cmd.Flags().StringVar(&c.flagAccess, "access", "", i18n.G("Access given to the destination paths, create-only or full (rsync)")+"``")
+	cmd.Flags().StringVar(&c.flagCluster, "cluster", "", i18n.G("Ceph cluster name (ceph)")+"``")
+	cmd.Flags().StringVar(&c.flagPath, "path", "", i18n.G("Path a volume is exported to or imported from (ceph)")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return []string{"instance", "rsync", "ceph", "qemu-img"}, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		if len(args) == 1 {
+			if args[0] == "instance" {
+				return c.global.cmpInstances(toComplete)
+			}
+
+			return c.global.cmpRemotes(false)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+func (c *cmdDebugAppArmorProfile) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	if exit {
+		return err
+	}
+
+	kind := args[0]
+	if !slices.Contains([]string{"instance", "rsync", "ceph", "qemu-img"}, kind) {
+		return fmt.Errorf(i18n.G("Invalid profile kind %q"), kind)
+	}
+
+	// Parse remote.
+	remote := conf.DefaultRemote
+	name := ""
+	if len(args) > 1 {
+		remote, name, err = conf.ParseRemote(args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	if kind == "instance" && name == "" {
+		return fmt.Errorf(i18n.G("Missing instance name"))
+	} else if kind != "instance" && name != "" {
+		return fmt.Errorf(i18n.G("Only instance profiles take an instance name"))
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	profile, err := d.GetDebugAppArmorProfile(kind, &incus.DebugAppArmorProfileArgs{
+		Instance:     name,
+		Sources:      c.flagSources,
+		Destinations: c.flagDestinations,
+		Access:       c.flagAccess,
+		Cluster:      c.flagCluster,
+		Path:         c.flagPath,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(profile)
+
+	return nil
+}
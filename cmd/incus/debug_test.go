@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDebugMemoryFormatFromPath(t *testing.T) {
+	tests := map[string]string{
+		"v1.elf":              "elf",
+		"/var/tmp/v1.elf.zst": "elf",
+		"v1.elf.gz":           "elf",
+		"win.dmp":             "win-dmp",
+		"v1.dump":             "kdump-zlib",
+		"c1.tar":              "criu",
+		"c1-nginx.core":       "core",
+		"v1.bin":              "",
+		"v1":                  "",
+	}
+
+	for path, want := range tests {
+		got := debugMemoryFormatFromPath(path)
+		if got != want {
+			t.Errorf("debugMemoryFormatFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
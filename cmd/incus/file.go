@@ -511,7 +511,12 @@ func (c *cmdFilePull) Run(cmd *cobra.Command, args []string) error {
 					targetIsDir = true
 				}
 
-				err := c.file.recursivePullFile(resource.server, pathSpec[0], pathSpec[1], target)
+				if resource.server.HasExtension("instance_files_tar") {
+					err = c.file.treePullFile(resource.server, pathSpec[0], pathSpec[1], target)
+				} else {
+					err = c.file.recursivePullFile(resource.server, pathSpec[0], pathSpec[1], target)
+				}
+
 				if err != nil {
 					return err
 				}
@@ -746,7 +751,13 @@ func (c *cmdFilePush) Run(cmd *cobra.Command, args []string) error {
 
 		// Transfer the files
 		for _, fname := range sourcefilenames {
-			err := c.file.recursivePushFile(resource.server, resource.name, fname, targetPath)
+			var err error
+			if resource.server.HasExtension("instance_files_tar") {
+				err = c.file.treePushFile(resource.server, resource.name, fname, targetPath)
+			} else {
+				err = c.file.recursivePushFile(resource.server, resource.name, fname, targetPath)
+			}
+
 			if err != nil {
 				return err
 			}
@@ -1079,6 +1090,34 @@ func (c *cmdFile) recursivePushFile(d incus.InstanceServer, inst string, source
 	return filepath.Walk(source, sendFile)
 }
 
+// treePushFile pushes a whole directory tree in as few requests as possible.
+func (c *cmdFile) treePushFile(d incus.InstanceServer, inst string, source string, target string) error {
+	progress := cli.ProgressRenderer{
+		Format: fmt.Sprintf(i18n.G("Pushing %s to %s: %%s"), source, target),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	logger.Infof("Pushing %s to %s (tree)", source, target)
+	err := d.PushInstanceFileTree(inst, source, target, &incus.InstanceFileTreeArgs{ProgressHandler: progress.UpdateProgress})
+	progress.Done("")
+
+	return err
+}
+
+// treePullFile pulls a whole directory tree in a single request.
+func (c *cmdFile) treePullFile(d incus.InstanceServer, inst string, p string, targetDir string) error {
+	progress := cli.ProgressRenderer{
+		Format: fmt.Sprintf(i18n.G("Pulling %s from %s: %%s"), p, targetDir),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	logger.Infof("Pulling %s from %s (tree)", targetDir, p)
+	err := d.PullInstanceFileTree(inst, p, targetDir, &incus.InstanceFileTreeArgs{ProgressHandler: progress.UpdateProgress})
+	progress.Done("")
+
+	return err
+}
+
 func (c *cmdFile) recursiveMkdir(d incus.InstanceServer, inst string, p string, mode *os.FileMode, uid int64, gid int64) error {
 	/* special case, every instance has a /, we don't need to do anything */
 	if p == "/" {
@@ -572,8 +572,8 @@ func (c *cmdImageExport) Run(cmd *cobra.Command, args []string) error {
 	}
 	targetRootfs := targetMeta + ".root"
 
-	// Prepare the files
-	dest, err := os.Create(targetMeta)
+	// Prepare the files, keeping any partial download around so it can be resumed
+	dest, err := os.OpenFile(targetMeta, os.O_RDWR|os.O_CREATE, 0o666)
 	if err != nil {
 		return err
 	}
@@ -597,12 +597,13 @@ func (c *cmdImageExport) Run(cmd *cobra.Command, args []string) error {
 		MetaFile:        io.WriteSeeker(dest),
 		RootfsFile:      io.WriteSeeker(destRootfs),
 		ProgressHandler: progress.UpdateProgress,
+		Resume:          true,
 	}
 
 	// Download the image
 	resp, err := remoteServer.GetImageFile(fingerprint, req)
 	if err != nil {
-		_ = os.Remove(targetMeta)
+		// Leave the metadata file behind so that the next export can resume from it.
 		_ = os.Remove(targetRootfs)
 		progress.Done("")
 		return err
@@ -1363,12 +1364,7 @@ func (c *cmdImageList) Run(cmd *cobra.Command, args []string) error {
 	} else {
 		allImages, err = remoteServer.GetImagesWithFilter(serverFilters)
 		if err != nil {
-			allImages, err = remoteServer.GetImages()
-			if err != nil {
-				return err
-			}
-
-			clientFilters = filters
+			return err
 		}
 	}
 
@@ -30,6 +30,9 @@ type cmdGlobal struct {
 	cmd      *cobra.Command
 	ret      int
 
+	// Server configuration metadata already fetched, by remote.
+	metadataCache map[string]*api.MetadataConfiguration
+
 	flagForceLocal bool
 	flagHelp       bool
 	flagHelpAll    bool
@@ -141,6 +144,10 @@ Custom commands can be defined through aliases, use "incus alias" to control tho
 	copyCmd := cmdCopy{global: &globalCmd}
 	app.AddCommand(copyCmd.Command())
 
+	// debug sub-command
+	debugCmd := cmdDebug{global: &globalCmd}
+	app.AddCommand(debugCmd.Command())
+
 	// delete sub-command
 	deleteCmd := cmdDelete{global: &globalCmd}
 	app.AddCommand(deleteCmd.Command())
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -27,6 +29,9 @@ import (
 	"github.com/lxc/incus/v6/shared/util"
 )
 
+// remoteProbeTimeout is how long to wait for each address of a new remote to be reachable.
+const remoteProbeTimeout = 5 * time.Second
+
 type cmdRemote struct {
 	global *cmdGlobal
 }
@@ -174,8 +179,10 @@ func (c *cmdRemoteAdd) RunToken(server string, token string, rawToken *api.Certi
 		}
 	}
 
-	for _, addr := range rawToken.Addresses {
-		addr = fmt.Sprintf("https://%s", addr)
+	// Try the addresses which can be reached first, closest first, then the others.
+	results := internalUtil.ProbeAddresses(context.Background(), rawToken.Addresses, remoteProbeTimeout)
+	for _, addr := range internalUtil.SortAddresses(results) {
+		addr = fmt.Sprintf("https://%s", internalUtil.NetworkAddressURLHost(addr, ports.HTTPSDefaultPort))
 
 		err := c.addRemoteFromToken(addr, server, token, rawToken.Fingerprint)
 		if err != nil {
@@ -190,6 +197,7 @@ func (c *cmdRemoteAdd) RunToken(server string, token string, rawToken *api.Certi
 	}
 
 	fmt.Println(i18n.G("All server addresses are unavailable"))
+	fmt.Println(internalUtil.ProbeReport(results))
 	fmt.Printf(i18n.G("Please provide an alternate server address (empty to abort):") + " ")
 
 	buf := bufio.NewReader(os.Stdin)
@@ -396,6 +404,24 @@ func (c *cmdRemoteAdd) Run(cmd *cobra.Command, args []string) error {
 		addr = rScheme + "://" + rHost
 	}
 
+	// When the host name has several addresses, check whether any of them can be reached, so that failing to
+	// connect reports on each of them rather than only on the last one attempted.
+	var probeReport string
+	if rScheme == "https" && net.ParseIP(strings.Trim(rHost, "[]")) == nil {
+		ips, err := net.LookupHost(rHost)
+		if err == nil && len(ips) > 1 {
+			probeAddrs := make([]string, 0, len(ips))
+			for _, ip := range ips {
+				probeAddrs = append(probeAddrs, net.JoinHostPort(ip, rPort))
+			}
+
+			results := internalUtil.ProbeAddresses(context.Background(), probeAddrs, remoteProbeTimeout)
+			if !internalUtil.AnyUsable(results) {
+				probeReport = fmt.Sprintf(i18n.G("Unable to reach any of the addresses of %q:")+"\n%s", rHost, internalUtil.ProbeReport(results))
+			}
+		}
+	}
+
 	// Finally, actually add the remote, almost...  If the remote is a private
 	// HTTPS server then we need to ensure we have a client certificate before
 	// adding the remote server.
@@ -446,6 +472,10 @@ func (c *cmdRemoteAdd) Run(cmd *cobra.Command, args []string) error {
 		// Failed to connect using the system CA, so retrieve the remote certificate
 		certificate, err = localtls.GetRemoteCertificate(addr, c.global.conf.UserAgent)
 		if err != nil {
+			if probeReport != "" {
+				return fmt.Errorf("%w\n%s", err, probeReport)
+			}
+
 			return err
 		}
 	}
@@ -6,8 +6,10 @@ import (
 	"os"
 	"os/exec"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/lxc/incus/v6/client"
 	"github.com/lxc/incus/v6/internal/i18n"
@@ -44,6 +46,81 @@ func runBatch(names []string, action func(name string) error) []batchResult {
 	return results
 }
 
+// runBatchLimited is like runBatch but runs at most limit actions at once and returns the results in the order of names.
+func runBatchLimited(names []string, limit int, action func(name string) error) []batchResult {
+	results := make([]batchResult, len(names))
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = batchResult{action(name), name}
+		}(i, name)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// deviceTypeList is the list of device types known to the client, on top of those documented by the server.
+var deviceTypeList = []string{"disk", "gpu", "infiniband", "nic", "none", "pci", "proxy", "tpm", "unix-block", "unix-char", "unix-hotplug", "usb"}
+
+// deviceMetadataGroup returns the name of the configuration metadata group documenting a device type.
+func deviceMetadataGroup(deviceType string) string {
+	if deviceType == "unix-char" || deviceType == "unix-block" {
+		return "unix-char-block"
+	}
+
+	return deviceType
+}
+
+// deviceTypes returns the sorted list of known device types, including those documented in meta if set.
+func deviceTypes(meta *api.MetadataConfiguration) []string {
+	types := append([]string{}, deviceTypeList...)
+	if meta != nil {
+		for group := range meta.Config["devices"] {
+			name := string(group)
+			if name == deviceMetadataGroup("unix-char") || slices.Contains(types, name) {
+				continue
+			}
+
+			types = append(types, name)
+		}
+	}
+
+	sort.Strings(types)
+
+	return types
+}
+
+// getMetadataConfiguration returns the configuration metadata of the remote, only fetching it once.
+func (c *cmdGlobal) getMetadataConfiguration(resource remoteResource) (*api.MetadataConfiguration, error) {
+	meta, ok := c.metadataCache[resource.remote]
+	if ok {
+		return meta, nil
+	}
+
+	meta, err := resource.server.GetMetadataConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.metadataCache == nil {
+		c.metadataCache = map[string]*api.MetadataConfiguration{}
+	}
+
+	c.metadataCache[resource.remote] = meta
+
+	return meta, nil
+}
+
 // Add a device to an instance.
 func instanceDeviceAdd(client incus.InstanceServer, name string, devName string, dev map[string]string) error {
 	// Get the instance entry
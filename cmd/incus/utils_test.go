@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"reflect"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -82,3 +84,39 @@ func (s *utilsTestSuite) TestGetServerSupportedFilters() {
 	s.Equal([]string{"type=container"}, supportedFilters)
 	s.Equal([]string{"foo", "user.blah=a", "status=running,stopped"}, unsupportedFilters)
 }
+
+func (s *utilsTestSuite) TestRunBatchLimited() {
+	names := []string{"c1", "c2", "c3", "c4", "c5", "c6"}
+
+	var running, maxRunning int32
+	results := runBatchLimited(names, 2, func(name string) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			seen := atomic.LoadInt32(&maxRunning)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, current) {
+				break
+			}
+		}
+
+		if name == "c3" {
+			return fmt.Errorf("Failed on %s", name)
+		}
+
+		return nil
+	})
+
+	s.LessOrEqual(maxRunning, int32(2))
+	s.Len(results, len(names))
+
+	for i, result := range results {
+		s.Equal(names[i], result.name)
+
+		if result.name == "c3" {
+			s.EqualError(result.err, "Failed on c3")
+		} else {
+			s.NoError(result.err)
+		}
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/lxc/incus/v6/client"
 	"github.com/lxc/incus/v6/internal/revert"
+	"github.com/lxc/incus/v6/internal/server/apparmor"
 	"github.com/lxc/incus/v6/internal/server/auth"
 	"github.com/lxc/incus/v6/internal/server/auth/oidc"
 	"github.com/lxc/incus/v6/internal/server/cluster"
@@ -23,6 +25,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/response"
 	scriptletLoad "github.com/lxc/incus/v6/internal/server/scriptlet/load"
 	localUtil "github.com/lxc/incus/v6/internal/server/util"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/internal/version"
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/logger"
@@ -53,6 +56,7 @@ var api10 = []APIEndpoint{
 	instanceBackupsCmd,
 	instanceCmd,
 	instanceConsoleCmd,
+	instanceDeviceCmd,
 	instanceExecCmd,
 	instanceFileCmd,
 	instanceExecOutputCmd,
@@ -68,6 +72,10 @@ var api10 = []APIEndpoint{
 	instanceSnapshotsCmd,
 	instanceStateCmd,
 	instanceAccessCmd,
+	instanceDebugMemoryCmd,
+	instanceDebugQMPCmd,
+	debugPprofCmd,
+	debugAppArmorCmd,
 	eventsCmd,
 	imageAliasCmd,
 	imageAliasesCmd,
@@ -77,6 +85,7 @@ var api10 = []APIEndpoint{
 	imagesCmd,
 	imageSecretCmd,
 	metadataConfigurationCmd,
+	metadataDeviceTypesCmd,
 	networkCmd,
 	networkLeasesCmd,
 	networksCmd,
@@ -98,10 +107,12 @@ var api10 = []APIEndpoint{
 	networkZoneRecordCmd,
 	networkZoneRecordsCmd,
 	operationCmd,
+	operationFileCmd,
 	operationsCmd,
 	operationWait,
 	operationWebsocket,
 	profileCmd,
+	profileDeviceCmd,
 	profilesCmd,
 	projectCmd,
 	projectsCmd,
@@ -131,6 +142,15 @@ var api10 = []APIEndpoint{
 	metricsCmd,
 }
 
+// listenAddressesOptions selects the addresses advertised for a wildcard core.https_address, skipping those
+// which can't be used by remote clients and the host side of instance veth pairs.
+var listenAddressesOptions = internalUtil.ExpandListenOptions{
+	ExcludeLoopback:   true,
+	ExcludeLinkLocal:  true,
+	ExcludeDown:       true,
+	ExcludeInterfaces: []string{"veth*"},
+}
+
 // swagger:operation GET /1.0?public server server_get_untrusted
 //
 //  Get the server environment
@@ -252,7 +272,7 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 
 	localHTTPSAddress := s.LocalConfig.HTTPSAddress()
 
-	addresses, err := localUtil.ListenAddresses(localHTTPSAddress)
+	addresses, err := internalUtil.ExpandListenAddresses(localHTTPSAddress, listenAddressesOptions)
 	if err != nil {
 		return response.InternalError(err)
 	}
@@ -297,6 +317,7 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 
 	env := api.ServerEnvironment{
 		Addresses:              addresses,
+		AppArmorEnforcement:    apparmor.Enforcement(s.OS),
 		Architectures:          architectures,
 		Certificate:            certificate,
 		CertificateFingerprint: certificateFingerprint,
@@ -622,6 +643,21 @@ func doApi10Update(d *Daemon, r *http.Request, req api.ServerPut, patch bool) re
 			}
 		}
 
+		// Validate the extra AppArmor rules
+		for _, kind := range apparmor.RawRulesKinds {
+			key := "apparmor.raw." + kind
+
+			value, ok := nodeValues[key]
+			if !ok || value == newNodeConfig.AppArmorRawRules(kind) {
+				continue
+			}
+
+			err := apparmor.ValidateRawRules(s.OS, kind, value)
+			if err != nil {
+				return fmt.Errorf("Failed validation of %q: %w", key, err)
+			}
+		}
+
 		if patch {
 			nodeChanged, err = newNodeConfig.Patch(nodeValues)
 		} else {
@@ -694,7 +730,21 @@ func doApi10Update(d *Daemon, r *http.Request, req api.ServerPut, patch bool) re
 			clusterChanged, err = newClusterConfig.Replace(req.Config)
 		}
 
-		return err
+		if err != nil {
+			return err
+		}
+
+		// Cluster members don't send the PROXY protocol header, so they must use a separate listener.
+		_, proxyProtocolChanged := clusterChanged["core.https_proxy_protocol"]
+		_, httpsAddressChanged := nodeChanged["core.https_address"]
+		if s.ServerClustered && newClusterConfig.HTTPSProxyProtocol() && (proxyProtocolChanged || httpsAddressChanged) {
+			clusterAddress := newNodeConfig.ClusterAddress()
+			if clusterAddress == "" || localUtil.IsAddressCovered(clusterAddress, newNodeConfig.HTTPSAddress()) {
+				return api.StatusErrorf(http.StatusBadRequest, "Can't enable %q as %q is served by %q", "core.https_proxy_protocol", "cluster.https_address", "core.https_address")
+			}
+		}
+
+		return nil
 	})
 	if err != nil {
 		switch err.(type) {
@@ -790,6 +840,7 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 	openFGAChanged := false
 	ovnChanged := false
 	syslogChanged := false
+	appArmorDenialsChanged := false
 
 	for key := range clusterChanged {
 		switch key {
@@ -812,6 +863,9 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		case "core.https_trusted_proxy":
 			s.Endpoints.NetworkUpdateTrustedProxy(clusterChanged[key])
 
+		case "core.https_proxy_protocol":
+			s.Endpoints.NetworkUpdateProxyProtocol(clusterConfig.HTTPSProxyProtocol())
+
 		case "core.proxy_http", "core.proxy_https", "core.proxy_ignore_hosts":
 			daemonConfigSetProxy(d, clusterConfig)
 
@@ -844,6 +898,25 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 
 		case "core.syslog_socket":
 			syslogChanged = true
+
+		case "core.local_ports":
+			err := d.localPorts.SetRange(nodeConfig.LocalPorts())
+			if err != nil {
+				return err
+			}
+
+		case "apparmor.enforcement":
+			apparmor.SetEnforcement(nodeConfig.AppArmorEnforcement())
+
+		case "apparmor.log_denials":
+			appArmorDenialsChanged = true
+
+		case "storage.background_priority", "storage.background_cgroup", "storage.background_cpu_weight", "storage.background_io_weight":
+			setupBackgroundPriority(nodeConfig)
+
+		case "apparmor.raw.rsync", "apparmor.raw.ceph", "apparmor.raw.qemu_img":
+			kind := strings.TrimPrefix(key, "apparmor.raw.")
+			apparmor.SetRawRules(kind, nodeConfig.AppArmorRawRules(kind))
 		}
 	}
 
@@ -859,6 +932,7 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 
 		s.Endpoints.NetworkUpdateTrustedProxy(clusterConfig.HTTPSTrustedProxy())
+		s.Endpoints.NetworkUpdateProxyProtocol(clusterConfig.HTTPSProxyProtocol())
 	}
 
 	value, ok = nodeChanged["cluster.https_address"]
@@ -988,6 +1062,13 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	if appArmorDenialsChanged {
+		err := d.setupAppArmorDenials(nodeConfig.AppArmorLogDenials())
+		if err != nil {
+			return err
+		}
+	}
+
 	// Compile and load the instance placement scriptlet.
 	value, ok = clusterChanged["instances.placement.scriptlet"]
 	if ok {
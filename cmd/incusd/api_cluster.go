@@ -21,6 +21,7 @@ import (
 
 	"github.com/lxc/incus/v6/client"
 	internalInstance "github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/internal/ports"
 	"github.com/lxc/incus/v6/internal/revert"
 	"github.com/lxc/incus/v6/internal/server/acme"
 	"github.com/lxc/incus/v6/internal/server/auth"
@@ -441,6 +442,10 @@ func clusterPutBootstrap(d *Daemon, r *http.Request, req api.ClusterPut) respons
 			return fmt.Errorf("Cannot use wildcard core.https_address %q for cluster.https_address. Please specify a new cluster.https_address or core.https_address", localClusterAddress)
 		}
 
+		if internalUtil.IsInterfaceAddress(localHTTPSAddress) {
+			return fmt.Errorf("Cannot use interface core.https_address %q for cluster.https_address. Please specify a new cluster.https_address or core.https_address", localHTTPSAddress)
+		}
+
 		_, err = config.Patch(map[string]string{
 			"cluster.https_address": localHTTPSAddress,
 		})
@@ -536,6 +541,11 @@ func clusterPutJoin(d *Daemon, r *http.Request, req api.ClusterPut) response.Res
 			localHTTPSAddress = req.ServerAddress
 		}
 
+		// The cluster address can't follow the address of a network interface.
+		if internalUtil.IsInterfaceAddress(localHTTPSAddress) {
+			localHTTPSAddress = req.ServerAddress
+		}
+
 		// Update the cluster.https_address config key.
 		err := s.DB.Node.Transaction(r.Context(), func(ctx context.Context, tx *db.NodeTx) error {
 			var err error
@@ -590,7 +600,7 @@ func clusterPutJoin(d *Daemon, r *http.Request, req api.ClusterPut) response.Res
 		}
 
 		// Connect to the target cluster node.
-		client, err := incus.ConnectIncus(fmt.Sprintf("https://%s", req.ClusterAddress), args)
+		client, err := incus.ConnectIncus(fmt.Sprintf("https://%s", internalUtil.NetworkAddressURLHost(req.ClusterAddress, ports.HTTPSDefaultPort)), args)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/pprof"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/incus/v6/internal/server/apparmor"
+	"github.com/lxc/incus/v6/internal/server/auth"
+	"github.com/lxc/incus/v6/internal/server/db/operationtype"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/instance/drivers"
+	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
+	"github.com/lxc/incus/v6/internal/server/operations"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// debugCPUProfileDuration is how long the daemon CPU usage is sampled for.
+const debugCPUProfileDuration = 30 * time.Second
+
+var debugPprofCmd = APIEndpoint{
+	Path: "debug/pprof",
+
+	Get: APIEndpointAction{Handler: debugPprofGet, AccessHandler: allowUnixSocket},
+}
+
+var debugAppArmorCmd = APIEndpoint{
+	Path: "debug/apparmor",
+
+	Get: APIEndpointAction{Handler: debugAppArmorGet, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanEdit)},
+}
+
+// allowUnixSocket only lets through requests made over the local unix socket.
+func allowUnixSocket(d *Daemon, r *http.Request) response.Response {
+	if r.Context().Value(request.CtxProtocol) != "unix" {
+		return response.Forbidden(fmt.Errorf("This API is only available over the local unix socket"))
+	}
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation GET /1.0/debug/pprof server debug_pprof_get
+//
+//	Get a daemon profile
+//
+//	Gets a Go runtime profile of the daemon, in the pprof format.
+//	The goroutine and heap profiles are returned directly while the cpu
+//	profile is sampled in the background and streamed over the operation websocket.
+//
+//	This is only available over the local unix socket.
+//
+//	---
+//	produces:
+//	  - application/json
+//	  - application/octet-stream
+//	parameters:
+//	  - in: query
+//	    name: kind
+//	    description: Profile kind (goroutine, heap or cpu)
+//	    type: string
+//	    example: goroutine
+//	responses:
+//	  "200":
+//	    description: Raw profile data
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func debugPprofGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	kind := request.QueryParam(r, "kind")
+	switch kind {
+	case "goroutine", "heap":
+		profile := pprof.Lookup(kind)
+		if profile == nil {
+			return response.InternalError(fmt.Errorf("The %q profile isn't available", kind))
+		}
+
+		var buf bytes.Buffer
+		err := profile.WriteTo(&buf, 0)
+		if err != nil {
+			return response.InternalError(err)
+		}
+
+		files := []response.FileResponseEntry{{
+			Identifier:   kind,
+			Filename:     kind + ".pprof",
+			File:         bytes.NewReader(buf.Bytes()),
+			FileSize:     int64(buf.Len()),
+			FileModified: time.Now(),
+		}}
+
+		return response.FileResponse(r, files, nil)
+	case "cpu":
+	default:
+		return response.BadRequest(fmt.Errorf("Unsupported profile kind %q", kind))
+	}
+
+	secret, err := internalUtil.RandomHexString(32)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	profileWs := &debugWs{secret: secret, conn: make(chan *websocket.Conn, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan struct{})
+
+	run := func(op *operations.Operation) error {
+		defer close(runDone)
+		defer cancel()
+
+		return profileWs.Do(ctx, debugCPUProfile)
+	}
+
+	onCancel := func(op *operations.Operation) error {
+		cancel()
+		<-runDone
+
+		return nil
+	}
+
+	op, err := operations.OperationCreate(s, api.ProjectDefaultName, operations.OperationClassWebsocket, operationtype.DebugCPUProfile, nil, profileWs.Metadata(), run, onCancel, profileWs.Connect, r)
+	if err != nil {
+		cancel()
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// debugCPUProfile samples the daemon CPU usage into w until the profiling duration is reached or ctx is cancelled.
+func debugCPUProfile(ctx context.Context, w io.Writer) error {
+	// Buffer the profile so that nothing partial gets sent if profiling is interrupted.
+	var buf bytes.Buffer
+	err := pprof.StartCPUProfile(&buf)
+	if err != nil {
+		return fmt.Errorf("Failed starting CPU profiling: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		pprof.StopCPUProfile()
+		return ctx.Err()
+	case <-time.After(debugCPUProfileDuration):
+	}
+
+	pprof.StopCPUProfile()
+
+	_, err = io.Copy(w, &buf)
+	return err
+}
+
+// swagger:operation GET /1.0/debug/apparmor server debug_apparmor_get
+//
+//	Render an AppArmor profile
+//
+//	Returns the AppArmor profile the daemon would generate for an instance or
+//	a confined tool, without loading it.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: kind
+//	    description: Profile kind (instance, rsync, ceph or qemu-img)
+//	    type: string
+//	    example: rsync
+//	  - in: query
+//	    name: instance
+//	    description: Instance name (instance profiles)
+//	    type: string
+//	    example: c1
+//	  - in: query
+//	    name: project
+//	    description: Project of the instance
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: source
+//	    description: Path read from (rsync and qemu-img profiles, can be repeated for rsync)
+//	    type: string
+//	    example: /var/lib/incus/storage-pools/default/containers/c1
+//	  - in: query
+//	    name: destination
+//	    description: Path written to (rsync and qemu-img profiles, can be repeated for rsync)
+//	    type: string
+//	    example: /var/lib/incus/storage-pools/default/containers/c2
+//	  - in: query
+//	    name: access
+//	    description: Access given to the destination paths (rsync profiles, create-only or full)
+//	    type: string
+//	    example: create-only
+//	  - in: query
+//	    name: cluster
+//	    description: Ceph cluster name (ceph profiles)
+//	    type: string
+//	    example: ceph
+//	  - in: query
+//	    name: path
+//	    description: Path a volume is exported to or imported from (ceph profiles)
+//	    type: string
+//	    example: /var/lib/incus/backups/volume.img
+//	responses:
+//	  "200":
+//	    description: AppArmor profile
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: string
+//	          description: Profile text
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func debugAppArmorGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	var profile string
+	var err error
+
+	query := r.URL.Query()
+	sources := query["source"]
+	destinations := query["destination"]
+
+	kind := request.QueryParam(r, "kind")
+	switch kind {
+	case "instance":
+		projectName := request.ProjectParam(r)
+		name := request.QueryParam(r, "instance")
+		if name == "" {
+			return response.BadRequest(fmt.Errorf("Missing instance name"))
+		}
+
+		// Render the profile on the member running the instance.
+		resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name, instancetype.Any)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if resp != nil {
+			return resp
+		}
+
+		inst, err := instance.LoadByProjectAndName(s, projectName, name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		profile, err = drivers.AppArmorProfile(inst)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+	case "rsync":
+		if len(sources) == 0 || len(destinations) == 0 {
+			return response.BadRequest(fmt.Errorf("At least one source and destination path are required"))
+		}
+
+		// Destinations are given full access unless requested otherwise.
+		access := request.QueryParam(r, "access")
+		if access == "" {
+			access = apparmor.RsyncAccessFull
+		}
+
+		paths := make([]apparmor.RsyncPath, 0, len(sources)+len(destinations))
+		for _, source := range sources {
+			paths = append(paths, apparmor.RsyncPath{Path: source, Access: apparmor.RsyncAccessReadOnly})
+		}
+
+		for _, destination := range destinations {
+			paths = append(paths, apparmor.RsyncPath{Path: destination, Access: access})
+		}
+
+		profile, err = apparmor.RsyncProfile(s.OS, paths)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+	case "ceph":
+		profile, err = apparmor.CephProfile(request.QueryParam(r, "cluster"), request.QueryParam(r, "path"))
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+	case "qemu-img":
+		if len(sources) != 1 || len(destinations) > 1 {
+			return response.BadRequest(fmt.Errorf("A single source path and at most one destination path are required"))
+		}
+
+		// Image inspection runs through prlimit while conversions run through nice.
+		limiter := "prlimit"
+		destination := ""
+		if len(destinations) > 0 {
+			limiter = "nice"
+			destination = destinations[0]
+		}
+
+		profile, err = apparmor.QemuImgProfile(limiter, sources[0], destination)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+	default:
+		return response.BadRequest(fmt.Errorf("Unsupported profile kind %q", kind))
+	}
+
+	return response.SyncResponse(true, profile)
+}
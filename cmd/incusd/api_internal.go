@@ -58,6 +58,7 @@ var apiInternal = []APIEndpoint{
 	internalContainerOnStartCmd,
 	internalContainerOnStopCmd,
 	internalContainerOnStopNSCmd,
+	internalDebugProcessesCmd,
 	internalGarbageCollectorCmd,
 	internalImageOptimizeCmd,
 	internalImageRefreshCmd,
@@ -111,6 +112,12 @@ var internalGarbageCollectorCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: internalGC, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanEdit)},
 }
 
+var internalDebugProcessesCmd = APIEndpoint{
+	Path: "debug/processes",
+
+	Get: APIEndpointAction{Handler: internalDebugProcesses, AccessHandler: allowPermission(auth.ObjectTypeServer, auth.EntitlementCanEdit)},
+}
+
 var internalRAFTSnapshotCmd = APIEndpoint{
 	Path: "raft-snapshot",
 
@@ -1013,6 +1020,12 @@ func internalRAFTSnapshot(d *Daemon, r *http.Request) response.Response {
 	return response.InternalError(fmt.Errorf("Not supported"))
 }
 
+func internalDebugProcesses(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	return response.SyncResponse(true, s.Supervisor.List())
+}
+
 func internalBGPState(d *Daemon, r *http.Request) response.Response {
 	s := d.State()
 
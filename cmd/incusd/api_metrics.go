@@ -334,6 +334,9 @@ func internalMetrics(ctx context.Context, daemonStartTime time.Time, tx *db.Clus
 	// Daemon uptime
 	out.AddSamples(metrics.UptimeSeconds, metrics.Sample{Value: time.Since(daemonStartTime).Seconds()})
 
+	// Duration of external commands
+	out.AddSamples(metrics.CommandDurationSeconds, metrics.CommandSamples()...)
+
 	// Number of goroutines
 	out.AddSamples(metrics.GoGoroutines, metrics.Sample{Value: float64(runtime.NumGoroutine())})
 
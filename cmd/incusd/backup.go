@@ -157,6 +157,12 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 
 				progressText := fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(value, 2), units.GetByteSizeString(speed, 2))
 				meta["create_backup_progress"] = progressText
+				operations.SetOperationProgress(meta, "", api.OperationProgress{
+					Stage:     "create_backup",
+					Processed: value,
+					Speed:     speed,
+				})
+
 				_ = op.UpdateMetadata(meta)
 			},
 		},
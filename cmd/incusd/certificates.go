@@ -623,7 +623,7 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 		// Get all addresses the server is listening on. This is encoded in the certificate token,
 		// so that the client will not have to specify a server address. The client will iterate
 		// through all these addresses until it can connect to one of them.
-		addresses, err := localUtil.ListenAddresses(localHTTPSAddress)
+		addresses, err := internalUtil.ExpandListenAddresses(localHTTPSAddress, listenAddressesOptions)
 		if err != nil {
 			return response.InternalError(err)
 		}
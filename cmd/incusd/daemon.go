@@ -28,6 +28,7 @@ import (
 
 	internalIO "github.com/lxc/incus/v6/internal/io"
 	"github.com/lxc/incus/v6/internal/linux"
+	"github.com/lxc/incus/v6/internal/ports"
 	"github.com/lxc/incus/v6/internal/revert"
 	"github.com/lxc/incus/v6/internal/rsync"
 	"github.com/lxc/incus/v6/internal/server/acme"
@@ -36,6 +37,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/auth/oidc"
 	"github.com/lxc/incus/v6/internal/server/bgp"
 	"github.com/lxc/incus/v6/internal/server/certificate"
+	"github.com/lxc/incus/v6/internal/server/cgroup"
 	"github.com/lxc/incus/v6/internal/server/cluster"
 	clusterConfig "github.com/lxc/incus/v6/internal/server/cluster/config"
 	"github.com/lxc/incus/v6/internal/server/daemon"
@@ -52,10 +54,12 @@ import (
 	instanceDrivers "github.com/lxc/incus/v6/internal/server/instance/drivers"
 	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
 	"github.com/lxc/incus/v6/internal/server/loki"
+	"github.com/lxc/incus/v6/internal/server/metrics"
 	"github.com/lxc/incus/v6/internal/server/network/ovn"
 	"github.com/lxc/incus/v6/internal/server/network/ovs"
 	networkZone "github.com/lxc/incus/v6/internal/server/network/zone"
 	"github.com/lxc/incus/v6/internal/server/node"
+	"github.com/lxc/incus/v6/internal/server/operations"
 	"github.com/lxc/incus/v6/internal/server/project"
 	"github.com/lxc/incus/v6/internal/server/request"
 	"github.com/lxc/incus/v6/internal/server/response"
@@ -79,6 +83,7 @@ import (
 	"github.com/lxc/incus/v6/shared/idmap"
 	"github.com/lxc/incus/v6/shared/logger"
 	"github.com/lxc/incus/v6/shared/proxy"
+	"github.com/lxc/incus/v6/shared/subprocess"
 	localtls "github.com/lxc/incus/v6/shared/tls"
 	"github.com/lxc/incus/v6/shared/util"
 )
@@ -91,6 +96,7 @@ type Daemon struct {
 	firewall    firewall.Firewall
 	bgp         *bgp.Server
 	dns         *dns.Server
+	supervisor  *subprocess.Supervisor
 
 	// Event servers
 	devIncusEvents   *events.DevIncusServer
@@ -164,9 +170,15 @@ type Daemon struct {
 	// Syslog listener cancel function.
 	syslogSocketCancel context.CancelFunc
 
+	// AppArmor denials follower.
+	appArmorDenialsCancel context.CancelFunc
+
 	// OVN clients.
 	ovnnb *ovn.NB
 	ovnsb *ovn.SB
+
+	// Allocator for the ports of the local listeners.
+	localPorts *ports.Allocator
 }
 
 // DaemonConfig holds configuration values for Daemon.
@@ -196,6 +208,8 @@ func newDaemon(config *DaemonConfig, os *sys.OS) *Daemon {
 		shutdownCtx:    shutdownCtx,
 		shutdownCancel: shutdownCancel,
 		shutdownDoneCh: make(chan error),
+		localPorts:     ports.NewAllocator("127.0.0.1"),
+		supervisor:     subprocess.NewSupervisor(),
 	}
 
 	d.serverCert = func() *localtls.CertInfo { return d.serverCertInt }
@@ -502,6 +516,7 @@ func (d *Daemon) State() *state.State {
 		DB:                     d.db,
 		BGP:                    d.bgp,
 		DNS:                    d.dns,
+		Supervisor:             d.supervisor,
 		OS:                     d.os,
 		Endpoints:              d.endpoints,
 		Events:                 d.events,
@@ -520,6 +535,7 @@ func (d *Daemon) State() *state.State {
 		Authorizer:             d.authorizer,
 		OVNNB:                  d.ovnnb,
 		OVNSB:                  d.ovnsb,
+		LocalPorts:             d.localPorts,
 	}
 }
 
@@ -855,6 +871,9 @@ func (d *Daemon) init() error {
 
 	logger.Info("Starting up", logger.Ctx{"version": version.Version, "mode": mode, "path": internalUtil.VarPath("")})
 
+	// Track the duration of the external commands, logging the slow ones.
+	subprocess.SetHook(metrics.CommandHook(5 * time.Second))
+
 	/* List of sub-systems to trace */
 	trace := d.config.Trace
 
@@ -869,8 +888,17 @@ func (d *Daemon) init() error {
 		return apparmor.ArchiveWrapper(d.os, cmd, output, allowedCmds)
 	}
 
-	rsync.RunWrapper = func(cmd *exec.Cmd, source string, destination string) (func(), error) {
-		return apparmor.RsyncWrapper(d.os, cmd, source, destination)
+	rsync.RunWrapper = func(cmd *exec.Cmd, paths []rsync.Path) (func(), error) {
+		profilePaths := make([]apparmor.RsyncPath, 0, len(paths))
+		for _, path := range paths {
+			profilePaths = append(profilePaths, apparmor.RsyncPath{Path: path.Path, Access: string(path.Access)})
+		}
+
+		return apparmor.RsyncWrapper(d.os, cmd, profilePaths)
+	}
+
+	rsync.TransportWrapper = func(cmd *exec.Cmd, name string) (func(), error) {
+		return apparmor.TransportWrapper(d.os, cmd, name)
 	}
 
 	// Bump some kernel limits to avoid issues
@@ -1154,6 +1182,27 @@ func (d *Daemon) init() error {
 		return err
 	}
 
+	// Apply the range of ports of the local listeners.
+	err = d.localPorts.SetRange(d.localConfig.LocalPorts())
+	if err != nil {
+		return err
+	}
+
+	// Apply the priority of the background storage work.
+	setupBackgroundPriority(d.localConfig)
+
+	// Apply the AppArmor settings of the generated tool profiles.
+	apparmor.SetEnforcement(d.localConfig.AppArmorEnforcement())
+	for _, kind := range apparmor.RawRulesKinds {
+		apparmor.SetRawRules(kind, d.localConfig.AppArmorRawRules(kind))
+	}
+
+	// Drop the tool profiles left behind by a previous run.
+	err = apparmor.PruneProfiles(d.os)
+	if err != nil {
+		logger.Warn("Failed to prune leftover AppArmor profiles", logger.Ctx{"err": err})
+	}
+
 	localHTTPAddress := d.localConfig.HTTPSAddress()
 	localClusterAddress := d.localConfig.ClusterAddress()
 	debugAddress := d.localConfig.DebugAddress()
@@ -1370,10 +1419,12 @@ func (d *Daemon) init() error {
 	lokiURL, lokiUsername, lokiPassword, lokiCACert, lokiInstance, lokiLoglevel, lokiLabels, lokiTypes := d.globalConfig.LokiServer()
 	oidcIssuer, oidcClientID, oidcAudience, oidcClaim := d.globalConfig.OIDCServer()
 	syslogSocketEnabled := d.localConfig.SyslogSocket()
+	appArmorLogDenials := d.localConfig.AppArmorLogDenials()
 	openfgaAPIURL, openfgaAPIToken, openfgaStoreID := d.globalConfig.OpenFGA()
 	instancePlacementScriptlet := d.globalConfig.InstancesPlacementScriptlet()
 
 	d.endpoints.NetworkUpdateTrustedProxy(d.globalConfig.HTTPSTrustedProxy())
+	d.endpoints.NetworkUpdateProxyProtocol(d.globalConfig.HTTPSProxyProtocol())
 	d.globalConfigMu.Unlock()
 
 	// Setup Loki logger.
@@ -1392,6 +1443,14 @@ func (d *Daemon) init() error {
 		}
 	}
 
+	// Setup AppArmor denials follower.
+	if appArmorLogDenials {
+		err = d.setupAppArmorDenials(true)
+		if err != nil {
+			logger.Warn("Failed to follow AppArmor denials", logger.Ctx{"err": err})
+		}
+	}
+
 	// Setup OIDC authentication.
 	if oidcIssuer != "" && oidcClientID != "" {
 		d.oidcVerifier, err = oidc.NewVerifier(oidcIssuer, oidcClientID, oidcAudience, oidcClaim)
@@ -1689,6 +1748,9 @@ func (d *Daemon) Stop(ctx context.Context, sig os.Signal) error {
 	// Cancelling the context will make everyone aware that we're shutting down.
 	d.shutdownCancel()
 
+	// Leave the helper processes alone from now on, they're either stopped below or re-attached to on startup.
+	d.supervisor.Shutdown()
+
 	if d.gateway != nil {
 		d.stopClusterTasks()
 
@@ -2139,6 +2201,42 @@ func (d *Daemon) setupOpenFGA(apiURL string, apiToken string, storeID string) er
 	return nil
 }
 
+// refreshListenAddresses moves the listeners whose address refers to a network interface (e.g "%bond0:8443") to
+// the current address of their interface, listeners whose address didn't change being left alone.
+func (d *Daemon) refreshListenAddresses() {
+	d.globalConfigMu.Lock()
+	globalConfig := d.globalConfig
+	localConfig := d.localConfig
+	d.globalConfigMu.Unlock()
+
+	httpsAddress := localConfig.HTTPSAddress()
+	if internalUtil.IsInterfaceAddress(httpsAddress) {
+		err := d.endpoints.NetworkUpdateAddress(httpsAddress)
+		if err != nil {
+			logger.Error("Failed to refresh the network address", logger.Ctx{"address": httpsAddress, "err": err})
+		} else {
+			d.endpoints.NetworkUpdateTrustedProxy(globalConfig.HTTPSTrustedProxy())
+			d.endpoints.NetworkUpdateProxyProtocol(globalConfig.HTTPSProxyProtocol())
+		}
+	}
+
+	metricsAddress := localConfig.MetricsAddress()
+	if internalUtil.IsInterfaceAddress(metricsAddress) {
+		err := d.endpoints.MetricsUpdateAddress(metricsAddress, d.endpoints.NetworkCert())
+		if err != nil {
+			logger.Error("Failed to refresh the metrics address", logger.Ctx{"address": metricsAddress, "err": err})
+		}
+	}
+
+	storageBucketsAddress := localConfig.StorageBucketsAddress()
+	if internalUtil.IsInterfaceAddress(storageBucketsAddress) {
+		err := d.endpoints.StorageBucketsUpdateAddress(storageBucketsAddress, d.endpoints.NetworkCert())
+		if err != nil {
+			logger.Error("Failed to refresh the storage buckets address", logger.Ctx{"address": storageBucketsAddress, "err": err})
+		}
+	}
+}
+
 // Syslog listener.
 func (d *Daemon) setupSyslogSocket(enable bool) error {
 	// Always cancel the context to ensure that no goroutines leak.
@@ -2165,6 +2263,84 @@ func (d *Daemon) setupSyslogSocket(enable bool) error {
 	return nil
 }
 
+// setupBackgroundPriority applies the priority of the commands doing background storage work. A cgroup which
+// can't be used, like on hosts without a pure cgroup v2 layout, is skipped with a warning.
+func setupBackgroundPriority(config *node.Config) {
+	var priority subprocess.Priority
+
+	switch config.StorageBackgroundPriority() {
+	case "low":
+		priority = subprocess.Priority{Nice: 10, IOClass: subprocess.IOClassBestEffort, IOLevel: 7}
+	case "idle":
+		priority = subprocess.Priority{Nice: 19, IOClass: subprocess.IOClassIdle}
+	}
+
+	name, cpuWeight, ioWeight := config.StorageBackgroundCgroup()
+	if name != "" {
+		path, err := cgroup.SetupBackground(name, cpuWeight, ioWeight)
+		if err != nil {
+			logger.Warn("Running background storage work outside of its cgroup", logger.Ctx{"cgroup": name, "err": err})
+		} else {
+			priority.Cgroup = path
+		}
+	}
+
+	subprocess.SetBackgroundPriority(priority)
+}
+
+// AppArmor denials follower.
+func (d *Daemon) setupAppArmorDenials(enable bool) error {
+	// Always cancel the context to ensure that no goroutines leak.
+	if d.appArmorDenialsCancel != nil {
+		logger.Debug("Stopping AppArmor denials follower")
+		d.appArmorDenialsCancel()
+		d.appArmorDenialsCancel = nil
+	}
+
+	if !enable || !d.os.AppArmorAvailable {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(d.shutdownCtx)
+
+	logger.Debug("Starting AppArmor denials follower")
+
+	err := apparmor.ListenDenials(ctx, d.appArmorDenial)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	d.appArmorDenialsCancel = cancel
+
+	return nil
+}
+
+// appArmorDenial logs a denial in a tool profile. The tools don't know which operation they run for,
+// so the denial is only attached to an operation when it's the only one running.
+func (d *Daemon) appArmorDenial(denial apparmor.Denial) {
+	ctx := logger.Ctx{"profile": denial.Profile, "action": denial.Operation, "name": denial.Name, "denied": denial.Denied, "command": denial.Command, "pid": denial.PID}
+
+	var running []*operations.Operation
+	for _, op := range operations.Clone() {
+		if op.Status() == api.Running {
+			running = append(running, op)
+		}
+	}
+
+	if len(running) == 1 {
+		op := running[0]
+		ctx["operation"] = op.ID()
+
+		denials, _ := op.Metadata()["apparmor_denials"].([]string)
+		if len(denials) < 10 {
+			_ = op.ExtendMetadata(map[string]any{"apparmor_denials": append(denials, denial.String())})
+		}
+	}
+
+	logger.Warn("AppArmor denial in tool profile", ctx)
+}
+
 // Create a database connection and perform any updates needed.
 func initializeDbObject(d *Daemon) error {
 	logger.Info("Initializing local database")
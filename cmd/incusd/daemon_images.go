@@ -335,6 +335,13 @@ func ImageDownload(ctx context.Context, r *http.Request, s *state.State, op *ope
 
 		if meta["download_progress"] != progress.Text {
 			meta["download_progress"] = progress.Text
+			operations.SetOperationProgress(meta, "", api.OperationProgress{
+				Stage:     "download",
+				Processed: progress.TransferredBytes,
+				Total:     progress.TotalBytes,
+				Speed:     progress.Speed,
+			})
+
 			_ = op.UpdateMetadata(meta)
 		}
 	}
@@ -477,14 +484,19 @@ func ImageDownload(ctx context.Context, r *http.Request, s *state.State, op *ope
 		}
 
 		// Progress handler
+		tracker := &ioprogress.ProgressTracker{Length: raw.ContentLength}
+		tracker.Handler = func(percent int64, speed int64) {
+			progress(ioprogress.ProgressData{
+				Text:             fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)),
+				TransferredBytes: tracker.Processed(),
+				TotalBytes:       raw.ContentLength,
+				Speed:            speed,
+			})
+		}
+
 		body := &ioprogress.ProgressReader{
 			ReadCloser: raw.Body,
-			Tracker: &ioprogress.ProgressTracker{
-				Length: raw.ContentLength,
-				Handler: func(percent int64, speed int64) {
-					progress(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
-				},
-			},
+			Tracker:    tracker,
 		}
 
 		// Create the target files
@@ -57,6 +57,7 @@ import (
 	"github.com/lxc/incus/v6/shared/ioprogress"
 	"github.com/lxc/incus/v6/shared/logger"
 	"github.com/lxc/incus/v6/shared/osarch"
+	"github.com/lxc/incus/v6/shared/subprocess"
 	"github.com/lxc/incus/v6/shared/util"
 )
 
@@ -130,7 +131,6 @@ var imageTaskMu sync.Mutex
 
 func compressFile(compress string, infile io.Reader, outfile io.Writer) error {
 	reproducible := []string{"gzip"}
-	var cmd *exec.Cmd
 
 	// Parse the command.
 	fields, err := shellquote.Split(compress)
@@ -156,12 +156,10 @@ func compressFile(compress string, infile io.Reader, outfile io.Writer) error {
 		}
 
 		args = append(args, "--no-skip", "--force", "--compressor", "xz", tempfile.Name())
-		cmd = exec.Command(args[0], args[1:]...)
-		cmd.Stdin = infile
 
-		output, err := cmd.CombinedOutput()
+		_, _, err = subprocess.RunCommandOutput(context.TODO(), args[0], args[1:], subprocess.WithStdin(infile), subprocess.WithBackgroundPriority())
 		if err != nil {
-			return fmt.Errorf("tar2sqfs: %v (%v)", err, strings.TrimSpace(string(output)))
+			return fmt.Errorf("tar2sqfs: %w", err)
 		}
 		// Replay the result to outfile
 		_, err = tempfile.Seek(0, io.SeekStart)
@@ -183,10 +181,7 @@ func compressFile(compress string, infile io.Reader, outfile io.Writer) error {
 			args = append(args, "-n")
 		}
 
-		cmd := exec.Command(fields[0], args...)
-		cmd.Stdin = infile
-		cmd.Stdout = outfile
-		err := cmd.Run()
+		_, _, err := subprocess.RunCommandOutput(context.TODO(), fields[0], args, subprocess.WithStdin(infile), subprocess.WithStdout(outfile), subprocess.WithBackgroundPriority())
 		if err != nil {
 			return err
 		}
@@ -264,24 +259,29 @@ func imgPostInstanceInfo(ctx context.Context, s *state.State, r *http.Request, r
 
 	// Track progress creating image.
 	metadata := make(map[string]any)
-	imageProgressWriter := &ioprogress.ProgressWriter{
-		Tracker: &ioprogress.ProgressTracker{
-			Handler: func(value, speed int64) {
-				percent := int64(0)
-				var processed int64
-
-				if totalSize > 0 {
-					percent = value
-					processed = totalSize * (percent / 100.0)
-				} else {
-					processed = value
-				}
+	imageProgressTracker := &ioprogress.ProgressTracker{Length: totalSize}
+	imageProgressTracker.Handler = func(value, speed int64) {
+		percent := int64(0)
+		if totalSize > 0 {
+			percent = value
+		}
+
+		processed := imageProgressTracker.Processed()
+
+		operations.SetProgressMetadata(metadata, "create_image_from_container_pack", "Image pack", percent, processed, speed)
+		operations.SetOperationProgress(metadata, "", api.OperationProgress{
+			Stage:       "create_image_from_container_pack",
+			Description: "Image pack",
+			Processed:   processed,
+			Total:       totalSize,
+			Speed:       speed,
+		})
 
-				operations.SetProgressMetadata(metadata, "create_image_from_container_pack", "Image pack", percent, processed, speed)
-				_ = op.UpdateMetadata(metadata)
-			},
-			Length: totalSize,
-		},
+		_ = op.UpdateMetadata(metadata)
+	}
+
+	imageProgressWriter := &ioprogress.ProgressWriter{
+		Tracker: imageProgressTracker,
 	}
 
 	sha256 := sha256.New()
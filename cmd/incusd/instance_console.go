@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -541,6 +543,11 @@ func instanceConsolePost(d *Daemon, r *http.Request) response.Response {
 //	    description: Project name
 //	    type: string
 //	    example: default
+//	  - in: query
+//	    name: follow
+//	    description: Keep sending what the instance writes to its console
+//	    type: boolean
+//	    example: true
 //	responses:
 //	  "200":
 //	     description: Raw console log
@@ -594,6 +601,8 @@ func instanceConsoleLogGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(fmt.Errorf("Console backlog is only supported on containers"))
 	}
 
+	follow := util.IsTrue(r.FormValue("follow"))
+
 	c := inst.(instance.Container)
 	ent := response.FileResponseEntry{}
 	if !c.IsRunning() {
@@ -624,11 +633,20 @@ func instanceConsoleLogGet(d *Daemon, r *http.Request) response.Response {
 			return response.SmartError(err)
 		}
 
-		if errno == unix.ENODATA {
+		if errno != unix.ENODATA {
+			return response.SmartError(err)
+		}
+
+		if !follow {
 			return response.FileResponse(r, nil, nil)
 		}
+	}
 
-		return response.SmartError(err)
+	if follow {
+		return response.ManualResponse(func(w http.ResponseWriter) error {
+			instanceConsoleLogFollow(r.Context(), c, logContents, w)
+			return nil
+		})
 	}
 
 	ent.File = bytes.NewReader([]byte(logContents))
@@ -638,6 +656,79 @@ func instanceConsoleLogGet(d *Daemon, r *http.Request) response.Response {
 	return response.FileResponse(r, []response.FileResponseEntry{ent}, nil)
 }
 
+// instanceConsoleLogFollowInterval is how often the console ring buffer is polled when following it.
+var instanceConsoleLogFollowInterval = time.Second
+
+// instanceConsoleLogFollow streams the console ring buffer of the container to w, starting with its current
+// content and then sending what the instance writes until the request is done or the instance stops.
+func instanceConsoleLogFollow(ctx context.Context, c instance.Container, logContents string, w http.ResponseWriter) {
+	console := liblxc.ConsoleLogOptions{
+		ClearLog:       false,
+		ReadLog:        true,
+		ReadMax:        0,
+		WriteToLogFile: true,
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(instanceConsoleLogFollowInterval)
+	defer ticker.Stop()
+
+	previous := ""
+	for {
+		delta := consoleLogDelta(previous, logContents)
+		if delta != "" {
+			_, err := io.WriteString(w, delta)
+			if err != nil {
+				return
+			}
+
+			flusher, ok := w.(http.Flusher)
+			if ok {
+				flusher.Flush()
+			}
+		}
+
+		previous = logContents
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !c.IsRunning() {
+			return
+		}
+
+		var err error
+		logContents, err = c.ConsoleLog(console)
+		if err != nil {
+			errno, isErrno := linux.GetErrno(err)
+			if !isErrno || errno != unix.ENODATA {
+				logger.Warn("Failed reading the console ring buffer", logger.Ctx{"instance": c.Name(), "project": c.Project().Name, "err": err})
+				return
+			}
+
+			logContents = ""
+		}
+	}
+}
+
+// consoleLogDelta returns what got written to the console ring buffer between the previous and current
+// reads of it, accounting for the older data being dropped once the buffer is full.
+func consoleLogDelta(previous string, current string) string {
+	// Find the longest end of the previous content the current one starts with.
+	for size := min(len(previous), len(current)); size > 0; size-- {
+		if strings.HasPrefix(current, previous[len(previous)-size:]) {
+			return current[size:]
+		}
+	}
+
+	return current
+}
+
 // swagger:operation DELETE /1.0/instances/{name}/console instances instance_console_delete
 //
 //	Clear the console log
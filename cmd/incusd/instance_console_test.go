@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleLogDelta(t *testing.T) {
+	long := strings.Repeat("a", 2000) + "marker"
+
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		want     string
+	}{
+		{name: "Initial content", previous: "", current: "boot\n", want: "boot\n"},
+		{name: "Nothing new", previous: "boot\n", current: "boot\n", want: ""},
+		{name: "Appended", previous: "boot\n", current: "boot\nlogin: ", want: "login: "},
+		{name: "Wrapped buffer", previous: "abcdef", current: "cdefgh", want: "gh"},
+		{name: "Wrapped long buffer", previous: "x" + long, current: long[500:] + "new", want: "new"},
+		{name: "Cleared buffer", previous: "abcdef", current: "xyz", want: "xyz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, consoleLogDelta(tt.previous, tt.current))
+		})
+	}
+}
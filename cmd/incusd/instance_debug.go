@@ -0,0 +1,910 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"golang.org/x/sys/unix"
+
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/internal/jmap"
+	"github.com/lxc/incus/v6/internal/server/db"
+	"github.com/lxc/incus/v6/internal/server/db/operationtype"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
+	"github.com/lxc/incus/v6/internal/server/lifecycle"
+	"github.com/lxc/incus/v6/internal/server/operations"
+	"github.com/lxc/incus/v6/internal/server/project"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	storagePools "github.com/lxc/incus/v6/internal/server/storage"
+	storageDrivers "github.com/lxc/incus/v6/internal/server/storage/drivers"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/units"
+	"github.com/lxc/incus/v6/shared/util"
+	"github.com/lxc/incus/v6/shared/ws"
+)
+
+// instanceDebugMemoryFormats lists the dump formats supported by QEMU.
+var instanceDebugMemoryFormats = []string{"elf", "win-dmp", "kdump-zlib", "kdump-lzo", "kdump-snappy"}
+
+// instanceDebugMemoryContainerFormats lists the dump formats supported for containers.
+var instanceDebugMemoryContainerFormats = []string{"criu", "core"}
+
+// instanceDebugQMPCommands lists the read-only QMP commands which can be run through the debug API.
+var instanceDebugQMPCommands = []string{
+	"query-balloon",
+	"query-block",
+	"query-block-jobs",
+	"query-blockstats",
+	"query-chardev",
+	"query-cpus-fast",
+	"query-dump",
+	"query-hotpluggable-cpus",
+	"query-iothreads",
+	"query-jobs",
+	"query-kvm",
+	"query-memory-devices",
+	"query-memory-size-summary",
+	"query-migrate",
+	"query-name",
+	"query-pci",
+	"query-status",
+	"query-uuid",
+	"query-version",
+}
+
+// debugMemoryDumper writes a memory dump to w, optionally reporting progress.
+type debugMemoryDumper func(ctx context.Context, w io.Writer, progress func(completed int64, total int64)) error
+
+// instanceDebugMemoryCompressions lists the compression algorithms which can be applied to elf dumps.
+var instanceDebugMemoryCompressions = []string{"none", "gzip", "zstd"}
+
+// errDebugMemoryTooLarge is returned when a memory dump exceeds the requested maximum size.
+var errDebugMemoryTooLarge = errors.New("Memory dump exceeded the maximum size")
+
+// debugMemoryDumps tracks the in-flight memory dump operations, keyed on project and instance.
+type debugMemoryDumps struct {
+	mu  sync.Mutex
+	ops map[string]string
+}
+
+// instanceDebugMemoryDumps serializes memory dumps of the same instance.
+var instanceDebugMemoryDumps = &debugMemoryDumps{ops: map[string]string{}}
+
+// errDebugMemoryBusy is returned when a memory dump of the instance is already running.
+var errDebugMemoryBusy = errors.New("A memory dump of the instance is already running")
+
+// start calls create to set up the dump operation and records its ID under key.
+// If a dump is already running for key, create isn't called and the ID of the running operation is returned
+// along with errDebugMemoryBusy.
+func (d *debugMemoryDumps) start(key string, create func() (string, error)) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	opID, ok := d.ops[key]
+	if ok {
+		return opID, errDebugMemoryBusy
+	}
+
+	opID, err := create()
+	if err != nil {
+		return "", err
+	}
+
+	d.ops[key] = opID
+
+	return opID, nil
+}
+
+// finish releases key once its dump operation is done.
+func (d *debugMemoryDumps) finish(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.ops, key)
+}
+
+// debugMemoryWriter counts and hashes the bytes written and fails writes once more than limit bytes have been written.
+type debugMemoryWriter struct {
+	w        io.Writer
+	limit    int64
+	written  atomic.Int64
+	exceeded bool
+	hash     hash.Hash
+}
+
+func (dw *debugMemoryWriter) Write(p []byte) (int, error) {
+	if dw.limit > 0 && dw.written.Load()+int64(len(p)) > dw.limit {
+		dw.exceeded = true
+		return 0, errDebugMemoryTooLarge
+	}
+
+	n, err := dw.w.Write(p)
+	dw.written.Add(int64(n))
+	_, _ = dw.hash.Write(p[:n])
+
+	return n, err
+}
+
+// swagger:operation GET /1.0/instances/{name}/debug/memory instances instance_debug_memory_get
+//
+//	Dump the instance memory
+//
+//	Starts a background operation dumping the memory of a running instance.
+//
+//	The dump is either written into a file on the server, written into a custom
+//	storage volume or, when neither is provided, streamed to the client over the
+//	operation websocket.
+//
+//	This requires the can_access_debug entitlement on the instance.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: target
+//	    description: Cluster member expected to host the instance
+//	    type: string
+//	    example: server01
+//	  - in: query
+//	    name: path
+//	    description: Absolute path on the server to write the dump to, the dump is streamed over the operation websocket when empty
+//	    type: string
+//	    example: /var/tmp/vm1.elf
+//	  - in: query
+//	    name: pool
+//	    description: Storage pool of the custom volume to write the dump into (with volume, instead of path)
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: volume
+//	    description: Custom storage volume to write the dump into (with pool, instead of path)
+//	    type: string
+//	    example: dumps
+//	  - in: query
+//	    name: overwrite
+//	    description: Whether to replace an existing file at the dump path
+//	    type: boolean
+//	    example: true
+//	  - in: query
+//	    name: format
+//	    description: Dump format (elf, win-dmp, kdump-zlib, kdump-lzo or kdump-snappy for virtual machines, criu or core for containers)
+//	    type: string
+//	    example: elf
+//	  - in: query
+//	    name: pid
+//	    description: Process to dump with the core format on containers (defaults to the init process)
+//	    type: integer
+//	    example: 1
+//	  - in: query
+//	    name: compress
+//	    description: Compression applied to elf dumps (none, gzip or zstd)
+//	    type: string
+//	    example: zstd
+//	  - in: query
+//	    name: force
+//	    description: Skip the checks that the guest supports the requested format
+//	    type: boolean
+//	    example: true
+//	  - in: query
+//	    name: max-size
+//	    description: Maximum size of the dump file, the dump is aborted and removed when exceeded
+//	    type: string
+//	    example: 10GiB
+//	responses:
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "409":
+//	    description: A memory dump of the instance is already running
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceDebugMemoryGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if internalInstance.IsSnapshot(name) {
+		return response.BadRequest(fmt.Errorf("Invalid instance name"))
+	}
+
+	// Validate the request.
+	dumpPath := request.QueryParam(r, "path")
+	if dumpPath != "" && !filepath.IsAbs(dumpPath) {
+		return response.BadRequest(fmt.Errorf("The dump path must be absolute"))
+	}
+
+	overwrite := util.IsTrue(request.QueryParam(r, "overwrite"))
+	force := util.IsTrue(request.QueryParam(r, "force"))
+
+	poolName := request.QueryParam(r, "pool")
+	volumeName := request.QueryParam(r, "volume")
+	if (poolName == "") != (volumeName == "") {
+		return response.BadRequest(fmt.Errorf("Both a pool and a volume must be provided to dump into a storage volume"))
+	}
+
+	if volumeName != "" && dumpPath != "" {
+		return response.BadRequest(fmt.Errorf("A dump path can't be combined with a storage volume"))
+	}
+
+	format := request.QueryParam(r, "format")
+
+	pid := 0
+	if request.QueryParam(r, "pid") != "" {
+		pid, err = strconv.Atoi(request.QueryParam(r, "pid"))
+		if err != nil || pid <= 0 {
+			return response.BadRequest(fmt.Errorf("Invalid PID %q", request.QueryParam(r, "pid")))
+		}
+	}
+
+	compress := request.QueryParam(r, "compress")
+	if compress == "" {
+		compress = "none"
+	}
+
+	if !slices.Contains(instanceDebugMemoryCompressions, compress) {
+		return response.BadRequest(fmt.Errorf("Unsupported compression algorithm %q", compress))
+	}
+
+	maxSize, err := units.ParseByteSizeString(request.QueryParam(r, "max-size"))
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid maximum size: %w", err))
+	}
+
+	target := request.QueryParam(r, "target")
+	if target != "" && !s.ServerClustered {
+		return response.BadRequest(fmt.Errorf("Target only allowed when clustered"))
+	}
+
+	// Forward the request if the instance is remote.
+	resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// The request was forwarded to the member hosting the instance, make sure it's the requested one.
+	if target != "" && inst.Location() != target {
+		return response.BadRequest(fmt.Errorf("Instance %q is located on cluster member %q, not %q", name, inst.Location(), target))
+	}
+
+	if !inst.IsRunning() {
+		return response.BadRequest(fmt.Errorf("Instance is not running"))
+	}
+
+	// Make sure the dump can't clobber the server's own files.
+	if dumpPath != "" {
+		dumpPath, err = instanceDebugMemoryValidatePath(dumpPath, overwrite, []string{internalUtil.VarPath()})
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
+	// Check the target storage volume is a filesystem custom volume available on this server.
+	var pool storagePools.Pool
+	var volumeProjectName string
+	if volumeName != "" {
+		pool, err = storagePools.LoadByName(s, poolName)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		volumeProjectName, err = project.StorageVolumeProject(s.DB.Cluster, projectName, db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		var dbVolume *db.StorageVolume
+		err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+			dbVolume, err = tx.GetStoragePoolVolume(ctx, pool.ID(), volumeProjectName, db.StoragePoolVolumeTypeCustom, volumeName, true)
+			return err
+		})
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed loading storage volume %q in pool %q: %w", volumeName, poolName, err))
+		}
+
+		if dbVolume.ContentType != db.StoragePoolVolumeContentTypeNameFS {
+			return response.BadRequest(fmt.Errorf("Memory dumps can only be written to filesystem storage volumes"))
+		}
+	}
+
+	var dump debugMemoryDumper
+	switch inst.Type() {
+	case instancetype.VM:
+		if format == "" {
+			format = "elf"
+		}
+
+		if !slices.Contains(instanceDebugMemoryFormats, format) {
+			return response.BadRequest(fmt.Errorf("Unsupported dump format %q for virtual machines", format))
+		}
+
+		if pid != 0 {
+			return response.BadRequest(fmt.Errorf("A PID can only be provided for container core dumps"))
+		}
+
+		v := inst.(instance.VM)
+		dump = func(ctx context.Context, w io.Writer, progress func(completed int64, total int64)) error {
+			return v.DumpGuestMemory(ctx, w, instance.DumpGuestMemoryArgs{Format: format, Progress: progress, Force: force})
+		}
+
+	case instancetype.Container:
+		if format == "" {
+			format = "criu"
+		}
+
+		if !slices.Contains(instanceDebugMemoryContainerFormats, format) {
+			return response.BadRequest(fmt.Errorf("Unsupported dump format %q for containers", format))
+		}
+
+		if pid != 0 && format != "core" {
+			return response.BadRequest(fmt.Errorf("A PID can only be provided for container core dumps"))
+		}
+
+		c := inst.(instance.Container)
+		dump = func(ctx context.Context, w io.Writer, progress func(completed int64, total int64)) error {
+			return c.DumpMemory(ctx, w, instance.DumpMemoryArgs{Format: format, PID: pid})
+		}
+
+	default:
+		return response.BadRequest(fmt.Errorf("Memory dumps aren't supported for this instance type"))
+	}
+
+	if compress != "none" && format != "elf" {
+		return response.BadRequest(fmt.Errorf("Compression is only supported with the elf format"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan struct{})
+
+	// Setup the websocket when streaming the dump to the client.
+	var dumpWs *debugWs
+	if dumpPath == "" && volumeName == "" {
+		secret, err := internalUtil.RandomHexString(32)
+		if err != nil {
+			cancel()
+			return response.InternalError(err)
+		}
+
+		dumpWs = &debugWs{secret: secret, conn: make(chan *websocket.Conn, 1)}
+	}
+
+	dumpKey := project.Instance(projectName, name)
+
+	// Describe where the dump goes for the audit trail.
+	var fileName string
+	destination := dumpPath
+	if dumpWs != nil {
+		destination = "client"
+	} else if pool != nil {
+		fileName = instanceDebugMemoryFileName(name, format, compress)
+		destination = fmt.Sprintf("%s/%s/%s", poolName, volumeName, fileName)
+	}
+
+	requestor := request.CreateRequestor(r)
+
+	run := func(op *operations.Operation) error {
+		defer instanceDebugMemoryDumps.finish(dumpKey)
+		defer close(runDone)
+		defer cancel()
+
+		s.Events.SendLifecycle(projectName, lifecycle.InstanceDebugMemoryStarted.Event(inst, requestor, logger.Ctx{"format": format, "destination": destination}))
+
+		var err error
+		if dumpWs != nil {
+			err = dumpWs.Do(ctx, func(ctx context.Context, w io.Writer) error {
+				return instanceDebugMemoryWrite(ctx, op, dump, w, compress, maxSize)
+			})
+		} else if pool != nil {
+			err = instanceDebugMemoryVolumeDump(ctx, op, dump, pool, volumeProjectName, volumeName, fileName, compress, maxSize)
+		} else {
+			err = instanceDebugMemoryDump(ctx, op, dump, dumpPath, overwrite, compress, maxSize)
+		}
+
+		if errors.Is(err, errDebugMemoryTooLarge) {
+			err = fmt.Errorf("%w (%s)", err, units.GetByteSizeStringIEC(maxSize, 2))
+		}
+
+		if err != nil {
+			s.Events.SendLifecycle(projectName, lifecycle.InstanceDebugMemoryFailed.Event(inst, requestor, logger.Ctx{"format": format, "destination": destination, "error": err.Error()}))
+			return err
+		}
+
+		meta := op.Metadata()
+		s.Events.SendLifecycle(projectName, lifecycle.InstanceDebugMemoryCompleted.Event(inst, requestor, logger.Ctx{"format": format, "destination": destination, "size": meta["bytes_written"], "sha256": meta["sha256"]}))
+
+		return nil
+	}
+
+	onCancel := func(op *operations.Operation) error {
+		cancel()
+		<-runDone
+
+		return nil
+	}
+
+	resources := map[string][]api.URL{}
+	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", name)}
+	resources["instances_debug_memory"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", name, "debug", "memory")}
+
+	// Only one dump of a given instance can run at a time.
+	var op *operations.Operation
+	opID, err := instanceDebugMemoryDumps.start(dumpKey, func() (string, error) {
+		var err error
+		if dumpWs != nil {
+			op, err = operations.OperationCreate(s, projectName, operations.OperationClassWebsocket, operationtype.InstanceDebugMemory, resources, dumpWs.Metadata(), run, onCancel, dumpWs.Connect, r)
+		} else {
+			op, err = operations.OperationCreate(s, projectName, operations.OperationClassTask, operationtype.InstanceDebugMemory, resources, nil, run, onCancel, nil, r)
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		return op.ID(), nil
+	})
+	if err != nil {
+		cancel()
+
+		if errors.Is(err, errDebugMemoryBusy) {
+			return response.Conflict(fmt.Errorf("%w (operation %s)", err, opID))
+		}
+
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// debugWs streams debug data, such as memory dumps or profiles, to the client over the operation websocket.
+type debugWs struct {
+	secret string
+	conn   chan *websocket.Conn
+}
+
+// Metadata returns the websocket secret in the same layout as exec and console operations.
+func (s *debugWs) Metadata() any {
+	return jmap.Map{"fds": jmap.Map{"0": s.secret}}
+}
+
+// Connect handles the client connecting to the operation websocket.
+func (s *debugWs) Connect(op *operations.Operation, r *http.Request, w http.ResponseWriter) error {
+	secret := r.FormValue("secret")
+	if secret == "" {
+		return fmt.Errorf("missing secret")
+	}
+
+	if secret != s.secret {
+		return os.ErrPermission
+	}
+
+	conn, err := ws.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case s.conn <- conn:
+	default:
+		_ = conn.Close()
+		return fmt.Errorf("The debug websocket is already connected")
+	}
+
+	return nil
+}
+
+// Do waits for the client to connect and streams the output of write to it.
+// The context passed to write is cancelled if the client disconnects.
+func (s *debugWs) Do(ctx context.Context, write func(ctx context.Context, w io.Writer) error) error {
+	var conn *websocket.Conn
+	select {
+	case conn = <-s.conn:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Minute):
+		return fmt.Errorf("Timed out waiting for the client to connect")
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	// Abort as soon as the client goes away.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	wrapper := ws.NewWrapper(conn)
+
+	err := write(ctx, wrapper)
+	if err != nil {
+		return err
+	}
+
+	// Signal the end of the stream.
+	err = wrapper.Close()
+	if err != nil {
+		return err
+	}
+
+	return conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// instanceDebugMemoryValidatePath checks that a server-side dump path is safe to write to and returns it with
+// symlinks resolved. The path must be absolute, outside of deniedDirs, within an existing writable directory and
+// must not point to an existing file unless overwrite is set.
+func instanceDebugMemoryValidatePath(dumpPath string, overwrite bool, deniedDirs []string) (string, error) {
+	if !filepath.IsAbs(dumpPath) {
+		return "", fmt.Errorf("The dump path must be absolute")
+	}
+
+	// Resolve the parent directory as the dump file itself usually doesn't exist yet.
+	parentPath, err := filepath.EvalSymlinks(filepath.Dir(filepath.Clean(dumpPath)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("The parent directory of the dump path doesn't exist")
+		}
+
+		return "", fmt.Errorf("Failed resolving the dump path: %w", err)
+	}
+
+	parentInfo, err := os.Stat(parentPath)
+	if err != nil {
+		return "", fmt.Errorf("Failed checking the dump path: %w", err)
+	}
+
+	if !parentInfo.IsDir() {
+		return "", fmt.Errorf("The parent of the dump path isn't a directory")
+	}
+
+	resolvedPath := filepath.Join(parentPath, filepath.Base(dumpPath))
+
+	info, err := os.Lstat(resolvedPath)
+	if err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		resolvedPath, err = filepath.EvalSymlinks(resolvedPath)
+		if err != nil {
+			return "", fmt.Errorf("The dump path is a dangling symlink")
+		}
+
+		info, err = os.Stat(resolvedPath)
+	}
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("Failed checking the dump path: %w", err)
+	}
+
+	for _, deniedDir := range deniedDirs {
+		resolvedDir, err := filepath.EvalSymlinks(deniedDir)
+		if err != nil {
+			resolvedDir = filepath.Clean(deniedDir)
+		}
+
+		if resolvedPath == resolvedDir || strings.HasPrefix(resolvedPath, resolvedDir+"/") {
+			return "", fmt.Errorf("The dump path can't be within %q", deniedDir)
+		}
+	}
+
+	if info != nil {
+		if !info.Mode().IsRegular() {
+			return "", fmt.Errorf("The dump path already exists and isn't a regular file")
+		}
+
+		if !overwrite {
+			return "", fmt.Errorf("The dump path already exists, set overwrite to replace it")
+		}
+	}
+
+	if unix.Access(filepath.Dir(resolvedPath), unix.W_OK) != nil {
+		return "", fmt.Errorf("The parent directory of the dump path isn't writable")
+	}
+
+	return resolvedPath, nil
+}
+
+// instanceDebugMemoryFileName returns the name of a dump file written into a storage volume.
+func instanceDebugMemoryFileName(instanceName string, format string, compress string) string {
+	fileName := fmt.Sprintf("%s_%s.%s", instanceName, time.Now().UTC().Format("20060102-150405"), format)
+
+	switch compress {
+	case "gzip":
+		fileName += ".gz"
+	case "zstd":
+		fileName += ".zst"
+	}
+
+	return fileName
+}
+
+// instanceDebugMemoryVolumeDump mounts the custom volume, writes the memory dump into it as fileName and unmounts it.
+func instanceDebugMemoryVolumeDump(ctx context.Context, op *operations.Operation, dump debugMemoryDumper, pool storagePools.Pool, projectName string, volumeName string, fileName string, compress string, maxSize int64) error {
+	_, err := pool.MountCustomVolume(projectName, volumeName, op)
+	if err != nil {
+		return fmt.Errorf("Failed mounting storage volume %q: %w", volumeName, err)
+	}
+
+	defer func() { _, _ = pool.UnmountCustomVolume(projectName, volumeName, op) }()
+
+	volStorageName := project.StorageVolume(projectName, volumeName)
+	mountPath := storageDrivers.GetVolumeMountPath(pool.Name(), storageDrivers.VolumeTypeCustom, volStorageName)
+
+	meta := op.Metadata()
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+
+	meta["volume_file"] = fileName
+	_ = op.UpdateMetadata(meta)
+
+	return instanceDebugMemoryDump(ctx, op, dump, filepath.Join(mountPath, fileName), false, compress, maxSize)
+}
+
+// instanceDebugMemoryDump writes the memory dump to dumpPath, replacing an existing file only when overwrite is set.
+// The partially written file is removed on failure.
+func instanceDebugMemoryDump(ctx context.Context, op *operations.Operation, dump debugMemoryDumper, dumpPath string, overwrite bool, compress string, maxSize int64) (err error) {
+	flags := os.O_CREATE | os.O_WRONLY | unix.O_NOFOLLOW
+	if overwrite {
+		flags |= os.O_TRUNC
+	} else {
+		flags |= os.O_EXCL
+	}
+
+	f, err := os.OpenFile(dumpPath, flags, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed creating dump file: %w", err)
+	}
+
+	defer func() {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			_ = os.Remove(dumpPath)
+		}
+	}()
+
+	return instanceDebugMemoryWrite(ctx, op, dump, f, compress, maxSize)
+}
+
+// instanceDebugMemoryWrite writes the memory dump to w, applying the requested compression and
+// size limit while reporting progress on the operation.
+func instanceDebugMemoryWrite(ctx context.Context, op *operations.Operation, dump debugMemoryDumper, w io.Writer, compress string, maxSize int64) error {
+	out := &debugMemoryWriter{w: w, limit: maxSize, hash: sha256.New()}
+
+	progress := func(completed int64, total int64) {
+		progress := fmt.Sprintf("%s written", units.GetByteSizeStringIEC(out.written.Load(), 2))
+		if total > 0 {
+			progress = fmt.Sprintf("%d%% (%s)", completed*100/total, progress)
+		}
+
+		meta := op.Metadata()
+		if meta == nil {
+			meta = make(map[string]any)
+		}
+
+		meta["memory_progress"] = progress
+		meta["bytes_written"] = out.written.Load()
+		operations.SetOperationProgress(meta, "", api.OperationProgress{
+			Stage:     "memory",
+			Processed: completed,
+			Total:     total,
+		})
+
+		_ = op.UpdateMetadata(meta)
+	}
+
+	if compress == "none" {
+		err := dump(ctx, out, progress)
+		if err != nil {
+			return err
+		}
+
+		return instanceDebugMemoryChecksum(op, out)
+	}
+
+	// Compress the dump as it gets produced.
+	reader, writer := io.Pipe()
+	compressDone := make(chan error, 1)
+	go func() {
+		err := compressFile(compress, reader, out)
+		_ = reader.CloseWithError(err)
+		compressDone <- err
+	}()
+
+	err := dump(ctx, writer, progress)
+	_ = writer.CloseWithError(err)
+
+	compressErr := <-compressDone
+	if err != nil {
+		return err
+	}
+
+	if compressErr != nil {
+		// Surface the size limit rather than the compressor's exit status.
+		if out.exceeded {
+			return errDebugMemoryTooLarge
+		}
+
+		return fmt.Errorf("Failed compressing memory dump: %w", compressErr)
+	}
+
+	return instanceDebugMemoryChecksum(op, out)
+}
+
+// instanceDebugMemoryChecksum records the final size and sha256 of a completed dump in the operation metadata.
+func instanceDebugMemoryChecksum(op *operations.Operation, out *debugMemoryWriter) error {
+	meta := op.Metadata()
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+
+	meta["bytes_written"] = out.written.Load()
+	meta["sha256"] = hex.EncodeToString(out.hash.Sum(nil))
+
+	return op.UpdateMetadata(meta)
+}
+
+// swagger:operation GET /1.0/instances/{name}/debug/qmp instances instance_debug_qmp_get
+//
+//	Query the QEMU monitor
+//
+//	Runs a read-only QMP command against a running virtual machine and returns its raw result.
+//
+//	This requires the can_access_debug entitlement on the instance.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: command
+//	    description: QMP command to run
+//	    type: string
+//	    example: query-status
+//	responses:
+//	  "200":
+//	    description: QMP result
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: object
+//	          description: Raw QMP result
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceDebugQMPGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if internalInstance.IsSnapshot(name) {
+		return response.BadRequest(fmt.Errorf("Invalid instance name"))
+	}
+
+	// Only allow commands which can't alter the VM.
+	command := request.QueryParam(r, "command")
+	if !slices.Contains(instanceDebugQMPCommands, command) {
+		return response.BadRequest(fmt.Errorf("QMP command %q isn't allowed, supported commands are: %s", command, strings.Join(instanceDebugQMPCommands, ", ")))
+	}
+
+	// Forward the request if the instance is remote.
+	resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.Type() != instancetype.VM {
+		return response.BadRequest(fmt.Errorf("QMP queries are only supported on virtual machines"))
+	}
+
+	if !inst.IsRunning() {
+		return response.BadRequest(fmt.Errorf("Instance is not running"))
+	}
+
+	result, err := inst.(instance.VM).QueryMonitor(command)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, result)
+}
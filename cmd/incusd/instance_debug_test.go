@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Only one of several simultaneous dump requests for the same instance gets to create its operation,
+// the others are told about the winning one.
+func TestDebugMemoryDumpsConcurrentStart(t *testing.T) {
+	dumps := &debugMemoryDumps{ops: map[string]string{}}
+
+	const requests = 20
+
+	var wg sync.WaitGroup
+	var created sync.Map
+	results := make([]string, requests)
+	errs := make([]error, requests)
+
+	start := make(chan struct{})
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			<-start
+			results[i], errs[i] = dumps.start("default_v1", func() (string, error) {
+				opID := fmt.Sprintf("op-%d", i)
+				created.Store(opID, true)
+
+				return opID, nil
+			})
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	var winner string
+	for i := 0; i < requests; i++ {
+		if errs[i] == nil {
+			assert.Empty(t, winner, "More than one dump was started")
+			winner = results[i]
+		} else {
+			assert.ErrorIs(t, errs[i], errDebugMemoryBusy)
+		}
+	}
+
+	assert.NotEmpty(t, winner)
+
+	// Every rejected request points at the running operation and no other operation got created.
+	for i := 0; i < requests; i++ {
+		assert.Equal(t, winner, results[i])
+	}
+
+	count := 0
+	created.Range(func(key any, value any) bool {
+		count++
+		return true
+	})
+
+	assert.Equal(t, 1, count)
+}
+
+// Dumps of different instances don't block each other and an instance can be dumped again once done.
+func TestDebugMemoryDumpsFinish(t *testing.T) {
+	dumps := &debugMemoryDumps{ops: map[string]string{}}
+
+	opID, err := dumps.start("default_v1", func() (string, error) { return "op1", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "op1", opID)
+
+	opID, err = dumps.start("default_v2", func() (string, error) { return "op2", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "op2", opID)
+
+	// A failed creation doesn't hold the instance.
+	_, err = dumps.start("default_v3", func() (string, error) { return "", fmt.Errorf("Failed") })
+	assert.Error(t, err)
+
+	opID, err = dumps.start("default_v3", func() (string, error) { return "op3", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "op3", opID)
+
+	dumps.finish("default_v1")
+
+	opID, err = dumps.start("default_v1", func() (string, error) { return "op4", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "op4", opID)
+}
+
+func TestInstanceDebugMemoryValidatePath(t *testing.T) {
+	tmpDir := t.TempDir()
+	varDir := filepath.Join(tmpDir, "var")
+	dumpDir := filepath.Join(tmpDir, "dumps")
+
+	for _, dir := range []string{filepath.Join(varDir, "database"), dumpDir} {
+		err := os.MkdirAll(dir, 0700)
+		require.NoError(t, err)
+	}
+
+	existing := filepath.Join(dumpDir, "existing.elf")
+	err := os.WriteFile(existing, []byte("dump"), 0600)
+	require.NoError(t, err)
+
+	err = os.Symlink(filepath.Join(varDir, "database"), filepath.Join(dumpDir, "db"))
+	require.NoError(t, err)
+
+	err = os.Symlink(filepath.Join(varDir, "database", "global.db"), filepath.Join(dumpDir, "link.elf"))
+	require.NoError(t, err)
+
+	err = os.Symlink(existing, filepath.Join(dumpDir, "existing-link.elf"))
+	require.NoError(t, err)
+
+	deniedDirs := []string{varDir}
+
+	tests := []struct {
+		name      string
+		path      string
+		overwrite bool
+		want      string
+		wantErr   string
+	}{
+		{name: "new file", path: filepath.Join(dumpDir, "v1.elf"), want: filepath.Join(dumpDir, "v1.elf")},
+		{name: "relative path", path: "v1.elf", wantErr: "must be absolute"},
+		{name: "database directory", path: filepath.Join(varDir, "database", "global.db"), wantErr: "can't be within"},
+		{name: "denied directory itself", path: varDir, overwrite: true, wantErr: "can't be within"},
+		{name: "dot-dot into denied directory", path: filepath.Join(dumpDir, "..", "var", "database", "x"), wantErr: "can't be within"},
+		{name: "symlinked parent", path: filepath.Join(dumpDir, "db", "global.db"), wantErr: "can't be within"},
+		{name: "dangling symlink into denied directory", path: filepath.Join(dumpDir, "link.elf"), wantErr: "dangling symlink"},
+		{name: "missing parent", path: filepath.Join(dumpDir, "missing", "v1.elf"), wantErr: "doesn't exist"},
+		{name: "parent is a file", path: filepath.Join(existing, "v1.elf"), wantErr: "isn't a directory"},
+		{name: "existing file", path: existing, wantErr: "already exists"},
+		{name: "existing file with overwrite", path: existing, overwrite: true, want: existing},
+		{name: "symlink to existing file", path: filepath.Join(dumpDir, "existing-link.elf"), overwrite: true, want: existing},
+		{name: "existing directory", path: dumpDir, overwrite: true, wantErr: "isn't a regular file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := instanceDebugMemoryValidatePath(tt.path, tt.overwrite, deniedDirs)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/internal/revert"
+	"github.com/lxc/incus/v6/internal/server/db"
+	"github.com/lxc/incus/v6/internal/server/db/operationtype"
+	deviceConfig "github.com/lxc/incus/v6/internal/server/device/config"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/operations"
+	projecthelpers "github.com/lxc/incus/v6/internal/server/project"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/util"
+)
+
+// swagger:operation PATCH /1.0/instances/{name}/devices/{device} instances instance_device_patch
+//
+//	Partially update an instance device
+//
+//	Updates a subset of the configuration of an instance device.
+//	Keys set to an empty value are removed from the device.
+//	Changing the device holding the root filesystem requires the force parameter.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: force
+//	    description: Allow changing the device holding the root filesystem
+//	    type: boolean
+//	    example: false
+//	  - in: body
+//	    name: device
+//	    description: Device configuration keys
+//	    required: true
+//	    schema:
+//	      type: object
+//	      additionalProperties:
+//	        type: string
+//	responses:
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceDevicePatch(d *Daemon, r *http.Request) response.Response {
+	req := map[string]string{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return instanceDeviceUpdate(d, r, func(devices map[string]map[string]string, devName string) {
+		for k, v := range req {
+			if v == "" {
+				delete(devices[devName], k)
+				continue
+			}
+
+			devices[devName][k] = v
+		}
+	})
+}
+
+// swagger:operation DELETE /1.0/instances/{name}/devices/{device} instances instance_device_delete
+//
+//	Remove an instance device
+//
+//	Removes a device from the instance.
+//	Removing the device holding the root filesystem requires the force parameter.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: force
+//	    description: Allow changing the device holding the root filesystem
+//	    type: boolean
+//	    example: false
+//	responses:
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceDeviceDelete(d *Daemon, r *http.Request) response.Response {
+	return instanceDeviceUpdate(d, r, func(devices map[string]map[string]string, devName string) {
+		delete(devices, devName)
+	})
+}
+
+// instanceDeviceUpdate applies the change made by update to an existing device of the instance.
+func instanceDeviceUpdate(d *Daemon, r *http.Request, update func(devices map[string]map[string]string, devName string)) response.Response {
+	// Don't mess with instance while in setup mode.
+	<-d.waitReady.Done()
+
+	s := d.State()
+
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := request.ProjectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	devName, err := url.PathUnescape(mux.Vars(r)["device"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if internalInstance.IsSnapshot(name) {
+		return response.BadRequest(fmt.Errorf("Invalid instance name"))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	unlock, err := instanceOperationLock(s.ShutdownCtx, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	revert.Add(func() {
+		unlock()
+	})
+
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	devices := inst.LocalDevices().CloneNative()
+	_, ok := devices[devName]
+	if !ok {
+		_, ok = inst.ExpandedDevices()[devName]
+		if ok {
+			return response.BadRequest(fmt.Errorf("Device from profile(s) cannot be modified for individual instance"))
+		}
+
+		return response.NotFound(fmt.Errorf("Device %q not found", devName))
+	}
+
+	holdsRoot := internalInstance.HoldsRootDisk(inst.ExpandedDevices().CloneNative(), devName)
+	device := maps.Clone(devices[devName])
+
+	update(devices, devName)
+
+	if holdsRoot && internalInstance.AffectsRootDisk(device, devices[devName]) && !util.IsTrue(request.QueryParam(r, "force")) {
+		return response.BadRequest(fmt.Errorf("Device %q holds the root filesystem of the instance, changing it may prevent the instance from starting (use force to proceed anyway)", devName))
+	}
+
+	profileNames := make([]string, 0, len(inst.Profiles()))
+	for _, profile := range inst.Profiles() {
+		profileNames = append(profileNames, profile.Name)
+	}
+
+	// Check project limits.
+	req := api.InstancePut{
+		Config:      inst.LocalConfig(),
+		Description: inst.Description(),
+		Devices:     devices,
+		Ephemeral:   inst.IsEphemeral(),
+		Profiles:    profileNames,
+	}
+
+	err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return projecthelpers.AllowInstanceUpdate(tx, projectName, name, req, inst.LocalConfig())
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	do := func(op *operations.Operation) error {
+		defer unlock()
+
+		args := db.InstanceArgs{
+			Architecture: inst.Architecture(),
+			Config:       inst.LocalConfig(),
+			Description:  inst.Description(),
+			Devices:      deviceConfig.NewDevices(devices),
+			Ephemeral:    inst.IsEphemeral(),
+			Profiles:     inst.Profiles(),
+			Project:      projectName,
+		}
+
+		return inst.Update(args, true)
+	}
+
+	resources := map[string][]api.URL{}
+	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", name)}
+
+	op, err := operations.OperationCreate(s, projectName, operations.OperationClassTask, operationtype.InstanceUpdate, resources, nil, do, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	revert.Success()
+	return operations.OperationResponse(op)
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"archive/tar"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -89,12 +91,14 @@ func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
 //
 //	Get a file
 //
-//	Gets the file content. If it's a directory, a json list of files will be returned instead.
+//	Gets the file content. If it's a directory, a json list of files will be returned instead,
+//	unless a tarball of the whole tree is requested.
 //
 //	---
 //	produces:
 //	  - application/json
 //	  - application/octet-stream
+//	  - application/x-tar
 //	parameters:
 //	  - in: query
 //	    name: path
@@ -102,6 +106,11 @@ func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
 //	    type: string
 //	    example: default
 //	  - in: query
+//	    name: format
+//	    description: Set to "tar" to get a directory and its content as a tarball
+//	    type: string
+//	    example: tar
+//	  - in: query
 //	    name: project
 //	    description: Project name
 //	    type: string
@@ -189,6 +198,26 @@ func instanceFileGet(s *state.State, inst instance.Instance, path string, r *htt
 		"X-Incus-type":     fileType,
 	}
 
+	if r.FormValue("format") == "tar" {
+		if fileType != "directory" {
+			return response.BadRequest(fmt.Errorf("Only directories can be retrieved as a tarball"))
+		}
+
+		// Setup cleanup logic.
+		cleanup := revert.Clone()
+		revert.Success()
+
+		s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceFileRetrieved.Event(inst, logger.Ctx{"path": path}))
+		return response.ManualResponse(func(w http.ResponseWriter) error {
+			defer cleanup.Fail()
+
+			w.Header().Set("Content-Type", "application/x-tar")
+			w.WriteHeader(http.StatusOK)
+
+			return instanceFileWriteTar(client, path, w)
+		})
+	}
+
 	if fileType == "file" {
 		// Open the file.
 		file, err := client.Open(path)
@@ -375,10 +404,12 @@ func instanceFileHead(s *state.State, inst instance.Instance, path string, r *ht
 //	Create or replace a file
 //
 //	Creates a new file in the instance.
+//	With the "tar" type, the tarball in the body is extracted into the existing directory at path.
 //
 //	---
 //	consumes:
 //	  - application/octet-stream
+//	  - application/x-tar
 //	produces:
 //	  - application/json
 //	parameters:
@@ -415,7 +446,7 @@ func instanceFileHead(s *state.State, inst instance.Instance, path string, r *ht
 //	    example: 0644
 //	  - in: header
 //	    name: X-Incus-type
-//	    description: Type of file (file, symlink or directory)
+//	    description: Type of file (file, symlink, directory or tar)
 //	    schema:
 //	      type: string
 //	    example: file
@@ -555,6 +586,24 @@ func instanceFilePost(s *state.State, inst instance.Instance, path string, r *ht
 			}
 		}
 
+		s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceFilePushed.Event(inst, logger.Ctx{"path": path}))
+		return response.EmptySyncResponse
+	} else if type_ == "tar" {
+		stat, err := client.Stat(path)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if !stat.IsDir() {
+			return response.BadRequest(fmt.Errorf("Tarballs can only be extracted into a directory"))
+		}
+
+		// Extract the tarball into the instance.
+		err = instanceFileExtractTar(client, path, r.Body)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
 		s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceFilePushed.Event(inst, logger.Ctx{"path": path}))
 		return response.EmptySyncResponse
 	} else {
@@ -562,6 +611,181 @@ func instanceFilePost(s *state.State, inst instance.Instance, path string, r *ht
 	}
 }
 
+// instanceFileWriteTar writes the directory at dirPath and everything below it as a tarball.
+// Entry names are relative to the parent of dirPath, so the directory itself is included.
+func instanceFileWriteTar(client *sftp.Client, dirPath string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	parent := filepath.Dir(dirPath)
+
+	walker := client.Walk(dirPath)
+	for walker.Step() {
+		err := walker.Err()
+		if err != nil {
+			return err
+		}
+
+		entryPath := walker.Path()
+		stat := walker.Stat()
+
+		var link string
+		if stat.Mode()&os.ModeSymlink == os.ModeSymlink {
+			link, err = client.ReadLink(entryPath)
+			if err != nil {
+				return err
+			}
+		} else if !stat.IsDir() && !stat.Mode().IsRegular() {
+			// Skip devices, sockets and pipes.
+			continue
+		}
+
+		hdr, err := tar.FileInfoHeader(stat, link)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name, err = filepath.Rel(parent, entryPath)
+		if err != nil {
+			return err
+		}
+
+		if stat.IsDir() {
+			hdr.Name += "/"
+		}
+
+		fileStat, ok := stat.Sys().(*sftp.FileStat)
+		if ok {
+			hdr.Uid = int(fileStat.UID)
+			hdr.Gid = int(fileStat.GID)
+		}
+
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		if stat.Mode().IsRegular() {
+			file, err := client.Open(entryPath)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(tw, file)
+			_ = file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Close()
+}
+
+// instanceFileExtractTar extracts the tarball read from r into the directory at dirPath.
+// Directories, regular files and symlinks are supported, entries can't escape dirPath, including through the
+// symlinks extracted before them.
+func instanceFileExtractTar(client *sftp.Client, dirPath string, r io.Reader) error {
+	tr := tar.NewReader(r)
+
+	// Symlinks extracted so far, which would let the entries going through them escape dirPath.
+	symlinks := map[string]bool{}
+
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return api.StatusErrorf(http.StatusBadRequest, "Invalid tarball: %v", err)
+		}
+
+		// Keep the entry within the target directory.
+		target := filepath.Join(dirPath, filepath.Clean("/"+hdr.Name))
+		if target == filepath.Clean(dirPath) {
+			continue
+		}
+
+		link := instanceFileTarSymlink(symlinks, dirPath, target, hdr.Typeflag != tar.TypeSymlink)
+		if link != "" {
+			return api.StatusErrorf(http.StatusBadRequest, "Tarball entry %q goes through symlink %q", hdr.Name, link)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			_, err = client.Lstat(target)
+			if err != nil {
+				err = client.Mkdir(target)
+				if err != nil {
+					return err
+				}
+			}
+
+		case tar.TypeReg:
+			file, err := client.OpenFile(target, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(file, tr)
+			_ = file.Close()
+			if err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			// Check if already setup.
+			currentTarget, err := client.ReadLink(target)
+			if err == nil && currentTarget == hdr.Linkname {
+				continue
+			}
+
+			err = client.Symlink(hdr.Linkname, target)
+			if err != nil {
+				return err
+			}
+
+			symlinks[target] = true
+
+			continue
+
+		default:
+			return api.StatusErrorf(http.StatusBadRequest, "Unsupported type for tarball entry %q", hdr.Name)
+		}
+
+		// Set file permissions and ownership.
+		err = client.Chmod(target, fs.FileMode(hdr.Mode).Perm())
+		if err != nil {
+			return err
+		}
+
+		err = client.Chown(target, hdr.Uid, hdr.Gid)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// instanceFileTarSymlink returns the symlink among the extracted ones which target, or one of its parents below
+// dirPath, is. Target itself is only considered when self is true.
+func instanceFileTarSymlink(symlinks map[string]bool, dirPath string, target string, self bool) string {
+	dirPath = filepath.Clean(dirPath)
+
+	path := target
+	if !self {
+		path = filepath.Dir(target)
+	}
+
+	for path != dirPath && path != "/" && path != "." {
+		if symlinks[path] {
+			return path
+		}
+
+		path = filepath.Dir(path)
+	}
+
+	return ""
+}
+
 // swagger:operation DELETE /1.0/instances/{name}/files instances instance_files_delete
 //
 //	Delete a file
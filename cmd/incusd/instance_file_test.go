@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tarball entries can't go through the symlinks extracted before them, as those may point anywhere.
+func TestInstanceFileTarSymlink(t *testing.T) {
+	symlinks := map[string]bool{"/root/dir/link": true}
+
+	tests := []struct {
+		target string
+		self   bool
+		link   string
+	}{
+		{"/root/dir/file", true, ""},
+		{"/root/dir/sub/file", true, ""},
+		{"/root/dir/link", true, "/root/dir/link"},
+		{"/root/dir/link", false, ""},
+		{"/root/dir/link/file", true, "/root/dir/link"},
+		{"/root/dir/link/sub/file", false, "/root/dir/link"},
+		{"/root/dir/linked", true, ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.link, instanceFileTarSymlink(symlinks, "/root/dir/", tt.target, tt.self), tt.target)
+	}
+}
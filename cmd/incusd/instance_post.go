@@ -787,7 +787,7 @@ func migrateInstance(ctx context.Context, s *state.State, inst instance.Instance
 			Source: api.InstanceSource{
 				Type:        "migration",
 				Mode:        "pull",
-				Operation:   fmt.Sprintf("https://%s%s", sourceMemberInfo.Address, sourceOp.URL()),
+				Operation:   fmt.Sprintf("https://%s%s", migrationSourceAddress(s, sourceMemberInfo.Address, targetMemberInfo.Address), sourceOp.URL()),
 				Websockets:  sourceSecrets,
 				Certificate: string(networkCert.PublicKey()),
 				Live:        req.Live,
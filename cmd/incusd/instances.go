@@ -47,6 +47,14 @@ var instanceCmd = APIEndpoint{
 	Patch:  APIEndpointAction{Handler: instancePatch, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanEdit, "name")},
 }
 
+var instanceDeviceCmd = APIEndpoint{
+	Name: "instanceDevice",
+	Path: "instances/{name}/devices/{device}",
+
+	Delete: APIEndpointAction{Handler: instanceDeviceDelete, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanEdit, "name")},
+	Patch:  APIEndpointAction{Handler: instanceDevicePatch, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanEdit, "name")},
+}
+
 var instanceRebuildCmd = APIEndpoint{
 	Name: "instanceRebuild",
 	Path: "instances/{name}/rebuild",
@@ -163,6 +171,20 @@ var instanceAccessCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: instanceAccess, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanView, "name")},
 }
 
+var instanceDebugMemoryCmd = APIEndpoint{
+	Name: "instanceDebugMemory",
+	Path: "instances/{name}/debug/memory",
+
+	Get: APIEndpointAction{Handler: instanceDebugMemoryGet, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanAccessDebug, "name")},
+}
+
+var instanceDebugQMPCmd = APIEndpoint{
+	Name: "instanceDebugQMP",
+	Path: "instances/{name}/debug/qmp",
+
+	Get: APIEndpointAction{Handler: instanceDebugQMPGet, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanAccessDebug, "name")},
+}
+
 type instanceAutostartList []instance.Instance
 
 func (slice instanceAutostartList) Len() int {
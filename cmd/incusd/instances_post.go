@@ -483,6 +483,11 @@ func createFromCopy(ctx context.Context, s *state.State, r *http.Request, projec
 	// Devices override
 	sourceDevices := source.LocalDevices()
 
+	err = internalInstance.ValidNewDeviceNames(req.Devices, sourceDevices.CloneNative())
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	if req.Devices == nil {
 		req.Devices = make(map[string]map[string]string)
 	}
@@ -1079,6 +1084,15 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
+	// The devices of instances being copied or migrated keep their names, the copies being checked once merged
+	// with the devices of their source.
+	if req.Source.Type == "image" || req.Source.Type == "none" {
+		err = internalInstance.ValidNewDeviceNames(req.Devices)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	if s.ServerClustered && !clusterNotification && targetMemberInfo == nil {
 		// Run instance placement scriptlet if enabled and no cluster member selected yet.
 		if s.GlobalConfig.InstancesPlacementScriptlet() != "" {
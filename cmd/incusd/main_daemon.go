@@ -68,8 +68,9 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 	signal.Notify(sigCh, unix.SIGQUIT)
 	signal.Notify(sigCh, unix.SIGTERM)
 
-	chIgnore := make(chan os.Signal, 1)
-	signal.Notify(chIgnore, unix.SIGHUP)
+	// SIGHUP refreshes the listeners bound to the address of a network interface.
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, unix.SIGHUP)
 
 	err := d.Init()
 	if err != nil {
@@ -88,6 +89,12 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 				}()
 			}
 
+		case sig := <-hupCh:
+			logger.Info("Received signal, refreshing listen addresses", logger.Ctx{"signal": sig})
+			if d.shutdownCtx.Err() == nil {
+				go d.refreshListenAddresses()
+			}
+
 		case err = <-d.shutdownDoneCh:
 			return err
 		}
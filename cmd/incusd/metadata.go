@@ -13,6 +13,12 @@ var metadataConfigurationCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: metadataConfigurationGet, AllowUntrusted: true},
 }
 
+var metadataDeviceTypesCmd = APIEndpoint{
+	Path: "metadata/device-types",
+
+	Get: APIEndpointAction{Handler: metadataDeviceTypesGet, AllowUntrusted: true},
+}
+
 // swagger:operation GET /1.0/metadata/configuration metadata_configuration_get
 //
 //	Get the metadata configuration
@@ -51,3 +57,50 @@ var metadataConfigurationCmd = APIEndpoint{
 func metadataConfigurationGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponse(true, metadata.Data)
 }
+
+// swagger:operation GET /1.0/metadata/device-types metadata_device_types_get
+//
+//	Get the device types
+//
+//	Returns the configuration keys of each device type, indexed by device type.
+//
+//	---
+//	produces:
+//	  - application/json
+//	responses:
+//	  "200":
+//	    description: Device types
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: object
+//	          additionalProperties:
+//	            type: array
+//	            items:
+//	              $ref: "#/definitions/MetadataDeviceTypeKey"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func metadataDeviceTypesGet(d *Daemon, r *http.Request) response.Response {
+	deviceTypes, err := metadata.DeviceTypes()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.SyncResponse(true, deviceTypes)
+}
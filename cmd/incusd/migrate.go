@@ -8,7 +8,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -17,11 +19,15 @@ import (
 
 	"github.com/lxc/incus/v6/internal/jmap"
 	"github.com/lxc/incus/v6/internal/migration"
+	"github.com/lxc/incus/v6/internal/ports"
 	"github.com/lxc/incus/v6/internal/server/instance"
 	localMigration "github.com/lxc/incus/v6/internal/server/migration"
 	"github.com/lxc/incus/v6/internal/server/operations"
+	"github.com/lxc/incus/v6/internal/server/state"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/idmap"
+	"github.com/lxc/incus/v6/shared/logger"
 )
 
 type migrationFields struct {
@@ -246,3 +252,42 @@ func (s *migrationSink) Connect(op *operations.Operation, r *http.Request, w htt
 	// operation actually exists.
 	return api.StatusErrorf(http.StatusForbidden, "Invalid migration sink secret")
 }
+
+// migrationSourceAddress returns the address the target member should use to reach a migration source running on
+// this member. On multi-homed hosts, this is the local address used to reach the target when the network listener
+// serves it, so the target connects back over the same path. The member address is used otherwise.
+func migrationSourceAddress(s *state.State, memberAddress string, targetAddress string) string {
+	networkAddress := s.Endpoints.NetworkAddress()
+	if networkAddress == "" {
+		return memberAddress
+	}
+
+	outbound, err := internalUtil.GetOutboundAddress(targetAddress)
+	if err != nil {
+		logger.Debug("Failed getting outbound address for migration", logger.Ctx{"target": targetAddress, "err": err})
+		return memberAddress
+	}
+
+	member, err := internalUtil.ParseNetworkAddress(memberAddress, ports.HTTPSDefaultPort)
+	if err != nil {
+		return memberAddress
+	}
+
+	host, _, err := net.SplitHostPort(outbound)
+	if err != nil || host == member.Host {
+		return memberAddress
+	}
+
+	// Only the network listener may serve addresses other than the member address.
+	listener, err := internalUtil.ParseNetworkAddress(networkAddress, ports.HTTPSDefaultPort)
+	if err != nil {
+		return memberAddress
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(listener.Port))
+	if !internalUtil.IsAddressCovered(address, networkAddress) {
+		return memberAddress
+	}
+
+	return address
+}
@@ -410,6 +410,14 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	// Check that the subnets don't overlap with the ones of the other networks of the project.
+	if clientType != clusterRequest.ClientTypeJoiner {
+		err = network.ValidateSubnetOverlap(s, projectName, req.Name, req.Config)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	targetNode := request.QueryParam(r, "target")
 	if targetNode != "" {
 		if !netTypeInfo.NodeSpecificConfig {
@@ -1346,7 +1354,7 @@ func networkPut(d *Daemon, r *http.Request) response.Response {
 
 	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
 
-	response := doNetworkUpdate(projectName, n, req, targetNode, clientType, r.Method, s.ServerClustered)
+	response := doNetworkUpdate(s, projectName, n, req, targetNode, clientType, r.Method, s.ServerClustered)
 
 	requestor := request.CreateRequestor(r)
 	s.Events.SendLifecycle(projectName, lifecycle.NetworkUpdated.Event(n, requestor, nil))
@@ -1399,7 +1407,7 @@ func networkPatch(d *Daemon, r *http.Request) response.Response {
 
 // doNetworkUpdate loads the current local network config, merges with the requested network config, validates
 // and applies the changes. Will also notify other cluster nodes of non-node specific config if needed.
-func doNetworkUpdate(projectName string, n network.Network, req api.NetworkPut, targetNode string, clientType clusterRequest.ClientType, httpMethod string, clustered bool) response.Response {
+func doNetworkUpdate(s *state.State, projectName string, n network.Network, req api.NetworkPut, targetNode string, clientType clusterRequest.ClientType, httpMethod string, clustered bool) response.Response {
 	if req.Config == nil {
 		req.Config = map[string]string{}
 	}
@@ -1432,6 +1440,14 @@ func doNetworkUpdate(projectName string, n network.Network, req api.NetworkPut,
 		return response.BadRequest(err)
 	}
 
+	// Check that changed subnets don't overlap with the ones of the other networks of the project.
+	if clientType == clusterRequest.ClientTypeNormal && (req.Config["ipv4.address"] != n.Config()["ipv4.address"] || req.Config["ipv6.address"] != n.Config()["ipv6.address"]) {
+		err = network.ValidateSubnetOverlap(s, projectName, n.Name(), req.Config)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	// Apply the new configuration (will also notify other cluster nodes if needed).
 	err = n.Update(req, targetNode, clientType)
 	if err != nil {
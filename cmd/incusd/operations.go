@@ -43,6 +43,12 @@ var operationsCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: operationsGet, AccessHandler: allowAuthenticated},
 }
 
+var operationFileCmd = APIEndpoint{
+	Path: "operations/{id}/files/{name}",
+
+	Get: APIEndpointAction{Handler: operationFileGet, AccessHandler: allowAuthenticated},
+}
+
 var operationWait = APIEndpoint{
 	Path: "operations/{id}/wait",
 
@@ -224,6 +230,89 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 	return response.ForwardedResponse(client, r)
 }
 
+// swagger:operation GET /1.0/operations/{id}/files/{name} operations operation_file_get
+//
+//	Get a file attached to the operation
+//
+//	Downloads a file produced by the operation.
+//	Each file can only be downloaded once, being removed from the server afterwards.
+//	The X-Incus-sha256 header holds the SHA256 checksum of the file.
+//
+//	---
+//	produces:
+//	  - application/octet-stream
+//	responses:
+//	  "200":
+//	    description: Raw file data
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func operationFileGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	id, err := url.PathUnescape(mux.Vars(r)["id"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// First check if the file is attached to an operation from this node
+	file, err := operations.TakeFile(id, name)
+	if err == nil {
+		ent := response.FileResponseEntry{
+			Path:     file.Path,
+			Filename: file.Name,
+			Cleanup:  file.Remove,
+		}
+
+		return response.FileResponse(r, []response.FileResponseEntry{ent}, map[string]string{"X-Incus-sha256": file.SHA256})
+	}
+
+	// Then check if the operation is running on another node, and, if so, forward the request
+	var address string
+	err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+		filter := dbCluster.OperationFilter{UUID: &id}
+		ops, err := dbCluster.GetOperations(ctx, tx.Tx(), filter)
+		if err != nil {
+			return err
+		}
+
+		if len(ops) < 1 {
+			return api.StatusErrorf(http.StatusNotFound, "Operation file not found")
+		}
+
+		if len(ops) > 1 {
+			return fmt.Errorf("More than one operation matches")
+		}
+
+		operation := ops[0]
+
+		address = operation.NodeAddress
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if address == "" || address == s.LocalConfig.ClusterAddress() {
+		return response.NotFound(fmt.Errorf("Operation file not found"))
+	}
+
+	client, err := cluster.Connect(address, s.Endpoints.NetworkCert(), s.ServerCert(), r, false)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.ForwardedResponse(client, r)
+}
+
 // swagger:operation DELETE /1.0/operations/{id} operations operation_delete
 //
 //	Cancel the operation
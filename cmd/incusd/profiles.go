@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"slices"
@@ -15,6 +16,7 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/lxc/incus/v6/client"
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
 	"github.com/lxc/incus/v6/internal/jmap"
 	"github.com/lxc/incus/v6/internal/server/auth"
 	"github.com/lxc/incus/v6/internal/server/cluster"
@@ -51,6 +53,13 @@ var profileCmd = APIEndpoint{
 	Put:    APIEndpointAction{Handler: profilePut, AccessHandler: allowPermission(auth.ObjectTypeProfile, auth.EntitlementCanEdit, "name")},
 }
 
+var profileDeviceCmd = APIEndpoint{
+	Path: "profiles/{name}/devices/{device}",
+
+	Delete: APIEndpointAction{Handler: profileDeviceDelete, AccessHandler: allowPermission(auth.ObjectTypeProfile, auth.EntitlementCanEdit, "name")},
+	Patch:  APIEndpointAction{Handler: profileDevicePatch, AccessHandler: allowPermission(auth.ObjectTypeProfile, auth.EntitlementCanEdit, "name")},
+}
+
 // swagger:operation GET /1.0/profiles profiles profiles_get
 //
 //  Get the profiles
@@ -315,6 +324,11 @@ func profilesPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
+	err = internalInstance.ValidNewDeviceNames(req.Devices)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// Update DB entry.
 	err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
 		devices, err := dbCluster.APIToDevices(req.Devices)
@@ -553,9 +567,7 @@ func profilePut(d *Daemon, r *http.Request) response.Response {
 			return response.SmartError(err)
 		}
 
-		err = notifier(func(client incus.InstanceServer) error {
-			return client.UseProject(p.Name).UpdateProfile(name, profile.ProfilePut, "")
-		})
+		err = notifyProfileUpdate(notifier, p.Name, name, profile.ProfilePut)
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -567,6 +579,14 @@ func profilePut(d *Daemon, r *http.Request) response.Response {
 	return response.SmartError(err)
 }
 
+// notifyProfileUpdate sends the profile as it was before being updated to the other cluster members,
+// so that they apply the changes to their own instances using it.
+func notifyProfileUpdate(notifier cluster.Notifier, projectName string, profileName string, old api.ProfilePut) error {
+	return notifier(func(client incus.InstanceServer) error {
+		return client.UseProject(projectName).UpdateProfile(profileName, old, "")
+	})
+}
+
 // swagger:operation PATCH /1.0/profiles/{name} profiles profile_patch
 //
 //	Partially update the profile
@@ -699,6 +719,192 @@ func profilePatch(d *Daemon, r *http.Request) response.Response {
 	return response.SmartError(doProfileUpdate(r.Context(), s, *p, name, id, profile, req))
 }
 
+// swagger:operation PATCH /1.0/profiles/{name}/devices/{device} profiles profile_device_patch
+//
+//	Partially update a profile device
+//
+//	Updates a subset of the configuration of a profile device.
+//	Keys set to an empty value are removed from the device.
+//	Changing the device holding the root filesystem requires the force parameter.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: force
+//	    description: Allow changing the device holding the root filesystem
+//	    type: boolean
+//	    example: false
+//	  - in: body
+//	    name: device
+//	    description: Device configuration keys
+//	    required: true
+//	    schema:
+//	      type: object
+//	      additionalProperties:
+//	        type: string
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func profileDevicePatch(d *Daemon, r *http.Request) response.Response {
+	req := map[string]string{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return profileDeviceUpdate(d, r, func(devices map[string]map[string]string, devName string) {
+		for k, v := range req {
+			if v == "" {
+				delete(devices[devName], k)
+				continue
+			}
+
+			devices[devName][k] = v
+		}
+	})
+}
+
+// swagger:operation DELETE /1.0/profiles/{name}/devices/{device} profiles profile_device_delete
+//
+//	Remove a profile device
+//
+//	Removes a device from the profile.
+//	Removing the device holding the root filesystem requires the force parameter.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: force
+//	    description: Allow changing the device holding the root filesystem
+//	    type: boolean
+//	    example: false
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func profileDeviceDelete(d *Daemon, r *http.Request) response.Response {
+	return profileDeviceUpdate(d, r, func(devices map[string]map[string]string, devName string) {
+		delete(devices, devName)
+	})
+}
+
+// profileDeviceUpdate applies the change made by update to an existing device of the profile.
+func profileDeviceUpdate(d *Daemon, r *http.Request, update func(devices map[string]map[string]string, devName string)) response.Response {
+	s := d.State()
+
+	p, err := project.ProfileProject(s.DB.Cluster, request.ProjectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	devName, err := url.PathUnescape(mux.Vars(r)["device"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var id int64
+	var profile *api.Profile
+
+	err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+		current, err := dbCluster.GetProfile(ctx, tx.Tx(), p.Name, name)
+		if err != nil {
+			return fmt.Errorf("Failed to retrieve profile=%q: %w", name, err)
+		}
+
+		profile, err = current.ToAPI(ctx, tx.Tx())
+		if err != nil {
+			return err
+		}
+
+		id = int64(current.ID)
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	devices := deviceConfig.NewDevices(profile.Devices).CloneNative()
+	_, ok := devices[devName]
+	if !ok {
+		return response.NotFound(fmt.Errorf("Device %q not found", devName))
+	}
+
+	holdsRoot := internalInstance.HoldsRootDisk(devices, devName)
+	device := maps.Clone(devices[devName])
+
+	update(devices, devName)
+
+	if holdsRoot && internalInstance.AffectsRootDisk(device, devices[devName]) && !util.IsTrue(request.QueryParam(r, "force")) {
+		return response.BadRequest(fmt.Errorf("Device %q holds the root filesystem of the profile, changing it may prevent instances using it from starting (use force to proceed anyway)", devName))
+	}
+
+	req := api.ProfilePut{
+		Config:      profile.Config,
+		Description: profile.Description,
+		Devices:     devices,
+	}
+
+	err = doProfileUpdate(r.Context(), s, *p, name, id, profile, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		// Notify all other nodes. If a node is down, it will be ignored.
+		notifier, err := cluster.NewNotifier(s, s.Endpoints.NetworkCert(), s.ServerCert(), cluster.NotifyAlive)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		err = notifyProfileUpdate(notifier, p.Name, name, profile.ProfilePut)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	requestor := request.CreateRequestor(r)
+	s.Events.SendLifecycle(p.Name, lifecycle.ProfileUpdated.Event(name, p.Name, requestor, nil))
+
+	return response.EmptySyncResponse
+}
+
 // swagger:operation POST /1.0/profiles/{name} profiles profile_post
 //
 //	Rename the profile
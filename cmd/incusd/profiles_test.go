@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// profileUpdateServer records the profile updates a cluster member is notified of.
+type profileUpdateServer struct {
+	incus.InstanceServer
+
+	project string
+	updates map[string]api.ProfilePut
+}
+
+func (s *profileUpdateServer) UseProject(name string) incus.InstanceServer {
+	return &profileUpdateServer{project: name, updates: s.updates}
+}
+
+func (s *profileUpdateServer) UpdateProfile(name string, profile api.ProfilePut, ETag string) error {
+	s.updates[s.project+"/"+name] = profile
+
+	return nil
+}
+
+// The other members are sent the profile as it was before the update, to apply the changes to their instances.
+func TestNotifyProfileUpdate(t *testing.T) {
+	members := []*profileUpdateServer{
+		{updates: map[string]api.ProfilePut{}},
+		{updates: map[string]api.ProfilePut{}},
+	}
+
+	notifier := func(hook func(incus.InstanceServer) error) error {
+		for _, member := range members {
+			err := hook(member)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	old := api.ProfilePut{
+		Description: "Web servers",
+		Devices: map[string]map[string]string{
+			"eth0": {"type": "nic", "network": "incusbr0"},
+		},
+	}
+
+	err := notifyProfileUpdate(notifier, "web", "frontend", old)
+	assert.NoError(t, err)
+
+	for _, member := range members {
+		assert.Equal(t, map[string]api.ProfilePut{"web/frontend": old}, member.updates)
+	}
+}
@@ -37,6 +37,12 @@ func doProfileUpdate(ctx context.Context, s *state.State, p api.Project, profile
 		return err
 	}
 
+	// Only the names of devices being added have to follow the naming rules.
+	err = internalInstance.ValidNewDeviceNames(req.Devices, profile.Devices)
+	if err != nil {
+		return err
+	}
+
 	insts, projects, err := getProfileInstancesInfo(ctx, s.DB.Cluster, p.Name, profileName)
 	if err != nil {
 		return fmt.Errorf("Failed to query instances associated with profile %q: %w", profileName, err)
@@ -1480,7 +1480,7 @@ func storageVolumePostClusteringMigrate(s *state.State, r *http.Request, srcPool
 			Source: api.StorageVolumeSource{
 				Type:        "migration",
 				Mode:        "pull",
-				Operation:   fmt.Sprintf("https://%s%s", srcMember.Address, srcOp.URL()),
+				Operation:   fmt.Sprintf("https://%s%s", migrationSourceAddress(s, srcMember.Address, newMember.Address), srcOp.URL()),
 				Websockets:  sourceSecrets,
 				Certificate: string(networkCert.PublicKey()),
 				Name:        newVolumeName,
@@ -237,6 +237,9 @@ func storagePoolVolumeSnapshotsTypePost(d *Daemon, r *http.Request) response.Res
 
 	// Create the snapshot.
 	snapshot := func(op *operations.Operation) error {
+		thaw := storagePoolVolumeFreezeUsers(s, poolName, projectName, &parentDBVolume.StorageVolume)
+		defer thaw()
+
 		return pool.CreateCustomVolumeSnapshot(projectName, volumeName, req.Name, expiry, op)
 	}
 
@@ -1437,7 +1440,10 @@ func autoCreateCustomVolumeSnapshots(ctx context.Context, s *state.State, volume
 			return fmt.Errorf("Error loading pool for volume %q (project %q, pool %q): %w", v.Name, v.ProjectName, v.PoolName, err)
 		}
 
+		vol := api.StorageVolume{Name: v.Name, Type: db.StoragePoolVolumeTypeNameCustom, Config: v.Config}
+		thaw := storagePoolVolumeFreezeUsers(s, v.PoolName, v.ProjectName, &vol)
 		err = pool.CreateCustomVolumeSnapshot(v.ProjectName, v.Name, snapshotName, expiry, nil)
+		thaw()
 		if err != nil {
 			return fmt.Errorf("Error creating snapshot for volume %q (project %q, pool %q): %w", v.Name, v.ProjectName, v.PoolName, err)
 		}
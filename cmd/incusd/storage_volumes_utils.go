@@ -12,6 +12,7 @@ import (
 	storagePools "github.com/lxc/incus/v6/internal/server/storage"
 	"github.com/lxc/incus/v6/internal/version"
 	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
 )
 
 var supportedVolumeTypes = []int{db.StoragePoolVolumeTypeContainer, db.StoragePoolVolumeTypeVM, db.StoragePoolVolumeTypeCustom, db.StoragePoolVolumeTypeImage}
@@ -151,3 +152,56 @@ func storagePoolVolumeBackupLoadByName(ctx context.Context, s *state.State, proj
 
 	return backup, nil
 }
+
+// storagePoolVolumeFreezeUsers freezes the guest filesystems of the local running virtual machines using the
+// custom volume when its snapshots.consistency is application, and returns a function thawing them. A guest
+// failing to freeze, like when its agent isn't running, only gets a crash consistent snapshot of the volume.
+func storagePoolVolumeFreezeUsers(s *state.State, poolName string, projectName string, vol *api.StorageVolume) func() {
+	if vol.Config["snapshots.consistency"] != "application" {
+		return func() {}
+	}
+
+	// Load the instances first, as they can't be frozen while listing them within a transaction.
+	var vms []instance.VM
+	err := storagePools.VolumeUsedByInstanceDevices(s, poolName, projectName, vol, true, func(dbInst db.InstanceArgs, project api.Project, usedByDevices []string) error {
+		if dbInst.Node != s.ServerName {
+			return nil
+		}
+
+		inst, err := instance.Load(s, dbInst, project)
+		if err != nil {
+			return err
+		}
+
+		vm, ok := inst.(instance.VM)
+		if ok && inst.IsRunning() {
+			vms = append(vms, vm)
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Warn("Failed finding the instances using the volume, falling back to crash consistent snapshot", logger.Ctx{"pool": poolName, "project": projectName, "volume": vol.Name, "err": err})
+		return func() {}
+	}
+
+	var thaws []func() error
+	for _, vm := range vms {
+		thaw, err := vm.FreezeFilesystems()
+		if err != nil {
+			logger.Warn("Failed freezing guest filesystems, falling back to crash consistent snapshot", logger.Ctx{"project": vm.Project().Name, "instance": vm.Name(), "volume": vol.Name, "err": err})
+			continue
+		}
+
+		thaws = append(thaws, thaw)
+	}
+
+	return func() {
+		for _, thaw := range thaws {
+			err := thaw()
+			if err != nil {
+				logger.Error("Failed thawing guest filesystems", logger.Ctx{"volume": vol.Name, "err": err})
+			}
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/ioprogress"
 	"github.com/lxc/incus/v6/shared/termios"
+	"github.com/lxc/incus/v6/shared/units"
 )
 
 // ProgressRenderer tracks the progress information.
@@ -183,12 +184,48 @@ func (p *ProgressRenderer) UpdateOp(op api.Operation) {
 		return
 	}
 
+	// Prefer the structured progress when the server provides it.
+	progress, err := op.ToOperationProgress()
+	if err == nil && progress != nil {
+		p.Update(FormatOperationProgress(*progress))
+		return
+	}
+
 	for key, value := range op.Metadata {
 		if !strings.HasSuffix(key, "_progress") {
 			continue
 		}
 
-		p.Update(value.(string))
+		status, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		p.Update(status)
 		break
 	}
 }
+
+// FormatOperationProgress renders the structured progress of an operation, including the completion
+// percentage and the time left when the total is known.
+func FormatOperationProgress(progress api.OperationProgress) string {
+	msg := units.GetByteSizeString(progress.Processed, 2)
+	if progress.Total > 0 {
+		msg = fmt.Sprintf("%d%% (%s/%s)", progress.Percent(), msg, units.GetByteSizeString(progress.Total, 2))
+	}
+
+	if progress.Speed > 0 {
+		msg = fmt.Sprintf("%s %s/s", msg, units.GetByteSizeString(progress.Speed, 2))
+	}
+
+	eta := progress.ETA()
+	if eta > 0 {
+		msg = fmt.Sprintf("%s, %s left", msg, eta)
+	}
+
+	if progress.Description != "" {
+		msg = fmt.Sprintf("%s: %s", progress.Description, msg)
+	}
+
+	return msg
+}
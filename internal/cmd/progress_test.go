@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func TestFormatOperationProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress api.OperationProgress
+		want     string
+	}{
+		{
+			name:     "Unknown total",
+			progress: api.OperationProgress{Processed: 1000000, Speed: 500000},
+			want:     "1.00MB 500.00kB/s",
+		},
+		{
+			name:     "Known total",
+			progress: api.OperationProgress{Processed: 1000000, Total: 4000000, Speed: 1000000},
+			want:     "25% (1.00MB/4.00MB) 1.00MB/s, 3s left",
+		},
+		{
+			name:     "Description",
+			progress: api.OperationProgress{Description: "Unpack", Processed: 2000000, Total: 4000000},
+			want:     "Unpack: 50% (2.00MB/4.00MB)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatOperationProgress(tt.progress))
+		})
+	}
+}
+
+func TestOperationProgressMetadata(t *testing.T) {
+	data, err := json.Marshal(api.Operation{Metadata: map[string]any{
+		"fs_progress":            "c1: 1.00MB (1.00MB/s)",
+		api.OperationProgressKey: api.OperationProgress{Stage: "fs", Description: "c1", Processed: 1000000, Speed: 1000000},
+	}})
+	require.NoError(t, err)
+
+	// The structured progress is decoded from the metadata as sent by the server.
+	op := api.Operation{}
+	err = json.Unmarshal(data, &op)
+	require.NoError(t, err)
+
+	progress, err := op.ToOperationProgress()
+	require.NoError(t, err)
+	assert.Equal(t, &api.OperationProgress{Stage: "fs", Description: "c1", Processed: 1000000, Speed: 1000000}, progress)
+	assert.Equal(t, int64(-1), progress.Percent())
+
+	// Operations without it only have the legacy strings.
+	delete(op.Metadata, api.OperationProgressKey)
+	progress, err = op.ToOperationProgress()
+	require.NoError(t, err)
+	assert.Nil(t, progress)
+}
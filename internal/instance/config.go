@@ -927,6 +927,17 @@ var InstanceConfigKeysVM = map[string]func(value string) error{
 	//  shortdesc: Addition/override to the generated `qemu.conf` file
 	"raw.qemu.conf": validate.IsAny,
 
+	// gendoc:generate(entity=instance, group=snapshots, key=snapshots.consistency)
+	// When set to `application`, the guest filesystems are frozen through the `incus-agent` while the snapshot is taken.
+	// If the agent isn't available, a crash-consistent snapshot is taken instead.
+	// ---
+	//  type: string
+	//  defaultdesc: `crash`
+	//  liveupdate: yes
+	//  condition: virtual machine
+	//  shortdesc: Consistency level of snapshots of running instances (`crash` or `application`)
+	"snapshots.consistency": validate.Optional(validate.IsOneOf("crash", "application")),
+
 	// gendoc:generate(entity=instance, group=security, key=security.agent.metrics)
 	//
 	// ---
@@ -2,6 +2,9 @@ package instance
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 )
 
 // IsRootDiskDevice returns true if the given device representation is configured as root disk for
@@ -44,3 +47,98 @@ func GetRootDiskDevice(devices map[string]map[string]string) (string, map[string
 
 	return "", nil, ErrNoRootDisk
 }
+
+// HoldsRootDisk returns true if the named device is the one holding the root filesystem, either by being
+// configured as root disk or by being the only disk device backed by a storage pool.
+func HoldsRootDisk(devices map[string]map[string]string, name string) bool {
+	device, ok := devices[name]
+	if !ok {
+		return false
+	}
+
+	if IsRootDiskDevice(device) {
+		return true
+	}
+
+	if device["type"] != "disk" || device["pool"] == "" || device["source"] != "" {
+		return false
+	}
+
+	for n, d := range devices {
+		if n != name && d["type"] == "disk" && d["pool"] != "" && d["source"] == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AffectsRootDisk returns true if replacing the device holding the root filesystem with newDevice changes
+// where the root filesystem comes from. A nil newDevice means the device gets removed.
+func AffectsRootDisk(device map[string]string, newDevice map[string]string) bool {
+	if newDevice == nil {
+		return true
+	}
+
+	for _, key := range []string{"type", "path", "pool", "source"} {
+		if device[key] != newDevice[key] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReservedDeviceNames are the names of the devices Incus creates on its own.
+var ReservedDeviceNames = []string{"agent", "config"}
+
+// deviceNamePattern describes the names allowed for new devices.
+const deviceNamePattern = "^[a-zA-Z0-9]([-_a-zA-Z0-9]{0,61}[a-zA-Z0-9])?$"
+
+var deviceNameRegexp = regexp.MustCompile(deviceNamePattern)
+
+// ValidDeviceName validates the name of a new device. It follows the same rules as instance names, also allowing
+// underscores as they're common in existing device names, and mustn't collide with the devices Incus creates on
+// its own.
+func ValidDeviceName(name string) error {
+	if !deviceNameRegexp.MatchString(name) {
+		return fmt.Errorf("Invalid device name %q, it must be 1-63 alphanumeric, hyphen or underscore characters, starting and ending with an alphanumeric character (allowed pattern is %s)", name, deviceNamePattern)
+	}
+
+	_, err := strconv.ParseUint(name, 10, 64)
+	if err == nil {
+		return fmt.Errorf("Invalid device name %q, it can't be a number", name)
+	}
+
+	if slices.Contains(ReservedDeviceNames, name) {
+		return fmt.Errorf("Device name %q is reserved", name)
+	}
+
+	return nil
+}
+
+// ValidNewDeviceNames validates the names of the devices which aren't in any of the existing device sets.
+// Existing devices are left alone so that they keep working whatever their name.
+func ValidNewDeviceNames(devices map[string]map[string]string, existing ...map[string]map[string]string) error {
+	for name := range devices {
+		found := false
+		for _, devs := range existing {
+			_, ok := devs[name]
+			if ok {
+				found = true
+				break
+			}
+		}
+
+		if found {
+			continue
+		}
+
+		err := ValidDeviceName(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
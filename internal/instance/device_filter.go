@@ -0,0 +1,60 @@
+package instance
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DeviceMatchesFilters returns true if the named device matches all the filters.
+//
+// Filters follow the syntax of instance list filters. A filter of the form key=value matches the value of
+// a configuration key of the device, "name" standing for the device name. Any other filter matches the
+// device name. Values may either be regular expressions or use "*" as a wildcard and have to match fully.
+func DeviceMatchesFilters(name string, device map[string]string, filters []string) bool {
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		if !ok {
+			if !deviceFilterMatch(filter, name) && !strings.HasPrefix(name, filter) {
+				return false
+			}
+
+			continue
+		}
+
+		actual := device[key]
+		if key == "name" {
+			actual = name
+		}
+
+		if !deviceFilterMatch(value, actual) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deviceFilterMatch checks whether value matches the filter pattern.
+func deviceFilterMatch(pattern string, value string) bool {
+	if pattern == value {
+		return true
+	}
+
+	var expr string
+	if strings.Contains(pattern, "*") && !strings.Contains(pattern, ".*") {
+		expr = strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	} else {
+		expr = pattern
+	}
+
+	if !(strings.HasPrefix(expr, "^") || strings.HasSuffix(expr, "$")) {
+		expr = "^" + expr + "$"
+	}
+
+	r, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+
+	return r.MatchString(value)
+}
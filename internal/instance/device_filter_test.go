@@ -0,0 +1,41 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceMatchesFilters(t *testing.T) {
+	device := map[string]string{
+		"type":    "proxy",
+		"listen":  "tcp:0.0.0.0:443",
+		"connect": "tcp:127.0.0.1:8443",
+	}
+
+	tests := []struct {
+		filters []string
+		want    bool
+	}{
+		{filters: nil, want: true},
+		{filters: []string{"type=proxy"}, want: true},
+		{filters: []string{"type=disk"}, want: false},
+		{filters: []string{"type=prox"}, want: false},
+		{filters: []string{"connect=*:8443"}, want: true},
+		{filters: []string{"listen=*:443*"}, want: true},
+		{filters: []string{"listen=*:80*"}, want: false},
+		{filters: []string{"listen=tcp:.*:443"}, want: true},
+		{filters: []string{"type=proxy", "listen=*:443"}, want: true},
+		{filters: []string{"type=proxy", "listen=*:80"}, want: false},
+		{filters: []string{"name=web*"}, want: true},
+		{filters: []string{"web"}, want: true},
+		{filters: []string{"db"}, want: false},
+		{filters: []string{"path="}, want: true},
+		{filters: []string{"path=*"}, want: true},
+		{filters: []string{"type=["}, want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DeviceMatchesFilters("web-https", device, tt.filters), "filters %v", tt.filters)
+	}
+}
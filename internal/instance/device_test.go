@@ -0,0 +1,60 @@
+package instance
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidDeviceName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "eth0"},
+		{name: "data-disk"},
+		{name: "my_disk"},
+		{name: "a"},
+		{name: strings.Repeat("a", 63)},
+		{name: strings.Repeat("a", 64), wantErr: "allowed pattern"},
+		{name: "", wantErr: "allowed pattern"},
+		{name: "-disk", wantErr: "allowed pattern"},
+		{name: "disk-", wantErr: "allowed pattern"},
+		{name: "_disk", wantErr: "allowed pattern"},
+		{name: "disk_", wantErr: "allowed pattern"},
+		{name: "1234", wantErr: "can't be a number"},
+		{name: "my disk", wantErr: "allowed pattern"},
+		{name: "dev/sda", wantErr: "allowed pattern"},
+		{name: "my.disk", wantErr: "allowed pattern"},
+		{name: "dïsk", wantErr: "allowed pattern"},
+		{name: strings.Repeat("é", 32), wantErr: "allowed pattern"},
+		{name: "agent", wantErr: "reserved"},
+		{name: "config", wantErr: "reserved"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidDeviceName(tt.name)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidNewDeviceNames(t *testing.T) {
+	local := map[string]map[string]string{"my.disk": {"type": "disk"}}
+	expanded := map[string]map[string]string{"my.disk": {"type": "disk"}, "legacy.nic": {"type": "nic"}}
+
+	// Existing devices keep their names.
+	assert.NoError(t, ValidNewDeviceNames(map[string]map[string]string{"my.disk": {}, "legacy.nic": {}}, local, expanded))
+
+	// New devices follow the naming rules.
+	assert.NoError(t, ValidNewDeviceNames(map[string]map[string]string{"my.disk": {}, "eth1": {}}, local))
+	assert.ErrorContains(t, ValidNewDeviceNames(map[string]map[string]string{"other.disk": {}}, local), "allowed pattern")
+	assert.ErrorContains(t, ValidNewDeviceNames(map[string]map[string]string{"agent": {}}), "reserved")
+}
@@ -0,0 +1,191 @@
+package ports
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoPortAvailable is returned when all the ports of the range are either reserved or in use.
+var ErrNoPortAvailable = errors.New("No port available")
+
+// kernelAttempts is the number of ports requested from the kernel before giving up, when no range is configured.
+const kernelAttempts = 10
+
+// listenTCP binds a TCP socket to the given address, replaced in tests.
+var listenTCP = func(address string) (net.Listener, error) {
+	return net.Listen("tcp", address)
+}
+
+// Allocator hands out TCP ports to bind local listeners to, either from a configured range or chosen by the
+// kernel, keeping track of the ones which were handed out so that they aren't handed out again until released or
+// until their reservation expires. A port is only handed out after checking that it can be bound.
+type Allocator struct {
+	mu sync.Mutex
+
+	host  string
+	first int
+	last  int
+	next  int
+
+	// reservations maps the reserved ports to their expiry, zero if they don't expire.
+	reservations map[int]time.Time
+}
+
+// NewAllocator returns an allocator for ports to bind on the given host, chosen by the kernel until a range is
+// set with SetRange.
+func NewAllocator(host string) *Allocator {
+	return &Allocator{
+		host:         host,
+		reservations: map[int]time.Time{},
+	}
+}
+
+// ParseRange parses a port range of the form "<first>-<last>", as well as a single port. An empty value gives a
+// zero range, leaving the choice of ports to the kernel.
+func ParseRange(value string) (int, int, error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+
+	firstValue, lastValue, found := strings.Cut(value, "-")
+	if !found {
+		lastValue = firstValue
+	}
+
+	first, err := strconv.ParseUint(firstValue, 10, 16)
+	if err != nil || first == 0 {
+		return 0, 0, fmt.Errorf("Invalid port %q in range %q", firstValue, value)
+	}
+
+	last, err := strconv.ParseUint(lastValue, 10, 16)
+	if err != nil || last == 0 {
+		return 0, 0, fmt.Errorf("Invalid port %q in range %q", lastValue, value)
+	}
+
+	if last < first {
+		return 0, 0, fmt.Errorf("Invalid port range %q, the last port must not be lower than the first one", value)
+	}
+
+	return int(first), int(last), nil
+}
+
+// SetRange changes the range ports are handed out from, both zero leaving the choice to the kernel. Existing
+// reservations are kept.
+func (a *Allocator) SetRange(first int, last int) error {
+	if (first == 0) != (last == 0) || first < 0 || last > 65535 || last < first {
+		return fmt.Errorf("Invalid port range %d-%d", first, last)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.first = first
+	a.last = last
+	a.next = first
+
+	return nil
+}
+
+// Reserve hands out a port which isn't reserved and can currently be bound, reserving it for the given duration,
+// or until released if zero. Ports of a range are handed out in turn, so that recently released ones are only
+// reused once the others have been. Returns ErrNoPortAvailable if the range is exhausted.
+func (a *Allocator) Reserve(ttl time.Duration) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for port, expiry := range a.reservations {
+		if !expiry.IsZero() && now.After(expiry) {
+			delete(a.reservations, port)
+		}
+	}
+
+	var expiry time.Time
+	if ttl > 0 {
+		expiry = now.Add(ttl)
+	}
+
+	if a.first == 0 {
+		return a.reserveFromKernel(expiry)
+	}
+
+	size := a.last - a.first + 1
+	for i := 0; i < size; i++ {
+		port := a.next
+
+		a.next++
+		if a.next > a.last {
+			a.next = a.first
+		}
+
+		_, reserved := a.reservations[port]
+		if reserved || !a.available(port) {
+			continue
+		}
+
+		a.reservations[port] = expiry
+
+		return port, nil
+	}
+
+	return 0, fmt.Errorf("Failed reserving a port in range %d-%d: %w", a.first, a.last, ErrNoPortAvailable)
+}
+
+// reserveFromKernel reserves a port chosen by the kernel, asking for another one if it's already reserved.
+func (a *Allocator) reserveFromKernel(expiry time.Time) (int, error) {
+	for i := 0; i < kernelAttempts; i++ {
+		listener, err := listenTCP(net.JoinHostPort(a.host, "0"))
+		if err != nil {
+			return 0, fmt.Errorf("Failed finding a free port: %w", err)
+		}
+
+		port := listener.Addr().(*net.TCPAddr).Port
+		_ = listener.Close()
+
+		_, reserved := a.reservations[port]
+		if reserved {
+			continue
+		}
+
+		a.reservations[port] = expiry
+
+		return port, nil
+	}
+
+	return 0, fmt.Errorf("Failed reserving a port chosen by the kernel: %w", ErrNoPortAvailable)
+}
+
+// available checks whether the port can be bound, by briefly binding it.
+func (a *Allocator) available(port int) bool {
+	listener, err := listenTCP(net.JoinHostPort(a.host, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+
+	_ = listener.Close()
+
+	return true
+}
+
+// Release makes a reserved port available again.
+func (a *Allocator) Release(port int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.reservations, port)
+}
+
+// Reserved returns whether the port is currently reserved.
+func (a *Allocator) Reserved(port int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	expiry, reserved := a.reservations[port]
+
+	return reserved && (expiry.IsZero() || time.Now().Before(expiry))
+}
@@ -0,0 +1,229 @@
+package ports
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeListener only reports the address it's bound to.
+type fakeListener struct {
+	net.Listener
+
+	addr *net.TCPAddr
+}
+
+func (l *fakeListener) Addr() net.Addr { return l.addr }
+func (l *fakeListener) Close() error   { return nil }
+
+// setListenTCP makes the given ports fail to bind for the duration of the test. Binding port 0 gives out the ports
+// of the kernel list in turn.
+func setListenTCP(t *testing.T, busy map[int]bool, kernel []int) {
+	old := listenTCP
+	t.Cleanup(func() { listenTCP = old })
+
+	var mu sync.Mutex
+	listenTCP = func(address string) (net.Listener, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		_, portValue, err := net.SplitHostPort(address)
+		require.NoError(t, err)
+
+		port, err := strconv.Atoi(portValue)
+		require.NoError(t, err)
+
+		if port == 0 {
+			if len(kernel) == 0 {
+				return nil, fmt.Errorf("No more ports")
+			}
+
+			port, kernel = kernel[0], kernel[1:]
+		}
+
+		if busy[port] {
+			return nil, fmt.Errorf("listen tcp %s: bind: address already in use", address)
+		}
+
+		return &fakeListener{addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}}, nil
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	cases := map[string][2]int{
+		"":            {0, 0},
+		"41000-41999": {41000, 41999},
+		"41000":       {41000, 41000},
+		"1-65535":     {1, 65535},
+	}
+
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			first, last, err := ParseRange(in)
+			require.NoError(t, err)
+			assert.Equal(t, out, [2]int{first, last})
+		})
+	}
+
+	for _, in := range []string{"-", "0-10", "10-0", "20-10", "1-65536", "foo", "10-", "10-20-30"} {
+		t.Run(in, func(t *testing.T) {
+			_, _, err := ParseRange(in)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAllocatorReserve(t *testing.T) {
+	setListenTCP(t, map[int]bool{41001: true}, nil)
+
+	a := NewAllocator("127.0.0.1")
+	require.NoError(t, a.SetRange(41000, 41003))
+
+	// Ports in use are skipped.
+	ports := []int{}
+	for i := 0; i < 3; i++ {
+		port, err := a.Reserve(0)
+		require.NoError(t, err)
+		ports = append(ports, port)
+	}
+
+	assert.Equal(t, []int{41000, 41002, 41003}, ports)
+	assert.True(t, a.Reserved(41002))
+
+	// The range is exhausted.
+	_, err := a.Reserve(0)
+	assert.ErrorIs(t, err, ErrNoPortAvailable)
+
+	// Released ports are handed out again.
+	a.Release(41002)
+	assert.False(t, a.Reserved(41002))
+
+	port, err := a.Reserve(0)
+	require.NoError(t, err)
+	assert.Equal(t, 41002, port)
+}
+
+func TestAllocatorRoundRobin(t *testing.T) {
+	setListenTCP(t, nil, nil)
+
+	a := NewAllocator("127.0.0.1")
+	require.NoError(t, a.SetRange(41000, 41002))
+
+	port, err := a.Reserve(0)
+	require.NoError(t, err)
+	assert.Equal(t, 41000, port)
+
+	// A port which was just released is only handed out again once the others have been.
+	a.Release(port)
+
+	for _, expected := range []int{41001, 41002, 41000} {
+		port, err := a.Reserve(0)
+		require.NoError(t, err)
+		assert.Equal(t, expected, port)
+	}
+}
+
+func TestAllocatorExpiry(t *testing.T) {
+	setListenTCP(t, nil, nil)
+
+	a := NewAllocator("127.0.0.1")
+	require.NoError(t, a.SetRange(41000, 41000))
+
+	_, err := a.Reserve(10 * time.Millisecond)
+	require.NoError(t, err)
+
+	_, err = a.Reserve(0)
+	assert.ErrorIs(t, err, ErrNoPortAvailable)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, a.Reserved(41000))
+
+	port, err := a.Reserve(0)
+	require.NoError(t, err)
+	assert.Equal(t, 41000, port)
+}
+
+func TestAllocatorKernel(t *testing.T) {
+	setListenTCP(t, nil, []int{45000, 45000, 45001})
+
+	a := NewAllocator("127.0.0.1")
+
+	port, err := a.Reserve(0)
+	require.NoError(t, err)
+	assert.Equal(t, 45000, port)
+
+	// A port chosen by the kernel again while reserved is skipped.
+	port, err = a.Reserve(0)
+	require.NoError(t, err)
+	assert.Equal(t, 45001, port)
+
+	_, err = a.Reserve(0)
+	assert.Error(t, err)
+}
+
+func TestAllocatorConcurrent(t *testing.T) {
+	setListenTCP(t, nil, nil)
+
+	a := NewAllocator("127.0.0.1")
+	require.NoError(t, a.SetRange(41000, 41099))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	reserved := map[int]bool{}
+	exhausted := 0
+
+	for i := 0; i < 150; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			port, err := a.Reserve(0)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if errors.Is(err, ErrNoPortAvailable) {
+				exhausted++
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.False(t, reserved[port], "Port %d handed out twice", port)
+			reserved[port] = true
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, reserved, 100)
+	assert.Equal(t, 50, exhausted)
+}
+
+func TestAllocatorSetRange(t *testing.T) {
+	setListenTCP(t, nil, nil)
+
+	a := NewAllocator("127.0.0.1")
+	require.NoError(t, a.SetRange(41000, 41000))
+
+	_, err := a.Reserve(0)
+	require.NoError(t, err)
+
+	// Reservations are kept when the range changes.
+	require.NoError(t, a.SetRange(41000, 41001))
+
+	port, err := a.Reserve(0)
+	require.NoError(t, err)
+	assert.Equal(t, 41001, port)
+
+	assert.Error(t, a.SetRange(41001, 41000))
+	assert.Error(t, a.SetRange(0, 41000))
+	assert.Error(t, a.SetRange(41000, 70000))
+	assert.Error(t, a.SetRange(10, 0))
+}
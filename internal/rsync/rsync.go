@@ -1,7 +1,7 @@
 package rsync
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net"
@@ -14,38 +14,67 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/lxc/incus/v6/internal/linux"
+	"github.com/lxc/incus/v6/internal/revert"
 	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/internal/version"
 	"github.com/lxc/incus/v6/shared/ioprogress"
-	"github.com/lxc/incus/v6/shared/logger"
 	"github.com/lxc/incus/v6/shared/subprocess"
 )
 
 // Debug controls additional debugging in rsync output.
 var Debug bool
 
+// Access is the access rsync needs to a path.
+type Access string
+
+const (
+	// AccessReadOnly only allows reading the path.
+	AccessReadOnly Access = "read-only"
+
+	// AccessCreateOnly allows creating and writing files, without locking or hard linking them.
+	AccessCreateOnly Access = "create-only"
+
+	// AccessFull allows any change to the path, as needed to mirror a tree (deletions, hard links).
+	AccessFull Access = "full"
+)
+
+// Path is a path rsync accesses along with the access it needs.
+type Path struct {
+	Path   string
+	Access Access
+}
+
 // RunWrapper is an optional function that's used to wrap rsync, useful for confinement like AppArmor.
-var RunWrapper func(cmd *exec.Cmd, source string, destination string) (func(), error)
+// It's given the paths rsync accesses.
+var RunWrapper func(cmd *exec.Cmd, paths []Path) (func(), error)
+
+// TransportWrapper is an optional function that's used to wrap the transport command rsync uses to reach
+// the other end of a transfer, useful for confinement like AppArmor. It's given the name the transport logs under.
+var TransportWrapper func(cmd *exec.Cmd, name string) (func(), error)
 
 // rsync is a wrapper for the rsync command which will respect RunWrapper.
-func rsync(args ...string) (string, error) {
-	if len(args) < 2 {
-		return "", fmt.Errorf("rsync call expects a minimum of two arguments (source and destination)")
+// The sources and destination are appended to args, the sources being read-only and the destination given access.
+func rsync(sources []string, destination string, access Access, args ...string) (string, error) {
+	if len(sources) == 0 || destination == "" {
+		return "", fmt.Errorf("rsync call expects at least a source and a destination")
 	}
 
+	args = append(args, sources...)
+	args = append(args, destination)
+
 	// Setup the command.
 	cmd := exec.Command("rsync", args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
 
 	// Call the wrapper if defined.
 	if RunWrapper != nil {
-		source := args[len(args)-2]
-		destination := args[len(args)-1]
+		paths := make([]Path, 0, len(sources)+1)
+		for _, source := range sources {
+			paths = append(paths, Path{Path: source, Access: AccessReadOnly})
+		}
+
+		paths = append(paths, Path{Path: destination, Access: access})
 
-		cleanup, err := RunWrapper(cmd, source, destination)
+		cleanup, err := RunWrapper(cmd, paths)
 		if err != nil {
 			return "", err
 		}
@@ -54,12 +83,9 @@ func rsync(args ...string) (string, error) {
 	}
 
 	// Run the command.
-	err := cmd.Run()
-	if err != nil {
-		return stdout.String(), subprocess.NewRunError("rsync", args, err, &stdout, &stderr)
-	}
+	stdout, _, err := subprocess.RunCommandOutput(context.TODO(), cmd.Args[0], cmd.Args[1:], subprocess.WithBackgroundPriority())
 
-	return stdout.String(), nil
+	return stdout, err
 }
 
 // LocalCopy copies a directory using rsync (with the --devices option).
@@ -99,12 +125,9 @@ func LocalCopy(source string, dest string, bwlimit string, xattrs bool, rsyncArg
 		args = append(args, rsyncArgs...)
 	}
 
-	args = append(args,
-		rsyncVerbosity,
-		internalUtil.AddSlash(source),
-		dest)
+	args = append(args, rsyncVerbosity)
 
-	msg, err := rsync(args...)
+	msg, err := rsync([]string{internalUtil.AddSlash(source)}, dest, AccessFull, args...)
 	if err != nil {
 		runError, ok := err.(subprocess.RunError)
 		if ok {
@@ -122,7 +145,12 @@ func LocalCopy(source string, dest string, bwlimit string, xattrs bool, rsyncArg
 	return msg, nil
 }
 
-func sendSetup(name string, path string, bwlimit string, execPath string, features []string, rsyncArgs ...string) (*exec.Cmd, net.Conn, io.ReadCloser, error) {
+// sendSetup starts rsync sending path through the netcat transport and returns it along with the connection
+// to the transport, the rsync stderr and a cleanup function to call once rsync has exited.
+func sendSetup(name string, path string, bwlimit string, execPath string, features []string, rsyncArgs ...string) (*exec.Cmd, net.Conn, *subprocess.OutputBuffer, func(), error) {
+	revert := revert.New()
+	defer revert.Fail()
+
 	/*
 	 * The way rsync works, it invokes a subprocess that does the actual
 	 * talking (given to it by a -E argument). Since there isn't an easy
@@ -146,7 +174,7 @@ func sendSetup(name string, path string, bwlimit string, execPath string, featur
 
 	l, err := net.Listen("unix", auds)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	defer func() { _ = l.Close() }()
@@ -157,7 +185,19 @@ func sendSetup(name string, path string, bwlimit string, execPath string, featur
 	 * other end of the incus websocket), and so the path specified on the
 	 * --server instance of rsync takes precedence.
 	 */
-	rsyncCmd := fmt.Sprintf("%s netcat %s %s --", execPath, auds, name)
+	transportCmd := exec.Command(execPath, "netcat", auds, name, "--")
+
+	// Call the transport wrapper if defined.
+	if TransportWrapper != nil {
+		cleanup, err := TransportWrapper(transportCmd, name)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		revert.Add(cleanup)
+	}
+
+	rsyncCmd := strings.Join(transportCmd.Args, " ")
 
 	args := []string{
 		"-ar",
@@ -189,22 +229,20 @@ func sendSetup(name string, path string, bwlimit string, execPath string, featur
 
 	// Call the wrapper if defined.
 	if RunWrapper != nil {
-		cleanup, err := RunWrapper(cmd, path, "")
+		cleanup, err := RunWrapper(cmd, []Path{{Path: path, Access: AccessReadOnly}})
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
-		defer cleanup()
+		revert.Add(cleanup)
 	}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, nil, nil, err
-	}
+	stderr := subprocess.NewOutputBuffer(subprocess.DefaultStderrLimit)
+	cmd.Stderr = stderr
 
-	err = cmd.Start()
+	err = subprocess.StartWithPriority(cmd, subprocess.BackgroundPriority())
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	var conn *net.Conn
@@ -223,30 +261,33 @@ func sendSetup(name string, path string, bwlimit string, execPath string, featur
 	select {
 	case conn = <-chConn:
 		if conn == nil {
-			output, _ := io.ReadAll(stderr)
 			_ = cmd.Process.Kill()
 			_ = cmd.Wait()
-			return nil, nil, nil, fmt.Errorf("Failed to connect to rsync socket (%s)", string(output))
+			return nil, nil, nil, nil, fmt.Errorf("Failed to connect to rsync socket (%s)", stderr.String())
 		}
 
 	case <-time.After(10 * time.Second):
-		output, _ := io.ReadAll(stderr)
 		_ = cmd.Process.Kill()
 		_ = cmd.Wait()
-		return nil, nil, nil, fmt.Errorf("rsync failed to spawn after 10s (%s)", string(output))
+		return nil, nil, nil, nil, fmt.Errorf("rsync failed to spawn after 10s (%s)", stderr.String())
 	}
 
-	return cmd, *conn, stderr, nil
+	cleanup := revert.Clone().Fail
+	revert.Success()
+
+	return cmd, *conn, stderr, cleanup, nil
 }
 
 // Send sets up the sending half of an rsync, to recursively send the
 // directory pointed to by path over the websocket.
 func Send(name string, path string, conn io.ReadWriteCloser, tracker *ioprogress.ProgressTracker, features []string, bwlimit string, execPath string, rsyncArgs ...string) error {
-	cmd, netcatConn, stderr, err := sendSetup(name, path, bwlimit, execPath, features, rsyncArgs...)
+	cmd, netcatConn, stderr, cleanup, err := sendSetup(name, path, bwlimit, execPath, features, rsyncArgs...)
 	if err != nil {
 		return err
 	}
 
+	defer cleanup()
+
 	// Setup progress tracker.
 	readNetcatPipe := io.ReadCloser(netcatConn)
 	if tracker != nil {
@@ -276,12 +317,6 @@ func Send(name string, path string, conn io.ReadWriteCloser, tracker *ioprogress
 	}()
 
 	// Wait for rsync to complete.
-	output, err := io.ReadAll(stderr)
-	if err != nil {
-		_ = cmd.Process.Kill()
-		logger.Errorf("Rsync stderr read failed: %s: %v", path, err)
-	}
-
 	err = cmd.Wait()
 	errs := []error{}
 	chCopyNetcatErr := <-chCopyNetcat
@@ -301,7 +336,7 @@ func Send(name string, path string, conn io.ReadWriteCloser, tracker *ioprogress
 	}
 
 	if len(errs) > 0 {
-		return fmt.Errorf("Rsync send failed: %s, %s: %v (%s)", name, path, errs, string(output))
+		return fmt.Errorf("Rsync send failed: %s, %s: %v (%s)", name, path, errs, strings.TrimSpace(stderr.String()))
 	}
 
 	return nil
@@ -309,8 +344,8 @@ func Send(name string, path string, conn io.ReadWriteCloser, tracker *ioprogress
 
 // Recv sets up the receiving half of the websocket to rsync (the other
 // half set up by rsync.Send), putting the contents in the directory specified
-// by path.
-func Recv(path string, conn io.ReadWriteCloser, tracker *ioprogress.ProgressTracker, features []string) error {
+// by path, which rsync is given access to.
+func Recv(path string, access Access, conn io.ReadWriteCloser, tracker *ioprogress.ProgressTracker, features []string) error {
 	args := []string{
 		"--server",
 		"-vlogDtpre.iLsfx",
@@ -330,7 +365,7 @@ func Recv(path string, conn io.ReadWriteCloser, tracker *ioprogress.ProgressTrac
 
 	// Call the wrapper if defined.
 	if RunWrapper != nil {
-		cleanup, err := RunWrapper(cmd, "", path)
+		cleanup, err := RunWrapper(cmd, []Path{{Path: path, Access: access}})
 		if err != nil {
 			return err
 		}
@@ -373,22 +408,14 @@ func Recv(path string, conn io.ReadWriteCloser, tracker *ioprogress.ProgressTrac
 		chCopySource <- err
 	}()
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		_ = cmd.Process.Kill()
-		logger.Errorf("Rsync stderr read failed: %s: %v", path, err)
-	}
+	stderr := subprocess.NewOutputBuffer(subprocess.DefaultStderrLimit)
+	cmd.Stderr = stderr
 
-	err = cmd.Start()
+	err = subprocess.StartWithPriority(cmd, subprocess.BackgroundPriority())
 	if err != nil {
 		return err
 	}
 
-	output, err := io.ReadAll(stderr)
-	if err != nil {
-		logger.Errorf("Rsync stderr read failed: %s: %v", path, err)
-	}
-
 	err = cmd.Wait()
 	errs := []error{}
 	chCopyRsyncErr := <-chCopyRsync
@@ -408,7 +435,7 @@ func Recv(path string, conn io.ReadWriteCloser, tracker *ioprogress.ProgressTrac
 	}
 
 	if len(errs) > 0 {
-		return fmt.Errorf("Rsync receive failed: %s: %v (%s)", path, errs, string(output))
+		return fmt.Errorf("Rsync receive failed: %s: %v (%s)", path, errs, strings.TrimSpace(stderr.String()))
 	}
 
 	return nil
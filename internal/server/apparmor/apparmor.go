@@ -6,12 +6,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/lxc/incus/v6/internal/server/sys"
 	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/internal/version"
-	"github.com/lxc/incus/v6/shared/subprocess"
+	"github.com/lxc/incus/v6/shared/logger"
 	"github.com/lxc/incus/v6/shared/util"
 )
 
@@ -29,7 +31,7 @@ func runApparmor(sysOS *sys.OS, command string, name string) error {
 		return nil
 	}
 
-	_, err := subprocess.RunCommand("apparmor_parser", []string{
+	_, err := aaPolicy.parser([]string{
 		fmt.Sprintf("-%sWL", command),
 		filepath.Join(aaPath, "cache"),
 		filepath.Join(aaPath, "profiles", name),
@@ -82,24 +84,12 @@ func deleteNamespace(sysOS *sys.OS, name string) error {
 
 // hasProfile checks if the profile is already loaded.
 func hasProfile(sysOS *sys.OS, name string) (bool, error) {
-	mangled := strings.Replace(strings.Replace(strings.Replace(name, "/", ".", -1), "<", "", -1), ">", "", -1)
-
-	profilesPath := "/sys/kernel/security/apparmor/policy/profiles"
-	if util.PathExists(profilesPath) {
-		entries, err := os.ReadDir(profilesPath)
-		if err != nil {
-			return false, err
-		}
-
-		for _, entry := range entries {
-			fields := strings.Split(entry.Name(), ".")
-			if mangled == strings.Join(fields[0:len(fields)-1], ".") {
-				return true, nil
-			}
-		}
+	loaded, err := aaPolicy.loaded()
+	if err != nil {
+		return false, err
 	}
 
-	return false, nil
+	return slices.Contains(loaded, name), nil
 }
 
 // parseProfile parses the profile without loading it into the kernel.
@@ -128,25 +118,92 @@ func unloadProfile(sysOS *sys.OS, fullName string, name string) error {
 
 	ok, err := hasProfile(sysOS, fullName)
 	if err != nil {
-		return err
+		return fmt.Errorf("Failed checking whether AppArmor profile %q is loaded: %w", fullName, err)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	// Without its source, the profile can still be removed by name.
+	if !util.PathExists(filepath.Join(aaPath, "profiles", name)) {
+		err = aaPolicy.remove(fullName)
+	} else {
+		err = runApparmor(sysOS, cmdUnload, name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed unloading AppArmor profile %q: %w", fullName, err)
+	}
+
+	return nil
+}
+
+// ProfileInUseError is returned when deleting a profile which still confines processes.
+// The profile is left loaded, along with its files, so that it can be deleted once they're gone.
+type ProfileInUseError struct {
+	Profile string
+	PIDs    []int
+}
+
+// Error returns the error message.
+func (e *ProfileInUseError) Error() string {
+	return fmt.Sprintf("AppArmor profile %q still confines processes %v", e.Profile, e.PIDs)
+}
+
+// How many times and how long deleting a profile waits for the processes it confines to exit.
+var deleteRetries = 3
+var deleteBackoff = 100 * time.Millisecond
+
+// waitUnconfined waits for the processes confined by the named profile to exit, with an increasing delay.
+func waitUnconfined(sysOS *sys.OS, fullName string) error {
+	ok, err := hasProfile(sysOS, fullName)
+	if err != nil {
+		return fmt.Errorf("Failed checking whether AppArmor profile %q is loaded: %w", fullName, err)
 	}
 
 	if !ok {
 		return nil
 	}
 
-	return runApparmor(sysOS, cmdUnload, name)
+	backoff := deleteBackoff
+	for attempt := 0; ; attempt++ {
+		pids, err := aaPolicy.confined(fullName)
+		if err != nil {
+			return fmt.Errorf("Failed listing processes confined by AppArmor profile %q: %w", fullName, err)
+		}
+
+		if len(pids) == 0 {
+			return nil
+		}
+
+		if attempt >= deleteRetries {
+			logger.Warn("Leaving AppArmor profile loaded as it still confines processes", logger.Ctx{"profile": fullName, "pids": pids})
+			return &ProfileInUseError{Profile: fullName, PIDs: pids}
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
 }
 
 // deleteProfile unloads and delete profile and cache for a profile.
+// Files already gone are ignored, and a *ProfileInUseError is returned if processes are still confined by it.
 func deleteProfile(sysOS *sys.OS, fullName string, name string) error {
 	if !sysOS.AppArmorAdmin {
 		return nil
 	}
 
+	if sysOS.AppArmorAvailable {
+		err := waitUnconfined(sysOS, fullName)
+		if err != nil {
+			return err
+		}
+	}
+
 	cacheDir, err := getCacheDir(sysOS)
 	if err != nil {
-		return err
+		return fmt.Errorf("Failed getting the AppArmor cache directory: %w", err)
 	}
 
 	err = unloadProfile(sysOS, fullName, name)
@@ -205,7 +262,7 @@ func getVersion(sysOS *sys.OS) (*version.DottedVersion, error) {
 		return version.NewDottedVersion("0.0")
 	}
 
-	out, err := subprocess.RunCommand("apparmor_parser", "--version")
+	out, err := aaPolicy.parser("--version")
 	if err != nil {
 		return nil, err
 	}
@@ -237,7 +294,7 @@ func getCacheDir(sysOS *sys.OS) (string, error) {
 		return basePath, nil
 	}
 
-	output, err := subprocess.RunCommand("apparmor_parser", "-L", basePath, "--print-cache-dir")
+	output, err := aaPolicy.parser("-L", basePath, "--print-cache-dir")
 	if err != nil {
 		return "", err
 	}
@@ -265,3 +322,13 @@ func profileName(prefix string, name string) string {
 
 	return fmt.Sprintf("incus-%s", name)
 }
+
+// qualifiedName returns the name to attach a loaded profile with, qualified with the
+// policy namespace the daemon runs in when nested so it doesn't resolve against the parent's.
+func qualifiedName(sysOS *sys.OS, name string) string {
+	if sysOS.AppArmorNamespace == "" {
+		return name
+	}
+
+	return fmt.Sprintf(":%s:%s", sysOS.AppArmorNamespace, name)
+}
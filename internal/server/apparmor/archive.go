@@ -1,14 +1,15 @@
 package apparmor
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"text/template"
-
-	"github.com/google/uuid"
+	"time"
 
 	"github.com/lxc/incus/v6/internal/revert"
 	"github.com/lxc/incus/v6/internal/server/sys"
@@ -18,7 +19,6 @@ import (
 var archiveProfileTpl = template.Must(template.New("archiveProfile").Parse(`#include <tunables/global>
 profile "{{.name}}" {
   #include <abstractions/base>
-  #include <abstractions/nameservice>
 
 {{range $index, $element := .allowedCommandPaths}}
   {{$element}} mixr,
@@ -31,10 +31,12 @@ profile "{{.name}}" {
 
   signal (receive) set=("term") peer=unconfined,
 
+  # Archives are untrusted, extraction never needs the network.
+  deny network,
+
   # Capabilities
   capability chown,
   capability dac_override,
-  capability dac_read_search,
   capability fowner,
   capability fsetid,
   capability mknod,
@@ -42,38 +44,51 @@ profile "{{.name}}" {
 }
 `))
 
-// ArchiveWrapper is used as a RunWrapper in the rsync package.
+// archiveProfiles holds the archive profiles currently loaded.
+var archiveProfiles = newProfileCache(time.Minute)
+
+// ArchiveWrapper is used as a RunWrapper in the archive package.
+// The profile only allows writing to the output path.
 func ArchiveWrapper(sysOS *sys.OS, cmd *exec.Cmd, output string, allowedCmds []string) (func(), error) {
-	if !sysOS.AppArmorAvailable {
+	if Enforcement(sysOS) == EnforcementDisabled {
 		return func() {}, nil
 	}
 
 	revert := revert.New()
 	defer revert.Fail()
 
-	// Load the profile.
-	profileName, err := archiveProfileLoad(sysOS, output, allowedCmds)
+	// Attempt to deref all paths.
+	outputPath, backupsPath, imagesPath := archiveDerefPaths(output)
+	allowedCmdPaths := archiveCmdPaths(allowedCmds)
+
+	// Load the profile, or reuse it if already loaded for the same paths.
+	name := archiveProfileName(outputPath, backupsPath, imagesPath, allowedCmdPaths)
+	unload := func() error { return deleteProfile(sysOS, name, name) }
+
+	err := archiveProfiles.acquire(name, func() error {
+		return archiveProfileLoad(sysOS, name, outputPath, backupsPath, imagesPath, allowedCmdPaths)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("Failed to load apparmor profile: %w", err)
+		return unconfined(cmd.Args[0], fmt.Errorf("Failed to load apparmor profile: %w", err))
 	}
 
-	revert.Add(func() { _ = deleteProfile(sysOS, profileName, profileName) })
+	revert.Add(func() { archiveProfiles.release(name, unload) })
 
 	// Resolve aa-exec.
 	execPath, err := exec.LookPath("aa-exec")
 	if err != nil {
-		return nil, err
+		return unconfined(cmd.Args[0], err)
 	}
 
 	// Override the command.
-	newArgs := []string{"aa-exec", "-p", profileName}
+	newArgs := []string{"aa-exec", "-p", qualifiedName(sysOS, name)}
 	newArgs = append(newArgs, cmd.Args...)
 	cmd.Args = newArgs
 	cmd.Path = execPath
 
 	// All done, setup a cleanup function and disarm reverter.
 	cleanup := func() {
-		_ = deleteProfile(sysOS, profileName, profileName)
+		archiveProfiles.release(name, unload)
 	}
 
 	revert.Success()
@@ -81,24 +96,59 @@ func ArchiveWrapper(sysOS *sys.OS, cmd *exec.Cmd, output string, allowedCmds []s
 	return cleanup, nil
 }
 
-func archiveProfileLoad(sysOS *sys.OS, output string, allowedCommandPaths []string) (string, error) {
+// archiveDerefPaths returns the output, backups and images paths with their symlinks resolved.
+func archiveDerefPaths(outputPath string) (string, string, string) {
+	return derefPath(outputPath), derefPath(internalUtil.VarPath("backups")), derefPath(internalUtil.VarPath("images"))
+}
+
+// archiveCmdPaths returns the full paths of the allowed commands.
+func archiveCmdPaths(allowedCmds []string) []string {
+	cmdPaths := make([]string, len(allowedCmds))
+	for i, cmd := range allowedCmds {
+		cmdPath, err := exec.LookPath(cmd)
+		if err == nil {
+			cmd = cmdPath
+		}
+
+		cmdFull, err := filepath.EvalSymlinks(cmd)
+		if err == nil {
+			cmd = cmdFull
+		}
+
+		cmdPaths[i] = cmd
+	}
+
+	return cmdPaths
+}
+
+// archiveProfileName returns the name of the profile for the given paths and commands, the same parameters
+// always resulting in the same name so that the profile can be reused.
+func archiveProfileName(outputPath string, backupsPath string, imagesPath string, allowedCmdPaths []string) string {
+	hash := sha256.New()
+	for _, field := range append([]string{outputPath, backupsPath, imagesPath}, allowedCmdPaths...) {
+		_, _ = io.WriteString(hash, field)
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return profileName("archive", fmt.Sprintf("%x", hash.Sum(nil)))
+}
+
+func archiveProfileLoad(sysOS *sys.OS, name string, outputPath string, backupsPath string, imagesPath string, allowedCmdPaths []string) error {
 	revert := revert.New()
 	defer revert.Fail()
 
-	// Generate a temporary profile name.
-	name := profileName("archive", uuid.New().String())
 	profilePath := filepath.Join(aaPath, "profiles", name)
 
 	// Generate the profile
-	content, err := archiveProfile(name, output, allowedCommandPaths)
+	content, err := archiveProfile(name, outputPath, backupsPath, imagesPath, allowedCmdPaths)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	// Write it to disk.
 	err = os.WriteFile(profilePath, []byte(content), 0600)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	revert.Add(func() { os.Remove(profilePath) })
@@ -106,56 +156,23 @@ func archiveProfileLoad(sysOS *sys.OS, output string, allowedCommandPaths []stri
 	// Load it.
 	err = loadProfile(sysOS, name)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	revert.Success()
-	return name, nil
+	return nil
 }
 
-// archiveProfile generates the AppArmor profile template from the given destination path.
-func archiveProfile(name string, outputPath string, allowedCommandPaths []string) (string, error) {
-	// Attempt to deref all paths.
-	outputPathFull, err := filepath.EvalSymlinks(outputPath)
-	if err != nil {
-		outputPathFull = outputPath // Use requested path if cannot resolve it.
-	}
-
-	backupsPath := internalUtil.VarPath("backups")
-	backupsPathFull, err := filepath.EvalSymlinks(backupsPath)
-	if err == nil {
-		backupsPath = backupsPathFull
-	}
-
-	imagesPath := internalUtil.VarPath("images")
-	imagesPathFull, err := filepath.EvalSymlinks(imagesPath)
-	if err == nil {
-		imagesPath = imagesPathFull
-	}
-
-	derefCommandPaths := make([]string, len(allowedCommandPaths))
-	for i, cmd := range allowedCommandPaths {
-		cmdPath, err := exec.LookPath(cmd)
-		if err == nil {
-			cmd = cmdPath
-		}
-
-		cmdFull, err := filepath.EvalSymlinks(cmd)
-		if err == nil {
-			derefCommandPaths[i] = cmdFull
-		} else {
-			derefCommandPaths[i] = cmd
-		}
-	}
-
+// archiveProfile generates the AppArmor profile template from the given dereferenced paths.
+func archiveProfile(name string, outputPath string, backupsPath string, imagesPath string, allowedCmdPaths []string) (string, error) {
 	// Render the profile.
 	var sb *strings.Builder = &strings.Builder{}
-	err = archiveProfileTpl.Execute(sb, map[string]any{
+	err := archiveProfileTpl.Execute(sb, map[string]any{
 		"name":                name,
-		"outputPath":          outputPathFull, // Use deferenced path in AppArmor profile.
+		"outputPath":          outputPath,
 		"backupsPath":         backupsPath,
 		"imagesPath":          imagesPath,
-		"allowedCommandPaths": derefCommandPaths,
+		"allowedCommandPaths": allowedCmdPaths,
 	})
 	if err != nil {
 		return "", err
@@ -0,0 +1,36 @@
+package apparmor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveProfile(t *testing.T) {
+	profile, err := archiveProfile("incus_archive-test", "/srv/unpack", "/var/lib/incus/backups", "/var/lib/incus/images", []string{"/usr/bin/tar", "/usr/bin/xz"})
+	require.NoError(t, err)
+
+	// Only the target and the backups can be written to.
+	writable := []string{}
+	for _, line := range strings.Split(profile, "\n") {
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(line), ","))
+		if len(fields) == 2 && strings.HasPrefix(fields[0], "/") && strings.Contains(fields[1], "w") {
+			writable = append(writable, fields[0])
+		}
+	}
+
+	assert.Equal(t, []string{"/srv/unpack/", "/srv/unpack/**", "/var/lib/incus/backups/**"}, writable)
+	assert.Contains(t, profile, "  /var/lib/incus/images/** r,\n")
+	assert.Contains(t, profile, "  /usr/bin/xz mixr,\n")
+
+	// The network is denied and no name service lookups are allowed.
+	assert.Contains(t, profile, "  deny network,\n")
+	assert.NotContains(t, profile, "abstractions/nameservice")
+
+	// Same parameters reuse the same profile, a different target doesn't.
+	name := archiveProfileName("/srv/unpack", "/var/lib/incus/backups", "/var/lib/incus/images", []string{"/usr/bin/tar"})
+	assert.Equal(t, name, archiveProfileName("/srv/unpack", "/var/lib/incus/backups", "/var/lib/incus/images", []string{"/usr/bin/tar"}))
+	assert.NotEqual(t, name, archiveProfileName("/srv/other", "/var/lib/incus/backups", "/var/lib/incus/images", []string{"/usr/bin/tar"}))
+}
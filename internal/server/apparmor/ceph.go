@@ -0,0 +1,221 @@
+package apparmor
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lxc/incus/v6/internal/revert"
+	"github.com/lxc/incus/v6/internal/server/sys"
+	"github.com/lxc/incus/v6/shared/util"
+)
+
+var cephProfileTpl = template.Must(template.New("cephProfile").Parse(`#include <tunables/global>
+profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
+  #include <abstractions/base>
+  #include <abstractions/nameservice>
+  #include <abstractions/python>
+
+  # Talking to the monitors and OSDs.
+  network inet stream,
+  network inet6 stream,
+
+  # Mapping and unmapping block devices (rbd map/unmap).
+  capability sys_admin,
+  network netlink raw,
+  /dev/rbd* rw,
+  /dev/rbd/** r,
+  /sys/bus/rbd/** rw,
+  /sys/devices/rbd/** r,
+  /sys/devices/virtual/block/rbd*/** r,
+  /sys/module/rbd/** r,
+
+  @{PROC}/@{pid}/cmdline r,
+  @{PROC}/@{pid}/mounts r,
+  @{PROC}/@{pid}/task/@{tid}/comm rw,
+  /{etc,lib,usr/lib}/os-release r,
+
+  # Configuration and keyrings.
+  /etc/ceph/ r,
+  /etc/ceph/** r,
+
+  # Admin and monitor sockets.
+  /{,var/}run/ceph/ rw,
+  /{,var/}run/ceph/** rwk,
+
+{{- if .exportPath }}
+
+  # Volume being exported or imported.
+  {{ .exportPath }} rwk,
+{{- end }}
+
+{{range $index, $element := .allowedCmdPaths}}
+  {{$element}} mixr,
+{{- end }}
+
+{{if .libraryPath -}}
+  # Entries from LD_LIBRARY_PATH
+{{range $index, $element := .libraryPath}}
+  {{$element}}/** mr,
+{{- end }}
+{{- end }}
+
+  # Silence denials on files that aren't required (the tools work fine without them).
+  deny /etc/ssl/openssl.cnf r,
+  deny /sys/devices/virtual/dmi/id/product_uuid r,
+  deny /sys/kernel/mm/transparent_hugepage/** r,
+  deny /var/log/ceph/** w,
+  deny /var/lib/ceph/** r,
+
+{{- if .raw }}
+
+  ### Configuration: apparmor.raw.ceph
+{{ .raw }}
+{{- end }}
+}
+`))
+
+// cephProfiles holds the ceph profiles currently loaded.
+var cephProfiles = newProfileCache(time.Minute)
+
+// CephWrapper confines a ceph or rbd command run against clusterName.
+// exportPath is the file the command reads or writes a volume from or to, if any.
+// Setting INCUS_SECURITY_APPARMOR_CEPH to false runs the commands unconfined, for debugging.
+func CephWrapper(sysOS *sys.OS, cmd *exec.Cmd, clusterName string, exportPath string) (func(), error) {
+	if Enforcement(sysOS) == EnforcementDisabled || util.IsFalse(os.Getenv("INCUS_SECURITY_APPARMOR_CEPH")) {
+		return func() {}, nil
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Attempt to deref all paths.
+	if exportPath != "" {
+		fullPath, err := filepath.EvalSymlinks(exportPath)
+		if err == nil {
+			exportPath = fullPath
+		}
+	}
+
+	// Load the profile, or reuse it if already loaded for the same cluster, path and rules.
+	raw := getRawRules(RawRulesCeph)
+	name := cephProfileName(clusterName, exportPath, raw)
+	unload := func() error { return deleteProfile(sysOS, name, name) }
+
+	err := cephProfiles.acquire(name, func() error { return cephProfileLoad(sysOS, name, exportPath, raw) })
+	if err != nil {
+		return unconfined(cmd.Args[0], fmt.Errorf("Failed to load ceph profile: %w", err))
+	}
+
+	revert.Add(func() { cephProfiles.release(name, unload) })
+
+	// Resolve aa-exec.
+	execPath, err := exec.LookPath("aa-exec")
+	if err != nil {
+		return unconfined(cmd.Args[0], err)
+	}
+
+	// Override the command.
+	newArgs := []string{"aa-exec", "-p", qualifiedName(sysOS, name)}
+	newArgs = append(newArgs, cmd.Args...)
+	cmd.Args = newArgs
+	cmd.Path = execPath
+
+	// All done, setup a cleanup function and disarm reverter.
+	cleanup := func() {
+		cephProfiles.release(name, unload)
+	}
+
+	revert.Success()
+
+	return cleanup, nil
+}
+
+// cephProfileName returns the name of the profile for the given cluster, export path and raw rules.
+func cephProfileName(clusterName string, exportPath string, raw string) string {
+	hash := sha256.New()
+	for _, field := range []string{clusterName, exportPath, os.Getenv("LD_LIBRARY_PATH"), raw} {
+		_, _ = io.WriteString(hash, field)
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return profileName("ceph", fmt.Sprintf("%x", hash.Sum(nil)))
+}
+
+func cephProfileLoad(sysOS *sys.OS, name string, exportPath string, raw string) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	profilePath := filepath.Join(aaPath, "profiles", name)
+
+	// Generate the profile
+	content, err := cephProfile(name, exportPath, raw)
+	if err != nil {
+		return err
+	}
+
+	// Write it to disk.
+	err = os.WriteFile(profilePath, []byte(content), 0600)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { os.Remove(profilePath) })
+
+	// Load it.
+	err = loadProfile(sysOS, name)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// cephProfile generates the AppArmor profile for the ceph tools from the given export path.
+// raw holds extra rules, already indented for the profile body.
+func cephProfile(name string, exportPath string, raw string) (string, error) {
+	// Fully deref the tools, skipping those not installed.
+	allowedCmdPaths := []string{}
+	for _, cmd := range []string{"ceph", "rbd"} {
+		cmdPath, err := exec.LookPath(cmd)
+		if err != nil {
+			continue
+		}
+
+		cmdFull, err := filepath.EvalSymlinks(cmdPath)
+		if err == nil {
+			cmdPath = cmdFull
+		}
+
+		allowedCmdPaths = append(allowedCmdPaths, cmdPath)
+	}
+
+	libraryPath := []string{}
+	for _, path := range strings.Split(os.Getenv("LD_LIBRARY_PATH"), ":") {
+		if path != "" {
+			libraryPath = append(libraryPath, path)
+		}
+	}
+
+	// Render the profile.
+	var sb *strings.Builder = &strings.Builder{}
+	err := cephProfileTpl.Execute(sb, map[string]any{
+		"name":            name,
+		"exportPath":      exportPath,
+		"allowedCmdPaths": allowedCmdPaths,
+		"libraryPath":     libraryPath,
+		"raw":             raw,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
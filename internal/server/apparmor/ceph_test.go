@@ -0,0 +1,25 @@
+package apparmor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCephProfile(t *testing.T) {
+	profile, err := cephProfile("incus_ceph-test", "", "")
+	require.NoError(t, err)
+	assert.Contains(t, profile, "profile \"incus_ceph-test\"")
+	assert.Contains(t, profile, "  /etc/ceph/** r,\n")
+	assert.NotContains(t, profile, "# Volume being exported")
+	assert.NotContains(t, profile, "  /** mr,")
+
+	profile, err = cephProfile("incus_ceph-test", "/srv/export.img", "")
+	require.NoError(t, err)
+	assert.Contains(t, profile, "  /srv/export.img rwk,\n")
+
+	// The same cluster and path share a profile.
+	assert.Equal(t, cephProfileName("ceph", "/srv/export.img", ""), cephProfileName("ceph", "/srv/export.img", ""))
+	assert.NotEqual(t, cephProfileName("ceph", "", ""), cephProfileName("other", "", ""))
+}
@@ -0,0 +1,182 @@
+package apparmor
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// Limits on the denials reported, parsing and logging them not being free.
+const (
+	denialsInterval = 10 * time.Second
+	denialsBurst    = 20
+)
+
+// Denial is an AppArmor denial in one of the generated tool profiles.
+type Denial struct {
+	Profile   string
+	Operation string
+	Name      string
+	Denied    string
+	Command   string
+	PID       int
+}
+
+// String returns a short description of the denial.
+func (d Denial) String() string {
+	return fmt.Sprintf("%s: %s %q denied %q (%s[%d])", d.Profile, d.Operation, d.Name, d.Denied, d.Command, d.PID)
+}
+
+// ListenDenials follows the audit log until ctx is done, calling handler for the denials in the generated
+// tool profiles. Denials beyond denialsBurst per denialsInterval are dropped and only counted.
+// This requires the CAP_AUDIT_READ capability.
+func ListenDenials(ctx context.Context, handler func(Denial)) error {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, unix.NETLINK_AUDIT)
+	if err != nil {
+		return fmt.Errorf("Failed creating audit socket: %w", err)
+	}
+
+	// Subscribe to the read-only copy of the audit log.
+	err = unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: unix.AUDIT_NLGRP_READLOG})
+	if err != nil {
+		_ = unix.Close(fd)
+		return fmt.Errorf("Failed subscribing to the audit log: %w", err)
+	}
+
+	// Non-blocking, so closing the file interrupts pending reads.
+	sock := os.NewFile(uintptr(fd), "audit")
+
+	go func() {
+		<-ctx.Done()
+		_ = sock.Close()
+	}()
+
+	go func() {
+		buf := make([]byte, unix.Getpagesize()*4)
+		limiter := denialsLimiter{}
+
+		for {
+			n, err := sock.Read(buf)
+			if err != nil {
+				return
+			}
+
+			msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+			if err != nil {
+				continue
+			}
+
+			for _, msg := range msgs {
+				if msg.Header.Type != unix.AUDIT_AVC {
+					continue
+				}
+
+				denial, ok := parseDenial(string(msg.Data))
+				if !ok {
+					continue
+				}
+
+				allowed, suppressed := limiter.allow(time.Now())
+				if suppressed > 0 {
+					logger.Warn("Dropped AppArmor denials over the rate limit", logger.Ctx{"count": suppressed})
+				}
+
+				if allowed {
+					handler(*denial)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// parseDenial parses an AppArmor audit record, only returning denials in the generated tool profiles.
+func parseDenial(record string) (*Denial, bool) {
+	fields := auditFields(record)
+	if fields["apparmor"] != "DENIED" || !isWrapperProfile(fields["profile"]) {
+		return nil, false
+	}
+
+	pid, _ := strconv.Atoi(fields["pid"])
+
+	return &Denial{
+		Profile:   fields["profile"],
+		Operation: fields["operation"],
+		Name:      fields["name"],
+		Denied:    fields["denied_mask"],
+		Command:   fields["comm"],
+		PID:       pid,
+	}, true
+}
+
+// auditFields splits an audit record into its key=value fields, unquoting the values.
+// Strings which audit had to hex encode, such as paths containing spaces, are decoded back.
+func auditFields(record string) map[string]string {
+	fields := map[string]string{}
+	record = strings.TrimRight(record, "\x00\n")
+
+	for len(record) > 0 {
+		key, rest, found := strings.Cut(record, "=")
+		if !found {
+			break
+		}
+
+		// Skip the record header, as in "audit(1700000000.123:42): apparmor=...".
+		key = key[strings.LastIndex(key, " ")+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			value, record, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, record, _ = strings.Cut(rest, " ")
+
+			if slices.Contains([]string{"profile", "name", "comm"}, key) {
+				decoded, err := hex.DecodeString(value)
+				if err == nil {
+					value = string(decoded)
+				}
+			}
+		}
+
+		fields[key] = value
+	}
+
+	return fields
+}
+
+// denialsLimiter allows up to denialsBurst denials per denialsInterval.
+type denialsLimiter struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// allow returns whether a denial at now should be reported, and once per interval the number dropped in the previous one.
+func (l *denialsLimiter) allow(now time.Time) (bool, int) {
+	suppressed := 0
+	if now.Sub(l.start) >= denialsInterval {
+		suppressed = l.suppressed
+		l.start = now
+		l.count = 0
+		l.suppressed = 0
+	}
+
+	if l.count >= denialsBurst {
+		l.suppressed++
+		return false, suppressed
+	}
+
+	l.count++
+	return true, suppressed
+}
@@ -0,0 +1,48 @@
+package apparmor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDenial(t *testing.T) {
+	// A denial in a generated profile.
+	denial, ok := parseDenial(`audit(1700000000.123:42): apparmor="DENIED" operation="open" class="file" profile="incus_rsync-0123abcd" name="/etc/shadow" pid=1234 comm="rsync" requested_mask="r" denied_mask="r" fsuid=0 ouid=0` + "\x00")
+	assert.True(t, ok)
+	assert.Equal(t, Denial{Profile: "incus_rsync-0123abcd", Operation: "open", Name: "/etc/shadow", Denied: "r", Command: "rsync", PID: 1234}, *denial)
+
+	// Hex encoded paths are decoded.
+	denial, ok = parseDenial(`audit(1700000000.123:43): apparmor="DENIED" operation="mknod" profile="incus_archive-0123abcd" name=2F7372762F6120622F63 pid=99 comm="tar" requested_mask="c" denied_mask="c"`)
+	assert.True(t, ok)
+	assert.Equal(t, "/srv/a b/c", denial.Name)
+
+	// Instance profiles and allowed accesses are ignored.
+	_, ok = parseDenial(`audit(1700000000.123:44): apparmor="DENIED" operation="open" profile="incus-c1_</var/lib/incus>" name="/etc/shadow" pid=1 comm="cat" denied_mask="r"`)
+	assert.False(t, ok)
+
+	_, ok = parseDenial(`audit(1700000000.123:45): apparmor="ALLOWED" operation="open" profile="incus_rsync-0123abcd" name="/etc/shadow" pid=1 comm="rsync" denied_mask="r"`)
+	assert.False(t, ok)
+}
+
+func TestDenialsLimiter(t *testing.T) {
+	limiter := denialsLimiter{}
+	now := time.Now()
+
+	for i := 0; i < denialsBurst; i++ {
+		allowed, _ := limiter.allow(now)
+		assert.True(t, allowed)
+	}
+
+	// Over the burst, denials are dropped and counted.
+	for i := 0; i < 5; i++ {
+		allowed, _ := limiter.allow(now)
+		assert.False(t, allowed)
+	}
+
+	// The next interval reports how many were dropped.
+	allowed, suppressed := limiter.allow(now.Add(denialsInterval))
+	assert.True(t, allowed)
+	assert.Equal(t, 5, suppressed)
+}
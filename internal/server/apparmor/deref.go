@@ -0,0 +1,99 @@
+package apparmor
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// derefCacheTTL is how long a resolved path is reused before being resolved again.
+const derefCacheTTL = 30 * time.Second
+
+// derefCache holds recently resolved paths, avoiding repeated lookups on slow or automounted filesystems.
+var derefCache = &resolvedPaths{paths: map[string]resolvedPath{}}
+
+// resolvedPaths maps paths to their resolution.
+type resolvedPaths struct {
+	mu    sync.Mutex
+	paths map[string]resolvedPath
+}
+
+// resolvedPath is a path with its symlinks resolved and when that resolution expires.
+type resolvedPath struct {
+	path    string
+	expires time.Time
+}
+
+// get returns the resolution of path if still valid.
+func (c *resolvedPaths) get(path string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.paths[path]
+	if !ok || time.Now().After(entry.expires) {
+		return "", false
+	}
+
+	return entry.path, true
+}
+
+// set records the resolution of path, dropping expired entries along the way.
+func (c *resolvedPaths) set(path string, fullPath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range c.paths {
+		if now.After(entry.expires) {
+			delete(c.paths, key)
+		}
+	}
+
+	c.paths[path] = resolvedPath{path: fullPath, expires: now.Add(derefCacheTTL)}
+}
+
+// derefPaths returns paths with their symlinks resolved, skipping empty ones.
+func derefPaths(paths []string) []string {
+	fullPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		fullPath := derefPath(path)
+		logger.Debug("Resolved path for AppArmor profile", logger.Ctx{"path": path, "resolved": fullPath})
+
+		fullPaths = append(fullPaths, fullPath)
+	}
+
+	return fullPaths
+}
+
+// derefPath returns path with its symlinks resolved. When path doesn't exist yet, such as a destination
+// about to be created, its closest existing parent is resolved instead and the missing components appended back.
+// Any other failure leaves path as is.
+func derefPath(path string) string {
+	path = filepath.Clean(path)
+
+	fullPath, ok := derefCache.get(path)
+	if ok {
+		return fullPath
+	}
+
+	fullPath, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		derefCache.set(path, fullPath)
+		return fullPath
+	}
+
+	parent := filepath.Dir(path)
+	if !errors.Is(err, fs.ErrNotExist) || parent == path {
+		return path
+	}
+
+	return filepath.Join(derefPath(parent), filepath.Base(path))
+}
@@ -0,0 +1,42 @@
+package apparmor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerefPath(t *testing.T) {
+	t.Cleanup(func() { derefCache = &resolvedPaths{paths: map[string]resolvedPath{}} })
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Mkdir(target, 0755))
+	require.NoError(t, os.Symlink(target, link))
+
+	// Existing paths are resolved.
+	assert.Equal(t, target, derefPath(link))
+
+	// Missing leaves are appended to their resolved parent.
+	assert.Equal(t, filepath.Join(target, "new"), derefPath(filepath.Join(link, "new")))
+	assert.Equal(t, filepath.Join(target, "new", "leaf"), derefPath(filepath.Join(link, "new", "leaf")+"/"))
+
+	// Resolutions are reused until they expire.
+	other := filepath.Join(dir, "other")
+	require.NoError(t, os.Mkdir(other, 0755))
+	require.NoError(t, os.Remove(link))
+	require.NoError(t, os.Symlink(other, link))
+	assert.Equal(t, target, derefPath(link))
+
+	derefCache = &resolvedPaths{paths: map[string]resolvedPath{}}
+	assert.Equal(t, other, derefPath(link))
+
+	// Empty paths are skipped.
+	assert.Equal(t, []string{other}, derefPaths([]string{"", link}))
+}
@@ -0,0 +1,66 @@
+package apparmor
+
+import (
+	"sync"
+
+	"github.com/lxc/incus/v6/internal/server/sys"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// Enforcement modes of the profiles confining the tools run by the daemon, matching the apparmor.enforcement server key.
+const (
+	// EnforcementRequired fails the operation when a tool can't be confined.
+	EnforcementRequired = "required"
+
+	// EnforcementBestEffort runs the tool unconfined when it can't be confined.
+	EnforcementBestEffort = "best-effort"
+
+	// EnforcementDisabled runs the tools unconfined, without generating any profile.
+	EnforcementDisabled = "disabled"
+)
+
+// enforcement holds the current enforcement mode.
+var enforcement = EnforcementRequired
+var enforcementMu sync.Mutex
+
+// SetEnforcement sets how the tools run by the daemon are confined, an empty mode meaning required.
+func SetEnforcement(mode string) {
+	if mode == "" {
+		mode = EnforcementRequired
+	}
+
+	enforcementMu.Lock()
+	defer enforcementMu.Unlock()
+
+	enforcement = mode
+}
+
+// Enforcement returns how the tools run by the daemon are effectively confined.
+// Nothing is enforced when the daemon can't load profiles, such as when nested without stacking.
+func Enforcement(sysOS *sys.OS) string {
+	if !sysOS.AppArmorAvailable || !sysOS.AppArmorAdmin {
+		return EnforcementDisabled
+	}
+
+	return enforcementMode()
+}
+
+// enforcementMode returns the configured enforcement mode.
+func enforcementMode() string {
+	enforcementMu.Lock()
+	defer enforcementMu.Unlock()
+
+	return enforcement
+}
+
+// unconfined handles a failure to confine tool according to the enforcement mode. The error is returned
+// in required mode, otherwise it's logged and a no-op cleanup is returned for the tool to run unconfined.
+func unconfined(tool string, err error) (func(), error) {
+	if enforcementMode() == EnforcementRequired {
+		return nil, err
+	}
+
+	logger.Warn("Running unconfined after failing to load its AppArmor profile", logger.Ctx{"tool": tool, "err": err})
+
+	return func() {}, nil
+}
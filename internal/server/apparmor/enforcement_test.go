@@ -0,0 +1,66 @@
+package apparmor
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/server/sys"
+)
+
+func TestRsyncWrapperEnforcement(t *testing.T) {
+	// Profiles can't be written, so loading them always fails.
+	oldPath := aaPath
+	aaPath = filepath.Join(t.TempDir(), "missing")
+	t.Cleanup(func() {
+		aaPath = oldPath
+		SetEnforcement("")
+	})
+
+	sysOS := &sys.OS{ExecPath: "/usr/bin/incusd", AppArmorAvailable: true, AppArmorAdmin: true}
+	paths := []RsyncPath{{Path: "/srv/a", Access: RsyncAccessReadOnly}, {Path: "/srv/b", Access: RsyncAccessFull}}
+
+	// Required fails the operation.
+	SetEnforcement(EnforcementRequired)
+	cmd := exec.Command("rsync", "/srv/a/", "/srv/b")
+	_, err := RsyncWrapper(sysOS, cmd, paths)
+	assert.Error(t, err)
+	assert.Equal(t, EnforcementRequired, Enforcement(sysOS))
+
+	// Best-effort runs unconfined.
+	SetEnforcement(EnforcementBestEffort)
+	cmd = exec.Command("rsync", "/srv/a/", "/srv/b")
+	cleanup, err := RsyncWrapper(sysOS, cmd, paths)
+	require.NoError(t, err)
+	cleanup()
+	assert.Equal(t, []string{"rsync", "/srv/a/", "/srv/b"}, cmd.Args)
+
+	// Failed loads aren't cached.
+	rsyncProfiles.mu.Lock()
+	assert.Empty(t, rsyncProfiles.profiles)
+	rsyncProfiles.mu.Unlock()
+
+	// Disabled doesn't even try.
+	SetEnforcement(EnforcementDisabled)
+	cmd = exec.Command("rsync", "/srv/a/", "/srv/b")
+	cleanup, err = RsyncWrapper(sysOS, cmd, paths)
+	require.NoError(t, err)
+	cleanup()
+	assert.Equal(t, []string{"rsync", "/srv/a/", "/srv/b"}, cmd.Args)
+
+	// Without AppArmor, nothing is enforced.
+	assert.Equal(t, EnforcementDisabled, Enforcement(&sys.OS{}))
+
+	// Nor when profiles can't be loaded, such as when nested without stacking.
+	SetEnforcement(EnforcementRequired)
+	assert.Equal(t, EnforcementDisabled, Enforcement(&sys.OS{AppArmorAvailable: true}))
+
+	cmd = exec.Command("rsync", "/srv/a/", "/srv/b")
+	cleanup, err = RsyncWrapper(&sys.OS{ExecPath: "/usr/bin/incusd", AppArmorAvailable: true}, cmd, paths)
+	require.NoError(t, err)
+	cleanup()
+	assert.Equal(t, []string{"rsync", "/srv/a/", "/srv/b"}, cmd.Args)
+}
@@ -0,0 +1,212 @@
+package apparmor
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lxc/incus/v6/internal/revert"
+	"github.com/lxc/incus/v6/internal/server/sys"
+)
+
+var forkfileProfileTpl = template.Must(template.New("forkfileProfile").Parse(`#include <tunables/global>
+profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
+  #include <abstractions/base>
+
+  capability chown,
+  capability dac_override,
+  capability dac_read_search,
+  capability fowner,
+  capability fsetid,
+  capability mknod,
+  capability setfcap,
+  capability setgid,
+  capability setuid,
+
+  # Attaching to the instance, or chrooting into its filesystem when stopped.
+  capability sys_admin,
+  capability sys_chroot,
+  capability sys_ptrace,
+  ptrace (read),
+  @{PROC}/*/ns/* r,
+  @{PROC}/@{pid}/** r,
+
+  # Serving SFTP on the socket handed over by the daemon.
+  unix (accept, receive, send, getattr, getopt, setopt, shutdown) type=stream,
+  {{ .socketPath }} rw,
+
+  # The instance filesystem.
+{{- range .paths }}
+  {{ . }}/ rw,
+  {{ . }}/** rwkl,
+{{- end }}
+
+  {{ .execPath }} mr,
+
+{{if .libraryPath -}}
+  # Entries from LD_LIBRARY_PATH
+{{range $index, $element := .libraryPath}}
+  {{$element}}/** mr,
+{{- end }}
+{{- end }}
+
+  # Never needed by file transfers.
+  deny network inet,
+  deny network inet6,
+
+  # Silence denials on files that aren't required.
+  deny /sys/kernel/mm/transparent_hugepage/hpage_pmd_size r,
+}
+`))
+
+// forkfileProfiles holds the forkfile profiles currently loaded.
+var forkfileProfiles = newProfileCache(time.Minute)
+
+// ForkfileWrapper confines the forkfile helper serving the files of an instance over SFTP.
+// The profile only allows the helper to use socketPath and the given paths, which must be those of the instance
+// filesystem as seen by the helper: its rootfs for a stopped instance, the root of its mount namespace otherwise.
+func ForkfileWrapper(sysOS *sys.OS, cmd *exec.Cmd, socketPath string, paths []string) (func(), error) {
+	if Enforcement(sysOS) == EnforcementDisabled {
+		return func() {}, nil
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Attempt to deref all paths, keeping the requested ones as the path seen through a
+	// shifted or idmapped mount can differ from where the storage is mounted.
+	socketPath = derefPath(socketPath)
+	paths = forkfilePaths(paths)
+
+	// Load the profile, or reuse it if already loaded for the same paths.
+	name := forkfileProfileName(sysOS, socketPath, paths)
+	unload := func() error { return deleteProfile(sysOS, name, name) }
+
+	err := forkfileProfiles.acquire(name, func() error { return forkfileProfileLoad(sysOS, name, socketPath, paths) })
+	if err != nil {
+		return unconfined("forkfile", fmt.Errorf("Failed to load forkfile profile: %w", err))
+	}
+
+	revert.Add(func() { forkfileProfiles.release(name, unload) })
+
+	// Resolve aa-exec.
+	execPath, err := exec.LookPath("aa-exec")
+	if err != nil {
+		return unconfined("forkfile", err)
+	}
+
+	// Override the command.
+	newArgs := []string{"aa-exec", "-p", qualifiedName(sysOS, name)}
+	newArgs = append(newArgs, cmd.Args...)
+	cmd.Args = newArgs
+	cmd.Path = execPath
+
+	// All done, setup a cleanup function and disarm reverter.
+	cleanup := func() {
+		forkfileProfiles.release(name, unload)
+	}
+
+	revert.Success()
+
+	return cleanup, nil
+}
+
+// forkfilePaths returns the given paths along with their dereferenced form, without trailing slashes.
+func forkfilePaths(paths []string) []string {
+	fullPaths := []string{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		for _, p := range []string{filepath.Clean(path), derefPath(path)} {
+			p = strings.TrimSuffix(p, "/")
+			if !slices.Contains(fullPaths, p) {
+				fullPaths = append(fullPaths, p)
+			}
+		}
+	}
+
+	return fullPaths
+}
+
+// forkfileProfileName returns the name of the profile for the given socket and paths, the same parameters
+// always resulting in the same name so that the profile can be reused.
+func forkfileProfileName(sysOS *sys.OS, socketPath string, paths []string) string {
+	hash := sha256.New()
+	for _, field := range append([]string{socketPath, sysOS.ExecPath, os.Getenv("LD_LIBRARY_PATH")}, paths...) {
+		_, _ = io.WriteString(hash, field)
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return profileName("forkfile", fmt.Sprintf("%x", hash.Sum(nil)))
+}
+
+func forkfileProfileLoad(sysOS *sys.OS, name string, socketPath string, paths []string) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	profilePath := filepath.Join(aaPath, "profiles", name)
+
+	// Generate the profile
+	content, err := forkfileProfile(sysOS, name, socketPath, paths)
+	if err != nil {
+		return err
+	}
+
+	// Write it to disk.
+	err = os.WriteFile(profilePath, []byte(content), 0600)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { os.Remove(profilePath) })
+
+	// Load it.
+	err = loadProfile(sysOS, name)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// forkfileProfile generates the AppArmor profile template from the given socket and instance paths.
+func forkfileProfile(sysOS *sys.OS, name string, socketPath string, paths []string) (string, error) {
+	// Fully deref the executable path.
+	execPath := sysOS.ExecPath
+	fullPath, err := filepath.EvalSymlinks(execPath)
+	if err == nil {
+		execPath = fullPath
+	}
+
+	libraryPath := []string{}
+	for _, path := range strings.Split(os.Getenv("LD_LIBRARY_PATH"), ":") {
+		if path != "" {
+			libraryPath = append(libraryPath, path)
+		}
+	}
+
+	// Render the profile.
+	var sb *strings.Builder = &strings.Builder{}
+	err = forkfileProfileTpl.Execute(sb, map[string]any{
+		"name":        name,
+		"execPath":    execPath,
+		"socketPath":  socketPath,
+		"paths":       paths,
+		"libraryPath": libraryPath,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,53 @@
+package apparmor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/server/sys"
+)
+
+func TestForkfileProfile(t *testing.T) {
+	sysOS := &sys.OS{ExecPath: "/usr/bin/incusd"}
+
+	// A stopped instance is reached through its rootfs.
+	profile, err := forkfileProfile(sysOS, "incus_forkfile-test", "/run/incus/c1/forkfile.sock", []string{"/var/lib/incus/containers/c1/rootfs"})
+	require.NoError(t, err)
+
+	assert.Contains(t, profile, "  /var/lib/incus/containers/c1/rootfs/ rw,\n  /var/lib/incus/containers/c1/rootfs/** rwkl,\n")
+	assert.Contains(t, profile, "  /run/incus/c1/forkfile.sock rw,\n")
+	assert.Contains(t, profile, "  deny network inet,\n")
+	assert.NotContains(t, profile, "  / rw,")
+
+	// A running one through the root of its mount namespace.
+	profile, err = forkfileProfile(sysOS, "incus_forkfile-test", "/run/incus/c1/forkfile.sock", forkfilePaths([]string{"/"}))
+	require.NoError(t, err)
+
+	assert.Contains(t, profile, "  / rw,\n  /** rwkl,\n")
+}
+
+func TestForkfilePaths(t *testing.T) {
+	t.Cleanup(func() { derefCache = &resolvedPaths{paths: map[string]resolvedPath{}} })
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	// The instance path links to where the volume is mounted, both are allowed.
+	volume := filepath.Join(dir, "storage-pools", "default", "containers", "c1")
+	instance := filepath.Join(dir, "containers", "c1")
+	require.NoError(t, os.MkdirAll(filepath.Join(volume, "rootfs"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(instance), 0755))
+	require.NoError(t, os.Symlink(volume, instance))
+
+	paths := forkfilePaths([]string{filepath.Join(instance, "rootfs") + "/", filepath.Join(volume, "rootfs"), ""})
+	assert.Equal(t, []string{filepath.Join(instance, "rootfs"), filepath.Join(volume, "rootfs")}, paths)
+
+	// Same paths reuse the same profile.
+	sysOS := &sys.OS{ExecPath: "/usr/bin/incusd"}
+	assert.Equal(t, forkfileProfileName(sysOS, "/run/c1.sock", paths), forkfileProfileName(sysOS, "/run/c1.sock", paths))
+	assert.NotEqual(t, forkfileProfileName(sysOS, "/run/c1.sock", paths), forkfileProfileName(sysOS, "/run/c2.sock", paths))
+}
@@ -37,6 +37,11 @@ func InstanceProfileName(inst instance) string {
 	return profileName("", name)
 }
 
+// InstanceProfileLabel returns the name to attach the instance's AppArmor profile with.
+func InstanceProfileLabel(sysOS *sys.OS, inst instance) string {
+	return qualifiedName(sysOS, InstanceProfileName(inst))
+}
+
 // InstanceNamespaceName returns the instance's AppArmor namespace.
 func InstanceNamespaceName(inst instance) string {
 	// Unlike in profile names, / isn't an allowed character so replace with a -.
@@ -0,0 +1,97 @@
+package apparmor
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/subprocess"
+)
+
+// kernelPolicy is how profiles are compiled and make their way in and out of the kernel.
+// Tests replace it with a fake.
+type kernelPolicy interface {
+	// parser runs apparmor_parser with the given arguments.
+	parser(args ...string) (string, error)
+
+	// loaded returns the names of the profiles loaded in the kernel.
+	loaded() ([]string, error)
+
+	// confined returns the PIDs of the processes confined by the named profile.
+	confined(name string) ([]int, error)
+
+	// remove unloads the named profile without needing its source.
+	remove(name string) error
+}
+
+// aaPolicy is the kernel policy in use.
+var aaPolicy kernelPolicy = hostPolicy{}
+
+// hostPolicy is the kernel policy of the host.
+type hostPolicy struct{}
+
+func (hostPolicy) parser(args ...string) (string, error) {
+	return subprocess.RunCommand("apparmor_parser", args...)
+}
+
+func (hostPolicy) loaded() ([]string, error) {
+	profilesPath := "/sys/kernel/security/apparmor/policy/profiles"
+
+	entries, err := os.ReadDir(profilesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(profilesPath, entry.Name(), "name"))
+		if err != nil {
+			continue
+		}
+
+		names = append(names, strings.TrimSpace(string(content)))
+	}
+
+	return names, nil
+}
+
+func (hostPolicy) confined(name string) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	pids := []int{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		// Processes come and go, skip those which can't be read.
+		content, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "attr", "current"))
+		if err != nil {
+			continue
+		}
+
+		// Labels are "name (mode)", possibly stacked as in "name//&other (mode)".
+		label, _, _ := strings.Cut(strings.TrimSpace(string(content)), " (")
+		for _, profile := range strings.Split(label, "//&") {
+			if profile == name {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+
+	return pids, nil
+}
+
+func (hostPolicy) remove(name string) error {
+	return os.WriteFile("/sys/kernel/security/apparmor/.remove", []byte(name), 0)
+}
@@ -0,0 +1,213 @@
+package apparmor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/server/sys"
+)
+
+// fakePolicy is a kernel policy keeping the loaded profiles in memory.
+type fakePolicy struct {
+	mu sync.Mutex
+
+	profiles map[string]bool
+	pids     map[string][]int
+
+	// Number of checks for which the profile still confines its processes, -1 for forever.
+	exitAfter int
+
+	// Error returned when unloading a profile.
+	unloadErr error
+
+	removed []string
+}
+
+func (f *fakePolicy) parser(args ...string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case args[0] == "--version":
+		return "AppArmor parser version 2.12\n", nil
+	case strings.HasPrefix(args[0], "-R"):
+		if f.unloadErr != nil {
+			return "", f.unloadErr
+		}
+
+		delete(f.profiles, filepath.Base(args[2]))
+	case strings.HasPrefix(args[0], "-r"):
+		f.profiles[filepath.Base(args[2])] = true
+	}
+
+	return "", nil
+}
+
+func (f *fakePolicy) loaded() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := []string{}
+	for name := range f.profiles {
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (f *fakePolicy) confined(name string) ([]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.exitAfter == 0 {
+		return nil, nil
+	}
+
+	if f.exitAfter > 0 {
+		f.exitAfter--
+	}
+
+	return f.pids[name], nil
+}
+
+func (f *fakePolicy) remove(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.profiles, name)
+	f.removed = append(f.removed, name)
+	return nil
+}
+
+// setupFakePolicy replaces the kernel policy and profiles directory for the duration of the test.
+func setupFakePolicy(t *testing.T) *fakePolicy {
+	oldPolicy, oldPath, oldBackoff := aaPolicy, aaPath, deleteBackoff
+	t.Cleanup(func() { aaPolicy, aaPath, deleteBackoff = oldPolicy, oldPath, oldBackoff })
+
+	f := &fakePolicy{profiles: map[string]bool{}, pids: map[string][]int{}}
+	aaPolicy = f
+	aaPath = t.TempDir()
+	deleteBackoff = time.Millisecond
+
+	for _, dir := range []string{"profiles", "cache"} {
+		require.NoError(t, os.Mkdir(filepath.Join(aaPath, dir), 0700))
+	}
+
+	return f
+}
+
+func TestDeleteProfile(t *testing.T) {
+	sysOS := &sys.OS{AppArmorAvailable: true, AppArmorAdmin: true}
+	profilePath := func(name string) string { return filepath.Join(aaPath, "profiles", name) }
+
+	t.Run("unloaded", func(t *testing.T) {
+		f := setupFakePolicy(t)
+		require.NoError(t, os.WriteFile(profilePath("incus_rsync-a"), nil, 0600))
+		require.NoError(t, loadProfile(sysOS, "incus_rsync-a"))
+
+		require.NoError(t, deleteProfile(sysOS, "incus_rsync-a", "incus_rsync-a"))
+		assert.Empty(t, f.profiles)
+		assert.NoFileExists(t, profilePath("incus_rsync-a"))
+
+		// Deleting it again is a no-op.
+		require.NoError(t, deleteProfile(sysOS, "incus_rsync-a", "incus_rsync-a"))
+	})
+
+	t.Run("file already gone", func(t *testing.T) {
+		f := setupFakePolicy(t)
+		f.profiles["incus_rsync-a"] = true
+
+		require.NoError(t, deleteProfile(sysOS, "incus_rsync-a", "incus_rsync-a"))
+		assert.Empty(t, f.profiles)
+		assert.Equal(t, []string{"incus_rsync-a"}, f.removed)
+	})
+
+	t.Run("processes exiting", func(t *testing.T) {
+		f := setupFakePolicy(t)
+		require.NoError(t, os.WriteFile(profilePath("incus_rsync-a"), nil, 0600))
+		f.profiles["incus_rsync-a"] = true
+		f.pids["incus_rsync-a"] = []int{42}
+		f.exitAfter = 2
+
+		require.NoError(t, deleteProfile(sysOS, "incus_rsync-a", "incus_rsync-a"))
+		assert.Empty(t, f.profiles)
+	})
+
+	t.Run("still in use", func(t *testing.T) {
+		f := setupFakePolicy(t)
+		require.NoError(t, os.WriteFile(profilePath("incus_rsync-a"), nil, 0600))
+		f.profiles["incus_rsync-a"] = true
+		f.pids["incus_rsync-a"] = []int{42, 43}
+		f.exitAfter = -1
+
+		err := deleteProfile(sysOS, "incus_rsync-a", "incus_rsync-a")
+
+		var inUse *ProfileInUseError
+		require.True(t, errors.As(err, &inUse))
+		assert.Equal(t, []int{42, 43}, inUse.PIDs)
+
+		// The profile is left loaded, along with its file.
+		assert.True(t, f.profiles["incus_rsync-a"])
+		assert.FileExists(t, profilePath("incus_rsync-a"))
+	})
+
+	t.Run("unload failure", func(t *testing.T) {
+		f := setupFakePolicy(t)
+		require.NoError(t, os.WriteFile(profilePath("incus_rsync-a"), nil, 0600))
+		f.profiles["incus_rsync-a"] = true
+		f.unloadErr = errors.New("Read-only file system")
+
+		err := deleteProfile(sysOS, "incus_rsync-a", "incus_rsync-a")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"incus_rsync-a"`)
+
+		var inUse *ProfileInUseError
+		assert.False(t, errors.As(err, &inUse))
+		assert.FileExists(t, profilePath("incus_rsync-a"))
+	})
+}
+
+func TestProfileCacheInUse(t *testing.T) {
+	unloads := 0
+	inUse := true
+	mu := sync.Mutex{}
+
+	unload := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		unloads++
+		if inUse {
+			return &ProfileInUseError{Profile: "incus_rsync-a", PIDs: []int{42}}
+		}
+
+		return nil
+	}
+
+	c := newProfileCache(5 * time.Millisecond)
+	require.NoError(t, c.acquire("incus_rsync-a", func() error { return nil }))
+	c.release("incus_rsync-a", unload)
+
+	// The profile is kept while in use, unloading being retried.
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return unloads >= 2
+	}, time.Second, time.Millisecond)
+	assert.True(t, c.has("incus_rsync-a"))
+
+	mu.Lock()
+	inUse = false
+	mu.Unlock()
+
+	assert.Eventually(t, func() bool { return !c.has("incus_rsync-a") }, time.Second, time.Millisecond)
+}
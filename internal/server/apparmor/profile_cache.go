@@ -0,0 +1,173 @@
+package apparmor
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// profileCache keeps generated profiles loaded while they're in use and for a grace period afterwards,
+// so that repeated invocations with the same parameters don't each go through apparmor_parser.
+type profileCache struct {
+	mu       sync.Mutex
+	profiles map[string]*cachedProfile
+
+	// How long an unused profile stays loaded.
+	idle time.Duration
+}
+
+// cachedProfile tracks the users of a loaded profile.
+type cachedProfile struct {
+	// Held while the profile is being loaded or unloaded.
+	mu     sync.Mutex
+	loaded bool
+
+	// Protected by the cache lock.
+	refs  int
+	timer *time.Timer
+}
+
+// newProfileCache returns a cache unloading profiles after being unused for idle.
+func newProfileCache(idle time.Duration) *profileCache {
+	return &profileCache{
+		profiles: map[string]*cachedProfile{},
+		idle:     idle,
+	}
+}
+
+// acquire takes a reference on the named profile, calling load if it isn't loaded yet.
+// Every successful call must be followed by a call to release with the unload function of the profile.
+func (c *profileCache) acquire(name string, load func() error) error {
+	c.mu.Lock()
+	p := c.profiles[name]
+	if p == nil {
+		p = &cachedProfile{}
+		c.profiles[name] = p
+	}
+
+	p.refs++
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+
+	c.mu.Unlock()
+
+	// Wait for any concurrent load or unload of the profile.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.loaded {
+		err := load()
+		if err != nil {
+			c.mu.Lock()
+			p.refs--
+			c.forget(name, p)
+			c.mu.Unlock()
+
+			return err
+		}
+
+		p.loaded = true
+	}
+
+	return nil
+}
+
+// release drops a reference on the named profile, unloading it once unused for the idle period.
+func (c *profileCache) release(name string, unload func() error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.profiles[name]
+	if p == nil || p.refs == 0 {
+		return
+	}
+
+	p.refs--
+	if p.refs > 0 {
+		return
+	}
+
+	c.schedule(name, p, unload)
+}
+
+// schedule sets the timer unloading the named profile once idle.
+// It must be called with the cache lock held.
+func (c *profileCache) schedule(name string, p *cachedProfile, unload func() error) {
+	var timer *time.Timer
+	timer = time.AfterFunc(c.idle, func() {
+		// The timer is only known once AfterFunc returned, under the cache lock.
+		c.mu.Lock()
+		self := timer
+		c.mu.Unlock()
+
+		c.expire(name, p, self, unload)
+	})
+
+	p.timer = timer
+}
+
+// expire unloads the named profile unless it got used again since its timer was set.
+func (c *profileCache) expire(name string, p *cachedProfile, timer *time.Timer, unload func() error) {
+	c.mu.Lock()
+	if p.refs > 0 || p.timer != timer {
+		c.mu.Unlock()
+		return
+	}
+
+	p.timer = nil
+
+	// New users wait on the profile lock until it's unloaded, then load it again.
+	p.mu.Lock()
+	c.mu.Unlock()
+
+	if p.loaded {
+		err := unload()
+
+		// Keep profiles still confining processes around and try again later.
+		var inUse *ProfileInUseError
+		if errors.As(err, &inUse) {
+			p.mu.Unlock()
+
+			c.mu.Lock()
+			if p.refs == 0 && p.timer == nil {
+				c.schedule(name, p, unload)
+			}
+
+			c.mu.Unlock()
+			return
+		}
+
+		// Anything else is logged, the next user loading the profile again.
+		if err != nil {
+			logger.Warn("Failed to unload AppArmor profile", logger.Ctx{"profile": name, "err": err})
+		}
+
+		p.loaded = false
+	}
+
+	p.mu.Unlock()
+
+	c.mu.Lock()
+	c.forget(name, p)
+	c.mu.Unlock()
+}
+
+// forget removes the named profile from the cache if it's unused.
+// It must be called with the cache lock held.
+func (c *profileCache) forget(name string, p *cachedProfile) {
+	if p.refs == 0 && p.timer == nil && c.profiles[name] == p {
+		delete(c.profiles, name)
+	}
+}
+
+// has returns whether the named profile is tracked, in use or idling.
+func (c *profileCache) has(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.profiles[name] != nil
+}
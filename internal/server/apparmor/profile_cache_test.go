@@ -0,0 +1,122 @@
+package apparmor
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProfiles records the loads and unloads of profiles, failing on overlapping calls for a profile.
+type fakeProfiles struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	busy    map[string]bool
+	loaded  map[string]bool
+	loads   int
+	unloads int
+}
+
+func (f *fakeProfiles) call(name string, load bool) error {
+	f.mu.Lock()
+	if f.busy[name] {
+		f.t.Errorf("Concurrent load/unload of %q", name)
+	}
+
+	f.busy[name] = true
+	f.mu.Unlock()
+
+	// Give concurrent callers a chance to overlap.
+	time.Sleep(time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.busy[name] = false
+	f.loaded[name] = load
+	if load {
+		f.loads++
+	} else {
+		f.unloads++
+	}
+
+	return nil
+}
+
+func TestProfileCacheParallel(t *testing.T) {
+	f := &fakeProfiles{t: t, busy: map[string]bool{}, loaded: map[string]bool{}}
+	c := newProfileCache(20 * time.Millisecond)
+
+	// Simulate parallel transfers, over two sets of paths.
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("rsync-%d", i%2)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := c.acquire(name, func() error { return f.call(name, true) })
+			assert.NoError(t, err)
+
+			f.mu.Lock()
+			assert.True(t, f.loaded[name])
+			f.mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+			c.release(name, func() error { return f.call(name, false) })
+		}()
+	}
+
+	wg.Wait()
+
+	// Each profile stays loaded until idle.
+	f.mu.Lock()
+	assert.Equal(t, 2, f.loads)
+	assert.Equal(t, 0, f.unloads)
+	f.mu.Unlock()
+
+	assert.Eventually(t, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		return f.unloads == 2 && !f.loaded["rsync-0"] && !f.loaded["rsync-1"]
+	}, time.Second, 5*time.Millisecond)
+
+	c.mu.Lock()
+	assert.Empty(t, c.profiles)
+	c.mu.Unlock()
+}
+
+func TestProfileCacheReuse(t *testing.T) {
+	f := &fakeProfiles{t: t, busy: map[string]bool{}, loaded: map[string]bool{}}
+	c := newProfileCache(time.Hour)
+
+	load := func() error { return f.call("rsync", true) }
+	unload := func() error { return f.call("rsync", false) }
+
+	// Reusing a profile within the idle period doesn't reload it.
+	require.NoError(t, c.acquire("rsync", load))
+	c.release("rsync", unload)
+	require.NoError(t, c.acquire("rsync", load))
+	c.release("rsync", unload)
+
+	assert.Equal(t, 1, f.loads)
+	assert.Equal(t, 0, f.unloads)
+
+	// A failed load isn't cached.
+	err := c.acquire("broken", func() error { return fmt.Errorf("Parser failure") })
+	assert.Error(t, err)
+
+	c.mu.Lock()
+	assert.NotContains(t, c.profiles, "broken")
+	c.mu.Unlock()
+
+	// Releasing more than acquired is ignored.
+	c.release("broken", unload)
+	assert.Equal(t, 0, f.unloads)
+}
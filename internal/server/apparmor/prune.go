@@ -0,0 +1,108 @@
+package apparmor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lxc/incus/v6/internal/server/sys"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// wrapperProfilePrefixes are the name prefixes of the profiles generated for the tools run by the daemon.
+// Instance profiles are named incus-<name> and so never match these.
+var wrapperProfilePrefixes = []string{
+	profileName("archive", ""),
+	profileName("ceph", ""),
+	profileName("forkfile", ""),
+	profileName("qemu-img", ""),
+	profileName("rsync", ""),
+	profileName("transport", ""),
+}
+
+// isWrapperProfile returns whether name is that of a profile generated for a tool run by the daemon.
+func isWrapperProfile(name string) bool {
+	for _, prefix := range wrapperProfilePrefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isLiveProfile returns whether name is that of a profile in use by a running tool.
+func isLiveProfile(name string) bool {
+	for _, cache := range []*profileCache{archiveProfiles, cephProfiles, forkfileProfiles, rsyncProfiles, transportProfiles} {
+		if cache.has(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PruneProfiles unloads and deletes the tool profiles left behind by a previous run of the daemon, such as
+// after a crash in the middle of a transfer. It's meant to run at startup, before any tool is run.
+func PruneProfiles(sysOS *sys.OS) error {
+	if !sysOS.AppArmorAdmin {
+		return nil
+	}
+
+	pruned := 0
+	failed := 0
+
+	// Profiles written to disk.
+	entries, err := os.ReadDir(filepath.Join(aaPath, "profiles"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	// Profiles still loaded, possibly with their file already gone.
+	if sysOS.AppArmorAvailable {
+		loaded, err := aaPolicy.loaded()
+		if err != nil {
+			return err
+		}
+
+		for _, name := range loaded {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if !isWrapperProfile(name) || isLiveProfile(name) {
+			continue
+		}
+
+		err := deleteProfile(sysOS, name, name)
+		if err != nil {
+			// Profiles still in use are logged and left for the next run.
+			var inUse *ProfileInUseError
+			if errors.As(err, &inUse) {
+				continue
+			}
+
+			logger.Warn("Failed to prune AppArmor profile", logger.Ctx{"profile": name, "err": err})
+			failed++
+			continue
+		}
+
+		pruned++
+	}
+
+	if pruned > 0 || failed > 0 {
+		logger.Info("Pruned leftover AppArmor profiles", logger.Ctx{"pruned": pruned, "failed": failed})
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package apparmor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/server/sys"
+)
+
+func TestPruneProfiles(t *testing.T) {
+	oldPath := aaPath
+	aaPath = t.TempDir()
+	t.Cleanup(func() { aaPath = oldPath })
+
+	require.NoError(t, os.Mkdir(filepath.Join(aaPath, "profiles"), 0700))
+
+	profiles := []string{
+		"incus_rsync-0123abcd",
+		"incus_rsync-live",
+		"incus_qemu-img-<srv-a>_<srv-b>",
+		"incus_transport-0123abcd",
+		"incus-c1",
+		"incus-rsync-c1",
+		"incus_forkproxy-c1_eth0",
+		"incus_rsync-",
+	}
+
+	for _, name := range profiles {
+		require.NoError(t, os.WriteFile(filepath.Join(aaPath, "profiles", name), nil, 0600))
+	}
+
+	// A profile in use by a running transfer.
+	require.NoError(t, rsyncProfiles.acquire("incus_rsync-live", func() error { return nil }))
+	t.Cleanup(func() {
+		rsyncProfiles.mu.Lock()
+		delete(rsyncProfiles.profiles, "incus_rsync-live")
+		rsyncProfiles.mu.Unlock()
+	})
+
+	// Only the leftover tool profiles are removed.
+	err := PruneProfiles(&sys.OS{AppArmorAdmin: true})
+	require.NoError(t, err)
+
+	remaining := []string{}
+	entries, err := os.ReadDir(filepath.Join(aaPath, "profiles"))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		remaining = append(remaining, entry.Name())
+	}
+
+	assert.ElementsMatch(t, []string{"incus-c1", "incus-rsync-c1", "incus_forkproxy-c1_eth0", "incus_rsync-", "incus_rsync-live"}, remaining)
+}
@@ -44,6 +44,12 @@ profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
   {{$element}}/** mr,
 {{- end }}
 {{- end }}
+
+{{- if .raw }}
+
+  ### Configuration: apparmor.raw.qemu_img
+{{ .raw }}
+{{- end }}
 }
 `))
 
@@ -61,40 +67,32 @@ func (nwc *nullWriteCloser) Close() error {
 // expected to be the qemu-img command and its arguments.
 func QemuImg(sysOS *sys.OS, cmd []string, imgPath string, dstPath string) (string, error) {
 	//It is assumed that command starts with a program which sets resource limits, like prlimit or nice
-	allowedCmds := []string{"qemu-img", cmd[0]}
-
-	allowedCmdPaths := []string{}
-	for _, c := range allowedCmds {
-		cmdPath, err := exec.LookPath(c)
-		if err != nil {
-			return "", fmt.Errorf("Failed to find executable %q: %w", c, err)
-		}
-
-		allowedCmdPaths = append(allowedCmdPaths, cmdPath)
+	allowedCmdPaths, err := qemuImgCmdPaths(cmd[0])
+	if err != nil {
+		return "", err
 	}
 
 	// Attempt to deref all paths.
-	imgFullPath, err := filepath.EvalSymlinks(imgPath)
-	if err == nil {
-		imgPath = imgFullPath
-	}
+	imgPath, dstPath = qemuImgDerefPaths(imgPath, dstPath)
 
-	if dstPath != "" {
-		dstFullPath, err := filepath.EvalSymlinks(dstPath)
-		if err == nil {
-			dstPath = dstFullPath
+	// Load the profile, running unconfined if disabled or in best-effort mode when it fails to load.
+	profileName := ""
+	if Enforcement(sysOS) != EnforcementDisabled {
+		profileName, err = qemuImgProfileLoad(sysOS, imgPath, dstPath, allowedCmdPaths)
+		if err != nil {
+			_, err = unconfined("qemu-img", fmt.Errorf("Failed to load qemu-img profile: %w", err))
+			if err != nil {
+				return "", err
+			}
+
+			profileName = ""
+		} else {
+			defer func() {
+				_ = deleteProfile(sysOS, profileName, profileName)
+			}()
 		}
 	}
 
-	profileName, err := qemuImgProfileLoad(sysOS, imgPath, dstPath, allowedCmdPaths)
-	if err != nil {
-		return "", fmt.Errorf("Failed to load qemu-img profile: %w", err)
-	}
-
-	defer func() {
-		_ = deleteProfile(sysOS, profileName, profileName)
-	}()
-
 	var buffer bytes.Buffer
 	var output bytes.Buffer
 	p := subprocess.NewProcessWithFds(cmd[0], cmd[1:], nil, &nullWriteCloser{&output}, &nullWriteCloser{&buffer})
@@ -102,7 +100,9 @@ func QemuImg(sysOS *sys.OS, cmd []string, imgPath string, dstPath string) (strin
 		return "", fmt.Errorf("Failed creating qemu-img subprocess: %w", err)
 	}
 
-	p.SetApparmor(profileName)
+	if profileName != "" {
+		p.SetApparmor(qualifiedName(sysOS, profileName))
+	}
 
 	err = p.Start(context.Background())
 	if err != nil {
@@ -117,17 +117,54 @@ func QemuImg(sysOS *sys.OS, cmd []string, imgPath string, dstPath string) (strin
 	return output.String(), nil
 }
 
+// qemuImgCmdPaths returns the paths of qemu-img and of the resource limiting command it's run through.
+func qemuImgCmdPaths(limiter string) ([]string, error) {
+	allowedCmdPaths := []string{}
+	for _, c := range []string{"qemu-img", limiter} {
+		cmdPath, err := exec.LookPath(c)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to find executable %q: %w", c, err)
+		}
+
+		allowedCmdPaths = append(allowedCmdPaths, cmdPath)
+	}
+
+	return allowedCmdPaths, nil
+}
+
+// qemuImgDerefPaths returns the image and destination paths with their symlinks resolved.
+func qemuImgDerefPaths(imgPath string, dstPath string) (string, string) {
+	imgFullPath, err := filepath.EvalSymlinks(imgPath)
+	if err == nil {
+		imgPath = imgFullPath
+	}
+
+	if dstPath != "" {
+		dstFullPath, err := filepath.EvalSymlinks(dstPath)
+		if err == nil {
+			dstPath = dstFullPath
+		}
+	}
+
+	return imgPath, dstPath
+}
+
+// qemuImgProfileName returns the name of the qemu-img profile for the given paths.
+func qemuImgProfileName(imgPath string, dstPath string) string {
+	name := fmt.Sprintf("<%s>_<%s>", strings.ReplaceAll(strings.Trim(imgPath, "/"), "/", "-"), strings.ReplaceAll(strings.Trim(dstPath, "/"), "/", "-"))
+	return profileName("qemu-img", name)
+}
+
 // qemuImgProfileLoad ensures that the qemu-img's policy is loaded into the kernel.
 func qemuImgProfileLoad(sysOS *sys.OS, imgPath string, dstPath string, allowedCmdPaths []string) (string, error) {
-	name := fmt.Sprintf("<%s>_<%s>", strings.ReplaceAll(strings.Trim(imgPath, "/"), "/", "-"), strings.ReplaceAll(strings.Trim(dstPath, "/"), "/", "-"))
-	profileName := profileName("qemu-img", name)
+	profileName := qemuImgProfileName(imgPath, dstPath)
 	profilePath := filepath.Join(aaPath, "profiles", profileName)
 	content, err := os.ReadFile(profilePath)
 	if err != nil && !os.IsNotExist(err) {
 		return "", err
 	}
 
-	updated, err := qemuImgProfile(profileName, imgPath, dstPath, allowedCmdPaths)
+	updated, err := qemuImgProfile(profileName, imgPath, dstPath, allowedCmdPaths, getRawRules(RawRulesQemuImg))
 	if err != nil {
 		return "", err
 	}
@@ -148,7 +185,8 @@ func qemuImgProfileLoad(sysOS *sys.OS, imgPath string, dstPath string, allowedCm
 }
 
 // qemuImgProfile generates the AppArmor profile template from the given destination path.
-func qemuImgProfile(profileName string, imgPath string, dstPath string, allowedCmdPaths []string) (string, error) {
+// raw holds extra rules, already indented for the profile body.
+func qemuImgProfile(profileName string, imgPath string, dstPath string, allowedCmdPaths []string, raw string) (string, error) {
 	// Render the profile.
 	var sb *strings.Builder = &strings.Builder{}
 	err := qemuImgProfileTpl.Execute(sb, map[string]any{
@@ -157,6 +195,7 @@ func qemuImgProfile(profileName string, imgPath string, dstPath string, allowedC
 		"dstPath":         dstPath,
 		"allowedCmdPaths": allowedCmdPaths,
 		"libraryPath":     strings.Split(os.Getenv("LD_LIBRARY_PATH"), ":"),
+		"raw":             raw,
 	})
 	if err != nil {
 		return "", err
@@ -0,0 +1,106 @@
+package apparmor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lxc/incus/v6/internal/server/sys"
+)
+
+// Kinds of generated profiles that can be extended with raw rules, matching the apparmor.raw.* server keys.
+const (
+	RawRulesRsync   = "rsync"
+	RawRulesCeph    = "ceph"
+	RawRulesQemuImg = "qemu_img"
+)
+
+// RawRulesKinds lists the kinds of generated profiles that can be extended with raw rules.
+var RawRulesKinds = []string{RawRulesRsync, RawRulesCeph, RawRulesQemuImg}
+
+// rawRules holds the operator provided rules added to the generated profiles, by profile kind.
+var rawRules = map[string]string{}
+var rawRulesMu sync.Mutex
+
+// SetRawRules sets the rules added verbatim to the generated profiles of the given kind.
+// Profiles loaded from then on include the new rules.
+func SetRawRules(kind string, rules string) {
+	rawRulesMu.Lock()
+	defer rawRulesMu.Unlock()
+
+	rawRules[kind] = rules
+}
+
+// getRawRules returns the rules to add to the generated profiles of the given kind, indented for the profile body.
+func getRawRules(kind string) string {
+	rawRulesMu.Lock()
+	defer rawRulesMu.Unlock()
+
+	return rawRulesContent(rawRules[kind])
+}
+
+// rawRulesContent indents the rules for inclusion in a profile body.
+func rawRulesContent(rules string) string {
+	if strings.TrimSpace(rules) == "" {
+		return ""
+	}
+
+	content := ""
+	for _, line := range strings.Split(strings.Trim(rules, "\n"), "\n") {
+		content += fmt.Sprintf("  %s\n", line)
+	}
+
+	return content
+}
+
+// ValidateRawRules checks that the generated profiles of the given kind still parse once extended with rules.
+func ValidateRawRules(sysOS *sys.OS, kind string, rules string) error {
+	raw := rawRulesContent(rules)
+	if raw == "" {
+		return nil
+	}
+
+	// Render a profile of that kind with placeholder paths.
+	var name string
+	var content string
+	var err error
+
+	switch kind {
+	case RawRulesRsync:
+		name = profileName("rsync", "validate")
+		content, err = rsyncProfile(sysOS, name, []RsyncPath{{Path: "/validate/source", Access: RsyncAccessReadOnly}, {Path: "/validate/destination", Access: RsyncAccessFull}}, raw)
+	case RawRulesCeph:
+		name = profileName("ceph", "validate")
+		content, err = cephProfile(name, "", raw)
+	case RawRulesQemuImg:
+		name = profileName("qemu-img", "validate")
+		content, err = qemuImgProfile(name, "/validate/source", "/validate/destination", nil, raw)
+	default:
+		return fmt.Errorf("Unknown profile kind %q", kind)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Parse it, then drop the profile and any cache entry left behind.
+	cacheDir, err := getCacheDir(sysOS)
+	if err != nil {
+		return err
+	}
+
+	profilePath := filepath.Join(aaPath, "profiles", name)
+	err = os.WriteFile(profilePath, []byte(content), 0600)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.Remove(profilePath)
+		_ = os.Remove(filepath.Join(cacheDir, name))
+	}()
+
+	return parseProfile(sysOS, name)
+}
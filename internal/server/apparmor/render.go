@@ -0,0 +1,45 @@
+package apparmor
+
+import (
+	"github.com/lxc/incus/v6/internal/server/sys"
+)
+
+// The functions below render the profiles the daemon would generate, without writing or loading them.
+
+// RsyncProfile returns the profile used to run rsync with the given access to paths.
+func RsyncProfile(sysOS *sys.OS, paths []RsyncPath) (string, error) {
+	paths = derefRsyncPaths(paths)
+
+	raw := getRawRules(RawRulesRsync)
+
+	return rsyncProfile(sysOS, rsyncProfileName(sysOS, paths, raw), paths, raw)
+}
+
+// CephProfile returns the profile used to run the ceph tools against clusterName, exporting to exportPath if set.
+func CephProfile(clusterName string, exportPath string) (string, error) {
+	exportPaths := derefPaths([]string{exportPath})
+	if len(exportPaths) > 0 {
+		exportPath = exportPaths[0]
+	}
+
+	raw := getRawRules(RawRulesCeph)
+
+	return cephProfile(cephProfileName(clusterName, exportPath, raw), exportPath, raw)
+}
+
+// QemuImgProfile returns the profile used to run qemu-img, through the limiter command, from imgPath to dstPath.
+func QemuImgProfile(limiter string, imgPath string, dstPath string) (string, error) {
+	allowedCmdPaths, err := qemuImgCmdPaths(limiter)
+	if err != nil {
+		return "", err
+	}
+
+	imgPath, dstPath = qemuImgDerefPaths(imgPath, dstPath)
+
+	return qemuImgProfile(qemuImgProfileName(imgPath, dstPath), imgPath, dstPath, allowedCmdPaths, getRawRules(RawRulesQemuImg))
+}
+
+// InstanceProfile returns the profile of the instance, allowing the given extra binaries.
+func InstanceProfile(sysOS *sys.OS, inst instance, extraBinaries []string) (string, error) {
+	return instanceProfile(sysOS, inst, extraBinaries)
+}
@@ -1,18 +1,19 @@
 package apparmor
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
-
-	"github.com/google/uuid"
+	"time"
 
 	"github.com/lxc/incus/v6/internal/revert"
 	"github.com/lxc/incus/v6/internal/server/sys"
-	internalUtil "github.com/lxc/incus/v6/internal/util"
 )
 
 var rsyncProfileTpl = template.Must(template.New("rsyncProfile").Parse(`#include <tunables/global>
@@ -33,19 +34,27 @@ profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
   @{PROC}/@{pid}/cpuset r,
   /{etc,lib,usr/lib}/os-release r,
 
-  {{ .logPath }}/*/netcat.log rw,
+  # The transport to the other end of a transfer, confined by its own profile.
+  /{,usr/}bin/aa-exec mixr,
+  change_profile -> "{{ .transportProfiles }}",
+  @{PROC}/@{pid}/attr/{,apparmor/}exec w,
+  @{PROC}/@{pid}/mounts r,
+  /sys/module/apparmor/parameters/enabled r,
 
   /run/{resolvconf,NetworkManager,systemd/resolve,connman,netconfig}/resolv.conf r,
   /run/systemd/resolve/stub-resolv.conf r,
 
-{{- if .sourcePath }}
-  {{ .sourcePath }}/** r,
-  {{ .sourcePath }}/ r,
+{{- range .paths }}
+{{- if eq .Access "read-only" }}
+  {{ .Path }}/** r,
+  {{ .Path }}/ r,
+{{- else if eq .Access "create-only" }}
+  {{ .Path }}/** rw,
+  {{ .Path }}/ rw,
+{{- else if eq .Access "full" }}
+  {{ .Path }}/** rwkl,
+  {{ .Path }}/ rwkl,
 {{- end }}
-
-{{- if .dstPath }}
-  {{ .dstPath }}/** rwkl,
-  {{ .dstPath }}/ rwkl,
 {{- end }}
 
   {{ .execPath }} mixr,
@@ -64,12 +73,40 @@ profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
   deny /etc/ssl/openssl.cnf r,
   deny /sys/devices/virtual/dmi/id/product_uuid r,
   deny /sys/kernel/mm/transparent_hugepage/hpage_pmd_size r,
+
+{{- if .raw }}
+
+  ### Configuration: apparmor.raw.rsync
+{{ .raw }}
+{{- end }}
 }
 `))
 
+// Access rsync can be given to a path.
+const (
+	// RsyncAccessReadOnly only allows reading the path.
+	RsyncAccessReadOnly = "read-only"
+
+	// RsyncAccessCreateOnly allows creating and writing files, without locking or hard linking them.
+	RsyncAccessCreateOnly = "create-only"
+
+	// RsyncAccessFull also allows locking and hard linking files.
+	RsyncAccessFull = "full"
+)
+
+// RsyncPath is a path rsync is given access to.
+type RsyncPath struct {
+	Path   string
+	Access string
+}
+
+// rsyncProfiles holds the rsync profiles currently loaded.
+var rsyncProfiles = newProfileCache(time.Minute)
+
 // RsyncWrapper is used as a RunWrapper in the rsync package.
-func RsyncWrapper(sysOS *sys.OS, cmd *exec.Cmd, sourcePath string, dstPath string) (func(), error) {
-	if !sysOS.AppArmorAvailable {
+// The profile allows the given access to each of the paths.
+func RsyncWrapper(sysOS *sys.OS, cmd *exec.Cmd, paths []RsyncPath) (func(), error) {
+	if Enforcement(sysOS) == EnforcementDisabled {
 		return func() {}, nil
 	}
 
@@ -77,43 +114,35 @@ func RsyncWrapper(sysOS *sys.OS, cmd *exec.Cmd, sourcePath string, dstPath strin
 	defer revert.Fail()
 
 	// Attempt to deref all paths.
-	if sourcePath != "" {
-		fullPath, err := filepath.EvalSymlinks(sourcePath)
-		if err == nil {
-			sourcePath = fullPath
-		}
-	}
+	paths = derefRsyncPaths(paths)
 
-	if dstPath != "" {
-		fullPath, err := filepath.EvalSymlinks(dstPath)
-		if err == nil {
-			dstPath = fullPath
-		}
-	}
+	// Load the profile, or reuse it if already loaded for the same paths and rules.
+	raw := getRawRules(RawRulesRsync)
+	name := rsyncProfileName(sysOS, paths, raw)
+	unload := func() error { return deleteProfile(sysOS, name, name) }
 
-	// Load the profile.
-	profileName, err := rsyncProfileLoad(sysOS, sourcePath, dstPath)
+	err := rsyncProfiles.acquire(name, func() error { return rsyncProfileLoad(sysOS, name, paths, raw) })
 	if err != nil {
-		return nil, fmt.Errorf("Failed to load rsync profile: %w", err)
+		return unconfined("rsync", fmt.Errorf("Failed to load rsync profile: %w", err))
 	}
 
-	revert.Add(func() { _ = deleteProfile(sysOS, profileName, profileName) })
+	revert.Add(func() { rsyncProfiles.release(name, unload) })
 
 	// Resolve aa-exec.
 	execPath, err := exec.LookPath("aa-exec")
 	if err != nil {
-		return nil, err
+		return unconfined("rsync", err)
 	}
 
 	// Override the command.
-	newArgs := []string{"aa-exec", "-p", profileName}
+	newArgs := []string{"aa-exec", "-p", qualifiedName(sysOS, name)}
 	newArgs = append(newArgs, cmd.Args...)
 	cmd.Args = newArgs
 	cmd.Path = execPath
 
 	// All done, setup a cleanup function and disarm reverter.
 	cleanup := func() {
-		_ = deleteProfile(sysOS, profileName, profileName)
+		rsyncProfiles.release(name, unload)
 	}
 
 	revert.Success()
@@ -121,24 +150,53 @@ func RsyncWrapper(sysOS *sys.OS, cmd *exec.Cmd, sourcePath string, dstPath strin
 	return cleanup, nil
 }
 
-func rsyncProfileLoad(sysOS *sys.OS, sourcePath string, dstPath string) (string, error) {
+// derefRsyncPaths returns paths with their symlinks resolved, skipping empty ones.
+func derefRsyncPaths(paths []RsyncPath) []RsyncPath {
+	fullPaths := make([]RsyncPath, 0, len(paths))
+	for _, path := range paths {
+		for _, fullPath := range derefPaths([]string{path.Path}) {
+			fullPaths = append(fullPaths, RsyncPath{Path: fullPath, Access: path.Access})
+		}
+	}
+
+	return fullPaths
+}
+
+// rsyncProfileName returns the name of the profile for the given paths and raw rules, the same parameters
+// always resulting in the same name so that the profile can be reused.
+func rsyncProfileName(sysOS *sys.OS, paths []RsyncPath, raw string) string {
+	hash := sha256.New()
+	for _, path := range paths {
+		_, _ = io.WriteString(hash, path.Path)
+		_, _ = hash.Write([]byte{0})
+		_, _ = io.WriteString(hash, path.Access)
+		_, _ = hash.Write([]byte{1})
+	}
+
+	for _, field := range []string{sysOS.ExecPath, os.Getenv("LD_LIBRARY_PATH"), raw} {
+		_, _ = io.WriteString(hash, field)
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return profileName("rsync", fmt.Sprintf("%x", hash.Sum(nil)))
+}
+
+func rsyncProfileLoad(sysOS *sys.OS, name string, paths []RsyncPath, raw string) error {
 	revert := revert.New()
 	defer revert.Fail()
 
-	// Generate a temporary profile name.
-	name := profileName("rsync", uuid.New().String())
 	profilePath := filepath.Join(aaPath, "profiles", name)
 
 	// Generate the profile
-	content, err := rsyncProfile(sysOS, name, sourcePath, dstPath)
+	content, err := rsyncProfile(sysOS, name, paths, raw)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	// Write it to disk.
 	err = os.WriteFile(profilePath, []byte(content), 0600)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	revert.Add(func() { os.Remove(profilePath) })
@@ -146,17 +204,22 @@ func rsyncProfileLoad(sysOS *sys.OS, sourcePath string, dstPath string) (string,
 	// Load it.
 	err = loadProfile(sysOS, name)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	revert.Success()
-	return name, nil
+	return nil
 }
 
-// rsyncProfile generates the AppArmor profile template from the given destination path.
-func rsyncProfile(sysOS *sys.OS, name string, sourcePath string, dstPath string) (string, error) {
-	// Render the profile.
-	logPath := internalUtil.LogPath("")
+// rsyncProfile generates the AppArmor profile template from the given paths.
+// raw holds extra rules, already indented for the profile body.
+func rsyncProfile(sysOS *sys.OS, name string, paths []RsyncPath, raw string) (string, error) {
+	// Refuse unknown access rather than silently granting none or too much.
+	for _, path := range paths {
+		if !slices.Contains([]string{RsyncAccessReadOnly, RsyncAccessCreateOnly, RsyncAccessFull}, path.Access) {
+			return "", fmt.Errorf("Invalid rsync access %q for %q", path.Access, path.Path)
+		}
+	}
 
 	// Fully deref the executable path.
 	execPath := sysOS.ExecPath
@@ -165,14 +228,15 @@ func rsyncProfile(sysOS *sys.OS, name string, sourcePath string, dstPath string)
 		execPath = fullPath
 	}
 
+	// Render the profile.
 	var sb *strings.Builder = &strings.Builder{}
 	err = rsyncProfileTpl.Execute(sb, map[string]any{
-		"name":        name,
-		"execPath":    execPath,
-		"sourcePath":  sourcePath,
-		"dstPath":     dstPath,
-		"logPath":     logPath,
-		"libraryPath": strings.Split(os.Getenv("LD_LIBRARY_PATH"), ":"),
+		"name":              name,
+		"execPath":          execPath,
+		"paths":             paths,
+		"libraryPath":       strings.Split(os.Getenv("LD_LIBRARY_PATH"), ":"),
+		"raw":               raw,
+		"transportProfiles": qualifiedName(sysOS, profileName("transport", "*")),
 	})
 	if err != nil {
 		return "", err
@@ -0,0 +1,140 @@
+package apparmor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/server/sys"
+)
+
+func TestRsyncProfileMultiplePaths(t *testing.T) {
+	sysOS := &sys.OS{ExecPath: "/usr/bin/incusd"}
+
+	paths := []RsyncPath{
+		{Path: "/srv/a", Access: RsyncAccessReadOnly},
+		{Path: "/srv/b", Access: RsyncAccessReadOnly},
+		{Path: "/srv/c", Access: RsyncAccessFull},
+		{Path: "/srv/d", Access: RsyncAccessFull},
+	}
+
+	profile, err := rsyncProfile(sysOS, "incus_rsync-test", paths, "")
+	require.NoError(t, err)
+
+	for _, path := range []string{"/srv/a", "/srv/b"} {
+		assert.Contains(t, profile, "  "+path+"/** r,\n")
+		assert.Contains(t, profile, "  "+path+"/ r,\n")
+	}
+
+	for _, path := range []string{"/srv/c", "/srv/d"} {
+		assert.Contains(t, profile, "  "+path+"/** rwkl,\n")
+		assert.Contains(t, profile, "  "+path+"/ rwkl,\n")
+	}
+
+	// Changing the access to a path gives a different profile.
+	readOnly := []RsyncPath{{Path: "/srv/a", Access: RsyncAccessReadOnly}}
+	full := []RsyncPath{{Path: "/srv/a", Access: RsyncAccessFull}}
+	assert.NotEqual(t, rsyncProfileName(sysOS, readOnly, ""), rsyncProfileName(sysOS, full, ""))
+	assert.Equal(t, rsyncProfileName(sysOS, readOnly, ""), rsyncProfileName(sysOS, readOnly, ""))
+}
+
+func TestRsyncProfileAccess(t *testing.T) {
+	sysOS := &sys.OS{ExecPath: "/usr/bin/incusd"}
+
+	tests := []struct {
+		access string
+		rules  []string
+	}{
+		{
+			access: RsyncAccessReadOnly,
+			rules:  []string{"  /srv/vol/** r,", "  /srv/vol/ r,"},
+		},
+		{
+			access: RsyncAccessCreateOnly,
+			rules:  []string{"  /srv/vol/** rw,", "  /srv/vol/ rw,"},
+		},
+		{
+			access: RsyncAccessFull,
+			rules:  []string{"  /srv/vol/** rwkl,", "  /srv/vol/ rwkl,"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.access, func(t *testing.T) {
+			profile, err := rsyncProfile(sysOS, "incus_rsync-test", []RsyncPath{{Path: "/srv/vol", Access: test.access}}, "")
+			require.NoError(t, err)
+
+			// The path gets exactly the rules of its access.
+			rules := []string{}
+			for _, line := range strings.Split(profile, "\n") {
+				if strings.Contains(line, "/srv/vol") {
+					rules = append(rules, line)
+				}
+			}
+
+			assert.Equal(t, test.rules, rules)
+		})
+	}
+
+	// Unknown access is refused.
+	_, err := rsyncProfile(sysOS, "incus_rsync-test", []RsyncPath{{Path: "/srv/vol", Access: "write-only"}}, "")
+	assert.Error(t, err)
+}
+
+func TestRsyncProfileRawRules(t *testing.T) {
+	sysOS := &sys.OS{ExecPath: "/usr/bin/incusd"}
+
+	paths := []RsyncPath{{Path: "/srv/a", Access: RsyncAccessReadOnly}, {Path: "/srv/b", Access: RsyncAccessFull}}
+
+	raw := rawRulesContent("/usr/lib/audit/libshim.so mr,\n/var/log/audit/** w,\n")
+	profile, err := rsyncProfile(sysOS, "incus_rsync-test", paths, raw)
+	require.NoError(t, err)
+
+	assert.Contains(t, profile, "  ### Configuration: apparmor.raw.rsync\n  /usr/lib/audit/libshim.so mr,\n  /var/log/audit/** w,\n")
+
+	// Changing the rules gives a different profile.
+	assert.NotEqual(t, rsyncProfileName(sysOS, paths, ""), rsyncProfileName(sysOS, paths, raw))
+
+	// No rules, no section.
+	profile, err = rsyncProfile(sysOS, "incus_rsync-test", paths, rawRulesContent("\n"))
+	require.NoError(t, err)
+	assert.NotContains(t, profile, "apparmor.raw.rsync")
+}
+
+func TestTransportProfile(t *testing.T) {
+	t.Setenv("LD_LIBRARY_PATH", "")
+	sysOS := &sys.OS{ExecPath: "/usr/bin/incusd"}
+
+	profile, err := transportProfile(sysOS, "incus_transport-test", "/var/log/incus/c1/netcat.log")
+	require.NoError(t, err)
+
+	assert.Contains(t, profile, "  /var/log/incus/c1/netcat.log rw,\n")
+	assert.Contains(t, profile, "  /usr/bin/incusd mr,\n")
+	assert.NotContains(t, profile, "/** mr,")
+
+	// Each log gets its own profile.
+	assert.NotEqual(t, transportProfileName(sysOS, "/var/log/incus/c1/netcat.log"), transportProfileName(sysOS, "/var/log/incus/c2/netcat.log"))
+	assert.Contains(t, transportProfileName(sysOS, "/var/log/incus/c1/netcat.log"), "incus_transport-")
+}
+
+func TestRsyncProfileNamespace(t *testing.T) {
+	paths := []RsyncPath{{Path: "/srv/a", Access: RsyncAccessReadOnly}}
+
+	// At the root, transports are attached by their plain name.
+	profile, err := rsyncProfile(&sys.OS{ExecPath: "/usr/bin/incusd"}, "incus_rsync-test", paths, "")
+	require.NoError(t, err)
+	assert.Contains(t, profile, "  change_profile -> \"incus_transport-*\",\n")
+
+	// When nested, they're qualified with the daemon's namespace.
+	sysOS := &sys.OS{ExecPath: "/usr/bin/incusd", AppArmorStacked: true, AppArmorNamespace: "incus-c1_<var-lib-incus>"}
+	profile, err = rsyncProfile(sysOS, "incus_rsync-test", paths, "")
+	require.NoError(t, err)
+	assert.Contains(t, profile, "  change_profile -> \":incus-c1_<var-lib-incus>:incus_transport-*\",\n")
+
+	// The profile itself keeps its plain name, as it's loaded in the current namespace.
+	assert.Contains(t, profile, "profile \"incus_rsync-test\" ")
+	assert.Equal(t, ":incus-c1_<var-lib-incus>:incus_rsync-test", qualifiedName(sysOS, "incus_rsync-test"))
+	assert.Equal(t, "incus_rsync-test", qualifiedName(&sys.OS{}, "incus_rsync-test"))
+}
@@ -0,0 +1,173 @@
+package apparmor
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lxc/incus/v6/internal/revert"
+	"github.com/lxc/incus/v6/internal/server/sys"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
+)
+
+var transportProfileTpl = template.Must(template.New("transportProfile").Parse(`#include <tunables/global>
+profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
+  #include <abstractions/base>
+
+  # Forwarding rsync's stdin and stdout to the daemon.
+  unix (connect, send, receive) type=stream peer=(addr="@incusd/*"),
+
+  @{PROC}/@{pid}/cmdline r,
+
+  # Log of the transfer.
+  {{ .logPath }} rw,
+
+  {{ .execPath }} mr,
+
+{{if .libraryPath -}}
+  # Entries from LD_LIBRARY_PATH
+{{range $index, $element := .libraryPath}}
+  {{$element}}/** mr,
+{{- end }}
+{{- end }}
+
+  # Silence denials on files that aren't required.
+  deny /sys/kernel/mm/transparent_hugepage/hpage_pmd_size r,
+}
+`))
+
+// transportProfiles holds the transport profiles currently loaded.
+var transportProfiles = newProfileCache(time.Minute)
+
+// TransportWrapper is used as a TransportWrapper in the rsync package.
+// The profile only allows talking to the daemon and writing the transfer log of name.
+func TransportWrapper(sysOS *sys.OS, cmd *exec.Cmd, name string) (func(), error) {
+	if Enforcement(sysOS) == EnforcementDisabled {
+		return func() {}, nil
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Load the profile, or reuse it if already loaded for the same log.
+	logPath := transportLogPath(name)
+	profile := transportProfileName(sysOS, logPath)
+	unload := func() error { return deleteProfile(sysOS, profile, profile) }
+
+	err := transportProfiles.acquire(profile, func() error { return transportProfileLoad(sysOS, profile, logPath) })
+	if err != nil {
+		return unconfined("netcat", fmt.Errorf("Failed to load transport profile: %w", err))
+	}
+
+	revert.Add(func() { transportProfiles.release(profile, unload) })
+
+	// Resolve aa-exec.
+	execPath, err := exec.LookPath("aa-exec")
+	if err != nil {
+		return unconfined("netcat", err)
+	}
+
+	// Override the command.
+	newArgs := []string{"aa-exec", "-p", qualifiedName(sysOS, profile)}
+	newArgs = append(newArgs, cmd.Args...)
+	cmd.Args = newArgs
+	cmd.Path = execPath
+
+	// All done, setup a cleanup function and disarm reverter.
+	cleanup := func() {
+		transportProfiles.release(profile, unload)
+	}
+
+	revert.Success()
+
+	return cleanup, nil
+}
+
+// transportLogPath returns the path of the log written by the transport of name, with its directory dereferenced.
+func transportLogPath(name string) string {
+	logDir := internalUtil.LogPath(name)
+	fullPath, err := filepath.EvalSymlinks(logDir)
+	if err == nil {
+		logDir = fullPath
+	}
+
+	return filepath.Join(logDir, "netcat.log")
+}
+
+// transportProfileName returns the name of the transport profile for the given log path.
+func transportProfileName(sysOS *sys.OS, logPath string) string {
+	hash := sha256.New()
+	for _, field := range []string{logPath, sysOS.ExecPath, os.Getenv("LD_LIBRARY_PATH")} {
+		_, _ = io.WriteString(hash, field)
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return profileName("transport", fmt.Sprintf("%x", hash.Sum(nil)))
+}
+
+func transportProfileLoad(sysOS *sys.OS, name string, logPath string) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	profilePath := filepath.Join(aaPath, "profiles", name)
+
+	// Generate the profile
+	content, err := transportProfile(sysOS, name, logPath)
+	if err != nil {
+		return err
+	}
+
+	// Write it to disk.
+	err = os.WriteFile(profilePath, []byte(content), 0600)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { os.Remove(profilePath) })
+
+	// Load it.
+	err = loadProfile(sysOS, name)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// transportProfile generates the AppArmor profile template for the transport writing to the given log.
+func transportProfile(sysOS *sys.OS, name string, logPath string) (string, error) {
+	// Fully deref the executable path.
+	execPath := sysOS.ExecPath
+	fullPath, err := filepath.EvalSymlinks(execPath)
+	if err == nil {
+		execPath = fullPath
+	}
+
+	libraryPath := []string{}
+	for _, path := range strings.Split(os.Getenv("LD_LIBRARY_PATH"), ":") {
+		if path != "" {
+			libraryPath = append(libraryPath, path)
+		}
+	}
+
+	// Render the profile.
+	var sb *strings.Builder = &strings.Builder{}
+	err = transportProfileTpl.Execute(sb, map[string]any{
+		"name":        name,
+		"execPath":    execPath,
+		"logPath":     logPath,
+		"libraryPath": libraryPath,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
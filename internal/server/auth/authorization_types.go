@@ -37,6 +37,7 @@ const (
 	EntitlementCanAccessFiles   Entitlement = "can_access_files"
 	EntitlementCanAccessConsole Entitlement = "can_access_console"
 	EntitlementCanExec          Entitlement = "can_exec"
+	EntitlementCanAccessDebug   Entitlement = "can_access_debug"
 
 	// Instance and storage volume entitlements.
 	EntitlementCanManageSnapshots Entitlement = "can_manage_snapshots"
@@ -55,6 +55,11 @@ func (t *tls) CheckPermission(ctx context.Context, r *http.Request, object Objec
 		return nil
 	}
 
+	// Debug access exposes the guest memory, keep it to unrestricted certificates.
+	if entitlement == EntitlementCanAccessDebug {
+		return api.StatusErrorf(http.StatusForbidden, "Certificate is restricted")
+	}
+
 	if details.isAllProjectsRequest {
 		// Only admins (users with non-restricted certs) can use the all-projects parameter.
 		return api.StatusErrorf(http.StatusForbidden, "Certificate is restricted")
@@ -124,6 +129,11 @@ func (t *tls) GetPermissionChecker(ctx context.Context, r *http.Request, entitle
 		return allowFunc(true), nil
 	}
 
+	// Debug access exposes the guest memory, keep it to unrestricted certificates.
+	if entitlement == EntitlementCanAccessDebug {
+		return nil, api.StatusErrorf(http.StatusForbidden, "Certificate is restricted")
+	}
+
 	// Handle project-restricted metrics access.
 	if certType == certificate.TypeMetrics && entitlement == EntitlementCanViewMetrics {
 		return func(o Object) bool {
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/server/certificate"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// A restricted certificate can edit the instances of its projects but not access their debug APIs.
+func TestTLSCheckPermissionDebug(t *testing.T) {
+	certificateCache := &certificate.Cache{}
+	certificateCache.SetCertificatesAndProjects(map[certificate.Type]map[string]x509.Certificate{
+		certificate.TypeClient: {
+			"restricted":   {},
+			"unrestricted": {},
+		},
+	}, map[string][]string{
+		"restricted": {"default"},
+	})
+
+	authorizer, err := LoadAuthorizer(context.Background(), DriverTLS, logger.Log, certificateCache)
+	require.NoError(t, err)
+
+	newRequest := func(fingerprint string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/1.0/instances/c1/debug/memory", nil)
+		ctx := context.WithValue(r.Context(), request.CtxUsername, fingerprint)
+		ctx = context.WithValue(ctx, request.CtxProtocol, api.AuthenticationMethodTLS)
+
+		return r.WithContext(ctx)
+	}
+
+	object := ObjectInstance("default", "c1")
+
+	// Restricted certificate.
+	r := newRequest("restricted")
+
+	err = authorizer.CheckPermission(context.Background(), r, object, EntitlementCanEdit)
+	assert.NoError(t, err)
+
+	err = authorizer.CheckPermission(context.Background(), r, object, EntitlementCanAccessDebug)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusForbidden), "Expected a 403 error, got %v", err)
+
+	_, err = authorizer.GetPermissionChecker(context.Background(), r, EntitlementCanAccessDebug, ObjectTypeInstance)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusForbidden), "Expected a 403 error, got %v", err)
+
+	// Unrestricted certificate.
+	r = newRequest("unrestricted")
+
+	err = authorizer.CheckPermission(context.Background(), r, object, EntitlementCanAccessDebug)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,44 @@
+package cgroup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SetupBackground prepares the cgroup in which the commands doing background work are placed, given by its path
+// relative to the root of the hierarchy, creating it if needed and setting its CPU and IO weights when not 0.
+// It returns the full path of the cgroup, or an error when it can't be used, like without a pure cgroup v2 layout.
+func SetupBackground(name string, cpuWeight int64, ioWeight int64) (string, error) {
+	if cgLayout != CgroupsUnified {
+		return "", fmt.Errorf("Placing commands in a cgroup requires a pure cgroup v2 layout")
+	}
+
+	path := filepath.Join(cgPath, filepath.Clean("/"+name))
+	if path == cgPath {
+		return "", fmt.Errorf("The background cgroup can't be the root cgroup")
+	}
+
+	err := os.MkdirAll(path, 0755)
+	if err != nil {
+		return "", fmt.Errorf("Failed creating cgroup %q: %w", path, err)
+	}
+
+	weights := map[string]string{}
+	if cpuWeight > 0 {
+		weights["cpu.weight"] = fmt.Sprintf("%d", cpuWeight)
+	}
+
+	if ioWeight > 0 {
+		weights["io.weight"] = fmt.Sprintf("default %d", ioWeight)
+	}
+
+	for key, value := range weights {
+		err = os.WriteFile(filepath.Join(path, key), []byte(value), 0)
+		if err != nil {
+			return "", fmt.Errorf("Failed setting %q of cgroup %q, the controller may not be enabled in its parent: %w", key, path, err)
+		}
+	}
+
+	return path, nil
+}
@@ -100,6 +100,12 @@ func (c *Config) HTTPSTrustedProxy() string {
 	return c.m.GetString("core.https_trusted_proxy")
 }
 
+// HTTPSProxyProtocol returns whether connections to the HTTPS listener must come from a trusted proxy and start
+// with a PROXY protocol header.
+func (c *Config) HTTPSProxyProtocol() bool {
+	return c.m.GetBool("core.https_proxy_protocol")
+}
+
 // OfflineThreshold returns the configured heartbeat threshold, i.e. the
 // number of seconds before after which an unresponsive node is considered
 // offline..
@@ -443,6 +449,19 @@ var ConfigSchema = config.Schema{
 	//  shortdesc: Trusted servers to provide the client's address
 	"core.https_trusted_proxy": {},
 
+	// gendoc:generate(entity=server, group=core, key=core.https_proxy_protocol)
+	// If enabled, only the servers listed in `core.https_trusted_proxy` can connect to `core.https_address`, and every
+	// connection must start with a PROXY protocol header (version 1 or 2), as sent by a load balancer such as HAProxy in TCP mode.
+	// The client address it carries is used instead of the one of the load balancer.
+	// Connections from other addresses or without a valid header are closed.
+	// Cluster members don't send the header, so it can't be enabled on a cluster member whose `cluster.https_address` is served by `core.https_address`.
+	// ---
+	//  type: bool
+	//  scope: global
+	//  defaultdesc: `false`
+	//  shortdesc: Whether to require the PROXY protocol on the HTTPS listener
+	"core.https_proxy_protocol": {Type: config.Bool, Default: "false"},
+
 	// gendoc:generate(entity=server, group=core, key=core.proxy_http)
 	// If this option is not specified, the daemon falls back to the `HTTP_PROXY` environment variable (if set).
 	// ---
@@ -10,12 +10,14 @@ import (
 	"time"
 
 	"github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/internal/ports"
 	clusterRequest "github.com/lxc/incus/v6/internal/server/cluster/request"
 	"github.com/lxc/incus/v6/internal/server/db"
 	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
 	"github.com/lxc/incus/v6/internal/server/request"
 	"github.com/lxc/incus/v6/internal/server/state"
 	storagePools "github.com/lxc/incus/v6/internal/server/storage"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/internal/version"
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/proxy"
@@ -73,7 +75,7 @@ func Connect(address string, networkCert *localtls.CertInfo, serverCert *localtl
 		args.Proxy = proxy
 	}
 
-	url := fmt.Sprintf("https://%s", address)
+	url := fmt.Sprintf("https://%s", internalUtil.NetworkAddressURLHost(address, ports.HTTPSDefaultPort))
 	return incus.ConnectIncus(url, args)
 }
 
@@ -201,7 +203,7 @@ func SetupTrust(serverCert *localtls.CertInfo, serverName string, targetAddress
 		UserAgent:     version.UserAgent,
 	}
 
-	target, err := incus.ConnectIncus(fmt.Sprintf("https://%s", targetAddress), args)
+	target, err := incus.ConnectIncus(fmt.Sprintf("https://%s", internalUtil.NetworkAddressURLHost(targetAddress, ports.HTTPSDefaultPort)), args)
 	if err != nil {
 		return fmt.Errorf("Failed to connect to target cluster node %q: %w", targetAddress, err)
 	}
@@ -238,7 +240,7 @@ func UpdateTrust(serverCert *localtls.CertInfo, serverName string, targetAddress
 		UserAgent:     version.UserAgent,
 	}
 
-	target, err := incus.ConnectIncus(fmt.Sprintf("https://%s", targetAddress), args)
+	target, err := incus.ConnectIncus(fmt.Sprintf("https://%s", internalUtil.NetworkAddressURLHost(targetAddress, ports.HTTPSDefaultPort)), args)
 	if err != nil {
 		return fmt.Errorf("Failed to connect to target cluster node %q: %w", targetAddress, err)
 	}
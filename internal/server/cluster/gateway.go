@@ -20,12 +20,14 @@ import (
 	dqlite "github.com/cowsql/go-cowsql"
 	client "github.com/cowsql/go-cowsql/client"
 
+	"github.com/lxc/incus/v6/internal/ports"
 	"github.com/lxc/incus/v6/internal/revert"
 	"github.com/lxc/incus/v6/internal/server/certificate"
 	"github.com/lxc/incus/v6/internal/server/db"
 	"github.com/lxc/incus/v6/internal/server/response"
 	"github.com/lxc/incus/v6/internal/server/state"
 	localUtil "github.com/lxc/incus/v6/internal/server/util"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/shared/logger"
 	"github.com/lxc/incus/v6/shared/tcp"
 	localtls "github.com/lxc/incus/v6/shared/tls"
@@ -697,7 +699,7 @@ func (g *Gateway) LeaderAddress() (string, error) {
 			Timeout:   timeout,
 		}
 
-		url := fmt.Sprintf("https://%s%s", address, databaseEndpoint)
+		url := fmt.Sprintf("https://%s%s", internalUtil.NetworkAddressURLHost(address, ports.HTTPSDefaultPort), databaseEndpoint)
 		request, err := http.NewRequest("GET", url, nil)
 		if err != nil {
 			return "", err
@@ -1015,7 +1017,7 @@ func dqliteNetworkDial(ctx context.Context, name string, addr string, g *Gateway
 		return nil, err
 	}
 
-	path := fmt.Sprintf("https://%s%s", addr, databaseEndpoint)
+	path := fmt.Sprintf("https://%s%s", internalUtil.NetworkAddressURLHost(addr, ports.HTTPSDefaultPort), databaseEndpoint)
 
 	// Establish the connection
 	request := &http.Request{
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/lxc/incus/v6/internal/ports"
 	"github.com/lxc/incus/v6/internal/server/db"
 	"github.com/lxc/incus/v6/internal/server/db/cluster"
 	"github.com/lxc/incus/v6/internal/server/db/query"
@@ -18,6 +19,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/response"
 	"github.com/lxc/incus/v6/internal/server/task"
 	"github.com/lxc/incus/v6/internal/server/warnings"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/logger"
 	localtls "github.com/lxc/incus/v6/shared/tls"
@@ -520,7 +522,7 @@ func HeartbeatNode(taskCtx context.Context, address string, networkCert *localtl
 	}
 
 	timeout := 2 * time.Second
-	url := fmt.Sprintf("https://%s%s", address, databaseEndpoint)
+	url := fmt.Sprintf("https://%s%s", internalUtil.NetworkAddressURLHost(address, ports.HTTPSDefaultPort), databaseEndpoint)
 	transport, cleanup := tlsTransport(config)
 	defer cleanup()
 	client := &http.Client{
@@ -102,6 +102,11 @@ func MemberState(ctx context.Context, s *state.State, memberName string) (*api.C
 			return nil, fmt.Errorf("Failed getting storage pool resources %q: %w", pools[poolID].Name, err)
 		}
 
+		// The pools stuck answering, like the ones of a wedged storage cluster, only report part of their usage.
+		if res.Degraded != "" {
+			logger.Warn("Storage pool usage is incomplete", logger.Ctx{"pool": pools[poolID].Name, "reason": res.Degraded})
+		}
+
 		memberState.StoragePools[pools[poolID].Name] = api.StoragePoolState{
 			ResourcesStoragePool: *res,
 		}
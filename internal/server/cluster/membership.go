@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -1185,6 +1186,12 @@ func membershipCheckClusterStateForAccept(ctx context.Context, tx *db.ClusterTx,
 			return fmt.Errorf("The cluster already has a member with address: %s", address)
 		}
 
+		// Different addresses may still resolve to the same IP, other than the placeholder of a standalone server.
+		conflict, addresses := internalUtil.AddressesConflict(member.Address, address)
+		if conflict && member.Address != "0.0.0.0" {
+			return fmt.Errorf("The address %s conflicts with the one of member %q (%s) on %s", address, member.Name, member.Address, strings.Join(addresses, ", "))
+		}
+
 		if member.Schema != schema {
 			return fmt.Errorf("The joining server version doesn't match (expected %s with DB schema %v)", version.Version, schema)
 		}
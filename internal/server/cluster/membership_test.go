@@ -195,6 +195,16 @@ func TestAccept_UnmetPreconditions(t *testing.T) {
 			},
 			"The cluster already has a member with address: 5.6.7.8:666",
 		},
+		{
+			"buzz",
+			"[::ffff:5.6.7.8]:666",
+			cluster.SchemaVersion,
+			len(version.APIExtensions),
+			func(f *membershipFixtures) {
+				f.ClusterNode("5.6.7.8:666")
+			},
+			`The address [::ffff:5.6.7.8]:666 conflicts with the one of member "rusp" (5.6.7.8:666) on 5.6.7.8:666`,
+		},
 		{
 			"buzz",
 			"1.2.3.4:666",
@@ -12,10 +12,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lxc/incus/v6/internal/ports"
 	"github.com/lxc/incus/v6/internal/server/db/cluster"
 	"github.com/lxc/incus/v6/internal/server/db/operationtype"
 	"github.com/lxc/incus/v6/internal/server/db/query"
 	localUtil "github.com/lxc/incus/v6/internal/server/util"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/internal/version"
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/osarch"
@@ -110,7 +112,7 @@ func (n NodeInfo) ToAPI(ctx context.Context, tx *ClusterTx, args NodeInfoArgs) (
 	result := api.ClusterMember{}
 	result.Description = n.Description
 	result.ServerName = n.Name
-	result.URL = fmt.Sprintf("https://%s", n.Address)
+	result.URL = fmt.Sprintf("https://%s", internalUtil.NetworkAddressURLHost(n.Address, ports.HTTPSDefaultPort))
 	result.Database = false
 	result.Config = n.Config
 
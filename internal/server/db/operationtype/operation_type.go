@@ -79,6 +79,8 @@ const (
 	BucketBackupRemove
 	BucketBackupRename
 	BucketBackupRestore
+	InstanceDebugMemory
+	DebugCPUProfile
 )
 
 // Description return a human-readable description of the operation type.
@@ -210,6 +212,10 @@ func (t Type) Description() string {
 		return "Renaming bucket backup"
 	case BucketBackupRestore:
 		return "Restoring bucket backup"
+	case InstanceDebugMemory:
+		return "Dumping instance memory"
+	case DebugCPUProfile:
+		return "Profiling daemon CPU usage"
 	default:
 		return "Executing operation"
 	}
@@ -267,6 +273,10 @@ func (t Type) Permission() (auth.ObjectType, auth.Entitlement) {
 		return auth.ObjectTypeInstance, auth.EntitlementCanEdit
 	case SnapshotRestore:
 		return auth.ObjectTypeInstance, auth.EntitlementCanEdit
+	case InstanceDebugMemory:
+		return auth.ObjectTypeInstance, auth.EntitlementCanAccessDebug
+	case DebugCPUProfile:
+		return auth.ObjectTypeServer, auth.EntitlementCanEdit
 
 	case ImageDownload:
 		return auth.ObjectTypeImage, auth.EntitlementCanEdit
@@ -26,6 +26,13 @@ func newByType(state *state.State, projectName string, conf deviceConfig.Device)
 	var dev device
 	switch conf["type"] {
 	case "nic":
+		// gendoc:generate(entity=devices, group=nic, key=nictype)
+		//
+		// ---
+		//  type: string
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The device type, one of `bridged`, `macvlan`, `ipvlan`, `p2p`, `routed`, `physical`, `sriov` or `ovn` (instead of specifying `network`)
 		switch nicType {
 		case "physical":
 			dev = &nicPhysical{}
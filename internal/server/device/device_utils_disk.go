@@ -209,27 +209,21 @@ again:
 		"unmap",
 		unmapImageName)
 	if err != nil {
-		runError, ok := err.(subprocess.RunError)
-		if ok {
-			exitError, ok := runError.Unwrap().(*exec.ExitError)
-			if ok {
-				if exitError.ExitCode() == 22 {
-					// EINVAL (already unmapped)
-					return nil
-				}
-
-				if exitError.ExitCode() == 16 {
-					// EBUSY (currently in use)
-					busyCount++
-					if busyCount == 10 {
-						return err
-					}
-
-					// Wait a second an try again
-					time.Sleep(time.Second)
-					goto again
-				}
+		if subprocess.ExitedWith(err, 22) {
+			// EINVAL (already unmapped)
+			return nil
+		}
+
+		if subprocess.ExitedWith(err, 16) {
+			// EBUSY (currently in use)
+			busyCount++
+			if busyCount == 10 {
+				return err
 			}
+
+			// Wait a second an try again
+			time.Sleep(time.Second)
+			goto again
 		}
 
 		return err
@@ -191,6 +191,7 @@ func (d *disk) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: bool
 		//  default: `false`
+		//  liveupdate: yes
 		//  required: no
 		//  shortdesc: Controls whether to make the mount read-only
 		"readonly": validate.Optional(validate.IsBool),
@@ -217,6 +218,7 @@ func (d *disk) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: string
+		//  liveupdate: yes
 		//  required: yes
 		//  shortdesc: Source of a file system or block device (see {ref}`devices-disk-types` for details)
 		"source": validate.IsAny,
@@ -315,6 +317,7 @@ func (d *disk) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: string
+		//  liveupdate: yes
 		//  required: yes
 		//  shortdesc: Path inside the instance where the disk will be mounted (only for containers)
 		"path": validate.IsAny,
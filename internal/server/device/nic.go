@@ -14,43 +14,342 @@ import (
 func nicValidationRules(requiredFields []string, optionalFields []string, instConf instance.ConfigReader) map[string]func(value string) error {
 	// Define a set of default validators for each field name.
 	defaultValidators := map[string]func(value string) error{
-		"acceleration":                         validate.Optional(validate.IsOneOf("none", "sriov", "vdpa")),
-		"name":                                 validate.Optional(validate.IsInterfaceName, func(_ string) error { return nicCheckNamesUnique(instConf) }),
-		"parent":                               validate.IsAny,
-		"network":                              validate.IsAny,
-		"mtu":                                  validate.Optional(validate.IsNetworkMTU),
-		"vlan":                                 validate.IsNetworkVLAN,
-		"gvrp":                                 validate.Optional(validate.IsBool),
-		"hwaddr":                               validate.IsNetworkMAC,
-		"host_name":                            validate.IsAny,
-		"limits.ingress":                       validate.IsAny,
-		"limits.egress":                        validate.IsAny,
-		"limits.max":                           validate.IsAny,
-		"limits.priority":                      validate.Optional(validate.IsUint32),
-		"security.mac_filtering":               validate.IsAny,
-		"security.ipv4_filtering":              validate.IsAny,
-		"security.ipv6_filtering":              validate.IsAny,
-		"security.port_isolation":              validate.Optional(validate.IsBool),
-		"ipv4.address":                         validate.Optional(validate.IsNetworkAddressV4),
-		"ipv6.address":                         validate.Optional(validate.IsNetworkAddressV6),
-		"ipv4.routes":                          validate.Optional(validate.IsListOf(validate.IsNetworkV4)),
-		"ipv6.routes":                          validate.Optional(validate.IsListOf(validate.IsNetworkV6)),
-		"boot.priority":                        validate.Optional(validate.IsUint32),
-		"ipv4.gateway":                         networkValidGateway,
-		"ipv6.gateway":                         networkValidGateway,
-		"ipv4.host_address":                    validate.Optional(validate.IsNetworkAddressV4),
-		"ipv6.host_address":                    validate.Optional(validate.IsNetworkAddressV6),
-		"ipv4.host_table":                      validate.Optional(validate.IsUint32),
-		"ipv6.host_table":                      validate.Optional(validate.IsUint32),
-		"queue.tx.length":                      validate.Optional(validate.IsUint32),
-		"ipv4.routes.external":                 validate.Optional(validate.IsListOf(validate.IsNetworkV4)),
-		"ipv6.routes.external":                 validate.Optional(validate.IsListOf(validate.IsNetworkV6)),
-		"nested":                               validate.IsAny,
-		"security.acls":                        validate.IsAny,
+		// gendoc:generate(entity=devices, group=nic, key=acceleration)
+		//
+		// ---
+		//  type: string
+		//  default: `none`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Enable hardware offloading (either `none`, `sriov` or `vdpa`, see {ref}`devices-nic-hw-acceleration`)
+		"acceleration": validate.Optional(validate.IsOneOf("none", "sriov", "vdpa")),
+
+		// gendoc:generate(entity=devices, group=nic, key=name)
+		//
+		// ---
+		//  type: string
+		//  default: kernel assigned
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The name of the interface inside the instance
+		"name": validate.Optional(validate.IsInterfaceName, func(_ string) error { return nicCheckNamesUnique(instConf) }),
+
+		// gendoc:generate(entity=devices, group=nic, key=parent)
+		//
+		// ---
+		//  type: string
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The name of the host device
+		"parent": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=network)
+		//
+		// ---
+		//  type: string
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The managed network to link the device to (instead of specifying the `nictype` directly)
+		"network": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=mtu)
+		//
+		// ---
+		//  type: integer
+		//  default: parent MTU
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The MTU of the new interface
+		"mtu": validate.Optional(validate.IsNetworkMTU),
+
+		// gendoc:generate(entity=devices, group=nic, key=vlan)
+		//
+		// ---
+		//  type: integer
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The VLAN ID to use for non-tagged traffic or to attach to (can be `none` to remove port from default VLAN)
+		"vlan": validate.IsNetworkVLAN,
+
+		// gendoc:generate(entity=devices, group=nic, key=gvrp)
+		//
+		// ---
+		//  type: bool
+		//  default: `false`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Register VLAN using GARP VLAN Registration Protocol
+		"gvrp": validate.Optional(validate.IsBool),
+
+		// gendoc:generate(entity=devices, group=nic, key=hwaddr)
+		//
+		// ---
+		//  type: string
+		//  default: randomly assigned
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The MAC address of the new interface
+		"hwaddr": validate.IsNetworkMAC,
+
+		// gendoc:generate(entity=devices, group=nic, key=host_name)
+		//
+		// ---
+		//  type: string
+		//  default: randomly assigned
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The name of the interface inside the host
+		"host_name": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=limits.ingress)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: I/O limit in bit/s for incoming traffic (various suffixes supported, see {ref}`instances-limit-units`)
+		"limits.ingress": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=limits.egress)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: I/O limit in bit/s for outgoing traffic (various suffixes supported, see {ref}`instances-limit-units`)
+		"limits.egress": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=limits.max)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: I/O limit in bit/s for both incoming and outgoing traffic (same as setting both `limits.ingress` and `limits.egress`)
+		"limits.max": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=limits.priority)
+		//
+		// ---
+		//  type: integer
+		//  required: no
+		//  shortdesc: The `skb->priority` value (32-bit unsigned integer) for outgoing traffic, to be used by the kernel queuing discipline (qdisc) to prioritize network packets
+		"limits.priority": validate.Optional(validate.IsUint32),
+
+		// gendoc:generate(entity=devices, group=nic, key=security.mac_filtering)
+		//
+		// ---
+		//  type: bool
+		//  default: `false`
+		//  required: no
+		//  shortdesc: Prevent the instance from spoofing another instance's MAC address
+		"security.mac_filtering": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=security.ipv4_filtering)
+		//
+		// ---
+		//  type: bool
+		//  default: `false`
+		//  required: no
+		//  shortdesc: Prevent the instance from spoofing another instance's IPv4 address (enables `security.mac_filtering`)
+		"security.ipv4_filtering": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=security.ipv6_filtering)
+		//
+		// ---
+		//  type: bool
+		//  default: `false`
+		//  required: no
+		//  shortdesc: Prevent the instance from spoofing another instance's IPv6 address (enables `security.mac_filtering`)
+		"security.ipv6_filtering": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=security.port_isolation)
+		//
+		// ---
+		//  type: bool
+		//  default: `false`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Prevent the NIC from communicating with other NICs in the network that have port isolation enabled
+		"security.port_isolation": validate.Optional(validate.IsBool),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv4.address)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: IPv4 address (or comma-delimited list of static addresses, depending on the NIC type) to assign to the instance
+		"ipv4.address": validate.Optional(validate.IsNetworkAddressV4),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv6.address)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: IPv6 address (or comma-delimited list of static addresses, depending on the NIC type) to assign to the instance
+		"ipv6.address": validate.Optional(validate.IsNetworkAddressV6),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv4.routes)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: Comma-delimited list of IPv4 static routes to add on host to NIC
+		"ipv4.routes": validate.Optional(validate.IsListOf(validate.IsNetworkV4)),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv6.routes)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: Comma-delimited list of IPv6 static routes to add on host to NIC
+		"ipv6.routes": validate.Optional(validate.IsListOf(validate.IsNetworkV6)),
+
+		// gendoc:generate(entity=devices, group=nic, key=boot.priority)
+		//
+		// ---
+		//  type: integer
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Boot priority for VMs (higher value boots first)
+		"boot.priority": validate.Optional(validate.IsUint32),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv4.gateway)
+		//
+		// ---
+		//  type: string
+		//  default: `auto`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Whether to add an automatic default IPv4 gateway (can be `auto` or `none`) or the IPv4 address of the gateway
+		"ipv4.gateway": networkValidGateway,
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv6.gateway)
+		//
+		// ---
+		//  type: string
+		//  default: `auto`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Whether to add an automatic default IPv6 gateway (can be `auto` or `none`) or the IPv6 address of the gateway
+		"ipv6.gateway": networkValidGateway,
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv4.host_address)
+		//
+		// ---
+		//  type: string
+		//  default: `169.254.0.1`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The IPv4 address to add to the host-side `veth` interface
+		"ipv4.host_address": validate.Optional(validate.IsNetworkAddressV4),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv6.host_address)
+		//
+		// ---
+		//  type: string
+		//  default: `fe80::1`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The IPv6 address to add to the host-side `veth` interface
+		"ipv6.host_address": validate.Optional(validate.IsNetworkAddressV6),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv4.host_table)
+		//
+		// ---
+		//  type: integer
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The custom policy routing table ID to add IPv4 static routes to (in addition to the main routing table)
+		"ipv4.host_table": validate.Optional(validate.IsUint32),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv6.host_table)
+		//
+		// ---
+		//  type: integer
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The custom policy routing table ID to add IPv6 static routes to (in addition to the main routing table)
+		"ipv6.host_table": validate.Optional(validate.IsUint32),
+
+		// gendoc:generate(entity=devices, group=nic, key=queue.tx.length)
+		//
+		// ---
+		//  type: integer
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The transmit queue length for the NIC
+		"queue.tx.length": validate.Optional(validate.IsUint32),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv4.routes.external)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: Comma-delimited list of IPv4 static routes to route to the NIC and publish on uplink network
+		"ipv4.routes.external": validate.Optional(validate.IsListOf(validate.IsNetworkV4)),
+
+		// gendoc:generate(entity=devices, group=nic, key=ipv6.routes.external)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: Comma-delimited list of IPv6 static routes to route to the NIC and publish on uplink network
+		"ipv6.routes.external": validate.Optional(validate.IsListOf(validate.IsNetworkV6)),
+
+		// gendoc:generate(entity=devices, group=nic, key=nested)
+		//
+		// ---
+		//  type: string
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: The parent NIC name to nest this NIC under (see also `vlan`)
+		"nested": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=security.acls)
+		//
+		// ---
+		//  type: string
+		//  required: no
+		//  shortdesc: Comma-separated list of network ACLs to apply
+		"security.acls": validate.IsAny,
+
+		// gendoc:generate(entity=devices, group=nic, key=security.acls.default.ingress.action)
+		//
+		// ---
+		//  type: string
+		//  default: `reject`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Action to use for ingress traffic that doesn't match any ACL rule
 		"security.acls.default.ingress.action": validate.Optional(validate.IsOneOf(acl.ValidActions...)),
-		"security.acls.default.egress.action":  validate.Optional(validate.IsOneOf(acl.ValidActions...)),
+
+		// gendoc:generate(entity=devices, group=nic, key=security.acls.default.egress.action)
+		//
+		// ---
+		//  type: string
+		//  default: `reject`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Action to use for egress traffic that doesn't match any ACL rule
+		"security.acls.default.egress.action": validate.Optional(validate.IsOneOf(acl.ValidActions...)),
+
+		// gendoc:generate(entity=devices, group=nic, key=security.acls.default.ingress.logged)
+		//
+		// ---
+		//  type: bool
+		//  default: `false`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Whether to log ingress traffic that doesn't match any ACL rule
 		"security.acls.default.ingress.logged": validate.Optional(validate.IsBool),
-		"security.acls.default.egress.logged":  validate.Optional(validate.IsBool),
+
+		// gendoc:generate(entity=devices, group=nic, key=security.acls.default.egress.logged)
+		//
+		// ---
+		//  type: bool
+		//  default: `false`
+		//  liveupdate: yes
+		//  required: no
+		//  shortdesc: Whether to log egress traffic that doesn't match any ACL rule
+		"security.acls.default.egress.logged": validate.Optional(validate.IsBool),
 	}
 
 	validators := map[string]func(value string) error{}
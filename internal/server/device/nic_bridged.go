@@ -297,6 +297,13 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 	}
 
 	// Add bridge specific vlan.tagged validation.
+	// gendoc:generate(entity=devices, group=nic, key=vlan.tagged)
+	//
+	// ---
+	//  type: integer
+	//  liveupdate: yes
+	//  required: no
+	//  shortdesc: Comma-delimited list of VLAN IDs or VLAN ranges to join for tagged traffic
 	rules["vlan.tagged"] = func(value string) error {
 		if value == "" {
 			return nil
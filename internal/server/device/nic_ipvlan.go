@@ -110,6 +110,14 @@ func (d *nicIPVLAN) validateConfig(instConf instance.ConfigReader) error {
 		return validate.IsListOf(validate.IsNetworkAddressV6)(value)
 	}
 
+	// gendoc:generate(entity=devices, group=nic, key=mode)
+	//
+	// ---
+	//  type: string
+	//  default: `l3s`
+	//  liveupdate: yes
+	//  required: no
+	//  shortdesc: The IPVLAN mode (either `l2` or `l3s`)
 	rules["mode"] = func(value string) error {
 		if value == "" {
 			return nil
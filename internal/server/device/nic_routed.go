@@ -85,7 +85,25 @@ func (d *nicRouted) validateConfig(instConf instance.ConfigReader) error {
 	rules["ipv4.address"] = validate.Optional(validate.IsListOf(validate.IsNetworkAddressV4))
 	rules["ipv6.address"] = validate.Optional(validate.IsListOf(validate.IsNetworkAddressV6))
 	rules["gvrp"] = validate.Optional(validate.IsBool)
+
+	// gendoc:generate(entity=devices, group=nic, key=ipv4.neighbor_probe)
+	//
+	// ---
+	//  type: bool
+	//  default: `true`
+	//  liveupdate: yes
+	//  required: no
+	//  shortdesc: Whether to probe the parent network for IP address availability
 	rules["ipv4.neighbor_probe"] = validate.Optional(validate.IsBool)
+
+	// gendoc:generate(entity=devices, group=nic, key=ipv6.neighbor_probe)
+	//
+	// ---
+	//  type: bool
+	//  default: `true`
+	//  liveupdate: yes
+	//  required: no
+	//  shortdesc: Whether to probe the parent network for IP address availability
 	rules["ipv6.neighbor_probe"] = validate.Optional(validate.IsBool)
 
 	err = d.config.Validate(rules)
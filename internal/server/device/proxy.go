@@ -92,6 +92,7 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 		"security.uid":   validate.Optional(unixValidUserID),
 		"security.gid":   validate.Optional(unixValidUserID),
 		"proxy_protocol": validate.Optional(validate.IsBool),
+		"vip":            validate.Optional(validate.IsBool),
 	}
 
 	err := d.config.Validate(rules)
@@ -176,6 +177,17 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 		if listenIPVersion != connectIPVersion {
 			return fmt.Errorf("Cannot mix IP versions between listen and connect in nat mode")
 		}
+
+		// Check the listen address is present on the server when the config is changed, rather than when the
+		// instance starts, as the interface holding it may not be configured yet. Profiles aren't tied to a server.
+		if d.inst == nil && instConf.Type() != instancetype.Any {
+			err = network.ValidateListenAddressPresent(d.state, listenAddress, util.IsTrue(d.config["vip"]))
+			if err != nil {
+				return err
+			}
+		}
+	} else if d.config["vip"] != "" {
+		return fmt.Errorf("Only NAT proxies can use vip")
 	}
 
 	return nil
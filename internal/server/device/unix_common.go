@@ -49,6 +49,7 @@ func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: string
+		//  liveupdate: yes
 		//  shortdesc: Path on the host (one of `source` and `path` must be set)
 		"source": func(value string) error {
 			if value == "" {
@@ -67,6 +68,7 @@ func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  default: 0
+		//  liveupdate: yes
 		//  shortdesc: GID of the device owner in the instance
 		"gid": unixValidUserID,
 
@@ -75,6 +77,7 @@ func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  default: device on host
+		//  liveupdate: yes
 		//  shortdesc: Device major number
 		"major": unixValidDeviceNum,
 
@@ -83,6 +86,7 @@ func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  default: device on host
+		//  liveupdate: yes
 		//  shortdesc: Device minor number
 		"minor": unixValidDeviceNum,
 
@@ -91,6 +95,7 @@ func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  default: 0660
+		//  liveupdate: yes
 		//  shortdesc: Mode of the device in the instance
 		"mode": unixValidOctalFileMode,
 
@@ -98,6 +103,7 @@ func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: string
+		//  liveupdate: yes
 		//  shortdesc: Path inside the instance (one of `source` and `path` must be set)
 		"path": validate.IsAny,
 
@@ -106,6 +112,7 @@ func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: bool
 		//  default: true
+		//  liveupdate: yes
 		//  shortdesc: Whether this device is required to start the instance
 		"required": validate.Optional(validate.IsBool),
 
@@ -114,6 +121,7 @@ func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  default: 0
+		//  liveupdate: yes
 		//  shortdesc: UID of the device owner in the instance
 		"uid": unixValidUserID,
 	}
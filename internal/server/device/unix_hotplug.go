@@ -49,6 +49,7 @@ func (d *unixHotplug) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: string
+		//  liveupdate: yes
 		//  shortdesc: The vendor ID of the USB device
 		"vendorid": validate.Optional(validate.IsDeviceID),
 
@@ -56,6 +57,7 @@ func (d *unixHotplug) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: string
+		//  liveupdate: yes
 		//  shortdesc: The product ID of the USB device
 		"productid": validate.Optional(validate.IsDeviceID),
 
@@ -64,6 +66,7 @@ func (d *unixHotplug) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  default: 0
+		//  liveupdate: yes
 		//  shortdesc: UID of the device owner in the instance
 		"uid": unixValidUserID,
 
@@ -72,6 +75,7 @@ func (d *unixHotplug) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  default: 0
+		//  liveupdate: yes
 		//  shortdesc: GID of the device owner in the instance
 		"gid": unixValidUserID,
 
@@ -80,6 +84,7 @@ func (d *unixHotplug) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  default: 0660
+		//  liveupdate: yes
 		//  shortdesc: Mode of the device in the instance
 		"mode": unixValidOctalFileMode,
 
@@ -88,6 +93,7 @@ func (d *unixHotplug) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: bool
 		//  default: true
+		//  liveupdate: yes
 		//  shortdesc: Whether this device is required to start the instance
 		"required": validate.Optional(validate.IsBool),
 	}
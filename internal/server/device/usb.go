@@ -58,6 +58,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: string
+		//  liveupdate: yes
 		//  shortdesc: The vendor ID of the USB device
 		"vendorid": validate.Optional(validate.IsDeviceID),
 
@@ -65,6 +66,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: string
+		//  liveupdate: yes
 		//  shortdesc: The product ID of the USB device
 		"productid": validate.Optional(validate.IsDeviceID),
 
@@ -72,6 +74,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: string
+		//  liveupdate: yes
 		//  shortdesc: The serial number of the USB device
 		"serial": validate.Optional(validate.IsAny),
 
@@ -80,6 +83,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  defaultdesc: `0`
+		//  liveupdate: yes
 		//  shortdesc: Only for containers: UID of the device owner in the instance
 		"uid": unixValidUserID,
 
@@ -88,6 +92,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  defaultdesc: `0`
+		//  liveupdate: yes
 		//  shortdesc: Only for containers: GID of the device owner in the instance
 		"gid": unixValidUserID,
 
@@ -96,6 +101,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: int
 		//  defaultdesc: `0660`
+		//  liveupdate: yes
 		//  shortdesc: Only for containers: Mode of the device in the instance
 		"mode": unixValidOctalFileMode,
 
@@ -104,6 +110,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 		// ---
 		//  type: bool
 		//  defaultdesc: `false`
+		//  liveupdate: yes
 		//  shortdesc: Whether this device is required to start the instance (the default is `false`, and all devices can be hotplugged)
 		"required": validate.Optional(validate.IsBool),
 
@@ -111,6 +118,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: int
+		//  liveupdate: yes
 		//  shortdesc: The bus number of which the USB device is attached
 		"busnum": validate.Optional(validate.IsUint32),
 
@@ -118,6 +126,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 		//
 		// ---
 		//  type: int
+		//  liveupdate: yes
 		//  shortdesc: The device number of the USB device
 		"devnum": validate.Optional(validate.IsUint32),
 	}
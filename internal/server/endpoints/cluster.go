@@ -63,7 +63,7 @@ func (e *Endpoints) ClusterUpdateAddress(address string) error {
 		var listener net.Listener
 
 		for i := 0; i < 10; i++ { // Ten retries over a second seems reasonable.
-			listener, err = net.Listen("tcp", address)
+			listener, err = networkListen(address)
 			if err == nil {
 				break
 			}
@@ -4,21 +4,27 @@ import (
 	"crypto/tls"
 	"net"
 	"sync"
+	"time"
 
-	"github.com/armon/go-proxyproto"
+	"github.com/pires/go-proxyproto"
 
 	"github.com/lxc/incus/v6/internal/server/util"
+	"github.com/lxc/incus/v6/shared/logger"
 	localtls "github.com/lxc/incus/v6/shared/tls"
 )
 
+// proxyHeaderTimeout is how long trusted proxies have to send the PROXY protocol header.
+var proxyHeaderTimeout = 5 * time.Second
+
 // FancyTLSListener is a variation of the standard tls.Listener that supports
 // atomically swapping the underlying TLS configuration and proxy protocol wrapping.
 // Requests served before the swap will continue using the old configuration.
 type FancyTLSListener struct {
 	net.Listener
-	mu           sync.RWMutex
-	config       *tls.Config
-	trustedProxy []net.IP
+	mu            sync.RWMutex
+	config        *tls.Config
+	trustedProxy  []net.IP
+	proxyProtocol bool
 }
 
 // NewFancyTLSListener creates a new FancyTLSListener.
@@ -34,19 +40,35 @@ func NewFancyTLSListener(inner net.Listener, cert *localtls.CertInfo) *FancyTLSL
 // Accept waits for and returns the next incoming TLS connection then use the
 // current TLS configuration to handle it.
 func (l *FancyTLSListener) Accept() (net.Conn, error) {
-	c, err := l.Listener.Accept()
-	if err != nil {
-		return nil, err
-	}
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
 
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	config := l.config
-	if isProxy(c.RemoteAddr().String(), l.trustedProxy) {
-		c = proxyproto.NewConn(c, 0)
-	}
+		l.mu.RLock()
+		config := l.config
+		trusted := isProxy(c.RemoteAddr().String(), l.trustedProxy)
+		proxyProtocol := l.proxyProtocol
+		l.mu.RUnlock()
+
+		if trusted {
+			// The header is read along with the first data, so that slow clients don't hold up the listener.
+			policy := proxyproto.USE
+			if proxyProtocol {
+				policy = proxyproto.REQUIRE
+			}
+
+			c = proxyproto.NewConn(c, proxyproto.WithPolicy(policy), proxyproto.SetReadHeaderTimeout(proxyHeaderTimeout))
+		} else if proxyProtocol {
+			// Only the trusted proxies can connect when the PROXY protocol is required.
+			logger.Debug("Rejecting connection from untrusted proxy", logger.Ctx{"remote": c.RemoteAddr().String()})
+			_ = c.Close()
+			continue
+		}
 
-	return tls.Server(c, config), nil
+		return tls.Server(c, config), nil
+	}
 }
 
 // Config safely swaps the underlying TLS configuration.
@@ -67,6 +89,15 @@ func (l *FancyTLSListener) TrustedProxy(trustedProxy []net.IP) {
 	l.trustedProxy = trustedProxy
 }
 
+// ProxyProtocol sets whether all connections must come from a trusted proxy and start with a PROXY protocol
+// header (version 1 or 2), the client address it carries becoming the remote address of the connection.
+func (l *FancyTLSListener) ProxyProtocol(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.proxyProtocol = enabled
+}
+
 func isProxy(addr string, proxies []net.IP) bool {
 	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
@@ -0,0 +1,105 @@
+package listeners
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acceptRaw dials the listener, sends the data and returns the connection it accepted, unwrapped from TLS.
+func acceptRaw(t *testing.T, listener *FancyTLSListener, data string) (net.Conn, error) {
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	_, err = client.Write([]byte(data))
+	require.NoError(t, err)
+
+	// Close the listener after a while so that rejected connections don't block the test.
+	timer := time.AfterFunc(time.Second, func() { _ = listener.Close() })
+	defer timer.Stop()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn.(*tls.Conn).NetConn(), nil
+}
+
+func newTestListener(t *testing.T, trusted bool, proxyProtocol bool) *FancyTLSListener {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	listener := &FancyTLSListener{Listener: inner, config: &tls.Config{}}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	if trusted {
+		listener.TrustedProxy([]net.IP{net.ParseIP("127.0.0.1")})
+	}
+
+	listener.ProxyProtocol(proxyProtocol)
+
+	return listener
+}
+
+func TestFancyTLSListenerProxyProtocol(t *testing.T) {
+	header := "PROXY TCP4 192.0.2.1 192.0.2.2 56324 8443\r\n"
+
+	cases := []struct {
+		name          string
+		trusted       bool
+		proxyProtocol bool
+		data          string
+		remote        string
+		read          string
+	}{
+		{"Trusted proxy with header", true, false, header + "hello", "192.0.2.1:56324", "hello"},
+		{"Trusted proxy without header", true, false, "hello", "127.0.0.1", "hello"},
+		{"Untrusted peer with header", false, false, header + "hello", "127.0.0.1", "PROXY"},
+		{"Required from trusted proxy", true, true, header + "hello", "192.0.2.1:56324", "hello"},
+		{"Required without header", true, true, "hello", "127.0.0.1", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			listener := newTestListener(t, c.trusted, c.proxyProtocol)
+
+			conn, err := acceptRaw(t, listener, c.data)
+			require.NoError(t, err)
+
+			host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+			if c.remote == "127.0.0.1" {
+				assert.Equal(t, c.remote, host)
+			} else {
+				assert.Equal(t, c.remote, conn.RemoteAddr().String())
+			}
+
+			buf := make([]byte, 5)
+			_, err = io.ReadFull(conn, buf)
+			if c.read == "" {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, c.read, string(buf))
+		})
+	}
+}
+
+func TestFancyTLSListenerProxyProtocolUntrusted(t *testing.T) {
+	// Peers other than the trusted proxies can't connect when the PROXY protocol is required, so that they can't
+	// pretend to be any client.
+	listener := newTestListener(t, false, true)
+
+	_, err := acceptRaw(t, listener, "PROXY TCP4 192.0.2.1 192.0.2.2 56324 8443\r\nhello")
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
@@ -17,7 +17,11 @@ func metricsCreateListener(address string, cert *localtls.CertInfo) (net.Listene
 	// Listening on `tcp` network with address 0.0.0.0 will end up with listening
 	// on both IPv4 and IPv6 interfaces. Pass `tcp4` to make it
 	// work only on 0.0.0.0. https://go-review.googlesource.com/c/go/+/45771/
-	listenAddress := internalUtil.CanonicalNetworkAddress(address, ports.HTTPSMetricsDefaultPort)
+	listenAddress, err := internalUtil.ResolveListenAddress(address, ports.HTTPSMetricsDefaultPort)
+	if err != nil {
+		return nil, fmt.Errorf("Bind network address: %w", err)
+	}
+
 	protocol := "tcp"
 
 	if strings.HasPrefix(listenAddress, "0.0.0.0") {
@@ -49,7 +53,11 @@ func (e *Endpoints) MetricsAddress() string {
 // MetricsUpdateAddress updates the address for the metrics endpoint, shutting it down and restarting it.
 func (e *Endpoints) MetricsUpdateAddress(address string, cert *localtls.CertInfo) error {
 	if address != "" {
-		address = internalUtil.CanonicalNetworkAddress(address, ports.HTTPSMetricsDefaultPort)
+		var err error
+		address, err = internalUtil.ResolveListenAddress(address, ports.HTTPSMetricsDefaultPort)
+		if err != nil {
+			return err
+		}
 	}
 
 	oldAddress := e.MetricsAddress()
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"strings"
 	"time"
 
 	"github.com/lxc/incus/v6/internal/ports"
@@ -58,8 +57,14 @@ func (e *Endpoints) NetworkAddress() string {
 // NetworkUpdateAddress updates the address for the network endpoint, shutting
 // it down and restarting it.
 func (e *Endpoints) NetworkUpdateAddress(address string) error {
+	// Addresses referring to a network interface are resolved again, only moving the listener if the address of
+	// the interface changed.
 	if address != "" {
-		address = internalUtil.CanonicalNetworkAddress(address, ports.HTTPSDefaultPort)
+		var err error
+		address, err = internalUtil.ResolveListenAddress(address, ports.HTTPSDefaultPort)
+		if err != nil {
+			return err
+		}
 	}
 
 	oldAddress := e.NetworkAddress()
@@ -94,7 +99,7 @@ func (e *Endpoints) NetworkUpdateAddress(address string) error {
 		var listener net.Listener
 
 		for i := 0; i < 10; i++ { // Ten retries over a second seems reasonable.
-			listener, err = net.Listen("tcp", address)
+			listener, err = networkListen(address)
 			if err == nil {
 				break
 			}
@@ -178,22 +183,52 @@ func (e *Endpoints) NetworkUpdateTrustedProxy(trustedProxy string) {
 	}
 }
 
+// NetworkUpdateProxyProtocol sets whether connections to the network endpoint must start with a PROXY protocol
+// header. The cluster endpoint, when using a separate address, isn't affected.
+func (e *Endpoints) NetworkUpdateProxyProtocol(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	listener, ok := e.listeners[network]
+	if !ok || listener == nil {
+		return
+	}
+
+	fancyListener, ok := listener.(*listeners.FancyTLSListener)
+	if ok {
+		fancyListener.ProxyProtocol(enabled)
+	}
+}
+
 // Create a new net.Listener bound to the tcp socket of the network endpoint.
 func networkCreateListener(address string, cert *localtls.CertInfo) (net.Listener, error) {
-	// Listening on `tcp` network with address 0.0.0.0 will end up with listening
-	// on both IPv4 and IPv6 interfaces. Pass `tcp4` to make it
-	// work only on 0.0.0.0. https://go-review.googlesource.com/c/go/+/45771/
-	listenAddress := internalUtil.CanonicalNetworkAddress(address, ports.HTTPSDefaultPort)
-	protocol := "tcp"
-
-	if strings.HasPrefix(listenAddress, "0.0.0.0") {
-		protocol = "tcp4"
+	listenAddress, err := internalUtil.ResolveListenAddress(address, ports.HTTPSDefaultPort)
+	if err != nil {
+		return nil, fmt.Errorf("Bind network address: %w", err)
 	}
 
-	listener, err := net.Listen(protocol, listenAddress)
+	listener, err := networkListen(listenAddress)
 	if err != nil {
 		return nil, fmt.Errorf("Bind network address: %w", err)
 	}
 
 	return listeners.NewFancyTLSListener(listener, cert), nil
 }
+
+// networkListen listens on the given address, serving both IPv4 and IPv6 for wildcard addresses even where
+// IPv4-mapped addresses aren't available, and logs the families actually served.
+func networkListen(address string) (net.Listener, error) {
+	listener, families, err := internalUtil.ListenDualStack(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := internalUtil.ParseNetworkAddress(address, ports.HTTPSDefaultPort)
+	if err == nil && addr.IsWildcard && addr.Host != "0.0.0.0" && len(families) < 2 {
+		logger.Warn("Only serving some address families on wildcard address", logger.Ctx{"address": address, "families": families})
+	} else {
+		logger.Info("Listening on network address", logger.Ctx{"address": address, "families": families})
+	}
+
+	return listener, nil
+}
@@ -17,7 +17,11 @@ func storageBucketsCreateListener(address string, cert *localtls.CertInfo) (net.
 	// Listening on `tcp` network with address 0.0.0.0 will end up with listening
 	// on both IPv4 and IPv6 interfaces. Pass `tcp4` to make it
 	// work only on 0.0.0.0. https://go-review.googlesource.com/c/go/+/45771/
-	listenAddress := internalUtil.CanonicalNetworkAddress(address, ports.HTTPSStorageBucketsDefaultPort)
+	listenAddress, err := internalUtil.ResolveListenAddress(address, ports.HTTPSStorageBucketsDefaultPort)
+	if err != nil {
+		return nil, fmt.Errorf("Bind network address: %w", err)
+	}
+
 	protocol := "tcp"
 
 	if strings.HasPrefix(listenAddress, "0.0.0.0") {
@@ -50,7 +54,11 @@ func (e *Endpoints) StorageBucketsAddress() string {
 // restarting it.
 func (e *Endpoints) StorageBucketsUpdateAddress(address string, cert *localtls.CertInfo) error {
 	if address != "" {
-		address = internalUtil.CanonicalNetworkAddress(address, ports.HTTPSStorageBucketsDefaultPort)
+		var err error
+		address, err = internalUtil.ResolveListenAddress(address, ports.HTTPSStorageBucketsDefaultPort)
+		if err != nil {
+			return err
+		}
 	}
 
 	oldAddress := e.StorageBucketsAddress()
@@ -988,7 +988,7 @@ func (d *lxc) initLXC(config bool) (*liblxc.Container, error) {
 			}
 		} else {
 			// If not currently confined, use the container's profile
-			profile := apparmor.InstanceProfileName(d)
+			profile := apparmor.InstanceProfileLabel(d.state.OS, d)
 
 			/* In the nesting case, we want to enable the inside
 			 * daemon to load its profile. Unprivileged containers can
@@ -4187,6 +4187,12 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 		if err != nil {
 			return fmt.Errorf("Invalid devices: %w", err)
 		}
+
+		// Only the names of devices being added have to follow the naming rules.
+		err = internalInstance.ValidNewDeviceNames(args.Devices.CloneNative(), d.localDevices.CloneNative(), d.expandedDevices.CloneNative())
+		if err != nil {
+			return fmt.Errorf("Invalid devices: %w", err)
+		}
 	}
 
 	var profiles []string
@@ -6283,7 +6289,7 @@ func (d *lxc) MigrateReceive(args instance.MigrateReceiveArgs) error {
 					d.logger.Debug("Waiting to receive pre-dump rsync")
 
 					// Transfer a CRIU pre-dump.
-					err = rsync.Recv(internalUtil.AddSlash(imagesDir), stateConn, nil, rsyncFeatures)
+					err = rsync.Recv(internalUtil.AddSlash(imagesDir), rsync.AccessCreateOnly, stateConn, nil, rsyncFeatures)
 					if err != nil {
 						return fmt.Errorf("Failed receiving pre-dump rsync: %w", err)
 					}
@@ -6313,7 +6319,7 @@ func (d *lxc) MigrateReceive(args instance.MigrateReceiveArgs) error {
 
 			// Final CRIU dump.
 			d.logger.Debug("About to receive final dump rsync")
-			err = rsync.Recv(internalUtil.AddSlash(imagesDir), stateConn, nil, rsyncFeatures)
+			err = rsync.Recv(internalUtil.AddSlash(imagesDir), rsync.AccessCreateOnly, stateConn, nil, rsyncFeatures)
 			if err != nil {
 				return fmt.Errorf("Failed receiving final dump rsync: %w", err)
 			}
@@ -6988,6 +6994,28 @@ func (d *lxc) FileSFTPConn() (net.Conn, error) {
 			}
 		}
 
+		// Confine the server to the instance filesystem, as seen from its mount namespace when running.
+		paths := []string{"/"}
+		if !d.IsRunning() {
+			pool, err := d.getStoragePool()
+			if err != nil {
+				chReady <- err
+				return
+			}
+
+			// The rootfs may be reached through the instance path or where its volume is mounted.
+			volPath := storageDrivers.GetVolumeMountPath(pool.Name(), storageDrivers.VolumeTypeContainer, project.Instance(d.Project().Name, d.Name()))
+			paths = []string{d.RootfsPath(), filepath.Join(volPath, "rootfs")}
+		}
+
+		cleanup, err := apparmor.ForkfileWrapper(d.state.OS, &forkfile, forkfilePath, paths)
+		if err != nil {
+			chReady <- err
+			return
+		}
+
+		defer cleanup()
+
 		// Start the server.
 		err = forkfile.Start()
 		if err != nil {
@@ -7185,6 +7213,148 @@ func (d *lxc) ConsoleLog(opts liblxc.ConsoleLogOptions) (string, error) {
 	return string(msg), nil
 }
 
+// DumpMemory dumps the memory of the running container and writes it to the provided writer.
+// The "criu" format produces a tarball of the CRIU memory pre-dump images while the "core" format
+// produces a core file of a single process.
+func (d *lxc) DumpMemory(ctx context.Context, w io.Writer, args instance.DumpMemoryArgs) error {
+	if !d.IsRunning() {
+		return fmt.Errorf("Instance is not running")
+	}
+
+	// Dumps can be as large as the memory of the container, so keep them off a possibly memory backed /tmp.
+	tempDir, err := os.MkdirTemp(internalUtil.VarPath("images"), "incus_memory_")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	switch args.Format {
+	case "criu":
+		// A pre-dump only captures the memory pages and leaves the container running.
+		criuMigrationArgs := instance.CriuMigrationArgs{
+			Cmd:          liblxc.MIGRATE_PRE_DUMP,
+			Stop:         false,
+			ActionScript: false,
+			StateDir:     tempDir,
+			Function:     "memory-dump",
+		}
+
+		err = d.migrate(&criuMigrationArgs)
+		if err != nil {
+			return fmt.Errorf("Failed dumping container memory: %w", err)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		tarWriter := instancewriter.NewInstanceTarWriter(w, nil)
+		err = filepath.Walk(tempDir, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if path == tempDir {
+				return nil
+			}
+
+			return tarWriter.WriteFile(path[len(tempDir)+1:], path, fi, false)
+		})
+		if err != nil {
+			_ = tarWriter.Close()
+			return fmt.Errorf("Failed writing memory dump: %w", err)
+		}
+
+		return tarWriter.Close()
+
+	case "core":
+		_, err := exec.LookPath("gcore")
+		if err != nil {
+			return fmt.Errorf("Core dumps require gcore (from gdb) to be installed")
+		}
+
+		pid := d.InitPID()
+		if args.PID > 0 {
+			pid, err = d.hostPID(args.PID)
+			if err != nil {
+				return err
+			}
+		}
+
+		prefix := filepath.Join(tempDir, "core")
+		_, err = subprocess.RunCommandContext(ctx, "gcore", "-o", prefix, strconv.Itoa(pid))
+		if err != nil {
+			return fmt.Errorf("Failed dumping process memory: %w", err)
+		}
+
+		coreFile, err := os.Open(fmt.Sprintf("%s.%d", prefix, pid))
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = coreFile.Close() }()
+
+		_, err = io.Copy(w, coreFile)
+		if err != nil {
+			return fmt.Errorf("Failed writing memory dump: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("Unsupported dump format %q", args.Format)
+}
+
+// hostPID translates a PID from the container's PID namespace into the matching host PID.
+func (d *lxc) hostPID(pid int) (int, error) {
+	initPID := d.InitPID()
+	if initPID <= 0 {
+		return -1, fmt.Errorf("Instance is not running")
+	}
+
+	pidNS, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/pid", initPID))
+	if err != nil {
+		return -1, err
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return -1, err
+	}
+
+	for _, entry := range entries {
+		hostPID, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		// Only consider processes in the container's PID namespace.
+		procNS, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/pid", hostPID))
+		if err != nil || procNS != pidNS {
+			continue
+		}
+
+		status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", hostPID))
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(string(status), "\n") {
+			if !strings.HasPrefix(line, "NSpid:") {
+				continue
+			}
+
+			fields := strings.Fields(line)
+			if fields[len(fields)-1] == strconv.Itoa(pid) {
+				return hostPID, nil
+			}
+		}
+	}
+
+	return -1, fmt.Errorf("No process with PID %d in the container", pid)
+}
+
 // Exec executes a command inside the instance.
 func (d *lxc) Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, stderr *os.File) (instance.Cmd, error) {
 	// Generate the LXC config if missing.
@@ -119,6 +119,9 @@ const qemuMigrationNBDExportName = "incus_root"
 // 4 are reserved, and the other 4 can be used for any USB device.
 const qemuSparseUSBPorts = 8
 
+// qemuFreezeTimeout is the maximum time the guest filesystems may remain frozen during a snapshot.
+const qemuFreezeTimeout = 30 * time.Second
+
 var errQemuAgentOffline = fmt.Errorf("VM agent isn't currently running")
 
 type monitorHook func(m *qmp.Monitor) error
@@ -1573,7 +1576,7 @@ func (d *qemu) start(stateful bool, op *operationlock.InstanceOperation) error {
 		return err
 	}
 
-	p.SetApparmor(apparmor.InstanceProfileName(d))
+	p.SetApparmor(apparmor.InstanceProfileLabel(d.state.OS, d))
 
 	// Update the backup.yaml file just before starting the instance process, but after all devices have been
 	// setup, so that the backup file contains the volatile keys used for this instance start, so that they can
@@ -1923,6 +1926,138 @@ func (d *qemu) AgentCertificate() *x509.Certificate {
 	return cert
 }
 
+// QueryMonitor runs the provided QMP query command and returns its raw result.
+// The caller is responsible for only allowing commands which don't alter the VM.
+func (d *qemu) QueryMonitor(command string) (json.RawMessage, error) {
+	if !d.IsRunning() {
+		return nil, fmt.Errorf("Instance is not running")
+	}
+
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return nil, err
+	}
+
+	return monitor.RunQuery(command)
+}
+
+// DumpGuestMemory dumps the guest memory in the requested format and writes it to the provided writer.
+// Cancelling the context aborts the dump.
+func (d *qemu) DumpGuestMemory(ctx context.Context, w io.Writer, args instance.DumpGuestMemoryArgs) error {
+	if !d.IsRunning() {
+		return fmt.Errorf("Instance is not running")
+	}
+
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	// Fail early rather than after QEMU went through the whole guest memory.
+	if args.Format == "win-dmp" && !args.Force {
+		err = d.checkWinDump(monitor)
+		if err != nil {
+			return err
+		}
+	}
+
+	// QEMU writes the dump into a pipe which we then copy to the target writer.
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = reader.Close() }()
+
+	copyDone := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(w, reader)
+
+		// Close the read side on failure so QEMU doesn't block on a full pipe.
+		_ = reader.Close()
+		copyDone <- err
+	}()
+
+	err = monitor.DumpGuestMemory(writer, args.Format)
+	_ = writer.Close()
+	if err != nil {
+		_ = reader.Close()
+		<-copyDone
+		return fmt.Errorf("Failed dumping guest memory: %w", err)
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case copyErr := <-copyDone:
+			if copyErr != nil {
+				return fmt.Errorf("Failed writing memory dump: %w", copyErr)
+			}
+
+			// QEMU records the final status before closing its end of the pipe.
+			status, err := monitor.QueryDump()
+			if err != nil {
+				return err
+			}
+
+			if status.Status != "completed" {
+				return fmt.Errorf("Failed dumping guest memory: Dump status is %q", status.Status)
+			}
+
+			if args.Progress != nil {
+				args.Progress(status.Completed, status.Total)
+			}
+
+			return nil
+		case <-ctx.Done():
+			// Closing the pipe makes QEMU fail the dump.
+			_ = reader.Close()
+			<-copyDone
+			return ctx.Err()
+		case <-ticker.C:
+			if args.Progress == nil {
+				continue
+			}
+
+			status, err := monitor.QueryDump()
+			if err != nil {
+				continue
+			}
+
+			args.Progress(status.Completed, status.Total)
+		}
+	}
+}
+
+// checkWinDump checks that the guest can produce a win-dmp memory dump.
+func (d *qemu) checkWinDump(monitor *qmp.Monitor) error {
+	formats, err := monitor.QueryDumpFormats()
+	if err != nil {
+		return fmt.Errorf("Failed getting the supported dump formats: %w", err)
+	}
+
+	if !slices.Contains(formats, "win-dmp") {
+		return fmt.Errorf("The win-dmp format isn't supported on this architecture")
+	}
+
+	// Windows relies on the VM generation ID device.
+	_, err = monitor.RunQuery("query-vm-generation-id")
+	if err != nil {
+		return fmt.Errorf("win-dmp requires a Windows guest with SMBIOS and VM generation ID support: %w", err)
+	}
+
+	osName := d.expandedConfig["image.os"]
+	if osName != "" && !strings.Contains(strings.ToLower(osName), "windows") {
+		return fmt.Errorf("win-dmp requires a Windows guest with SMBIOS and VM generation ID support, the guest is running %q", osName)
+	}
+
+	return nil
+}
+
 func (d *qemu) architectureSupportsUEFI(arch int) bool {
 	return slices.Contains([]int{osarch.ARCH_64BIT_INTEL_X86, osarch.ARCH_64BIT_ARMV8_LITTLE_ENDIAN}, arch)
 }
@@ -4814,6 +4949,24 @@ func (d *qemu) snapshot(name string, expiry time.Time, stateful bool) error {
 		}
 	}
 
+	// Quiesce the guest filesystems if an application consistent snapshot was requested.
+	if !stateful && d.expandedConfig["snapshots.consistency"] == "application" && d.IsRunning() {
+		thaw, err := d.FreezeFilesystems()
+		if err != nil {
+			d.logger.Warn("Failed freezing guest filesystems, falling back to crash consistent snapshot", logger.Ctx{"err": err})
+		} else {
+			defer func() {
+				err := thaw()
+				if api.StatusErrorCheck(err, http.StatusConflict) {
+					// The agent already thawed the filesystems when the freeze timed out.
+					d.logger.Warn("Guest filesystems were thawed before the snapshot completed, snapshot is only crash consistent", logger.Ctx{"err": err})
+				} else if err != nil {
+					d.logger.Error("Failed thawing guest filesystems", logger.Ctx{"err": err})
+				}
+			}()
+		}
+	}
+
 	// Create the snapshot.
 	err = d.snapshotCommon(d, name, expiry, stateful)
 	if err != nil {
@@ -4837,6 +4990,49 @@ func (d *qemu) snapshot(name string, expiry time.Time, stateful bool) error {
 	return nil
 }
 
+// FreezeFilesystems asks the agent to freeze the guest filesystems and returns a function to thaw them.
+// The agent automatically thaws the filesystems once qemuFreezeTimeout is reached.
+func (d *qemu) FreezeFilesystems() (func() error, error) {
+	client, err := d.getAgentClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't let an unresponsive guest hold up the snapshot.
+	client.Timeout = qemuFreezeTimeout
+
+	agentArgs := &incus.ConnectionArgs{SkipGetServer: true}
+	agent, err := incus.ConnectIncusHTTP(agentArgs, client)
+	if err != nil {
+		return nil, fmt.Errorf("Failed connecting to the agent: %w", err)
+	}
+
+	req := agentAPI.FilesystemsFreezePost{Timeout: int64(qemuFreezeTimeout / time.Second)}
+
+	_, _, err = agent.RawQuery("POST", "/1.0/filesystems/freeze", req, "")
+	if err != nil {
+		agent.Disconnect()
+		return nil, err
+	}
+
+	d.logger.Debug("Guest filesystems frozen")
+
+	thaw := func() error {
+		defer agent.Disconnect()
+
+		_, _, err := agent.RawQuery("DELETE", "/1.0/filesystems/freeze", nil, "")
+		if err != nil {
+			return err
+		}
+
+		d.logger.Debug("Guest filesystems thawed")
+
+		return nil
+	}
+
+	return thaw, nil
+}
+
 // Snapshot takes a new snapshot.
 func (d *qemu) Snapshot(name string, expiry time.Time, stateful bool) error {
 	unlock, err := d.updateBackupFileLock(context.Background())
@@ -5212,6 +5408,12 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		if err != nil {
 			return fmt.Errorf("Invalid devices: %w", err)
 		}
+
+		// Only the names of devices being added have to follow the naming rules.
+		err = internalInstance.ValidNewDeviceNames(args.Devices.CloneNative(), d.localDevices.CloneNative(), d.expandedDevices.CloneNative())
+		if err != nil {
+			return fmt.Errorf("Invalid devices: %w", err)
+		}
 	}
 
 	var profiles []string
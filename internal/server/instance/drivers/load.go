@@ -8,6 +8,7 @@ import (
 
 	internalInstance "github.com/lxc/incus/v6/internal/instance"
 	"github.com/lxc/incus/v6/internal/revert"
+	"github.com/lxc/incus/v6/internal/server/apparmor"
 	"github.com/lxc/incus/v6/internal/server/db"
 	"github.com/lxc/incus/v6/internal/server/db/cluster"
 	"github.com/lxc/incus/v6/internal/server/db/warningtype"
@@ -188,6 +189,23 @@ func DriverStatuses() map[instancetype.Type]*DriverStatus {
 	return driverStatuses
 }
 
+// AppArmorProfile returns the AppArmor profile the instance would be started with, without loading it.
+func AppArmorProfile(inst instance.Instance) (string, error) {
+	switch d := inst.(type) {
+	case *lxc:
+		return apparmor.InstanceProfile(d.state.OS, d, nil)
+	case *qemu:
+		qemuPath, _, err := d.qemuArchConfig(d.architecture)
+		if err != nil {
+			return "", err
+		}
+
+		return apparmor.InstanceProfile(d.state.OS, d, []string{qemuPath})
+	}
+
+	return "", fmt.Errorf("Instance type invalid")
+}
+
 // instanceRefGet retrieves an instance reference.
 func instanceRefGet(projectName string, instName string) instance.Instance {
 	instanceRefsMu.Lock()
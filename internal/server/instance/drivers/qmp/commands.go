@@ -1041,3 +1041,84 @@ func (m *Monitor) SetBlockThrottle(id string, bytesRead int, bytesWrite int, iop
 
 	return nil
 }
+
+// DumpStatus represents the status of a guest memory dump.
+type DumpStatus struct {
+	Status    string `json:"status"`
+	Completed int64  `json:"completed"`
+	Total     int64  `json:"total"`
+}
+
+// DumpGuestMemory starts a background dump of the guest memory in the requested format into the provided file.
+// The progress of the dump can be tracked with QueryDump.
+func (m *Monitor) DumpGuestMemory(dumpFile *os.File, format string) error {
+	err := m.SendFile("memory-dump", dumpFile)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = m.CloseFile("memory-dump") }()
+
+	var args struct {
+		Paging   bool   `json:"paging"`
+		Detach   bool   `json:"detach"`
+		Protocol string `json:"protocol"`
+		Format   string `json:"format,omitempty"`
+	}
+
+	args.Paging = false
+	args.Detach = true
+	args.Protocol = "fd:memory-dump"
+	args.Format = format
+
+	err = m.run("dump-guest-memory", args, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// QueryDump returns the status of the current (or last) guest memory dump.
+func (m *Monitor) QueryDump() (*DumpStatus, error) {
+	var resp struct {
+		Return DumpStatus `json:"return"`
+	}
+
+	err := m.run("query-dump", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Return, nil
+}
+
+// QueryDumpFormats returns the guest memory dump formats supported by QEMU.
+func (m *Monitor) QueryDumpFormats() ([]string, error) {
+	var resp struct {
+		Return struct {
+			Formats []string `json:"formats"`
+		} `json:"return"`
+	}
+
+	err := m.run("query-dump-guest-memory-capability", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Return.Formats, nil
+}
+
+// RunQuery runs a QMP command which takes no arguments and returns its raw result.
+func (m *Monitor) RunQuery(cmd string) (json.RawMessage, error) {
+	var resp struct {
+		Return json.RawMessage `json:"return"`
+	}
+
+	err := m.run(cmd, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Return, nil
+}
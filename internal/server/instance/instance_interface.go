@@ -3,6 +3,7 @@ package instance
 import (
 	"context"
 	"crypto/x509"
+	"encoding/json"
 	"io"
 	"net"
 	"os"
@@ -182,6 +183,13 @@ type Container interface {
 	InsertSeccompUnixDevice(prefix string, m deviceConfig.Device, pid int) error
 	DevptsFd() (*os.File, error)
 	IdmappedStorage(path string, fstype string) idmap.IdmapStorageType
+	DumpMemory(ctx context.Context, w io.Writer, args DumpMemoryArgs) error
+}
+
+// DumpMemoryArgs represent arguments for dumping the memory of a container.
+type DumpMemoryArgs struct {
+	Format string // Either "criu" (pre-dump images) or "core" (single process core file).
+	PID    int    // Process to dump with the "core" format, relative to the container's PID namespace.
 }
 
 // VM interface is for VM specific functions.
@@ -189,6 +197,16 @@ type VM interface {
 	Instance
 
 	AgentCertificate() *x509.Certificate
+	DumpGuestMemory(ctx context.Context, w io.Writer, args DumpGuestMemoryArgs) error
+	FreezeFilesystems() (func() error, error)
+	QueryMonitor(command string) (json.RawMessage, error)
+}
+
+// DumpGuestMemoryArgs represent arguments for dumping the memory of a VM.
+type DumpGuestMemoryArgs struct {
+	Format   string
+	Progress func(completed int64, total int64) // Called periodically with the bytes of guest memory processed.
+	Force    bool                               // Skip the checks that the guest supports the format.
 }
 
 // CriuMigrationArgs arguments for CRIU migration.
@@ -0,0 +1,30 @@
+package lifecycle
+
+import (
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// InstanceDebugAction represents a lifecycle event action for instance debug operations.
+type InstanceDebugAction string
+
+// All supported lifecycle events for instance debug operations.
+const (
+	InstanceDebugMemoryStarted   = InstanceDebugAction(api.EventLifecycleInstanceDebugMemoryStarted)
+	InstanceDebugMemoryCompleted = InstanceDebugAction(api.EventLifecycleInstanceDebugMemoryCompleted)
+	InstanceDebugMemoryFailed    = InstanceDebugAction(api.EventLifecycleInstanceDebugMemoryFailed)
+)
+
+// Event creates the lifecycle event for a debug action on an instance.
+func (a InstanceDebugAction) Event(inst instance, requestor *api.EventLifecycleRequestor, ctx map[string]any) api.EventLifecycle {
+	u := api.NewURL().Path(version.APIVersion, "instances", inst.Name()).Project(inst.Project().Name)
+
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    u.String(),
+		Context:   ctx,
+		Requestor: requestor,
+		Name:      inst.Name(),
+		Project:   inst.Project().Name,
+	}
+}
@@ -3,6 +3,10 @@ package metadata
 import (
 	"embed"
 	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
 )
 
 var Data map[string]any
@@ -24,3 +28,63 @@ func init() {
 
 	Data = data
 }
+
+// DeviceTypes returns the configuration keys of each device type described in the metadata,
+// sorted by name.
+func DeviceTypes() (map[string][]api.MetadataDeviceTypeKey, error) {
+	// Go through JSON to get the typed version of the metadata.
+	file, err := generatedDoc.ReadFile("configuration.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var config api.MetadataConfiguration
+	err = json.Unmarshal(file, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceTypes := map[string][]api.MetadataDeviceTypeKey{}
+	for group, configGroup := range config.Config["devices"] {
+		keys := []api.MetadataDeviceTypeKey{}
+		for _, k := range configGroup.Keys {
+			for name, entry := range k {
+				keys = append(keys, api.MetadataDeviceTypeKey{
+					Name:          name,
+					Type:          entry.Type,
+					Default:       strings.Trim(entry.Default, "`"),
+					LiveUpdate:    entry.LiveUpdate == "yes",
+					InstanceTypes: deviceKeyInstanceTypes(entry),
+					Description:   entry.Description,
+				})
+			}
+		}
+
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+		deviceTypes[string(group)] = keys
+	}
+
+	return deviceTypes, nil
+}
+
+// deviceKeyInstanceTypes returns the instance types a device key applies to, based on its condition or,
+// lacking one, on the restriction noted in its description.
+func deviceKeyInstanceTypes(entry api.MetadataConfigKey) []string {
+	condition := entry.Condition
+	if condition == "" {
+		if strings.HasPrefix(entry.Description, "Only for containers:") || strings.HasSuffix(entry.Description, "(only for containers)") {
+			condition = "container"
+		} else if strings.HasPrefix(entry.Description, "Only for VMs:") {
+			condition = "virtual machine"
+		}
+	}
+
+	switch condition {
+	case "container", "unprivileged container":
+		return []string{"container"}
+	case "virtual machine":
+		return []string{"virtual-machine"}
+	}
+
+	return []string{"container", "virtual-machine"}
+}
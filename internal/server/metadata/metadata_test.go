@@ -0,0 +1,46 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func TestDeviceTypes(t *testing.T) {
+	deviceTypes, err := DeviceTypes()
+	require.NoError(t, err)
+
+	keys := map[string]api.MetadataDeviceTypeKey{}
+	for _, k := range deviceTypes["disk"] {
+		keys[k.Name] = k
+	}
+
+	assert.Equal(t, "bool", keys["readonly"].Type)
+	assert.True(t, keys["readonly"].LiveUpdate)
+	assert.False(t, keys["pool"].LiveUpdate)
+	assert.Equal(t, []string{"container"}, keys["shift"].InstanceTypes)
+	assert.Equal(t, []string{"virtual-machine"}, keys["io.bus"].InstanceTypes)
+	assert.Equal(t, []string{"container", "virtual-machine"}, keys["source"].InstanceTypes)
+
+	usb := deviceTypes["usb"]
+	require.NotEmpty(t, usb)
+	assert.Equal(t, "busnum", usb[0].Name)
+
+	for _, k := range usb {
+		if k.Name == "mode" {
+			assert.Equal(t, "0660", k.Default)
+			assert.Equal(t, []string{"container"}, k.InstanceTypes)
+		}
+	}
+
+	nic := map[string]api.MetadataDeviceTypeKey{}
+	for _, k := range deviceTypes["nic"] {
+		nic[k.Name] = k
+	}
+
+	assert.True(t, nic["parent"].LiveUpdate)
+	assert.False(t, nic["limits.ingress"].LiveUpdate)
+}
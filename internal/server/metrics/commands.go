@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/subprocess"
+)
+
+// commandDurations holds the duration in seconds of the external commands run by the daemon, by binary name.
+var commandDurations = NewHistogram("binary", []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300})
+
+// CommandHook returns a hook for subprocess.SetHook recording the duration of every command run by the daemon
+// and logging at debug level the commands taking longer than slowThreshold.
+func CommandHook(slowThreshold time.Duration) func(subprocess.CommandResult) {
+	return func(result subprocess.CommandResult) {
+		commandDurations.Observe(commandBinary(result.Args), result.Duration.Seconds())
+
+		if result.Duration < slowThreshold {
+			return
+		}
+
+		logger.Debug("Slow command", logger.Ctx{"command": strings.Join(result.Args, " "), "duration": result.Duration, "exitCode": result.ExitCode, "stderr": strings.TrimSpace(result.Stderr)})
+	}
+}
+
+// CommandSamples returns the samples of the command duration histogram.
+func CommandSamples() []Sample {
+	return commandDurations.Samples()
+}
+
+// commandBinary returns the name of the binary a command runs, looking past the AppArmor confinement wrapper.
+func commandBinary(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	if filepath.Base(args[0]) == "aa-exec" && len(args) > 3 && args[1] == "-p" {
+		args = args[3:]
+	}
+
+	return filepath.Base(args[0])
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/subprocess"
+)
+
+// debugLogger records the debug messages.
+type debugLogger struct {
+	logger.Logger
+
+	messages []logger.Ctx
+}
+
+func (l *debugLogger) Debug(msg string, args ...logger.Ctx) {
+	l.messages = append(l.messages, args...)
+}
+
+func TestCommandHook(t *testing.T) {
+	old := logger.Log
+	log := &debugLogger{}
+	logger.Log = log
+	t.Cleanup(func() { logger.Log = old })
+
+	oldDurations := commandDurations
+	commandDurations = NewHistogram("binary", []float64{1})
+	t.Cleanup(func() { commandDurations = oldDurations })
+
+	hook := CommandHook(time.Second)
+	hook(subprocess.CommandResult{Args: []string{"rbd", "ls"}, Duration: 100 * time.Millisecond})
+	hook(subprocess.CommandResult{Args: []string{"/usr/bin/aa-exec", "-p", "incus_ceph", "rbd", "map", "--key=[redacted]"}, Duration: 3 * time.Second, ExitCode: 16, Stderr: "busy\n"})
+
+	// Only the slow command is logged.
+	require.Len(t, log.messages, 1)
+	assert.Equal(t, "/usr/bin/aa-exec -p incus_ceph rbd map --key=[redacted]", log.messages[0]["command"])
+	assert.Equal(t, 3*time.Second, log.messages[0]["duration"])
+	assert.Equal(t, 16, log.messages[0]["exitCode"])
+	assert.Equal(t, "busy", log.messages[0]["stderr"])
+
+	// Both are recorded under the binary name.
+	samples := CommandSamples()
+	require.Len(t, samples, 4)
+	assert.Equal(t, Sample{Labels: map[string]string{"binary": "rbd"}, Value: 2, Suffix: "_count"}, samples[3])
+	assert.Equal(t, Sample{Labels: map[string]string{"binary": "rbd", "le": "1"}, Value: 1, Suffix: "_bucket"}, samples[0])
+}
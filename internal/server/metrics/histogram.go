@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Histogram counts observed values in buckets, separately for each value of a label.
+type Histogram struct {
+	mu      sync.Mutex
+	label   string
+	bounds  []float64
+	entries map[string]*histogramEntry
+}
+
+// histogramEntry holds the observations for a value of the label.
+type histogramEntry struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram returns a Histogram with buckets having the given upper bounds, in increasing order, and
+// splitting the observations by the given label.
+func NewHistogram(label string, bounds []float64) *Histogram {
+	return &Histogram{
+		label:   label,
+		bounds:  bounds,
+		entries: map[string]*histogramEntry{},
+	}
+}
+
+// Observe records a value for the given label value.
+func (h *Histogram) Observe(labelValue string, value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.entries[labelValue]
+	if !ok {
+		entry = &histogramEntry{counts: make([]uint64, len(h.bounds))}
+		h.entries[labelValue] = entry
+	}
+
+	for i, bound := range h.bounds {
+		if value <= bound {
+			entry.counts[i]++
+		}
+	}
+
+	entry.count++
+	entry.sum += value
+}
+
+// Samples returns the samples of the histogram as specified by OpenMetrics. For each label value, these are the
+// cumulative counts of each bucket, with its upper bound in the "le" label, followed by the sum and the count.
+func (h *Histogram) Samples() []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	labelValues := make([]string, 0, len(h.entries))
+	for labelValue := range h.entries {
+		labelValues = append(labelValues, labelValue)
+	}
+
+	sort.Strings(labelValues)
+
+	samples := make([]Sample, 0, len(labelValues)*(len(h.bounds)+3))
+	for _, labelValue := range labelValues {
+		entry := h.entries[labelValue]
+
+		for i, bound := range h.bounds {
+			samples = append(samples, Sample{Labels: map[string]string{h.label: labelValue, "le": strconv.FormatFloat(bound, 'g', -1, 64)}, Value: float64(entry.counts[i]), Suffix: "_bucket"})
+		}
+
+		samples = append(samples,
+			Sample{Labels: map[string]string{h.label: labelValue, "le": "+Inf"}, Value: float64(entry.count), Suffix: "_bucket"},
+			Sample{Labels: map[string]string{h.label: labelValue}, Value: entry.sum, Suffix: "_sum"},
+			Sample{Labels: map[string]string{h.label: labelValue}, Value: float64(entry.count), Suffix: "_count"},
+		)
+	}
+
+	return samples
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram("binary", []float64{0.1, 1})
+	h.Observe("rbd", 0.05)
+	h.Observe("rbd", 0.5)
+	h.Observe("rbd", 2)
+	h.Observe("ceph", 1)
+
+	m := NewMetricSet(nil)
+	m.AddSamples(CommandDurationSeconds, h.Samples()...)
+
+	expected := `# HELP incus_command_duration_seconds The duration of the external commands run by the daemon in seconds.
+# TYPE incus_command_duration_seconds histogram
+incus_command_duration_seconds_bucket{binary="ceph",le="0.1"} 0
+incus_command_duration_seconds_bucket{binary="ceph",le="1"} 1
+incus_command_duration_seconds_bucket{binary="ceph",le="+Inf"} 1
+incus_command_duration_seconds_sum{binary="ceph"} 1
+incus_command_duration_seconds_count{binary="ceph"} 1
+incus_command_duration_seconds_bucket{binary="rbd",le="0.1"} 1
+incus_command_duration_seconds_bucket{binary="rbd",le="1"} 2
+incus_command_duration_seconds_bucket{binary="rbd",le="+Inf"} 3
+incus_command_duration_seconds_sum{binary="rbd"} 2.55
+incus_command_duration_seconds_count{binary="rbd"} 3
+# EOF
+`
+
+	require.Equal(t, expected, m.String())
+}
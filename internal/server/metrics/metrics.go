@@ -111,6 +111,8 @@ func (m *MetricSet) String() string {
 		// ProcsTotal is a gauge according to the OpenMetrics spec as its value can decrease.
 		if metricType == ProcsTotal || metricType == CPUs || metricType == GoGoroutines || metricType == GoHeapObjects {
 			metricTypeName = "gauge"
+		} else if metricType == CommandDurationSeconds {
+			metricTypeName = "histogram"
 		} else if strings.HasSuffix(MetricNames[metricType], "_total") || strings.HasSuffix(MetricNames[metricType], "_seconds") {
 			metricTypeName = "counter"
 		} else if strings.HasSuffix(MetricNames[metricType], "_bytes") {
@@ -147,9 +149,9 @@ func (m *MetricSet) String() string {
 			valueStr := strconv.FormatFloat(sample.Value, 'g', -1, 64)
 
 			if labels != "" {
-				_, err = out.WriteString(fmt.Sprintf("%s{%s} %s\n", MetricNames[metricType], labels, valueStr))
+				_, err = out.WriteString(fmt.Sprintf("%s%s{%s} %s\n", MetricNames[metricType], sample.Suffix, labels, valueStr))
 			} else {
-				_, err = out.WriteString(fmt.Sprintf("%s %s\n", MetricNames[metricType], valueStr))
+				_, err = out.WriteString(fmt.Sprintf("%s%s %s\n", MetricNames[metricType], sample.Suffix, valueStr))
 			}
 
 			if err != nil {
@@ -4,6 +4,9 @@ package metrics
 type Sample struct {
 	Labels map[string]string
 	Value  float64
+
+	// Suffix is appended to the metric name, like "_bucket" for the samples of a histogram.
+	Suffix string
 }
 
 // MetricSet represents a set of metrics.
@@ -144,10 +147,13 @@ const (
 	GoOtherSysBytes
 	// GoNextGCBytes represents the number of heap bytes when next garbage collection will take place.
 	GoNextGCBytes
+	// CommandDurationSeconds represents the duration of the external commands run by the daemon.
+	CommandDurationSeconds
 )
 
 // MetricNames associates a metric type to its name.
 var MetricNames = map[MetricType]string{
+	CommandDurationSeconds:      "incus_command_duration_seconds",
 	CPUSecondsTotal:             "incus_cpu_seconds_total",
 	CPUs:                        "incus_cpu_effective_total",
 	DiskReadBytesTotal:          "incus_disk_read_bytes_total",
@@ -216,6 +222,7 @@ var MetricNames = map[MetricType]string{
 
 // MetricHeaders represents the metric headers which contain help messages as specified by OpenMetrics.
 var MetricHeaders = map[MetricType]string{
+	CommandDurationSeconds:      "# HELP incus_command_duration_seconds The duration of the external commands run by the daemon in seconds.",
 	CPUSecondsTotal:             "# HELP incus_cpu_seconds_total The total number of CPU time used in seconds.",
 	CPUs:                        "# HELP incus_cpu_effective_total The total number of effective CPUs.",
 	DiskReadBytesTotal:          "# HELP incus_disk_read_bytes_total The total number of bytes read.",
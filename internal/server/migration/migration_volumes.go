@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"slices"
+	"strings"
 
 	"github.com/lxc/incus/v6/internal/migration"
 	backupConfig "github.com/lxc/incus/v6/internal/server/backup/config"
@@ -257,6 +258,13 @@ func progressWrapperRender(op *operations.Operation, key string, description str
 
 	if meta[key] != progress {
 		meta[key] = progress
+		operations.SetOperationProgress(meta, description, api.OperationProgress{
+			Stage:       strings.TrimSuffix(key, "_progress"),
+			Description: description,
+			Processed:   progressInt,
+			Speed:       speedInt,
+		})
+
 		_ = op.UpdateMetadata(meta)
 	}
 }
@@ -2,7 +2,7 @@ package network
 
 import (
 	"context"
-	"errors"
+	"crypto/sha256"
 	"fmt"
 	"net"
 	"net/http"
@@ -45,6 +45,16 @@ import (
 // Default MTU for bridge interface.
 const bridgeMTUDefault = 1500
 
+// dnsmasqRestartPolicy restarts a failed dnsmasq, backing off up to a minute and giving up after 5 attempts in a
+// row.
+var dnsmasqRestartPolicy = subprocess.RestartPolicy{
+	Mode:        subprocess.RestartOnFailure,
+	MaxRestarts: 5,
+	Delay:       time.Second,
+	MaxDelay:    time.Minute,
+	ResetAfter:  10 * time.Minute,
+}
+
 // bridge represents a bridge network.
 type bridge struct {
 	common
@@ -1350,12 +1360,6 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		return err
 	}
 
-	// Kill any existing dnsmasq daemon for this network.
-	err = dnsmasq.Kill(n.name, false)
-	if err != nil {
-		return err
-	}
-
 	// Configure dnsmasq.
 	if n.UsesDNSMasq() {
 		// Setup the dnsmasq domain.
@@ -1407,16 +1411,25 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			return err
 		}
 
-		// Create subprocess object dnsmasq.
+		// Declare the supervised dnsmasq process.
 		dnsmasqLogPath := internalUtil.LogPath(fmt.Sprintf("dnsmasq.%s.log", n.name))
-		p, err := subprocess.NewProcess(command, dnsmasqCmd, "", dnsmasqLogPath)
-		if err != nil {
-			return fmt.Errorf("Failed to create subprocess: %s", err)
+		proc := subprocess.SupervisedProcess{
+			Name:    n.dnsmasqProcessName(),
+			Command: command,
+			Args:    dnsmasqCmd,
+			PidFile: internalUtil.VarPath("networks", n.name, "dnsmasq.pid"),
+			Stderr:  dnsmasqLogPath,
+			Policy:  dnsmasqRestartPolicy,
+			// Changes to raw.dnsmasq only show in the config file, so track them to restart dnsmasq.
+			ConfigHash: fmt.Sprintf("%x", sha256.Sum256([]byte(n.config["raw.dnsmasq"]))),
+			OnExit: func(exit subprocess.ProcessExit) {
+				n.logger.Warn("The DNS and DHCP service exited", logger.Ctx{"pid": exit.PID, "exitCode": exit.ExitCode, "err": exit.Err, "restarting": exit.Restarting})
+			},
 		}
 
 		// Apply AppArmor confinement.
 		if n.config["raw.dnsmasq"] == "" {
-			p.SetApparmor(apparmor.DnsmasqProfileName(n))
+			proc.Apparmor = apparmor.DnsmasqProfileName(n)
 
 			err = warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(n.state.DB.Cluster, n.project, warningtype.AppArmorDisabledDueToRawDnsmasq, dbCluster.TypeNetwork, int(n.id))
 			if err != nil {
@@ -1433,35 +1446,37 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			}
 		}
 
+		// Kill any existing dnsmasq daemon for this network, unless it's running with the same configuration,
+		// including one started before the daemon restarted, in which case it's kept.
+		if !n.state.Supervisor.Running(proc) {
+			err = n.killDNSMasq()
+			if err != nil {
+				return err
+			}
+		}
+
 		// Start dnsmasq.
-		err = p.Start(context.Background())
+		exited, err := n.state.Supervisor.Start(proc)
 		if err != nil {
 			return fmt.Errorf("Failed to run: %s %s: %w", command, strings.Join(dnsmasqCmd, " "), err)
 		}
 
 		// Check dnsmasq started OK.
-		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Millisecond*time.Duration(500)))
-		_, err = p.Wait(ctx)
-		if !errors.Is(err, context.DeadlineExceeded) {
+		select {
+		case exit := <-exited:
+			_ = n.state.Supervisor.Stop(proc.Name)
 			stderr, _ := os.ReadFile(dnsmasqLogPath)
-			cancel()
 
-			return fmt.Errorf("The DNS and DHCP service exited prematurely: %w (%q)", err, strings.TrimSpace(string(stderr)))
+			return fmt.Errorf("The DNS and DHCP service exited prematurely: %w (%q)", exit.Err, strings.TrimSpace(string(stderr)))
+		case <-time.After(500 * time.Millisecond):
 		}
-
-		cancel()
-
-		err = p.Save(internalUtil.VarPath("networks", n.name, "dnsmasq.pid"))
+	} else {
+		// Kill any existing dnsmasq daemon for this network.
+		err = n.killDNSMasq()
 		if err != nil {
-			// Kill Process if started, but could not save the file.
-			err2 := p.Stop()
-			if err != nil {
-				return fmt.Errorf("Could not kill subprocess while handling saving error: %s: %s", err, err2)
-			}
-
-			return fmt.Errorf("Failed to save subprocess details: %s", err)
+			return err
 		}
-	} else {
+
 		// Clean up old dnsmasq config if exists and we are not starting dnsmasq.
 		leasesPath := internalUtil.VarPath("networks", n.name, "dnsmasq.leases")
 		if util.PathExists(leasesPath) {
@@ -1572,7 +1587,7 @@ func (n *bridge) Stop() error {
 	}
 
 	// Kill any existing dnsmasq daemon for this network
-	err = dnsmasq.Kill(n.name, false)
+	err = n.killDNSMasq()
 	if err != nil {
 		return err
 	}
@@ -2170,6 +2185,14 @@ func (n *bridge) ForwardCreate(forward api.NetworkForwardsPost, clientType reque
 		return err
 	}
 
+	// Check the listen address is present on this server, for the traffic to reach the forward.
+	if clientType == request.ClientTypeNormal {
+		err = ValidateListenAddressPresent(n.state, listenAddressNet.IP, util.IsTrue(forward.Config["vip"]))
+		if err != nil {
+			return err
+		}
+	}
+
 	externalSubnetsInUse, err := n.getExternalSubnetInUse()
 	if err != nil {
 		return err
@@ -2329,6 +2352,14 @@ func (n *bridge) ForwardUpdate(listenAddress string, req api.NetworkForwardPut,
 		return err
 	}
 
+	// Check the listen address is present on this server when it stops being flagged as a virtual IP.
+	if clientType == request.ClientTypeNormal && util.IsTrue(curForward.Config["vip"]) && !util.IsTrue(req.Config["vip"]) {
+		err = ValidateListenAddressPresent(n.state, net.ParseIP(curForward.ListenAddress), false)
+		if err != nil {
+			return err
+		}
+	}
+
 	curForwardEtagHash, err := localUtil.EtagHash(curForward.Etag())
 	if err != nil {
 		return err
@@ -2712,6 +2743,21 @@ func (n *bridge) Leases(projectName string, clientType request.ClientType) ([]ap
 	return leases, nil
 }
 
+// dnsmasqProcessName returns the name of the supervised dnsmasq process of the network.
+func (n *bridge) dnsmasqProcessName() string {
+	return fmt.Sprintf("dnsmasq.%s", n.name)
+}
+
+// killDNSMasq kills the dnsmasq daemon of the network, including one started before the daemon restarted.
+func (n *bridge) killDNSMasq() error {
+	err := n.state.Supervisor.Stop(n.dnsmasqProcessName())
+	if err != nil {
+		return fmt.Errorf("Unable to kill dnsmasq: %w", err)
+	}
+
+	return dnsmasq.Kill(n.name, false)
+}
+
 // UsesDNSMasq indicates if network's config indicates if it needs to use dnsmasq.
 func (n *bridge) UsesDNSMasq() bool {
 	return !slices.Contains([]string{"", "none"}, n.config["ipv4.address"]) || !slices.Contains([]string{"", "none"}, n.config["ipv6.address"])
@@ -862,11 +862,21 @@ func (n *common) forwardValidate(listenAddress net.IP, forward *api.NetworkForwa
 	}
 
 	// Look for any unknown config fields.
-	for k := range forward.Config {
+	for k, v := range forward.Config {
 		if k == "target_address" {
 			continue
 		}
 
+		// Only bridge forwards check that the listen address is present on the server.
+		if k == "vip" && n.netType == "bridge" {
+			err := validate.Optional(validate.IsBool)(v)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid value for option %q: %w", k, err)
+			}
+
+			continue
+		}
+
 		// User keys are not validated.
 		if internalInstance.IsUserConfig(k) {
 			continue
@@ -1125,28 +1125,7 @@ func IPInSlice(key net.IP, list []net.IP) bool {
 
 // SubnetContains returns true if outerSubnet contains innerSubnet.
 func SubnetContains(outerSubnet *net.IPNet, innerSubnet *net.IPNet) bool {
-	if outerSubnet == nil || innerSubnet == nil {
-		return false
-	}
-
-	if !outerSubnet.Contains(innerSubnet.IP) {
-		return false
-	}
-
-	outerOnes, outerBits := outerSubnet.Mask.Size()
-	innerOnes, innerBits := innerSubnet.Mask.Size()
-
-	// Check number of bits in mask match.
-	if innerBits != outerBits {
-		return false
-	}
-
-	// Check that the inner subnet isn't outside of the outer subnet.
-	if innerOnes < outerOnes {
-		return false
-	}
-
-	return true
+	return internalUtil.SubnetContains(outerSubnet, innerSubnet)
 }
 
 // SubnetContainsIP returns true if outsetSubnet contains IP address.
@@ -1215,6 +1194,60 @@ func SubnetParseAppend(subnets []*net.IPNet, parseSubnet ...string) ([]*net.IPNe
 	return subnets, nil
 }
 
+// ValidateSubnetOverlap checks that the subnets of the ipv4.address and ipv6.address keys of the given network
+// config don't overlap with the ones of another managed network of the project.
+func ValidateSubnetOverlap(s *state.State, projectName string, networkName string, config map[string]string) error {
+	subnets := map[string]*net.IPNet{}
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		if slices.Contains([]string{"", "none", "auto"}, config[key]) {
+			continue
+		}
+
+		// Invalid values are reported by the validation of the network config.
+		subnet, err := internalUtil.NormalizeCIDR(config[key])
+		if err != nil {
+			continue
+		}
+
+		subnets[key] = subnet
+	}
+
+	if len(subnets) == 0 {
+		return nil
+	}
+
+	var networks map[int64]api.Network
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		networks, err = tx.GetCreatedNetworksByProject(ctx, projectName)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Failed loading networks of project %q: %w", projectName, err)
+	}
+
+	for _, network := range networks {
+		if network.Name == networkName {
+			continue
+		}
+
+		for _, otherKey := range []string{"ipv4.address", "ipv6.address"} {
+			otherSubnet, err := internalUtil.NormalizeCIDR(network.Config[otherKey])
+			if err != nil {
+				continue
+			}
+
+			for key, subnet := range subnets {
+				if internalUtil.SubnetsOverlap(subnet, otherSubnet) {
+					return fmt.Errorf("The %q subnet %s overlaps with the %q subnet %s of network %q", key, subnet, otherKey, otherSubnet, network.Name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 // IPRangesOverlap checks whether two ip ranges have ip addresses in common.
 func IPRangesOverlap(r1, r2 *iprange.Range) bool {
 	if r1.End == nil {
@@ -0,0 +1,133 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/internal/server/cluster"
+	"github.com/lxc/incus/v6/internal/server/state"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// AddressPresentInCluster returns the names of the other cluster members having the given IP address configured on
+// one of their network interfaces, found by querying the state of their interfaces. Members which are offline are
+// skipped.
+func AddressPresentInCluster(s *state.State, address net.IP) ([]string, error) {
+	notifier, err := cluster.NewNotifier(s, s.Endpoints.NetworkCert(), s.ServerCert(), cluster.NotifyAlive)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var members []string
+
+	err = notifier(func(client incus.InstanceServer) error {
+		found, err := addressPresentOnMember(client, address)
+		if err != nil || !found {
+			return err
+		}
+
+		server, _, err := client.GetServer()
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		members = append(members, server.Environment.ServerName)
+		mu.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(members)
+
+	return members, nil
+}
+
+// addressPresentOnMember checks whether the given IP address is configured on one of the network interfaces of the
+// member the client is connected to.
+func addressPresentOnMember(client incus.InstanceServer, address net.IP) (bool, error) {
+	names, err := client.GetNetworkNames()
+	if err != nil {
+		return false, fmt.Errorf("Failed getting networks: %w", err)
+	}
+
+	for _, name := range names {
+		// Managed networks may not have an interface on the member.
+		networkState, err := client.GetNetworkState(name)
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range networkState.Addresses {
+			if address.Equal(net.ParseIP(addr.Address)) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// ValidateListenAddressPresent checks that a listen address relying on DNAT rules is configured on a network
+// interface of this server, so that the traffic to it reaches the rules. Addresses on interfaces which are down are
+// accepted with a warning, as the interface may be brought up later. Virtual IPs, like the ones managed by
+// keepalived, are only present on the server currently holding them and so aren't checked.
+func ValidateListenAddressPresent(s *state.State, listenAddress net.IP, vip bool) error {
+	if vip {
+		return nil
+	}
+
+	hostAddress, err := internalUtil.AddressPresentOnHost(listenAddress.String())
+	if err != nil {
+		return err
+	}
+
+	if hostAddress != nil {
+		if !hostAddress.Up {
+			logger.Warn("Listen address is on a network interface which is down", logger.Ctx{"address": listenAddress.String(), "interface": hostAddress.Interface})
+		}
+
+		return nil
+	}
+
+	if s.ServerClustered {
+		members, err := AddressPresentInCluster(s, listenAddress)
+		if err != nil {
+			logger.Warn("Failed looking for listen address on other cluster members", logger.Ctx{"address": listenAddress.String(), "err": err})
+		} else if len(members) > 0 {
+			return fmt.Errorf("Listen address %q isn't present on this server but on cluster member(s) %s", listenAddress.String(), strings.Join(members, ", "))
+		}
+	}
+
+	addresses, err := internalUtil.HostAddresses()
+	if err != nil {
+		return err
+	}
+
+	available := []string{}
+	for _, address := range addresses {
+		if !address.Up || !address.IP.IsGlobalUnicast() || (address.IP.To4() == nil) != (listenAddress.To4() == nil) {
+			continue
+		}
+
+		if !slices.Contains(available, address.IP.String()) {
+			available = append(available, address.IP.String())
+		}
+	}
+
+	if len(available) == 0 {
+		return fmt.Errorf("Listen address %q isn't present on this server, which has no usable address of that family", listenAddress.String())
+	}
+
+	return fmt.Errorf("Listen address %q isn't present on this server, available addresses are: %s", listenAddress.String(), strings.Join(available, ", "))
+}
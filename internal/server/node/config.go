@@ -3,6 +3,7 @@ package node
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/lxc/incus/v6/internal/ports"
 	"github.com/lxc/incus/v6/internal/server/config"
@@ -100,6 +101,16 @@ func (c *Config) StorageBucketsAddress() string {
 	return objectAddress
 }
 
+// LocalPorts returns the first and last ports of the range to bind the local listeners to, zero if unset.
+func (c *Config) LocalPorts() (int, int) {
+	first, last, err := ports.ParseRange(c.m.GetString("core.local_ports"))
+	if err != nil {
+		return 0, 0
+	}
+
+	return first, last
+}
+
 // StorageBackupsVolume returns the name of the pool/volume to use for storing backup tarballs.
 func (c *Config) StorageBackupsVolume() string {
 	return c.m.GetString("storage.backups_volume")
@@ -110,6 +121,20 @@ func (c *Config) StorageImagesVolume() string {
 	return c.m.GetString("storage.images_volume")
 }
 
+// StorageBackgroundPriority returns the priority of the commands doing background storage work.
+func (c *Config) StorageBackgroundPriority() string {
+	return c.m.GetString("storage.background_priority")
+}
+
+// StorageBackgroundCgroup returns the cgroup to place the commands doing background storage work in, along with
+// the CPU and IO weights to set on it, zero if unset.
+func (c *Config) StorageBackgroundCgroup() (string, int64, int64) {
+	cpuWeight, _ := strconv.ParseInt(c.m.GetString("storage.background_cpu_weight"), 10, 64)
+	ioWeight, _ := strconv.ParseInt(c.m.GetString("storage.background_io_weight"), 10, 64)
+
+	return c.m.GetString("storage.background_cgroup"), cpuWeight, ioWeight
+}
+
 // SyslogSocket returns true if the syslog socket is enabled, otherwise false.
 func (c *Config) SyslogSocket() bool {
 	return c.m.GetBool("core.syslog_socket")
@@ -150,17 +175,35 @@ func (c *Config) update(values map[string]string) (map[string]string, error) {
 	return changed, nil
 }
 
+// AppArmorEnforcement returns how the tools run by the server are confined by AppArmor.
+func (c *Config) AppArmorEnforcement() string {
+	return c.m.GetString("apparmor.enforcement")
+}
+
+// AppArmorLogDenials returns whether AppArmor denials in the generated tool profiles are logged.
+func (c *Config) AppArmorLogDenials() bool {
+	return c.m.GetBool("apparmor.log_denials")
+}
+
+// AppArmorRawRules returns the extra rules to add to the generated AppArmor profiles of the given kind.
+func (c *Config) AppArmorRawRules(kind string) string {
+	return c.m.GetString("apparmor.raw." + kind)
+}
+
 // ConfigSchema defines available server configuration keys.
 var ConfigSchema = config.Schema{
 	// Network address for this server
 
 	// gendoc:generate(entity=server, group=core, key=core.https_address)
 	// See {ref}`server-expose`.
+	// The address may also refer to a network interface, as in `%bond0:8443`, to bind to its current global address.
+	// IPv6 is preferred unless the family is specified, as in `%bond0/4:8443`. The address is looked up again when the
+	// daemon receives `SIGHUP`.
 	// ---
 	//  type: string
 	//  scope: local
 	//  shortdesc: Address to bind for the remote API (HTTPS)
-	"core.https_address": {Validator: validate.Optional(validate.IsListenAddress(true, true, false))},
+	"core.https_address": {Validator: validate.Optional(isListenAddress)},
 
 	// Network address for cluster communication
 
@@ -216,21 +259,36 @@ var ConfigSchema = config.Schema{
 
 	// gendoc:generate(entity=server, group=core, key=core.metrics_address)
 	// See {ref}`metrics`.
+	// The address may also refer to a network interface, as for `core.https_address`.
 	// ---
 	//  type: string
 	//  scope: local
 	//  shortdesc: Address to bind the metrics server to (HTTPS)
-	"core.metrics_address": {Validator: validate.Optional(validate.IsListenAddress(true, true, false))},
+	"core.metrics_address": {Validator: validate.Optional(isListenAddress)},
 
 	// Network address for the storage buckets server
 
 	// gendoc:generate(entity=server, group=core, key=core.storage_buckets_address)
 	// See {ref}`howto-storage-buckets`.
+	// The address may also refer to a network interface, as for `core.https_address`.
 	// ---
 	//  type: string
 	//  scope: local
 	//  shortdesc: Address to bind the storage object server to (HTTPS)
-	"core.storage_buckets_address": {Validator: validate.Optional(validate.IsListenAddress(true, true, false))},
+	"core.storage_buckets_address": {Validator: validate.Optional(isListenAddress)},
+
+	// Range of ports for the local listeners
+
+	// gendoc:generate(entity=server, group=core, key=core.local_ports)
+	// Specify the range using the syntax `FIRST-LAST` (for example, `41000-41999`).
+	// The ports are used by the listeners the server sets up for its own needs on the loopback interface,
+	// like the MinIO processes serving the storage buckets of local storage pools.
+	// When unset, the ports are chosen by the kernel.
+	// ---
+	//  type: string
+	//  scope: local
+	//  shortdesc: Range of ports to bind the local listeners to
+	"core.local_ports": {Validator: validate.Optional(isPortRange)},
 
 	// Syslog socket
 
@@ -259,4 +317,114 @@ var ConfigSchema = config.Schema{
 	//  scope: local
 	//  shortdesc: Volume to use to store the image tarballs
 	"storage.images_volume": {},
+
+	// gendoc:generate(entity=server, group=miscellaneous, key=storage.background_priority)
+	// Possible values are `normal`, `low` (lower CPU and best-effort IO priority) and `idle` (lowest CPU priority
+	// and IO only when the disks are otherwise idle).
+	// This applies to the bulk storage work like migrations, backups and image compression, not to the instances.
+	// ---
+	//  type: string
+	//  scope: local
+	//  defaultdesc: `normal`
+	//  shortdesc: Priority of the background storage work
+	"storage.background_priority": {Validator: validate.Optional(validate.IsOneOf("normal", "low", "idle")), Default: "normal"},
+
+	// gendoc:generate(entity=server, group=miscellaneous, key=storage.background_cgroup)
+	// Path of a cgroup, relative to the root of the cgroup hierarchy, to place the background storage work in.
+	// It's created if missing. This requires a pure cgroup v2 layout and is ignored otherwise.
+	// ---
+	//  type: string
+	//  scope: local
+	//  shortdesc: Cgroup to run the background storage work in
+	"storage.background_cgroup": {},
+
+	// gendoc:generate(entity=server, group=miscellaneous, key=storage.background_cpu_weight)
+	// Value of `cpu.weight` for `storage.background_cgroup`, from 1 to 10000 (100 being the default for other cgroups).
+	// The `cpu` controller must be enabled in the parent cgroup.
+	// ---
+	//  type: integer
+	//  scope: local
+	//  shortdesc: CPU weight of the background storage work
+	"storage.background_cpu_weight": {Validator: validate.Optional(validate.IsInRange(1, 10000))},
+
+	// gendoc:generate(entity=server, group=miscellaneous, key=storage.background_io_weight)
+	// Value of `io.weight` for `storage.background_cgroup`, from 1 to 10000 (100 being the default for other cgroups).
+	// The `io` controller must be enabled in the parent cgroup.
+	// ---
+	//  type: integer
+	//  scope: local
+	//  shortdesc: IO weight of the background storage work
+	"storage.background_io_weight": {Validator: validate.Optional(validate.IsInRange(1, 10000))},
+
+	// AppArmor confinement of the tools run by the server
+
+	// gendoc:generate(entity=server, group=apparmor, key=apparmor.enforcement)
+	// Possible values are `required` (failing the operation when a tool can't be confined),
+	// `best-effort` (logging a warning and running the tool unconfined when its profile fails to load)
+	// and `disabled` (running the tools unconfined without generating profiles).
+	// This applies to the tools run by the server (`rsync`, the Ceph tools, `qemu-img`, ...), not to the instances.
+	// ---
+	//  type: string
+	//  scope: local
+	//  defaultdesc: `required`
+	//  shortdesc: How the tools run by the server are confined by AppArmor
+	"apparmor.enforcement": {Validator: validate.Optional(validate.IsOneOf("required", "best-effort", "disabled")), Default: "required"},
+
+	// gendoc:generate(entity=server, group=apparmor, key=apparmor.log_denials)
+	// When enabled, the server follows the audit log and logs the AppArmor denials in the profiles
+	// confining the tools it runs, attaching them to the operation running at the time when there's only one.
+	// This requires the `CAP_AUDIT_READ` capability and is rate limited, as parsing the audit events has a cost.
+	// ---
+	//  type: bool
+	//  scope: local
+	//  defaultdesc: `false`
+	//  shortdesc: Whether to log AppArmor denials in the tool profiles
+	"apparmor.log_denials": {Validator: validate.Optional(validate.IsBool), Type: config.Bool},
+
+	// Extra AppArmor rules for the generated tool profiles
+
+	// gendoc:generate(entity=server, group=apparmor, key=apparmor.raw.rsync)
+	// The rules are added verbatim to the AppArmor profile confining `rsync` and must parse with `apparmor_parser`.
+	// This is meant to work around local denials (unusual libraries, `LD_PRELOAD` shims) and is unsupported,
+	// as the rules can weaken the confinement and aren't checked against the rest of the profile.
+	// ---
+	//  type: blob
+	//  scope: local
+	//  shortdesc: Extra AppArmor rules for the `rsync` profile (unsupported)
+	"apparmor.raw.rsync": {},
+
+	// gendoc:generate(entity=server, group=apparmor, key=apparmor.raw.ceph)
+	// The rules are added verbatim to the AppArmor profile confining the Ceph tools and must parse with `apparmor_parser`.
+	// This is meant to work around local denials and is unsupported,
+	// as the rules can weaken the confinement and aren't checked against the rest of the profile.
+	// ---
+	//  type: blob
+	//  scope: local
+	//  shortdesc: Extra AppArmor rules for the Ceph tools profile (unsupported)
+	"apparmor.raw.ceph": {},
+
+	// gendoc:generate(entity=server, group=apparmor, key=apparmor.raw.qemu_img)
+	// The rules are added verbatim to the AppArmor profile confining `qemu-img` and must parse with `apparmor_parser`.
+	// This is meant to work around local denials and is unsupported,
+	// as the rules can weaken the confinement and aren't checked against the rest of the profile.
+	// ---
+	//  type: blob
+	//  scope: local
+	//  shortdesc: Extra AppArmor rules for the `qemu-img` profile (unsupported)
+	"apparmor.raw.qemu_img": {},
+}
+
+// isListenAddress validates a listen address, which may also refer to a network interface as in "%bond0:8443".
+func isListenAddress(value string) error {
+	if internalUtil.IsInterfaceAddress(value) {
+		return internalUtil.ValidateInterfaceAddress(value)
+	}
+
+	return validate.IsListenAddress(true, true, false)(value)
+}
+
+// isPortRange validates a range of ports of the form "<first>-<last>".
+func isPortRange(value string) error {
+	_, _, err := ports.ParseRange(value)
+	return err
 }
@@ -97,6 +97,49 @@ func TestConfig_PatchKeepsValues(t *testing.T) {
 	assert.Equal(t, map[string]string{"core.https_address": "127.0.0.1:666"}, values)
 }
 
+// Listen addresses may refer to a network interface.
+func TestConfig_InterfaceAddress(t *testing.T) {
+	tx, cleanup := db.NewTestNodeTx(t)
+	defer cleanup()
+
+	config, err := node.ConfigLoad(context.Background(), tx)
+	require.NoError(t, err)
+
+	_, err = config.Patch(map[string]string{"core.https_address": "%bond0/4"})
+	require.NoError(t, err)
+	assert.Equal(t, "%bond0/4:8443", config.HTTPSAddress())
+
+	_, err = config.Patch(map[string]string{"core.metrics_address": "%bond0:9100"})
+	require.NoError(t, err)
+	assert.Equal(t, "%bond0:9100", config.MetricsAddress())
+
+	_, err = config.Patch(map[string]string{"core.https_address": "%bond0/5"})
+	assert.Error(t, err)
+
+	_, err = config.Patch(map[string]string{"cluster.https_address": "%bond0:8443"})
+	assert.Error(t, err)
+}
+
+func TestConfig_LocalPorts(t *testing.T) {
+	tx, cleanup := db.NewTestNodeTx(t)
+	defer cleanup()
+
+	config, err := node.ConfigLoad(context.Background(), tx)
+	require.NoError(t, err)
+
+	first, last := config.LocalPorts()
+	assert.Equal(t, [2]int{0, 0}, [2]int{first, last})
+
+	_, err = config.Patch(map[string]string{"core.local_ports": "41000-41999"})
+	require.NoError(t, err)
+
+	first, last = config.LocalPorts()
+	assert.Equal(t, [2]int{41000, 41999}, [2]int{first, last})
+
+	_, err = config.Patch(map[string]string{"core.local_ports": "41999-41000"})
+	assert.Error(t, err)
+}
+
 // The core.https_address config key is fetched from the db with a new
 // transaction.
 func TestHTTPSAddress(t *testing.T) {
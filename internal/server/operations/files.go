@@ -0,0 +1,132 @@
+package operations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// FileExpiry is how long a file attached to an operation is kept around when not downloaded.
+var FileExpiry = time.Hour
+
+// File is a file attached to an operation, to be downloaded once by the client.
+type File struct {
+	Name   string
+	Path   string
+	Size   int64
+	SHA256 string
+
+	expiry *time.Timer
+}
+
+var filesLock sync.Mutex
+var files = map[string]map[string]*File{}
+
+// AddFile attaches the file at path to the operation under name, for the client to download it.
+// The file is deleted once downloaded or after FileExpiry. Its SHA256 checksum is computed
+// unless provided.
+func (op *Operation) AddFile(name string, path string, checksum string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("Operation file %q isn't a regular file", path)
+	}
+
+	if checksum == "" {
+		checksum, err = fileChecksum(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	file := &File{
+		Name:   name,
+		Path:   path,
+		Size:   fi.Size(),
+		SHA256: checksum,
+	}
+
+	filesLock.Lock()
+	defer filesLock.Unlock()
+
+	_, ok := files[op.id][name]
+	if ok {
+		return fmt.Errorf("Operation file %q already exists", name)
+	}
+
+	if files[op.id] == nil {
+		files[op.id] = map[string]*File{}
+	}
+
+	files[op.id][name] = file
+
+	opID := op.id
+	file.expiry = time.AfterFunc(FileExpiry, func() {
+		expired, err := TakeFile(opID, name)
+		if err != nil {
+			return
+		}
+
+		expired.Remove()
+	})
+
+	return nil
+}
+
+// TakeFile returns the named file attached to the operation, no longer making it available.
+// The caller is responsible for calling Remove once done with it.
+func TakeFile(opID string, name string) (*File, error) {
+	filesLock.Lock()
+	defer filesLock.Unlock()
+
+	file, ok := files[opID][name]
+	if !ok {
+		return nil, api.StatusErrorf(http.StatusNotFound, "Operation file not found")
+	}
+
+	file.expiry.Stop()
+
+	delete(files[opID], name)
+	if len(files[opID]) == 0 {
+		delete(files, opID)
+	}
+
+	return file, nil
+}
+
+// Remove deletes the file from disk.
+func (f *File) Remove() {
+	err := os.Remove(f.Path)
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warn("Failed to remove operation file", logger.Ctx{"path": f.Path, "err": err})
+	}
+}
+
+// fileChecksum returns the hex encoded SHA256 checksum of the file at path.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
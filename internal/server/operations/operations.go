@@ -650,6 +650,11 @@ func (op *Operation) URL() string {
 	return op.url
 }
 
+// Context returns a context which is done once the operation is done, including when it gets cancelled.
+func (op *Operation) Context() context.Context {
+	return op.finished
+}
+
 // Resources returns the operation resources.
 func (op *Operation) Resources() map[string][]api.URL {
 	return op.resources
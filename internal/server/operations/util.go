@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strconv"
 
+	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/units"
 )
 
@@ -57,3 +58,45 @@ func SetProgressMetadata(metadata map[string]any, stage, displayPrefix string, p
 		metadata[stage+"_progress"] = fmt.Sprintf("%s: %s/s", displayPrefix, units.GetByteSizeString(speed, 2))
 	}
 }
+
+// SetOperationProgress updates an operation metadata map with the provided structured progress.
+// When item is set, the progress is that of one item of a multi-item transfer and the overall
+// progress sums up the progress of all the items, described as the latest one.
+func SetOperationProgress(metadata map[string]any, item string, progress api.OperationProgress) {
+	if item == "" {
+		metadata[api.OperationProgressKey] = progress
+		return
+	}
+
+	// Don't modify the items in place as the previous metadata may still be rendered.
+	previous, _ := metadata[api.OperationProgressKey].(api.OperationProgress)
+	items := make(map[string]api.OperationProgress, len(previous.Items)+1)
+	for name, itemProgress := range previous.Items {
+		items[name] = itemProgress
+	}
+
+	items[item] = progress
+
+	overall := api.OperationProgress{
+		Stage:       progress.Stage,
+		Description: progress.Description,
+		Speed:       progress.Speed,
+		Items:       items,
+	}
+
+	totalKnown := true
+	for _, itemProgress := range items {
+		overall.Processed += itemProgress.Processed
+		overall.Total += itemProgress.Total
+
+		if itemProgress.Total <= 0 {
+			totalKnown = false
+		}
+	}
+
+	if !totalKnown {
+		overall.Total = 0
+	}
+
+	metadata[api.OperationProgressKey] = overall
+}
@@ -446,7 +446,12 @@ func (r *fileResponse) Render(w http.ResponseWriter) error {
 		}
 
 		w.Header().Set("Content-Type", "application/octet-stream")
-		w.Header().Set("Content-Length", fmt.Sprintf("%d", sz))
+
+		// Ranged requests get their length from ServeContent.
+		if r.req.Header.Get("Range") == "" {
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", sz))
+		}
+
 		w.Header().Set("Content-Disposition", fmt.Sprintf("inline;filename=%s", r.files[0].Filename))
 
 		http.ServeContent(w, r.req, r.files[0].Filename, mt, rs)
@@ -524,7 +529,7 @@ func (r *forwardedResponse) Render(w http.ResponseWriter) error {
 	}
 
 	url := fmt.Sprintf("%s%s", info.Addresses[0], r.request.URL.RequestURI())
-	forwarded, err := http.NewRequest(r.request.Method, url, r.request.Body)
+	forwarded, err := http.NewRequestWithContext(r.request.Context(), r.request.Method, url, r.request.Body)
 	if err != nil {
 		return err
 	}
@@ -543,6 +548,8 @@ func (r *forwardedResponse) Render(w http.ResponseWriter) error {
 		return err
 	}
 
+	defer func() { _ = response.Body.Close() }()
+
 	for key := range response.Header {
 		w.Header().Set(key, response.Header.Get(key))
 	}
@@ -551,10 +558,29 @@ func (r *forwardedResponse) Render(w http.ResponseWriter) error {
 		w.WriteHeader(response.StatusCode)
 	}
 
+	// Pass streamed content along as it comes.
+	flusher, ok := w.(http.Flusher)
+	if ok && response.ContentLength < 0 {
+		_, err = io.Copy(flushWriter{w: w, flusher: flusher}, response.Body)
+		return err
+	}
+
 	_, err = io.Copy(w, response.Body)
 	return err
 }
 
+// flushWriter flushes the response after every write.
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.flusher.Flush()
+	return n, err
+}
+
 func (r *forwardedResponse) String() string {
 	return fmt.Sprintf("request to %s", r.request.URL)
 }
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/lxc/incus/v6/internal/ports"
 	"github.com/lxc/incus/v6/internal/server/auth"
 	"github.com/lxc/incus/v6/internal/server/bgp"
 	clusterConfig "github.com/lxc/incus/v6/internal/server/cluster/config"
@@ -21,6 +22,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/network/ovn"
 	"github.com/lxc/incus/v6/internal/server/node"
 	"github.com/lxc/incus/v6/internal/server/sys"
+	"github.com/lxc/incus/v6/shared/subprocess"
 	localtls "github.com/lxc/incus/v6/shared/tls"
 )
 
@@ -40,6 +42,9 @@ type State struct {
 	// DNS server
 	DNS *dns.Server
 
+	// Helper process supervisor
+	Supervisor *subprocess.Supervisor
+
 	// OS access
 	OS    *sys.OS
 	Proxy func(req *http.Request) (*url.URL, error)
@@ -85,4 +90,7 @@ type State struct {
 	// OVN.
 	OVNNB *ovn.NB
 	OVNSB *ovn.SB
+
+	// Ports of the local listeners.
+	LocalPorts *ports.Allocator
 }
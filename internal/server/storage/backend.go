@@ -1684,11 +1684,19 @@ func (b *backend) imageFiller(fingerprint string, op *operations.Operation) func
 		var tracker *ioprogress.ProgressTracker
 		if op != nil { // Not passed when being done as part of pre-migration setup.
 			metadata := make(map[string]any)
-			tracker = &ioprogress.ProgressTracker{
-				Handler: func(percent, speed int64) {
-					operations.SetProgressMetadata(metadata, "create_instance_from_image_unpack", "Unpack", percent, 0, speed)
-					_ = op.UpdateMetadata(metadata)
-				}}
+			tracker = &ioprogress.ProgressTracker{}
+			tracker.Handler = func(percent, speed int64) {
+				operations.SetProgressMetadata(metadata, "create_instance_from_image_unpack", "Unpack", percent, 0, speed)
+				operations.SetOperationProgress(metadata, "", api.OperationProgress{
+					Stage:       "create_instance_from_image_unpack",
+					Description: "Unpack",
+					Processed:   tracker.Processed(),
+					Total:       tracker.Length,
+					Speed:       speed,
+				})
+
+				_ = op.UpdateMetadata(metadata)
+			}
 		}
 
 		imageFile := internalUtil.VarPath("images", fingerprint)
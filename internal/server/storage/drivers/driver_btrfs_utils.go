@@ -371,7 +371,7 @@ func (d *btrfs) sendSubvolume(path string, parent string, conn io.ReadWriteClose
 	cmd.Stdout = stdout
 
 	// Run the command.
-	err = cmd.Start()
+	err = subprocess.StartWithPriority(cmd, subprocess.BackgroundPriority())
 	if err != nil {
 		return err
 	}
@@ -618,7 +618,7 @@ func (d *btrfs) receiveSubVolume(r io.Reader, receivePath string, tracker *iopro
 		}
 	}
 
-	err = subprocess.RunCommandWithFds(context.TODO(), stdin, nil, "btrfs", "receive", "-e", receivePath)
+	_, _, err = subprocess.RunCommandOutput(context.TODO(), "btrfs", []string{"receive", "-e", receivePath}, subprocess.WithStdin(stdin), subprocess.WithBackgroundPriority())
 	if err != nil {
 		return "", err
 	}
@@ -1,9 +1,9 @@
 package drivers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -26,6 +26,9 @@ var cephLoaded bool
 
 type ceph struct {
 	common
+
+	// runner is used to run the ceph and rbd commands, defaults to running them as subprocesses.
+	runner cephCommandRunner
 }
 
 // load is used to run one-time action per-driver rather than per-pool.
@@ -54,7 +57,7 @@ func (d *ceph) load() error {
 
 	// Detect and record the version.
 	if cephVersion == "" {
-		out, err := subprocess.RunCommand("rbd", "--version")
+		out, err := d.queryCommand("rbd", "--version")
 		if err != nil {
 			return err
 		}
@@ -160,14 +163,17 @@ func (d *ceph) Create() error {
 
 	if !poolExists {
 		// Create new osd pool.
-		_, err := subprocess.TryRunCommand("ceph",
-			"--name", fmt.Sprintf("client.%s", d.config["ceph.user.name"]),
-			"--cluster", d.config["ceph.cluster_name"],
-			"osd",
-			"pool",
-			"create",
-			d.config["ceph.osd.pool_name"],
-			d.config["ceph.osd.pg_num"])
+		_, err := subprocess.TryWithPolicy(context.Background(), subprocess.DefaultRetryPolicy, func(ctx context.Context) (string, error) {
+			return d.runCommandContext(ctx,
+				"ceph",
+				"--name", fmt.Sprintf("client.%s", d.config["ceph.user.name"]),
+				"--cluster", d.config["ceph.cluster_name"],
+				"osd",
+				"pool",
+				"create",
+				d.config["ceph.osd.pool_name"],
+				d.config["ceph.osd.pg_num"])
+		})
 		if err != nil {
 			return err
 		}
@@ -175,12 +181,15 @@ func (d *ceph) Create() error {
 		revert.Add(func() { _ = d.osdDeletePool() })
 
 		// Initialize the pool. This is not necessary but allows the pool to be monitored.
-		_, err = subprocess.TryRunCommand("rbd",
-			"--id", d.config["ceph.user.name"],
-			"--cluster", d.config["ceph.cluster_name"],
-			"pool",
-			"init",
-			d.config["ceph.osd.pool_name"])
+		_, err = subprocess.TryWithPolicy(context.Background(), subprocess.DefaultRetryPolicy, func(ctx context.Context) (string, error) {
+			return d.runCommandContext(ctx,
+				"rbd",
+				"--id", d.config["ceph.user.name"],
+				"--cluster", d.config["ceph.cluster_name"],
+				"pool",
+				"init",
+				d.config["ceph.osd.pool_name"])
+		})
 		if err != nil {
 			d.logger.Warn("Failed to initialize pool", logger.Ctx{"pool": d.config["ceph.osd.pool_name"], "cluster": d.config["ceph.cluster_name"]})
 		}
@@ -219,7 +228,7 @@ func (d *ceph) Create() error {
 		}
 
 		// Use existing OSD pool.
-		msg, err := subprocess.RunCommand("ceph",
+		msg, err := d.queryCommand("ceph",
 			"--name", fmt.Sprintf("client.%s", d.config["ceph.user.name"]),
 			"--cluster", d.config["ceph.cluster_name"],
 			"osd",
@@ -334,15 +343,19 @@ func (d *ceph) Unmount() (bool, error) {
 
 // GetResources returns the pool resource usage information.
 func (d *ceph) GetResources() (*api.ResourcesStoragePool, error) {
-	var stdout bytes.Buffer
-
-	err := subprocess.RunCommandWithFds(context.TODO(), nil, &stdout,
+	stdout, err := d.statusCommand(
 		"ceph",
 		"--name", fmt.Sprintf("client.%s", d.config["ceph.user.name"]),
 		"--cluster", d.config["ceph.cluster_name"],
 		"df",
 		"-f", "json")
 	if err != nil {
+		// The error carries what the command wrote to stderr, like the monitors it failed to reach. The usage
+		// can't be told from a partial JSON document, so only report why it's missing.
+		if errors.Is(err, subprocess.ErrInactivityTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			return &api.ResourcesStoragePool{Degraded: fmt.Sprintf("Ceph cluster %q isn't responding: %v", d.config["ceph.cluster_name"], err)}, nil
+		}
+
 		return nil, err
 	}
 
@@ -363,7 +376,7 @@ func (d *ceph) GetResources() (*api.ResourcesStoragePool, error) {
 
 	// Parse the JSON output.
 	df := cephDf{}
-	err = json.NewDecoder(&stdout).Decode(&df)
+	err = json.Unmarshal([]byte(stdout), &df)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,637 @@
+package drivers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/subprocess"
+)
+
+// fakeCephError mimics the exit status of a failed ceph or rbd command.
+type fakeCephError struct {
+	code int
+	msg  string
+}
+
+func (e fakeCephError) Error() string {
+	return fmt.Sprintf("%s: exit status %d", e.msg, e.code)
+}
+
+// ExitCode returns the simulated exit status.
+func (e fakeCephError) ExitCode() int {
+	return e.code
+}
+
+// Exit codes returned by the rbd tool.
+const (
+	fakeCephENOENT    = 2
+	fakeCephEBUSY     = 16
+	fakeCephEEXIST    = 17
+	fakeCephEINVAL    = 22
+	fakeCephENOTEMPTY = 39
+)
+
+type fakeRBDSnapshot struct {
+	name      string
+	protected bool
+	mapped    bool
+}
+
+type fakeRBDImage struct {
+	snapshots []*fakeRBDSnapshot
+	parent    string
+	mapped    bool
+}
+
+// fakeCephRunner simulates a single OSD pool in memory and answers the subset of
+// ceph and rbd commands used by the driver.
+type fakeCephRunner struct {
+	pool    string
+	images  map[string]*fakeRBDImage
+	devices int
+
+	// wedged makes the ceph commands hang like they do when the cluster can't be reached, so that they only
+	// return when killed for not writing anything.
+	wedged bool
+}
+
+func newFakeCephRunner(pool string) *fakeCephRunner {
+	return &fakeCephRunner{
+		pool:   pool,
+		images: map[string]*fakeRBDImage{},
+	}
+}
+
+// newFakeCephDriver returns a ceph driver backed by a fake command runner.
+func newFakeCephDriver() (*ceph, *fakeCephRunner) {
+	runner := newFakeCephRunner("testosdpool")
+
+	d := &ceph{
+		common: common{
+			name: "testpool",
+			config: map[string]string{
+				"ceph.cluster_name":  CephDefaultCluster,
+				"ceph.osd.pool_name": runner.pool,
+				"ceph.user.name":     CephDefaultUser,
+			},
+			logger: logger.AddContext(nil),
+		},
+		runner: runner,
+	}
+
+	return d, runner
+}
+
+// RunCommandContext implements cephCommandRunner.
+func (f *fakeCephRunner) RunCommandContext(ctx context.Context, inactivityTimeout time.Duration, name string, arg ...string) (string, error) {
+	if f.wedged && name == "ceph" {
+		if inactivityTimeout == 0 {
+			return "", fmt.Errorf("Command %q would hang without an inactivity timeout", arg)
+		}
+
+		return "", fmt.Errorf("%w (no output for %s): %w", subprocess.ErrInactivityTimeout, inactivityTimeout, fakeCephError{code: -1, msg: "signal: killed"})
+	}
+
+	flags := map[string]string{}
+	args := []string{}
+	for i := 0; i < len(arg); i++ {
+		switch arg[i] {
+		case "--id", "--name", "--cluster", "--pool", "--image-feature", "--data-pool", "--size", "--snap", "--image", "--format":
+			if i+1 < len(arg) {
+				flags[arg[i]] = arg[i+1]
+				i++
+			}
+
+		case "--allow-shrink", "--yes-i-really-really-mean-it":
+
+		default:
+			args = append(args, arg[i])
+		}
+	}
+
+	switch name {
+	case "ceph":
+		return f.ceph(args)
+	case "rbd":
+		return f.rbd(flags, args)
+	}
+
+	return "", fakeCephError{code: fakeCephENOENT, msg: fmt.Sprintf("Unknown command %q", name)}
+}
+
+func (f *fakeCephRunner) ceph(args []string) (string, error) {
+	if len(args) == 5 && args[0] == "osd" && args[1] == "pool" && args[2] == "get" {
+		if args[3] != f.pool {
+			return "", fakeCephError{code: fakeCephENOENT, msg: fmt.Sprintf("pool '%s' does not exist", args[3])}
+		}
+
+		return fmt.Sprintf("%s: 32\n", args[4]), nil
+	}
+
+	if len(args) == 3 && args[0] == "df" && args[1] == "-f" && args[2] == "json" {
+		return fmt.Sprintf(`{"pools": [{"name": %q, "stats": {"bytes_used": 1024, "max_avail": 3072}}]}`, f.pool), nil
+	}
+
+	return "", fakeCephError{code: fakeCephEINVAL, msg: fmt.Sprintf("Unsupported ceph command %q", args)}
+}
+
+func (f *fakeCephRunner) rbd(flags map[string]string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fakeCephError{code: fakeCephEINVAL, msg: "Missing rbd command"}
+	}
+
+	switch args[0] {
+	case "--version":
+		return "ceph version 18.2.0 (5dd24139a1eada541a3bc16b6941c5dde975e26d) reef (stable)\n", nil
+	case "create":
+		return "", f.create(args[1:])
+	case "rm":
+		return "", f.remove(args[1:])
+	case "map":
+		return f.mapImage(args[1:])
+	case "unmap":
+		return "", f.unmap(args[1:])
+	case "clone":
+		return "", f.clone(args[1:])
+	case "children":
+		return f.children(flags)
+	case "mv":
+		return "", f.move(args[1:])
+	case "info":
+		return f.info(args[1:])
+	case "snap":
+		if len(args) < 2 {
+			return "", fakeCephError{code: fakeCephEINVAL, msg: "Missing rbd snap command"}
+		}
+
+		switch args[1] {
+		case "create":
+			return "", f.snapCreate(flags, args[2:])
+		case "protect":
+			return "", f.snapProtect(flags, args[2:], true)
+		case "unprotect":
+			return "", f.snapProtect(flags, args[2:], false)
+		case "rm":
+			return "", f.snapRemove(args[2:])
+		case "rename":
+			return "", f.snapRename(args[2:])
+		case "ls":
+			return f.snapList(args[2:])
+		case "purge":
+			return "", f.snapPurge(args[2:])
+		}
+	}
+
+	return "", fakeCephError{code: fakeCephEINVAL, msg: fmt.Sprintf("Unsupported rbd command %q", args)}
+}
+
+// parseName splits "[<pool>/]<image>[@<snapshot>]" into its image and snapshot parts.
+func (f *fakeCephRunner) parseName(args []string) (string, string, error) {
+	if len(args) != 1 {
+		return "", "", fakeCephError{code: fakeCephEINVAL, msg: fmt.Sprintf("Expected a single image name, got %q", args)}
+	}
+
+	name := args[0]
+
+	pool, image, found := strings.Cut(name, "/")
+	if found {
+		if pool != f.pool {
+			return "", "", fakeCephError{code: fakeCephENOENT, msg: fmt.Sprintf("pool '%s' does not exist", pool)}
+		}
+
+		name = image
+	}
+
+	image, snapshot, _ := strings.Cut(name, "@")
+
+	return image, snapshot, nil
+}
+
+// lookup returns the named image and, if requested, its snapshot.
+func (f *fakeCephRunner) lookup(image string, snapshot string) (*fakeRBDImage, *fakeRBDSnapshot, error) {
+	img, ok := f.images[image]
+	if !ok {
+		return nil, nil, fakeCephError{code: fakeCephENOENT, msg: fmt.Sprintf("image %q not found", image)}
+	}
+
+	if snapshot == "" {
+		return img, nil, nil
+	}
+
+	for _, snap := range img.snapshots {
+		if snap.name == snapshot {
+			return img, snap, nil
+		}
+	}
+
+	return nil, nil, fakeCephError{code: fakeCephENOENT, msg: fmt.Sprintf("snapshot %q not found", snapshot)}
+}
+
+// childrenOf returns the fully qualified names of the clones of a snapshot.
+func (f *fakeCephRunner) childrenOf(image string, snapshot string) []string {
+	parent := fmt.Sprintf("%s/%s@%s", f.pool, image, snapshot)
+
+	children := []string{}
+	for name, img := range f.images {
+		if img.parent == parent {
+			children = append(children, fmt.Sprintf("%s/%s", f.pool, name))
+		}
+	}
+
+	sort.Strings(children)
+
+	return children
+}
+
+func (f *fakeCephRunner) create(args []string) error {
+	image, _, err := f.parseName(args)
+	if err != nil {
+		return err
+	}
+
+	_, ok := f.images[image]
+	if ok {
+		return fakeCephError{code: fakeCephEEXIST, msg: fmt.Sprintf("image %q already exists", image)}
+	}
+
+	f.images[image] = &fakeRBDImage{}
+
+	return nil
+}
+
+func (f *fakeCephRunner) remove(args []string) error {
+	image, _, err := f.parseName(args)
+	if err != nil {
+		return err
+	}
+
+	img, _, err := f.lookup(image, "")
+	if err != nil {
+		return err
+	}
+
+	if len(img.snapshots) > 0 {
+		return fakeCephError{code: fakeCephENOTEMPTY, msg: fmt.Sprintf("image %q has snapshots", image)}
+	}
+
+	if img.mapped {
+		return fakeCephError{code: fakeCephEBUSY, msg: fmt.Sprintf("image %q is mapped", image)}
+	}
+
+	delete(f.images, image)
+
+	return nil
+}
+
+func (f *fakeCephRunner) mapImage(args []string) (string, error) {
+	image, snapshot, err := f.parseName(args)
+	if err != nil {
+		return "", err
+	}
+
+	img, snap, err := f.lookup(image, snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	if snap != nil {
+		snap.mapped = true
+	} else {
+		img.mapped = true
+	}
+
+	devPath := fmt.Sprintf("/dev/rbd%d", f.devices)
+	f.devices++
+
+	return devPath + "\n", nil
+}
+
+func (f *fakeCephRunner) unmap(args []string) error {
+	image, snapshot, err := f.parseName(args)
+	if err != nil {
+		return err
+	}
+
+	img, snap, err := f.lookup(image, snapshot)
+	if err != nil {
+		return fakeCephError{code: fakeCephEINVAL, msg: err.Error()}
+	}
+
+	if snap != nil {
+		if !snap.mapped {
+			return fakeCephError{code: fakeCephEINVAL, msg: "not mapped"}
+		}
+
+		snap.mapped = false
+
+		return nil
+	}
+
+	if !img.mapped {
+		return fakeCephError{code: fakeCephEINVAL, msg: "not mapped"}
+	}
+
+	img.mapped = false
+
+	return nil
+}
+
+func (f *fakeCephRunner) clone(args []string) error {
+	if len(args) != 2 {
+		return fakeCephError{code: fakeCephEINVAL, msg: "Expected source and target"}
+	}
+
+	srcImage, srcSnapshot, err := f.parseName(args[:1])
+	if err != nil {
+		return err
+	}
+
+	_, snap, err := f.lookup(srcImage, srcSnapshot)
+	if err != nil {
+		return err
+	}
+
+	if !snap.protected {
+		return fakeCephError{code: fakeCephEINVAL, msg: "parent snapshot must be protected"}
+	}
+
+	dstImage, _, err := f.parseName(args[1:])
+	if err != nil {
+		return err
+	}
+
+	_, ok := f.images[dstImage]
+	if ok {
+		return fakeCephError{code: fakeCephEEXIST, msg: fmt.Sprintf("image %q already exists", dstImage)}
+	}
+
+	f.images[dstImage] = &fakeRBDImage{parent: fmt.Sprintf("%s/%s@%s", f.pool, srcImage, srcSnapshot)}
+
+	return nil
+}
+
+func (f *fakeCephRunner) children(flags map[string]string) (string, error) {
+	_, _, err := f.lookup(flags["--image"], flags["--snap"])
+	if err != nil {
+		return "", err
+	}
+
+	children := f.childrenOf(flags["--image"], flags["--snap"])
+	if len(children) == 0 {
+		return "", nil
+	}
+
+	return strings.Join(children, "\n") + "\n", nil
+}
+
+func (f *fakeCephRunner) move(args []string) error {
+	if len(args) != 2 {
+		return fakeCephError{code: fakeCephEINVAL, msg: "Expected source and target"}
+	}
+
+	srcImage, _, err := f.parseName(args[:1])
+	if err != nil {
+		return err
+	}
+
+	dstImage, _, err := f.parseName(args[1:])
+	if err != nil {
+		return err
+	}
+
+	img, _, err := f.lookup(srcImage, "")
+	if err != nil {
+		return err
+	}
+
+	_, ok := f.images[dstImage]
+	if ok {
+		return fakeCephError{code: fakeCephEEXIST, msg: fmt.Sprintf("image %q already exists", dstImage)}
+	}
+
+	delete(f.images, srcImage)
+	f.images[dstImage] = img
+
+	// Clones keep pointing at their parent under its new name.
+	oldPrefix := fmt.Sprintf("%s/%s@", f.pool, srcImage)
+	for _, child := range f.images {
+		if strings.HasPrefix(child.parent, oldPrefix) {
+			child.parent = fmt.Sprintf("%s/%s@%s", f.pool, dstImage, strings.TrimPrefix(child.parent, oldPrefix))
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeCephRunner) info(args []string) (string, error) {
+	image, snapshot, err := f.parseName(args)
+	if err != nil {
+		return "", err
+	}
+
+	img, _, err := f.lookup(image, snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "rbd image '%s':\n", image)
+	fmt.Fprintf(&sb, "\tsize 10 GiB in 2560 objects\n")
+	fmt.Fprintf(&sb, "\tformat: 2\n")
+	fmt.Fprintf(&sb, "\tfeatures: layering\n")
+
+	if img.parent != "" {
+		fmt.Fprintf(&sb, "\tparent: %s\n", img.parent)
+		fmt.Fprintf(&sb, "\toverlap: 10 GiB\n")
+	}
+
+	return sb.String(), nil
+}
+
+func (f *fakeCephRunner) snapCreate(flags map[string]string, args []string) error {
+	image, _, err := f.parseName(args)
+	if err != nil {
+		return err
+	}
+
+	img, _, err := f.lookup(image, "")
+	if err != nil {
+		return err
+	}
+
+	_, _, err = f.lookup(image, flags["--snap"])
+	if err == nil {
+		return fakeCephError{code: fakeCephEEXIST, msg: fmt.Sprintf("snapshot %q already exists", flags["--snap"])}
+	}
+
+	img.snapshots = append(img.snapshots, &fakeRBDSnapshot{name: flags["--snap"]})
+
+	return nil
+}
+
+func (f *fakeCephRunner) snapProtect(flags map[string]string, args []string, protect bool) error {
+	image, _, err := f.parseName(args)
+	if err != nil {
+		return err
+	}
+
+	_, snap, err := f.lookup(image, flags["--snap"])
+	if err != nil {
+		return err
+	}
+
+	if protect {
+		if snap.protected {
+			return fakeCephError{code: fakeCephEBUSY, msg: "snapshot is already protected"}
+		}
+
+		snap.protected = true
+
+		return nil
+	}
+
+	if !snap.protected {
+		return fakeCephError{code: fakeCephEINVAL, msg: "snapshot is already unprotected"}
+	}
+
+	if len(f.childrenOf(image, snap.name)) > 0 {
+		return fakeCephError{code: fakeCephEBUSY, msg: "snapshot has clones"}
+	}
+
+	snap.protected = false
+
+	return nil
+}
+
+func (f *fakeCephRunner) snapRemove(args []string) error {
+	image, snapshot, err := f.parseName(args)
+	if err != nil {
+		return err
+	}
+
+	img, snap, err := f.lookup(image, snapshot)
+	if err != nil {
+		return err
+	}
+
+	if snap.protected {
+		return fakeCephError{code: fakeCephEBUSY, msg: "snapshot is protected"}
+	}
+
+	for i, s := range img.snapshots {
+		if s == snap {
+			img.snapshots = append(img.snapshots[:i], img.snapshots[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeCephRunner) snapPurge(args []string) error {
+	image, _, err := f.parseName(args)
+	if err != nil {
+		return err
+	}
+
+	img, _, err := f.lookup(image, "")
+	if err != nil {
+		return err
+	}
+
+	for _, snap := range img.snapshots {
+		if snap.protected {
+			return fakeCephError{code: fakeCephEBUSY, msg: fmt.Sprintf("snapshot %q is protected", snap.name)}
+		}
+	}
+
+	img.snapshots = nil
+
+	return nil
+}
+
+func (f *fakeCephRunner) snapRename(args []string) error {
+	if len(args) != 2 {
+		return fakeCephError{code: fakeCephEINVAL, msg: "Expected source and target"}
+	}
+
+	srcImage, srcSnapshot, err := f.parseName(args[:1])
+	if err != nil {
+		return err
+	}
+
+	dstImage, dstSnapshot, err := f.parseName(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if srcImage != dstImage {
+		return fakeCephError{code: fakeCephEINVAL, msg: "Source and target image must match"}
+	}
+
+	_, snap, err := f.lookup(srcImage, srcSnapshot)
+	if err != nil {
+		return err
+	}
+
+	oldParent := fmt.Sprintf("%s/%s@%s", f.pool, srcImage, srcSnapshot)
+	newParent := fmt.Sprintf("%s/%s@%s", f.pool, dstImage, dstSnapshot)
+	for _, child := range f.images {
+		if child.parent == oldParent {
+			child.parent = newParent
+		}
+	}
+
+	snap.name = dstSnapshot
+
+	return nil
+}
+
+func (f *fakeCephRunner) snapList(args []string) (string, error) {
+	image, _, err := f.parseName(args)
+	if err != nil {
+		return "", err
+	}
+
+	img, _, err := f.lookup(image, "")
+	if err != nil {
+		return "", err
+	}
+
+	type snapEntry struct {
+		ID        int    `json:"id"`
+		Name      string `json:"name"`
+		Size      int64  `json:"size"`
+		Protected string `json:"protected"`
+	}
+
+	entries := []snapEntry{}
+	for i, snap := range img.snapshots {
+		entries = append(entries, snapEntry{ID: i + 1, Name: snap.name, Size: 10737418240, Protected: fmt.Sprintf("%t", snap.protected)})
+	}
+
+	out, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// imageNames returns the sorted names of all images in the pool.
+func (f *fakeCephRunner) imageNames() []string {
+	names := make([]string, 0, len(f.images))
+	for name := range f.images {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
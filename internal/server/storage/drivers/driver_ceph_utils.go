@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,7 +16,7 @@ import (
 
 	"github.com/google/uuid"
 
-	"github.com/lxc/incus/v6/internal/linux"
+	"github.com/lxc/incus/v6/internal/server/apparmor"
 	"github.com/lxc/incus/v6/internal/server/db"
 	"github.com/lxc/incus/v6/internal/server/response"
 	"github.com/lxc/incus/v6/shared/api"
@@ -48,9 +49,118 @@ var cephVolTypePrefixes = map[VolumeType]string{
 	VolumeTypeCustom:    db.StoragePoolVolumeTypeNameCustom,
 }
 
+// cephQueryTimeout bounds the ceph and rbd commands only querying the cluster, which otherwise hang for as long
+// as the cluster can't be reached.
+const cephQueryTimeout = 2 * time.Minute
+
+// cephStatusInactivityTimeout bounds how long the ceph commands reporting on the state of the cluster can go without
+// writing anything. They answer within seconds unless the cluster is wedged, in which case they may never finish.
+const cephStatusInactivityTimeout = 30 * time.Second
+
+// cephCommandRunner runs the ceph and rbd command line tools on behalf of the driver, killing them once they
+// didn't write anything for the inactivity timeout, unless it's zero.
+type cephCommandRunner interface {
+	RunCommandContext(ctx context.Context, inactivityTimeout time.Duration, name string, arg ...string) (string, error)
+}
+
+// cephUnmapRetryPolicy retries unmapping an RBD volume while it's in use (EBUSY), waiting a second between each
+// attempt. Other failures, like EINVAL when it's already unmapped, are returned right away.
+var cephUnmapRetryPolicy = subprocess.RetryPolicy{
+	Attempts: 10,
+	Delay:    time.Second,
+	Retry:    subprocess.RetryOnExitCodes(16),
+}
+
+// cephResizeRetryPolicy retries resizing an RBD volume a couple of times in quick succession, unless the size is
+// invalid (EINVAL) which retrying won't change.
+var cephResizeRetryPolicy = subprocess.RetryPolicy{
+	Attempts:   3,
+	Delay:      250 * time.Millisecond,
+	Multiplier: 2,
+	Jitter:     0.2,
+	Retry: func(err error, exitCode int) bool {
+		return exitCode != 22
+	},
+}
+
+// runCommand runs a ceph or rbd command through the driver's command runner.
+func (d *ceph) runCommand(name string, arg ...string) (string, error) {
+	return d.runCommandContext(context.Background(), name, arg...)
+}
+
+// queryCommand runs a ceph or rbd command only querying the cluster, killing it after cephQueryTimeout.
+func (d *ceph) queryCommand(name string, arg ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cephQueryTimeout)
+	defer cancel()
+
+	return d.runCommandContext(ctx, name, arg...)
+}
+
+// statusCommand runs a ceph command reporting on the state of the cluster, killing it after cephQueryTimeout or
+// once it didn't write anything for cephStatusInactivityTimeout. The output it wrote until then is returned along
+// with the error.
+func (d *ceph) statusCommand(name string, arg ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cephQueryTimeout)
+	defer cancel()
+
+	stdout, err := d.runCommandInactivity(ctx, cephStatusInactivityTimeout, name, arg...)
+	if err != nil && ctx.Err() != nil {
+		return stdout, fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+
+	return stdout, err
+}
+
+// runCommandContext runs a ceph or rbd command through the driver's command runner, killing it once the
+// context is done.
+func (d *ceph) runCommandContext(ctx context.Context, name string, arg ...string) (string, error) {
+	return d.runCommandInactivity(ctx, 0, name, arg...)
+}
+
+// runCommandInactivity runs a ceph or rbd command like runCommandContext, also killing it once it didn't write
+// anything for the inactivity timeout, unless it's zero.
+func (d *ceph) runCommandInactivity(ctx context.Context, inactivityTimeout time.Duration, name string, arg ...string) (string, error) {
+	if d.runner != nil {
+		return d.runner.RunCommandContext(ctx, inactivityTimeout, name, arg...)
+	}
+
+	cmd := exec.Command(name, arg...)
+	cleanup, err := d.confineCommand(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	defer cleanup()
+
+	options := []subprocess.RunOption{subprocess.WithEnv(d.cephEnv()...)}
+	if inactivityTimeout > 0 {
+		options = append(options, subprocess.WithInactivityTimeout(inactivityTimeout))
+	}
+
+	stdout, _, err := subprocess.RunCommandOutput(ctx, cmd.Args[0], cmd.Args[1:], options...)
+
+	return stdout, err
+}
+
+// cephEnv returns the environment variables pointing the ceph and rbd commands at the configuration file of the
+// cluster rather than letting them look for one. They're only set for the commands, not in the daemon's environment.
+func (d *ceph) cephEnv() []string {
+	return []string{fmt.Sprintf("CEPH_CONF=%s", cephConfigPath(d.config["ceph.cluster_name"]))}
+}
+
+// confineCommand wraps a ceph or rbd command so it runs under its AppArmor profile.
+// The returned function must be called once the command is done.
+func (d *ceph) confineCommand(cmd *exec.Cmd) (func(), error) {
+	if d.state == nil {
+		return func() {}, nil
+	}
+
+	return apparmor.CephWrapper(d.state.OS, cmd, d.config["ceph.cluster_name"], "")
+}
+
 // osdPoolExists checks whether a given OSD pool exists.
 func (d *ceph) osdPoolExists() (bool, error) {
-	_, err := subprocess.RunCommand(
+	_, err := d.queryCommand(
 		"ceph",
 		"--name", fmt.Sprintf("client.%s", d.config["ceph.user.name"]),
 		"--cluster", d.config["ceph.cluster_name"],
@@ -61,9 +171,8 @@ func (d *ceph) osdPoolExists() (bool, error) {
 		"size")
 
 	if err != nil {
-		status, _ := linux.ExitStatus(err)
 		// If the error status code is 2, the pool definitely doesn't exist.
-		if status == 2 {
+		if subprocess.ExitedWith(err, 2) {
 			return false, nil
 		}
 
@@ -84,7 +193,7 @@ func (d *ceph) osdPoolExists() (bool, error) {
 //     that this call actually deleted an OSD pool it needs to check for the
 //     existence of the pool first.
 func (d *ceph) osdDeletePool() error {
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"ceph",
 		"--name", fmt.Sprintf("client.%s", d.config["ceph.user.name"]),
 		"--cluster", d.config["ceph.cluster_name"],
@@ -136,7 +245,7 @@ func (d *ceph) rbdCreateVolume(vol Volume, size string) error {
 		"create",
 		d.getRBDVolumeName(vol, "", false, false))
 
-	_, err = subprocess.RunCommand("rbd", cmd...)
+	_, err = d.runCommand("rbd", cmd...)
 	return err
 }
 
@@ -146,7 +255,7 @@ func (d *ceph) rbdCreateVolume(vol Volume, size string) error {
 //     to be sure that this call actually deleted an RBD storage volume it needs
 //     to check for the existence of the pool first.
 func (d *ceph) rbdDeleteVolume(vol Volume) error {
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -165,7 +274,7 @@ func (d *ceph) rbdDeleteVolume(vol Volume) error {
 // in the /dev directory and is therefore necessary in order to mount it.
 func (d *ceph) rbdMapVolume(vol Volume) (string, error) {
 	rbdName := d.getRBDVolumeName(vol, "", false, false)
-	devPath, err := subprocess.RunCommand(
+	devPath, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -190,53 +299,38 @@ func (d *ceph) rbdMapVolume(vol Volume) (string, error) {
 // rbdUnmapVolume unmaps a given RBD storage volume.
 // This is a precondition in order to delete an RBD storage volume can.
 func (d *ceph) rbdUnmapVolume(vol Volume, unmapUntilEINVAL bool) error {
-	busyCount := 0
 	rbdVol := d.getRBDVolumeName(vol, "", false, false)
 
 	ourDeactivate := false
 
-again:
-	_, err := subprocess.RunCommand(
-		"rbd",
-		"--id", d.config["ceph.user.name"],
-		"--cluster", d.config["ceph.cluster_name"],
-		"--pool", d.config["ceph.osd.pool_name"],
-		"unmap",
-		rbdVol)
-	if err != nil {
-		runError, ok := err.(subprocess.RunError)
-		if ok {
-			exitError, ok := runError.Unwrap().(*exec.ExitError)
-			if ok {
-				if exitError.ExitCode() == 22 {
-					// EINVAL (already unmapped).
-					if ourDeactivate {
-						d.logger.Debug("Deactivated RBD volume", logger.Ctx{"volName": rbdVol})
-					}
-
-					return nil
+	for {
+		_, err := subprocess.TryWithPolicy(context.Background(), cephUnmapRetryPolicy, func(ctx context.Context) (string, error) {
+			return d.runCommandContext(ctx,
+				"rbd",
+				"--id", d.config["ceph.user.name"],
+				"--cluster", d.config["ceph.cluster_name"],
+				"--pool", d.config["ceph.osd.pool_name"],
+				"unmap",
+				rbdVol)
+		})
+		if err != nil {
+			if subprocess.ExitedWith(err, 22) {
+				// EINVAL (already unmapped).
+				if ourDeactivate {
+					d.logger.Debug("Deactivated RBD volume", logger.Ctx{"volName": rbdVol})
 				}
 
-				if exitError.ExitCode() == 16 {
-					// EBUSY (currently in use).
-					busyCount++
-					if busyCount == 10 {
-						return err
-					}
-
-					// Wait a second an try again.
-					time.Sleep(time.Second)
-					goto again
-				}
+				return nil
 			}
+
+			return err
 		}
 
-		return err
-	}
+		if !unmapUntilEINVAL {
+			break
+		}
 
-	if unmapUntilEINVAL {
 		ourDeactivate = true
-		goto again
 	}
 
 	d.logger.Debug("Deactivated RBD volume", logger.Ctx{"volName": rbdVol})
@@ -248,7 +342,7 @@ again:
 // This is a precondition in order to delete an RBD snapshot can.
 func (d *ceph) rbdUnmapVolumeSnapshot(vol Volume, snapshotName string, unmapUntilEINVAL bool) error {
 again:
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -256,15 +350,9 @@ again:
 		"unmap",
 		d.getRBDVolumeName(vol, snapshotName, false, false))
 	if err != nil {
-		runError, ok := err.(subprocess.RunError)
-		if ok {
-			exitError, ok := runError.Unwrap().(*exec.ExitError)
-			if ok {
-				if exitError.ExitCode() == 22 {
-					// EINVAL (already unmapped).
-					return nil
-				}
-			}
+		if subprocess.ExitedWith(err, 22) {
+			// EINVAL (already unmapped).
+			return nil
 		}
 
 		return err
@@ -279,7 +367,7 @@ again:
 
 // rbdCreateVolumeSnapshot creates a read-write snapshot of a given RBD storage volume.
 func (d *ceph) rbdCreateVolumeSnapshot(vol Volume, snapshotName string) error {
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -298,7 +386,7 @@ func (d *ceph) rbdCreateVolumeSnapshot(vol Volume, snapshotName string) error {
 // rbdProtectVolumeSnapshot protects a given snapshot from being deleted.
 // This is a precondition to be able to create RBD clones from a given snapshot.
 func (d *ceph) rbdProtectVolumeSnapshot(vol Volume, snapshotName string) error {
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -308,15 +396,9 @@ func (d *ceph) rbdProtectVolumeSnapshot(vol Volume, snapshotName string) error {
 		"--snap", snapshotName,
 		d.getRBDVolumeName(vol, "", false, false))
 	if err != nil {
-		runError, ok := err.(subprocess.RunError)
-		if ok {
-			exitError, ok := runError.Unwrap().(*exec.ExitError)
-			if ok {
-				if exitError.ExitCode() == 16 {
-					// EBUSY (snapshot already protected).
-					return nil
-				}
-			}
+		if subprocess.ExitedWith(err, 16) {
+			// EBUSY (snapshot already protected).
+			return nil
 		}
 
 		return err
@@ -329,7 +411,7 @@ func (d *ceph) rbdProtectVolumeSnapshot(vol Volume, snapshotName string) error {
 // - This is a precondition to be able to delete an RBD snapshot.
 // - This command will only succeed if the snapshot does not have any clones.
 func (d *ceph) rbdUnprotectVolumeSnapshot(vol Volume, snapshotName string) error {
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -339,15 +421,9 @@ func (d *ceph) rbdUnprotectVolumeSnapshot(vol Volume, snapshotName string) error
 		"--snap", snapshotName,
 		d.getRBDVolumeName(vol, "", false, false))
 	if err != nil {
-		runError, ok := err.(subprocess.RunError)
-		if ok {
-			exitError, ok := runError.Unwrap().(*exec.ExitError)
-			if ok {
-				if exitError.ExitCode() == 22 {
-					// EBUSY (snapshot already unprotected).
-					return nil
-				}
-			}
+		if subprocess.ExitedWith(err, 22) {
+			// EBUSY (snapshot already unprotected).
+			return nil
 		}
 
 		return err
@@ -380,7 +456,7 @@ func (d *ceph) rbdCreateClone(sourceVol Volume, sourceSnapshotName string, targe
 		d.getRBDVolumeName(sourceVol, sourceSnapshotName, false, true),
 		d.getRBDVolumeName(targetVol, "", false, true))
 
-	_, err := subprocess.RunCommand("rbd", cmd...)
+	_, err := d.runCommand("rbd", cmd...)
 	if err != nil {
 		return err
 	}
@@ -390,7 +466,7 @@ func (d *ceph) rbdCreateClone(sourceVol Volume, sourceSnapshotName string, targe
 
 // rbdListSnapshotClones list all clones of an RBD snapshot.
 func (d *ceph) rbdListSnapshotClones(vol Volume, snapshotName string) ([]string, error) {
-	msg, err := subprocess.RunCommand(
+	msg, err := d.queryCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -424,7 +500,7 @@ func (d *ceph) rbdMarkVolumeDeleted(vol Volume, newVolumeName string) error {
 	newVol := NewVolume(d, d.name, vol.volType, vol.contentType, newVolumeName, vol.config, vol.poolConfig)
 	deletedName := d.getRBDVolumeName(newVol, "", true, true)
 
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -449,7 +525,7 @@ func (d *ceph) rbdRenameVolume(vol Volume, newVolumeName string) error {
 	// new volume name generated in getRBDVolumeName.
 	newVol := NewVolume(d, d.name, vol.volType, vol.contentType, newVolumeName, vol.config, vol.poolConfig)
 
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -471,7 +547,7 @@ func (d *ceph) rbdRenameVolume(vol Volume, newVolumeName string) error {
 // original name and the caller maps it under its new name the snapshot will be
 // mapped twice. This will prevent it from being deleted.
 func (d *ceph) rbdRenameVolumeSnapshot(vol Volume, oldSnapshotName string, newSnapshotName string) error {
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -494,7 +570,7 @@ func (d *ceph) rbdRenameVolumeSnapshot(vol Volume, oldSnapshotName string, newSn
 //     The caller will usually want to parse this according to its needs. This
 //     helper library provides two small functions to do this but see below.
 func (d *ceph) rbdGetVolumeParent(vol Volume) (string, error) {
-	msg, err := subprocess.RunCommand(
+	msg, err := d.queryCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -528,7 +604,7 @@ func (d *ceph) rbdGetVolumeParent(vol Volume) (string, error) {
 // This requires that the snapshot does not have any clones and is unmapped and
 // unprotected.
 func (d *ceph) rbdDeleteVolumeSnapshot(vol Volume, snapshotName string) error {
-	_, err := subprocess.RunCommand(
+	_, err := d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -550,7 +626,7 @@ func (d *ceph) rbdDeleteVolumeSnapshot(vol Volume, snapshotName string) error {
 // this will only return
 // <rbd-snapshot-name>.
 func (d *ceph) rbdListVolumeSnapshots(vol Volume) ([]string, error) {
-	msg, err := subprocess.RunCommand(
+	msg, err := d.queryCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -595,7 +671,7 @@ func (d *ceph) rbdListVolumeSnapshots(vol Volume) ([]string, error) {
 // copyWithSnapshots creates a non-sparse copy of a container including its snapshots.
 // This does not introduce a dependency relation between the source RBD storage
 // volume and the target RBD storage volume.
-func (d *ceph) copyWithSnapshots(sourceVolumeName string, targetVolumeName string, sourceParentSnapshot string) error {
+func (d *ceph) copyWithSnapshots(ctx context.Context, sourceVolumeName string, targetVolumeName string, sourceParentSnapshot string) error {
 	args := []string{
 		"export-diff",
 		"--id", d.config["ceph.user.name"],
@@ -619,23 +695,20 @@ func (d *ceph) copyWithSnapshots(sourceVolumeName string, targetVolumeName strin
 		"-",
 		targetVolumeName)
 
-	rbdRecvCmd.Stdin, _ = rbdSendCmd.StdoutPipe()
-	rbdRecvCmd.Stdout = os.Stdout
-	rbdRecvCmd.Stderr = os.Stderr
+	for _, cmd := range []*exec.Cmd{rbdSendCmd, rbdRecvCmd} {
+		cmd.Env = append(os.Environ(), d.cephEnv()...)
 
-	err := rbdRecvCmd.Start()
-	if err != nil {
-		return err
-	}
+		cleanup, err := d.confineCommand(cmd)
+		if err != nil {
+			return err
+		}
 
-	err = rbdSendCmd.Run()
-	if err != nil {
-		return err
+		defer cleanup()
 	}
 
-	err = rbdRecvCmd.Wait()
+	err := subprocess.RunPipeline(ctx, rbdSendCmd, rbdRecvCmd)
 	if err != nil {
-		return err
+		return fmt.Errorf("Failed copying RBD volume %q to %q: %w", sourceVolumeName, targetVolumeName, err)
 	}
 
 	return nil
@@ -1154,11 +1227,13 @@ func (d *ceph) sendVolume(conn io.ReadWriteCloser, volumeName string, volumePare
 
 	cmd := exec.Command("rbd", args...)
 
-	stderr, err := cmd.StderrPipe()
+	cleanup, err := d.confineCommand(cmd)
 	if err != nil {
 		return err
 	}
 
+	defer cleanup()
+
 	// Setup progress tracker.
 	var stdout io.WriteCloser = conn
 	if tracker != nil {
@@ -1168,19 +1243,9 @@ func (d *ceph) sendVolume(conn io.ReadWriteCloser, volumeName string, volumePare
 		}
 	}
 
-	cmd.Stdout = stdout
-
-	err = cmd.Start()
+	_, _, err = subprocess.RunCommandOutput(context.TODO(), cmd.Args[0], cmd.Args[1:], subprocess.WithStdout(stdout), subprocess.WithEnv(d.cephEnv()...), subprocess.WithBackgroundPriority())
 	if err != nil {
-		return err
-	}
-
-	output, _ := io.ReadAll(stderr)
-
-	// Handle errors.
-	err = cmd.Wait()
-	if err != nil {
-		return fmt.Errorf("ceph export-diff failed: %w (%s)", err, string(output))
+		return fmt.Errorf("ceph export-diff failed: %w", err)
 	}
 
 	return nil
@@ -1197,48 +1262,16 @@ func (d *ceph) receiveVolume(volumeName string, conn io.ReadWriteCloser, writeWr
 
 	cmd := exec.Command("rbd", args...)
 
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return err
-	}
-
-	stderr, err := cmd.StderrPipe()
+	cleanup, err := d.confineCommand(cmd)
 	if err != nil {
 		return err
 	}
 
-	// Forward input through stdin.
-	chCopyConn := make(chan error, 1)
-	go func() {
-		_, err = io.Copy(stdin, conn)
-		_ = stdin.Close()
-		chCopyConn <- err
-	}()
+	defer cleanup()
 
-	// Run the command.
-	err = cmd.Start()
+	_, _, err = subprocess.RunCommandOutput(context.TODO(), cmd.Args[0], cmd.Args[1:], subprocess.WithStdin(conn), subprocess.WithEnv(d.cephEnv()...), subprocess.WithBackgroundPriority())
 	if err != nil {
-		return err
-	}
-
-	// Read any error.
-	output, _ := io.ReadAll(stderr)
-
-	// Handle errors.
-	errs := []error{}
-	chCopyConnErr := <-chCopyConn
-
-	err = cmd.Wait()
-	if err != nil {
-		errs = append(errs, err)
-
-		if chCopyConnErr != nil {
-			errs = append(errs, chCopyConnErr)
-		}
-	}
-
-	if len(errs) > 0 {
-		return fmt.Errorf("Problem with ceph import-diff: (%v) %s", errs, string(output))
+		return fmt.Errorf("Problem with ceph import-diff: %w", err)
 	}
 
 	return nil
@@ -1263,7 +1296,18 @@ func (d *ceph) resizeVolume(vol Volume, sizeBytes int64, allowShrink bool) error
 	)
 
 	// Resize the block device.
-	_, err := subprocess.TryRunCommand("rbd", args...)
+	cmd := exec.Command("rbd", args...)
+	cleanup, err := d.confineCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	_, err = subprocess.TryWithPolicy(context.Background(), cephResizeRetryPolicy, func(ctx context.Context) (string, error) {
+		stdout, _, err := subprocess.RunCommandOutput(ctx, cmd.Args[0], cmd.Args[1:], subprocess.WithEnv(d.cephEnv()...))
+		return stdout, err
+	})
 
 	return err
 }
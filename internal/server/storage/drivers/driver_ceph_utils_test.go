@@ -2,7 +2,11 @@ package drivers
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_ceph_getRBDVolumeName(t *testing.T) {
@@ -103,7 +107,7 @@ func Test_ceph_getRBDVolumeName(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			d := &ceph{
-				common{
+				common: common{
 					config: map[string]string{
 						"ceph.osd.pool_name": "testosdpool",
 					},
@@ -117,6 +121,7 @@ func Test_ceph_getRBDVolumeName(t *testing.T) {
 		})
 	}
 }
+
 func Example_ceph_parseParent() {
 	d := &ceph{}
 
@@ -149,3 +154,206 @@ func Example_ceph_parseParent() {
 	// pool container test-project_c4  block  <nil>
 	// pool zombie_container test-project_c1_28e7a7ab-740a-490c-8118-7caf7810f83b  filesystem zombie_snapshot_1027f4ab-de11-4cee-8015-bd532a1fed76 <nil>
 }
+
+func Test_ceph_osdPoolExists(t *testing.T) {
+	d, runner := newFakeCephDriver()
+
+	exists, err := d.osdPoolExists()
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	runner.pool = "otherpool"
+
+	exists, err = d.osdPoolExists()
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func Test_ceph_GetResources(t *testing.T) {
+	d, runner := newFakeCephDriver()
+
+	res, err := d.GetResources()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4096), res.Space.Total)
+	assert.Equal(t, uint64(1024), res.Space.Used)
+	assert.Empty(t, res.Degraded)
+
+	// A wedged cluster gets the command killed for not writing anything, which is reported rather than failing.
+	runner.wedged = true
+
+	res, err = d.GetResources()
+	require.NoError(t, err)
+	assert.Zero(t, res.Space.Total)
+	assert.Contains(t, res.Degraded, `Ceph cluster "ceph" isn't responding`)
+	assert.Contains(t, res.Degraded, "no output for 30s")
+}
+
+func Test_ceph_rbdMapVolume(t *testing.T) {
+	d, runner := newFakeCephDriver()
+	vol := NewVolume(d, d.name, VolumeTypeContainer, ContentTypeFS, "c1", nil, nil)
+
+	_, err := d.rbdMapVolume(vol)
+	require.Error(t, err)
+
+	require.NoError(t, d.rbdCreateVolume(vol, "10GiB"))
+
+	devPath, err := d.rbdMapVolume(vol)
+	require.NoError(t, err)
+	assert.Equal(t, "/dev/rbd0", devPath)
+	assert.True(t, runner.images["container_c1"].mapped)
+
+	// Unmapping until EINVAL must stop once the volume is unmapped.
+	require.NoError(t, d.rbdUnmapVolume(vol, true))
+	assert.False(t, runner.images["container_c1"].mapped)
+}
+
+func Test_ceph_rbdProtectVolumeSnapshot(t *testing.T) {
+	d, runner := newFakeCephDriver()
+	vol := NewVolume(d, d.name, VolumeTypeContainer, ContentTypeFS, "c1", nil, nil)
+
+	require.NoError(t, d.rbdCreateVolume(vol, "10GiB"))
+	require.NoError(t, d.rbdCreateVolumeSnapshot(vol, "snapshot_snap0"))
+
+	// Protecting and unprotecting twice must not fail (EBUSY and EINVAL are ignored).
+	require.NoError(t, d.rbdProtectVolumeSnapshot(vol, "snapshot_snap0"))
+	require.NoError(t, d.rbdProtectVolumeSnapshot(vol, "snapshot_snap0"))
+	assert.True(t, runner.images["container_c1"].snapshots[0].protected)
+
+	require.NoError(t, d.rbdUnprotectVolumeSnapshot(vol, "snapshot_snap0"))
+	require.NoError(t, d.rbdUnprotectVolumeSnapshot(vol, "snapshot_snap0"))
+	assert.False(t, runner.images["container_c1"].snapshots[0].protected)
+}
+
+func Test_ceph_rbdGetVolumeParent(t *testing.T) {
+	d, _ := newFakeCephDriver()
+	image := NewVolume(d, d.name, VolumeTypeImage, ContentTypeFS, "fp", map[string]string{"block.filesystem": "ext4"}, nil)
+	vol := NewVolume(d, d.name, VolumeTypeContainer, ContentTypeFS, "c1", nil, nil)
+
+	require.NoError(t, d.rbdCreateVolume(image, "10GiB"))
+
+	_, err := d.rbdGetVolumeParent(image)
+	assert.True(t, strings.Contains(err.Error(), "parent not found"))
+
+	require.NoError(t, d.rbdCreateVolumeSnapshot(image, "readonly"))
+	require.NoError(t, d.rbdProtectVolumeSnapshot(image, "readonly"))
+	require.NoError(t, d.rbdCreateClone(image, "readonly", vol))
+
+	parent, err := d.rbdGetVolumeParent(vol)
+	require.NoError(t, err)
+	assert.Equal(t, "testosdpool/image_fp_ext4@readonly", parent)
+
+	parentVol, snapName, err := d.parseParent(parent)
+	require.NoError(t, err)
+	assert.Equal(t, VolumeTypeImage, parentVol.volType)
+	assert.Equal(t, "fp", parentVol.name)
+	assert.Equal(t, "readonly", snapName)
+
+	clones, err := d.rbdListSnapshotClones(image, "readonly")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"testosdpool/container_c1"}, clones)
+}
+
+func Test_ceph_deleteVolume(t *testing.T) {
+	d, runner := newFakeCephDriver()
+	vol := NewVolume(d, d.name, VolumeTypeContainer, ContentTypeFS, "c1", nil, nil)
+
+	require.NoError(t, d.rbdCreateVolume(vol, "10GiB"))
+	require.NoError(t, d.rbdCreateVolumeSnapshot(vol, "snapshot_snap0"))
+
+	ret, err := d.deleteVolume(vol)
+	require.NoError(t, err)
+	assert.Equal(t, 0, ret)
+	assert.Empty(t, runner.imageNames())
+}
+
+func Test_ceph_deleteVolume_imageWithClone(t *testing.T) {
+	d, runner := newFakeCephDriver()
+	image := NewVolume(d, d.name, VolumeTypeImage, ContentTypeFS, "fp", map[string]string{"block.filesystem": "ext4"}, nil)
+	vol := NewVolume(d, d.name, VolumeTypeContainer, ContentTypeFS, "c1", nil, nil)
+
+	require.NoError(t, d.rbdCreateVolume(image, "10GiB"))
+	require.NoError(t, d.rbdCreateVolumeSnapshot(image, "readonly"))
+	require.NoError(t, d.rbdProtectVolumeSnapshot(image, "readonly"))
+	require.NoError(t, d.rbdCreateClone(image, "readonly", vol))
+
+	// Images with dependent clones are only marked as deleted.
+	require.NoError(t, d.rbdMarkVolumeDeleted(image, image.name))
+	assert.Equal(t, []string{"container_c1", "zombie_image_fp_ext4"}, runner.imageNames())
+
+	// Deleting the last clone takes the zombie image along with it.
+	ret, err := d.deleteVolume(vol)
+	require.NoError(t, err)
+	assert.Equal(t, 0, ret)
+	assert.Empty(t, runner.imageNames())
+}
+
+func Test_ceph_deleteVolume_snapshotWithClone(t *testing.T) {
+	d, runner := newFakeCephDriver()
+	vol := NewVolume(d, d.name, VolumeTypeContainer, ContentTypeFS, "c1", nil, nil)
+	copyVol := NewVolume(d, d.name, VolumeTypeContainer, ContentTypeFS, "c2", nil, nil)
+
+	require.NoError(t, d.rbdCreateVolume(vol, "10GiB"))
+	require.NoError(t, d.rbdCreateVolumeSnapshot(vol, "snapshot_snap0"))
+	require.NoError(t, d.rbdProtectVolumeSnapshot(vol, "snapshot_snap0"))
+	require.NoError(t, d.rbdCreateClone(vol, "snapshot_snap0", copyVol))
+
+	// The source volume is still needed by its clone and becomes a zombie.
+	ret, err := d.deleteVolume(vol)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ret)
+
+	names := runner.imageNames()
+	require.Len(t, names, 2)
+	assert.Equal(t, "container_c2", names[0])
+	assert.True(t, strings.HasPrefix(names[1], "zombie_container_c1_"))
+
+	zombie := runner.images[names[1]]
+	require.Len(t, zombie.snapshots, 1)
+	assert.True(t, strings.HasPrefix(zombie.snapshots[0].name, "zombie_snapshot_"))
+	assert.Equal(t, fmt.Sprintf("testosdpool/%s@%s", names[1], zombie.snapshots[0].name), runner.images["container_c2"].parent)
+
+	// Deleting a zombie snapshot which still has live clones leaves it alone.
+	zombieVol := NewVolume(d, d.name, VolumeType("zombie_container"), ContentTypeFS, strings.TrimPrefix(names[1], "zombie_container_"), nil, nil)
+	ret, err = d.deleteVolumeSnapshot(zombieVol, zombie.snapshots[0].name)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ret)
+	assert.Len(t, runner.imageNames(), 2)
+
+	// Deleting the clone recursively removes the zombie snapshot and volume.
+	ret, err = d.deleteVolume(copyVol)
+	require.NoError(t, err)
+	assert.Equal(t, 0, ret)
+	assert.Empty(t, runner.imageNames())
+}
+
+func Test_ceph_deleteVolumeSnapshot(t *testing.T) {
+	d, runner := newFakeCephDriver()
+	vol := NewVolume(d, d.name, VolumeTypeContainer, ContentTypeFS, "c1", nil, nil)
+	copyVol := NewVolume(d, d.name, VolumeTypeContainer, ContentTypeFS, "c2", nil, nil)
+
+	require.NoError(t, d.rbdCreateVolume(vol, "10GiB"))
+	require.NoError(t, d.rbdCreateVolumeSnapshot(vol, "snapshot_snap0"))
+	require.NoError(t, d.rbdCreateVolumeSnapshot(vol, "snapshot_snap1"))
+	require.NoError(t, d.rbdProtectVolumeSnapshot(vol, "snapshot_snap1"))
+	require.NoError(t, d.rbdCreateClone(vol, "snapshot_snap1", copyVol))
+
+	// A snapshot without clones is removed.
+	ret, err := d.deleteVolumeSnapshot(vol, "snapshot_snap0")
+	require.NoError(t, err)
+	assert.Equal(t, 0, ret)
+
+	snapshots, err := d.rbdListVolumeSnapshots(vol)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"snapshot_snap1"}, snapshots)
+
+	// A snapshot with a live clone is renamed to a zombie snapshot.
+	ret, err = d.deleteVolumeSnapshot(vol, "snapshot_snap1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, ret)
+
+	snapshots, err = d.rbdListVolumeSnapshots(vol)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.True(t, strings.HasPrefix(snapshots[0], "zombie_snapshot_"))
+	assert.True(t, runner.images["container_c1"].snapshots[0].protected)
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
@@ -36,7 +35,7 @@ import (
 func (d *ceph) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Operation) error {
 	// Function to rename an RBD volume.
 	renameVolume := func(oldName string, newName string) error {
-		_, err := subprocess.RunCommand(
+		_, err := d.runCommand(
 			"rbd",
 			"--id", d.config["ceph.user.name"],
 			"--cluster", d.config["ceph.cluster_name"],
@@ -294,15 +293,20 @@ func (d *ceph) getVolumeSize(volumeName string) (int64, error) {
 		Size int64 `json:"size"`
 	}{}
 
-	jsonInfo, err := subprocess.TryRunCommand(
-		"rbd",
-		"info",
-		"--format", "json",
-		"--id", d.config["ceph.user.name"],
-		"--cluster", d.config["ceph.cluster_name"],
-		"--pool", d.config["ceph.osd.pool_name"],
-		volumeName,
-	)
+	ctx, cancel := context.WithTimeout(context.Background(), cephQueryTimeout)
+	defer cancel()
+
+	jsonInfo, err := subprocess.TryWithPolicy(ctx, subprocess.DefaultRetryPolicy, func(ctx context.Context) (string, error) {
+		return d.runCommandContext(ctx,
+			"rbd",
+			"info",
+			"--format", "json",
+			"--id", d.config["ceph.user.name"],
+			"--cluster", d.config["ceph.cluster_name"],
+			"--pool", d.config["ceph.osd.pool_name"],
+			volumeName,
+		)
+	})
 	if err != nil {
 		return -1, err
 	}
@@ -390,7 +394,7 @@ func (d *ceph) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots boo
 	if !copySnapshots || len(snapshots) == 0 {
 		// If lightweight clone mode isn't enabled, perform a full copy of the volume.
 		if util.IsFalse(d.config["ceph.rbd.clone_copy"]) {
-			_, err = subprocess.RunCommand(
+			_, err = d.runCommand(
 				"rbd",
 				"--id", d.config["ceph.user.name"],
 				"--cluster", d.config["ceph.cluster_name"],
@@ -468,6 +472,12 @@ func (d *ceph) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots boo
 	// Receive over the placeholder volume we created above.
 	targetVolumeName := d.getRBDVolumeName(vol, "", false, true)
 
+	// Stop copying if the operation is cancelled.
+	ctx := context.Background()
+	if op != nil {
+		ctx = op.Context()
+	}
+
 	lastSnap := ""
 
 	if len(snapshots) > 0 {
@@ -485,7 +495,7 @@ func (d *ceph) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots boo
 
 		lastSnap = fmt.Sprintf("snapshot_%s", snap)
 		sourceVolumeName := d.getRBDVolumeName(srcVol, lastSnap, false, true)
-		err = d.copyWithSnapshots(sourceVolumeName, targetVolumeName, prev)
+		err = d.copyWithSnapshots(ctx, sourceVolumeName, targetVolumeName, prev)
 		if err != nil {
 			return err
 		}
@@ -506,7 +516,7 @@ func (d *ceph) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots boo
 	// Copy snapshot.
 	sourceVolumeName := d.getRBDVolumeName(srcVol, "", false, true)
 
-	err = d.copyWithSnapshots(sourceVolumeName, targetVolumeName, lastSnap)
+	err = d.copyWithSnapshots(ctx, sourceVolumeName, targetVolumeName, lastSnap)
 	if err != nil {
 		return err
 	}
@@ -700,7 +710,7 @@ func (d *ceph) DeleteVolume(vol Volume, op *operations.Operation) error {
 			}
 
 			// Delete snapshots.
-			_, err := subprocess.RunCommand(
+			_, err := d.runCommand(
 				"rbd",
 				"--id", d.config["ceph.user.name"],
 				"--cluster", d.config["ceph.cluster_name"],
@@ -762,7 +772,7 @@ func (d *ceph) hasVolume(rbdVolumeName string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
 	defer cancel()
 
-	_, err := subprocess.RunCommandContext(ctx,
+	_, err := d.runCommandContext(ctx,
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -772,14 +782,9 @@ func (d *ceph) hasVolume(rbdVolumeName string) (bool, error) {
 	)
 
 	if err != nil {
-		runErr, ok := err.(subprocess.RunError)
-		if ok {
-			exitError, ok := runErr.Unwrap().(*exec.ExitError)
-			if ok {
-				if exitError.ExitCode() == 2 {
-					return false, nil
-				}
-			}
+		if subprocess.ExitedWith(err, 2) {
+			// ENOENT (volume doesn't exist).
+			return false, nil
 		}
 
 		return false, err
@@ -900,7 +905,7 @@ func (d *ceph) GetVolumeUsage(vol Volume) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
 	defer cancel()
 
-	jsonInfo, err := subprocess.RunCommandContext(ctx,
+	jsonInfo, err := d.runCommandContext(ctx,
 		"rbd",
 		"du",
 		"--format", "json",
@@ -1069,29 +1074,17 @@ func (d *ceph) GetVolumeDiskPath(vol Volume) (string, error) {
 func (d *ceph) ListVolumes() ([]Volume, error) {
 	vols := make(map[string]Volume)
 
-	cmd := exec.Command("rbd",
+	output, err := d.queryCommand("rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
 		"--pool", d.config["ceph.osd.pool_name"],
 		"ls",
 	)
-
-	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Failed getting volume list: %w", err)
 	}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, err
-	}
-
-	err = cmd.Start()
-	if err != nil {
-		return nil, err
-	}
-
-	scanner := bufio.NewScanner(stdout)
+	scanner := bufio.NewScanner(strings.NewReader(output))
 	for scanner.Scan() {
 		rawName := strings.TrimSpace(scanner.Text())
 		var volType VolumeType
@@ -1150,16 +1143,6 @@ func (d *ceph) ListVolumes() ([]Volume, error) {
 		return nil, fmt.Errorf("Unexpected duplicate volume %q found", volName)
 	}
 
-	errMsg, err := io.ReadAll(stderr)
-	if err != nil {
-		return nil, err
-	}
-
-	err = cmd.Wait()
-	if err != nil {
-		return nil, fmt.Errorf("Failed getting volume list: %v: %w", strings.TrimSpace(string(errMsg)), err)
-	}
-
 	volList := make([]Volume, len(vols))
 	for _, v := range vols {
 		volList = append(volList, v)
@@ -1520,7 +1503,7 @@ func (d *ceph) CreateVolumeSnapshot(snapVol Volume, op *operations.Operation) er
 // DeleteVolumeSnapshot removes a snapshot from the storage device.
 func (d *ceph) DeleteVolumeSnapshot(snapVol Volume, op *operations.Operation) error {
 	// Check if snapshot exists, and return if not.
-	_, err := subprocess.RunCommand(
+	_, err := d.queryCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -1787,7 +1770,7 @@ func (d *ceph) RestoreVolume(vol Volume, snapshotName string, op *operations.Ope
 		defer func() { _ = d.MountVolume(vol, op) }()
 	}
 
-	_, err = subprocess.RunCommand(
+	_, err = d.runCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
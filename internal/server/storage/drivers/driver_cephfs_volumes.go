@@ -199,7 +199,7 @@ func (d *cephfs) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser,
 				wrapper = localMigration.ProgressTracker(op, "fs_progress", snapName)
 			}
 
-			err = rsync.Recv(path, conn, wrapper, volTargetArgs.MigrationType.Features)
+			err = rsync.Recv(path, rsync.AccessCreateOnly, conn, wrapper, volTargetArgs.MigrationType.Features)
 			if err != nil {
 				return err
 			}
@@ -231,7 +231,7 @@ func (d *cephfs) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser,
 			wrapper = localMigration.ProgressTracker(op, "fs_progress", vol.name)
 		}
 
-		return rsync.Recv(path, conn, wrapper, volTargetArgs.MigrationType.Features)
+		return rsync.Recv(path, rsync.AccessCreateOnly, conn, wrapper, volTargetArgs.MigrationType.Features)
 	}, op)
 	if err != nil {
 		return err
@@ -16,6 +16,12 @@ import (
 
 // radosgwadmin wrapper around radosgw-admin command.
 func (d *cephobject) radosgwadmin(ctx context.Context, args ...string) (string, error) {
+	return d.radosgwadminRedacted(ctx, nil, args...)
+}
+
+// radosgwadminRedacted wrapper around radosgw-admin command, keeping the given secrets out of what's reported
+// about the command and of the returned error.
+func (d *cephobject) radosgwadminRedacted(ctx context.Context, secrets []string, args ...string) (string, error) {
 	_, ok := ctx.Deadline()
 	if !ok {
 		// Set default timeout of 30s if no deadline context provided.
@@ -27,7 +33,9 @@ func (d *cephobject) radosgwadmin(ctx context.Context, args ...string) (string,
 	cmd := []string{"radosgw-admin", "--cluster", d.config["cephobject.cluster_name"], "--id", d.config["cephobject.user.name"]}
 	cmd = append(cmd, args...)
 
-	return subprocess.RunCommandContext(ctx, cmd[0], cmd[1:]...)
+	stdout, _, err := subprocess.RunCommandOutput(ctx, cmd[0], cmd[1:], subprocess.WithRedactedArgs(secrets...))
+
+	return stdout, err
 }
 
 // radosgwadminGetUser returns credentials for an existing radosgw user (and its sub users).
@@ -155,7 +163,7 @@ func (d *cephobject) radosgwadminSubUserAdd(ctx context.Context, user string, su
 		args = append(args, "--secret", secretKey)
 	}
 
-	out, err := d.radosgwadmin(ctx, args...)
+	out, err := d.radosgwadminRedacted(ctx, []string{secretKey}, args...)
 	if err != nil {
 		return nil, err
 	}
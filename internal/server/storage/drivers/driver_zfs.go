@@ -1,6 +1,8 @@
 package drivers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -621,34 +623,35 @@ func (d *zfs) Unmount() (bool, error) {
 }
 
 func (d *zfs) GetResources() (*api.ResourcesStoragePool, error) {
-	// Get the total amount of space.
-	availableStr, err := d.getDatasetProperty(d.config["zfs.pool_name"], "available")
-	if err != nil {
-		return nil, err
-	}
+	// Inode allocation is dynamic so no use in reporting them.
+	res := api.ResourcesStoragePool{}
 
-	available, err := strconv.ParseUint(strings.TrimSpace(availableStr), 10, 64)
+	// Get the total and used amounts of space. When the pool is faulted, the command gets stuck and is killed,
+	// so report whatever it got before that.
+	props, err := d.queryDatasetProperties(d.config["zfs.pool_name"], "available", "used")
 	if err != nil {
-		return nil, err
+		if !errors.Is(err, subprocess.ErrInactivityTimeout) && !errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+
+		res.Degraded = fmt.Sprintf("ZFS pool %q isn't responding: %v", d.config["zfs.pool_name"], err)
 	}
 
-	// Get the used amount of space.
-	usedStr, err := d.getDatasetProperty(d.config["zfs.pool_name"], "used")
-	if err != nil {
-		return nil, err
+	// Only report the space when both amounts were gathered, so that the used space never exceeds the total.
+	available, err := strconv.ParseUint(strings.TrimSpace(props["available"]), 10, 64)
+	if err == nil {
+		var used uint64
+		used, err = strconv.ParseUint(strings.TrimSpace(props["used"]), 10, 64)
+		if err == nil {
+			res.Space.Total = used + available
+			res.Space.Used = used
+		}
 	}
 
-	used, err := strconv.ParseUint(strings.TrimSpace(usedStr), 10, 64)
-	if err != nil {
+	if err != nil && res.Degraded == "" {
 		return nil, err
 	}
 
-	// Build the struct.
-	// Inode allocation is dynamic so no use in reporting them.
-	res := api.ResourcesStoragePool{}
-	res.Space.Total = used + available
-	res.Space.Used = used
-
 	return &res, nil
 }
 
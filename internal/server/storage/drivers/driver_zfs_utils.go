@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -35,6 +36,13 @@ const (
 
 	// zfsMaxVolBlocksize is a maximum value for volblocksize property.
 	zfsMaxVolBlocksize = 128 * 1024
+
+	// zfsQueryInactivityTimeout bounds how long the zfs commands querying a pool can go without writing anything,
+	// as they get stuck rather than fail on a faulted pool.
+	zfsQueryInactivityTimeout = 30 * time.Second
+
+	// zfsQueryTimeout bounds the zfs commands querying a pool, for the ones getting stuck while still writing.
+	zfsQueryTimeout = 2 * time.Minute
 )
 
 func (d *zfs) dataset(vol Volume, deleted bool) string {
@@ -270,6 +278,40 @@ func (d *zfs) getDatasetProperties(dataset string, keys ...string) (map[string]s
 	return props, nil
 }
 
+// queryDatasetProperties gets the properties of a dataset like getDatasetProperties, gathering them as the zfs
+// command writes them so that the ones it managed to get are returned along with the error when it gets stuck.
+// The command is killed after zfsQueryTimeout, or once it didn't write anything for zfsQueryInactivityTimeout.
+func (d *zfs) queryDatasetProperties(dataset string, keys ...string) (map[string]string, error) {
+	props := make(map[string]string, len(keys))
+
+	lines := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for line := range lines {
+			prop := strings.Split(line, "\t")
+			if len(prop) < 2 {
+				continue
+			}
+
+			props[prop[0]] = prop[1]
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), zfsQueryTimeout)
+	defer cancel()
+
+	_, err := subprocess.RunCommandStream(ctx, lines, "zfs", []string{"get", "-H", "-p", "-o", "property,value", strings.Join(keys, ","), dataset}, subprocess.WithInactivityTimeout(zfsQueryInactivityTimeout))
+	<-done
+
+	if err != nil && ctx.Err() != nil {
+		return props, fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+
+	return props, err
+}
+
 // version returns the ZFS version based on package or kernel module version.
 func (d *zfs) version() (string, error) {
 	// This function is only really ever relevant on Ubuntu as the only
@@ -370,7 +412,7 @@ func (d *zfs) sendDataset(dataset string, parent string, volSrcArgs *migration.V
 	cmd.Stdout = stdout
 
 	// Run the command.
-	err = cmd.Start()
+	err = subprocess.StartWithPriority(cmd, subprocess.BackgroundPriority())
 	if err != nil {
 		return err
 	}
@@ -403,7 +445,7 @@ func (d *zfs) receiveDataset(vol Volume, r io.Reader, tracker *ioprogress.Progre
 		}
 	}
 
-	err := subprocess.RunCommandWithFds(context.TODO(), stdin, nil, "zfs", args...)
+	_, _, err := subprocess.RunCommandOutput(context.TODO(), "zfs", args, subprocess.WithStdin(stdin), subprocess.WithBackgroundPriority())
 	if err != nil {
 		return err
 	}
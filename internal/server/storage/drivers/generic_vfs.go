@@ -314,7 +314,13 @@ func genericVFSCreateVolumeFromMigration(d Driver, initVolume func(vol Volume) (
 		d.Logger().Debug("Receiving filesystem volume started", logger.Ctx{"volName": volName, "path": path, "features": volTargetArgs.MigrationType.Features})
 		defer d.Logger().Debug("Receiving filesystem volume stopped", logger.Ctx{"volName": volName, "path": path})
 
-		return rsync.Recv(path, conn, wrapper, volTargetArgs.MigrationType.Features)
+		// Refreshing replaces the existing content while new volumes only get files created.
+		access := rsync.AccessCreateOnly
+		if volTargetArgs.Refresh {
+			access = rsync.AccessFull
+		}
+
+		return rsync.Recv(path, access, conn, wrapper, volTargetArgs.MigrationType.Features)
 	}
 
 	recvBlockVol := func(volName string, conn io.ReadWriteCloser, path string) error {
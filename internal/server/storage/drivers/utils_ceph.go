@@ -58,12 +58,17 @@ func CephGetRBDImageName(vol Volume, snapName string, zombie bool) string {
 	return out
 }
 
+// cephConfigPath returns the path to the configuration file of a ceph cluster.
+func cephConfigPath(cluster string) string {
+	return fmt.Sprintf("/etc/ceph/%s.conf", cluster)
+}
+
 // CephMonitors gets the mon-host field for the relevant cluster and extracts the list of addresses and ports.
 func CephMonitors(cluster string) ([]string, error) {
 	// Open the CEPH configuration.
-	cephConf, err := os.Open(fmt.Sprintf("/etc/ceph/%s.conf", cluster))
+	cephConf, err := os.Open(cephConfigPath(cluster))
 	if err != nil {
-		return nil, fmt.Errorf("Failed to open %q: %w", fmt.Sprintf("/etc/ceph/%s.conf", cluster), err)
+		return nil, fmt.Errorf("Failed to open %q: %w", cephConfigPath(cluster), err)
 	}
 
 	// Locate the mon-host key and its values.
@@ -183,7 +188,7 @@ func getCephKeyFromFile(path string) (string, error) {
 // CephKeyring gets the key for a particular Ceph cluster and client name.
 func CephKeyring(cluster string, client string) (string, error) {
 	var cephSecret string
-	cephConfigPath := fmt.Sprintf("/etc/ceph/%v.conf", cluster)
+	configPath := cephConfigPath(cluster)
 
 	keyringPathFull := fmt.Sprintf("/etc/ceph/%v.client.%v.keyring", cluster, client)
 	keyringPathCluster := fmt.Sprintf("/etc/ceph/%v.keyring", cluster)
@@ -198,11 +203,11 @@ func CephKeyring(cluster string, client string) (string, error) {
 		return getCephKeyFromFile(keyringPathGlobal)
 	} else if util.PathExists(keyringPathGlobalBin) {
 		return getCephKeyFromFile(keyringPathGlobalBin)
-	} else if util.PathExists(cephConfigPath) {
+	} else if util.PathExists(configPath) {
 		// Open the CEPH config file.
-		cephConfig, err := os.Open(cephConfigPath)
+		cephConfig, err := os.Open(configPath)
 		if err != nil {
-			return "", fmt.Errorf("Failed to open %q: %w", cephConfigPath, err)
+			return "", fmt.Errorf("Failed to open %q: %w", configPath, err)
 		}
 
 		// Locate the keyring entry and its value.
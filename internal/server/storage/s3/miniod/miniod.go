@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"net"
 	"net/url"
 	"os"
 	"os/exec"
@@ -204,18 +203,12 @@ func EnsureRunning(s *state.State, bucketVol storageDrivers.Volume) (*Process, e
 
 	miniosMu.Unlock()
 
-	// Find free random port for minio process to listen on.
-	listener, err := net.Listen("tcp", fmt.Sprintf("%s:0", minioHost))
+	// Reserve a free port for minio process to listen on, released once the process stops.
+	listenPort, err := s.LocalPorts.Reserve(0)
 	if err != nil {
 		return nil, fmt.Errorf("Failed finding free listen port for bucket MinIO process: %w", err)
 	}
 
-	listenPort := listener.Addr().(*net.TCPAddr).Port
-	err = listener.Close()
-	if err != nil {
-		return nil, err
-	}
-
 	minioProc = &Process{
 		bucketName:   bucketName,
 		transactions: 1,
@@ -331,6 +324,8 @@ func EnsureRunning(s *state.State, bucketVol storageDrivers.Volume) (*Process, e
 		delete(minios, bucketName)
 		miniosMu.Unlock()
 
+		s.LocalPorts.Release(listenPort)
+
 		client, err := minioProc.AdminClient()
 		if err != nil {
 			l.Error("Error creating MinIO client", logger.Ctx{"err": err})
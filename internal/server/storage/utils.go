@@ -485,8 +485,9 @@ func poolAndVolumeCommonRules(vol *drivers.Volume) map[string]func(string) error
 			_, err := internalInstance.GetExpiry(time.Time{}, value)
 			return err
 		},
-		"snapshots.schedule": validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly"})),
-		"snapshots.pattern":  validate.IsAny,
+		"snapshots.schedule":    validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly"})),
+		"snapshots.pattern":     validate.IsAny,
+		"snapshots.consistency": validate.Optional(validate.IsOneOf("crash", "application")),
 	}
 
 	// security.shifted and security.unmapped are only relevant for custom filesystem volumes.
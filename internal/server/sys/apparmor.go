@@ -57,6 +57,14 @@ func (s *OS) initAppArmor() []cluster.Warning {
 		}
 	}
 
+	/* Detect the policy namespace we're nested in */
+	if s.AppArmorStacked {
+		s.AppArmorNamespace = appArmorNamespace()
+		if s.AppArmorNamespace != "" {
+			logger.Debug("Running in a nested AppArmor policy namespace", logger.Ctx{"namespace": s.AppArmorNamespace})
+		}
+	}
+
 	/* Detect AppArmor admin support */
 	if !haveMacAdmin() {
 		if s.AppArmorAvailable {
@@ -101,6 +109,26 @@ func haveMacAdmin() bool {
 	return false
 }
 
+// Returns the name of the policy namespace we're running in, or an empty string at the root.
+func appArmorNamespace() string {
+	contentBytes, err := os.ReadFile("/sys/kernel/security/apparmor/.ns_level")
+	if err != nil {
+		return ""
+	}
+
+	level, err := strconv.Atoi(strings.TrimSpace(string(contentBytes)))
+	if err != nil || level == 0 {
+		return ""
+	}
+
+	contentBytes, err = os.ReadFile("/sys/kernel/security/apparmor/.ns_name")
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(contentBytes))
+}
+
 // Returns true if AppArmor stacking support is available.
 func appArmorCanStack() bool {
 	contentBytes, err := os.ReadFile("/sys/kernel/security/apparmor/features/domain/stack")
@@ -67,6 +67,7 @@ type OS struct {
 	AppArmorAdmin     bool
 	AppArmorAvailable bool
 	AppArmorConfined  bool
+	AppArmorNamespace string // Policy namespace the daemon runs in when nested, empty otherwise.
 	AppArmorStacked   bool
 	AppArmorStacking  bool
 
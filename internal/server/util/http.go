@@ -10,7 +10,6 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -18,7 +17,6 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/lxc/incus/v6/internal/ports"
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/logger"
 	localtls "github.com/lxc/incus/v6/shared/tls"
@@ -210,75 +208,6 @@ func IsRecursionRequest(r *http.Request) bool {
 	return recursion != 0
 }
 
-// ListenAddresses returns a list of <host>:<port> combinations at which this machine can be reached.
-// It accepts the configured listen address in the following formats: <host>, <host>:<port> or :<port>.
-// If a listen port is not specified then then ports.HTTPSDefaultPort is used instead.
-// If a non-empty and non-wildcard host is passed in then this functions returns a single element list with the
-// listen address specified. Otherwise if an empty host or wildcard address is specified then all global unicast
-// addresses actively configured on the host are returned. If an IPv4 wildcard address (0.0.0.0) is specified as
-// the host then only IPv4 addresses configured on the host are returned.
-func ListenAddresses(configListenAddress string) ([]string, error) {
-	addresses := make([]string, 0)
-
-	if configListenAddress == "" {
-		return addresses, nil
-	}
-
-	// Check if configListenAddress is a bare IP address (wrapped with square brackets or unwrapped) or a
-	// hostname (without port). If so then add the default port to the configListenAddress ready for parsing.
-	unwrappedConfigListenAddress := strings.Trim(configListenAddress, "[]")
-	listenIP := net.ParseIP(unwrappedConfigListenAddress)
-	if listenIP != nil || !strings.Contains(unwrappedConfigListenAddress, ":") {
-		// Use net.JoinHostPort so that IPv6 addresses are correctly wrapped ready for parsing below.
-		configListenAddress = net.JoinHostPort(unwrappedConfigListenAddress, fmt.Sprintf("%d", ports.HTTPSDefaultPort))
-	}
-
-	// By this point we should always have the configListenAddress in form <host>:<port>, so lets check that.
-	// This also ensures that any wrapped IPv6 addresses are unwrapped ready for comparison below.
-	localHost, localPort, err := net.SplitHostPort(configListenAddress)
-	if err != nil {
-		return nil, err
-	}
-
-	if localHost == "" || localHost == "0.0.0.0" || localHost == "::" {
-		ifaces, err := net.Interfaces()
-		if err != nil {
-			return addresses, err
-		}
-
-		for _, i := range ifaces {
-			addrs, err := i.Addrs()
-			if err != nil {
-				continue
-			}
-
-			for _, addr := range addrs {
-				var ip net.IP
-				switch v := addr.(type) {
-				case *net.IPNet:
-					ip = v.IP
-				case *net.IPAddr:
-					ip = v.IP
-				}
-
-				if !ip.IsGlobalUnicast() {
-					continue
-				}
-
-				if ip.To4() == nil && localHost == "0.0.0.0" {
-					continue
-				}
-
-				addresses = append(addresses, net.JoinHostPort(ip.String(), localPort))
-			}
-		}
-	} else {
-		addresses = append(addresses, net.JoinHostPort(localHost, localPort))
-	}
-
-	return addresses, nil
-}
-
 // IsJSONRequest returns true if the content type of the HTTP request is JSON.
 func IsJSONRequest(r *http.Request) bool {
 	for k, vs := range r.Header {
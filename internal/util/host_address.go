@@ -0,0 +1,82 @@
+package util
+
+import (
+	"fmt"
+	"net"
+)
+
+// HostAddress is an IP address configured on a network interface of the host.
+type HostAddress struct {
+	IP        net.IP
+	Interface string
+	Up        bool
+}
+
+// hostAddresses returns the addresses of all the network interfaces of the host, replaced in tests.
+var hostAddresses = func() ([]HostAddress, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []HostAddress
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+
+			result = append(result, HostAddress{IP: ipNet.IP, Interface: iface.Name, Up: iface.Flags&net.FlagUp != 0})
+		}
+	}
+
+	return result, nil
+}
+
+// HostAddresses returns the IP addresses configured on the network interfaces of the host, whether the
+// interfaces are up or not.
+func HostAddresses() ([]HostAddress, error) {
+	addresses, err := hostAddresses()
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting host addresses: %w", err)
+	}
+
+	return addresses, nil
+}
+
+// AddressPresentOnHost returns the address of the host matching the given IP address, possibly with a port (e.g
+// "[2001:db8::1]:8443"), or nil if it isn't configured on any network interface of the host. The interface may be
+// down, as told by the returned address.
+func AddressPresentOnHost(addr string) (*HostAddress, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		parsed, err := ParseNetworkAddress(addr, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		ip = parsed.ip()
+		if ip == nil {
+			return nil, fmt.Errorf("Address %q isn't an IP address", addr)
+		}
+	}
+
+	addresses, err := HostAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, address := range addresses {
+		if address.IP.Equal(ip) {
+			return &address, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setHostAddresses replaces the addresses of the host for the duration of the test.
+func setHostAddresses(t *testing.T, addresses []HostAddress) {
+	old := hostAddresses
+	t.Cleanup(func() { hostAddresses = old })
+
+	hostAddresses = func() ([]HostAddress, error) { return addresses, nil }
+}
+
+func TestAddressPresentOnHost(t *testing.T) {
+	setHostAddresses(t, []HostAddress{
+		{IP: net.ParseIP("127.0.0.1"), Interface: "lo", Up: true},
+		{IP: net.ParseIP("192.0.2.1"), Interface: "eth0", Up: true},
+		{IP: net.ParseIP("2001:db8::1"), Interface: "eth0", Up: true},
+		{IP: net.ParseIP("198.51.100.1"), Interface: "eth1", Up: false},
+	})
+
+	cases := []struct {
+		address   string
+		iface     string
+		up        bool
+		malformed bool
+	}{
+		{"192.0.2.1", "eth0", true, false},
+		{"192.0.2.1:8443", "eth0", true, false},
+		{"2001:db8::1", "eth0", true, false},
+		{"2001:db8:0::1", "eth0", true, false},
+		{"[2001:db8::1]:8443", "eth0", true, false},
+		{"::ffff:192.0.2.1", "eth0", true, false},
+		{"198.51.100.1", "eth1", false, false},
+		{"192.0.2.2", "", false, false},
+		{"2001:db8::2", "", false, false},
+		{"example.com", "", false, true},
+		{"garbage:1:2", "", false, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.address, func(t *testing.T) {
+			address, err := AddressPresentOnHost(c.address)
+			if c.malformed {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			if c.iface == "" {
+				assert.Nil(t, address)
+				return
+			}
+
+			require.NotNil(t, address)
+			assert.Equal(t, c.iface, address.Interface)
+			assert.Equal(t, c.up, address.Up)
+		})
+	}
+}
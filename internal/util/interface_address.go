@@ -0,0 +1,129 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// interfaceAddrs returns the addresses of the given network interface, replaced in tests.
+var interfaceAddrs = func(name string) ([]net.Addr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return iface.Addrs()
+}
+
+// IsInterfaceAddress returns whether the given address refers to a network interface rather than to a host, as in
+// "%bond0:8443", in which case it must be resolved with ResolveListenAddress before being bound.
+func IsInterfaceAddress(address string) bool {
+	return strings.HasPrefix(address, "%")
+}
+
+// ValidateInterfaceAddress checks that the given address is a valid reference to a network interface, of the form
+// "%<interface>[/4|/6][:<port>]". The interface doesn't need to exist.
+func ValidateInterfaceAddress(address string) error {
+	_, _, _, err := parseInterfaceAddress(address)
+	return err
+}
+
+// parseInterfaceAddress splits an address referring to a network interface into the name of the interface, the
+// preferred address family ("4", "6" or "" if unspecified) and the port, empty if missing.
+func parseInterfaceAddress(address string) (string, string, string, error) {
+	spec, found := strings.CutPrefix(address, "%")
+	if !found {
+		return "", "", "", fmt.Errorf("Interface address %q must start with %%", address)
+	}
+
+	// Interface names can't contain colons nor slashes, so the first ones separate the port and the family.
+	spec, port, found := strings.Cut(spec, ":")
+	if found && port != "" {
+		_, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return "", "", "", fmt.Errorf("Invalid port %q in interface address %q", port, address)
+		}
+	}
+
+	name, family, found := strings.Cut(spec, "/")
+	if found && family != "4" && family != "6" {
+		return "", "", "", fmt.Errorf("Invalid address family %q in interface address %q, must be 4 or 6", family, address)
+	}
+
+	if name == "" || len(name) > 15 || strings.ContainsAny(name, "% \t\n") {
+		return "", "", "", fmt.Errorf("Invalid interface name %q in interface address %q", name, address)
+	}
+
+	return name, family, port, nil
+}
+
+// ResolveListenAddress returns the canonical form of the given listen address, as CanonicalNetworkAddress does,
+// failing if it can't be parsed.
+// Addresses referring to a network interface (e.g "%bond0:8443") are resolved to the current global address of
+// the interface. IPv6 addresses are preferred unless the address family is specified (e.g "%bond0/4:8443"), the
+// other family being used if the interface has no global address of the preferred one.
+func ResolveListenAddress(address string, defaultPort int) (string, error) {
+	addr, err := ParseNetworkAddress(address, defaultPort)
+	if err != nil {
+		return "", err
+	}
+
+	if !IsInterfaceAddress(addr.Host) {
+		return addr.String(), nil
+	}
+
+	ip, err := resolveInterfaceHost(addr.Host)
+	if err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(ip.String(), strconv.Itoa(addr.Port)), nil
+}
+
+// resolveInterfaceHost returns the current global address of the network interface referred to by the given
+// address, the port being ignored.
+func resolveInterfaceHost(address string) (net.IP, error) {
+	name, family, _, err := parseInterfaceAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := interfaceAddrs(name)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting addresses of interface %q: %w", name, err)
+	}
+
+	// Keep the first address of each family, which is the primary one.
+	var ipv4, ipv6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+
+		if ipNet.IP.To4() != nil {
+			if ipv4 == nil {
+				ipv4 = ipNet.IP.To4()
+			}
+		} else if ipv6 == nil {
+			ipv6 = ipNet.IP
+		}
+	}
+
+	preferred, fallback := ipv6, ipv4
+	if family == "4" {
+		preferred, fallback = ipv4, ipv6
+	}
+
+	if preferred != nil {
+		return preferred, nil
+	}
+
+	if fallback != nil {
+		return fallback, nil
+	}
+
+	return nil, fmt.Errorf("Interface %q has no global address", name)
+}
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/ports"
+)
+
+// setInterfaceAddrs replaces the addresses of the network interfaces for the duration of the test.
+func setInterfaceAddrs(t *testing.T, ifaces map[string][]string) {
+	old := interfaceAddrs
+	t.Cleanup(func() { interfaceAddrs = old })
+
+	interfaceAddrs = func(name string) ([]net.Addr, error) {
+		cidrs, ok := ifaces[name]
+		if !ok {
+			return nil, fmt.Errorf("route ip+net: no such network interface")
+		}
+
+		addrs := make([]net.Addr, 0, len(cidrs))
+		for _, cidr := range cidrs {
+			ip, subnet, err := net.ParseCIDR(cidr)
+			require.NoError(t, err)
+
+			addrs = append(addrs, &net.IPNet{IP: ip, Mask: subnet.Mask})
+		}
+
+		return addrs, nil
+	}
+}
+
+func TestValidateInterfaceAddress(t *testing.T) {
+	valid := []string{"%bond0", "%bond0:", "%bond0:8443", "%bond0/4", "%bond0/6:8443", "%eth0.100:443"}
+	for _, address := range valid {
+		t.Run(address, func(t *testing.T) {
+			assert.NoError(t, ValidateInterfaceAddress(address))
+		})
+	}
+
+	invalid := []string{"bond0:8443", "%", "%:8443", "%bond0/5", "%bond0/:8443", "%bond0:http", "%bond0:65536", "%averyveryverylongname", "%bo nd0"}
+	for _, address := range invalid {
+		t.Run(address, func(t *testing.T) {
+			assert.Error(t, ValidateInterfaceAddress(address))
+		})
+	}
+}
+
+func TestResolveListenAddress(t *testing.T) {
+	setInterfaceAddrs(t, map[string][]string{
+		"bond0": {"fe80::1/64", "10.0.0.2/24", "2001:db8::2/64", "10.0.0.3/24", "2001:db8::3/64"},
+		"eth0":  {"127.0.0.1/8", "fe80::2/64", "192.0.2.1/24"},
+		"eth1":  {"fe80::3/64", "2001:db8:1::1/64"},
+		"dummy": {"fe80::4/64"},
+	})
+
+	cases := map[string]string{
+		"10.0.0.1":        "10.0.0.1:8443",
+		"[::]:8444":       "[::]:8444",
+		"%bond0":          "[2001:db8::2]:8443",
+		"%bond0:8444":     "[2001:db8::2]:8444",
+		"%bond0/6:":       "[2001:db8::2]:8443",
+		"%bond0/4":        "10.0.0.2:8443",
+		"%bond0/4:8444":   "10.0.0.2:8444",
+		"%eth0":           "192.0.2.1:8443",
+		"%eth1/4":         "[2001:db8:1::1]:8443",
+		"%unknown:8443":   "",
+		"%dummy:8443":     "",
+		"%bond0/5:8443":   "",
+		"%bond0:nonsense": "",
+	}
+
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			address, err := ResolveListenAddress(in, ports.HTTPSDefaultPort)
+			if out == "" {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, out, address)
+		})
+	}
+}
+
+func TestResolveListenAddressChange(t *testing.T) {
+	setInterfaceAddrs(t, map[string][]string{"bond0": {"10.0.0.2/24"}})
+
+	address, err := ResolveListenAddress("%bond0", ports.HTTPSDefaultPort)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2:8443", address)
+
+	// The address is looked up again every time.
+	setInterfaceAddrs(t, map[string][]string{"bond0": {"10.0.0.4/24"}})
+
+	address, err = ResolveListenAddress("%bond0", ports.HTTPSDefaultPort)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.4:8443", address)
+}
+
+func TestInterfaceAddressCovered(t *testing.T) {
+	setInterfaceAddrs(t, map[string][]string{"bond0": {"10.0.0.2/24", "2001:db8::2/64"}})
+
+	assert.True(t, IsAddressCovered("[2001:db8::2]:8443", "%bond0:8443"))
+	assert.True(t, IsAddressCovered("10.0.0.2:8443", "%bond0/4"))
+	assert.False(t, IsAddressCovered("10.0.0.2:8443", "%bond0:8443"))
+	assert.False(t, IsAddressCovered("[2001:db8::2]:8444", "%bond0:8443"))
+	assert.True(t, IsAddressCovered("%bond0:8443", "[::]:8443"))
+
+	addresses, err := ExpandListenAddresses("%bond0/4", ExpandListenOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.2:8443"}, addresses)
+}
@@ -1,34 +1,211 @@
 package util
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/lxc/incus/v6/internal/ports"
 )
 
+// NetworkAddress is a network address of the form "host:port", as used for the listen and cluster addresses.
+type NetworkAddress struct {
+	// Host is an IP address in its canonical form without zone, a host name, a network interface (e.g "%bond0")
+	// or empty for the wildcard serving all address families.
+	Host string
+
+	// Port is the port, the default one if the address didn't have any.
+	Port int
+
+	// Zone is the zone identifier of a link-local IPv6 address.
+	Zone string
+
+	// IsWildcard tells whether the host is a wildcard ("", "0.0.0.0" or "::").
+	IsWildcard bool
+
+	// IsIPv6 tells whether the host is an IPv6 address.
+	IsIPv6 bool
+}
+
+// ParseNetworkAddress parses a network address of the form "host:port", filling it with the default port if it's
+// missing. The host may be an IP address, IPv6 addresses being possibly wrapped with square brackets and followed by
+// a zone identifier (e.g `[fe80::1%eth0]` or `[fe80::1%25eth0]` as in URLs), a host name, a network interface
+// (e.g `%bond0`) or empty.
+func ParseNetworkAddress(address string, defaultPort int) (NetworkAddress, error) {
+	host, port, bracketed, err := splitNetworkAddress(address)
+	if err != nil {
+		return NetworkAddress{}, err
+	}
+
+	addr := NetworkAddress{Port: defaultPort}
+	if port != "" {
+		portNumber, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return NetworkAddress{}, fmt.Errorf("Invalid port %q in address %q", port, address)
+		}
+
+		addr.Port = int(portNumber)
+	}
+
+	ip, zone := parseIPZone(host)
+	switch {
+	case ip != nil:
+		addr.Host = ip.String()
+		addr.Zone = zone
+		addr.IsIPv6 = ip.To4() == nil
+		addr.IsWildcard = ip.IsUnspecified()
+	case bracketed:
+		return NetworkAddress{}, fmt.Errorf("Invalid IPv6 address %q in address %q", host, address)
+	case host == "":
+		addr.IsWildcard = true
+	case IsInterfaceAddress(host):
+		_, _, _, err := parseInterfaceAddress(host)
+		if err != nil {
+			return NetworkAddress{}, err
+		}
+
+		addr.Host = host
+	default:
+		if len(host) > 253 || strings.ContainsFunc(host, isInvalidHostRune) {
+			return NetworkAddress{}, fmt.Errorf("Invalid host %q in address %q", host, address)
+		}
+
+		addr.Host = host
+	}
+
+	return addr, nil
+}
+
+// splitNetworkAddress splits an address into its host and port, either being possibly empty. Unlike
+// net.SplitHostPort, the port may be missing and IPv6 addresses needn't be wrapped in square brackets if it is.
+// Also returns whether the host was wrapped in square brackets.
+func splitNetworkAddress(address string) (string, string, bool, error) {
+	if address == "" {
+		return "", "", false, fmt.Errorf("Empty address")
+	}
+
+	rest, found := strings.CutPrefix(address, "[")
+	if found {
+		host, rest, found := strings.Cut(rest, "]")
+		if !found {
+			return "", "", false, fmt.Errorf("Missing ']' in address %q", address)
+		}
+
+		if rest == "" {
+			return host, "", true, nil
+		}
+
+		port, found := strings.CutPrefix(rest, ":")
+		if !found || strings.Contains(port, ":") {
+			return "", "", false, fmt.Errorf("Unexpected %q after host in address %q", rest, address)
+		}
+
+		return host, port, true, nil
+	}
+
+	switch strings.Count(address, ":") {
+	case 0:
+		return address, "", false, nil
+	case 1:
+		host, port, _ := strings.Cut(address, ":")
+		return host, port, false, nil
+	}
+
+	// Several colons are only allowed in bare IPv6 addresses, which can't be followed by a port.
+	ip, _ := parseIPZone(address)
+	if ip == nil {
+		return "", "", false, fmt.Errorf("Invalid address %q, IPv6 addresses must be wrapped in square brackets when followed by a port", address)
+	}
+
+	return address, "", false, nil
+}
+
+// isInvalidHostRune returns whether the character can't be part of a host name.
+func isInvalidHostRune(r rune) bool {
+	return (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '.' && r != '-' && r != '_'
+}
+
+// HostPort returns the host, along with the zone if any, and the port, as net.SplitHostPort would.
+func (a NetworkAddress) HostPort() (string, string) {
+	return joinIPZone(a.Host, a.Zone), strconv.Itoa(a.Port)
+}
+
+// String returns the canonical form of the address, such as "192.0.2.1:8443", "[2001:db8::1]:8443",
+// "[fe80::1%eth0]:8443", "[::]:8443" or ":8443".
+func (a NetworkAddress) String() string {
+	return net.JoinHostPort(a.HostPort())
+}
+
+// ip returns the IP of the host, or nil if it's not an IP address.
+func (a NetworkAddress) ip() net.IP {
+	return net.ParseIP(a.Host)
+}
+
 // CanonicalNetworkAddress parses the given network address and returns a string of the form "host:port",
 // possibly filling it with the default port if it's missing. It will also wrap a bare IPv6 address with square
-// brackets if needed.
+// brackets if needed. Zone identifiers of link-local IPv6 addresses are kept, unescaped as in "[fe80::1%eth0]:8443".
+// Addresses referring to a network interface (e.g "%bond0") are kept as is, with the default port if missing.
+// Addresses which can't be parsed are returned unchanged.
 func CanonicalNetworkAddress(address string, defaultPort int) string {
-	host, port, err := net.SplitHostPort(address)
+	addr, err := ParseNetworkAddress(address, defaultPort)
 	if err != nil {
-		ip := net.ParseIP(address)
-		if ip != nil {
-			// If the input address is a bare IP address, then convert it to a proper listen address
-			// using the canonical IP with default port and wrap IPv6 addresses in square brackets.
-			address = net.JoinHostPort(ip.String(), fmt.Sprintf("%d", defaultPort))
-		} else {
-			// Otherwise assume this is either a host name or a partial address (e.g `[::]`) without
-			// a port number, so append the default port.
-			address = fmt.Sprintf("%s:%d", address, defaultPort)
-		}
-	} else if port == "" && address[len(address)-1] == ':' {
-		// An address that ends with a trailing colon will be parsed as having an empty port.
-		address = net.JoinHostPort(host, fmt.Sprintf("%d", defaultPort))
+		return address
+	}
+
+	return addr.String()
+}
+
+// NetworkAddressURLHost returns the canonical form of the given network address for use as the host of a URL,
+// escaping the zone identifier of link-local IPv6 addresses as in "[fe80::1%25eth0]:8443".
+func NetworkAddressURLHost(address string, defaultPort int) string {
+	addr, err := ParseNetworkAddress(address, defaultPort)
+	if err != nil {
+		return address
+	}
+
+	host, port := addr.HostPort()
+	if addr.Zone != "" {
+		host = addr.Host + "%25" + addr.Zone
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// parseIPZone parses an IP address, possibly followed by the zone identifier of an IPv6 address (e.g
+// `fe80::1%eth0`). The zone may be escaped as in URLs (e.g `fe80::1%25eth0`).
+// Returns nil if the address isn't valid, zones only being allowed on IPv6 addresses.
+func parseIPZone(address string) (net.IP, string) {
+	hostIP, zone, found := strings.Cut(address, "%")
+	ip := net.ParseIP(hostIP)
+	if ip == nil || !found {
+		return ip, ""
+	}
+
+	if strings.HasPrefix(zone, "25") && len(zone) > 2 {
+		zone = zone[2:]
+	}
+
+	if zone == "" || ip.To4() != nil || strings.ContainsAny(zone, "%[]:") {
+		return nil, ""
+	}
+
+	return ip, zone
+}
+
+// joinIPZone appends the zone identifier, if any, to an IPv6 address.
+func joinIPZone(ip string, zone string) string {
+	if zone == "" {
+		return ip
 	}
 
-	return address
+	return ip + "%" + zone
 }
 
 // CanonicalNetworkAddressFromAddressAndPort returns a network address from separate address and port values.
@@ -74,113 +251,578 @@ func NetworkInterfaceAddress() string {
 	return ""
 }
 
+// ExpandListenOptions controls which interface addresses ExpandListenAddresses returns for wildcard addresses.
+type ExpandListenOptions struct {
+	// ExcludeLoopback skips loopback addresses.
+	ExcludeLoopback bool
+
+	// ExcludeLinkLocal skips link-local addresses.
+	ExcludeLinkLocal bool
+
+	// ExcludeDown skips the addresses of interfaces which aren't up.
+	ExcludeDown bool
+
+	// ExcludeInterfaces skips the interfaces whose name matches any of these patterns, as used by path.Match.
+	ExcludeInterfaces []string
+}
+
+// ExpandListenAddresses returns a list of <host>:<port> combinations at which this machine can be reached.
+// It accepts the configured listen address in the following formats: <host>, <host>:<port> or :<port>.
+// If a listen port is not specified then then ports.HTTPSDefaultPort is used instead.
+// If a non-empty and non-wildcard host is passed in then this functions returns a single element list with the
+// listen address specified. Otherwise if an empty host or wildcard address is specified then the unicast
+// addresses configured on the host and matching the options are returned. If an IPv4 wildcard address (0.0.0.0)
+// is specified as the host then only IPv4 addresses configured on the host are returned. Addresses referring to
+// a network interface (e.g "%bond0:8443") are resolved to the current address of the interface.
+func ExpandListenAddresses(configListenAddress string, opts ExpandListenOptions) ([]string, error) {
+	addresses := make([]string, 0)
+
+	if configListenAddress == "" {
+		return addresses, nil
+	}
+
+	listenAddress, err := ParseNetworkAddress(configListenAddress, ports.HTTPSDefaultPort)
+	if err != nil {
+		return nil, err
+	}
+
+	// Addresses referring to a network interface are only reachable at the current address of the interface.
+	if IsInterfaceAddress(listenAddress.Host) {
+		address, err := ResolveListenAddress(configListenAddress, ports.HTTPSDefaultPort)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(addresses, address), nil
+	}
+
+	if !listenAddress.IsWildcard {
+		return append(addresses, listenAddress.String()), nil
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return addresses, err
+	}
+
+	listenIfaces := make([]listenInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		listenIfaces = append(listenIfaces, listenInterface{name: iface.Name, up: iface.Flags&net.FlagUp != 0, addrs: addrs})
+	}
+
+	_, localPort := listenAddress.HostPort()
+
+	return expandInterfaceAddresses(listenAddress.Host, localPort, listenIfaces, opts), nil
+}
+
+// listenInterface is a network interface along with its addresses.
+type listenInterface struct {
+	name  string
+	up    bool
+	addrs []net.Addr
+}
+
+// expandInterfaceAddresses returns the addresses of the interfaces matching the options and the wildcard host.
+func expandInterfaceAddresses(wildcard string, port string, ifaces []listenInterface, opts ExpandListenOptions) []string {
+	addresses := make([]string, 0)
+
+	for _, iface := range ifaces {
+		if opts.ExcludeDown && !iface.up {
+			continue
+		}
+
+		excluded := false
+		for _, pattern := range opts.ExcludeInterfaces {
+			match, _ := path.Match(pattern, iface.name)
+			if match {
+				excluded = true
+				break
+			}
+		}
+
+		if excluded {
+			continue
+		}
+
+		for _, addr := range iface.addrs {
+			var ip net.IP
+			switch v := addr.(type) {
+			case *net.IPNet:
+				ip = v.IP
+			case *net.IPAddr:
+				ip = v.IP
+			}
+
+			if ip == nil || ip.IsUnspecified() || ip.IsMulticast() || ip.Equal(net.IPv4bcast) {
+				continue
+			}
+
+			if opts.ExcludeLoopback && ip.IsLoopback() {
+				continue
+			}
+
+			if opts.ExcludeLinkLocal && ip.IsLinkLocalUnicast() {
+				continue
+			}
+
+			// IPv4 addresses may be represented as IPv4-mapped IPv6 addresses, which To4 handles.
+			if ip.To4() == nil && wildcard == "0.0.0.0" {
+				continue
+			}
+
+			// Link-local IPv6 addresses are only usable along with their interface.
+			host := ip.String()
+			if ip.To4() == nil && ip.IsLinkLocalUnicast() {
+				host = joinIPZone(host, iface.name)
+			}
+
+			address := net.JoinHostPort(host, port)
+			if !slices.Contains(addresses, address) {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+
+	return addresses
+}
+
+// ListenDualStack listens on the given TCP address, returning the listener along with the address families
+// ("ipv4" and "ipv6") it serves.
+//
+// Addresses with an empty host or the IPv6 wildcard (e.g `:8443` or `[::]:8443`) are served through separate IPv4
+// and IPv6 sockets rather than relying on IPv4-mapped addresses, which aren't available on hosts with
+// net.ipv6.bindv6only=1. If one of the families isn't available on the host, only the other one is served.
+// Other addresses are served through a single socket, the IPv4 wildcard (`0.0.0.0`) only serving IPv4.
+func ListenDualStack(address string) (net.Listener, []string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if host != "" && host != "::" {
+		network := "tcp"
+		if host == "0.0.0.0" {
+			network = "tcp4"
+		}
+
+		listener, err := net.Listen(network, address)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		family := "ipv6"
+		tcpAddr, ok := listener.Addr().(*net.TCPAddr)
+		if ok && tcpAddr.IP.To4() != nil {
+			family = "ipv4"
+		}
+
+		return listener, []string{family}, nil
+	}
+
+	listeners := []net.Listener{}
+	families := []string{}
+
+	// The IPv6 socket only accepts IPv6 connections when listening on `tcp6`.
+	listener6, err := net.Listen("tcp6", net.JoinHostPort("::", port))
+	if err != nil && !isFamilyUnavailable(err) {
+		return nil, nil, err
+	}
+
+	if err == nil {
+		listeners = append(listeners, listener6)
+		families = append(families, "ipv6")
+
+		// Use the same port for both families if it was picked by the kernel.
+		tcpAddr, ok := listener6.Addr().(*net.TCPAddr)
+		if ok {
+			port = fmt.Sprintf("%d", tcpAddr.Port)
+		}
+	}
+
+	listener4, err := net.Listen("tcp4", net.JoinHostPort("0.0.0.0", port))
+	if err != nil && (!isFamilyUnavailable(err) || len(listeners) == 0) {
+		for _, listener := range listeners {
+			_ = listener.Close()
+		}
+
+		return nil, nil, err
+	}
+
+	if err == nil {
+		listeners = append(listeners, listener4)
+		families = append(families, "ipv4")
+	}
+
+	if len(listeners) == 1 {
+		return listeners[0], families, nil
+	}
+
+	return newMultiListener(listeners), families, nil
+}
+
+// isFamilyUnavailable returns whether a listen error is caused by the address family being unavailable.
+func isFamilyUnavailable(err error) bool {
+	return errors.Is(err, syscall.EAFNOSUPPORT) || errors.Is(err, syscall.EPROTONOSUPPORT) || errors.Is(err, syscall.EADDRNOTAVAIL)
+}
+
+// multiListener accepts connections from several listeners.
+type multiListener struct {
+	listeners []net.Listener
+	accepted  chan acceptResult
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+func newMultiListener(listeners []net.Listener) *multiListener {
+	m := &multiListener{
+		listeners: listeners,
+		accepted:  make(chan acceptResult),
+		closed:    make(chan struct{}),
+	}
+
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			for {
+				conn, err := listener.Accept()
+				if err != nil && errors.Is(err, net.ErrClosed) {
+					return
+				}
+
+				select {
+				case m.accepted <- acceptResult{conn: conn, err: err}:
+				case <-m.closed:
+					if conn != nil {
+						_ = conn.Close()
+					}
+
+					return
+				}
+			}
+		}(listener)
+	}
+
+	return m
+}
+
+// Accept waits for and returns the next connection accepted by any of the listeners.
+func (m *multiListener) Accept() (net.Conn, error) {
+	select {
+	case result := <-m.accepted:
+		return result.conn, result.err
+	case <-m.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes all the listeners.
+// Any blocked Accept operations will be unblocked and return errors.
+func (m *multiListener) Close() error {
+	var errs []error
+	m.closeOnce.Do(func() {
+		close(m.closed)
+
+		for _, listener := range m.listeners {
+			err := listener.Close()
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// Addr returns the address of the first listener, the IPv6 one when serving both families.
+func (m *multiListener) Addr() net.Addr {
+	return m.listeners[0].Addr()
+}
+
 // IsAddressCovered detects if network address1 is actually covered by
 // address2, in the sense that they are either the same address or address2 is
-// specified using a wildcard with the same port of address1.
+// specified using a wildcard or a subnet (e.g `10.30.0.0/24:8443`) with the same port of address1.
+// Loopback addresses are covered by any loopback address of the same family and port, the names of the local host
+// (`localhost`, `ip6-localhost` and `localhost.localdomain`) being resolved without the resolver.
 func IsAddressCovered(address1, address2 string) bool {
-	address1 = CanonicalNetworkAddress(address1, ports.HTTPSDefaultPort)
-	address2 = CanonicalNetworkAddress(address2, ports.HTTPSDefaultPort)
+	return IsAddressCoveredContext(context.Background(), address1, address2)
+}
 
-	if address1 == address2 {
-		return true
+// IsAddressCoveredContext is IsAddressCovered, the context bounding the wait for host names to be resolved.
+// Host names which couldn't be resolved in time don't cover, nor are covered by, other addresses.
+func IsAddressCoveredContext(ctx context.Context, address1, address2 string) bool {
+	_, _, err := parseSubnetAddress(address2, ports.HTTPSDefaultPort)
+	if err == nil {
+		return isAddressInSubnetCovered(ctx, address1, address2)
 	}
 
-	host1, port1, err := net.SplitHostPort(address1)
+	addr1, err := ParseNetworkAddress(address1, ports.HTTPSDefaultPort)
 	if err != nil {
 		return false
 	}
 
-	host2, port2, err := net.SplitHostPort(address2)
+	addr2, err := ParseNetworkAddress(address2, ports.HTTPSDefaultPort)
 	if err != nil {
 		return false
 	}
 
+	if addr1 == addr2 {
+		return true
+	}
+
 	// If the ports are different, then address1 is clearly not covered by
 	// address2.
-	if port2 != port1 {
+	if addr1.Port != addr2.Port {
 		return false
 	}
 
-	// If address1 contains a host name, let's try to resolve it, in order
-	// to compare the actual IPs.
-	var addresses1 []net.IP
-	if host1 != "" {
-		ip := net.ParseIP(host1)
-		if ip != nil {
-			addresses1 = append(addresses1, ip)
-		} else {
-			ips, err := net.LookupHost(host1)
-			if err == nil && len(ips) > 0 {
-				for _, ipStr := range ips {
-					ip := net.ParseIP(ipStr)
-					if ip != nil {
-						addresses1 = append(addresses1, ip)
-					}
+	// If the addresses contain host names, let's try to resolve them, in order
+	// to compare the actual IPs. Link-local addresses only match within the same zone.
+	if addr1.Zone == addr2.Zone {
+		addresses1 := resolveAddress(ctx, addr1)
+		addresses2 := resolveAddress(ctx, addr2)
+
+		for _, a1 := range addresses1 {
+			for _, a2 := range addresses2 {
+				// Loopback addresses are all served by a loopback listener of the same family.
+				if a1.Equal(a2) || sameLoopback(a1, a2) {
+					return true
 				}
 			}
 		}
 	}
 
-	// If address2 contains a host name, let's try to resolve it, in order
-	// to compare the actual IPs.
-	var addresses2 []net.IP
-	if host2 != "" {
-		ip := net.ParseIP(host2)
-		if ip != nil {
-			addresses2 = append(addresses2, ip)
-		} else {
-			ips, err := net.LookupHost(host2)
-			if err == nil && len(ips) > 0 {
-				for _, ipStr := range ips {
-					ip := net.ParseIP(ipStr)
-					if ip != nil {
-						addresses2 = append(addresses2, ip)
-					}
-				}
+	// If address2 is using an IPv4 wildcard for the host, then address2 is
+	// only covered if it's an IPv4 address.
+	if addr2.Host == "0.0.0.0" {
+		return addr1.ip() != nil && !addr1.IsIPv6
+	}
+
+	// If address2 is using an IPv6 wildcard for the host, then address2 is
+	// always covered, whatever the zone.
+	return addr2.IsWildcard
+}
+
+// IsAddressInSubnetCovered detects if network address is covered by a subnet with a port, such as
+// `10.30.0.0/24:8443` or `[fd00::/64]:8443`, the default port being assumed if missing.
+// The address is covered if it uses the same port and its host, or one of the IPs it resolves to, is in the subnet.
+func IsAddressInSubnetCovered(address string, cidrWithPort string) bool {
+	return isAddressInSubnetCovered(context.Background(), address, cidrWithPort)
+}
+
+func isAddressInSubnetCovered(ctx context.Context, address string, cidrWithPort string) bool {
+	subnet, subnetPort, err := parseSubnetAddress(cidrWithPort, ports.HTTPSDefaultPort)
+	if err != nil {
+		return false
+	}
+
+	addr, err := ParseNetworkAddress(address, ports.HTTPSDefaultPort)
+	if err != nil {
+		return false
+	}
+
+	if addr.Port != subnetPort {
+		return false
+	}
+
+	for _, ip := range resolveAddress(ctx, addr) {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSubnetAddress parses a subnet, possibly followed by a port, returning the default port if missing.
+func parseSubnetAddress(address string, defaultPort int) (*net.IPNet, int, error) {
+	if !strings.Contains(address, "/") {
+		return nil, 0, fmt.Errorf("Not a subnet %q", address)
+	}
+
+	// Without a port, e.g `10.30.0.0/24`, `fd00::/64` or `[fd00::/64]`.
+	_, subnet, err := net.ParseCIDR(strings.TrimSuffix(strings.TrimPrefix(address, "["), "]"))
+	if err == nil {
+		return subnet, defaultPort, nil
+	}
+
+	host, port, _, err := splitNetworkAddress(address)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Invalid subnet address %q: %w", address, err)
+	}
+
+	_, subnet, err = net.ParseCIDR(host)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Invalid subnet address %q: %w", address, err)
+	}
+
+	if port == "" {
+		return subnet, defaultPort, nil
+	}
+
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Invalid port %q in subnet address %q", port, address)
+	}
+
+	return subnet, int(portNumber), nil
+}
+
+// AddressesConflict detects if network addresses address1 and address2 overlap, in the sense that they use the
+// same port and share at least one IP once their host names are resolved to all their addresses. Wildcards overlap
+// with any address of the families they serve. Returns the overlapping addresses in the "host:port" form.
+func AddressesConflict(address1, address2 string) (bool, []string) {
+	addr1, err := ParseNetworkAddress(address1, ports.HTTPSDefaultPort)
+	if err != nil {
+		return false, nil
+	}
+
+	addr2, err := ParseNetworkAddress(address2, ports.HTTPSDefaultPort)
+	if err != nil {
+		return false, nil
+	}
+
+	if addr1.Port != addr2.Port {
+		return false, nil
+	}
+
+	port := strconv.Itoa(addr1.Port)
+	wildcard1 := wildcardFamily(addr1)
+	wildcard2 := wildcardFamily(addr2)
+
+	// Two wildcards overlap on the IPv4 addresses if either only serves IPv4.
+	if wildcard1 != "" && wildcard2 != "" {
+		if wildcard1 == "ipv4" || wildcard2 == "ipv4" {
+			return true, []string{net.JoinHostPort("0.0.0.0", port)}
+		}
+
+		return true, []string{net.JoinHostPort("::", port)}
+	}
+
+	ctx := context.Background()
+	addresses1 := resolveAddress(ctx, addr1)
+	addresses2 := resolveAddress(ctx, addr2)
+
+	// A wildcard overlaps with the addresses of the other side in the families it serves.
+	if wildcard1 != "" || wildcard2 != "" {
+		wildcard, addresses, zone := wildcard1, addresses2, addr2.Zone
+		if wildcard == "" {
+			wildcard, addresses, zone = wildcard2, addresses1, addr1.Zone
+		}
+
+		overlaps := []string{}
+		for _, ip := range addresses {
+			if wildcard == "ipv4" && ip.To4() == nil {
+				continue
 			}
+
+			overlaps = append(overlaps, net.JoinHostPort(joinIPZone(ip.String(), zone), port))
 		}
+
+		return len(overlaps) > 0, overlaps
+	}
+
+	if addr1.Zone != addr2.Zone {
+		return false, nil
 	}
 
+	overlaps := []string{}
 	for _, a1 := range addresses1 {
 		for _, a2 := range addresses2 {
-			if a1.Equal(a2) {
-				return true
+			if !a1.Equal(a2) {
+				continue
+			}
+
+			overlap := net.JoinHostPort(joinIPZone(a1.String(), addr1.Zone), port)
+			if !slices.Contains(overlaps, overlap) {
+				overlaps = append(overlaps, overlap)
 			}
 		}
 	}
 
-	// If address2 is using an IPv4 wildcard for the host, then address2 is
-	// only covered if it's an IPv4 address.
-	if host2 == "0.0.0.0" {
-		ip1 := net.ParseIP(host1)
-		if ip1 != nil && ip1.To4() != nil {
-			return true
+	return len(overlaps) > 0, overlaps
+}
+
+// wildcardFamily returns the families served by a wildcard address ("any" or "ipv4"), or "" for other addresses.
+func wildcardFamily(addr NetworkAddress) string {
+	if !addr.IsWildcard {
+		return ""
+	}
+
+	if addr.Host == "0.0.0.0" {
+		return "ipv4"
+	}
+
+	return "any"
+}
+
+// resolveAddress returns the IPs of the host of the given address, none for wildcards.
+// Host names are resolved through the cache of LookupHostIPs, and network interfaces to their current address.
+func resolveAddress(ctx context.Context, addr NetworkAddress) []net.IP {
+	if addr.Host == "" {
+		return nil
+	}
+
+	if IsInterfaceAddress(addr.Host) {
+		ip, err := resolveInterfaceHost(addr.Host)
+		if err != nil {
+			return nil
 		}
 
-		return false
+		return []net.IP{ip}
 	}
 
-	// If address2 is using an IPv6 wildcard for the host, then address2 is
-	// always covered.
-	if host2 == "::" || host2 == "" {
-		return true
+	ip := addr.ip()
+	if ip != nil {
+		return []net.IP{ip}
 	}
 
-	return false
+	ips := loopbackHostIPs(addr.Host)
+	if ips != nil {
+		return ips
+	}
+
+	ips, err := LookupHostIPs(ctx, addr.Host)
+	if err != nil {
+		return nil
+	}
+
+	return ips
+}
+
+// loopbackHosts are the names of the local host, mapped to their loopback addresses whatever /etc/hosts says, so
+// that addresses using them are handled the same on every host.
+var loopbackHosts = map[string][]net.IP{
+	"localhost":             {net.IPv4(127, 0, 0, 1).To4(), net.IPv6loopback},
+	"localhost.localdomain": {net.IPv4(127, 0, 0, 1).To4(), net.IPv6loopback},
+	"ip6-localhost":         {net.IPv6loopback},
+}
+
+// loopbackHostIPs returns the loopback addresses of the given host name if it's one of the names of the local
+// host, nil otherwise.
+func loopbackHostIPs(host string) []net.IP {
+	return slices.Clone(loopbackHosts[strings.TrimSuffix(strings.ToLower(host), ".")])
+}
+
+// sameLoopback returns whether both IPs are loopback addresses of the same family.
+func sameLoopback(ip1 net.IP, ip2 net.IP) bool {
+	return ip1.IsLoopback() && ip2.IsLoopback() && (ip1.To4() == nil) == (ip2.To4() == nil)
 }
 
 // IsWildCardAddress returns whether the given address is a wildcard.
 func IsWildCardAddress(address string) bool {
-	address = CanonicalNetworkAddress(address, ports.HTTPSDefaultPort)
-
-	host, _, err := net.SplitHostPort(address)
+	addr, err := ParseNetworkAddress(address, ports.HTTPSDefaultPort)
 	if err != nil {
 		return false
 	}
 
-	if host == "0.0.0.0" || host == "::" || host == "" {
-		return true
-	}
-
-	return false
+	return addr.IsWildcard
 }
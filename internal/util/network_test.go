@@ -0,0 +1,448 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/ports"
+)
+
+func TestParseNetworkAddress(t *testing.T) {
+	cases := []struct {
+		in   string
+		addr NetworkAddress
+		out  string
+	}{
+		{"127.0.0.1", NetworkAddress{Host: "127.0.0.1", Port: 8443}, "127.0.0.1:8443"},
+		{"127.0.0.1:", NetworkAddress{Host: "127.0.0.1", Port: 8443}, "127.0.0.1:8443"},
+		{"[127.0.0.1]:443", NetworkAddress{Host: "127.0.0.1", Port: 443}, "127.0.0.1:443"},
+		{"192.168.1.1:443", NetworkAddress{Host: "192.168.1.1", Port: 443}, "192.168.1.1:443"},
+		{"foo.bar", NetworkAddress{Host: "foo.bar", Port: 8443}, "foo.bar:8443"},
+		{"foo.bar:", NetworkAddress{Host: "foo.bar", Port: 8443}, "foo.bar:8443"},
+		{"foo.bar:8444", NetworkAddress{Host: "foo.bar", Port: 8444}, "foo.bar:8444"},
+		{"f921:7358:4510:3fce:ac2e:844:2a35:54e", NetworkAddress{Host: "f921:7358:4510:3fce:ac2e:844:2a35:54e", Port: 8443, IsIPv6: true}, "[f921:7358:4510:3fce:ac2e:844:2a35:54e]:8443"},
+		{"[f921:7358:4510:3fce:ac2e:844:2a35:54e]", NetworkAddress{Host: "f921:7358:4510:3fce:ac2e:844:2a35:54e", Port: 8443, IsIPv6: true}, "[f921:7358:4510:3fce:ac2e:844:2a35:54e]:8443"},
+		{"[f921:7358:4510:3fce:ac2e:844:2a35:54e]:", NetworkAddress{Host: "f921:7358:4510:3fce:ac2e:844:2a35:54e", Port: 8443, IsIPv6: true}, "[f921:7358:4510:3fce:ac2e:844:2a35:54e]:8443"},
+		{"[f921:7358:4510:3fce:ac2e:844:2a35:54e]:8444", NetworkAddress{Host: "f921:7358:4510:3fce:ac2e:844:2a35:54e", Port: 8444, IsIPv6: true}, "[f921:7358:4510:3fce:ac2e:844:2a35:54e]:8444"},
+		{"[2001:DB8:0::1]", NetworkAddress{Host: "2001:db8::1", Port: 8443, IsIPv6: true}, "[2001:db8::1]:8443"},
+		{"fe80::1%vlan10", NetworkAddress{Host: "fe80::1", Port: 8443, Zone: "vlan10", IsIPv6: true}, "[fe80::1%vlan10]:8443"},
+		{"[fe80::1%vlan10]", NetworkAddress{Host: "fe80::1", Port: 8443, Zone: "vlan10", IsIPv6: true}, "[fe80::1%vlan10]:8443"},
+		{"[fe80::1%vlan10]:", NetworkAddress{Host: "fe80::1", Port: 8443, Zone: "vlan10", IsIPv6: true}, "[fe80::1%vlan10]:8443"},
+		{"[fe80::1%vlan10]:8444", NetworkAddress{Host: "fe80::1", Port: 8444, Zone: "vlan10", IsIPv6: true}, "[fe80::1%vlan10]:8444"},
+		{"[fe80::1%25vlan10]", NetworkAddress{Host: "fe80::1", Port: 8443, Zone: "vlan10", IsIPv6: true}, "[fe80::1%vlan10]:8443"},
+		{"[fe80::1%25vlan10]:8444", NetworkAddress{Host: "fe80::1", Port: 8444, Zone: "vlan10", IsIPv6: true}, "[fe80::1%vlan10]:8444"},
+		{":8444", NetworkAddress{Port: 8444, IsWildcard: true}, ":8444"},
+		{":", NetworkAddress{Port: 8443, IsWildcard: true}, ":8443"},
+		{"0.0.0.0", NetworkAddress{Host: "0.0.0.0", Port: 8443, IsWildcard: true}, "0.0.0.0:8443"},
+		{"::", NetworkAddress{Host: "::", Port: 8443, IsWildcard: true, IsIPv6: true}, "[::]:8443"},
+		{"[::]", NetworkAddress{Host: "::", Port: 8443, IsWildcard: true, IsIPv6: true}, "[::]:8443"},
+		{"[::]:8444", NetworkAddress{Host: "::", Port: 8444, IsWildcard: true, IsIPv6: true}, "[::]:8444"},
+		{"%bond0", NetworkAddress{Host: "%bond0", Port: 8443}, "%bond0:8443"},
+		{"%bond0:", NetworkAddress{Host: "%bond0", Port: 8443}, "%bond0:8443"},
+		{"%bond0/4", NetworkAddress{Host: "%bond0/4", Port: 8443}, "%bond0/4:8443"},
+		{"%bond0/6:8444", NetworkAddress{Host: "%bond0/6", Port: 8444}, "%bond0/6:8444"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.in, func(t *testing.T) {
+			addr, err := ParseNetworkAddress(c.in, ports.HTTPSDefaultPort)
+			require.NoError(t, err)
+			assert.Equal(t, c.addr, addr)
+			assert.Equal(t, c.out, addr.String())
+			assert.Equal(t, c.out, CanonicalNetworkAddress(c.in, ports.HTTPSDefaultPort))
+
+			// The canonical form parses back to the same address.
+			again, err := ParseNetworkAddress(addr.String(), 1)
+			require.NoError(t, err)
+			assert.Equal(t, addr, again)
+
+			host, port := addr.HostPort()
+			assert.Equal(t, c.out, net.JoinHostPort(host, port))
+		})
+	}
+}
+
+func TestParseNetworkAddressInvalid(t *testing.T) {
+	cases := []string{
+		"",
+		"[::1",
+		"::1]",
+		"[::1]x",
+		"[::1]:8443:8444",
+		"[::1]]:8443",
+		"[foo.bar]:8443",
+		"[127.0.0.1%eth0]",
+		"127.0.0.1%eth0:8443",
+		"fe80::1%:8443",
+		"127.0.0.1:99999",
+		"127.0.0.1:-1",
+		"127.0.0.1:https",
+		"foo.bar:8443:8444",
+		"foo bar:8443",
+		"foo/bar",
+		"https://foo.bar:8443",
+		"%:8443",
+		"%bond0/5:8443",
+		"[%bond0]:8443",
+	}
+
+	for _, in := range cases {
+		t.Run(in, func(t *testing.T) {
+			_, err := ParseNetworkAddress(in, ports.HTTPSDefaultPort)
+			assert.Error(t, err)
+
+			// Addresses which can't be parsed are left alone.
+			assert.Equal(t, in, CanonicalNetworkAddress(in, ports.HTTPSDefaultPort))
+		})
+	}
+}
+
+func TestNetworkAddressURLHost(t *testing.T) {
+	cases := map[string]string{
+		"127.0.0.1":             "127.0.0.1:8443",
+		"foo.bar:8444":          "foo.bar:8444",
+		"[::1]":                 "[::1]:8443",
+		"fe80::1%vlan10":        "[fe80::1%25vlan10]:8443",
+		"[fe80::1%vlan10]:8444": "[fe80::1%25vlan10]:8444",
+		"[fe80::1%25vlan10]":    "[fe80::1%25vlan10]:8443",
+	}
+
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			host := NetworkAddressURLHost(in, ports.HTTPSDefaultPort)
+			assert.Equal(t, out, host)
+
+			// The result parses back as the host of a URL.
+			u, err := url.Parse("https://" + host)
+			require.NoError(t, err)
+			assert.Equal(t, CanonicalNetworkAddress(in, ports.HTTPSDefaultPort), u.Host)
+		})
+	}
+}
+
+func TestIsAddressCovered(t *testing.T) {
+	t.Cleanup(setResolver(testResolver))
+
+	type testCase struct {
+		address1 string
+		address2 string
+		covered  bool
+	}
+
+	cases := []testCase{
+		{"127.0.0.1:8443", "127.0.0.1:8443", true},
+		{"garbage", "127.0.0.1:8443", false},
+		{"127.0.0.1:8444", "garbage", false},
+		{"127.0.0.1:8444", "127.0.0.1:8443", false},
+		{"127.0.0.1:8443", "0.0.0.0:8443", true},
+		{"[::1]:8443", "0.0.0.0:8443", false},
+		{":8443", "0.0.0.0:8443", false},
+		{"127.0.0.1:8443", "[::]:8443", true},
+		{"[::1]:8443", "[::]:8443", true},
+		{"[::1]:8443", ":8443", true},
+		{":8443", "[::]:8443", true},
+		{"0.0.0.0:8443", "[::]:8443", true},
+		{"10.30.0.8:8443", "[::]", true},
+		{"localhost:8443", "127.0.0.1:8443", true},
+		{"127.0.0.1:8443", "localhost:8443", true},
+		{"[::1]:8443", "ip6-localhost:8443", true},
+		{"[::1]:8443", "localhost:8443", true},
+		{"127.0.0.1:8443", "ip6-localhost:8443", false},
+		{"localhost.localdomain:8443", "127.0.0.1:8443", true},
+		{"LOCALHOST:8443", "[::1]:8443", true},
+		{"localhost.:8443", "127.0.0.1:8443", true},
+		{"localhost:8444", "127.0.0.1:8443", false},
+		{"192.0.2.99:8443", "localhost:8443", false},
+		{"127.0.0.2:8443", "127.0.0.1:8443", true},
+		{"127.0.0.1:8443", "127.1.2.3:8443", true},
+		{"myhost:8443", "localhost:8443", true},
+		{"[::1]:8443", "127.0.0.1:8443", false},
+		{"127.0.0.1:8443", "[::1]:8443", false},
+		{"127.0.0.2:8444", "127.0.0.1:8443", false},
+		{"missing:8443", "127.0.0.1:8443", false},
+		{"[fe80::1%vlan10]:8443", "[fe80::1%vlan10]:8443", true},
+		{"fe80::1%vlan10", "[fe80::1%25vlan10]:8443", true},
+		{"[fe80::1%vlan10]:8443", "[fe80::1%vlan20]:8443", false},
+		{"[fe80::1%vlan10]:8443", "[fe80::1]:8443", false},
+		{"[fe80::1%vlan10]:8444", "[fe80::1%vlan10]:8443", false},
+		{"[fe80::1%vlan10]:8443", "[::]:8443", true},
+		{"[fe80::1%vlan10]:8443", ":8443", true},
+		{"[fe80::1%vlan10]:8443", "0.0.0.0:8443", false},
+		{"10.30.0.8:8443", "10.30.0.0/24:8443", true},
+		{"10.30.0.8:8443", "10.30.1.0/24:8443", false},
+		{"[fd00::8]:8443", "[fd00::/64]:8443", true},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s-%s", c.address1, c.address2), func(t *testing.T) {
+			covered := IsAddressCovered(c.address1, c.address2)
+			if c.covered {
+				assert.True(t, covered)
+			} else {
+				assert.False(t, covered)
+			}
+		})
+	}
+}
+
+func TestIsAddressInSubnetCovered(t *testing.T) {
+	t.Cleanup(setResolver(testResolver))
+
+	cases := []struct {
+		address string
+		subnet  string
+		covered bool
+	}{
+		// IPv4.
+		{"10.30.0.8:8443", "10.30.0.0/24:8443", true},
+		{"10.30.0.8", "10.30.0.0/24", true},
+		{"10.30.0.8:8443", "10.30.0.0/24", true},
+		{"10.30.0.8:8443", "10.30.0.0/24:", true},
+		{"10.30.0.8", "10.30.0.8/32:8443", true},
+		{"10.30.0.0:8443", "10.30.0.0/24:8443", true},
+		{"10.30.0.255:8443", "10.30.0.0/24:8443", true},
+		{"10.30.1.8:8443", "10.30.0.0/24:8443", false},
+		{"10.30.0.8:8444", "10.30.0.0/24:8443", false},
+		{"10.30.0.8:8443", "10.30.0.5/24:8443", true},
+		{"10.30.0.8:8443", "0.0.0.0/0:8443", true},
+
+		// IPv6.
+		{"[fd00::8]:8443", "[fd00::/64]:8443", true},
+		{"fd00::8", "fd00::/64", true},
+		{"[fd00::8]:8443", "[fd00::/64]", true},
+		{"[fd00:0:0:1::8]:8443", "[fd00::/64]:8443", false},
+		{"[fd00::8]:8444", "[fd00::/64]:8443", false},
+		{"[fe80::1%vlan10]:8443", "[fe80::/64]:8443", true},
+		{"[fd00::8]:8443", "[::/0]:8443", true},
+
+		// Mixed families.
+		{"10.30.0.8:8443", "[fd00::/64]:8443", false},
+		{"[fd00::8]:8443", "10.30.0.0/24:8443", false},
+		{"10.30.0.8:8443", "[::ffff:10.30.0.0/120]:8443", true},
+
+		// Host names.
+		{"localhost:8443", "127.0.0.0/8:8443", true},
+		{"localhost:8444", "127.0.0.0/8:8443", false},
+
+		// Invalid input.
+		{"garbage", "10.30.0.0/24:8443", false},
+		{"10.30.0.8:8443", "10.30.0.0:8443", false},
+		{"10.30.0.8:8443", "10.30.0.0/33:8443", false},
+		{"10.30.0.8:8443", "garbage/24", false},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s-%s", c.address, c.subnet), func(t *testing.T) {
+			assert.Equal(t, c.covered, IsAddressInSubnetCovered(c.address, c.subnet))
+		})
+	}
+}
+
+func TestAddressesConflict(t *testing.T) {
+	t.Cleanup(setResolver(&fakeResolver{hosts: map[string][]string{
+		"localhost":     {"127.0.0.1", "::1"},
+		"multi.example": {"192.0.2.1", "192.0.2.2", "2001:db8::1"},
+		"other.example": {"192.0.2.2"},
+		"v6.example":    {"2001:db8::1"},
+		"far.example":   {"198.51.100.1"},
+	}}))
+
+	cases := []struct {
+		address1  string
+		address2  string
+		conflict  bool
+		addresses []string
+	}{
+		// Concrete addresses.
+		{"192.0.2.1:8443", "192.0.2.1:8443", true, []string{"192.0.2.1:8443"}},
+		{"192.0.2.1", "192.0.2.1:8443", true, []string{"192.0.2.1:8443"}},
+		{"192.0.2.1:8443", "192.0.2.1:8444", false, nil},
+		{"192.0.2.1:8443", "192.0.2.2:8443", false, nil},
+		{"[2001:db8::1]:8443", "[2001:db8:0::1]:8443", true, []string{"[2001:db8::1]:8443"}},
+		{"[fe80::1%eth0]:8443", "[fe80::1%eth0]:8443", true, []string{"[fe80::1%eth0]:8443"}},
+		{"[fe80::1%eth0]:8443", "[fe80::1%eth1]:8443", false, nil},
+
+		// Host names with several records.
+		{"multi.example:8443", "192.0.2.2:8443", true, []string{"192.0.2.2:8443"}},
+		{"multi.example:8443", "other.example:8443", true, []string{"192.0.2.2:8443"}},
+		{"multi.example:8443", "v6.example:8443", true, []string{"[2001:db8::1]:8443"}},
+		{"multi.example:8443", "multi.example:8443", true, []string{"192.0.2.1:8443", "192.0.2.2:8443", "[2001:db8::1]:8443"}},
+		{"multi.example:8443", "far.example:8443", false, nil},
+		{"multi.example:8443", "other.example:8444", false, nil},
+		{"missing.example:8443", "192.0.2.1:8443", false, nil},
+		{"localhost:8443", "[::1]:8443", true, []string{"[::1]:8443"}},
+
+		// Wildcards.
+		{"[::]:8443", "192.0.2.1:8443", true, []string{"192.0.2.1:8443"}},
+		{"192.0.2.1:8443", ":8443", true, []string{"192.0.2.1:8443"}},
+		{"0.0.0.0:8443", "[2001:db8::1]:8443", false, nil},
+		{"0.0.0.0:8443", "multi.example:8443", true, []string{"192.0.2.1:8443", "192.0.2.2:8443"}},
+		{"v6.example:8443", "0.0.0.0:8443", false, nil},
+		{"[::]:8443", "0.0.0.0:8443", true, []string{"0.0.0.0:8443"}},
+		{":8443", "[::]:8443", true, []string{"[::]:8443"}},
+		{"[::]:8443", "[::]:8444", false, nil},
+
+		// Invalid input.
+		{"garbage:1:2", "192.0.2.1:8443", false, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s-%s", c.address1, c.address2), func(t *testing.T) {
+			conflict, addresses := AddressesConflict(c.address1, c.address2)
+			assert.Equal(t, c.conflict, conflict)
+			if c.conflict {
+				assert.Equal(t, c.addresses, addresses)
+			}
+
+			// The check is symmetric.
+			reverse, _ := AddressesConflict(c.address2, c.address1)
+			assert.Equal(t, conflict, reverse)
+		})
+	}
+}
+
+func ExampleExpandListenAddresses() {
+	listenAddressConfigs := []string{
+		"",
+		"127.0.0.1:8000",   // Valid IPv4 address with port.
+		"127.0.0.1",        // Valid IPv4 address without port.
+		"[127.0.0.1]",      // Valid wrapped IPv4 address without port.
+		"[::1]:8000",       // Valid IPv6 address with port.
+		"::1:8000",         // Valid IPv6 address without port (that might look like a port).
+		"::1",              // Valid IPv6 address without port.
+		"[::1]",            // Valid wrapped IPv6 address without port.
+		"example.com",      // Valid hostname without port.
+		"example.com:8000", // Valid hostname with port.
+		"foo:8000:9000",    // Invalid host and port combination.
+		":::8000",          // Invalid host and port combination.
+	}
+
+	for _, listlistenAddressConfig := range listenAddressConfigs {
+		listenAddress, err := ExpandListenAddresses(listlistenAddressConfig, ExpandListenOptions{})
+		fmt.Printf("%q: %v %v\n", listlistenAddressConfig, listenAddress, err)
+	}
+
+	// Output: "": [] <nil>
+	// "127.0.0.1:8000": [127.0.0.1:8000] <nil>
+	// "127.0.0.1": [127.0.0.1:8443] <nil>
+	// "[127.0.0.1]": [127.0.0.1:8443] <nil>
+	// "[::1]:8000": [[::1]:8000] <nil>
+	// "::1:8000": [[::1:8000]:8443] <nil>
+	// "::1": [[::1]:8443] <nil>
+	// "[::1]": [[::1]:8443] <nil>
+	// "example.com": [example.com:8443] <nil>
+	// "example.com:8000": [example.com:8000] <nil>
+	// "foo:8000:9000": [] Invalid address "foo:8000:9000", IPv6 addresses must be wrapped in square brackets when followed by a port
+	// ":::8000": [] Invalid address ":::8000", IPv6 addresses must be wrapped in square brackets when followed by a port
+}
+
+func TestExpandInterfaceAddresses(t *testing.T) {
+	ipNet := func(cidr string) net.Addr {
+		ip, subnet, _ := net.ParseCIDR(cidr)
+		subnet.IP = ip
+		return subnet
+	}
+
+	ifaces := []listenInterface{
+		{name: "lo", up: true, addrs: []net.Addr{ipNet("127.0.0.1/8"), ipNet("::1/128")}},
+		{name: "eth0", up: true, addrs: []net.Addr{ipNet("10.0.0.2/24"), ipNet("2001:db8::2/64"), ipNet("fe80::2/64")}},
+		{name: "eth1", up: true, addrs: []net.Addr{&net.IPAddr{IP: net.ParseIP("::ffff:192.0.2.1")}, ipNet("169.254.0.1/16")}},
+		{name: "eth2", up: false, addrs: []net.Addr{ipNet("10.1.0.2/24")}},
+		{name: "vethabcd", up: true, addrs: []net.Addr{ipNet("10.2.0.2/24")}},
+		{name: "dummy0", up: true, addrs: []net.Addr{ipNet("224.0.0.1/32"), &net.IPAddr{IP: net.IPv6unspecified}, ipNet("10.0.0.2/24")}},
+	}
+
+	tests := []struct {
+		name     string
+		wildcard string
+		opts     ExpandListenOptions
+		want     []string
+	}{
+		{
+			name:     "Dual-stack with everything",
+			wildcard: "::",
+			want:     []string{"127.0.0.1:8443", "[::1]:8443", "10.0.0.2:8443", "[2001:db8::2]:8443", "[fe80::2%eth0]:8443", "192.0.2.1:8443", "169.254.0.1:8443", "10.1.0.2:8443", "10.2.0.2:8443"},
+		},
+		{
+			name:     "Empty host is dual-stack",
+			wildcard: "",
+			opts:     ExpandListenOptions{ExcludeLoopback: true, ExcludeLinkLocal: true, ExcludeDown: true, ExcludeInterfaces: []string{"veth*"}},
+			want:     []string{"10.0.0.2:8443", "[2001:db8::2]:8443", "192.0.2.1:8443"},
+		},
+		{
+			name:     "IPv4 only, including IPv4-mapped addresses",
+			wildcard: "0.0.0.0",
+			opts:     ExpandListenOptions{ExcludeLoopback: true},
+			want:     []string{"10.0.0.2:8443", "192.0.2.1:8443", "169.254.0.1:8443", "10.1.0.2:8443", "10.2.0.2:8443"},
+		},
+		{
+			name:     "Interface patterns",
+			wildcard: "::",
+			opts:     ExpandListenOptions{ExcludeInterfaces: []string{"lo", "eth*", "veth*"}},
+			want:     []string{"10.0.0.2:8443"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, expandInterfaceAddresses(tt.wildcard, "8443", ifaces, tt.opts))
+		})
+	}
+}
+
+func TestListenDualStack(t *testing.T) {
+	// Wildcard addresses serve whichever families the host has, on the same port.
+	listener, families, err := ListenDualStack(":0")
+	require.NoError(t, err)
+	require.NotEmpty(t, families)
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+
+	loopbacks := map[string]string{"ipv4": "127.0.0.1", "ipv6": "::1"}
+	for _, family := range families {
+		conn, err := net.Dial("tcp", net.JoinHostPort(loopbacks[family], port))
+		require.NoError(t, err)
+
+		accepted, err := listener.Accept()
+		require.NoError(t, err)
+
+		host, _, err := net.SplitHostPort(accepted.RemoteAddr().String())
+		require.NoError(t, err)
+		assert.Equal(t, loopbacks[family], host)
+
+		_ = accepted.Close()
+		_ = conn.Close()
+	}
+
+	// Closing unblocks pending Accept calls.
+	errCh := make(chan error)
+	go func() {
+		_, err := listener.Accept()
+		errCh <- err
+	}()
+
+	require.NoError(t, listener.Close())
+	assert.True(t, errors.Is(<-errCh, net.ErrClosed))
+
+	// The IPv4 wildcard only serves IPv4.
+	listener, families, err = ListenDualStack("0.0.0.0:0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ipv4"}, families)
+	_ = listener.Close()
+
+	// Specific addresses use a single socket.
+	listener, families, err = ListenDualStack("127.0.0.1:0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ipv4"}, families)
+	_ = listener.Close()
+
+	_, _, err = ListenDualStack("garbage")
+	assert.Error(t, err)
+}
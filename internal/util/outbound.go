@@ -0,0 +1,99 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/lxc/incus/v6/internal/ports"
+)
+
+// outboundDial sets up a UDP socket towards the given address, replaced in tests.
+var outboundDial = func(address string) (net.Conn, error) {
+	return net.Dial("udp", address)
+}
+
+// hostAddrs returns the addresses of all the network interfaces of the host, replaced in tests.
+var hostAddrs = net.InterfaceAddrs
+
+// GetOutboundAddress returns the local address used to reach the given target, in its canonical form with the
+// default HTTPS port. The source address is chosen by the kernel routing table by connecting a UDP socket to the
+// target, which doesn't send any packet. If the target is unroutable, the first global address of the host is
+// returned instead, preferring the family of the target.
+func GetOutboundAddress(target string) (string, error) {
+	addr, err := ParseNetworkAddress(target, ports.HTTPSDefaultPort)
+	if err != nil {
+		return "", err
+	}
+
+	ip := outboundIP(addr)
+	if ip == nil {
+		ip, err = firstGlobalAddress(addr.IsIPv6)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return net.JoinHostPort(ip.String(), strconv.Itoa(ports.HTTPSDefaultPort)), nil
+}
+
+// outboundIP returns the source address the kernel picks to reach the given address, nil if it isn't routable.
+func outboundIP(addr NetworkAddress) net.IP {
+	conn, err := outboundDial(addr.String())
+	if err != nil {
+		return nil
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	local, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok || local.IP == nil || local.IP.IsUnspecified() {
+		return nil
+	}
+
+	if local.IP.To4() != nil {
+		return local.IP.To4()
+	}
+
+	return local.IP
+}
+
+// firstGlobalAddress returns the first global unicast address of the host, of the IPv6 family if preferIPv6 is
+// true and of the IPv4 family otherwise, the other family being used if the host has no such address.
+func firstGlobalAddress(preferIPv6 bool) (net.IP, error) {
+	addrs, err := hostAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting host addresses: %w", err)
+	}
+
+	var ipv4, ipv6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+
+		if ipNet.IP.To4() != nil {
+			if ipv4 == nil {
+				ipv4 = ipNet.IP.To4()
+			}
+		} else if ipv6 == nil {
+			ipv6 = ipNet.IP
+		}
+	}
+
+	preferred, fallback := ipv4, ipv6
+	if preferIPv6 {
+		preferred, fallback = ipv6, ipv4
+	}
+
+	if preferred != nil {
+		return preferred, nil
+	}
+
+	if fallback != nil {
+		return fallback, nil
+	}
+
+	return nil, fmt.Errorf("No global address found on the host")
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outboundConn is a connection which only reports its local address.
+type outboundConn struct {
+	net.Conn
+
+	local net.Addr
+}
+
+func (c *outboundConn) LocalAddr() net.Addr { return c.local }
+func (c *outboundConn) Close() error        { return nil }
+
+// setOutboundRoutes replaces the routing decisions, mapping target hosts to source addresses, and the addresses of
+// the host for the duration of the test.
+func setOutboundRoutes(t *testing.T, routes map[string]string, addrs []string) {
+	oldDial, oldAddrs := outboundDial, hostAddrs
+	t.Cleanup(func() { outboundDial, hostAddrs = oldDial, oldAddrs })
+
+	outboundDial = func(address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		require.NoError(t, err)
+
+		source, ok := routes[host]
+		if !ok {
+			return nil, fmt.Errorf("connect: network is unreachable")
+		}
+
+		return &outboundConn{local: &net.UDPAddr{IP: net.ParseIP(source), Port: 40000}}, nil
+	}
+
+	hostAddrs = func() ([]net.Addr, error) {
+		result := make([]net.Addr, 0, len(addrs))
+		for _, cidr := range addrs {
+			ip, subnet, err := net.ParseCIDR(cidr)
+			require.NoError(t, err)
+
+			result = append(result, &net.IPNet{IP: ip, Mask: subnet.Mask})
+		}
+
+		return result, nil
+	}
+}
+
+func TestGetOutboundAddress(t *testing.T) {
+	setOutboundRoutes(t, map[string]string{
+		"10.0.0.1":     "10.0.0.2",
+		"192.0.2.1":    "192.0.2.10",
+		"2001:db8::1":  "2001:db8::2",
+		"127.0.0.1":    "127.0.0.1",
+		"198.51.100.1": "0.0.0.0",
+	}, []string{"127.0.0.1/8", "fe80::1/64", "203.0.113.5/24", "2001:db8:1::5/64"})
+
+	cases := map[string]string{
+		"10.0.0.1":            "10.0.0.2:8443",
+		"10.0.0.1:9000":       "10.0.0.2:8443",
+		"192.0.2.1:8443":      "192.0.2.10:8443",
+		"[2001:db8::1]:8443":  "[2001:db8::2]:8443",
+		"127.0.0.1:8443":      "127.0.0.1:8443",
+		"198.51.100.1:8443":   "203.0.113.5:8443",
+		"[2001:db8:2::1]:443": "[2001:db8:1::5]:8443",
+	}
+
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			address, err := GetOutboundAddress(in)
+			require.NoError(t, err)
+			assert.Equal(t, out, address)
+		})
+	}
+
+	_, err := GetOutboundAddress("[::1")
+	assert.Error(t, err)
+}
+
+func TestGetOutboundAddressFallback(t *testing.T) {
+	setOutboundRoutes(t, nil, []string{"127.0.0.1/8", "fe80::1/64", "2001:db8::5/64"})
+
+	// The other family is used if the host has no global address of the target's one.
+	address, err := GetOutboundAddress("10.0.0.1:8443")
+	require.NoError(t, err)
+	assert.Equal(t, "[2001:db8::5]:8443", address)
+
+	setOutboundRoutes(t, nil, []string{"127.0.0.1/8", "fe80::1/64"})
+
+	_, err = GetOutboundAddress("10.0.0.1:8443")
+	assert.Error(t, err)
+}
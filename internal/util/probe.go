@@ -0,0 +1,163 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/lxc/incus/v6/internal/ports"
+	"github.com/lxc/incus/v6/shared/proxy"
+)
+
+// probeWorkers caps the number of addresses being probed at once.
+const probeWorkers = 16
+
+// probeDial connects to an address, replaced in tests.
+var probeDial = func(ctx context.Context, address string, timeout time.Duration) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// probeProxy returns the proxy used to connect to an address, replaced in tests.
+var probeProxy = proxy.FromEnvironment
+
+// ProbeResult is the outcome of probing a network address.
+type ProbeResult struct {
+	// Address is the probed address, in its canonical form if it could be parsed.
+	Address string
+
+	// Reachable tells whether a TCP connection could be established.
+	Reachable bool
+
+	// Proxy is the proxy the connections to the address go through, in which case it isn't probed as
+	// connecting to it directly tells nothing about whether it can be reached.
+	Proxy *url.URL
+
+	// Latency is the time it took to establish the connection, or to fail doing so.
+	Latency time.Duration
+
+	// Err is the reason the address isn't reachable.
+	Err error
+}
+
+// String returns a human-readable description of the result.
+func (r ProbeResult) String() string {
+	if r.Proxy != nil {
+		return fmt.Sprintf("%s: not probed, using proxy %s", r.Address, r.Proxy.Redacted())
+	}
+
+	if r.Reachable {
+		return fmt.Sprintf("%s: reachable in %s", r.Address, r.Latency.Round(time.Millisecond))
+	}
+
+	return fmt.Sprintf("%s: %v", r.Address, r.Err)
+}
+
+// ProbeAddresses checks which of the given network addresses can be reached by connecting to them over TCP, the
+// default HTTPS port being used for addresses without one. The addresses are probed concurrently, each for at
+// most the given timeout, except for those reached through a proxy. Returns the results in the order of the
+// addresses.
+func ProbeAddresses(ctx context.Context, addrs []string, timeout time.Duration) []ProbeResult {
+	results := make([]ProbeResult, len(addrs))
+
+	g := errgroup.Group{}
+	g.SetLimit(probeWorkers)
+
+	for i, address := range addrs {
+		i, address := i, address
+		g.Go(func() error {
+			results[i] = probeAddress(ctx, address, timeout)
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	return results
+}
+
+// probeAddress connects to the given address and closes the connection right away.
+func probeAddress(ctx context.Context, address string, timeout time.Duration) ProbeResult {
+	addr, err := ParseNetworkAddress(address, ports.HTTPSDefaultPort)
+	if err != nil {
+		return ProbeResult{Address: address, Err: err}
+	}
+
+	result := ProbeResult{Address: addr.String()}
+
+	result.Proxy, err = probeProxy(&http.Request{URL: &url.URL{Scheme: "https", Host: result.Address}})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if result.Proxy != nil {
+		return result
+	}
+
+	start := time.Now()
+	conn, err := probeDial(ctx, result.Address, timeout)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	_ = conn.Close()
+	result.Reachable = true
+
+	return result
+}
+
+// usable returns whether connecting to the address may succeed, as far as the probe can tell.
+func (r ProbeResult) usable() bool {
+	return r.Reachable || r.Proxy != nil
+}
+
+// SortAddresses returns the addresses of the results in the order they should be tried. The reachable ones come
+// first, from the lowest latency to the highest, followed by the ones reached through a proxy and then by the
+// unreachable ones, as failing to connect within the probe timeout doesn't mean that a connection can't succeed.
+func SortAddresses(results []ProbeResult) []string {
+	sorted := slices.Clone(results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].usable() != sorted[j].usable() {
+			return sorted[i].usable()
+		}
+
+		if sorted[i].Reachable != sorted[j].Reachable {
+			return sorted[i].Reachable
+		}
+
+		return sorted[i].Reachable && sorted[i].Latency < sorted[j].Latency
+	})
+
+	addresses := make([]string, 0, len(sorted))
+	for _, result := range sorted {
+		addresses = append(addresses, result.Address)
+	}
+
+	return addresses
+}
+
+// AnyUsable returns whether any of the addresses is reachable or reached through a proxy.
+func AnyUsable(results []ProbeResult) bool {
+	return slices.ContainsFunc(results, ProbeResult.usable)
+}
+
+// ProbeReport returns a description of the results, one per line, suitable for error messages.
+func ProbeReport(results []ProbeResult) string {
+	lines := make([]string, 0, len(results))
+	for _, result := range results {
+		lines = append(lines, " - "+result.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
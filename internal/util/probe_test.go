@@ -0,0 +1,159 @@
+package util
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noProbeProxy makes the addresses be probed whatever the proxy environment of the tests.
+func noProbeProxy(t *testing.T) {
+	old := probeProxy
+	t.Cleanup(func() { probeProxy = old })
+
+	probeProxy = func(req *http.Request) (*url.URL, error) { return nil, nil }
+}
+
+func TestProbeAddresses(t *testing.T) {
+	noProbeProxy(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	// A port nothing listens on anymore.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddress := closed.Addr().String()
+	_ = closed.Close()
+
+	addrs := []string{closedAddress, listener.Addr().String(), "[::1"}
+	results := ProbeAddresses(context.Background(), addrs, time.Second)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, closedAddress, results[0].Address)
+	assert.False(t, results[0].Reachable)
+	assert.Error(t, results[0].Err)
+
+	assert.Equal(t, listener.Addr().String(), results[1].Address)
+	assert.True(t, results[1].Reachable)
+	assert.NoError(t, results[1].Err)
+
+	assert.Equal(t, "[::1", results[2].Address)
+	assert.False(t, results[2].Reachable)
+	assert.Error(t, results[2].Err)
+
+	// The unreachable addresses are still tried, last.
+	assert.Equal(t, []string{listener.Addr().String(), closedAddress, "[::1"}, SortAddresses(results))
+	assert.True(t, AnyUsable(results))
+	assert.False(t, AnyUsable([]ProbeResult{results[0], results[2]}))
+	assert.Contains(t, ProbeReport(results), listener.Addr().String()+": reachable in ")
+}
+
+func TestProbeAddressesConcurrency(t *testing.T) {
+	noProbeProxy(t)
+
+	var mu sync.Mutex
+	running := 0
+	maxRunning := 0
+
+	old := probeDial
+	defer func() { probeDial = old }()
+
+	// Each address takes a different time to reach.
+	probeDial = func(ctx context.Context, address string, timeout time.Duration) (net.Conn, error) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		host, _, err := net.SplitHostPort(address)
+		require.NoError(t, err)
+
+		ip := net.ParseIP(host).To4()
+		time.Sleep(time.Duration(ip[3]) * time.Millisecond)
+
+		client, server := net.Pipe()
+		_ = server.Close()
+
+		return client, nil
+	}
+
+	addrs := []string{}
+	for i := 4 * probeWorkers; i > 0; i-- {
+		addrs = append(addrs, net.IPv4(192, 0, 2, byte(i)).String())
+	}
+
+	results := ProbeAddresses(context.Background(), addrs, time.Second)
+	for i, result := range results {
+		assert.Equal(t, addrs[i]+":8443", result.Address)
+		assert.True(t, result.Reachable)
+	}
+
+	assert.LessOrEqual(t, maxRunning, probeWorkers)
+
+	// The reachable addresses are sorted by latency.
+	latencies := map[string]time.Duration{}
+	for _, result := range results {
+		latencies[result.Address] = result.Latency
+	}
+
+	reachable := SortAddresses(results)
+	require.Len(t, reachable, len(addrs))
+	for i := 1; i < len(reachable); i++ {
+		assert.LessOrEqual(t, latencies[reachable[i-1]], latencies[reachable[i]])
+	}
+}
+
+func TestProbeAddressesProxy(t *testing.T) {
+	old := probeProxy
+	defer func() { probeProxy = old }()
+
+	oldDial := probeDial
+	defer func() { probeDial = oldDial }()
+
+	// Only the first address goes through the proxy, which isn't dialed.
+	proxyURL := &url.URL{Scheme: "http", Host: "proxy.example.com:3128"}
+	probeProxy = func(req *http.Request) (*url.URL, error) {
+		if req.URL.Host == "192.0.2.1:8443" {
+			return proxyURL, nil
+		}
+
+		return nil, nil
+	}
+
+	probeDial = func(ctx context.Context, address string, timeout time.Duration) (net.Conn, error) {
+		require.NotEqual(t, "192.0.2.1:8443", address)
+
+		if address == "192.0.2.2:8443" {
+			return nil, context.DeadlineExceeded
+		}
+
+		client, server := net.Pipe()
+		_ = server.Close()
+
+		return client, nil
+	}
+
+	results := ProbeAddresses(context.Background(), []string{"192.0.2.2", "192.0.2.1", "192.0.2.3"}, time.Second)
+	assert.Equal(t, proxyURL, results[1].Proxy)
+	assert.False(t, results[1].Reachable)
+	assert.NoError(t, results[1].Err)
+	assert.Contains(t, ProbeReport(results), "192.0.2.1:8443: not probed, using proxy http://proxy.example.com:3128")
+
+	assert.Equal(t, []string{"192.0.2.3:8443", "192.0.2.1:8443", "192.0.2.2:8443"}, SortAddresses(results))
+	assert.True(t, AnyUsable(results[:2]))
+}
@@ -0,0 +1,129 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Lifetime of the cached host name resolutions, host names not found being looked up again sooner.
+const (
+	resolveTTL         = 30 * time.Second
+	resolveNegativeTTL = 5 * time.Second
+)
+
+// resolveTimeout bounds the lookups, shared between callers which may have no deadline of their own.
+var resolveTimeout = 5 * time.Second
+
+// Resolver looks up the IP addresses of a host name, as net.Resolver does.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// hostEntry is a cached host name resolution.
+type hostEntry struct {
+	ips     []net.IP
+	err     error
+	expires time.Time
+}
+
+// hostCache caches the resolution of host names, concurrent lookups of the same name sharing a single query.
+type hostCache struct {
+	mu       sync.Mutex
+	resolver Resolver
+	entries  map[string]hostEntry
+	group    singleflight.Group
+}
+
+// hosts caches the host names resolved by the network address helpers.
+var hosts = &hostCache{resolver: net.DefaultResolver, entries: map[string]hostEntry{}}
+
+// setResolver replaces the resolver used by the network address helpers and clears the cache.
+// Returns a function restoring the previous resolver.
+func setResolver(resolver Resolver) func() {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+
+	old := hosts.resolver
+	hosts.resolver = resolver
+	hosts.entries = map[string]hostEntry{}
+
+	return func() { _ = setResolver(old) }
+}
+
+// LookupHostIPs returns the IP addresses of the given host name, using a cached result if recent enough.
+// Host names which don't exist are cached too, for a shorter time. The context bounds the wait for the result.
+func LookupHostIPs(ctx context.Context, host string) ([]net.IP, error) {
+	return hosts.lookup(ctx, host)
+}
+
+func (c *hostCache) lookup(ctx context.Context, host string) ([]net.IP, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	resolver := c.resolver
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		return entry.ips, entry.err
+	}
+
+	results := c.group.DoChan(host, func() (any, error) {
+		// Not bound to the context of the caller, as other callers may be waiting for the same result.
+		lookupCtx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+		defer cancel()
+
+		addresses, err := resolver.LookupHost(lookupCtx, host)
+
+		var ips []net.IP
+		for _, address := range addresses {
+			ip := net.ParseIP(address)
+			if ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+
+		c.store(host, ips, err)
+
+		return ips, err
+	})
+
+	select {
+	case result := <-results:
+		ips, _ := result.Val.([]net.IP)
+		return ips, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// store caches the result of a lookup, unless it failed for any other reason than the host not existing.
+func (c *hostCache) store(host string, ips []net.IP, err error) {
+	ttl := resolveTTL
+	if err != nil {
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			return
+		}
+
+		ttl = resolveNegativeTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop the expired entries from time to time.
+	now := time.Now()
+	if len(c.entries) >= 1024 {
+		for name, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, name)
+			}
+		}
+	}
+
+	c.entries[host] = hostEntry{ips: ips, err: err, expires: now.Add(ttl)}
+}
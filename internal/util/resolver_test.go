@@ -0,0 +1,133 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver resolves host names from a static table, counting the lookups.
+type fakeResolver struct {
+	mu      sync.Mutex
+	hosts   map[string][]string
+	err     error
+	block   chan struct{}
+	lookups int
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	r.lookups++
+	block := r.block
+	r.mu.Unlock()
+
+	if block != nil {
+		<-block
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	addresses, ok := r.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return addresses, nil
+}
+
+func (r *fakeResolver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lookups
+}
+
+// testResolver gives unusual addresses to the local host names, as some /etc/hosts do, which must not be used.
+var testResolver = &fakeResolver{hosts: map[string][]string{
+	"localhost":     {"192.0.2.99"},
+	"ip6-localhost": {"2001:db8::99"},
+	"myhost":        {"127.0.1.1"},
+}}
+
+func TestLookupHostIPs(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string][]string{"example.com": {"192.0.2.1", "2001:db8::1", "garbage"}}}
+	t.Cleanup(setResolver(resolver))
+
+	// Results are cached.
+	for i := 0; i < 3; i++ {
+		ips, err := LookupHostIPs(context.Background(), "example.com")
+		require.NoError(t, err)
+		assert.Equal(t, []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")}, ips)
+	}
+
+	assert.Equal(t, 1, resolver.count())
+
+	// So are host names not found.
+	for i := 0; i < 3; i++ {
+		_, err := LookupHostIPs(context.Background(), "missing.example.com")
+
+		var dnsErr *net.DNSError
+		require.True(t, errors.As(err, &dnsErr))
+		assert.True(t, dnsErr.IsNotFound)
+	}
+
+	assert.Equal(t, 2, resolver.count())
+
+	// But not other failures.
+	resolver.err = errors.New("server misbehaving")
+	for i := 0; i < 2; i++ {
+		_, err := LookupHostIPs(context.Background(), "other.example.com")
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, 4, resolver.count())
+}
+
+func TestLookupHostIPsConcurrent(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string][]string{"example.com": {"192.0.2.1"}}, block: make(chan struct{})}
+	t.Cleanup(setResolver(resolver))
+
+	// Callers give up once their context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := LookupHostIPs(ctx, "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// While concurrent callers share the pending lookup.
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ips, err := LookupHostIPs(context.Background(), "example.com")
+			assert.NoError(t, err)
+			assert.Equal(t, []net.IP{net.ParseIP("192.0.2.1")}, ips)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(resolver.block)
+	wg.Wait()
+
+	assert.Equal(t, 1, resolver.count())
+
+	// A slow resolver doesn't hold up address comparisons past their deadline.
+	resolver = &fakeResolver{hosts: map[string][]string{}, block: make(chan struct{})}
+	t.Cleanup(setResolver(resolver))
+	t.Cleanup(func() { close(resolver.block) })
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.False(t, IsAddressCoveredContext(ctx, "slow.example.com:8443", "127.0.0.1:8443"))
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"net"
+)
+
+// NormalizeCIDR parses a subnet in CIDR notation, possibly with host bits set (e.g "10.0.0.1/24") and IPv4 subnets
+// possibly written as IPv4-mapped IPv6 ones (e.g "::ffff:10.0.0.0/120"). Returns the subnet with its host bits
+// cleared, IPv4 subnets being in their 4 bytes form (e.g "10.0.0.0/24").
+func NormalizeCIDR(cidr string) (*net.IPNet, error) {
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeSubnet(subnet), nil
+}
+
+// normalizeSubnet returns a copy of the subnet with its host bits cleared, converting IPv4-mapped IPv6 subnets and
+// IPv4 addresses in their 16 bytes form to IPv4 subnets. Returns nil if the mask isn't in the canonical form.
+func normalizeSubnet(subnet *net.IPNet) *net.IPNet {
+	ones, bits := subnet.Mask.Size()
+	if bits == 0 {
+		return nil
+	}
+
+	ip := subnet.IP
+	if bits == 8*net.IPv6len && ones >= 96 && ip.To4() != nil {
+		// IPv4-mapped IPv6 subnet, e.g ::ffff:10.0.0.0/120.
+		ones -= 96
+		bits = 8 * net.IPv4len
+	}
+
+	if bits == 8*net.IPv4len {
+		ip = ip.To4()
+		if ip == nil {
+			return nil
+		}
+	} else {
+		ip = ip.To16()
+	}
+
+	mask := net.CIDRMask(ones, bits)
+
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+// SubnetContains returns whether the inner subnet is entirely part of the outer subnet. Subnets of different
+// families never contain each other, IPv4-mapped IPv6 subnets being considered as IPv4 ones.
+func SubnetContains(outerSubnet *net.IPNet, innerSubnet *net.IPNet) bool {
+	if outerSubnet == nil || innerSubnet == nil {
+		return false
+	}
+
+	outer := normalizeSubnet(outerSubnet)
+	inner := normalizeSubnet(innerSubnet)
+	if outer == nil || inner == nil {
+		return false
+	}
+
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+
+	if outerBits != innerBits || innerOnes < outerOnes {
+		return false
+	}
+
+	return outer.Contains(inner.IP)
+}
+
+// SubnetsOverlap returns whether the two subnets have any address in common, which for subnets means that one
+// contains the other.
+func SubnetsOverlap(subnet1 *net.IPNet, subnet2 *net.IPNet) bool {
+	return SubnetContains(subnet1, subnet2) || SubnetContains(subnet2, subnet1)
+}
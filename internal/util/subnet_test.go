@@ -0,0 +1,103 @@
+package util
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCIDR(t *testing.T) {
+	cases := map[string]string{
+		"10.0.0.0/24":          "10.0.0.0/24",
+		"10.0.0.1/24":          "10.0.0.0/24",
+		"10.0.0.255/31":        "10.0.0.254/31",
+		"10.0.0.1/32":          "10.0.0.1/32",
+		"0.0.0.0/0":            "0.0.0.0/0",
+		"::ffff:10.0.0.1/120":  "10.0.0.0/24",
+		"::ffff:10.0.0.1/96":   "0.0.0.0/0",
+		"::ffff:0:0/80":        "::/80",
+		"2001:db8::1/64":       "2001:db8::/64",
+		"2001:DB8:0::1/127":    "2001:db8::/127",
+		"2001:db8::ffff/127":   "2001:db8::fffe/127",
+		"2001:db8::1/128":      "2001:db8::1/128",
+		"fd42:1:2:3::1234/48":  "fd42:1:2::/48",
+		"::/0":                 "::/0",
+		"::ffff:192.0.2.1/128": "192.0.2.1/32",
+	}
+
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			subnet, err := NormalizeCIDR(in)
+			require.NoError(t, err)
+			assert.Equal(t, out, subnet.String())
+		})
+	}
+
+	for _, in := range []string{"", "10.0.0.1", "10.0.0.0/33", "2001:db8::/129", "fe80::1%eth0/64", "foo/24"} {
+		t.Run(in, func(t *testing.T) {
+			_, err := NormalizeCIDR(in)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSubnetsOverlap(t *testing.T) {
+	cases := []struct {
+		subnet1  string
+		subnet2  string
+		contains bool
+		overlap  bool
+	}{
+		{"10.0.0.0/24", "10.0.0.0/24", true, true},
+		{"10.0.0.0/16", "10.0.5.0/24", true, true},
+		{"10.0.5.0/24", "10.0.0.0/16", false, true},
+		{"10.0.0.0/24", "10.0.1.0/24", false, false},
+		{"10.0.0.1/24", "10.0.0.200/24", true, true},
+		{"10.0.0.0/31", "10.0.0.1/32", true, true},
+		{"10.0.0.0/31", "10.0.0.2/31", false, false},
+		{"10.0.0.2/31", "10.0.0.0/30", false, true},
+		{"0.0.0.0/0", "192.0.2.0/24", true, true},
+		{"2001:db8::/127", "2001:db8::1/128", true, true},
+		{"2001:db8::/127", "2001:db8::2/127", false, false},
+		{"2001:db8::2/127", "2001:db8::/126", false, true},
+		{"2001:db8::/64", "2001:db8:0:1::/64", false, false},
+		{"2001:db8::/32", "2001:db8:1::/48", true, true},
+		{"::/0", "2001:db8::/64", true, true},
+
+		// Mixed families never overlap, IPv4-mapped subnets being IPv4 ones.
+		{"::/0", "10.0.0.0/8", false, false},
+		{"0.0.0.0/0", "2001:db8::/64", false, false},
+		{"::ffff:10.0.0.0/104", "10.0.5.0/24", true, true},
+		{"10.0.0.0/8", "::ffff:10.0.5.0/120", true, true},
+		{"::ffff:10.0.0.0/120", "10.0.1.0/24", false, false},
+		{"::/64", "0.0.0.0/0", false, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.subnet1+" "+c.subnet2, func(t *testing.T) {
+			_, subnet1, err := net.ParseCIDR(c.subnet1)
+			require.NoError(t, err)
+
+			_, subnet2, err := net.ParseCIDR(c.subnet2)
+			require.NoError(t, err)
+
+			assert.Equal(t, c.contains, SubnetContains(subnet1, subnet2))
+			assert.Equal(t, c.overlap, SubnetsOverlap(subnet1, subnet2))
+			assert.Equal(t, c.overlap, SubnetsOverlap(subnet2, subnet1))
+		})
+	}
+}
+
+func TestSubnetContainsUnnormalized(t *testing.T) {
+	// Host bits set, IPv4 addresses in their 16 bytes form and non-canonical masks.
+	outer := &net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(24, 32)}
+	inner := &net.IPNet{IP: net.ParseIP("10.0.0.130"), Mask: net.CIDRMask(25, 32)}
+	assert.True(t, SubnetContains(outer, inner))
+	assert.False(t, SubnetContains(inner, outer))
+
+	assert.False(t, SubnetContains(outer, nil))
+	assert.False(t, SubnetContains(nil, inner))
+	assert.False(t, SubnetContains(outer, &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.IPMask{255, 0, 255, 0}}))
+}
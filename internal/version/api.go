@@ -426,6 +426,27 @@ var APIExtensions = []string{
 	"projects_force_delete",
 	"resources_cpu_flags",
 	"disk_io_bus_cache_filesystem",
+	"snapshots_consistency",
+	"instance_debug_memory",
+	"instance_debug_qmp",
+	"debug_pprof",
+	"device_patch",
+	"operation_files",
+	"operation_progress",
+	"console_log_follow",
+	"instance_files_tar",
+	"metadata_device_types",
+	"debug_apparmor_profile",
+	"server_apparmor_raw",
+	"apparmor_enforcement",
+	"apparmor_log_denials",
+	"server_https_proxy_protocol",
+	"server_listen_interface_address",
+	"server_local_ports",
+	"network_listen_address_presence",
+	"metrics_command_duration",
+	"storage_background_priority",
+	"storage_pool_resources_degraded",
 }
 
 // APIExtensionsCount returns the number of available API extensions.
@@ -0,0 +1,8 @@
+package api
+
+// FilesystemsFreezePost contains the fields used to freeze the guest filesystems.
+type FilesystemsFreezePost struct {
+	// Number of seconds after which the filesystems are automatically thawed
+	// Example: 60
+	Timeout int64 `json:"timeout" yaml:"timeout"`
+}
@@ -36,6 +36,9 @@ const (
 	EventLifecycleInstanceConsoleReset              = "instance-console-reset"
 	EventLifecycleInstanceConsoleRetrieved          = "instance-console-retrieved"
 	EventLifecycleInstanceCreated                   = "instance-created"
+	EventLifecycleInstanceDebugMemoryCompleted      = "instance-debug-memory-completed"
+	EventLifecycleInstanceDebugMemoryFailed         = "instance-debug-memory-failed"
+	EventLifecycleInstanceDebugMemoryStarted        = "instance-debug-memory-started"
 	EventLifecycleInstanceDeleted                   = "instance-deleted"
 	EventLifecycleInstanceExec                      = "instance-exec"
 	EventLifecycleInstanceFileDeleted               = "instance-file-deleted"
@@ -107,3 +107,34 @@ type MetadataConfigKey struct {
 	// Example: "Specify the kernel modules as a comma-separated list."
 	LongDescription string `json:"longdesc" yaml:"longdesc"`
 }
+
+// MetadataDeviceTypeKey describes a configuration key of a device type
+//
+// swagger:model
+//
+// API extension: metadata_device_types.
+type MetadataDeviceTypeKey struct {
+	// Name of the configuration key
+	// Example: readonly
+	Name string `json:"name" yaml:"name"`
+
+	// Type of the configuration value
+	// Example: bool
+	Type string `json:"type" yaml:"type"`
+
+	// Default value, empty if the key has none or it depends on other settings
+	// Example: false
+	Default string `json:"default" yaml:"default"`
+
+	// Whether the key can be changed while the instance is running
+	// Example: true
+	LiveUpdate bool `json:"live_update" yaml:"live_update"`
+
+	// Instance types the key applies to
+	// Example: ["container", "virtual-machine"]
+	InstanceTypes []string `json:"instance_types" yaml:"instance_types"`
+
+	// Short description of the key
+	// Example: Controls whether to make the mount read-only
+	Description string `json:"description" yaml:"description"`
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -176,3 +177,87 @@ func (op *Operation) ToClusterJoinToken() (*ClusterMemberJoinToken, error) {
 
 	return &joinToken, nil
 }
+
+// OperationProgressKey is the operation metadata key holding the structured progress of the operation.
+//
+// API extension: operation_progress.
+const OperationProgressKey = "progress_details"
+
+// OperationProgress represents the progress of the data processed by an operation
+//
+// swagger:model
+//
+// API extension: operation_progress.
+type OperationProgress struct {
+	// Stage of the operation the progress is for
+	// Example: create_image_from_container_pack
+	Stage string `json:"stage" yaml:"stage"`
+
+	// Description of the processed data
+	// Example: Image pack
+	Description string `json:"description" yaml:"description"`
+
+	// Number of bytes processed so far
+	// Example: 104857600
+	Processed int64 `json:"processed" yaml:"processed"`
+
+	// Total number of bytes to process (0 when unknown)
+	// Example: 1073741824
+	Total int64 `json:"total" yaml:"total"`
+
+	// Processing speed in bytes per second
+	// Example: 52428800
+	Speed int64 `json:"speed" yaml:"speed"`
+
+	// Progress of the individual items of a multi-item transfer (volumes and snapshots), keyed by name
+	// Example: {"c1": {"stage": "fs", "description": "c1", "processed": 104857600, "total": 0, "speed": 52428800}}
+	Items map[string]OperationProgress `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+// Percent returns the completion percentage, or -1 when the total is unknown.
+func (p OperationProgress) Percent() int64 {
+	if p.Total <= 0 {
+		return -1
+	}
+
+	return min(p.Processed*100/p.Total, 100)
+}
+
+// ETA returns the estimated time left, or -1 when it can't be estimated.
+func (p OperationProgress) ETA() time.Duration {
+	if p.Total <= 0 || p.Speed <= 0 {
+		return -1
+	}
+
+	if p.Processed >= p.Total {
+		return 0
+	}
+
+	return time.Duration((p.Total-p.Processed)/p.Speed) * time.Second
+}
+
+// ToOperationProgress returns the structured progress from the operation metadata.
+// It returns nil if the operation doesn't report any.
+func (op *Operation) ToOperationProgress() (*OperationProgress, error) {
+	switch value := op.Metadata[OperationProgressKey].(type) {
+	case nil:
+		return nil, nil
+	case OperationProgress:
+		return &value, nil
+	case *OperationProgress:
+		return value, nil
+	}
+
+	data, err := json.Marshal(op.Metadata[OperationProgressKey])
+	if err != nil {
+		return nil, err
+	}
+
+	progress := OperationProgress{}
+	err = json.Unmarshal(data, &progress)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid operation progress: %w", err)
+	}
+
+	return &progress, nil
+}
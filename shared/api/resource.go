@@ -786,6 +786,12 @@ type ResourcesStoragePool struct {
 
 	// DIsk inode usage
 	Inodes ResourcesStoragePoolInodes `json:"inodes,omitempty" yaml:"inodes,omitempty"`
+
+	// Reason the usage is incomplete, when the storage stopped responding while being queried
+	// Example: Ceph cluster "ceph" isn't responding
+	//
+	// API extension: storage_pool_resources_degraded
+	Degraded string `json:"degraded,omitempty" yaml:"degraded,omitempty"`
 }
 
 // ResourcesStoragePoolSpace represents the space available to a given storage pool
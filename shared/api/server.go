@@ -6,6 +6,12 @@ type ServerEnvironment struct {
 	// Example: [":8443"]
 	Addresses []string `json:"addresses" yaml:"addresses"`
 
+	// How the tools run by the server are confined by AppArmor (required, best-effort or disabled)
+	// Example: required
+	//
+	// API extension: apparmor_enforcement
+	AppArmorEnforcement string `json:"apparmor_enforcement" yaml:"apparmor_enforcement"`
+
 	// List of architectures supported by the server
 	// Example: ["x86_64", "i686"]
 	Architectures []string `json:"architectures" yaml:"architectures"`
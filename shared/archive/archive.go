@@ -19,7 +19,7 @@ import (
 	"github.com/lxc/incus/v6/shared/subprocess"
 )
 
-// RunWrapper is an optional function that's used to wrap rsync, useful for confinement like AppArmor.
+// RunWrapper is an optional function that's used to wrap the extraction commands, useful for confinement like AppArmor.
 var RunWrapper func(cmd *exec.Cmd, output string, allowedCmds []string) (func(), error)
 
 type nullWriteCloser struct {
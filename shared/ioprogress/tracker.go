@@ -15,6 +15,11 @@ type ProgressTracker struct {
 	last       *time.Time
 }
 
+// Processed returns the number of bytes processed so far.
+func (pt *ProgressTracker) Processed() int64 {
+	return pt.total
+}
+
 func (pt *ProgressTracker) update(n int) {
 	// Skip the rest if no handler attached
 	if pt.Handler == nil {
@@ -0,0 +1,34 @@
+package subprocess
+
+import (
+	"errors"
+	"slices"
+)
+
+// ExitCode returns the exit code of a command from the error it failed with, looking through RunError, wrapped
+// and joined errors, and whether one was found. A nil error is an exit code of 0, while a command which failed to
+// start or was killed by a signal has none. With joined errors, the first one having an exit code is used.
+func ExitCode(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+
+	var exitErr interface{ ExitCode() int }
+	if !errors.As(err, &exitErr) {
+		return -1, false
+	}
+
+	code := exitErr.ExitCode()
+	if code < 0 {
+		return -1, false
+	}
+
+	return code, true
+}
+
+// ExitedWith returns whether a command exited with one of the given codes, given the error it failed with.
+func ExitedWith(err error, codes ...int) bool {
+	code, ok := ExitCode(err)
+
+	return ok && slices.Contains(codes, code)
+}
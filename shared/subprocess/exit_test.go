@@ -0,0 +1,48 @@
+//go:build linux
+
+package subprocess
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 16").Run()
+	killedErr := exec.Command("sh", "-c", "kill -9 $$").Run()
+	runErr := NewRunError("sh", []string{"-c", "exit 16"}, exitErr, nil, nil)
+
+	cases := []struct {
+		name string
+		err  error
+		code int
+		ok   bool
+	}{
+		{"nil", nil, 0, true},
+		{"exit error", exitErr, 16, true},
+		{"run error", runErr, 16, true},
+		{"wrapped run error", fmt.Errorf("Failed unmapping: %w", runErr), 16, true},
+		{"joined errors", errors.Join(errors.New("copy failed"), fmt.Errorf("Failed unmapping: %w", runErr)), 16, true},
+		{"killed", NewRunError("sh", nil, killedErr, nil, nil), -1, false},
+		{"other error", fmt.Errorf("Failed: %w", exec.ErrNotFound), -1, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			code, ok := ExitCode(c.err)
+			if code != c.code || ok != c.ok {
+				t.Errorf("Expected (%d, %v), got (%d, %v)", c.code, c.ok, code, ok)
+			}
+
+			if ExitedWith(c.err, 16) != (c.code == 16) {
+				t.Errorf("Unexpected ExitedWith result for %v", c.err)
+			}
+		})
+	}
+
+	if !ExitedWith(runErr, 22, 16) || ExitedWith(runErr) {
+		t.Error("Unexpected ExitedWith result with several codes")
+	}
+}
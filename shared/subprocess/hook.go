@@ -0,0 +1,91 @@
+package subprocess
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// CommandResult describes a command run by the RunCommand family of functions, as reported to the hook.
+type CommandResult struct {
+	// Args is the command line, with the arguments flagged as sensitive redacted.
+	Args []string
+
+	// Duration is how long the command took to run.
+	Duration time.Duration
+
+	// ExitCode is the exit code of the command, or -1 if it failed to start or was killed by a signal.
+	ExitCode int
+
+	// Stderr is the beginning of the output of the command on stderr.
+	Stderr string
+}
+
+// hookStderrLimit is the maximum number of bytes of stderr reported to the hook.
+const hookStderrLimit = 4096
+
+// redactedArg replaces the sensitive values in the arguments reported to the hook.
+const redactedArg = "[redacted]"
+
+// hook is the function called after every command, if any.
+var hook atomic.Pointer[func(CommandResult)]
+
+// SetHook sets a function called after every command run by the RunCommand family of functions, or removes it
+// when nil. The function is called synchronously by the caller of the command, so must return quickly.
+func SetHook(f func(CommandResult)) {
+	if f == nil {
+		hook.Store(nil)
+		return
+	}
+
+	hook.Store(&f)
+}
+
+// WithRedactedArgs flags values passed in the arguments of the command as sensitive, like keys, so that they're
+// replaced in what's reported to the hook and in the returned error.
+func WithRedactedArgs(values ...string) RunOption {
+	return func(o *runOptions) {
+		o.redacted = append(o.redacted, values...)
+	}
+}
+
+// redactArgs returns a copy of the arguments in which the sensitive values are replaced.
+func redactArgs(args []string, values []string) []string {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		for _, value := range values {
+			if value != "" {
+				arg = strings.ReplaceAll(arg, value, redactedArg)
+			}
+		}
+
+		out = append(out, arg)
+	}
+
+	return out
+}
+
+// reportCommand calls the hook, if any, with the outcome of a command.
+func reportCommand(name string, args []string, redacted []string, start time.Time, err error, stderr *OutputBuffer) {
+	f := hook.Load()
+	if f == nil {
+		return
+	}
+
+	result := CommandResult{
+		Args:     redactArgs(append([]string{name}, args...), redacted),
+		Duration: time.Since(start),
+	}
+
+	result.ExitCode, _ = ExitCode(err)
+
+	if stderr != nil {
+		// Commands may echo their arguments, so redact before truncating to not leave part of a value.
+		result.Stderr = redactArgs([]string{stderr.String()}, redacted)[0]
+		if len(result.Stderr) > hookStderrLimit {
+			result.Stderr = result.Stderr[:hookStderrLimit]
+		}
+	}
+
+	(*f)(result)
+}
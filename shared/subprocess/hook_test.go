@@ -0,0 +1,96 @@
+//go:build linux
+
+package subprocess
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// setHook records the commands reported to the hook for the duration of the test.
+func setHook(t *testing.T) *[]CommandResult {
+	results := []CommandResult{}
+	SetHook(func(result CommandResult) { results = append(results, result) })
+	t.Cleanup(func() { SetHook(nil) })
+
+	return &results
+}
+
+func TestHook(t *testing.T) {
+	results := setHook(t)
+
+	_, _, _ = RunCommandOutput(context.Background(), "sh", []string{"-c", "echo $0 >&2; exit 2", "--key=s3cr3t"}, WithRedactedArgs("s3cr3t"))
+	_ = RunCommandWithFds(context.Background(), nil, nil, "true")
+	_, _ = RunCommand("/nonexistent")
+
+	if len(*results) != 3 {
+		t.Fatalf("Expected 3 commands to be reported, got %d", len(*results))
+	}
+
+	result := (*results)[0]
+
+	args := strings.Join(result.Args, " ")
+	if args != "sh -c echo $0 >&2; exit 2 --key=[redacted]" {
+		t.Errorf("Unexpected arguments %q", args)
+	}
+
+	if result.Stderr != "--key=[redacted]\n" {
+		t.Errorf("Unexpected stderr %q", result.Stderr)
+	}
+
+	if result.ExitCode != 2 {
+		t.Errorf("Unexpected exit code %d", result.ExitCode)
+	}
+
+	if result.Duration <= 0 {
+		t.Errorf("Unexpected duration %s", result.Duration)
+	}
+
+	if (*results)[1].ExitCode != 0 {
+		t.Errorf("Unexpected exit code %d", (*results)[1].ExitCode)
+	}
+
+	if (*results)[2].ExitCode != -1 {
+		t.Errorf("Unexpected exit code %d for a command failing to start", (*results)[2].ExitCode)
+	}
+}
+
+func TestHookStderrLimit(t *testing.T) {
+	results := setHook(t)
+
+	_, _, _ = RunCommandOutput(context.Background(), "sh", []string{"-c", "head -c 10000 /dev/zero >&2"})
+
+	if len((*results)[0].Stderr) != hookStderrLimit {
+		t.Errorf("Expected stderr to be truncated to %d bytes, got %d", hookStderrLimit, len((*results)[0].Stderr))
+	}
+
+	// Nothing is reported once the hook is removed.
+	SetHook(nil)
+	_, _ = RunCommand("true")
+
+	if len(*results) != 1 {
+		t.Errorf("Expected no command to be reported without a hook")
+	}
+}
+
+func TestRedactedArgsError(t *testing.T) {
+	// The sensitive values are kept out of the error, whether they're passed as arguments or echoed by the command.
+	_, _, err := RunCommandOutput(context.Background(), "sh", []string{"-c", "echo $0 $1; echo $1 >&2; exit 2", "--secret", "s3cr3t"}, WithRedactedArgs("s3cr3t"))
+	if err == nil {
+		t.Fatal("Expected the command to fail")
+	}
+
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("The error contains the secret: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "--secret [redacted]") {
+		t.Errorf("Expected the redacted arguments in the error, got %v", err)
+	}
+
+	runErr, ok := err.(RunError)
+	if !ok || strings.Contains(runErr.StdOut().String(), "s3cr3t") || strings.Contains(runErr.StdErr().String(), "s3cr3t") {
+		t.Errorf("The output carried by the error contains the secret: %v", err)
+	}
+}
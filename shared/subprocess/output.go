@@ -0,0 +1,95 @@
+package subprocess
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultStdoutLimit is the maximum number of bytes of stdout captured by the RunCommand family of functions.
+const DefaultStdoutLimit = 64 * 1024 * 1024
+
+// DefaultStderrLimit is the maximum number of bytes of stderr captured by the RunCommand family of functions.
+const DefaultStderrLimit = 1024 * 1024
+
+// ErrOutputTruncated is wrapped in the error of a command whose stdout went past its limit, see
+// RunCommandOutput. The output it would return can't be relied on, like JSON cut half way.
+var ErrOutputTruncated = errors.New("Command output truncated")
+
+// OutputBuffer captures the output of a command, keeping at most a given number of bytes so that a
+// misbehaving command can't grow the memory of the caller. Anything past the limit is discarded and
+// replaced by a truncation notice. It's safe to write to it and read it concurrently.
+type OutputBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	discarded int64
+}
+
+// NewOutputBuffer returns an OutputBuffer keeping at most limit bytes, or everything if the limit is 0.
+func NewOutputBuffer(limit int) *OutputBuffer {
+	return &OutputBuffer{limit: limit}
+}
+
+// Write captures the data which fits within the limit. It never fails so the command isn't interrupted
+// by a broken pipe once the limit is reached.
+func (b *OutputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data := p
+	if b.limit > 0 && b.buf.Len()+len(data) > b.limit {
+		data = data[:b.limit-b.buf.Len()]
+		b.discarded += int64(len(p) - len(data))
+	}
+
+	b.buf.Write(data)
+
+	return len(p), nil
+}
+
+// Truncated returns whether some of the output was discarded.
+func (b *OutputBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.discarded > 0
+}
+
+// String returns the captured output, followed by a notice with the number of discarded bytes if any.
+func (b *OutputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.discarded == 0 {
+		return b.buf.String()
+	}
+
+	return fmt.Sprintf("%s\n[output truncated, %d bytes discarded]\n", b.buf.String(), b.discarded)
+}
+
+// captured returns the captured output, without the truncation notice.
+func (b *OutputBuffer) captured() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// truncatedError returns the error of a command whose output went past the limit, or nil if it didn't.
+func (b *OutputBuffer) truncatedError() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.discarded == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w, %d bytes discarded past the limit of %d bytes", ErrOutputTruncated, b.discarded, b.limit)
+}
+
+// buffer returns a copy of the captured output as returned by String.
+func (b *OutputBuffer) buffer() *bytes.Buffer {
+	return bytes.NewBufferString(b.String())
+}
@@ -0,0 +1,33 @@
+package subprocess
+
+import (
+	"testing"
+)
+
+func TestOutputBuffer(t *testing.T) {
+	buf := NewOutputBuffer(8)
+
+	for _, data := range []string{"hello", " world", "!"} {
+		n, err := buf.Write([]byte(data))
+		if err != nil || n != len(data) {
+			t.Fatalf("Unexpected write result %d, %v", n, err)
+		}
+	}
+
+	if !buf.Truncated() {
+		t.Error("Expected the output to be truncated")
+	}
+
+	expected := "hello wo\n[output truncated, 4 bytes discarded]\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	// No limit.
+	buf = NewOutputBuffer(0)
+	_, _ = buf.Write([]byte("hello world"))
+
+	if buf.Truncated() || buf.String() != "hello world" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+}
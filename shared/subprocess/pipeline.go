@@ -0,0 +1,176 @@
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// PipelineError is the error of a failed stage of a pipeline run by RunPipeline.
+type PipelineError struct {
+	// Stage is the position of the failed command in the pipeline, starting at 0.
+	Stage int
+
+	// Err is the RunError of the command, carrying its stderr.
+	Err error
+}
+
+func (e PipelineError) Error() string {
+	return fmt.Sprintf("Pipeline stage %d failed: %v", e.Stage, e.Err)
+}
+
+func (e PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// RunPipeline runs the commands, connecting the stdout of each to the stdin of the next, and waits for all of
+// them to exit. The stderr of the commands not sending it elsewhere is captured as with RunCommandOutput.
+// As soon as one of the commands fails or the context is done, the whole pipeline is killed.
+//
+// The returned error joins a PipelineError for each command which failed, from the last one in the pipeline to
+// the first one. The first of them is thus the root cause of the failure: a command exiting makes the ones
+// before it fail to write to it, whether they're killed by SIGPIPE or exit with an error of their own, possibly
+// before it's even done exiting, so the order in which the commands fail tells nothing. The commands killed by a
+// signal, like the ones killed along with the pipeline, are left out unless no command exited with an error by
+// itself. The context error is included when the pipeline was killed because of the context.
+func RunPipeline(ctx context.Context, cmds ...*exec.Cmd) error {
+	// Connect the commands, closing the parent's copies of the pipes once they're started.
+	var pipes []*os.File
+	defer func() {
+		for _, pipe := range pipes {
+			_ = pipe.Close()
+		}
+	}()
+
+	for i := 1; i < len(cmds); i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+
+		pipes = append(pipes, r, w)
+		cmds[i-1].Stdout = w
+		cmds[i].Stdin = r
+	}
+
+	stderrs := make([]*OutputBuffer, len(cmds))
+	for i, cmd := range cmds {
+		if cmd.Stderr == nil {
+			stderrs[i] = NewOutputBuffer(DefaultStderrLimit)
+			cmd.Stderr = stderrs[i]
+		}
+
+		setProcessGroup(cmd)
+	}
+
+	var mu sync.Mutex
+	killed := false
+	kill := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if killed {
+			return
+		}
+
+		killed = true
+		for _, cmd := range cmds {
+			if cmd.Process != nil {
+				_ = killProcessGroup(cmd)
+			}
+		}
+	}
+
+	stageError := func(stage int, err error) error {
+		cmd := cmds[stage]
+
+		var stderr = stderrs[stage]
+		if stderr == nil {
+			stderr = NewOutputBuffer(0)
+		}
+
+		return PipelineError{Stage: stage, Err: NewRunError(cmd.Args[0], cmd.Args[1:], err, nil, stderr.buffer())}
+	}
+
+	start := time.Now()
+	for i, cmd := range cmds {
+		err := cmd.Start()
+		if err != nil {
+			kill()
+			for _, started := range cmds[:i] {
+				_ = started.Wait()
+			}
+
+			return stageError(i, err)
+		}
+	}
+
+	for _, pipe := range pipes {
+		_ = pipe.Close()
+	}
+
+	pipes = nil
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			kill()
+		case <-done:
+		}
+	}()
+
+	errs := make([]error, len(cmds))
+	var wg sync.WaitGroup
+	for i, cmd := range cmds {
+		wg.Add(1)
+		go func(i int, cmd *exec.Cmd) {
+			defer wg.Done()
+
+			err := cmd.Wait()
+			reportCommand(cmd.Args[0], cmd.Args[1:], nil, start, err, stderrs[i])
+			if err == nil {
+				return
+			}
+
+			errs[i] = err
+			kill()
+		}(i, cmd)
+	}
+
+	wg.Wait()
+	close(done)
+
+	// Only report the commands killed by a signal, like the ones killed along with the pipeline, when no other
+	// command failed.
+	var failures []error
+	var signaled []error
+	for i := len(cmds) - 1; i >= 0; i-- {
+		if errs[i] == nil {
+			continue
+		}
+
+		var exitErr *exec.ExitError
+		_, exited := ExitCode(errs[i])
+		if errors.As(errs[i], &exitErr) && !exited {
+			signaled = append(signaled, stageError(i, errs[i]))
+			continue
+		}
+
+		failures = append(failures, stageError(i, errs[i]))
+	}
+
+	if len(failures) == 0 {
+		failures = signaled
+	}
+
+	if ctx.Err() != nil && len(failures) > 0 {
+		failures = append([]error{ctx.Err()}, failures...)
+	}
+
+	return errors.Join(failures...)
+}
@@ -0,0 +1,114 @@
+//go:build linux
+
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunPipeline(t *testing.T) {
+	var out strings.Builder
+
+	last := exec.Command("tr", "a-z", "A-Z")
+	last.Stdout = &out
+
+	err := RunPipeline(context.Background(), exec.Command("echo", "hello"), exec.Command("cat"), last)
+	if err != nil {
+		t.Fatalf("Failed running pipeline: %v", err)
+	}
+
+	if out.String() != "HELLO\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestRunPipelineFailure(t *testing.T) {
+	// Each stage fails in turn while the others keep reading or writing, so that they only stop because the
+	// pipeline is killed or their pipe is closed.
+	for failing := 0; failing < 3; failing++ {
+		cmds := []*exec.Cmd{
+			exec.Command("yes"),
+			exec.Command("cat"),
+			exec.Command("cat"),
+		}
+
+		cmds[failing] = exec.Command("sh", "-c", "echo failure >&2; exit 3")
+
+		start := time.Now()
+		err := RunPipeline(context.Background(), cmds...)
+		if time.Since(start) > 10*time.Second {
+			t.Errorf("Pipeline failing at stage %d took %s to return", failing, time.Since(start))
+		}
+
+		var pipelineErr PipelineError
+		if !errors.As(err, &pipelineErr) {
+			t.Fatalf("Expected a PipelineError for stage %d, got %v", failing, err)
+		}
+
+		// The stages killed by a broken pipe or along with the pipeline aren't reported.
+		if pipelineErr.Stage != failing || strings.Count(err.Error(), "Pipeline stage") != 1 {
+			t.Errorf("Expected only stage %d to be reported, got %v", failing, err)
+		}
+
+		if !ExitedWith(err, 3) {
+			t.Errorf("Expected exit code 3 for stage %d, got %v", failing, err)
+		}
+
+		var runErr RunError
+		if !errors.As(err, &runErr) || runErr.StdErr().String() != "failure\n" {
+			t.Errorf("Expected the stderr of stage %d, got %v", failing, err)
+		}
+	}
+}
+
+func TestRunPipelineBrokenPipe(t *testing.T) {
+	// The first stage ignores SIGPIPE, so that it exits with an error of its own once the second stage exited,
+	// sometimes before that one's exit is noticed, and the failure must still be blamed on the second stage.
+	for i := 0; i < 20; i++ {
+		cmds := []*exec.Cmd{
+			exec.Command("sh", "-c", "trap '' PIPE; yes"),
+			exec.Command("sh", "-c", "echo failure >&2; exit 3"),
+		}
+
+		err := RunPipeline(context.Background(), cmds...)
+
+		var pipelineErr PipelineError
+		if !errors.As(err, &pipelineErr) || pipelineErr.Stage != 1 || !ExitedWith(pipelineErr, 3) {
+			t.Fatalf("Expected stage 1 to be reported first, got %v", err)
+		}
+	}
+}
+
+func TestRunPipelineStartFailure(t *testing.T) {
+	err := RunPipeline(context.Background(), exec.Command("yes"), exec.Command("/nonexistent"))
+
+	var pipelineErr PipelineError
+	if !errors.As(err, &pipelineErr) || pipelineErr.Stage != 1 {
+		t.Errorf("Expected stage 1 to fail, got %v", err)
+	}
+}
+
+func TestRunPipelineContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := RunPipeline(ctx, exec.Command("yes"), exec.Command("cat"))
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("Pipeline took %s to be killed", time.Since(start))
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+
+	var pipelineErr PipelineError
+	if !errors.As(err, &pipelineErr) {
+		t.Errorf("Expected the killed stages to be reported, got %v", err)
+	}
+}
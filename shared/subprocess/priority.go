@@ -0,0 +1,76 @@
+package subprocess
+
+import (
+	"os/exec"
+	"sync"
+)
+
+// IOClass is an IO scheduling class, as set with ionice.
+type IOClass int
+
+const (
+	// IOClassNone leaves the IO scheduling class unchanged.
+	IOClassNone IOClass = 0
+
+	// IOClassBestEffort schedules the IO according to the level, from 0 (highest) to 7 (lowest).
+	IOClassBestEffort IOClass = 2
+
+	// IOClassIdle only schedules the IO when no other process needs the disk.
+	IOClassIdle IOClass = 3
+)
+
+// Priority defines the CPU and IO scheduling of a command, the zero value leaving it unchanged.
+// It's only applied on Linux.
+type Priority struct {
+	// Nice is the niceness of the command, from 1 (highest) to 19 (lowest), or 0 to leave it unchanged.
+	Nice int
+
+	// IOClass and IOLevel define the IO scheduling of the command.
+	IOClass IOClass
+	IOLevel int
+
+	// Cgroup is the path of a cgroup v2 to place the command in, if not empty.
+	Cgroup string
+}
+
+// backgroundPriority is the priority of the commands doing background work.
+var backgroundPriority Priority
+var backgroundPriorityMu sync.Mutex
+
+// SetBackgroundPriority sets the priority of the commands doing background work, see WithBackgroundPriority.
+func SetBackgroundPriority(priority Priority) {
+	backgroundPriorityMu.Lock()
+	defer backgroundPriorityMu.Unlock()
+
+	backgroundPriority = priority
+}
+
+// BackgroundPriority returns the priority of the commands doing background work.
+func BackgroundPriority() Priority {
+	backgroundPriorityMu.Lock()
+	defer backgroundPriorityMu.Unlock()
+
+	return backgroundPriority
+}
+
+// WithPriority runs the command with the given CPU and IO scheduling.
+func WithPriority(priority Priority) RunOption {
+	return func(o *runOptions) {
+		o.priority = priority
+	}
+}
+
+// WithBackgroundPriority runs the command with the priority set by SetBackgroundPriority. It's meant for bulk
+// work, like transferring volumes for migrations and backups, which shouldn't compete with the instances.
+func WithBackgroundPriority() RunOption {
+	return WithPriority(BackgroundPriority())
+}
+
+// StartWithPriority starts the command with the given CPU and IO scheduling.
+func StartWithPriority(cmd *exec.Cmd, priority Priority) error {
+	if priority == (Priority{}) {
+		return cmd.Start()
+	}
+
+	return startWithPriority(cmd, priority)
+}
@@ -0,0 +1,100 @@
+//go:build linux
+
+package subprocess
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// ioprioWhoProcess makes ioprio_set apply to a single thread.
+const ioprioWhoProcess = 1
+
+// ioprioClassShift is the position of the class in an IO priority.
+const ioprioClassShift = 13
+
+// setThreadPriority sets the niceness and IO priority of the calling thread.
+func setThreadPriority(nice int, ioprio int) error {
+	err := unix.Setpriority(unix.PRIO_PROCESS, 0, nice)
+	if err != nil {
+		return err
+	}
+
+	_, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(ioprio))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// startWithPriority starts the command from a thread with the priority, so that the command inherits it from its
+// very start rather than after having started other processes, then moves it to its cgroup, if any.
+// Failing to apply the priority doesn't prevent the command from running.
+func startWithPriority(cmd *exec.Cmd, priority Priority) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		runtime.LockOSThread()
+
+		// The kernel returns 20 minus the niceness.
+		oldNice, err := unix.Getpriority(unix.PRIO_PROCESS, 0)
+		if err != nil {
+			runtime.UnlockOSThread()
+			errCh <- cmd.Start()
+			return
+		}
+
+		oldIOPrio, _, errno := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, 0, 0)
+		if errno != 0 {
+			oldIOPrio = 0
+		}
+
+		nice := 20 - oldNice
+		if priority.Nice != 0 {
+			nice = priority.Nice
+		}
+
+		ioprio := int(oldIOPrio)
+		if priority.IOClass != IOClassNone {
+			ioprio = int(priority.IOClass)<<ioprioClassShift | priority.IOLevel
+		}
+
+		err = setThreadPriority(nice, ioprio)
+		if err != nil {
+			logger.Debug("Failed setting priority of command", logger.Ctx{"cmd": cmd.Path, "err": err})
+		}
+
+		errCh <- cmd.Start()
+
+		// Leave the thread locked if its priority can't be restored, so that it's terminated along with the
+		// goroutine rather than reused.
+		err = setThreadPriority(20-oldNice, int(oldIOPrio))
+		if err != nil {
+			return
+		}
+
+		runtime.UnlockOSThread()
+	}()
+
+	err := <-errCh
+	if err != nil {
+		return err
+	}
+
+	if priority.Cgroup != "" {
+		err = os.WriteFile(filepath.Join(priority.Cgroup, "cgroup.procs"), []byte(strconv.Itoa(cmd.Process.Pid)), 0)
+		if err != nil {
+			logger.Debug("Failed placing command in cgroup", logger.Ctx{"cmd": cmd.Path, "cgroup": priority.Cgroup, "err": err})
+		}
+	}
+
+	return nil
+}
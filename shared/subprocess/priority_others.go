@@ -0,0 +1,12 @@
+//go:build !linux
+
+package subprocess
+
+import (
+	"os/exec"
+)
+
+// startWithPriority starts the command, the priority being only applied on Linux.
+func startWithPriority(cmd *exec.Cmd, _ Priority) error {
+	return cmd.Start()
+}
@@ -0,0 +1,74 @@
+//go:build linux
+
+package subprocess
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestStartWithPriority(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+
+	// A cgroup which can't be used doesn't prevent the command from running.
+	err := StartWithPriority(cmd, Priority{Nice: 15, IOClass: IOClassIdle, Cgroup: "/nonexistent"})
+	if err != nil {
+		t.Fatalf("Failed starting command: %v", err)
+	}
+
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", cmd.Process.Pid))
+	if err != nil {
+		t.Fatalf("Failed reading command status: %v", err)
+	}
+
+	// The niceness is the 17th field after the command name.
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	if fields[16] != "15" {
+		t.Errorf("Expected niceness 15, got %s", fields[16])
+	}
+
+	ioprio, _, errno := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(cmd.Process.Pid), 0)
+	if errno != 0 {
+		t.Fatalf("Failed getting IO priority: %v", errno)
+	}
+
+	if IOClass(ioprio>>ioprioClassShift) != IOClassIdle {
+		t.Errorf("Expected the idle IO class, got %d", ioprio>>ioprioClassShift)
+	}
+
+	// The priority of the caller is left unchanged.
+	stat, err = os.ReadFile("/proc/self/stat")
+	if err != nil {
+		t.Fatalf("Failed reading own status: %v", err)
+	}
+
+	fields = strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	if fields[16] != "0" {
+		t.Errorf("Expected own niceness to be unchanged, got %s", fields[16])
+	}
+}
+
+func TestRunCommandOutputPriority(t *testing.T) {
+	SetBackgroundPriority(Priority{Nice: 10})
+	defer SetBackgroundPriority(Priority{})
+
+	stdout, _, err := RunCommandOutput(context.Background(), "sh", []string{"-c", "cut -d ' ' -f 19 /proc/$$/stat"}, WithBackgroundPriority())
+	if err != nil {
+		t.Fatalf("Failed running command: %v", err)
+	}
+
+	if stdout != "10\n" {
+		t.Errorf("Expected niceness 10, got %q", stdout)
+	}
+}
@@ -0,0 +1,96 @@
+package subprocess
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// RetryPolicy sets how a failing command is retried.
+type RetryPolicy struct {
+	// Attempts is the maximum number of times the command is run.
+	Attempts int
+
+	// Delay is the wait before the first retry.
+	Delay time.Duration
+
+	// Multiplier scales the wait after each retry, the wait staying the same when it's 0 or 1.
+	Multiplier float64
+
+	// Jitter is the fraction, between 0 and 1, by which each wait is randomly shortened or lengthened, so that
+	// concurrent retries spread out.
+	Jitter float64
+
+	// Retry decides whether a failed attempt is retried, given its error and the exit code of the command (-1 if
+	// it failed to start or was killed). Every failure is retried when it's nil.
+	Retry func(err error, exitCode int) bool
+}
+
+// DefaultRetryPolicy is the policy of TryRunCommand, running the command up to 20 times with a 500ms delay
+// between each attempt.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts: 20,
+	Delay:    500 * time.Millisecond,
+}
+
+// RetryOnExitCodes returns a RetryPolicy.Retry function only retrying the commands exiting with one of the
+// given codes.
+func RetryOnExitCodes(codes ...int) func(err error, exitCode int) bool {
+	return func(err error, exitCode int) bool {
+		return slices.Contains(codes, exitCode)
+	}
+}
+
+// wait returns how long to wait before the given retry, the first being 0.
+func (p RetryPolicy) wait(retry int) time.Duration {
+	wait := float64(p.Delay)
+	if p.Multiplier > 0 {
+		for i := 0; i < retry; i++ {
+			wait *= p.Multiplier
+		}
+	}
+
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(wait)
+}
+
+// TryWithPolicy calls run until it succeeds, following the retry policy, and returns the output and error of
+// the last attempt. It gives up as soon as the context is done, which is also passed to run.
+func TryWithPolicy(ctx context.Context, policy RetryPolicy, run func(ctx context.Context) (string, error)) (string, error) {
+	var err error
+	var output string
+
+	for i := 0; i < max(policy.Attempts, 1); i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return output, err
+			case <-time.After(policy.wait(i - 1)):
+			}
+		}
+
+		output, err = run(ctx)
+		if err == nil {
+			break
+		}
+
+		code, _ := ExitCode(err)
+		if policy.Retry != nil && !policy.Retry(err, code) {
+			break
+		}
+	}
+
+	return output, err
+}
+
+// TryRunCommandWithPolicy runs the specified command until it runs without an error, following the retry policy.
+// If it's still failing once the policy gives up then the error of the last attempt is returned.
+func TryRunCommandWithPolicy(policy RetryPolicy, name string, arg ...string) (string, error) {
+	return TryWithPolicy(context.Background(), policy, func(ctx context.Context) (string, error) {
+		return RunCommandContext(ctx, name, arg...)
+	})
+}
@@ -0,0 +1,93 @@
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// exitCodeError is an error of a command exiting with a given code.
+type exitCodeError int
+
+func (e exitCodeError) Error() string { return "exit status" }
+func (e exitCodeError) ExitCode() int { return int(e) }
+
+func TestTryWithPolicy(t *testing.T) {
+	policy := RetryPolicy{Attempts: 5, Delay: time.Millisecond, Retry: RetryOnExitCodes(16)}
+
+	cases := []struct {
+		name     string
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{"success", []error{nil}, 1, nil},
+		{"retried", []error{exitCodeError(16), exitCodeError(16), nil}, 3, nil},
+		{"not retried", []error{exitCodeError(16), exitCodeError(22), nil}, 2, exitCodeError(22)},
+		{"no exit code", []error{errors.New("failed"), nil}, 1, errors.New("failed")},
+		{"exhausted", []error{exitCodeError(16), exitCodeError(16), exitCodeError(16), exitCodeError(16), exitCodeError(16), nil}, 5, exitCodeError(16)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			attempts := 0
+			output, err := TryWithPolicy(context.Background(), policy, func(ctx context.Context) (string, error) {
+				err := c.errs[attempts]
+				attempts++
+
+				return "attempt", err
+			})
+
+			if attempts != c.attempts {
+				t.Errorf("Expected %d attempts, got %d", c.attempts, attempts)
+			}
+
+			if output != "attempt" {
+				t.Errorf("Unexpected output %q", output)
+			}
+
+			if (err == nil) != (c.err == nil) || (err != nil && err.Error() != c.err.Error()) {
+				t.Errorf("Expected error %v, got %v", c.err, err)
+			}
+		})
+	}
+}
+
+func TestTryWithPolicyContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	start := time.Now()
+	_, err := TryWithPolicy(ctx, RetryPolicy{Attempts: 100, Delay: 20 * time.Millisecond}, func(ctx context.Context) (string, error) {
+		attempts++
+		return "", errors.New("failed")
+	})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if attempts >= 100 || time.Since(start) > time.Second {
+		t.Errorf("Retries went on after the context was done (%d attempts in %s)", attempts, time.Since(start))
+	}
+}
+
+func TestRetryPolicyWait(t *testing.T) {
+	policy := RetryPolicy{Delay: 100 * time.Millisecond, Multiplier: 2}
+
+	for retry, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		if policy.wait(retry) != expected {
+			t.Errorf("Expected a wait of %s before retry %d, got %s", expected, retry, policy.wait(retry))
+		}
+	}
+
+	// The jitter stays within its fraction of the wait.
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		wait := policy.wait(1)
+		if wait < 100*time.Millisecond || wait > 300*time.Millisecond {
+			t.Fatalf("Wait %s is out of the jitter range", wait)
+		}
+	}
+}
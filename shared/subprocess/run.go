@@ -3,10 +3,12 @@ package subprocess
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 )
@@ -37,7 +39,7 @@ func (e RunError) StdOut() *bytes.Buffer {
 	return e.stdout
 }
 
-// StdErr returns the stdout buffer.
+// StdErr returns the stderr buffer.
 func (e RunError) StdErr() *bytes.Buffer {
 	return e.stderr
 }
@@ -53,36 +55,180 @@ func NewRunError(cmd string, args []string, err error, stdout *bytes.Buffer, std
 	}
 }
 
-// RunCommandSplit runs a command with a supplied environment and optional arguments and returns the
-// resulting stdout and stderr output as separate variables. If the supplied environment is nil then
-// the default environment is used. If the command fails to start or returns a non-zero exit code
-// then an error is returned containing the output of stderr too.
-func RunCommandSplit(ctx context.Context, env []string, filesInherit []*os.File, name string, arg ...string) (string, string, error) {
-	cmd := exec.CommandContext(ctx, name, arg...)
+// RunOption configures how a command is run by RunCommandOutput.
+type RunOption func(*runOptions)
+
+// runOptions holds the configuration of a command run by RunCommandOutput.
+type runOptions struct {
+	env          []string
+	extraEnv     []string
+	dir          string
+	filesInherit []*os.File
+	stdin        io.Reader
+	stdout       io.Writer
+	stdoutLimit  int
+	stderrLimit  int
+	redacted     []string
+	priority     Priority
+
+	inactivityTimeout time.Duration
+	lines             chan<- string
+}
+
+// WithEnv adds environment variables, in the "KEY=value" form, to the environment of the command only. They
+// override the variables of the same name from the environment of the caller, which is left unchanged.
+func WithEnv(vars ...string) RunOption {
+	return func(o *runOptions) {
+		o.extraEnv = append(o.extraEnv, vars...)
+	}
+}
+
+// WithDir sets the working directory of the command, the one of the caller being used otherwise.
+func WithDir(dir string) RunOption {
+	return func(o *runOptions) {
+		o.dir = dir
+	}
+}
+
+// WithStdin sets the reader the command gets its input from.
+func WithStdin(stdin io.Reader) RunOption {
+	return func(o *runOptions) {
+		o.stdin = stdin
+	}
+}
+
+// WithStdout sends the output of the command on stdout to the writer instead of capturing it.
+func WithStdout(stdout io.Writer) RunOption {
+	return func(o *runOptions) {
+		o.stdout = stdout
+	}
+}
+
+// WithOutputLimits sets the maximum number of bytes captured from stdout and stderr, 0 meaning no limit.
+// The output beyond the limits is discarded. A truncated stderr ends with a truncation notice while a truncated
+// stdout makes the command fail with ErrOutputTruncated.
+func WithOutputLimits(stdout int, stderr int) RunOption {
+	return func(o *runOptions) {
+		o.stdoutLimit = stdout
+		o.stderrLimit = stderr
+	}
+}
+
+// RunCommandOutput runs a command with optional arguments and returns the resulting stdout and stderr output
+// separately, each of them being limited to DefaultStdoutLimit and DefaultStderrLimit unless configured otherwise.
+// When stdout goes past its limit, the output captured until then is returned along with an error wrapping
+// ErrOutputTruncated. The returned stdout is empty when it's sent to a writer with WithStdout.
+// If the command fails to start or returns a non-zero exit code then a RunError is returned, carrying the
+// captured stderr. When the context is done, the command is killed along with the processes it started.
+func RunCommandOutput(ctx context.Context, name string, args []string, options ...RunOption) (string, string, error) {
+	opts := runOptions{
+		stdoutLimit: DefaultStdoutLimit,
+		stderrLimit: DefaultStderrLimit,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var inactivityTimer *time.Timer
+	if opts.inactivityTimeout > 0 {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		inactivityTimer = time.AfterFunc(opts.inactivityTimeout, func() { cancel(ErrInactivityTimeout) })
+		defer inactivityTimer.Stop()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	killProcessGroupOnCancel(ctx, cmd)
+
+	if opts.env != nil || opts.extraEnv != nil {
+		env := opts.env
+		if env == nil {
+			env = os.Environ()
+		}
+
+		// Clip so that appending never writes to the array of the given environment.
+		cmd.Env = append(slices.Clip(env), opts.extraEnv...)
+	}
+
+	cmd.Dir = opts.dir
 
-	if env != nil {
-		cmd.Env = env
+	if opts.filesInherit != nil {
+		cmd.ExtraFiles = opts.filesInherit
 	}
 
-	if filesInherit != nil {
-		cmd.ExtraFiles = filesInherit
+	stdout := NewOutputBuffer(opts.stdoutLimit)
+	stderr := NewOutputBuffer(opts.stderrLimit)
+	cmd.Stdin = opts.stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if opts.stdout != nil {
+		cmd.Stdout = opts.stdout
+	}
+
+	var lines *lineWriter
+	if opts.lines != nil {
+		lines = &lineWriter{ctx: ctx, lines: opts.lines}
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, lines)
+	}
+
+	if inactivityTimer != nil {
+		cmd.Stdout = &activityWriter{w: cmd.Stdout, timer: inactivityTimer, timeout: opts.inactivityTimeout}
+	}
+
+	start := time.Now()
+	err := StartWithPriority(cmd, opts.priority)
+	if err == nil {
+		err = cmd.Wait()
+	}
+
+	if lines != nil {
+		lines.flush()
 	}
 
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Truncated output can't be parsed reliably, so don't let it pass for the successful output of the command.
+	if err == nil {
+		err = stdout.truncatedError()
+	}
+
+	if err != nil && errors.Is(context.Cause(ctx), ErrInactivityTimeout) {
+		err = inactivityError(opts.inactivityTimeout, err)
+	}
 
-	err := cmd.Run()
+	reportCommand(name, args, opts.redacted, start, err, stderr)
 	if err != nil {
-		return stdout.String(), stderr.String(), NewRunError(name, arg, err, &stdout, &stderr)
+		// The error ends up in logs and API responses, so keep the sensitive values out of it.
+		errStdout, errStderr := stdout.buffer(), stderr.buffer()
+		if len(opts.redacted) > 0 {
+			args = redactArgs(args, opts.redacted)
+			errStdout = bytes.NewBufferString(redactArgs([]string{errStdout.String()}, opts.redacted)[0])
+			errStderr = bytes.NewBufferString(redactArgs([]string{errStderr.String()}, opts.redacted)[0])
+		}
+
+		return stdout.captured(), stderr.String(), NewRunError(name, args, err, errStdout, errStderr)
 	}
 
-	return stdout.String(), stderr.String(), nil
+	return stdout.captured(), stderr.String(), nil
+}
+
+// RunCommandSplit runs a command with a supplied environment and optional arguments and returns the
+// resulting stdout and stderr output as separate variables. If the supplied environment is nil then
+// the default environment is used. If the command fails to start or returns a non-zero exit code
+// then an error is returned containing the output of stderr too. The output is limited as with
+// RunCommandOutput.
+func RunCommandSplit(ctx context.Context, env []string, filesInherit []*os.File, name string, arg ...string) (string, string, error) {
+	return RunCommandOutput(ctx, name, arg, func(o *runOptions) {
+		o.env = env
+		o.filesInherit = filesInherit
+	})
 }
 
 // RunCommandContext runs a command with optional arguments and returns stdout. If the command fails to
 // start or returns a non-zero exit code then an error is returned containing the output of stderr.
+// When the context is done, the command is killed along with the processes it started.
 func RunCommandContext(ctx context.Context, name string, arg ...string) (string, error) {
 	stdout, _, err := RunCommandSplit(ctx, nil, nil, name, arg...)
 	return stdout, err
@@ -92,8 +238,7 @@ func RunCommandContext(ctx context.Context, name string, arg ...string) (string,
 // start or returns a non-zero exit code then an error is returned containing the output of stderr.
 // Deprecated: Use RunCommandContext.
 func RunCommand(name string, arg ...string) (string, error) {
-	stdout, _, err := RunCommandSplit(context.TODO(), nil, nil, name, arg...)
-	return stdout, err
+	return RunCommandContext(context.Background(), name, arg...)
 }
 
 // RunCommandInheritFds runs a command with optional arguments and passes a set
@@ -113,43 +258,23 @@ func RunCommandCLocale(name string, arg ...string) (string, error) {
 	return stdout, err
 }
 
-// RunCommandWithFds runs a command with supplied file descriptors.
+// RunCommandWithFds runs a command with supplied file descriptors. If the command fails to start or
+// returns a non-zero exit code then a RunError is returned, carrying the captured stderr.
 func RunCommandWithFds(ctx context.Context, stdin io.Reader, stdout io.Writer, name string, arg ...string) error {
-	cmd := exec.CommandContext(ctx, name, arg...)
-
-	if stdin != nil {
-		cmd.Stdin = stdin
-	}
-
-	if stdout != nil {
-		cmd.Stdout = stdout
-	}
-
-	var buffer bytes.Buffer
-	cmd.Stderr = &buffer
-
-	err := cmd.Run()
-	if err != nil {
-		return NewRunError(name, arg, err, nil, &buffer)
-	}
-
-	return nil
+	_, _, err := RunCommandOutput(ctx, name, arg, WithStdin(stdin), WithStdout(stdout))
+	return err
 }
 
 // TryRunCommand runs the specified command up to 20 times with a 500ms delay between each call
 // until it runs without an error. If after 20 times it is still failing then returns the error.
 func TryRunCommand(name string, arg ...string) (string, error) {
-	var err error
-	var output string
-
-	for i := 0; i < 20; i++ {
-		output, err = RunCommand(name, arg...)
-		if err == nil {
-			break
-		}
-
-		time.Sleep(500 * time.Millisecond)
-	}
+	return TryRunCommandWithPolicy(DefaultRetryPolicy, name, arg...)
+}
 
-	return output, err
+// TryRunCommandContext is TryRunCommand, giving up as soon as the context is done, in which case the
+// running command is killed and the error of the last attempt is returned.
+func TryRunCommandContext(ctx context.Context, name string, arg ...string) (string, error) {
+	return TryWithPolicy(ctx, DefaultRetryPolicy, func(ctx context.Context) (string, error) {
+		return RunCommandContext(ctx, name, arg...)
+	})
 }
@@ -0,0 +1,239 @@
+//go:build linux
+
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// processGone returns whether the process doesn't exist anymore or is a zombie waiting to be reaped.
+func processGone(pid int) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+
+	// The state follows the command name, which is between parentheses.
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+func TestRunCommandContext(t *testing.T) {
+	output, err := RunCommandContext(context.Background(), "sh", "-c", "echo hello; echo world >&2")
+	if err != nil {
+		t.Fatalf("Failed running command: %v", err)
+	}
+
+	if output != "hello\n" {
+		t.Errorf("Unexpected output %q", output)
+	}
+
+	_, err = RunCommandContext(context.Background(), "sh", "-c", "echo failure >&2; exit 3")
+
+	var runErr RunError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("Expected a RunError, got %v", err)
+	}
+
+	if runErr.StdErr().String() != "failure\n" {
+		t.Errorf("Unexpected stderr %q", runErr.StdErr().String())
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("Expected exit code 3, got %v", err)
+	}
+}
+
+func TestRunCommandOutput(t *testing.T) {
+	stdout, stderr, err := RunCommandOutput(context.Background(), "sh", []string{"-c", "printf 0123456789; printf abcdefghij >&2; exit 1"}, WithOutputLimits(4, 6))
+
+	if stdout != "0123" {
+		t.Errorf("Unexpected stdout %q", stdout)
+	}
+
+	if stderr != "abcdef\n[output truncated, 4 bytes discarded]\n" {
+		t.Errorf("Unexpected stderr %q", stderr)
+	}
+
+	// The captured stderr is part of the error, even when wrapped.
+	err = fmt.Errorf("Failed doing something: %w", err)
+
+	if !strings.Contains(err.Error(), "abcdef\n[output truncated, 4 bytes discarded]") {
+		t.Errorf("Expected the error to include stderr, got %q", err.Error())
+	}
+
+	var runErr RunError
+	if !errors.As(err, &runErr) || runErr.StdErr().String() != stderr {
+		t.Errorf("Expected a RunError carrying stderr, got %v", err)
+	}
+}
+
+func TestRunCommandOutputTruncated(t *testing.T) {
+	// A command succeeding with more output than the limit fails, without the truncation notice in its output.
+	stdout, _, err := RunCommandOutput(context.Background(), "sh", []string{"-c", "printf 0123456789"}, WithOutputLimits(4, 0))
+	if !errors.Is(err, ErrOutputTruncated) {
+		t.Errorf("Expected the command to fail with truncated output, got %v", err)
+	}
+
+	if stdout != "0123" {
+		t.Errorf("Unexpected stdout %q", stdout)
+	}
+
+	// Output within the limit is left alone.
+	stdout, _, err = RunCommandOutput(context.Background(), "sh", []string{"-c", "printf 0123"}, WithOutputLimits(4, 0))
+	if err != nil || stdout != "0123" {
+		t.Errorf("Unexpected result %q, %v", stdout, err)
+	}
+}
+
+func TestRunCommandContextTimeout(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The pipeline keeps the output open, so only killing the whole process group lets the command return.
+	start := time.Now()
+	_, err := RunCommandContext(ctx, "sh", "-c", fmt.Sprintf("sleep 30 & echo $! > %s; wait", pidFile))
+	if err == nil {
+		t.Fatal("Expected the command to be killed")
+	}
+
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("Command took %s to be killed", time.Since(start))
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Errorf("Expected an exit error, got %v", err)
+	}
+
+	content, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("Failed reading PID of child process: %v", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		t.Fatalf("Invalid PID of child process: %v", err)
+	}
+
+	for i := 0; i < 50 && !processGone(pid); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if !processGone(pid) {
+		t.Errorf("Child process %d is still running", pid)
+	}
+}
+
+func TestTryRunCommandContext(t *testing.T) {
+	countFile := filepath.Join(t.TempDir(), "count")
+
+	// Succeeds on the third attempt.
+	script := fmt.Sprintf("echo x >> %s; [ $(wc -l < %s) -ge 3 ] && echo done", countFile, countFile)
+	output, err := TryRunCommandContext(context.Background(), "sh", "-c", script)
+	if err != nil {
+		t.Fatalf("Failed running command: %v", err)
+	}
+
+	if output != "done\n" {
+		t.Errorf("Unexpected output %q", output)
+	}
+
+	// Gives up once the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 700*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = TryRunCommandContext(ctx, "false")
+	if err == nil {
+		t.Fatal("Expected the command to fail")
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Retries went on for %s after the context was done", time.Since(start))
+	}
+}
+
+func TestTryRunCommandWithPolicy(t *testing.T) {
+	countFile := filepath.Join(t.TempDir(), "count")
+
+	// Exits with EBUSY twice, then with EINVAL which isn't retried.
+	script := fmt.Sprintf("echo x >> %s; [ $(wc -l < %s) -ge 3 ] && exit 22; exit 16", countFile, countFile)
+	_, err := TryRunCommandWithPolicy(RetryPolicy{Attempts: 5, Delay: 10 * time.Millisecond, Retry: RetryOnExitCodes(16)}, "sh", "-c", script)
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 22 {
+		t.Errorf("Expected exit code 22, got %v", err)
+	}
+
+	content, err := os.ReadFile(countFile)
+	if err != nil {
+		t.Fatalf("Failed reading attempt count: %v", err)
+	}
+
+	if strings.Count(string(content), "x") != 3 {
+		t.Errorf("Expected 3 attempts, got %d", strings.Count(string(content), "x"))
+	}
+}
+
+func TestRunCommandOutputEnv(t *testing.T) {
+	t.Setenv("INCUS_TEST_PARENT", "parent")
+	t.Setenv("INCUS_TEST_OVERRIDE", "parent")
+
+	dir := t.TempDir()
+	stdout, _, err := RunCommandOutput(context.Background(), "sh", []string{"-c", `echo "$INCUS_TEST_PARENT $INCUS_TEST_OVERRIDE $INCUS_TEST_ADDED $(pwd)"`}, WithEnv("INCUS_TEST_OVERRIDE=child", "INCUS_TEST_ADDED=added"), WithDir(dir))
+	if err != nil {
+		t.Fatalf("Failed running command: %v", err)
+	}
+
+	if stdout != fmt.Sprintf("parent child added %s\n", dir) {
+		t.Errorf("Unexpected output %q", stdout)
+	}
+
+	// The environment of the caller is left unchanged.
+	if os.Getenv("INCUS_TEST_OVERRIDE") != "parent" || os.Getenv("INCUS_TEST_ADDED") != "" {
+		t.Error("The environment of the caller was modified")
+	}
+
+	// The variables are added on top of a given environment.
+	env := make([]string, 1, 2)
+	env[0] = "INCUS_TEST_GIVEN=given"
+	stdout, _, err = RunCommandOutput(context.Background(), "sh", []string{"-c", `echo "$INCUS_TEST_GIVEN $INCUS_TEST_PARENT $INCUS_TEST_ADDED"`}, func(o *runOptions) { o.env = env }, WithEnv("INCUS_TEST_ADDED=added"))
+	if err != nil {
+		t.Fatalf("Failed running command: %v", err)
+	}
+
+	if stdout != "given  added\n" {
+		t.Errorf("Unexpected output %q", stdout)
+	}
+
+	if len(env[:cap(env)][1]) != 0 {
+		t.Error("The given environment was modified")
+	}
+}
+
+func TestRunCommandOutputStdio(t *testing.T) {
+	var buf strings.Builder
+
+	stdout, _, err := RunCommandOutput(context.Background(), "cat", nil, WithStdin(strings.NewReader("hello")), WithStdout(&buf))
+	if err != nil {
+		t.Fatalf("Failed running command: %v", err)
+	}
+
+	if stdout != "" || buf.String() != "hello" {
+		t.Errorf("Unexpected output %q, %q", stdout, buf.String())
+	}
+}
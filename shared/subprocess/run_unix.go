@@ -0,0 +1,45 @@
+//go:build !windows
+
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes the command run in its own process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// killProcessGroup kills a command started with setProcessGroup along with the processes it started.
+func killProcessGroup(cmd *exec.Cmd) error {
+	err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+
+	return err
+}
+
+// killProcessGroupOnCancel runs the command in its own process group, killed as a whole once the context is done
+// rather than only the command, so that the processes it started (like the ones of a shell pipeline) don't
+// outlive it. Commands whose context can't be done are left in the process group of the caller.
+func killProcessGroupOnCancel(ctx context.Context, cmd *exec.Cmd) {
+	if ctx.Done() == nil {
+		return
+	}
+
+	setProcessGroup(cmd)
+
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package subprocess
+
+import (
+	"context"
+	"os/exec"
+)
+
+// setProcessGroup does nothing on Windows.
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// killProcessGroup only kills the command on Windows.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killProcessGroupOnCancel does nothing on Windows, where only the command is killed once the context is done.
+func killProcessGroupOnCancel(ctx context.Context, cmd *exec.Cmd) {
+}
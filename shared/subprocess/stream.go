@@ -0,0 +1,106 @@
+package subprocess
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrInactivityTimeout is wrapped in the error of a command killed because it stopped producing output, see
+// WithInactivityTimeout.
+var ErrInactivityTimeout = errors.New("Command stopped producing output")
+
+// WithInactivityTimeout kills the command, along with the processes it started, when it doesn't write anything
+// to stdout for the given duration. It's meant for commands which may get stuck half way through their output,
+// like the ones querying an unresponsive storage cluster. The returned error then wraps ErrInactivityTimeout and
+// the stdout captured so far is still returned.
+func WithInactivityTimeout(timeout time.Duration) RunOption {
+	return func(o *runOptions) {
+		o.inactivityTimeout = timeout
+	}
+}
+
+// RunCommandStream runs a command like RunCommandOutput, sending each line of its stdout, without the trailing
+// newline, to the channel as soon as it's written. The channel is closed once the command is done, so the caller
+// must keep receiving from it until then. The returned stdout is captured as with RunCommandOutput, so that the
+// output collected until the command failed, was killed along with the context or by WithInactivityTimeout, is
+// returned along with the error.
+func RunCommandStream(ctx context.Context, lines chan<- string, name string, args []string, options ...RunOption) (string, error) {
+	defer close(lines)
+
+	stdout, _, err := RunCommandOutput(ctx, name, args, append(options, func(o *runOptions) {
+		o.lines = lines
+	})...)
+
+	return stdout, err
+}
+
+// activityWriter postpones the inactivity timer of a command each time it writes to stdout.
+type activityWriter struct {
+	w       io.Writer
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (w *activityWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.timer.Reset(w.timeout)
+	}
+
+	return w.w.Write(p)
+}
+
+// lineWriter sends the complete lines written to it to a channel, giving up on sending them once the context of
+// the command is done so that a caller which stopped receiving can't block it.
+type lineWriter struct {
+	ctx   context.Context
+	lines chan<- string
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.send(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// flush sends the last line if it didn't end with a newline.
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.send(string(w.partial))
+		w.partial = nil
+	}
+}
+
+func (w *lineWriter) send(line string) {
+	select {
+	case w.lines <- line:
+	case <-w.ctx.Done():
+	}
+}
+
+// inactivityError returns the error of a command killed by WithInactivityTimeout.
+func inactivityError(timeout time.Duration, err error) error {
+	return fmt.Errorf("%w (no output for %s): %w", ErrInactivityTimeout, timeout, err)
+}
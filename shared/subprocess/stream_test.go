@@ -0,0 +1,123 @@
+//go:build linux
+
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// collectLines receives the lines streamed by RunCommandStream until the channel is closed.
+func collectLines(lines chan string) chan []string {
+	collected := make(chan []string, 1)
+	go func() {
+		var received []string
+		for line := range lines {
+			received = append(received, line)
+		}
+
+		collected <- received
+	}()
+
+	return collected
+}
+
+func TestRunCommandStream(t *testing.T) {
+	lines := make(chan string)
+	first := make(chan time.Time, 1)
+	collected := make(chan []string, 1)
+	go func() {
+		var received []string
+		for line := range lines {
+			if len(received) == 0 {
+				first <- time.Now()
+			}
+
+			received = append(received, line)
+		}
+
+		collected <- received
+	}()
+
+	start := time.Now()
+	stdout, err := RunCommandStream(context.Background(), lines, "sh", []string{"-c", "echo one; sleep 1; printf 'two\\nthree'"})
+	if err != nil {
+		t.Fatalf("Failed running command: %v", err)
+	}
+
+	// The first line is received before the command is done.
+	if (<-first).Sub(start) > 900*time.Millisecond {
+		t.Error("The first line wasn't streamed as soon as it was written")
+	}
+
+	received := <-collected
+	if len(received) != 3 || received[0] != "one" || received[1] != "two" || received[2] != "three" {
+		t.Errorf("Unexpected lines %q", received)
+	}
+
+	if stdout != "one\ntwo\nthree" {
+		t.Errorf("Unexpected stdout %q", stdout)
+	}
+}
+
+func TestRunCommandStreamInactivityTimeout(t *testing.T) {
+	lines := make(chan string)
+	collected := collectLines(lines)
+
+	// The command keeps producing output for longer than the timeout, then gets stuck.
+	start := time.Now()
+	stdout, err := RunCommandStream(context.Background(), lines, "sh", []string{"-c", "for i in 1 2 3; do echo $i; sleep 0.2; done; sleep 30"}, WithInactivityTimeout(500*time.Millisecond))
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("Command took %s to be killed", time.Since(start))
+	}
+
+	if !errors.Is(err, ErrInactivityTimeout) {
+		t.Fatalf("Expected an inactivity timeout, got %v", err)
+	}
+
+	var runErr RunError
+	if !errors.As(err, &runErr) || runErr.StdOut().String() != "1\n2\n3\n" {
+		t.Errorf("Expected the partial output in the error, got %v", err)
+	}
+
+	if stdout != "1\n2\n3\n" {
+		t.Errorf("Unexpected partial stdout %q", stdout)
+	}
+
+	received := <-collected
+	if len(received) != 3 {
+		t.Errorf("Unexpected lines %q", received)
+	}
+}
+
+func TestRunCommandStreamContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	lines := make(chan string)
+	collected := collectLines(lines)
+
+	// The command never stops producing output, so only the context stops it.
+	stdout, err := RunCommandStream(ctx, lines, "sh", []string{"-c", "while true; do echo tick; sleep 0.1; done"}, WithInactivityTimeout(time.Minute))
+	if err == nil || errors.Is(err, ErrInactivityTimeout) {
+		t.Errorf("Expected the command to be killed along with the context, got %v", err)
+	}
+
+	if stdout == "" || len(<-collected) == 0 {
+		t.Error("Expected the output collected before the command was killed")
+	}
+}
+
+func TestRunCommandOutputInactivityTimeout(t *testing.T) {
+	// A command writing regularly isn't killed, however long it runs.
+	stdout, _, err := RunCommandOutput(context.Background(), "sh", []string{"-c", "for i in 1 2 3 4 5; do echo $i; sleep 0.2; done"}, WithInactivityTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("Failed running command: %v", err)
+	}
+
+	if stdout != "1\n2\n3\n4\n5\n" {
+		t.Errorf("Unexpected stdout %q", stdout)
+	}
+}
@@ -0,0 +1,551 @@
+//go:build !windows
+
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RestartMode defines when a supervised process is restarted after it exits.
+type RestartMode string
+
+const (
+	// RestartNever leaves the process stopped once it exits.
+	RestartNever RestartMode = "never"
+
+	// RestartOnFailure restarts the process when it exits with an error or is killed.
+	RestartOnFailure RestartMode = "on-failure"
+
+	// RestartAlways restarts the process whenever it exits.
+	RestartAlways RestartMode = "always"
+)
+
+// RestartPolicy defines how a supervised process is restarted.
+type RestartPolicy struct {
+	// Mode is when the process is restarted.
+	Mode RestartMode
+
+	// MaxRestarts is the maximum number of consecutive restarts before giving up, or 0 for no limit.
+	MaxRestarts int
+
+	// Delay is the wait before the first restart, doubled for each consecutive restart.
+	Delay time.Duration
+
+	// MaxDelay caps the wait between restarts, if not 0.
+	MaxDelay time.Duration
+
+	// ResetAfter is how long the process must run for its restarts not to be considered consecutive anymore.
+	ResetAfter time.Duration
+}
+
+// delay returns the wait before the given consecutive restart, starting at 0.
+func (p RestartPolicy) delay(restart int) time.Duration {
+	delay := p.Delay
+	for i := 0; i < restart && (p.MaxDelay == 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
+
+// SupervisedProcess declares a long-running process run by a Supervisor.
+type SupervisedProcess struct {
+	// Name identifies the process within the supervisor.
+	Name string
+
+	// Command and Args are the command line of the process.
+	Command string
+	Args    []string
+
+	// PidFile is where the process details are saved, so that the supervisor can re-attach to it after a restart.
+	PidFile string
+
+	// Stdout and Stderr are the optional log files of the process, appended to when it's restarted.
+	Stdout string
+	Stderr string
+
+	// Apparmor is the optional AppArmor profile to run the process under.
+	Apparmor string
+
+	// ConfigHash identifies the configuration the process reads from files, if any, so that a running process
+	// is only kept when it didn't change.
+	ConfigHash string
+
+	// Policy defines how the process is restarted when it exits.
+	Policy RestartPolicy
+
+	// OnExit is called, if set, every time the process exits.
+	OnExit func(ProcessExit)
+}
+
+// ProcessExit describes the exit of a supervised process.
+type ProcessExit struct {
+	// Name is the name of the supervised process.
+	Name string
+
+	// PID is the PID the process had.
+	PID int64
+
+	// ExitCode is the exit code of the process, or -1 if it was killed or its exit code isn't known.
+	ExitCode int
+
+	// Err is the error the process exited with, if any.
+	Err error
+
+	// Restarting indicates whether the process is going to be restarted.
+	Restarting bool
+}
+
+// SupervisedProcessInfo exposes the state of a supervised process.
+type SupervisedProcessInfo struct {
+	Name          string    `json:"name" yaml:"name"`
+	Command       []string  `json:"command" yaml:"command"`
+	PID           int64     `json:"pid" yaml:"pid"`
+	Running       bool      `json:"running" yaml:"running"`
+	Attached      bool      `json:"attached" yaml:"attached"`
+	Restarts      int       `json:"restarts" yaml:"restarts"`
+	StartedAt     time.Time `json:"started_at" yaml:"started_at"`
+	LastExitAt    time.Time `json:"last_exit_at" yaml:"last_exit_at"`
+	LastExitCode  int       `json:"last_exit_code" yaml:"last_exit_code"`
+	LastExitError string    `json:"last_exit_error" yaml:"last_exit_error"`
+}
+
+// supervisedState is the content of the PID file of a supervised process.
+type supervisedState struct {
+	Process    `yaml:",inline"`
+	Restarts   int    `yaml:"restarts"`
+	ConfigHash string `yaml:"config_hash,omitempty"`
+}
+
+// sameProcess returns whether both specs run the same command line with the same configuration.
+func sameProcess(a SupervisedProcess, b SupervisedProcess) bool {
+	return a.Command == b.Command && slices.Equal(a.Args, b.Args) && a.Apparmor == b.Apparmor && a.ConfigHash == b.ConfigHash
+}
+
+// supervised is a process run by a Supervisor.
+type supervised struct {
+	spec SupervisedProcess
+	proc *Process
+	info SupervisedProcessInfo
+
+	// consecutive is the number of restarts since the process last ran for long enough.
+	consecutive int
+
+	// stop is closed to stop supervising the process.
+	stop chan struct{}
+
+	// exits is closed and replaced whenever the process exits.
+	exits    chan struct{}
+	lastExit ProcessExit
+
+	// exited receives the exit of the current process, being replaced whenever the process is started.
+	exited chan ProcessExit
+}
+
+// Supervisor runs long-running processes, like helper daemons, restarting them according to their policy when
+// they exit. The processes outlive the supervisor and are re-attached to through their PID file.
+type Supervisor struct {
+	mu        sync.Mutex
+	processes map[string]*supervised
+	shutdown  bool
+
+	// pollInterval is how often the processes which weren't spawned by this supervisor are checked.
+	pollInterval time.Duration
+}
+
+// NewSupervisor returns a new Supervisor.
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		processes:    map[string]*supervised{},
+		pollInterval: time.Second,
+	}
+}
+
+// Start starts supervising the process, keeping it running if it's already running with the same command line
+// and configuration, whether it's supervised already or its PID file refers to it, or starting it otherwise.
+// Any other process still running under the same name must be stopped first.
+//
+// The returned channel receives the exit of the process, so that callers checking that it keeps running don't
+// miss it exiting right away. Nothing is sent when the process is stopped through Stop.
+func (s *Supervisor) Start(spec SupervisedProcess) (<-chan ProcessExit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shutdown {
+		return nil, fmt.Errorf("Supervisor is shut down")
+	}
+
+	sp, ok := s.processes[spec.Name]
+	if ok {
+		if sp.info.Running && sameProcess(sp.spec, spec) {
+			return sp.exited, nil
+		}
+
+		return nil, fmt.Errorf("Process %q is already supervised", spec.Name)
+	}
+
+	sp = &supervised{
+		spec:  spec,
+		stop:  make(chan struct{}),
+		exits: make(chan struct{}),
+		info: SupervisedProcessInfo{
+			Name:    spec.Name,
+			Command: append([]string{spec.Command}, spec.Args...),
+		},
+	}
+
+	proc, restarts := attachProcess(spec)
+	if proc != nil {
+		sp.proc = proc
+		sp.exited = make(chan ProcessExit, 1)
+		sp.info.PID = proc.PID
+		sp.info.Running = true
+		sp.info.Attached = true
+		sp.info.Restarts = restarts
+		sp.info.StartedAt = time.Now()
+	} else {
+		err := sp.start(true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.processes[spec.Name] = sp
+	go s.monitor(sp, sp.proc)
+
+	return sp.exited, nil
+}
+
+// Running returns whether the process is running with the command line and configuration of the spec, either
+// supervised or referred to by its PID file, meaning that Start would keep it running.
+func (s *Supervisor) Running(spec SupervisedProcess) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sp, ok := s.processes[spec.Name]
+	if ok {
+		return sp.info.Running && sameProcess(sp.spec, spec)
+	}
+
+	proc, _ := attachProcess(spec)
+
+	return proc != nil
+}
+
+// Stop stops supervising the process, kills it and removes its PID file.
+func (s *Supervisor) Stop(name string) error {
+	s.mu.Lock()
+	sp, ok := s.processes[name]
+	if ok {
+		delete(s.processes, name)
+		close(sp.stop)
+	}
+
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	err := sp.proc.Stop()
+	if err != nil && err != ErrNotRunning {
+		return err
+	}
+
+	if sp.spec.PidFile != "" {
+		err = os.Remove(sp.spec.PidFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Shutdown stops supervising all the processes, leaving them running so that they can be re-attached to.
+func (s *Supervisor) Shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shutdown = true
+	for name, sp := range s.processes {
+		close(sp.stop)
+		delete(s.processes, name)
+	}
+}
+
+// Wait waits for the next exit of the process.
+func (s *Supervisor) Wait(ctx context.Context, name string) (ProcessExit, error) {
+	s.mu.Lock()
+	sp, ok := s.processes[name]
+	if !ok {
+		s.mu.Unlock()
+		return ProcessExit{}, fmt.Errorf("Process %q isn't supervised", name)
+	}
+
+	exits := sp.exits
+	s.mu.Unlock()
+
+	select {
+	case <-exits:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return sp.lastExit, nil
+	case <-ctx.Done():
+		return ProcessExit{}, ctx.Err()
+	}
+}
+
+// List returns the state of the supervised processes, sorted by name.
+func (s *Supervisor) List() []SupervisedProcessInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]SupervisedProcessInfo, 0, len(s.processes))
+	for _, sp := range s.processes {
+		list = append(list, sp.info)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+// attachProcess returns the process referred to by the PID file of the spec, along with its restart count, if
+// it's still running with the same command line and configuration.
+func attachProcess(spec SupervisedProcess) (*Process, int) {
+	if spec.PidFile == "" {
+		return nil, 0
+	}
+
+	content, err := os.ReadFile(spec.PidFile)
+	if err != nil {
+		return nil, 0
+	}
+
+	state := supervisedState{}
+	err = yaml.Unmarshal(content, &state)
+	if err != nil {
+		return nil, 0
+	}
+
+	proc := &state.Process
+	if proc.Name != spec.Command || !slices.Equal(proc.Args, spec.Args) || proc.Apparmor != spec.Apparmor || state.ConfigHash != spec.ConfigHash {
+		return nil, 0
+	}
+
+	_, err = proc.GetPid()
+	if err != nil {
+		return nil, 0
+	}
+
+	return proc, state.Restarts
+}
+
+// start starts the process and saves its PID file. The log files are truncated on the first start.
+func (sp *supervised) start(first bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if first {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	openLog := func(path string) (io.WriteCloser, error) {
+		if path == "" {
+			return nil, nil
+		}
+
+		f, err := os.OpenFile(path, flags, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to open log file %q: %w", path, err)
+		}
+
+		return f, nil
+	}
+
+	stdout, err := openLog(sp.spec.Stdout)
+	if err != nil {
+		return err
+	}
+
+	stderr := stdout
+	if sp.spec.Stderr != sp.spec.Stdout {
+		stderr, err = openLog(sp.spec.Stderr)
+		if err != nil {
+			if stdout != nil {
+				_ = stdout.Close()
+			}
+
+			return err
+		}
+	}
+
+	proc := NewProcessWithFds(sp.spec.Command, sp.spec.Args, nil, stdout, stderr)
+	proc.closeFds = true
+	proc.SetApparmor(sp.spec.Apparmor)
+
+	err = proc.Start(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if sp.spec.PidFile != "" {
+		// Only copy the saved fields, the others being updated once the process exits.
+		saved := Process{Name: proc.Name, Args: proc.Args, Apparmor: proc.Apparmor, PID: proc.PID}
+
+		dat, err := yaml.Marshal(supervisedState{Process: saved, Restarts: sp.info.Restarts, ConfigHash: sp.spec.ConfigHash})
+		if err == nil {
+			err = os.WriteFile(sp.spec.PidFile, dat, 0644)
+		}
+
+		if err != nil {
+			_ = proc.Stop()
+			return fmt.Errorf("Failed saving PID file %q: %w", sp.spec.PidFile, err)
+		}
+	}
+
+	sp.proc = proc
+	sp.exited = make(chan ProcessExit, 1)
+	sp.info.PID = proc.PID
+	sp.info.Running = true
+	sp.info.Attached = false
+	sp.info.StartedAt = time.Now()
+
+	return nil
+}
+
+// errSupervisionStopped is returned when waiting for a process which stopped being supervised.
+var errSupervisionStopped = errors.New("Supervision stopped")
+
+// wait waits for the process to exit, polling it when it wasn't spawned by this supervisor, and returns its exit
+// code and error.
+func (s *Supervisor) wait(sp *supervised, proc *Process) (int, error) {
+	if proc.hasMonitor {
+		select {
+		case <-proc.chExit:
+			exitCode, err := proc.Wait(context.Background())
+			return int(exitCode), err
+		case <-sp.stop:
+			return 0, errSupervisionStopped
+		}
+	}
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_, err := proc.GetPid()
+			if err != nil {
+				return -1, fmt.Errorf("Process exited with unknown status")
+			}
+
+		case <-sp.stop:
+			return 0, errSupervisionStopped
+		}
+	}
+}
+
+// monitor waits for the process to exit and restarts it according to its policy until the supervision is stopped.
+func (s *Supervisor) monitor(sp *supervised, proc *Process) {
+	for {
+		exitCode, err := s.wait(sp, proc)
+		if err == errSupervisionStopped {
+			return
+		}
+
+		s.mu.Lock()
+		policy := sp.spec.Policy
+		if policy.ResetAfter > 0 && time.Since(sp.info.StartedAt) >= policy.ResetAfter {
+			sp.consecutive = 0
+		}
+
+		restart := policy.Mode == RestartAlways || (policy.Mode == RestartOnFailure && (exitCode != 0 || err != nil))
+		if policy.MaxRestarts > 0 && sp.consecutive >= policy.MaxRestarts {
+			restart = false
+		}
+
+		select {
+		case <-sp.stop:
+			restart = false
+		default:
+		}
+
+		exit := ProcessExit{Name: sp.spec.Name, PID: sp.info.PID, ExitCode: exitCode, Err: err, Restarting: restart}
+
+		sp.info.Running = false
+		sp.info.LastExitAt = time.Now()
+		sp.info.LastExitCode = exitCode
+		sp.info.LastExitError = ""
+		if err != nil {
+			sp.info.LastExitError = err.Error()
+		}
+
+		sp.lastExit = exit
+		sp.exited <- exit
+		close(sp.exits)
+		sp.exits = make(chan struct{})
+		delay := policy.delay(sp.consecutive)
+		s.mu.Unlock()
+
+		if sp.spec.OnExit != nil {
+			sp.spec.OnExit(exit)
+		}
+
+		if !restart {
+			return
+		}
+
+		// Keep trying to start the process, counting failures to start like exits.
+		for {
+			select {
+			case <-time.After(delay):
+			case <-sp.stop:
+				return
+			}
+
+			s.mu.Lock()
+			select {
+			case <-sp.stop:
+				s.mu.Unlock()
+				return
+			default:
+			}
+
+			sp.consecutive++
+			sp.info.Restarts++
+			err = sp.start(false)
+			if err == nil {
+				proc = sp.proc
+				s.mu.Unlock()
+				break
+			}
+
+			sp.info.LastExitAt = time.Now()
+			sp.info.LastExitCode = -1
+			sp.info.LastExitError = err.Error()
+			giveUp := policy.MaxRestarts > 0 && sp.consecutive >= policy.MaxRestarts
+			delay = policy.delay(sp.consecutive)
+			s.mu.Unlock()
+
+			if giveUp {
+				return
+			}
+		}
+	}
+}
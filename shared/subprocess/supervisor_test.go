@@ -0,0 +1,303 @@
+//go:build linux
+
+package subprocess
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// helperSpec returns the spec of the fake helper, recording its PIDs in a file of the returned directory.
+func helperSpec(t *testing.T, seconds string, exitCode string, policy RestartPolicy) (SupervisedProcess, string) {
+	dir := t.TempDir()
+
+	return SupervisedProcess{
+		Name:    "helper",
+		Command: "sh",
+		Args:    []string{"testscript/helper.sh", filepath.Join(dir, "pids"), seconds, exitCode},
+		PidFile: filepath.Join(dir, "helper.pid"),
+		Stdout:  filepath.Join(dir, "helper.log"),
+		Stderr:  filepath.Join(dir, "helper.log"),
+		Policy:  policy,
+	}, dir
+}
+
+// helperPIDs returns the PIDs the fake helper ran with, waiting for it to have run at least the given number of
+// times.
+func helperPIDs(t *testing.T, dir string, count int) []string {
+	var pids []string
+	for i := 0; i < 250 && len(pids) < count; i++ {
+		content, err := os.ReadFile(filepath.Join(dir, "pids"))
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Failed reading helper PIDs: %v", err)
+		}
+
+		pids = strings.Fields(string(content))
+		if len(pids) < count {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	return pids
+}
+
+// collectExits waits for the given number of exits notified through the channel.
+func collectExits(t *testing.T, exits chan ProcessExit, count int) []ProcessExit {
+	var collected []ProcessExit
+	for len(collected) < count {
+		select {
+		case exit := <-exits:
+			collected = append(collected, exit)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Got %d exits out of %d", len(collected), count)
+		}
+	}
+
+	return collected
+}
+
+func TestSupervisorRestartOnFailure(t *testing.T) {
+	exits := make(chan ProcessExit, 10)
+
+	spec, dir := helperSpec(t, "0", "3", RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 2, Delay: 10 * time.Millisecond})
+	spec.OnExit = func(exit ProcessExit) { exits <- exit }
+
+	s := NewSupervisor()
+	_, err := s.Start(spec)
+	if err != nil {
+		t.Fatalf("Failed starting helper: %v", err)
+	}
+
+	collected := collectExits(t, exits, 3)
+	for i, exit := range collected {
+		if exit.ExitCode != 3 || exit.Err == nil {
+			t.Errorf("Unexpected exit %d: %+v", i, exit)
+		}
+
+		if exit.Restarting != (i < 2) {
+			t.Errorf("Unexpected restart decision for exit %d: %+v", i, exit)
+		}
+	}
+
+	pids := helperPIDs(t, dir, 3)
+	if len(pids) != 3 {
+		t.Errorf("Expected the helper to run 3 times, got %d", len(pids))
+	}
+
+	list := s.List()
+	if len(list) != 1 || list[0].Restarts != 2 || list[0].Running || list[0].LastExitCode != 3 {
+		t.Errorf("Unexpected supervised processes %+v", list)
+	}
+
+	// The logs of all the runs are kept.
+	log, err := os.ReadFile(spec.Stderr)
+	if err != nil {
+		t.Fatalf("Failed reading helper log: %v", err)
+	}
+
+	if strings.Count(string(log), "exiting with 3") != 3 {
+		t.Errorf("Unexpected helper log %q", log)
+	}
+
+	err = s.Stop("helper")
+	if err != nil {
+		t.Errorf("Failed stopping helper: %v", err)
+	}
+
+	if len(s.List()) != 0 {
+		t.Error("Helper is still supervised after being stopped")
+	}
+}
+
+func TestSupervisorNoRestart(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode string
+		policy   RestartPolicy
+	}{
+		{"Never", "1", RestartPolicy{Mode: RestartNever}},
+		{"OnFailureSuccess", "0", RestartPolicy{Mode: RestartOnFailure}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, dir := helperSpec(t, "0", tt.exitCode, tt.policy)
+
+			s := NewSupervisor()
+			exited, err := s.Start(spec)
+			if err != nil {
+				t.Fatalf("Failed starting helper: %v", err)
+			}
+
+			// The helper exits right away, possibly before anyone could wait for it, which the returned channel
+			// still reports.
+			var exit ProcessExit
+			select {
+			case exit = <-exited:
+			case <-time.After(10 * time.Second):
+				t.Fatal("Helper exit wasn't reported")
+			}
+
+			if exit.Restarting {
+				t.Errorf("Unexpected restart: %+v", exit)
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			pids := helperPIDs(t, dir, 1)
+			if len(pids) != 1 {
+				t.Errorf("Expected the helper to run once, got %d", len(pids))
+			}
+		})
+	}
+}
+
+func TestSupervisorReattach(t *testing.T) {
+	spec, dir := helperSpec(t, "30", "0", RestartPolicy{Mode: RestartOnFailure, Delay: 10 * time.Millisecond})
+
+	s := NewSupervisor()
+	_, err := s.Start(spec)
+	if err != nil {
+		t.Fatalf("Failed starting helper: %v", err)
+	}
+
+	// The helper keeps running once the supervisor is gone, like across a daemon restart.
+	s.Shutdown()
+
+	s = NewSupervisor()
+	s.pollInterval = 20 * time.Millisecond
+	if !s.Running(spec) {
+		t.Fatal("Expected the helper left running to be found")
+	}
+
+	_, err = s.Start(spec)
+	if err != nil {
+		t.Fatalf("Failed re-attaching to helper: %v", err)
+	}
+
+	defer func() { _ = s.Stop("helper") }()
+
+	pids := helperPIDs(t, dir, 1)
+	list := s.List()
+	if len(pids) != 1 || len(list) != 1 || !list[0].Attached || strconv.FormatInt(list[0].PID, 10) != pids[0] {
+		t.Fatalf("Expected to re-attach to helper %v, got %+v", pids, list)
+	}
+
+	// A re-attached helper which dies is noticed and restarted.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = syscall.Kill(int(list[0].PID), syscall.SIGKILL)
+	if err != nil {
+		t.Fatalf("Failed killing helper: %v", err)
+	}
+
+	exit, err := s.Wait(ctx, "helper")
+	if err != nil {
+		t.Fatalf("Failed waiting for helper: %v", err)
+	}
+
+	if exit.ExitCode != -1 || !exit.Restarting {
+		t.Errorf("Unexpected exit %+v", exit)
+	}
+
+	pids = helperPIDs(t, dir, 2)
+	list = s.List()
+	if len(pids) != 2 || list[0].Attached || list[0].Restarts != 1 {
+		t.Errorf("Expected the helper to be restarted, got %+v", list)
+	}
+
+	// Stopping the helper kills it and removes its PID file.
+	err = s.Stop("helper")
+	if err != nil {
+		t.Fatalf("Failed stopping helper: %v", err)
+	}
+
+	if !processGone(int(list[0].PID)) {
+		t.Errorf("Helper %d is still running", list[0].PID)
+	}
+
+	_, err = os.Stat(spec.PidFile)
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected the PID file to be removed, got %v", err)
+	}
+}
+
+func TestSupervisorKeepRunning(t *testing.T) {
+	spec, dir := helperSpec(t, "30", "0", RestartPolicy{Mode: RestartNever})
+	spec.ConfigHash = "one"
+
+	s := NewSupervisor()
+	_, err := s.Start(spec)
+	if err != nil {
+		t.Fatalf("Failed starting helper: %v", err)
+	}
+
+	defer func() { _ = s.Stop("helper") }()
+
+	// Starting the same helper again keeps it running.
+	_, err = s.Start(spec)
+	if err != nil {
+		t.Fatalf("Failed starting helper again: %v", err)
+	}
+
+	pids := helperPIDs(t, dir, 1)
+	if len(pids) != 1 {
+		t.Fatalf("Expected the helper to run once, got %v", pids)
+	}
+
+	// A helper with another configuration isn't kept, whether it's supervised or left running by a previous
+	// supervisor.
+	changed := spec
+	changed.ConfigHash = "two"
+	if s.Running(changed) {
+		t.Error("Expected the helper with another configuration not to be considered running")
+	}
+
+	_, err = s.Start(changed)
+	if err == nil {
+		t.Error("Expected starting the helper with another configuration to fail while it's supervised")
+	}
+
+	s.Shutdown()
+
+	s = NewSupervisor()
+	if s.Running(changed) {
+		t.Error("Expected the helper left running with another configuration not to be re-attached")
+	}
+
+	pid, err := strconv.Atoi(pids[0])
+	if err != nil {
+		t.Fatalf("Invalid helper PID %q: %v", pids[0], err)
+	}
+
+	err = syscall.Kill(pid, syscall.SIGKILL)
+	if err != nil {
+		t.Fatalf("Failed killing helper: %v", err)
+	}
+
+	_, err = s.Start(changed)
+	if err != nil {
+		t.Fatalf("Failed starting helper with another configuration: %v", err)
+	}
+
+	list := s.List()
+	if len(helperPIDs(t, dir, 2)) != 2 || len(list) != 1 || list[0].Attached {
+		t.Errorf("Expected the helper to be started again, got %+v", list)
+	}
+}
+
+func TestRestartPolicyDelay(t *testing.T) {
+	policy := RestartPolicy{Delay: time.Second, MaxDelay: 5 * time.Second}
+
+	for restart, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if policy.delay(restart) != expected {
+			t.Errorf("Expected delay %s for restart %d, got %s", expected, restart, policy.delay(restart))
+		}
+	}
+}
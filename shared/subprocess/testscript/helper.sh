@@ -0,0 +1,8 @@
+#!/bin/sh
+# Fake helper daemon: records its PID, runs for the given number of seconds and exits with the given code.
+echo $$ >> "$1"
+echo "helper $$ running"
+
+sleep "$2"
+echo "helper $$ exiting with $3" >&2
+exit "$3"
@@ -32,7 +32,8 @@ func RFC3493Dialer(context context.Context, network string, address string) (net
 
 	var errs []error
 	for _, a := range addrs {
-		c, err := net.DialTimeout(network, net.JoinHostPort(a, port), 10*time.Second)
+		dialer := net.Dialer{Timeout: 10 * time.Second}
+		c, err := dialer.DialContext(context, network, net.JoinHostPort(a, port))
 		if err != nil {
 			errs = append(errs, err)
 			continue
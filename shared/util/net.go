@@ -50,18 +50,24 @@ func DownloadFileHash(ctx context.Context, httpClient *http.Client, useragent st
 	// Handle the data
 	body := r.Body
 	if progress != nil {
+		tracker := &ioprogress.ProgressTracker{Length: r.ContentLength}
+		tracker.Handler = func(percent int64, speed int64) {
+			text := fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))
+			if filename != "" {
+				text = fmt.Sprintf("%s: %s", filename, text)
+			}
+
+			progress(ioprogress.ProgressData{
+				Text:             text,
+				TransferredBytes: tracker.Processed(),
+				TotalBytes:       r.ContentLength,
+				Speed:            speed,
+			})
+		}
+
 		body = &ioprogress.ProgressReader{
 			ReadCloser: r.Body,
-			Tracker: &ioprogress.ProgressTracker{
-				Length: r.ContentLength,
-				Handler: func(percent int64, speed int64) {
-					if filename != "" {
-						progress(ioprogress.ProgressData{Text: fmt.Sprintf("%s: %d%% (%s/s)", filename, percent, units.GetByteSizeString(speed, 2))})
-					} else {
-						progress(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
-					}
-				},
-			},
+			Tracker:    tracker,
 		}
 	}
 